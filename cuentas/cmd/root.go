@@ -34,6 +34,19 @@ type Config struct {
 	HaciendaRetryMax     int           `mapstructure:"hacienda_retry_max"`      // NEW
 	HaciendaRetryWaitMin time.Duration `mapstructure:"hacienda_retry_wait_min"` // NEW
 	HaciendaRetryWaitMax time.Duration `mapstructure:"hacienda_retry_wait_max"`
+
+	// SMTP (email delivery of accepted DTEs)
+	SMTPHost        string        `mapstructure:"smtp_host"`
+	SMTPPort        int           `mapstructure:"smtp_port"`
+	SMTPUsername    string        `mapstructure:"smtp_username"`
+	SMTPPassword    string        `mapstructure:"smtp_password"`
+	SMTPFrom        string        `mapstructure:"smtp_from"`
+	SMTPFromName    string        `mapstructure:"smtp_from_name"`
+	SMTPImplicitTLS bool          `mapstructure:"smtp_implicit_tls"`
+	SMTPTimeout     time.Duration `mapstructure:"smtp_timeout"`
+
+	// Operator token for company provisioning; empty disables it
+	AdminToken string `mapstructure:"admin_token"`
 }
 
 var GlobalConfig Config
@@ -99,6 +112,19 @@ func init() {
 	viper.SetDefault("firmador_retry_max", 3)
 	viper.SetDefault("firmador_retry_wait_min", 1*time.Second)
 	viper.SetDefault("firmador_retry_wait_max", 5*time.Second)
+
+	// SMTP defaults (MailHog on localhost)
+	viper.SetDefault("smtp_host", "localhost")
+	viper.SetDefault("smtp_port", 1025)
+	viper.SetDefault("smtp_username", "")
+	viper.SetDefault("smtp_password", "")
+	viper.SetDefault("smtp_from", "no-reply@localhost")
+	viper.SetDefault("smtp_from_name", "")
+	viper.SetDefault("smtp_implicit_tls", false)
+	viper.SetDefault("smtp_timeout", 30*time.Second)
+
+	// Company provisioning is disabled until an operator token is set
+	viper.SetDefault("admin_token", "")
 }
 
 // initConfig reads in config file and ENV variables.
@@ -15,8 +15,10 @@ import (
 	"cuentas/internal/hacienda"
 	"cuentas/internal/handlers"
 	"cuentas/internal/middleware"
+	"cuentas/internal/models"
 	"cuentas/internal/services"
 	"cuentas/internal/services/firmador"
+	"cuentas/internal/services/mailer"
 	"cuentas/internal/workers"
 
 	"github.com/gin-gonic/gin"
@@ -35,6 +37,13 @@ var (
 	dteService         *dte.DTEService
 	contingencyService *services.ContingencyService
 	contingencyWorker  *workers.ContingencyWorker
+	mailerClient       *mailer.Client
+	emailService       *services.EmailDeliveryService
+	emailWorker        *workers.EmailWorker
+	webhookService     *services.WebhookService
+	webhookWorker      *workers.WebhookWorker
+	ledgerService      *services.LedgerService
+	ledgerWorker       *workers.LedgerWorker
 )
 
 // ServeCmd represents the serve command
@@ -82,6 +91,11 @@ var ServeCmd = &cobra.Command{
 			log.Fatalf("Failed to initialize Hacienda service: %v", err)
 		}
 
+		// Initialize Webhooks (service and worker); DTE and contingency publish to it
+		if err := initializeWebhooks(); err != nil {
+			log.Fatalf("Failed to initialize Webhooks: %v", err)
+		}
+
 		// Initialize Contingency service
 		if err := initializeContingencyService(); err != nil {
 			log.Fatalf("Failed to initialize Contingency service: %v", err)
@@ -102,6 +116,19 @@ var ServeCmd = &cobra.Command{
 			log.Fatalf("Failed to initialize Contingency worker: %v", err)
 		}
 
+		// Initialize Email delivery (mailer, service and worker)
+		if err := initializeEmailDelivery(); err != nil {
+			log.Fatalf("Failed to initialize Email delivery: %v", err)
+		}
+		emailWorker.Start(context.Background())
+		webhookWorker.Start(context.Background())
+
+		// Initialize the general ledger (service and posting worker)
+		if err := initializeLedger(); err != nil {
+			log.Fatalf("Failed to initialize Ledger: %v", err)
+		}
+		ledgerWorker.Start(context.Background())
+
 		fmt.Printf("Server running on port: %s\n", GlobalConfig.Port)
 		startServer()
 	},
@@ -110,7 +137,7 @@ var ServeCmd = &cobra.Command{
 func initializeContingencyService() error {
 	fmt.Println("Initializing Contingency service...")
 
-	contingencyService = services.NewContingencyService(database.DB)
+	contingencyService = services.NewContingencyService(database.DB, webhookService)
 
 	fmt.Println("Contingency service initialized")
 	return nil
@@ -127,6 +154,7 @@ func initializeContingencyWorker() error {
 		haciendaClient,
 		haciendaService,
 		vaultService,
+		webhookService,
 		nil, // Use default config
 	)
 
@@ -134,6 +162,37 @@ func initializeContingencyWorker() error {
 	return nil
 }
 
+func initializeEmailDelivery() error {
+	fmt.Println("Initializing Email delivery...")
+
+	mailerClient = mailer.NewClientFromViper()
+	emailService = services.NewEmailDeliveryService(database.DB, mailerClient)
+	emailWorker = workers.NewEmailWorker(emailService, nil) // Use default config
+
+	fmt.Printf("Email delivery initialized (SMTP: %s)\n", mailerClient.GetAddress())
+	return nil
+}
+
+func initializeWebhooks() error {
+	fmt.Println("Initializing Webhooks...")
+
+	webhookService = services.NewWebhookService(database.DB)
+	webhookWorker = workers.NewWebhookWorker(webhookService, nil) // Use default config
+
+	fmt.Println("Webhooks initialized")
+	return nil
+}
+
+func initializeLedger() error {
+	fmt.Println("Initializing Ledger...")
+
+	ledgerService = services.NewLedgerService(database.DB)
+	ledgerWorker = workers.NewLedgerWorker(ledgerService, nil) // Use default config
+
+	fmt.Println("Ledger initialized")
+	return nil
+}
+
 func initializeDTEValidator() error {
 	fmt.Println("🔧 Initializing DTE schema validator...")
 
@@ -190,6 +249,7 @@ func initializeDTEService() error {
 		haciendaClient,
 		haciendaService,
 		contingencyService,
+		webhookService,
 	)
 
 	fmt.Println("DTE service initialized")
@@ -316,96 +376,254 @@ func startServer() {
 		c.Next()
 	})
 
-	r.Use(middleware.CompanyIDMiddleware())
+	apiKeyService := services.NewAPIKeyService(database.DB)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	userService := services.NewUserService(database.DB)
+	userHandler := handlers.NewUserHandler(userService)
 
-	// API v1 routes
-	v1 := r.Group("/v1")
+	// Unauthenticated and operator (admin token) routes
+	public := r.Group("/v1")
 	{
-		v1.GET("/health", handlers.HealthHandler)
-		v1.POST("/companies", handlers.CreateCompanyHandler)
-		v1.GET("/companies", handlers.ListCompaniesHandler)
-		v1.GET("/companies/:id", handlers.GetCompanyHandler)
-		v1.POST("/companies/:id/authenticate", handlers.AuthenticateCompanyHandler)
-
-		v1.POST("/clients", handlers.CreateClientHandler)
-		v1.GET("/clients/:id", handlers.GetClientHandler)
-		v1.GET("/clients", handlers.ListClientsHandler)
-		v1.PUT("/clients/:id", handlers.UpdateClientHandler)
-		v1.DELETE("/clients/:id", handlers.DeleteClientHandler)
+		public.GET("/health", handlers.HealthHandler)
+		public.POST("/auth/login", userHandler.Login)
+
+		provisioning := public.Group("", middleware.AdminToken(GlobalConfig.AdminToken))
+		provisioning.POST("/companies", handlers.CreateCompanyHandler)
+		provisioning.GET("/companies", handlers.ListCompaniesHandler)
+		provisioning.POST("/admin/companies/:id/api-keys", apiKeyHandler.IssueCompanyAPIKey)
+		provisioning.POST("/admin/companies/:id/users", userHandler.CreateCompanyAdmin)
+	}
+
+	// Permission checks, by role (see models.RoleHasPermission)
+	salesRead := middleware.RequirePermission(models.PermSalesRead)
+	salesWrite := middleware.RequirePermission(models.PermSalesWrite)
+	salesAdjust := middleware.RequirePermission(models.PermSalesAdjust)
+	purchasesRead := middleware.RequirePermission(models.PermPurchasesRead)
+	purchasesWrite := middleware.RequirePermission(models.PermPurchasesWrite)
+	inventoryWrite := middleware.RequirePermission(models.PermInventoryWrite)
+	invalidate := middleware.RequirePermission(models.PermInvalidate)
+	reports := middleware.RequirePermission(models.PermReports)
+	manageContingency := middleware.RequirePermission(models.PermContingency)
+	bankReconcile := middleware.RequirePermission(models.PermBankReconcile)
+	settings := middleware.RequirePermission(models.PermSettings)
+
+	// Idempotency-Key support on create and finalize endpoints
+	idempotencyService := services.NewIdempotencyService(database.DB, database.RedisClient)
+	idempotent := middleware.Idempotency(idempotencyService)
+
+	// API v1 routes: company_id, user_id and role come from the session or API key
+	v1 := r.Group("/v1", middleware.Authenticate(apiKeyService, userService))
+	{
+		v1.GET("/auth/me", userHandler.Me)
+		v1.POST("/auth/logout", userHandler.Logout)
+		v1.PUT("/auth/password", userHandler.ChangePassword)
+
+		v1.GET("/companies/:id", middleware.RequireOwnCompany("id"), handlers.GetCompanyHandler)
+		v1.POST("/companies/:id/authenticate", middleware.RequireOwnCompany("id"), settings, handlers.AuthenticateCompanyHandler)
+
+		// User management
+		users := v1.Group("/users", settings)
+		{
+			users.POST("", userHandler.CreateUser)
+			users.GET("", userHandler.ListUsers)
+			users.GET("/:id", userHandler.GetUser)
+			users.PATCH("/:id", userHandler.UpdateUser)
+			users.POST("/:id/password", userHandler.ResetUserPassword)
+		}
+
+		// API key management
+		apiKeys := v1.Group("/api-keys", settings, middleware.RequireScope(models.APIKeyScopeAdmin))
+		{
+			apiKeys.POST("", apiKeyHandler.CreateAPIKey)
+			apiKeys.GET("", apiKeyHandler.ListAPIKeys)
+			apiKeys.GET("/:id", apiKeyHandler.GetAPIKey)
+			apiKeys.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+		}
+
+		v1.POST("/clients", salesWrite, idempotent, handlers.CreateClientHandler)
+		v1.GET("/clients/:id", salesRead, handlers.GetClientHandler)
+		v1.GET("/clients", salesRead, handlers.ListClientsHandler)
+		v1.PUT("/clients/:id", salesWrite, handlers.UpdateClientHandler)
+		v1.DELETE("/clients/:id", salesWrite, handlers.DeleteClientHandler)
+
+		// Client account statements (estado de cuenta)
+		statementHandler := handlers.NewStatementHandler(services.NewStatementService(database.DB, emailService, mailerClient))
+		v1.GET("/clients/:id/statement", salesRead, statementHandler.GetStatement)
+		v1.POST("/clients/:id/statement/email", salesWrite, statementHandler.EmailStatement)
 
 		// Inventory item routes
 		inventorySvc := services.NewInventoryService(database.DB)
 		inventoryHandler := handlers.NewInventoryHandler(inventorySvc)
 
-		v1.POST("/inventory/items", inventoryHandler.CreateInventoryItemHandler)
-		v1.GET("/inventory/items/:id", inventoryHandler.GetInventoryItemHandler)
-		v1.GET("/inventory/items", inventoryHandler.ListInventoryItemsHandler)
-		v1.PUT("/inventory/items/:id", inventoryHandler.UpdateInventoryItemHandler)
-		v1.DELETE("/inventory/items/:id", inventoryHandler.DeleteInventoryItemHandler)
+		v1.POST("/inventory/items", inventoryWrite, idempotent, inventoryHandler.CreateInventoryItemHandler)
+		v1.GET("/inventory/items/:id", salesRead, inventoryHandler.GetInventoryItemHandler)
+		v1.GET("/inventory/items", salesRead, inventoryHandler.ListInventoryItemsHandler)
+		v1.PUT("/inventory/items/:id", inventoryWrite, inventoryHandler.UpdateInventoryItemHandler)
+		v1.DELETE("/inventory/items/:id", inventoryWrite, inventoryHandler.DeleteInventoryItemHandler)
 
 		// Inventory tax routes
-		v1.GET("/inventory/items/:id/taxes", inventoryHandler.GetItemTaxesHandler)
-		v1.POST("/inventory/items/:id/taxes", inventoryHandler.AddItemTaxHandler)
-		v1.DELETE("/inventory/items/:id/taxes/:code", inventoryHandler.RemoveItemTaxHandler)
+		v1.GET("/inventory/items/:id/taxes", salesRead, inventoryHandler.GetItemTaxesHandler)
+		v1.POST("/inventory/items/:id/taxes", inventoryWrite, inventoryHandler.AddItemTaxHandler)
+		v1.DELETE("/inventory/items/:id/taxes/:code", inventoryWrite, inventoryHandler.RemoveItemTaxHandler)
 
 		// Inventory cost tracking (CQRS)
-		v1.POST("/inventory/items/:id/purchase", inventoryHandler.RecordPurchaseHandler)
-		v1.POST("/inventory/items/:id/adjustment", inventoryHandler.RecordAdjustmentHandler)
-		v1.GET("/inventory/items/:id/state", inventoryHandler.GetInventoryStateHandler)
-		v1.GET("/inventory/states", inventoryHandler.ListInventoryStatesHandler)
-		v1.GET("/inventory/items/:id/cost-history", inventoryHandler.GetCostHistoryHandler)
-		v1.GET("/inventory/items/:id/legal-register", inventoryHandler.GetLegalInventoryRegisterHandler)
-
-		v1.GET("/inventory/events", inventoryHandler.GetAllEventsHandler)
-		v1.GET("/inventory/valuation", inventoryHandler.GetInventoryValuationHandler)
+		v1.POST("/inventory/items/:id/purchase", inventoryWrite, idempotent, inventoryHandler.RecordPurchaseHandler)
+		v1.POST("/inventory/items/:id/adjustment", inventoryWrite, idempotent, inventoryHandler.RecordAdjustmentHandler)
+		v1.POST("/inventory/items/:id/transfers", inventoryWrite, idempotent, inventoryHandler.RecordTransferHandler)
+		v1.POST("/inventory/locations/allocate", inventoryWrite, idempotent, inventoryHandler.AllocateInventoryLocationsHandler)
+		v1.GET("/inventory/items/:id/state", reports, inventoryHandler.GetInventoryStateHandler)
+		v1.GET("/inventory/states", reports, inventoryHandler.ListInventoryStatesHandler)
+		v1.GET("/inventory/items/:id/cost-history", reports, inventoryHandler.GetCostHistoryHandler)
+		v1.GET("/inventory/items/:id/legal-register", reports, inventoryHandler.GetLegalInventoryRegisterHandler)
+
+		v1.GET("/inventory/events", reports, inventoryHandler.GetAllEventsHandler)
+		v1.GET("/inventory/valuation", reports, inventoryHandler.GetInventoryValuationHandler)
 
 		// Invoice routes
 		invoiceService := services.NewInvoiceService(inventorySvc)
 
 		invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
-		v1.POST("/invoices", invoiceHandler.CreateInvoice)
-		v1.GET("/invoices", invoiceHandler.ListInvoices)
-		v1.GET("/invoices/:id", invoiceHandler.GetInvoice)
-		v1.DELETE("/invoices/:id", invoiceHandler.DeleteInvoice)
-		v1.POST("/invoices/:id/finalize", invoiceHandler.FinalizeInvoice)
+		v1.POST("/invoices", salesWrite, idempotent, invoiceHandler.CreateInvoice)
+		v1.GET("/invoices", salesRead, invoiceHandler.ListInvoices)
+		v1.GET("/invoices/:id", salesRead, invoiceHandler.GetInvoice)
+		v1.DELETE("/invoices/:id", salesWrite, invoiceHandler.DeleteInvoice)
+		v1.POST("/invoices/:id/preview-dte", salesWrite, invoiceHandler.PreviewInvoiceDTE)
+		v1.POST("/invoices/:id/finalize", salesWrite, idempotent, invoiceHandler.FinalizeInvoice)
+
+		// Accounts receivable: payments after finalization and aging
+		paymentHandler := handlers.NewPaymentHandler(services.NewPaymentService(database.DB))
+		v1.POST("/invoices/:id/payments", salesWrite, idempotent, paymentHandler.RecordPayment)
+		v1.GET("/invoices/:id/payments", salesRead, paymentHandler.ListInvoicePayments)
+		v1.GET("/payments", salesRead, paymentHandler.ListPayments)
+		v1.GET("/payments/:id", salesRead, paymentHandler.GetPayment)
+		v1.POST("/payments/:id/void", salesAdjust, paymentHandler.VoidPayment)
+		v1.GET("/reports/ar-aging", reports, paymentHandler.GetAgingReport)
+
+		// IVA books (libros de IVA) per fiscal month
+		taxBookHandler := handlers.NewTaxBookHandler(services.NewTaxBookService(database.DB))
+		v1.GET("/reports/consumer-sales-book", reports, taxBookHandler.GetConsumerSalesBook)
+		v1.GET("/reports/contributor-sales-book", reports, taxBookHandler.GetContributorSalesBook)
+		v1.GET("/reports/purchase-book", reports, taxBookHandler.GetPurchaseBook)
+		v1.GET("/reports/f07", reports, taxBookHandler.GetIVADeclaration)
+		v1.GET("/reports/f07/anexos/:anexo", reports, taxBookHandler.GetF07Anexo)
+
+		// General ledger: chart of accounts, posting rules, journal, financial
+		// statements and fiscal year closings
+		ledgerHandler := handlers.NewLedgerHandler(ledgerService)
+		ledger := v1.Group("/ledger")
+		{
+			ledger.GET("/accounts", reports, ledgerHandler.ListAccounts)
+			ledger.POST("/accounts", settings, ledgerHandler.CreateAccount)
+			ledger.POST("/accounts/default-chart", settings, ledgerHandler.SeedDefaultChart)
+			ledger.GET("/accounts/:id", reports, ledgerHandler.GetAccount)
+			ledger.PATCH("/accounts/:id", settings, ledgerHandler.UpdateAccount)
+			ledger.GET("/posting-rules", reports, ledgerHandler.ListPostingRules)
+			ledger.PUT("/posting-rules", settings, ledgerHandler.SetPostingRule)
+			ledger.DELETE("/posting-rules/:id", settings, ledgerHandler.DeletePostingRule)
+			ledger.GET("/settings", reports, ledgerHandler.GetSettings)
+			ledger.PUT("/settings", settings, ledgerHandler.UpdateSettings)
+			ledger.POST("/post", settings, ledgerHandler.PostPending)
+			ledger.GET("/journal", reports, ledgerHandler.ListJournal)
+			ledger.GET("/journal/:id", reports, ledgerHandler.GetJournalEntry)
+			ledger.GET("/reports/trial-balance", reports, ledgerHandler.GetTrialBalance)
+			ledger.GET("/reports/income-statement", reports, ledgerHandler.GetIncomeStatement)
+			ledger.GET("/reports/balance-sheet", reports, ledgerHandler.GetBalanceSheet)
+			ledger.GET("/closings", reports, ledgerHandler.ListClosings)
+			ledger.POST("/closings", settings, ledgerHandler.CloseFiscalYear)
+		}
+
+		// Bank accounts, statement import and reconciliation with payments
+		bankHandler := handlers.NewBankHandler(services.NewBankService(database.DB))
+		bank := v1.Group("/bank")
+		{
+			bank.GET("/accounts", reports, bankHandler.ListAccounts)
+			bank.POST("/accounts", settings, bankHandler.CreateAccount)
+			bank.GET("/accounts/:id", reports, bankHandler.GetAccount)
+			bank.PATCH("/accounts/:id", settings, bankHandler.UpdateAccount)
+			bank.POST("/accounts/:id/statements", bankReconcile, bankHandler.ImportStatement)
+			bank.GET("/accounts/:id/statements", reports, bankHandler.ListStatements)
+			bank.DELETE("/statements/:id", bankReconcile, bankHandler.DeleteStatement)
+			bank.GET("/accounts/:id/lines", reports, bankHandler.ListLines)
+			bank.POST("/accounts/:id/auto-match", bankReconcile, bankHandler.AutoMatch)
+			bank.GET("/accounts/:id/reconciliation", reports, bankHandler.GetReconciliation)
+			bank.GET("/lines/:id/candidates", bankReconcile, bankHandler.ListCandidates)
+			bank.POST("/lines/:id/match", bankReconcile, bankHandler.MatchLine)
+			bank.DELETE("/lines/:id/match", bankReconcile, bankHandler.UnmatchLine)
+			bank.POST("/lines/:id/ignore", bankReconcile, bankHandler.IgnoreLine)
+		}
 
 		actividadHandler := handlers.NewActividadEconomicaHandler()
-		v1.GET("/actividades-economicas/categories", actividadHandler.GetCategories)
-		v1.GET("/actividades-economicas/categories/:code", actividadHandler.GetCategoryByCode)
-		v1.GET("/actividades-economicas/categories/:code/activities", actividadHandler.GetActivitiesByCategory)
-		v1.GET("/actividades-economicas/search", actividadHandler.SearchActivities)
-		v1.GET("/actividades-economicas/:code", actividadHandler.GetActivityDetails)
+		actividades := v1.Group("/actividades-economicas", salesRead)
+		{
+			actividades.GET("/categories", actividadHandler.GetCategories)
+			actividades.GET("/categories/:code", actividadHandler.GetCategoryByCode)
+			actividades.GET("/categories/:code/activities", actividadHandler.GetActivitiesByCategory)
+			actividades.GET("/search", actividadHandler.SearchActivities)
+			actividades.GET("/:code", actividadHandler.GetActivityDetails)
+		}
 
 		establishmentHandler := handlers.NewEstablishmentHandler()
 
-		v1.POST("/establishments", establishmentHandler.CreateEstablishment)
-		v1.GET("/establishments", establishmentHandler.ListEstablishments)
-		v1.GET("/establishments/:id", establishmentHandler.GetEstablishment)
-		v1.PATCH("/establishments/:id", establishmentHandler.UpdateEstablishment)
-		v1.DELETE("/establishments/:id", establishmentHandler.DeactivateEstablishment)
+		v1.POST("/establishments", settings, establishmentHandler.CreateEstablishment)
+		v1.GET("/establishments", salesRead, establishmentHandler.ListEstablishments)
+		v1.GET("/establishments/:id", salesRead, establishmentHandler.GetEstablishment)
+		v1.PATCH("/establishments/:id", settings, establishmentHandler.UpdateEstablishment)
+		v1.DELETE("/establishments/:id", settings, establishmentHandler.DeactivateEstablishment)
 
 		// Point of Sale routes
-		v1.POST("/establishments/:id/pos", establishmentHandler.CreatePointOfSale)
-		v1.GET("/establishments/:id/pos", establishmentHandler.ListPointsOfSale)
-		v1.GET("/pos/:id", establishmentHandler.GetPointOfSale)
-		v1.PATCH("/pos/:id", establishmentHandler.UpdatePointOfSale)
-		v1.PATCH("/pos/:id/location", establishmentHandler.UpdatePOSLocation)
-		v1.DELETE("/pos/:id", establishmentHandler.DeactivatePointOfSale)
+		v1.POST("/establishments/:id/pos", settings, establishmentHandler.CreatePointOfSale)
+		v1.GET("/establishments/:id/pos", salesRead, establishmentHandler.ListPointsOfSale)
+		v1.GET("/pos/:id", salesRead, establishmentHandler.GetPointOfSale)
+		v1.PATCH("/pos/:id", settings, establishmentHandler.UpdatePointOfSale)
+		v1.PATCH("/pos/:id/location", settings, establishmentHandler.UpdatePOSLocation)
+		v1.DELETE("/pos/:id", settings, establishmentHandler.DeactivatePointOfSale)
 
 		// commitlog
-		v1.GET("/dte/commit-log", handlers.ListDTECommitLogHandler)
-		v1.GET("/dte/commit-log/:codigo_generacion", handlers.GetDTECommitLogEntryHandler)
+		v1.GET("/dte/commit-log", reports, handlers.ListDTECommitLogHandler)
+		v1.GET("/dte/commit-log/:codigo_generacion", salesRead, handlers.GetDTECommitLogEntryHandler)
+		v1.GET("/dte/:codigo_generacion/pdf", salesRead, handlers.GetDTEPDFHandler)
+
+		// Email delivery of accepted DTEs
+		emailHandler := handlers.NewEmailDeliveryHandler(emailService)
+		v1.GET("/email/settings", settings, emailHandler.GetEmailSettings)
+		v1.PUT("/email/settings", settings, emailHandler.UpdateEmailSettings)
+		v1.GET("/dte/email-deliveries", reports, emailHandler.ListEmailDeliveries)
+		v1.GET("/dte/:codigo_generacion/emails", salesRead, emailHandler.GetDTEEmailDeliveries)
+		v1.POST("/dte/:codigo_generacion/emails/resend", salesWrite, emailHandler.ResendDTEEmail)
+
+		// Webhook subscriptions and delivery log
+		webhookHandler := handlers.NewWebhookHandler(webhookService)
+		webhooks := v1.Group("/webhooks", settings, middleware.RequireScope(models.APIKeyScopeAdmin))
+		{
+			webhooks.POST("", webhookHandler.CreateWebhook)
+			webhooks.GET("", webhookHandler.ListWebhooks)
+			webhooks.GET("/:id", webhookHandler.GetWebhook)
+			webhooks.PATCH("/:id", webhookHandler.UpdateWebhook)
+			webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+			webhooks.POST("/:id/rotate-secret", webhookHandler.RotateWebhookSecret)
+			webhooks.GET("/:id/deliveries", webhookHandler.ListWebhookDeliveries)
+			webhooks.POST("/:id/deliveries/:delivery_id/redeliver", webhookHandler.RedeliverWebhook)
+		}
+
+		// DTE invalidation (evento de invalidación)
+		invalidationService := services.NewInvalidationService(inventorySvc)
+		invalidationHandler := handlers.NewInvalidationHandler(invalidationService)
+		v1.POST("/dte/:codigo_generacion/invalidate", invalidate, idempotent, invalidationHandler.InvalidateDTE)
+		v1.GET("/dte/invalidations", reports, invalidationHandler.ListInvalidations)
+		v1.GET("/dte/invalidations/:id", reports, invalidationHandler.GetInvalidation)
+		v1.POST("/dte/invalidations/:id/reverse-effects", invalidate, invalidationHandler.ReverseEffects)
 
 		remisionHandler := handlers.NewRemisionHandler(invoiceService)
 		remisiones := v1.Group("/remisiones")
 		{
-			remisiones.POST("", remisionHandler.CreateRemision)
-			remisiones.GET("", remisionHandler.ListRemisiones)
-			remisiones.GET("/:id", remisionHandler.GetRemision)
-			remisiones.DELETE("/:id", remisionHandler.DeleteRemision)
-			remisiones.POST("/:id/finalize", remisionHandler.FinalizeRemision)
-			remisiones.POST("/:id/link-invoice", remisionHandler.LinkRemisionToInvoice)
-			remisiones.GET("/:id/invoices", remisionHandler.GetRemisionLinkedInvoices)
+			remisiones.POST("", salesWrite, idempotent, remisionHandler.CreateRemision)
+			remisiones.GET("", salesRead, remisionHandler.ListRemisiones)
+			remisiones.GET("/:id", salesRead, remisionHandler.GetRemision)
+			remisiones.DELETE("/:id", salesWrite, remisionHandler.DeleteRemision)
+			remisiones.POST("/:id/preview-dte", salesWrite, remisionHandler.PreviewRemisionDTE)
+			remisiones.POST("/:id/finalize", salesWrite, idempotent, remisionHandler.FinalizeRemision)
+			remisiones.POST("/:id/link-invoice", salesWrite, remisionHandler.LinkRemisionToInvoice)
+			remisiones.GET("/:id/invoices", salesRead, remisionHandler.GetRemisionLinkedInvoices)
 		}
 
 		// notas
@@ -416,22 +634,91 @@ func startServer() {
 		notas := v1.Group("/notas")
 		{
 			// Nota de Débito
-			notas.POST("/debito", notasHandler.CreateNotaDebito)
-			notas.GET("/debito/:id", notasHandler.GetNotaDebito)
-			notas.POST("/debito/:id/finalize", notasHandler.FinalizeNotaDebito)
+			notas.POST("/debito", salesAdjust, idempotent, notasHandler.CreateNotaDebito)
+			notas.GET("/debito/:id", salesRead, notasHandler.GetNotaDebito)
+			notas.POST("/debito/:id/preview-dte", salesAdjust, notasHandler.PreviewNotaDebitoDTE)
+			notas.POST("/debito/:id/finalize", salesAdjust, idempotent, notasHandler.FinalizeNotaDebito)
 
 			// Nota de Crédito
-			notas.POST("/credito", notasHandler.CreateNotaCredito)
-			notas.GET("/credito/:id", notasHandler.GetNotaCredito)
-			notas.POST("/credito/:id/finalize", notasHandler.FinalizeNotaCredito)
+			notas.POST("/credito", salesAdjust, idempotent, notasHandler.CreateNotaCredito)
+			notas.GET("/credito/:id", salesRead, notasHandler.GetNotaCredito)
+			notas.POST("/credito/:id/preview-dte", salesAdjust, notasHandler.PreviewNotaCreditoDTE)
+			notas.POST("/credito/:id/finalize", salesAdjust, idempotent, notasHandler.FinalizeNotaCredito)
 		}
 
+		// Supplier registry
+		supplierHandler := handlers.NewSupplierHandler(services.NewSupplierService(database.DB))
+		v1.POST("/suppliers", purchasesWrite, idempotent, supplierHandler.CreateSupplier)
+		v1.GET("/suppliers", purchasesRead, supplierHandler.ListSuppliers)
+		v1.GET("/suppliers/:id", purchasesRead, supplierHandler.GetSupplier)
+		v1.PUT("/suppliers/:id", purchasesWrite, supplierHandler.UpdateSupplier)
+		v1.DELETE("/suppliers/:id", purchasesWrite, supplierHandler.DeactivateSupplier)
+
 		purchaseService := services.NewPurchaseService()
 		purchaseHandler := handlers.NewPurchaseHandler(purchaseService)
-		v1.POST("/purchases/fse", purchaseHandler.CreateFSE)
-		v1.GET("/purchases", purchaseHandler.ListPurchases)
-		v1.GET("/purchases/:id", purchaseHandler.GetPurchase)
-		v1.POST("/purchases/:id/finalize", purchaseHandler.FinalizePurchase)
+		v1.POST("/purchases/fse", purchasesWrite, idempotent, purchaseHandler.CreateFSE)
+		v1.POST("/purchases/received-dte", purchasesWrite, idempotent, purchaseHandler.CreateFromReceivedDTE)
+		v1.GET("/purchases", purchasesRead, purchaseHandler.ListPurchases)
+
+		// Accounts payable: supplier payments, purchases due and aging
+		apHandler := handlers.NewAccountsPayableHandler(services.NewAccountsPayableService(database.DB))
+		v1.GET("/purchases/due", purchasesRead, apHandler.ListPurchasesDue)
+		v1.POST("/purchases/:id/payments", purchasesWrite, idempotent, apHandler.RecordSupplierPayment)
+		v1.GET("/purchases/:id/payments", purchasesRead, apHandler.ListPurchasePayments)
+		v1.GET("/supplier-payments", purchasesRead, apHandler.ListSupplierPayments)
+		v1.GET("/supplier-payments/:id", purchasesRead, apHandler.GetSupplierPayment)
+		v1.POST("/supplier-payments/:id/void", purchasesWrite, apHandler.VoidSupplierPayment)
+		v1.GET("/reports/ap-aging", reports, apHandler.GetAgingReport)
+
+		v1.GET("/purchases/:id", purchasesRead, purchaseHandler.GetPurchase)
+		v1.POST("/purchases/:id/preview-dte", purchasesWrite, purchaseHandler.PreviewPurchaseDTE)
+		v1.POST("/purchases/:id/finalize", purchasesWrite, idempotent, purchaseHandler.FinalizePurchase)
+
+		// retentions (DTE 07)
+		retentionService := services.NewRetentionService()
+		retentionHandler := handlers.NewRetentionHandler(retentionService)
+		retentions := v1.Group("/retentions")
+		{
+			retentions.POST("/validate", purchasesRead, retentionHandler.ValidateEligibility)
+			retentions.POST("", purchasesWrite, idempotent, retentionHandler.CreateRetention)
+			retentions.GET("", purchasesRead, retentionHandler.ListRetentions)
+			retentions.GET("/:id", purchasesRead, retentionHandler.GetRetention)
+			retentions.POST("/:id/finalize", purchasesWrite, idempotent, retentionHandler.FinalizeRetention)
+		}
+
+		// liquidaciones (DTE 08)
+		liquidacionService := services.NewLiquidacionService()
+		liquidacionHandler := handlers.NewLiquidacionHandler(liquidacionService)
+		liquidaciones := v1.Group("/liquidaciones")
+		{
+			liquidaciones.POST("", purchasesWrite, idempotent, liquidacionHandler.CreateLiquidacion)
+			liquidaciones.GET("", purchasesRead, liquidacionHandler.ListLiquidaciones)
+			liquidaciones.GET("/:id", purchasesRead, liquidacionHandler.GetLiquidacion)
+			liquidaciones.POST("/:id/finalize", purchasesWrite, idempotent, liquidacionHandler.FinalizeLiquidacion)
+		}
+
+		// documento contable de liquidación (DTE 09)
+		dclService := services.NewDCLService()
+		dclHandler := handlers.NewDCLHandler(dclService)
+		dcl := v1.Group("/dcl")
+		{
+			dcl.POST("", purchasesWrite, idempotent, dclHandler.CreateDCL)
+			dcl.GET("", purchasesRead, dclHandler.ListDCLs)
+			dcl.GET("/:id", purchasesRead, dclHandler.GetDCL)
+			dcl.POST("/:id/finalize", purchasesWrite, idempotent, dclHandler.FinalizeDCL)
+		}
+
+		// donations (DTE 15)
+		donationService := services.NewDonationService()
+		donationHandler := handlers.NewDonationHandler(donationService)
+		donations := v1.Group("/donations")
+		{
+			donations.POST("", purchasesWrite, idempotent, donationHandler.CreateDonation)
+			donations.GET("", purchasesRead, donationHandler.ListDonations)
+			donations.GET("/reports/annual", reports, donationHandler.GetDonorAnnualReport)
+			donations.GET("/:id", purchasesRead, donationHandler.GetDonation)
+			donations.POST("/:id/finalize", purchasesWrite, idempotent, donationHandler.FinalizeDonation)
+		}
 
 		reconciliationService := services.NewDTEReconciliationService(
 			database.DB,
@@ -440,19 +727,19 @@ func startServer() {
 		)
 		reconciliationHandler := handlers.NewDTEReconciliationHandler(reconciliationService)
 
-		v1.GET("/dte/reconciliation", reconciliationHandler.ReconcileDTEs)
-		v1.GET("/dte/reconciliation/:codigo_generacion", reconciliationHandler.ReconcileSingleDTE)
+		v1.GET("/dte/reconciliation", reports, reconciliationHandler.ReconcileDTEs)
+		v1.GET("/dte/reconciliation/:codigo_generacion", reports, reconciliationHandler.ReconcileSingleDTE)
 
 		contingencyHandler := handlers.NewContingencyHandler(contingencyService)
 		contingency := v1.Group("/contingency")
 		{
-			contingency.GET("/periods", contingencyHandler.ListPeriods)
-			contingency.GET("/periods/:id", contingencyHandler.GetPeriod)
-			contingency.GET("/periods/:id/invoices", contingencyHandler.GetPeriodInvoices)
-			contingency.POST("/periods/:id/close", contingencyHandler.ClosePeriod)
-			contingency.GET("/lotes", contingencyHandler.ListLotes)
-			contingency.GET("/lotes/:id", contingencyHandler.GetLote)
-			contingency.GET("/events", contingencyHandler.ListEvents)
+			contingency.GET("/periods", reports, contingencyHandler.ListPeriods)
+			contingency.GET("/periods/:id", reports, contingencyHandler.GetPeriod)
+			contingency.GET("/periods/:id/invoices", reports, contingencyHandler.GetPeriodInvoices)
+			contingency.POST("/periods/:id/close", manageContingency, contingencyHandler.ClosePeriod)
+			contingency.GET("/lotes", reports, contingencyHandler.ListLotes)
+			contingency.GET("/lotes/:id", reports, contingencyHandler.GetLote)
+			contingency.GET("/events", reports, contingencyHandler.ListEvents)
 		}
 
 	}
@@ -0,0 +1,153 @@
+package dte
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cuentas/internal/models"
+)
+
+// ============================================
+// BUILD DOCUMENTO CONTABLE DE LIQUIDACIÓN (TYPE 09)
+// ============================================
+
+// BuildDocumentoContableLiquidacion builds a Type 09 DTE from a DCL record
+func (b *Builder) BuildDocumentoContableLiquidacion(ctx context.Context, dcl *models.DCL) (*DocumentoContableLiquidacion, error) {
+	company, err := b.loadCompany(ctx, dcl.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("load company: %w", err)
+	}
+
+	establishment, err := b.loadEstablishmentAndPOS(ctx, dcl.EstablishmentID, dcl.PointOfSaleID)
+	if err != nil {
+		return nil, fmt.Errorf("load establishment: %w", err)
+	}
+
+	client, err := b.loadClient(ctx, dcl.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("load client: %w", err)
+	}
+
+	receptor, err := b.buildDCLReceptor(client, dcl.ReceptorTipoEstablecimiento)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation("America/El_Salvador")
+	if err != nil {
+		loc = time.FixedZone("CST", -6*60*60)
+	}
+	now := time.Now().In(loc)
+
+	return &DocumentoContableLiquidacion{
+		Identificacion: LiquidacionIdentificacion{
+			Version:          1,
+			Ambiente:         dcl.Ambiente,
+			TipoDte:          TipoDteDocContableLiquidacion,
+			NumeroControl:    strings.ToUpper(dcl.NumeroControl),
+			CodigoGeneracion: strings.ToUpper(dcl.CodigoGeneracion),
+			TipoModelo:       1, // Previo
+			TipoOperacion:    1, // Normal - Type 09 schema does not allow contingency
+			FecEmi:           now.Format("2006-01-02"),
+			HorEmi:           now.Format("15:04:05"),
+			TipoMoneda:       "USD",
+		},
+		Emisor:          b.buildDCLEmisor(company, establishment),
+		Receptor:        *receptor,
+		CuerpoDocumento: b.buildDCLCuerpo(dcl),
+		Extension: DCLExtension{
+			NombEntrega: dcl.NombEntrega,
+			DocuEntrega: dcl.DocuEntrega,
+			CodEmpleado: dcl.CodEmpleado,
+		},
+		Apendice: nil,
+	}, nil
+}
+
+func (b *Builder) buildDCLEmisor(company *CompanyData, establishment *EstablishmentData) DCLEmisor {
+	var nombreComercial *string
+	if company.NombreComercial != "" {
+		nombreComercial = &company.NombreComercial
+	}
+
+	return DCLEmisor{
+		NIT:                 company.NIT,
+		NRC:                 fmt.Sprintf("%d", company.NCR),
+		Nombre:              company.Name,
+		CodActividad:        company.CodActividad,
+		DescActividad:       company.DescActividad,
+		NombreComercial:     nombreComercial,
+		TipoEstablecimiento: establishment.TipoEstablecimiento,
+		Telefono:            dclTelefono(establishment.Telefono),
+		Correo:              company.Email,
+		Direccion:           b.buildEmisorDireccion(establishment),
+		CodigoMH:            nil,
+		Codigo:              &establishment.CodEstablecimiento,
+		PuntoVentaMH:        nil,
+		PuntoVentaContri:    &establishment.CodPuntoVenta,
+	}
+}
+
+// buildDCLReceptor builds the affiliate section
+func (b *Builder) buildDCLReceptor(client *ClientData, tipoEstablecimiento string) (*DCLReceptor, error) {
+	if client.NIT == nil || client.NCR == nil {
+		return nil, fmt.Errorf("client %s must have NIT and NRC to receive a DCL", client.ID)
+	}
+
+	var telefono *string
+	if client.Telefono != nil {
+		if t := dclTelefono(*client.Telefono); len(t) == 8 {
+			telefono = &t
+		}
+	}
+
+	return &DCLReceptor{
+		NIT:                 fmt.Sprintf("%014d", *client.NIT),
+		NRC:                 fmt.Sprintf("%d", *client.NCR),
+		Nombre:              derefString(client.BusinessName),
+		CodActividad:        derefString(client.CodActividad),
+		DescActividad:       derefString(client.DescActividad),
+		NombreComercial:     client.BusinessName,
+		TipoEstablecimiento: tipoEstablecimiento,
+		Direccion:           b.buildReceptorDireccion(client),
+		Telefono:            telefono,
+		Correo:              derefString(client.Correo),
+		CodigoMH:            nil,
+		PuntoVentaMH:        nil,
+	}, nil
+}
+
+// buildDCLCuerpo maps the amounts computed when the DCL was created
+func (b *Builder) buildDCLCuerpo(dcl *models.DCL) DCLCuerpoDocumento {
+	var porcentComision *string
+	if dcl.CommissionRate > 0 {
+		porcentComision = stringPtr(fmt.Sprintf("%.2f%%", dcl.CommissionRate))
+	}
+
+	return DCLCuerpoDocumento{
+		PeriodoLiquidacionFechaInicio: dcl.PeriodoInicio.Format("2006-01-02"),
+		PeriodoLiquidacionFechaFin:    dcl.PeriodoFin.Format("2006-01-02"),
+		CodLiquidacion:                dcl.CodLiquidacion,
+		CantidadDoc:                   dcl.CantidadDoc,
+		ValorOperaciones:              dcl.ValorOperaciones,
+		MontoSinPercepcion:            dcl.MontoSinPercepcion,
+		DescripSinPercepcion:          dcl.DescripSinPercepcion,
+		SubTotal:                      dcl.SubTotal,
+		IVA:                           dcl.IVA,
+		MontoSujetoPercepcion:         dcl.MontoSujetoPercepcion,
+		IVAPercibido:                  dcl.IVAPercibido,
+		Comision:                      dcl.Comision,
+		PorcentComision:               porcentComision,
+		IVAComision:                   dcl.IVAComision,
+		LiquidoAPagar:                 dcl.LiquidoAPagar,
+		TotalLetras:                   b.numberToWords(dcl.LiquidoAPagar),
+		Observaciones:                 dcl.Observaciones,
+	}
+}
+
+// dclTelefono strips separators; Type 09 requires exactly 8 characters
+func dclTelefono(telefono string) string {
+	return strings.NewReplacer("-", "", " ", "", "(", "", ")", "").Replace(telefono)
+}
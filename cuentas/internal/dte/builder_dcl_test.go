@@ -0,0 +1,100 @@
+package dte
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"cuentas/internal/dte_schemas"
+	"cuentas/internal/models"
+)
+
+func TestDocumentoContableLiquidacionMatchesSchema(t *testing.T) {
+	b := &Builder{}
+
+	cantidad := 42
+	dcl := &models.DCL{
+		PeriodoInicio:         time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC),
+		PeriodoFin:            time.Date(2025, 11, 15, 0, 0, 0, 0, time.UTC),
+		CantidadDoc:           &cantidad,
+		ValorOperaciones:      1130.00,
+		SubTotal:              1000.00,
+		IVA:                   130.00,
+		MontoSujetoPercepcion: 1000.00,
+		IVAPercibido:          20.00,
+		CommissionRate:        3.5,
+		Comision:              39.55,
+		IVAComision:           5.14,
+		LiquidoAPagar:         1065.31,
+	}
+
+	cuerpo := b.buildDCLCuerpo(dcl)
+	if cuerpo.PorcentComision == nil || *cuerpo.PorcentComision != "3.50%" {
+		t.Errorf("PorcentComision = %v, want 3.50%%", cuerpo.PorcentComision)
+	}
+	if cuerpo.PeriodoLiquidacionFechaFin != "2025-11-15" {
+		t.Errorf("PeriodoLiquidacionFechaFin = %v, want 2025-11-15", cuerpo.PeriodoLiquidacionFechaFin)
+	}
+
+	codigo := "M001"
+	puntoVenta := "P001"
+	telefono := dclTelefono("2222-3333")
+	direccion := Direccion{Departamento: "06", Municipio: "14", Complemento: "Colonia Escalón, San Salvador"}
+
+	doc := &DocumentoContableLiquidacion{
+		Identificacion: LiquidacionIdentificacion{
+			Version:          1,
+			Ambiente:         "00",
+			TipoDte:          TipoDteDocContableLiquidacion,
+			NumeroControl:    "DTE-09-M001P001-000000000000001",
+			CodigoGeneracion: "F0E1D2C3-0000-0000-0000-000000000000",
+			TipoModelo:       1,
+			TipoOperacion:    1,
+			FecEmi:           "2025-11-16",
+			HorEmi:           "10:00:00",
+			TipoMoneda:       "USD",
+		},
+		Emisor: DCLEmisor{
+			NIT:                 "06142305911306",
+			NRC:                 "123456",
+			Nombre:              "Procesadora de Pagos SA de CV",
+			CodActividad:        "66190",
+			DescActividad:       "Actividades auxiliares de servicios financieros",
+			TipoEstablecimiento: "02",
+			Telefono:            telefono,
+			Correo:              "liquidaciones@example.com",
+			Direccion:           direccion,
+			Codigo:              &codigo,
+			PuntoVentaContri:    &puntoVenta,
+		},
+		Receptor: DCLReceptor{
+			NIT:                 "06140101001010",
+			NRC:                 "65432",
+			Nombre:              "Comercio Afiliado SA de CV",
+			CodActividad:        "47190",
+			DescActividad:       "Venta al por menor en comercios no especializados",
+			TipoEstablecimiento: "02",
+			Direccion:           direccion,
+			Telefono:            &telefono,
+			Correo:              "afiliado@example.com",
+		},
+		CuerpoDocumento: cuerpo,
+		Extension: DCLExtension{
+			NombEntrega: "Maria Lopez",
+			DocuEntrega: "01234567-8",
+		},
+	}
+	doc.CuerpoDocumento.TotalLetras = "MIL SESENTA Y CINCO 31/100 USD"
+
+	docJSON, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	validator, err := dte_schemas.NewValidator()
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+	if err := validator.ValidateJSON(TipoDteDocContableLiquidacion, docJSON); err != nil {
+		t.Errorf("schema validation failed: %v", err)
+	}
+}
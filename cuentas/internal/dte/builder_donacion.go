@@ -0,0 +1,195 @@
+package dte
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cuentas/internal/models"
+)
+
+// ============================================
+// BUILD COMPROBANTE DE DONACIÓN (TYPE 15)
+// ============================================
+
+// BuildComprobanteDonacion builds a Type 15 DTE from a donation record
+func (b *Builder) BuildComprobanteDonacion(ctx context.Context, donation *models.Donation) (*ComprobanteDonacion, error) {
+	company, err := b.loadCompany(ctx, donation.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("load company: %w", err)
+	}
+
+	establishment, err := b.loadEstablishmentAndPOS(ctx, donation.EstablishmentID, donation.PointOfSaleID)
+	if err != nil {
+		return nil, fmt.Errorf("load establishment: %w", err)
+	}
+
+	client, err := b.loadClient(ctx, donation.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("load client: %w", err)
+	}
+
+	donante, err := b.buildDonacionDonante(client, donation)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation("America/El_Salvador")
+	if err != nil {
+		loc = time.FixedZone("CST", -6*60*60)
+	}
+	now := time.Now().In(loc)
+
+	return &ComprobanteDonacion{
+		Identificacion: LiquidacionIdentificacion{
+			Version:          1,
+			Ambiente:         donation.Ambiente,
+			TipoDte:          TipoDteCompDonacion,
+			NumeroControl:    strings.ToUpper(donation.NumeroControl),
+			CodigoGeneracion: strings.ToUpper(donation.CodigoGeneracion),
+			TipoModelo:       1, // Previo
+			TipoOperacion:    1, // Normal - Type 15 schema does not allow contingency
+			FecEmi:           now.Format("2006-01-02"),
+			HorEmi:           now.Format("15:04:05"),
+			TipoMoneda:       "USD",
+		},
+		Donatario:       b.buildDonacionDonatario(company, establishment),
+		Donante:         *donante,
+		OtrosDocumentos: buildDonacionOtrosDocumentos(donation),
+		CuerpoDocumento: buildDonacionCuerpo(donation),
+		Resumen:         b.buildDonacionResumen(donation),
+		Apendice:        nil,
+	}, nil
+}
+
+// buildDonacionDonatario builds the issuer section (our company, the non-profit)
+func (b *Builder) buildDonacionDonatario(company *CompanyData, establishment *EstablishmentData) DonacionDonatario {
+	var nombreComercial *string
+	if company.NombreComercial != "" {
+		nombreComercial = &company.NombreComercial
+	}
+
+	var nrc *string
+	if company.NCR > 0 {
+		nrc = stringPtr(fmt.Sprintf("%d", company.NCR))
+	}
+
+	return DonacionDonatario{
+		TipoDocumento:       DocTypeNIT,
+		NumDocumento:        company.NIT,
+		NRC:                 nrc,
+		Nombre:              company.Name,
+		CodActividad:        company.CodActividad,
+		DescActividad:       company.DescActividad,
+		NombreComercial:     nombreComercial,
+		TipoEstablecimiento: establishment.TipoEstablecimiento,
+		Direccion:           b.buildEmisorDireccion(establishment),
+		Telefono:            establishment.Telefono,
+		Correo:              company.Email,
+		CodEstableMH:        nil,
+		CodEstable:          &establishment.CodEstablecimiento,
+		CodPuntoVentaMH:     nil,
+		CodPuntoVenta:       &establishment.CodPuntoVenta,
+	}
+}
+
+// buildDonacionDonante builds the donor section. Non-domiciled donors carry
+// no address or economic activity.
+func (b *Builder) buildDonacionDonante(client *ClientData, donation *models.Donation) (*DonacionDonante, error) {
+	donante := &DonacionDonante{
+		Nombre:         derefString(client.BusinessName),
+		CodDomiciliado: donation.CodDomiciliado,
+		CodPais:        donation.CodPais,
+	}
+
+	if client.NIT != nil {
+		donante.TipoDocumento = DocTypeNIT
+		donante.NumDocumento = fmt.Sprintf("%014d", *client.NIT)
+		if client.NCR != nil && *client.NCR > 0 {
+			donante.NRC = stringPtr(fmt.Sprintf("%d", *client.NCR))
+		}
+	} else if client.DUI != nil {
+		donante.TipoDocumento = DocTypeDUI
+		donante.NumDocumento = fmt.Sprintf("%08d-%d", *client.DUI/10, *client.DUI%10)
+	} else {
+		return nil, fmt.Errorf("client %s has no NIT or DUI", client.ID)
+	}
+
+	if donation.CodDomiciliado == 1 {
+		direccion := client.GetValidatedDireccion()
+		if direccion == nil {
+			return nil, fmt.Errorf("client %s has no valid address; domiciled donors require one", client.ID)
+		}
+		donante.Direccion = direccion
+		donante.CodActividad = client.CodActividad
+		donante.DescActividad = client.DescActividad
+	}
+
+	if client.Telefono != nil && len(*client.Telefono) >= 8 {
+		donante.Telefono = client.Telefono
+	}
+	if client.Correo != nil && *client.Correo != "" {
+		donante.Correo = client.Correo
+	}
+
+	return donante, nil
+}
+
+// buildDonacionOtrosDocumentos maps the associated documents captured on creation
+func buildDonacionOtrosDocumentos(donation *models.Donation) []DonacionOtroDocumento {
+	docs := make([]DonacionOtroDocumento, 0, len(donation.OtrosDocumentos))
+	for _, d := range donation.OtrosDocumentos {
+		docs = append(docs, DonacionOtroDocumento{
+			CodDocAsociado:   d.CodDocAsociado,
+			DescDocumento:    d.DescDocumento,
+			DetalleDocumento: d.DetalleDocumento,
+		})
+	}
+	return docs
+}
+
+// buildDonacionCuerpo maps the donated lines. The schema has no field for the
+// valuation method, so goods and services state it in the description.
+func buildDonacionCuerpo(donation *models.Donation) []DonacionCuerpoItem {
+	items := make([]DonacionCuerpoItem, 0, len(donation.Items))
+	for i, item := range donation.Items {
+		descripcion := item.Descripcion
+		if item.TipoDonacion != 1 {
+			if label, ok := models.DonationValuationLabels[item.ValuationMethod]; ok {
+				descripcion = fmt.Sprintf("%s (Valuación: %s)", descripcion, label)
+			}
+		}
+
+		items = append(items, DonacionCuerpoItem{
+			NumItem:      i + 1,
+			TipoDonacion: item.TipoDonacion,
+			Cantidad:     item.Cantidad,
+			Codigo:       item.Codigo,
+			UniMedida:    item.UniMedida,
+			Descripcion:  descripcion,
+			Depreciacion: item.Depreciacion,
+			ValorUni:     item.ValorUni,
+			Valor:        item.Valor,
+		})
+	}
+	return items
+}
+
+// buildDonacionResumen totals the donation; pagos is only reported for cash
+func (b *Builder) buildDonacionResumen(donation *models.Donation) DonacionResumen {
+	var pagos *[]DonacionPago
+	if donation.TotalEfectivo > 0 {
+		pagos = &[]DonacionPago{{
+			Codigo:     donation.FormaPago,
+			MontoPago:  donation.TotalEfectivo,
+			Referencia: donation.ReferenciaPago,
+		}}
+	}
+
+	return DonacionResumen{
+		ValorTotal:  donation.ValorTotal,
+		TotalLetras: b.numberToWords(donation.ValorTotal),
+		Pagos:       pagos,
+	}
+}
@@ -0,0 +1,112 @@
+package dte
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"cuentas/internal/dte_schemas"
+	"cuentas/internal/models"
+)
+
+func TestComprobanteDonacionMatchesSchema(t *testing.T) {
+	b := &Builder{}
+
+	formaPago := "05"
+	referencia := "TRF-2025-001"
+	donation := &models.Donation{
+		ValorTotal:     1450.00,
+		TotalEfectivo:  500.00,
+		TotalBienes:    750.00,
+		TotalServicios: 200.00,
+		FormaPago:      &formaPago,
+		ReferenciaPago: &referencia,
+		OtrosDocumentos: []models.DonationAssociatedDocument{{
+			CodDocAsociado:   1,
+			DescDocumento:    "Resolución de calificación 123-2024",
+			DetalleDocumento: "Entidad calificada para recibir donaciones deducibles",
+		}},
+		Items: []models.DonationItem{
+			{TipoDonacion: 1, ValuationMethod: models.DonationValuationNominal, Descripcion: "Donación en efectivo",
+				UniMedida: 99, Cantidad: 1, ValorUni: 500, Valor: 500},
+			{TipoDonacion: 2, ValuationMethod: models.DonationValuationBookValue, Descripcion: "Computadora portátil",
+				UniMedida: 59, Cantidad: 2, ValorUni: 450, Depreciacion: 150, Valor: models.DonationItemValue(2, 450, 150)},
+			{TipoDonacion: 3, ValuationMethod: models.DonationValuationMarketValue, Descripcion: "Asesoría legal",
+				UniMedida: 99, Cantidad: 1, ValorUni: 200, Valor: 200},
+		},
+	}
+
+	cuerpo := buildDonacionCuerpo(donation)
+	if cuerpo[1].Valor != 750 {
+		t.Errorf("goods valor = %v, want 750", cuerpo[1].Valor)
+	}
+	if !strings.Contains(cuerpo[1].Descripcion, "Valor en libros") {
+		t.Errorf("goods descripcion %q does not state the valuation method", cuerpo[1].Descripcion)
+	}
+	if cuerpo[0].Descripcion != "Donación en efectivo" {
+		t.Errorf("cash descripcion = %q, want it unchanged", cuerpo[0].Descripcion)
+	}
+
+	resumen := b.buildDonacionResumen(donation)
+	if resumen.Pagos == nil || (*resumen.Pagos)[0].MontoPago != 500 {
+		t.Errorf("pagos = %v, want a single 500.00 cash payment", resumen.Pagos)
+	}
+
+	codigo := "M001"
+	puntoVenta := "P001"
+	nrc := "123456"
+	telefono := "2222-3333"
+	doc := &ComprobanteDonacion{
+		Identificacion: LiquidacionIdentificacion{
+			Version:          1,
+			Ambiente:         "00",
+			TipoDte:          TipoDteCompDonacion,
+			NumeroControl:    "DTE-15-M001P001-000000000000001",
+			CodigoGeneracion: "A1B2C3D4-0000-0000-0000-000000000000",
+			TipoModelo:       1,
+			TipoOperacion:    1,
+			FecEmi:           "2025-11-16",
+			HorEmi:           "10:00:00",
+			TipoMoneda:       "USD",
+		},
+		Donatario: DonacionDonatario{
+			TipoDocumento:       DocTypeNIT,
+			NumDocumento:        "06142305911306",
+			NRC:                 &nrc,
+			Nombre:              "Fundación Ejemplo",
+			CodActividad:        "94990",
+			DescActividad:       "Actividades de otras asociaciones n.c.p.",
+			TipoEstablecimiento: "02",
+			Direccion:           Direccion{Departamento: "06", Municipio: "14", Complemento: "Colonia Escalón, San Salvador"},
+			Telefono:            telefono,
+			Correo:              "donaciones@example.com",
+			CodEstable:          &codigo,
+			CodPuntoVenta:       &puntoVenta,
+		},
+		Donante: DonacionDonante{
+			TipoDocumento:  DocTypeDUI,
+			NumDocumento:   "01234567-8",
+			Nombre:         "Juan Pérez",
+			Direccion:      &Direccion{Departamento: "06", Municipio: "14", Complemento: "San Salvador"},
+			Telefono:       &telefono,
+			CodDomiciliado: 1,
+			CodPais:        "9300",
+		},
+		OtrosDocumentos: buildDonacionOtrosDocumentos(donation),
+		CuerpoDocumento: cuerpo,
+		Resumen:         resumen,
+	}
+	doc.Resumen.TotalLetras = "MIL CUATROCIENTOS CINCUENTA 00/100 USD"
+
+	docJSON, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	validator, err := dte_schemas.NewValidator()
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+	if err := validator.ValidateJSON(TipoDteCompDonacion, docJSON); err != nil {
+		t.Errorf("schema validation failed: %v", err)
+	}
+}
@@ -4,12 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
 	"cuentas/internal/codigos"
-	"cuentas/internal/dte_schemas"
 	"cuentas/internal/models"
 )
 
@@ -177,14 +175,7 @@ func (b *Builder) BuildFacturaExportacion(ctx context.Context, invoice *models.I
 		return nil, err
 	}
 
-	// ✅ Validate JSON against schema
-	log.Printf("[BuildFacturaExportacion] Validating DTE against schema...")
-	if err := dte_schemas.Validate("11", jsonBytes); err != nil {
-		log.Printf("[BuildFacturaExportacion] ❌ Schema validation failed: %v", err)
-		return nil, fmt.Errorf("schema validation failed: %w", err)
-	}
-	log.Printf("[BuildFacturaExportacion] ✅ Schema validation passed")
-
+	// Schema validation is enforced by ProcessExportInvoice before signing
 	return jsonBytes, nil
 }
 
@@ -0,0 +1,155 @@
+package dte
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"cuentas/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ============================================
+// BUILD EVENTO DE INVALIDACIÓN
+// ============================================
+
+// BuildInvalidacion builds an Evento de Invalidación for an accepted DTE
+func (b *Builder) BuildInvalidacion(ctx context.Context, source *InvalidationSource, req *models.InvalidateDTERequest) (*Invalidacion, error) {
+	company, err := b.loadCompany(ctx, source.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("load company: %w", err)
+	}
+
+	establishment, err := b.loadEstablishmentAndPOS(ctx, source.EstablishmentID, source.PointOfSaleID)
+	if err != nil {
+		return nil, fmt.Errorf("load establishment: %w", err)
+	}
+
+	var nomEstablecimiento string
+	err = b.db.QueryRowContext(ctx, `SELECT nombre FROM establishments WHERE id = $1`, source.EstablishmentID).Scan(&nomEstablecimiento)
+	if err != nil {
+		return nil, fmt.Errorf("query establishment name: %w", err)
+	}
+
+	documento, err := b.buildInvalidacionDocumento(source, req)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation("America/El_Salvador")
+	if err != nil {
+		loc = time.FixedZone("CST", -6*60*60)
+	}
+	now := time.Now().In(loc)
+
+	var motivo *string
+	if strings.TrimSpace(req.MotivoAnulacion) != "" {
+		m := strings.TrimSpace(req.MotivoAnulacion)
+		motivo = &m
+	}
+
+	return &Invalidacion{
+		Identificacion: InvalidacionIdentificacion{
+			Version:          2,
+			Ambiente:         source.Ambiente,
+			CodigoGeneracion: strings.ToUpper(uuid.New().String()),
+			FecAnula:         now.Format("2006-01-02"),
+			HorAnula:         now.Format("15:04:05"),
+		},
+		Emisor: InvalidacionEmisor{
+			NIT:                 company.NIT,
+			Nombre:              company.Name,
+			TipoEstablecimiento: establishment.TipoEstablecimiento,
+			NomEstablecimiento:  &nomEstablecimiento,
+			CodEstableMH:        nil,
+			CodEstable:          &establishment.CodEstablecimiento,
+			CodPuntoVentaMH:     nil,
+			CodPuntoVenta:       &establishment.CodPuntoVenta,
+			Telefono:            &establishment.Telefono,
+			Correo:              company.Email,
+		},
+		Documento: *documento,
+		Motivo: InvalidacionMotivo{
+			TipoAnulacion:     req.TipoAnulacion,
+			MotivoAnulacion:   motivo,
+			NombreResponsable: req.NombreResponsable,
+			TipDocResponsable: req.TipDocResponsable,
+			NumDocResponsable: req.NumDocResponsable,
+			NombreSolicita:    req.NombreSolicita,
+			TipDocSolicita:    req.TipDocSolicita,
+			NumDocSolicita:    req.NumDocSolicita,
+		},
+	}, nil
+}
+
+// buildInvalidacionDocumento fills the documento section from the original DTE JSON.
+// The receptor lives under a different key depending on the DTE type.
+func (b *Builder) buildInvalidacionDocumento(source *InvalidationSource, req *models.InvalidateDTERequest) (*InvalidacionDocumento, error) {
+	var original map[string]json.RawMessage
+	if err := json.Unmarshal(source.DteUnsigned, &original); err != nil {
+		return nil, fmt.Errorf("parse original DTE: %w", err)
+	}
+
+	var receptor struct {
+		TipoDocumento *string `json:"tipoDocumento"`
+		NumDocumento  *string `json:"numDocumento"`
+		NIT           *string `json:"nit"`
+		Nombre        *string `json:"nombre"`
+		Telefono      *string `json:"telefono"`
+		Correo        *string `json:"correo"`
+	}
+
+	found := false
+	for _, key := range []string{"receptor", "sujetoExcluido", "donante"} {
+		raw, ok := original[key]
+		if !ok || string(raw) == "null" {
+			continue
+		}
+		if err := json.Unmarshal(raw, &receptor); err != nil {
+			return nil, fmt.Errorf("parse original %s: %w", key, err)
+		}
+		found = true
+		break
+	}
+	if !found {
+		return nil, fmt.Errorf("original DTE has no receptor to identify")
+	}
+
+	documento := &InvalidacionDocumento{
+		TipoDte:          source.TipoDte,
+		CodigoGeneracion: strings.ToUpper(source.CodigoGeneracion),
+		SelloRecibido:    source.SelloRecibido,
+		NumeroControl:    source.NumeroControl,
+		FecEmi:           source.FechaEmision,
+		Telefono:         receptor.Telefono,
+		Correo:           receptor.Correo,
+	}
+
+	montoIva := source.IVAAmount
+	documento.MontoIva = &montoIva
+
+	if req.RequiresReplacement() {
+		codigoR := strings.ToUpper(req.CodigoGeneracionR)
+		documento.CodigoGeneracionR = &codigoR
+	}
+
+	switch {
+	case receptor.TipoDocumento != nil && receptor.NumDocumento != nil:
+		documento.TipoDocumento = *receptor.TipoDocumento
+		documento.NumDocumento = *receptor.NumDocumento
+	case receptor.NIT != nil:
+		documento.TipoDocumento = "36" // NIT
+		documento.NumDocumento = *receptor.NIT
+	default:
+		return nil, fmt.Errorf("original DTE receptor has no identification document")
+	}
+
+	if receptor.Nombre != nil {
+		documento.Nombre = *receptor.Nombre
+	}
+
+	return documento, nil
+}
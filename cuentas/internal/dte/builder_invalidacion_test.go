@@ -0,0 +1,99 @@
+package dte
+
+import (
+	"testing"
+
+	"cuentas/internal/models"
+)
+
+func TestBuildInvalidacionDocumento(t *testing.T) {
+	b := &Builder{}
+
+	tests := []struct {
+		name        string
+		tipoDte     string
+		dteUnsigned string
+		req         models.InvalidateDTERequest
+		wantTipoDoc string
+		wantNumDoc  string
+		wantNombre  string
+		wantR       bool
+	}{
+		{
+			name:        "factura receptor with DUI",
+			tipoDte:     "01",
+			dteUnsigned: `{"receptor":{"tipoDocumento":"13","numDocumento":"01234567-8","nombre":"Juan Perez"}}`,
+			req:         models.InvalidateDTERequest{TipoAnulacion: 2},
+			wantTipoDoc: "13",
+			wantNumDoc:  "01234567-8",
+			wantNombre:  "Juan Perez",
+		},
+		{
+			name:        "ccf receptor identified by NIT",
+			tipoDte:     "03",
+			dteUnsigned: `{"receptor":{"nit":"06142305911306","nrc":"12345","nombre":"Empresa SA de CV"}}`,
+			req:         models.InvalidateDTERequest{TipoAnulacion: 1, CodigoGeneracionR: "A1B2C3D4-0000-0000-0000-000000000001"},
+			wantTipoDoc: "36",
+			wantNumDoc:  "06142305911306",
+			wantNombre:  "Empresa SA de CV",
+			wantR:       true,
+		},
+		{
+			name:        "fse sujeto excluido",
+			tipoDte:     "14",
+			dteUnsigned: `{"sujetoExcluido":{"tipoDocumento":"13","numDocumento":"09876543-2","nombre":"Proveedor Informal"}}`,
+			req:         models.InvalidateDTERequest{TipoAnulacion: 3, CodigoGeneracionR: "A1B2C3D4-0000-0000-0000-000000000002"},
+			wantTipoDoc: "13",
+			wantNumDoc:  "09876543-2",
+			wantNombre:  "Proveedor Informal",
+			wantR:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &InvalidationSource{
+				CodigoGeneracion: "F0E1D2C3-0000-0000-0000-000000000000",
+				TipoDte:          tt.tipoDte,
+				NumeroControl:    "DTE-" + tt.tipoDte + "-M001P001-000000000000001",
+				SelloRecibido:    "2025ABCDEF0123456789ABCDEF0123456789ABCD",
+				FechaEmision:     "2025-11-17",
+				IVAAmount:        1.30,
+				DteUnsigned:      []byte(tt.dteUnsigned),
+			}
+
+			got, err := b.buildInvalidacionDocumento(source, &tt.req)
+			if err != nil {
+				t.Fatalf("buildInvalidacionDocumento() error = %v", err)
+			}
+			if got.TipoDocumento != tt.wantTipoDoc {
+				t.Errorf("TipoDocumento = %v, want %v", got.TipoDocumento, tt.wantTipoDoc)
+			}
+			if got.NumDocumento != tt.wantNumDoc {
+				t.Errorf("NumDocumento = %v, want %v", got.NumDocumento, tt.wantNumDoc)
+			}
+			if got.Nombre != tt.wantNombre {
+				t.Errorf("Nombre = %v, want %v", got.Nombre, tt.wantNombre)
+			}
+			if (got.CodigoGeneracionR != nil) != tt.wantR {
+				t.Errorf("CodigoGeneracionR = %v, want set = %v", got.CodigoGeneracionR, tt.wantR)
+			}
+			if got.MontoIva == nil || *got.MontoIva != 1.30 {
+				t.Errorf("MontoIva = %v, want 1.30", got.MontoIva)
+			}
+		})
+	}
+}
+
+func TestBuildInvalidacionDocumentoWithoutReceptor(t *testing.T) {
+	b := &Builder{}
+
+	source := &InvalidationSource{
+		TipoDte:     "01",
+		DteUnsigned: []byte(`{"receptor":null}`),
+	}
+
+	if _, err := b.buildInvalidacionDocumento(source, &models.InvalidateDTERequest{TipoAnulacion: 2}); err == nil {
+		t.Error("expected error for DTE without receptor")
+	}
+}
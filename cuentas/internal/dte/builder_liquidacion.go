@@ -0,0 +1,175 @@
+package dte
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cuentas/internal/models"
+)
+
+// ============================================
+// BUILD COMPROBANTE DE LIQUIDACIÓN (TYPE 08)
+// ============================================
+
+// BuildComprobanteLiquidacion builds a Type 08 DTE from a liquidación and its documents
+func (b *Builder) BuildComprobanteLiquidacion(ctx context.Context, liquidacion *models.Liquidacion) (*ComprobanteLiquidacion, error) {
+	if len(liquidacion.Documents) == 0 {
+		return nil, fmt.Errorf("liquidación %s has no documents", liquidacion.ID)
+	}
+
+	company, err := b.loadCompany(ctx, liquidacion.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("load company: %w", err)
+	}
+
+	establishment, err := b.loadEstablishmentAndPOS(ctx, liquidacion.EstablishmentID, liquidacion.PointOfSaleID)
+	if err != nil {
+		return nil, fmt.Errorf("load establishment: %w", err)
+	}
+
+	client, err := b.loadClient(ctx, liquidacion.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("load client: %w", err)
+	}
+
+	receptor, err := b.buildLiquidacionReceptor(client)
+	if err != nil {
+		return nil, err
+	}
+
+	emisor := b.buildEmisor(company, establishment)
+	if company.NombreComercial == "" {
+		emisor.NombreComercial = nil
+	}
+
+	loc, err := time.LoadLocation("America/El_Salvador")
+	if err != nil {
+		loc = time.FixedZone("CST", -6*60*60)
+	}
+	now := time.Now().In(loc)
+
+	var extension *LiquidacionExtension
+	if liquidacion.Observaciones != nil && strings.TrimSpace(*liquidacion.Observaciones) != "" {
+		extension = &LiquidacionExtension{Observaciones: liquidacion.Observaciones}
+	}
+
+	return &ComprobanteLiquidacion{
+		Identificacion: LiquidacionIdentificacion{
+			Version:          1,
+			Ambiente:         liquidacion.Ambiente,
+			TipoDte:          TipoDteCompLiquidacion,
+			NumeroControl:    strings.ToUpper(liquidacion.NumeroControl),
+			CodigoGeneracion: strings.ToUpper(liquidacion.CodigoGeneracion),
+			TipoModelo:       1, // Previo
+			TipoOperacion:    1, // Normal - Type 08 schema does not allow contingency
+			FecEmi:           now.Format("2006-01-02"),
+			HorEmi:           now.Format("15:04:05"),
+			TipoMoneda:       "USD",
+		},
+		Emisor:          emisor,
+		Receptor:        *receptor,
+		CuerpoDocumento: b.buildLiquidacionCuerpo(liquidacion.Documents),
+		Resumen:         b.buildLiquidacionResumen(liquidacion),
+		Extension:       extension,
+		Apendice:        b.buildLiquidacionApendice(liquidacion),
+	}, nil
+}
+
+// buildLiquidacionReceptor builds the third-party seller section
+func (b *Builder) buildLiquidacionReceptor(client *ClientData) (*LiquidacionReceptor, error) {
+	if client.NIT == nil || client.NCR == nil {
+		return nil, fmt.Errorf("client %s must have NIT and NRC to receive a liquidación", client.ID)
+	}
+
+	return &LiquidacionReceptor{
+		NIT:             fmt.Sprintf("%014d", *client.NIT),
+		NRC:             fmt.Sprintf("%d", *client.NCR),
+		Nombre:          derefString(client.BusinessName),
+		CodActividad:    derefString(client.CodActividad),
+		DescActividad:   derefString(client.DescActividad),
+		NombreComercial: client.BusinessName,
+		Direccion:       b.buildReceptorDireccion(client),
+		Telefono:        client.Telefono,
+		Correo:          derefString(client.Correo),
+	}, nil
+}
+
+// buildLiquidacionCuerpo lists the summarized DTEs, referenced by codigoGeneracion
+func (b *Builder) buildLiquidacionCuerpo(documents []models.LiquidacionDocument) []LiquidacionCuerpoItem {
+	items := make([]LiquidacionCuerpoItem, 0, len(documents))
+	for _, doc := range documents {
+		numeroDocumento := doc.NumeroControl
+		if doc.TipoGeneracion == 2 {
+			numeroDocumento = strings.ToUpper(doc.CodigoGeneracion)
+		}
+
+		var tributos []string
+		if doc.IvaItem != 0 {
+			tributos = []string{"20"}
+		}
+
+		items = append(items, LiquidacionCuerpoItem{
+			NumItem:         doc.LineNumber,
+			TipoDte:         doc.TipoDte,
+			TipoGeneracion:  doc.TipoGeneracion,
+			NumeroDocumento: numeroDocumento,
+			FechaGeneracion: doc.FechaGeneracion.Format("2006-01-02"),
+			VentaNoSuj:      doc.VentaNoSuj,
+			VentaExenta:     doc.VentaExenta,
+			VentaGravada:    doc.VentaGravada,
+			Exportaciones:   doc.Exportaciones,
+			Tributos:        tributos,
+			IvaItem:         doc.IvaItem,
+			ObsItem:         doc.ObsItem,
+		})
+	}
+	return items
+}
+
+// buildLiquidacionResumen maps the totals computed when the liquidación was created
+func (b *Builder) buildLiquidacionResumen(liquidacion *models.Liquidacion) LiquidacionResumen {
+	var tributos *[]Tributo
+	if liquidacion.TotalIVA != 0 {
+		tributos = &[]Tributo{{
+			Codigo:      "20",
+			Descripcion: "Impuesto al Valor Agregado 13%",
+			Valor:       liquidacion.TotalIVA,
+		}}
+	}
+
+	// Schema: a zero total only allows condicionOperacion 1 (Contado)
+	condicion := liquidacion.CondicionOperacion
+	if liquidacion.MontoTotalOperacion == 0 || condicion == 0 {
+		condicion = 1
+	}
+
+	return LiquidacionResumen{
+		TotalNoSuj:          liquidacion.TotalNoSuj,
+		TotalExenta:         liquidacion.TotalExenta,
+		TotalGravada:        liquidacion.TotalGravada,
+		TotalExportacion:    liquidacion.TotalExportacion,
+		SubTotalVentas:      liquidacion.SubTotalVentas,
+		Tributos:            tributos,
+		MontoTotalOperacion: liquidacion.MontoTotalOperacion,
+		IvaPerci:            0,
+		Total:               liquidacion.MontoTotalOperacion,
+		TotalLetras:         b.numberToWords(liquidacion.MontoTotalOperacion),
+		CondicionOperacion:  condicion,
+	}
+}
+
+// buildLiquidacionApendice carries the seller commission, which has no field in the schema
+func (b *Builder) buildLiquidacionApendice(liquidacion *models.Liquidacion) *[]Apendice {
+	if liquidacion.CommissionRate == 0 {
+		return nil
+	}
+
+	return &[]Apendice{
+		{Campo: "comision_porcentaje", Etiqueta: "Porcentaje de comisión", Valor: fmt.Sprintf("%.2f%%", liquidacion.CommissionRate)},
+		{Campo: "comision_monto", Etiqueta: "Comisión", Valor: fmt.Sprintf("%.2f", liquidacion.CommissionAmount)},
+		{Campo: "comision_iva", Etiqueta: "IVA sobre comisión", Valor: fmt.Sprintf("%.2f", liquidacion.CommissionIVA)},
+		{Campo: "liquido_a_pagar", Etiqueta: "Líquido a pagar", Valor: fmt.Sprintf("%.2f", liquidacion.NetAmount)},
+	}
+}
@@ -0,0 +1,158 @@
+package dte
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"cuentas/internal/dte_schemas"
+	"cuentas/internal/models"
+)
+
+func testLiquidacion() *models.Liquidacion {
+	fecha := time.Date(2025, 11, 17, 0, 0, 0, 0, time.UTC)
+	return &models.Liquidacion{
+		ID:                  "LIQ-1",
+		TotalGravada:        150.00,
+		SubTotalVentas:      150.00,
+		TotalIVA:            19.50,
+		MontoTotalOperacion: 169.50,
+		CommissionRate:      10,
+		CommissionAmount:    15.00,
+		CommissionIVA:       1.95,
+		NetAmount:           152.55,
+		CondicionOperacion:  2,
+		Documents: []models.LiquidacionDocument{
+			{
+				LineNumber:       1,
+				CodigoGeneracion: "a1b2c3d4-0000-0000-0000-000000000001",
+				TipoDte:          "03",
+				TipoGeneracion:   2,
+				NumeroControl:    "DTE-03-M001P001-000000000000001",
+				FechaGeneracion:  fecha,
+				VentaGravada:     200.00,
+				IvaItem:          26.00,
+				ObsItem:          "DTE-03-M001P001-000000000000001",
+			},
+			{
+				LineNumber:       2,
+				CodigoGeneracion: "A1B2C3D4-0000-0000-0000-000000000002",
+				TipoDte:          "05",
+				TipoGeneracion:   2,
+				NumeroControl:    "DTE-05-M001P001-000000000000001",
+				FechaGeneracion:  fecha,
+				VentaGravada:     -50.00,
+				IvaItem:          -6.50,
+				ObsItem:          "DTE-05-M001P001-000000000000001",
+			},
+		},
+	}
+}
+
+func TestBuildLiquidacionCuerpo(t *testing.T) {
+	b := &Builder{}
+
+	items := b.buildLiquidacionCuerpo(testLiquidacion().Documents)
+	if len(items) != 2 {
+		t.Fatalf("len(items) = %d, want 2", len(items))
+	}
+	if items[0].NumeroDocumento != "A1B2C3D4-0000-0000-0000-000000000001" {
+		t.Errorf("NumeroDocumento = %v, want upper-case codigoGeneracion", items[0].NumeroDocumento)
+	}
+	if items[0].FechaGeneracion != "2025-11-17" {
+		t.Errorf("FechaGeneracion = %v, want 2025-11-17", items[0].FechaGeneracion)
+	}
+	if len(items[1].Tributos) != 1 || items[1].Tributos[0] != "20" {
+		t.Errorf("Tributos = %v, want [20]", items[1].Tributos)
+	}
+	if items[1].VentaGravada != -50.00 {
+		t.Errorf("nota de crédito VentaGravada = %v, want -50.00", items[1].VentaGravada)
+	}
+}
+
+func TestBuildLiquidacionResumen(t *testing.T) {
+	b := &Builder{}
+
+	liquidacion := testLiquidacion()
+	resumen := b.buildLiquidacionResumen(liquidacion)
+	if resumen.Total != 169.50 {
+		t.Errorf("Total = %v, want 169.50", resumen.Total)
+	}
+	if resumen.Tributos == nil || (*resumen.Tributos)[0].Valor != 19.50 {
+		t.Errorf("Tributos = %v, want IVA 19.50", resumen.Tributos)
+	}
+	if resumen.CondicionOperacion != 2 {
+		t.Errorf("CondicionOperacion = %v, want 2", resumen.CondicionOperacion)
+	}
+
+	liquidacion.MontoTotalOperacion = 0
+	if got := b.buildLiquidacionResumen(liquidacion).CondicionOperacion; got != 1 {
+		t.Errorf("zero total CondicionOperacion = %v, want 1", got)
+	}
+}
+
+func TestComprobanteLiquidacionMatchesSchema(t *testing.T) {
+	b := &Builder{}
+	liquidacion := testLiquidacion()
+
+	telefono := "22223333"
+	nombreComercial := "Distribuidora Central"
+	codEstable := "M001"
+	codPuntoVenta := "P001"
+	direccion := Direccion{Departamento: "06", Municipio: "14", Complemento: "Colonia Escalón, San Salvador"}
+
+	doc := &ComprobanteLiquidacion{
+		Identificacion: LiquidacionIdentificacion{
+			Version:          1,
+			Ambiente:         "00",
+			TipoDte:          TipoDteCompLiquidacion,
+			NumeroControl:    "DTE-08-M001P001-000000000000001",
+			CodigoGeneracion: "F0E1D2C3-0000-0000-0000-000000000000",
+			TipoModelo:       1,
+			TipoOperacion:    1,
+			FecEmi:           "2025-11-30",
+			HorEmi:           "10:00:00",
+			TipoMoneda:       "USD",
+		},
+		Emisor: Emisor{
+			NIT:                 "06142305911306",
+			NRC:                 "123456",
+			Nombre:              "Empresa Emisora SA de CV",
+			CodActividad:        "46900",
+			DescActividad:       "Venta al por mayor de otros productos",
+			TipoEstablecimiento: "01",
+			Direccion:           direccion,
+			Telefono:            telefono,
+			Correo:              "facturacion@example.com",
+			CodEstable:          &codEstable,
+			CodPuntoVenta:       &codPuntoVenta,
+		},
+		Receptor: LiquidacionReceptor{
+			NIT:             "06140101001010",
+			NRC:             "65432",
+			Nombre:          "Distribuidora Central SA de CV",
+			CodActividad:    "47190",
+			DescActividad:   "Venta al por menor en comercios no especializados",
+			NombreComercial: &nombreComercial,
+			Direccion:       direccion,
+			Telefono:        &telefono,
+			Correo:          "ventas@example.com",
+		},
+		CuerpoDocumento: b.buildLiquidacionCuerpo(liquidacion.Documents),
+		Resumen:         b.buildLiquidacionResumen(liquidacion),
+		Apendice:        b.buildLiquidacionApendice(liquidacion),
+	}
+	doc.Resumen.TotalLetras = "CIENTO SESENTA Y NUEVE 50/100 USD"
+
+	docJSON, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	validator, err := dte_schemas.NewValidator()
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+	if err := validator.ValidateJSON(TipoDteCompLiquidacion, docJSON); err != nil {
+		t.Errorf("schema validation failed: %v", err)
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"cuentas/internal/codigos"
-	"cuentas/internal/dte_schemas"
 	"cuentas/internal/models"
 )
 
@@ -421,13 +420,7 @@ func (b *Builder) _xBuildNotaRemision(ctx context.Context, invoice *models.Invoi
 	if err != nil {
 		return nil, fmt.Errorf("marshal JSON: %w", err)
 	}
-	// ✅ Validate JSON against schema
-	log.Printf("[BuildNotaRemision] Validating DTE against schema...")
-	if err := dte_schemas.Validate("04", jsonBytes); err != nil {
-		log.Printf("WARNING: [BuildNotaRemision] ❌ Schema validation failed: %v", err)
-		//return nil, fmt.Errorf("schema validation failed: %w", err)
-	}
-	log.Printf("[BuildNotaRemision] ✅ Schema validation passed")
+	// Schema validation is enforced by ProcessRemision before signing
 	return jsonBytes, nil
 }
 
@@ -511,13 +504,7 @@ func (b *Builder) BuildNotaRemision(ctx context.Context, invoice *models.Invoice
 		return nil, fmt.Errorf("marshal JSON: %w", err)
 	}
 
-	// ✅ Validate JSON against schema
-	log.Printf("[BuildNotaRemision] Validating DTE against schema...")
-	if err := dte_schemas.Validate("04", jsonBytes); err != nil {
-		log.Printf("WARNING: [BuildNotaRemision] ❌ Schema validation failed: %v", err)
-		//return nil, fmt.Errorf("schema validation failed: %w", err)
-	}
-	log.Printf("[BuildNotaRemision] ✅ Schema validation passed")
+	// Schema validation is enforced by ProcessRemision before signing
 
 	return jsonBytes, nil
 }
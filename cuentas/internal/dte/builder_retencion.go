@@ -0,0 +1,188 @@
+package dte
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"cuentas/internal/models"
+)
+
+// ============================================
+// BUILD COMPROBANTE DE RETENCIÓN (TYPE 07)
+// ============================================
+
+// BuildComprobanteRetencion builds a Type 07 DTE from a retention record
+func (b *Builder) BuildComprobanteRetencion(ctx context.Context, retention *models.Retention) (*ComprobanteRetencion, error) {
+	company, err := b.loadCompany(ctx, retention.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("load company: %w", err)
+	}
+
+	establishment, err := b.loadEstablishmentAndPOS(ctx, retention.EstablishmentID, retention.PointOfSaleID)
+	if err != nil {
+		return nil, fmt.Errorf("load establishment: %w", err)
+	}
+
+	receptor, err := b.buildRetencionReceptor(ctx, retention)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation("America/El_Salvador")
+	if err != nil {
+		loc = time.FixedZone("CST", -6*60*60)
+	}
+	now := time.Now().In(loc)
+
+	return &ComprobanteRetencion{
+		Identificacion: Identificacion{
+			Version:          1,
+			Ambiente:         retention.Ambiente,
+			TipoDte:          TipoDteCompRetencion,
+			NumeroControl:    strings.ToUpper(retention.NumeroControl),
+			CodigoGeneracion: strings.ToUpper(retention.CodigoGeneracion),
+			TipoModelo:       1, // Previo
+			TipoOperacion:    1, // Normal - Type 07 schema does not allow contingency
+			TipoContingencia: nil,
+			MotivoContin:     nil,
+			FecEmi:           now.Format("2006-01-02"),
+			HorEmi:           now.Format("15:04:05"),
+			TipoMoneda:       "USD",
+		},
+		Emisor:          b.buildRetencionEmisor(company, establishment),
+		Receptor:        *receptor,
+		CuerpoDocumento: []RetencionCuerpoItem{b.buildRetencionCuerpoItem(retention)},
+		Resumen: RetencionResumen{
+			TotalSujetoRetencion:   retention.MontoSujetoGrav,
+			TotalIVARetenido:       retention.IVARetenido,
+			TotalIVARetenidoLetras: b.numberToWords(retention.IVARetenido),
+		},
+		Extension: nil,
+		Apendice:  nil,
+	}, nil
+}
+
+func (b *Builder) buildRetencionEmisor(company *CompanyData, establishment *EstablishmentData) RetencionEmisor {
+	var nombreComercial *string
+	if company.NombreComercial != "" {
+		nombreComercial = &company.NombreComercial
+	}
+
+	return RetencionEmisor{
+		NIT:                 company.NIT,
+		NRC:                 fmt.Sprintf("%d", company.NCR),
+		Nombre:              company.Name,
+		CodActividad:        company.CodActividad,
+		DescActividad:       company.DescActividad,
+		NombreComercial:     nombreComercial,
+		TipoEstablecimiento: establishment.TipoEstablecimiento,
+		Direccion:           b.buildEmisorDireccion(establishment),
+		Telefono:            &establishment.Telefono,
+		CodigoMH:            nil,
+		Codigo:              &establishment.CodEstablecimiento,
+		PuntoVentaMH:        nil,
+		PuntoVenta:          &establishment.CodPuntoVenta,
+		Correo:              company.Email,
+	}
+}
+
+// buildRetencionReceptor builds the supplier section. Registered suppliers come
+// from the supplier registry; otherwise the snapshot stored on the purchase is used.
+func (b *Builder) buildRetencionReceptor(ctx context.Context, retention *models.Retention) (*RetencionReceptor, error) {
+	if retention.SupplierNIT == nil || *retention.SupplierNIT == "" {
+		return nil, fmt.Errorf("retention %s has no supplier NIT", retention.ID)
+	}
+
+	receptor := &RetencionReceptor{
+		TipoDocumento: "36",
+		NumDocumento:  *retention.SupplierNIT,
+		NRC:           retention.SupplierNRC,
+		Nombre:        retention.SupplierName,
+	}
+
+	var name, activityCode, activityDesc, dept, muni, complement, phone, email sql.NullString
+	if retention.HasSupplierID() {
+		query := `
+			SELECT name, activity_code, activity_desc,
+			       address_dept, address_muni, address_complement,
+			       phone, email
+			FROM suppliers
+			WHERE id = $1 AND company_id = $2
+		`
+		err := b.db.QueryRowContext(ctx, query, *retention.SupplierID, retention.CompanyID).Scan(
+			&name, &activityCode, &activityDesc,
+			&dept, &muni, &complement,
+			&phone, &email,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("load supplier: %w", err)
+		}
+		if name.Valid && name.String != "" {
+			receptor.NombreComercial = &name.String
+		}
+	} else {
+		query := `
+			SELECT supplier_activity_code, supplier_activity_desc,
+			       supplier_address_dept, supplier_address_muni, supplier_address_complement,
+			       supplier_phone, supplier_email
+			FROM purchases
+			WHERE id = $1 AND company_id = $2
+		`
+		err := b.db.QueryRowContext(ctx, query, retention.PurchaseID, retention.CompanyID).Scan(
+			&activityCode, &activityDesc,
+			&dept, &muni, &complement,
+			&phone, &email,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("query purchase supplier: %w", err)
+		}
+	}
+
+	receptor.CodActividad = activityCode.String
+	receptor.DescActividad = activityDesc.String
+	receptor.Direccion = Direccion{
+		Departamento: dept.String,
+		Municipio:    muni.String,
+		Complemento:  complement.String,
+	}
+	if phone.Valid && phone.String != "" {
+		receptor.Telefono = &phone.String
+	}
+	receptor.Correo = email.String
+
+	return receptor, nil
+}
+
+// buildRetencionCuerpoItem references the supplier DTE the retention applies to
+func (b *Builder) buildRetencionCuerpoItem(retention *models.Retention) RetencionCuerpoItem {
+	// Electronic documents are referenced by codigoGeneracion, physical ones by correlativo
+	tipoDoc := 1
+	numDocumento := retention.PurchaseNumeroControl
+	if strings.HasPrefix(strings.ToUpper(retention.PurchaseNumeroControl), "DTE-") {
+		tipoDoc = 2
+		numDocumento = strings.ToUpper(retention.PurchaseCodigoGeneracion)
+	}
+
+	return RetencionCuerpoItem{
+		NumItem:           1,
+		TipoDte:           retention.PurchaseTipoDte,
+		TipoDoc:           tipoDoc,
+		NumDocumento:      numDocumento,
+		FechaEmision:      retention.PurchaseFechaEmision.Format("2006-01-02"),
+		MontoSujetoGrav:   retention.MontoSujetoGrav,
+		CodigoRetencionMH: retention.RetentionCode,
+		IvaRetenido:       retention.IVARetenido,
+		Descripcion: fmt.Sprintf("Retención de IVA %s sobre documento %s",
+			retention.GetRetentionPercentage(), retention.PurchaseNumeroControl),
+	}
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -4,6 +4,8 @@ package dte
 import (
 	"math"
 	"testing"
+
+	"cuentas/internal/codigos"
 )
 
 func TestCalculateConsumidorFinal(t *testing.T) {
@@ -157,7 +159,7 @@ func TestCalculateResumenConsumidorFinal(t *testing.T) {
 		{PrecioUni: 11.30, VentaGravada: 11.30, IvaItem: 1.30},
 	}
 
-	result := calc.CalculateResumen(items, InvoiceTypeConsumidorFinal)
+	result := calc.CalculateResumen(items, codigos.PersonTypeNatural)
 
 	// For consumidor final: subTotal = totalGravada (NOT totalGravada + IVA!)
 	if result.TotalGravada != 11.30 {
@@ -181,7 +183,7 @@ func TestCalculateResumenCreditoFiscal(t *testing.T) {
 		{PrecioUni: 10.00, VentaGravada: 10.00, IvaItem: 1.30},
 	}
 
-	result := calc.CalculateResumen(items, InvoiceTypeCreditoFiscal)
+	result := calc.CalculateResumen(items, codigos.PersonTypeJuridica)
 
 	// For credito fiscal: subTotal = totalGravada + totalIva
 	if result.TotalGravada != 10.00 {
@@ -64,7 +64,7 @@ const (
 	TipoDteNotaDebito               = "06" // Nota de Débito
 	TipoDteCompRetencion            = "07" // Comprobante de Retención
 	TipoDteCompLiquidacion          = "08" // Comprobante de Liquidación
-	TipoDteDocContingencia          = "09" // Documento Contable de Liquidación
+	TipoDteDocContableLiquidacion   = "09" // Documento Contable de Liquidación
 	TipoDteFacturaExportacion       = "11" // Factura de Exportación
 	TipoDteFacturaSujetoExcluido    = "14" // Factura Sujeto Excluido
 	TipoDteCompDonacion             = "15" // Comprobante de Donación
@@ -169,4 +169,12 @@ var (
 	ErrNegativeVentaGravada = errors.New("venta gravada cannot be negative")
 	ErrNegativeIVA          = errors.New("IVA cannot be negative")
 	ErrInvalidInvoiceType   = errors.New("invalid invoice type")
+
+	// Invalidation errors
+	ErrDTENotFound            = errors.New("DTE not found or not accepted by Hacienda")
+	ErrDTEAlreadyInvalidated  = errors.New("DTE has already been invalidated")
+	ErrReplacementDTENotFound = errors.New("replacement DTE not found or not accepted by Hacienda")
+
+	// Transmission errors
+	ErrQueuedForContingency = errors.New("DTE queued for contingency")
 )
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"cuentas/internal/dte_schemas"
 	"cuentas/internal/models"
 
 	"github.com/google/uuid"
@@ -42,14 +43,16 @@ type IdentificacionEvento struct {
 
 // EmisorEvento - company info for event
 type EmisorEvento struct {
-	NIT                 string  `json:"nit"`
-	Nombre              string  `json:"nombre"`
-	NombreComercial     *string `json:"nombreComercial,omitempty"`
-	TipoEstablecimiento string  `json:"tipoEstablecimiento"`
-	CodEstablecimiento  *string `json:"codEstablecimiento,omitempty"`
-	CodPuntoVenta       *string `json:"codPuntoVenta,omitempty"`
-	Telefono            string  `json:"telefono"`
-	Correo              string  `json:"correo"`
+	NIT                  string  `json:"nit"`
+	Nombre               string  `json:"nombre"`
+	NombreResponsable    string  `json:"nombreResponsable"`
+	TipoDocResponsable   string  `json:"tipoDocResponsable"`
+	NumeroDocResponsable string  `json:"numeroDocResponsable"`
+	TipoEstablecimiento  string  `json:"tipoEstablecimiento"`
+	CodEstableMH         *string `json:"codEstableMH"`
+	CodPuntoVenta        *string `json:"codPuntoVenta"`
+	Telefono             string  `json:"telefono"`
+	Correo               string  `json:"correo"`
 }
 
 // DetalleDTEItem - individual DTE in the event
@@ -66,7 +69,7 @@ type MotivoContingencia struct {
 	HInicio            string  `json:"hInicio"`
 	HFin               string  `json:"hFin"`
 	TipoContingencia   int     `json:"tipoContingencia"`
-	MotivoContingencia *string `json:"motivoContingencia,omitempty"`
+	MotivoContingencia *string `json:"motivoContingencia"` // Required by schema v3, null unless tipo 5
 }
 
 // CompanyInfo holds company data needed for event building
@@ -80,8 +83,9 @@ type CompanyInfo struct {
 
 // EstablishmentInfo holds establishment data
 type EstablishmentInfo struct {
-	Codigo string
-	Tipo   string
+	Codigo   string
+	Tipo     string
+	Telefono string
 }
 
 // PointOfSaleInfo holds POS data
@@ -170,7 +174,14 @@ func (b *ContingencyEventBuilder) BuildEvent(
 		motivo.MotivoContingencia = period.MotivoContingencia
 	}
 
+	// Schema v3 requires a telephone of at least 8 characters
+	telefono := company.Telefono
+	if len(telefono) < 8 {
+		telefono = establishment.Telefono
+	}
+
 	// Build complete event
+	// The company itself is reported as responsible for the contingency
 	event := &EventoContingencia{
 		Identificacion: IdentificacionEvento{
 			Version:          3,
@@ -180,14 +191,16 @@ func (b *ContingencyEventBuilder) BuildEvent(
 			HTransmision:     now.Format("15:04:05"),
 		},
 		Emisor: EmisorEvento{
-			NIT:                 company.NIT,
-			Nombre:              company.Nombre,
-			NombreComercial:     company.NombreComercial,
-			TipoEstablecimiento: establishment.Tipo,
-			CodEstablecimiento:  &establishment.Codigo,
-			CodPuntoVenta:       &pos.Codigo,
-			Telefono:            company.Telefono,
-			Correo:              company.Correo,
+			NIT:                  company.NIT,
+			Nombre:               company.Nombre,
+			NombreResponsable:    company.Nombre,
+			TipoDocResponsable:   DocTypeNIT,
+			NumeroDocResponsable: company.NIT,
+			TipoEstablecimiento:  establishment.Tipo,
+			CodEstableMH:         nil,
+			CodPuntoVenta:        &pos.Codigo,
+			Telefono:             telefono,
+			Correo:               company.Correo,
 		},
 		DetalleDTE: detalleDTE,
 		Motivo:     motivo,
@@ -212,6 +225,10 @@ func (b *ContingencyEventBuilder) BuildEventJSON(
 		return nil, "", fmt.Errorf("failed to marshal event: %w", err)
 	}
 
+	if err := validateBeforeSigning(dte_schemas.SchemaContingencia, eventJSON); err != nil {
+		return nil, "", err
+	}
+
 	return eventJSON, codigoGeneracion, nil
 }
 
@@ -224,8 +241,9 @@ func (b *ContingencyEventBuilder) loadCompanyInfo(ctx context.Context, companyID
 	`
 
 	var company CompanyInfo
+	var nit int64
 	err := b.db.QueryRowContext(ctx, query, companyID).Scan(
-		&company.NIT,
+		&nit,
 		&company.Nombre,
 		&company.NombreComercial,
 		&company.Telefono,
@@ -235,6 +253,7 @@ func (b *ContingencyEventBuilder) loadCompanyInfo(ctx context.Context, companyID
 	if err != nil {
 		return nil, err
 	}
+	company.NIT = fmt.Sprintf("%014d", nit)
 
 	return &company, nil
 }
@@ -242,7 +261,7 @@ func (b *ContingencyEventBuilder) loadCompanyInfo(ctx context.Context, companyID
 // loadEstablishmentInfo loads establishment data
 func (b *ContingencyEventBuilder) loadEstablishmentInfo(ctx context.Context, establishmentID string) (*EstablishmentInfo, error) {
 	query := `
-		SELECT COALESCE(codigo_establecimiento, '0000'), COALESCE(tipo_establecimiento, '01')
+		SELECT cod_establecimiento, COALESCE(tipo_establecimiento, '01'), COALESCE(telefono, '')
 		FROM establishments
 		WHERE id = $1
 	`
@@ -251,6 +270,7 @@ func (b *ContingencyEventBuilder) loadEstablishmentInfo(ctx context.Context, est
 	err := b.db.QueryRowContext(ctx, query, establishmentID).Scan(
 		&est.Codigo,
 		&est.Tipo,
+		&est.Telefono,
 	)
 
 	if err != nil {
@@ -263,7 +283,7 @@ func (b *ContingencyEventBuilder) loadEstablishmentInfo(ctx context.Context, est
 // loadPointOfSaleInfo loads POS data
 func (b *ContingencyEventBuilder) loadPointOfSaleInfo(ctx context.Context, posID string) (*PointOfSaleInfo, error) {
 	query := `
-		SELECT COALESCE(codigo_punto_venta, '0000')
+		SELECT cod_punto_venta
 		FROM point_of_sale
 		WHERE id = $1
 	`
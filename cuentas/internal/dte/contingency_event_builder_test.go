@@ -0,0 +1,67 @@
+package dte
+
+import (
+	"encoding/json"
+	"testing"
+
+	"cuentas/internal/dte_schemas"
+)
+
+func TestEventoContingenciaMatchesSchema(t *testing.T) {
+	validator, err := dte_schemas.NewValidator()
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+
+	puntoVenta := "P001"
+	event := &EventoContingencia{
+		Identificacion: IdentificacionEvento{
+			Version:          3,
+			Ambiente:         "00",
+			CodigoGeneracion: "B1C2D3E4-0000-0000-0000-000000000000",
+			FTransmision:     "2025-11-16",
+			HTransmision:     "10:00:00",
+		},
+		Emisor: EmisorEvento{
+			NIT:                  "06142305911306",
+			Nombre:               "Comercial Ejemplo SA de CV",
+			NombreResponsable:    "Comercial Ejemplo SA de CV",
+			TipoDocResponsable:   DocTypeNIT,
+			NumeroDocResponsable: "06142305911306",
+			TipoEstablecimiento:  "02",
+			CodPuntoVenta:        &puntoVenta,
+			Telefono:             "2222-3333",
+			Correo:               "facturacion@example.com",
+		},
+		DetalleDTE: []DetalleDTEItem{
+			{NoItem: 1, CodigoGeneracion: "A1B2C3D4-0000-0000-0000-000000000000", TipoDoc: "01"},
+		},
+		Motivo: MotivoContingencia{
+			FInicio:          "2025-11-16",
+			FPeriodo:         "2025-11-16",
+			HInicio:          "08:00:00",
+			HFin:             "09:30:00",
+			TipoContingencia: 2,
+		},
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if err := validator.ValidateJSON(dte_schemas.SchemaContingencia, eventJSON); err != nil {
+		t.Errorf("schema validation failed: %v", err)
+	}
+
+	// Missing responsable must come back as a structured error list
+	event.Emisor.NombreResponsable = ""
+	eventJSON, _ = json.Marshal(event)
+	err = validator.ValidateJSON(dte_schemas.SchemaContingencia, eventJSON)
+	validationErr, ok := dte_schemas.AsSchemaValidationError(err)
+	if !ok {
+		t.Fatalf("expected *SchemaValidationError, got %v", err)
+	}
+	if validationErr.TipoDte != dte_schemas.SchemaContingencia || len(validationErr.Errors) == 0 {
+		t.Errorf("unexpected validation error: %+v", validationErr)
+	}
+}
@@ -0,0 +1,120 @@
+package dte
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+)
+
+// ContingencyHelperDCL provides contingency fallback for DCL (DTE 09) processing
+type ContingencyHelperDCL struct {
+	contingencyService *services.ContingencyService
+}
+
+// NewContingencyHelperDCL creates a new contingency helper for DCLs
+func NewContingencyHelperDCL(contingencyService *services.ContingencyService) *ContingencyHelperDCL {
+	return &ContingencyHelperDCL{
+		contingencyService: contingencyService,
+	}
+}
+
+// HandleSigningFailure queues a DCL when firmador fails
+func (h *ContingencyHelperDCL) HandleSigningFailure(
+	ctx context.Context,
+	dcl *models.DCL,
+	dteUnsigned interface{},
+	ambiente string,
+) error {
+	log.Printf("[ContingencyHelperDCL] Handling signing failure for DCL %s", dcl.ID)
+
+	// Marshal unsigned DTE to JSON
+	dteJSON, err := json.Marshal(dteUnsigned)
+	if err != nil {
+		return fmt.Errorf("failed to marshal unsigned DTE: %w", err)
+	}
+
+	// Queue for contingency (no signature)
+	err = h.contingencyService.QueueDCLForContingency(
+		ctx,
+		dcl,
+		"firmador_failed",
+		dteJSON,
+		nil, // No signature
+		ambiente,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to queue for contingency: %w", err)
+	}
+
+	log.Printf("[ContingencyHelperDCL] ✅ DCL %s queued for contingency (firmador failed)", dcl.ID)
+	return nil
+}
+
+// HandleAuthFailure queues a DCL when Hacienda auth fails
+func (h *ContingencyHelperDCL) HandleAuthFailure(
+	ctx context.Context,
+	dcl *models.DCL,
+	dteUnsigned interface{},
+	signedDTE string,
+	ambiente string,
+) error {
+	log.Printf("[ContingencyHelperDCL] Handling auth failure for DCL %s", dcl.ID)
+
+	dteJSON, err := json.Marshal(dteUnsigned)
+	if err != nil {
+		return fmt.Errorf("failed to marshal unsigned DTE: %w", err)
+	}
+
+	err = h.contingencyService.QueueDCLForContingency(
+		ctx,
+		dcl,
+		"hacienda_auth_failed",
+		dteJSON,
+		&signedDTE,
+		ambiente,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to queue for contingency: %w", err)
+	}
+
+	log.Printf("[ContingencyHelperDCL] ✅ DCL %s queued for contingency (auth failed)", dcl.ID)
+	return nil
+}
+
+// HandleSubmissionFailure queues a DCL when Hacienda submission fails
+func (h *ContingencyHelperDCL) HandleSubmissionFailure(
+	ctx context.Context,
+	dcl *models.DCL,
+	dteUnsigned interface{},
+	signedDTE string,
+	ambiente string,
+) error {
+	log.Printf("[ContingencyHelperDCL] Handling submission failure for DCL %s", dcl.ID)
+
+	dteJSON, err := json.Marshal(dteUnsigned)
+	if err != nil {
+		return fmt.Errorf("failed to marshal unsigned DTE: %w", err)
+	}
+
+	err = h.contingencyService.QueueDCLForContingency(
+		ctx,
+		dcl,
+		"hacienda_timeout",
+		dteJSON,
+		&signedDTE,
+		ambiente,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to queue for contingency: %w", err)
+	}
+
+	log.Printf("[ContingencyHelperDCL] ✅ DCL %s queued for contingency (submission failed)", dcl.ID)
+	return nil
+}
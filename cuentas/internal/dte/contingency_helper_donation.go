@@ -0,0 +1,120 @@
+package dte
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+)
+
+// ContingencyHelperDonation provides contingency fallback for Comprobante de Donación (DTE 15) processing
+type ContingencyHelperDonation struct {
+	contingencyService *services.ContingencyService
+}
+
+// NewContingencyHelperDonation creates a new contingency helper for donation receipts
+func NewContingencyHelperDonation(contingencyService *services.ContingencyService) *ContingencyHelperDonation {
+	return &ContingencyHelperDonation{
+		contingencyService: contingencyService,
+	}
+}
+
+// HandleSigningFailure queues a donation receipt when firmador fails
+func (h *ContingencyHelperDonation) HandleSigningFailure(
+	ctx context.Context,
+	donation *models.Donation,
+	dteUnsigned interface{},
+	ambiente string,
+) error {
+	log.Printf("[ContingencyHelperDonation] Handling signing failure for donation %s", donation.ID)
+
+	// Marshal unsigned DTE to JSON
+	dteJSON, err := json.Marshal(dteUnsigned)
+	if err != nil {
+		return fmt.Errorf("failed to marshal unsigned DTE: %w", err)
+	}
+
+	// Queue for contingency (no signature)
+	err = h.contingencyService.QueueDonationForContingency(
+		ctx,
+		donation,
+		"firmador_failed",
+		dteJSON,
+		nil, // No signature
+		ambiente,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to queue for contingency: %w", err)
+	}
+
+	log.Printf("[ContingencyHelperDonation] ✅ Donation %s queued for contingency (firmador failed)", donation.ID)
+	return nil
+}
+
+// HandleAuthFailure queues a donation receipt when Hacienda auth fails
+func (h *ContingencyHelperDonation) HandleAuthFailure(
+	ctx context.Context,
+	donation *models.Donation,
+	dteUnsigned interface{},
+	signedDTE string,
+	ambiente string,
+) error {
+	log.Printf("[ContingencyHelperDonation] Handling auth failure for donation %s", donation.ID)
+
+	dteJSON, err := json.Marshal(dteUnsigned)
+	if err != nil {
+		return fmt.Errorf("failed to marshal unsigned DTE: %w", err)
+	}
+
+	err = h.contingencyService.QueueDonationForContingency(
+		ctx,
+		donation,
+		"hacienda_auth_failed",
+		dteJSON,
+		&signedDTE,
+		ambiente,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to queue for contingency: %w", err)
+	}
+
+	log.Printf("[ContingencyHelperDonation] ✅ Donation %s queued for contingency (auth failed)", donation.ID)
+	return nil
+}
+
+// HandleSubmissionFailure queues a donation receipt when Hacienda submission fails
+func (h *ContingencyHelperDonation) HandleSubmissionFailure(
+	ctx context.Context,
+	donation *models.Donation,
+	dteUnsigned interface{},
+	signedDTE string,
+	ambiente string,
+) error {
+	log.Printf("[ContingencyHelperDonation] Handling submission failure for donation %s", donation.ID)
+
+	dteJSON, err := json.Marshal(dteUnsigned)
+	if err != nil {
+		return fmt.Errorf("failed to marshal unsigned DTE: %w", err)
+	}
+
+	err = h.contingencyService.QueueDonationForContingency(
+		ctx,
+		donation,
+		"hacienda_timeout",
+		dteJSON,
+		&signedDTE,
+		ambiente,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to queue for contingency: %w", err)
+	}
+
+	log.Printf("[ContingencyHelperDonation] ✅ Donation %s queued for contingency (submission failed)", donation.ID)
+	return nil
+}
@@ -0,0 +1,120 @@
+package dte
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+)
+
+// ContingencyHelperLiquidacion provides contingency fallback for Liquidación (DTE 08) processing
+type ContingencyHelperLiquidacion struct {
+	contingencyService *services.ContingencyService
+}
+
+// NewContingencyHelperLiquidacion creates a new contingency helper for liquidaciones
+func NewContingencyHelperLiquidacion(contingencyService *services.ContingencyService) *ContingencyHelperLiquidacion {
+	return &ContingencyHelperLiquidacion{
+		contingencyService: contingencyService,
+	}
+}
+
+// HandleSigningFailure queues a liquidación when firmador fails
+func (h *ContingencyHelperLiquidacion) HandleSigningFailure(
+	ctx context.Context,
+	liquidacion *models.Liquidacion,
+	dteUnsigned interface{},
+	ambiente string,
+) error {
+	log.Printf("[ContingencyHelperLiquidacion] Handling signing failure for liquidación %s", liquidacion.ID)
+
+	// Marshal unsigned DTE to JSON
+	dteJSON, err := json.Marshal(dteUnsigned)
+	if err != nil {
+		return fmt.Errorf("failed to marshal unsigned DTE: %w", err)
+	}
+
+	// Queue for contingency (no signature)
+	err = h.contingencyService.QueueLiquidacionForContingency(
+		ctx,
+		liquidacion,
+		"firmador_failed",
+		dteJSON,
+		nil, // No signature
+		ambiente,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to queue for contingency: %w", err)
+	}
+
+	log.Printf("[ContingencyHelperLiquidacion] ✅ Liquidación %s queued for contingency (firmador failed)", liquidacion.ID)
+	return nil
+}
+
+// HandleAuthFailure queues a liquidación when Hacienda auth fails
+func (h *ContingencyHelperLiquidacion) HandleAuthFailure(
+	ctx context.Context,
+	liquidacion *models.Liquidacion,
+	dteUnsigned interface{},
+	signedDTE string,
+	ambiente string,
+) error {
+	log.Printf("[ContingencyHelperLiquidacion] Handling auth failure for liquidación %s", liquidacion.ID)
+
+	dteJSON, err := json.Marshal(dteUnsigned)
+	if err != nil {
+		return fmt.Errorf("failed to marshal unsigned DTE: %w", err)
+	}
+
+	err = h.contingencyService.QueueLiquidacionForContingency(
+		ctx,
+		liquidacion,
+		"hacienda_auth_failed",
+		dteJSON,
+		&signedDTE,
+		ambiente,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to queue for contingency: %w", err)
+	}
+
+	log.Printf("[ContingencyHelperLiquidacion] ✅ Liquidación %s queued for contingency (auth failed)", liquidacion.ID)
+	return nil
+}
+
+// HandleSubmissionFailure queues a liquidación when Hacienda submission fails
+func (h *ContingencyHelperLiquidacion) HandleSubmissionFailure(
+	ctx context.Context,
+	liquidacion *models.Liquidacion,
+	dteUnsigned interface{},
+	signedDTE string,
+	ambiente string,
+) error {
+	log.Printf("[ContingencyHelperLiquidacion] Handling submission failure for liquidación %s", liquidacion.ID)
+
+	dteJSON, err := json.Marshal(dteUnsigned)
+	if err != nil {
+		return fmt.Errorf("failed to marshal unsigned DTE: %w", err)
+	}
+
+	err = h.contingencyService.QueueLiquidacionForContingency(
+		ctx,
+		liquidacion,
+		"hacienda_timeout",
+		dteJSON,
+		&signedDTE,
+		ambiente,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to queue for contingency: %w", err)
+	}
+
+	log.Printf("[ContingencyHelperLiquidacion] ✅ Liquidación %s queued for contingency (submission failed)", liquidacion.ID)
+	return nil
+}
@@ -0,0 +1,120 @@
+package dte
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+)
+
+// ContingencyHelperRetention provides contingency fallback for Retention (DTE 07) processing
+type ContingencyHelperRetention struct {
+	contingencyService *services.ContingencyService
+}
+
+// NewContingencyHelperRetention creates a new contingency helper for retentions
+func NewContingencyHelperRetention(contingencyService *services.ContingencyService) *ContingencyHelperRetention {
+	return &ContingencyHelperRetention{
+		contingencyService: contingencyService,
+	}
+}
+
+// HandleSigningFailure queues a retention when firmador fails
+func (h *ContingencyHelperRetention) HandleSigningFailure(
+	ctx context.Context,
+	retention *models.Retention,
+	dteUnsigned interface{},
+	ambiente string,
+) error {
+	log.Printf("[ContingencyHelperRetention] Handling signing failure for retention %s", retention.ID)
+
+	// Marshal unsigned DTE to JSON
+	dteJSON, err := json.Marshal(dteUnsigned)
+	if err != nil {
+		return fmt.Errorf("failed to marshal unsigned DTE: %w", err)
+	}
+
+	// Queue for contingency (no signature)
+	err = h.contingencyService.QueueRetentionForContingency(
+		ctx,
+		retention,
+		"firmador_failed",
+		dteJSON,
+		nil, // No signature
+		ambiente,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to queue for contingency: %w", err)
+	}
+
+	log.Printf("[ContingencyHelperRetention] ✅ Retention %s queued for contingency (firmador failed)", retention.ID)
+	return nil
+}
+
+// HandleAuthFailure queues a retention when Hacienda auth fails
+func (h *ContingencyHelperRetention) HandleAuthFailure(
+	ctx context.Context,
+	retention *models.Retention,
+	dteUnsigned interface{},
+	signedDTE string,
+	ambiente string,
+) error {
+	log.Printf("[ContingencyHelperRetention] Handling auth failure for retention %s", retention.ID)
+
+	dteJSON, err := json.Marshal(dteUnsigned)
+	if err != nil {
+		return fmt.Errorf("failed to marshal unsigned DTE: %w", err)
+	}
+
+	err = h.contingencyService.QueueRetentionForContingency(
+		ctx,
+		retention,
+		"hacienda_auth_failed",
+		dteJSON,
+		&signedDTE,
+		ambiente,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to queue for contingency: %w", err)
+	}
+
+	log.Printf("[ContingencyHelperRetention] ✅ Retention %s queued for contingency (auth failed)", retention.ID)
+	return nil
+}
+
+// HandleSubmissionFailure queues a retention when Hacienda submission fails
+func (h *ContingencyHelperRetention) HandleSubmissionFailure(
+	ctx context.Context,
+	retention *models.Retention,
+	dteUnsigned interface{},
+	signedDTE string,
+	ambiente string,
+) error {
+	log.Printf("[ContingencyHelperRetention] Handling submission failure for retention %s", retention.ID)
+
+	dteJSON, err := json.Marshal(dteUnsigned)
+	if err != nil {
+		return fmt.Errorf("failed to marshal unsigned DTE: %w", err)
+	}
+
+	err = h.contingencyService.QueueRetentionForContingency(
+		ctx,
+		retention,
+		"hacienda_timeout",
+		dteJSON,
+		&signedDTE,
+		ambiente,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to queue for contingency: %w", err)
+	}
+
+	log.Printf("[ContingencyHelperRetention] ✅ Retention %s queued for contingency (submission failed)", retention.ID)
+	return nil
+}
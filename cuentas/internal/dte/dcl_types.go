@@ -0,0 +1,78 @@
+package dte
+
+// ============================================
+// TYPE 09 - DOCUMENTO CONTABLE DE LIQUIDACIÓN TYPES
+// ============================================
+
+// DocumentoContableLiquidacion represents a complete Documento Contable de Liquidación (Type 09) DTE
+type DocumentoContableLiquidacion struct {
+	Identificacion  LiquidacionIdentificacion `json:"identificacion"` // Same shape as Type 08 (no contingency fields)
+	Emisor          DCLEmisor                 `json:"emisor"`
+	Receptor        DCLReceptor               `json:"receptor"`
+	CuerpoDocumento DCLCuerpoDocumento        `json:"cuerpoDocumento"`
+	Extension       DCLExtension              `json:"extension"`
+	Apendice        *[]Apendice               `json:"apendice"`
+}
+
+// DCLEmisor is the card processor / collection agent (our company)
+// NOTE: Type 09 uses codigoMH/codigo/puntoVentaMH/puntoVentaContri
+type DCLEmisor struct {
+	NIT                 string    `json:"nit"`
+	NRC                 string    `json:"nrc"`
+	Nombre              string    `json:"nombre"`
+	CodActividad        string    `json:"codActividad"`
+	DescActividad       string    `json:"descActividad"`
+	NombreComercial     *string   `json:"nombreComercial"`
+	TipoEstablecimiento string    `json:"tipoEstablecimiento"`
+	Telefono            string    `json:"telefono"`
+	Correo              string    `json:"correo"`
+	Direccion           Direccion `json:"direccion"`
+	CodigoMH            *string   `json:"codigoMH"`
+	Codigo              *string   `json:"codigo"`
+	PuntoVentaMH        *string   `json:"puntoVentaMH"`
+	PuntoVentaContri    *string   `json:"puntoVentaContri"`
+}
+
+// DCLReceptor is the affiliate whose operations are being settled
+type DCLReceptor struct {
+	NIT                 string    `json:"nit"`
+	NRC                 string    `json:"nrc"`
+	Nombre              string    `json:"nombre"`
+	CodActividad        string    `json:"codActividad"`
+	DescActividad       string    `json:"descActividad"`
+	NombreComercial     *string   `json:"nombreComercial"`
+	TipoEstablecimiento string    `json:"tipoEstablecimiento"`
+	Direccion           Direccion `json:"direccion"`
+	Telefono            *string   `json:"telefono"`
+	Correo              string    `json:"correo"`
+	CodigoMH            *string   `json:"codigoMH"`
+	PuntoVentaMH        *string   `json:"puntoVentaMH"`
+}
+
+// DCLCuerpoDocumento holds the settlement amounts (a single object, not an item list)
+type DCLCuerpoDocumento struct {
+	PeriodoLiquidacionFechaInicio string  `json:"periodoLiquidacionFechaInicio"`
+	PeriodoLiquidacionFechaFin    string  `json:"periodoLiquidacionFechaFin"`
+	CodLiquidacion                *string `json:"codLiquidacion"`
+	CantidadDoc                   *int    `json:"cantidadDoc"`
+	ValorOperaciones              float64 `json:"valorOperaciones"`
+	MontoSinPercepcion            float64 `json:"montoSinPercepcion"`
+	DescripSinPercepcion          *string `json:"descripSinPercepcion"`
+	SubTotal                      float64 `json:"subTotal"`
+	IVA                           float64 `json:"iva"`
+	MontoSujetoPercepcion         float64 `json:"montoSujetoPercepcion"`
+	IVAPercibido                  float64 `json:"ivaPercibido"`
+	Comision                      float64 `json:"comision"`
+	PorcentComision               *string `json:"porcentComision"`
+	IVAComision                   float64 `json:"ivaComision"`
+	LiquidoAPagar                 float64 `json:"liquidoApagar"`
+	TotalLetras                   string  `json:"totalLetras"`
+	Observaciones                 *string `json:"observaciones"`
+}
+
+// DCLExtension identifies who generated the liquidation (required for Type 09)
+type DCLExtension struct {
+	NombEntrega string  `json:"nombEntrega"`
+	DocuEntrega string  `json:"docuEntrega"`
+	CodEmpleado *string `json:"codEmpleado"`
+}
@@ -0,0 +1,85 @@
+package dte
+
+// ============================================
+// TYPE 15 - COMPROBANTE DE DONACIÓN TYPES
+// ============================================
+
+// ComprobanteDonacion represents a complete Comprobante de Donación (Type 15) DTE
+type ComprobanteDonacion struct {
+	Identificacion  LiquidacionIdentificacion `json:"identificacion"` // Same shape as Type 08 (no contingency fields)
+	Donatario       DonacionDonatario         `json:"donatario"`
+	Donante         DonacionDonante           `json:"donante"`
+	OtrosDocumentos []DonacionOtroDocumento   `json:"otrosDocumentos"`
+	CuerpoDocumento []DonacionCuerpoItem      `json:"cuerpoDocumento"`
+	Resumen         DonacionResumen           `json:"resumen"`
+	Apendice        *[]Apendice               `json:"apendice"`
+}
+
+// DonacionDonatario is the non-profit receiving the donation (our company, the issuer)
+// NOTE: Type 15 identifies the issuer by tipoDocumento/numDocumento instead of nit
+type DonacionDonatario struct {
+	TipoDocumento       string    `json:"tipoDocumento"` // Always "36" (NIT)
+	NumDocumento        string    `json:"numDocumento"`
+	NRC                 *string   `json:"nrc"`
+	Nombre              string    `json:"nombre"`
+	CodActividad        string    `json:"codActividad"`
+	DescActividad       string    `json:"descActividad"`
+	NombreComercial     *string   `json:"nombreComercial"`
+	TipoEstablecimiento string    `json:"tipoEstablecimiento"`
+	Direccion           Direccion `json:"direccion"`
+	Telefono            string    `json:"telefono"`
+	Correo              string    `json:"correo"`
+	CodEstableMH        *string   `json:"codEstableMH"`
+	CodEstable          *string   `json:"codEstable"`
+	CodPuntoVentaMH     *string   `json:"codPuntoVentaMH"`
+	CodPuntoVenta       *string   `json:"codPuntoVenta"`
+}
+
+// DonacionDonante is the donor
+type DonacionDonante struct {
+	TipoDocumento  string     `json:"tipoDocumento"`
+	NumDocumento   string     `json:"numDocumento"`
+	NRC            *string    `json:"nrc"`
+	Nombre         string     `json:"nombre"`
+	CodActividad   *string    `json:"codActividad"`
+	DescActividad  *string    `json:"descActividad"`
+	Direccion      *Direccion `json:"direccion"` // Required when domiciled, null otherwise
+	Telefono       *string    `json:"telefono"`
+	Correo         *string    `json:"correo"`
+	CodDomiciliado int        `json:"codDomiciliado"`
+	CodPais        string     `json:"codPais"`
+}
+
+// DonacionOtroDocumento is an associated document (required, 1-10 entries)
+type DonacionOtroDocumento struct {
+	CodDocAsociado   int    `json:"codDocAsociado"`
+	DescDocumento    string `json:"descDocumento"`
+	DetalleDocumento string `json:"detalleDocumento"`
+}
+
+// DonacionCuerpoItem is one donated line
+type DonacionCuerpoItem struct {
+	NumItem      int     `json:"numItem"`
+	TipoDonacion int     `json:"tipoDonacion"`
+	Cantidad     float64 `json:"cantidad"`
+	Codigo       *string `json:"codigo"`
+	UniMedida    int     `json:"uniMedida"`
+	Descripcion  string  `json:"descripcion"`
+	Depreciacion float64 `json:"depreciacion"`
+	ValorUni     float64 `json:"valorUni"`
+	Valor        float64 `json:"valor"`
+}
+
+// DonacionResumen holds the donation total and the cash payments
+type DonacionResumen struct {
+	ValorTotal  float64         `json:"valorTotal"`
+	TotalLetras string          `json:"totalLetras"`
+	Pagos       *[]DonacionPago `json:"pagos"` // Only when cash was donated
+}
+
+// DonacionPago is how a cash donation was received
+type DonacionPago struct {
+	Codigo     *string `json:"codigo"`
+	MontoPago  float64 `json:"montoPago"`
+	Referencia *string `json:"referencia"`
+}
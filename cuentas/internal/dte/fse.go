@@ -3,7 +3,6 @@ package dte
 
 import (
 	"context"
-	"cuentas/internal/dte_schemas"
 	"cuentas/internal/models"
 	"encoding/json"
 	"fmt"
@@ -53,15 +52,7 @@ func (b *Builder) BuildFSE(ctx context.Context, purchase *models.Purchase) ([]by
 		return nil, fmt.Errorf("marshal JSON: %w", err)
 	}
 
-	// Validate JSON against schema
-	log.Printf("[BuildFSE] Validating DTE against schema...")
-	if err := dte_schemas.Validate("14", jsonBytes); err != nil {
-		log.Printf("WARNING: [BuildFSE] ❌ Schema validation failed: %v", err)
-		// Don't fail on validation error, just warn
-	} else {
-		log.Printf("[BuildFSE] ✅ Schema validation passed")
-	}
-
+	// Schema validation is enforced by ProcessFSE before signing
 	return jsonBytes, nil
 }
 
@@ -0,0 +1,79 @@
+package dte
+
+// ============================================
+// EVENTO DE INVALIDACIÓN (ANULACIÓN) TYPES
+// ============================================
+
+// Invalidacion represents an Evento de Invalidación (anulacion-schema-v2)
+type Invalidacion struct {
+	Identificacion InvalidacionIdentificacion `json:"identificacion"`
+	Emisor         InvalidacionEmisor         `json:"emisor"`
+	Documento      InvalidacionDocumento      `json:"documento"`
+	Motivo         InvalidacionMotivo         `json:"motivo"`
+}
+
+// InvalidacionIdentificacion - the event uses version 2
+type InvalidacionIdentificacion struct {
+	Version          int    `json:"version"` // Always 2
+	Ambiente         string `json:"ambiente"`
+	CodigoGeneracion string `json:"codigoGeneracion"`
+	FecAnula         string `json:"fecAnula"` // YYYY-MM-DD
+	HorAnula         string `json:"horAnula"` // HH:MM:SS
+}
+
+type InvalidacionEmisor struct {
+	NIT                 string  `json:"nit"`
+	Nombre              string  `json:"nombre"`
+	TipoEstablecimiento string  `json:"tipoEstablecimiento"`
+	NomEstablecimiento  *string `json:"nomEstablecimiento"`
+	CodEstableMH        *string `json:"codEstableMH"`
+	CodEstable          *string `json:"codEstable"`
+	CodPuntoVentaMH     *string `json:"codPuntoVentaMH"`
+	CodPuntoVenta       *string `json:"codPuntoVenta"`
+	Telefono            *string `json:"telefono"`
+	Correo              string  `json:"correo"`
+}
+
+// InvalidacionDocumento describes the DTE being invalidated
+type InvalidacionDocumento struct {
+	TipoDte           string   `json:"tipoDte"`
+	CodigoGeneracion  string   `json:"codigoGeneracion"`
+	SelloRecibido     string   `json:"selloRecibido"`
+	NumeroControl     string   `json:"numeroControl"`
+	FecEmi            string   `json:"fecEmi"`
+	MontoIva          *float64 `json:"montoIva"`
+	CodigoGeneracionR *string  `json:"codigoGeneracionR"`
+	TipoDocumento     string   `json:"tipoDocumento"`
+	NumDocumento      string   `json:"numDocumento"`
+	Nombre            string   `json:"nombre"`
+	Telefono          *string  `json:"telefono,omitempty"`
+	Correo            *string  `json:"correo,omitempty"`
+}
+
+type InvalidacionMotivo struct {
+	TipoAnulacion     int     `json:"tipoAnulacion"`
+	MotivoAnulacion   *string `json:"motivoAnulacion"`
+	NombreResponsable string  `json:"nombreResponsable"`
+	TipDocResponsable string  `json:"tipDocResponsable"`
+	NumDocResponsable string  `json:"numDocResponsable"`
+	NombreSolicita    string  `json:"nombreSolicita"`
+	TipDocSolicita    string  `json:"tipDocSolicita"`
+	NumDocSolicita    string  `json:"numDocSolicita"`
+}
+
+// InvalidationSource is the accepted DTE being invalidated, loaded from dte_commit_log
+type InvalidationSource struct {
+	CompanyID        string
+	CodigoGeneracion string
+	TipoDte          string
+	NumeroControl    string
+	SelloRecibido    string
+	FechaEmision     string // YYYY-MM-DD
+	Ambiente         string
+	EstablishmentID  string
+	PointOfSaleID    string
+	InvoiceID        *string
+	PurchaseID       *string
+	IVAAmount        float64
+	DteUnsigned      []byte
+}
@@ -0,0 +1,84 @@
+package dte
+
+// ============================================
+// TYPE 08 - COMPROBANTE DE LIQUIDACIÓN TYPES
+// ============================================
+
+// ComprobanteLiquidacion represents a complete Comprobante de Liquidación (Type 08) DTE
+type ComprobanteLiquidacion struct {
+	Identificacion  LiquidacionIdentificacion `json:"identificacion"`
+	Emisor          Emisor                    `json:"emisor"`
+	Receptor        LiquidacionReceptor       `json:"receptor"`
+	CuerpoDocumento []LiquidacionCuerpoItem   `json:"cuerpoDocumento"`
+	Resumen         LiquidacionResumen        `json:"resumen"`
+	Extension       *LiquidacionExtension     `json:"extension"`
+	Apendice        *[]Apendice               `json:"apendice"`
+}
+
+// LiquidacionIdentificacion is the identification section for Type 08
+// NOTE: Type 08 has no tipoContingencia/motivoContin fields
+type LiquidacionIdentificacion struct {
+	Version          int    `json:"version"`
+	Ambiente         string `json:"ambiente"`
+	TipoDte          string `json:"tipoDte"`
+	NumeroControl    string `json:"numeroControl"`
+	CodigoGeneracion string `json:"codigoGeneracion"`
+	TipoModelo       int    `json:"tipoModelo"`
+	TipoOperacion    int    `json:"tipoOperacion"`
+	FecEmi           string `json:"fecEmi"` // YYYY-MM-DD
+	HorEmi           string `json:"horEmi"` // HH:MM:SS
+	TipoMoneda       string `json:"tipoMoneda"`
+}
+
+// LiquidacionReceptor is the third-party seller (must be a contributor)
+type LiquidacionReceptor struct {
+	NIT             string    `json:"nit"`
+	NRC             string    `json:"nrc"`
+	Nombre          string    `json:"nombre"`
+	CodActividad    string    `json:"codActividad"`
+	DescActividad   string    `json:"descActividad"`
+	NombreComercial *string   `json:"nombreComercial"`
+	Direccion       Direccion `json:"direccion"`
+	Telefono        *string   `json:"telefono"`
+	Correo          string    `json:"correo"`
+}
+
+// LiquidacionCuerpoItem references one DTE summarized by the liquidación
+type LiquidacionCuerpoItem struct {
+	NumItem         int      `json:"numItem"`
+	TipoDte         string   `json:"tipoDte"`         // "01", "03", "05", "06" or "11"
+	TipoGeneracion  int      `json:"tipoGeneracion"`  // 1 = Físico, 2 = Electrónico
+	NumeroDocumento string   `json:"numeroDocumento"` // codigoGeneracion (electrónico) or correlativo (físico)
+	FechaGeneracion string   `json:"fechaGeneracion"`
+	VentaNoSuj      float64  `json:"ventaNoSuj"`
+	VentaExenta     float64  `json:"ventaExenta"`
+	VentaGravada    float64  `json:"ventaGravada"`
+	Exportaciones   float64  `json:"exportaciones"`
+	Tributos        []string `json:"tributos"`
+	IvaItem         float64  `json:"ivaItem"`
+	ObsItem         string   `json:"obsItem"`
+}
+
+// LiquidacionResumen represents the liquidation totals
+type LiquidacionResumen struct {
+	TotalNoSuj          float64    `json:"totalNoSuj"`
+	TotalExenta         float64    `json:"totalExenta"`
+	TotalGravada        float64    `json:"totalGravada"`
+	TotalExportacion    float64    `json:"totalExportacion"`
+	SubTotalVentas      float64    `json:"subTotalVentas"`
+	Tributos            *[]Tributo `json:"tributos"`
+	MontoTotalOperacion float64    `json:"montoTotalOperacion"`
+	IvaPerci            float64    `json:"ivaPerci"`
+	Total               float64    `json:"total"`
+	TotalLetras         string     `json:"totalLetras"`
+	CondicionOperacion  int        `json:"condicionOperacion"`
+}
+
+// LiquidacionExtension holds optional delivery information
+type LiquidacionExtension struct {
+	NombEntrega   *string `json:"nombEntrega"`
+	DocuEntrega   *string `json:"docuEntrega"`
+	NombRecibe    *string `json:"nombRecibe"`
+	DocuRecibe    *string `json:"docuRecibe"`
+	Observaciones *string `json:"observaciones"`
+}
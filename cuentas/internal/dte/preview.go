@@ -0,0 +1,270 @@
+package dte
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"cuentas/internal/codigos"
+	"cuentas/internal/dte_schemas"
+	"cuentas/internal/models"
+)
+
+// ============================================
+// DTE PREVIEW (DRY RUN)
+// ============================================
+//
+// A preview runs the same builder as the Process* path and then the schema
+// and calculator checks, but stops there: nothing is signed, transmitted or
+// persisted. The caller supplies a provisional numero control on the draft
+// record, so dte_sequences is never touched.
+
+// previewTolerance is the rounding difference Hacienda accepts between amounts
+const previewTolerance = 0.01
+
+// PreviewWarning is a problem found while previewing a DTE
+type PreviewWarning struct {
+	Source  string `json:"source"` // "schema" or "calculator"
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// PreviewResult is the document that would be signed plus the checks run on it
+type PreviewResult struct {
+	TipoDte          string           `json:"tipo_dte"`
+	NumeroControl    string           `json:"numero_control"` // Provisional; assigned for real on finalize
+	CodigoGeneracion string           `json:"codigo_generacion"`
+	Valid            bool             `json:"valid"`
+	Warnings         []PreviewWarning `json:"warnings"`
+	Document         json.RawMessage  `json:"document"`
+
+	schemaErr *dte_schemas.SchemaValidationError
+}
+
+// SchemaError returns the schema violations the Process* path would reject the
+// document with, or nil when it passes its schema
+func (r *PreviewResult) SchemaError() error {
+	if r.schemaErr == nil {
+		return nil
+	}
+	return r.schemaErr
+}
+
+// PreviewInvoice builds the Type 01/03 DTE for a draft invoice
+func (s *DTEService) PreviewInvoice(ctx context.Context, invoice *models.Invoice) (*PreviewResult, error) {
+	factura, err := s.builder.BuildFromInvoice(ctx, invoice)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build DTE: %w", err)
+	}
+	factura.Identificacion.CodigoGeneracion = strings.ToUpper(factura.Identificacion.CodigoGeneracion)
+
+	dteJSON, err := json.Marshal(factura)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal DTE: %w", err)
+	}
+
+	return buildPreviewResult(factura.Identificacion.TipoDte, dteJSON)
+}
+
+// PreviewExportInvoice builds the Type 11 DTE for a draft export invoice
+func (s *DTEService) PreviewExportInvoice(ctx context.Context, invoice *models.Invoice) (*PreviewResult, error) {
+	dteJSON, err := s.builder.BuildFacturaExportacion(ctx, invoice)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build export DTE: %w", err)
+	}
+	return buildPreviewResult(TipoDteFacturaExportacion, dteJSON)
+}
+
+// PreviewRemision builds the Type 04 DTE for a draft nota de remisión
+func (s *DTEService) PreviewRemision(ctx context.Context, remision *models.Invoice) (*PreviewResult, error) {
+	dteJSON, err := s.builder.BuildNotaRemision(ctx, remision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build remision DTE: %w", err)
+	}
+	return buildPreviewResult(codigos.DocTypeNotaRemision, dteJSON)
+}
+
+// PreviewNotaDebito builds the Type 06 DTE for a draft nota de débito
+func (s *DTEService) PreviewNotaDebito(ctx context.Context, nota *models.NotaDebito) (*PreviewResult, error) {
+	dteJSON, err := s.builder.BuildNotaDebito(ctx, nota)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build nota de débito DTE: %w", err)
+	}
+	return buildPreviewResult(codigos.DocTypeNotaDebito, dteJSON)
+}
+
+// PreviewNotaCredito builds the Type 05 DTE for a draft nota de crédito
+func (s *DTEService) PreviewNotaCredito(ctx context.Context, nota *models.NotaCredito) (*PreviewResult, error) {
+	dteJSON, err := s.builder.BuildNotaCredito(ctx, nota)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build nota de crédito DTE: %w", err)
+	}
+	return buildPreviewResult(codigos.DocTypeNotaCredito, dteJSON)
+}
+
+// PreviewFSE builds the Type 14 DTE for a draft sujeto excluido purchase
+func (s *DTEService) PreviewFSE(ctx context.Context, purchase *models.Purchase) (*PreviewResult, error) {
+	dteJSON, err := s.builder.BuildFSE(ctx, purchase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build FSE DTE: %w", err)
+	}
+	return buildPreviewResult(codigos.DocTypeFacturaSujetoExcluido, dteJSON)
+}
+
+// buildPreviewResult runs the schema and calculator checks on a built document.
+// Schema violations become warnings instead of errors; only a failure to run
+// the checks at all is returned as an error.
+func buildPreviewResult(tipoDte string, dteJSON []byte) (*PreviewResult, error) {
+	var header struct {
+		Identificacion struct {
+			NumeroControl    string `json:"numeroControl"`
+			CodigoGeneracion string `json:"codigoGeneracion"`
+		} `json:"identificacion"`
+	}
+	if err := json.Unmarshal(dteJSON, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse built DTE: %w", err)
+	}
+
+	result := &PreviewResult{
+		TipoDte:          tipoDte,
+		NumeroControl:    header.Identificacion.NumeroControl,
+		CodigoGeneracion: header.Identificacion.CodigoGeneracion,
+		Warnings:         []PreviewWarning{},
+		Document:         dteJSON,
+	}
+
+	if err := dte_schemas.Validate(tipoDte, dteJSON); err != nil {
+		validationErr, ok := dte_schemas.AsSchemaValidationError(err)
+		if !ok {
+			return nil, fmt.Errorf("failed to validate DTE %s: %w", tipoDte, err)
+		}
+		result.schemaErr = validationErr
+		for _, ve := range validationErr.Errors {
+			result.Warnings = append(result.Warnings, PreviewWarning{
+				Source:  "schema",
+				Field:   ve.Field,
+				Message: ve.Message,
+			})
+		}
+	}
+
+	calcWarnings, err := checkDocumentCalculations(dteJSON)
+	if err != nil {
+		return nil, err
+	}
+	result.Warnings = append(result.Warnings, calcWarnings...)
+	result.Valid = len(result.Warnings) == 0
+
+	return result, nil
+}
+
+// checkDocumentCalculations re-adds the cuerpoDocumento amounts and compares them
+// with each line's arithmetic and with the resumen totals. It works on the JSON
+// so the same checks apply to every document shape.
+func checkDocumentCalculations(dteJSON []byte) ([]PreviewWarning, error) {
+	var doc struct {
+		CuerpoDocumento []map[string]interface{} `json:"cuerpoDocumento"`
+		Resumen         map[string]interface{}   `json:"resumen"`
+	}
+	if err := json.Unmarshal(dteJSON, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse built DTE: %w", err)
+	}
+
+	calc := NewCalculator()
+	warnings := []PreviewWarning{}
+
+	// Column in cuerpoDocumento → its total in resumen
+	columns := [][2]string{
+		{"ventaNoSuj", "totalNoSuj"},
+		{"ventaExenta", "totalExenta"},
+		{"ventaGravada", "totalGravada"},
+		{"compra", "totalCompra"},
+	}
+	sums := make(map[string]float64)
+	present := make(map[string]bool)
+
+	for i, item := range doc.CuerpoDocumento {
+		field := fmt.Sprintf("cuerpoDocumento.%d", i)
+
+		precioUni, _ := jsonAmount(item, "precioUni")
+		cantidad, _ := jsonAmount(item, "cantidad")
+		montoDescu, _ := jsonAmount(item, "montoDescu")
+		ventaGravada, _ := jsonAmount(item, "ventaGravada")
+		ivaItem, _ := jsonAmount(item, "ivaItem")
+
+		if err := calc.ValidateItemCalculation(ItemAmounts{
+			PrecioUni:    precioUni,
+			VentaGravada: ventaGravada,
+			IvaItem:      ivaItem,
+			MontoDescu:   montoDescu,
+		}); err != nil {
+			warnings = append(warnings, PreviewWarning{Source: "calculator", Field: field, Message: err.Error()})
+		}
+
+		lineTotal := 0.0
+		for _, col := range columns {
+			if v, ok := jsonAmount(item, col[0]); ok {
+				lineTotal += v
+				sums[col[0]] += v
+				present[col[0]] = true
+			}
+		}
+
+		expected := precioUni*cantidad - montoDescu
+		if _, ok := item["precioUni"]; ok && !amountsMatch(expected, lineTotal) {
+			warnings = append(warnings, PreviewWarning{
+				Source: "calculator",
+				Field:  field,
+				Message: fmt.Sprintf("precioUni × cantidad − montoDescu = %.8f but the line amounts add up to %.8f",
+					expected, lineTotal),
+			})
+		}
+	}
+
+	for _, col := range columns {
+		if !present[col[0]] {
+			continue
+		}
+		total, ok := jsonAmount(doc.Resumen, col[1])
+		if !ok {
+			continue
+		}
+		if !amountsMatch(sums[col[0]], total) {
+			warnings = append(warnings, PreviewWarning{
+				Source: "calculator",
+				Field:  "resumen." + col[1],
+				Message: fmt.Sprintf("resumen %s is %.2f but the items add up to %.2f",
+					col[1], total, RoundToResumenPrecision(sums[col[0]])),
+			})
+		}
+	}
+
+	if subTotalVentas, ok := jsonAmount(doc.Resumen, "subTotalVentas"); ok {
+		noSuj, _ := jsonAmount(doc.Resumen, "totalNoSuj")
+		exenta, _ := jsonAmount(doc.Resumen, "totalExenta")
+		gravada, _ := jsonAmount(doc.Resumen, "totalGravada")
+		if !amountsMatch(noSuj+exenta+gravada, subTotalVentas) {
+			warnings = append(warnings, PreviewWarning{
+				Source: "calculator",
+				Field:  "resumen.subTotalVentas",
+				Message: fmt.Sprintf("subTotalVentas is %.2f but totalNoSuj + totalExenta + totalGravada is %.2f",
+					subTotalVentas, RoundToResumenPrecision(noSuj+exenta+gravada)),
+			})
+		}
+	}
+
+	return warnings, nil
+}
+
+// jsonAmount reads a numeric field from a decoded JSON object
+func jsonAmount(m map[string]interface{}, key string) (float64, bool) {
+	v, ok := m[key].(float64)
+	return v, ok
+}
+
+// amountsMatch compares two amounts within Hacienda's rounding tolerance
+func amountsMatch(a, b float64) bool {
+	return math.Abs(a-b) <= previewTolerance+1e-9
+}
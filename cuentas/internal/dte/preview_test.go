@@ -0,0 +1,58 @@
+package dte
+
+import (
+	"testing"
+)
+
+func TestCheckDocumentCalculations(t *testing.T) {
+	tests := []struct {
+		name      string
+		doc       string
+		wantField []string
+	}{
+		{
+			name: "consistent factura",
+			doc: `{"cuerpoDocumento":[
+				{"precioUni":11.30,"cantidad":2,"montoDescu":0,"ventaNoSuj":0,"ventaExenta":0,"ventaGravada":22.60,"ivaItem":2.6},
+				{"precioUni":5.00,"cantidad":1,"montoDescu":1.00,"ventaNoSuj":0,"ventaExenta":4.00,"ventaGravada":0,"ivaItem":0}],
+				"resumen":{"totalNoSuj":0,"totalExenta":4.00,"totalGravada":22.60,"subTotalVentas":26.60}}`,
+		},
+		{
+			name: "line arithmetic off",
+			doc: `{"cuerpoDocumento":[
+				{"precioUni":10.00,"cantidad":3,"montoDescu":0,"ventaNoSuj":0,"ventaExenta":0,"ventaGravada":20.00}],
+				"resumen":{"totalNoSuj":0,"totalExenta":0,"totalGravada":20.00,"subTotalVentas":20.00}}`,
+			wantField: []string{"cuerpoDocumento.0"},
+		},
+		{
+			name: "resumen does not match items",
+			doc: `{"cuerpoDocumento":[
+				{"precioUni":10.00,"cantidad":1,"montoDescu":0,"ventaNoSuj":0,"ventaExenta":0,"ventaGravada":10.00}],
+				"resumen":{"totalNoSuj":0,"totalExenta":0,"totalGravada":12.00,"subTotalVentas":10.00}}`,
+			wantField: []string{"resumen.totalGravada", "resumen.subTotalVentas"},
+		},
+		{
+			name: "FSE compra within rounding tolerance",
+			doc: `{"cuerpoDocumento":[
+				{"precioUni":3.333333,"cantidad":3,"montoDescu":0,"compra":10.00}],
+				"resumen":{"totalCompra":10.00}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := checkDocumentCalculations([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("checkDocumentCalculations: %v", err)
+			}
+			if len(warnings) != len(tt.wantField) {
+				t.Fatalf("got %d warnings (%+v), want %d", len(warnings), warnings, len(tt.wantField))
+			}
+			for i, w := range warnings {
+				if w.Source != "calculator" || w.Field != tt.wantField[i] {
+					t.Errorf("warning %d = %+v, want calculator warning on %s", i, w, tt.wantField[i])
+				}
+			}
+		})
+	}
+}
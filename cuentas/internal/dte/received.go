@@ -0,0 +1,325 @@
+package dte
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cuentas/internal/dte_schemas"
+	"cuentas/internal/hacienda"
+	"cuentas/internal/models"
+	"cuentas/internal/tools"
+)
+
+// ============================================
+// RECEIVED DTEs (SUPPLIER DOCUMENTS)
+// ============================================
+//
+// A supplier hands over the Factura or CCF it issued to us either as the JSON
+// document or as the signed JWS returned by its firmador. Both are reduced to
+// the document itself, validated against the same embedded schema we use for
+// our own DTEs, and loaded as a regular purchase.
+
+var (
+	ErrReceivedDTEMalformed     = errors.New("received DTE is not a JSON document or a signed JWS")
+	ErrReceivedDTEUnsupported   = errors.New("only Factura (01) and Comprobante de Crédito Fiscal (03) can be received as purchases")
+	ErrReceivedDTENotProcessed  = errors.New("DTE has not been processed by Hacienda")
+	ErrReceivedDTESelloMismatch = errors.New("DTE sello de recepción does not match Hacienda's")
+)
+
+// haciendaEstadoProcesado is the consulta estado of a DTE Hacienda accepted
+const haciendaEstadoProcesado = "PROCESADO"
+
+// DTEStatusLookup is the part of the Hacienda client used to confirm a
+// received DTE. *hacienda.Client implements it; tests pass a local stand-in.
+type DTEStatusLookup interface {
+	ConsultarDTE(ctx context.Context, authToken, nitEmisor, tipoDte, codigoGeneracion string) (*hacienda.ConsultaDTEResponse, error)
+}
+
+// receivedEnvelopeFields are added around the document by the issuer's
+// system when it is handed to the receptor. They are not part of the schema.
+var receivedEnvelopeFields = []string{"firmaElectronica", "selloRecibido"}
+
+// ReceivedDTE is a DTE a supplier issued to the company
+type ReceivedDTE struct {
+	Document      DTE
+	JSON          []byte  // The document as validated, without envelope fields
+	Signed        *string // The JWS as received; nil for a plain JSON document
+	SelloRecibido *string // Hacienda's sello, when the supplier included it
+}
+
+// ParseReceivedDTE reads a supplier DTE given as a JSON object or as a JSON
+// string holding the signed JWS. Only Facturas and CCFs are accepted.
+func ParseReceivedDTE(raw json.RawMessage) (*ReceivedDTE, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, ErrReceivedDTEMalformed
+	}
+
+	received := &ReceivedDTE{}
+	docJSON := []byte(raw)
+
+	if raw[0] == '"' {
+		var jws string
+		if err := json.Unmarshal(raw, &jws); err != nil {
+			return nil, ErrReceivedDTEMalformed
+		}
+		payload, err := decodeJWSPayload(jws)
+		if err != nil {
+			return nil, err
+		}
+		jws = strings.TrimSpace(jws)
+		received.Signed = &jws
+		docJSON = payload
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(docJSON, &fields); err != nil {
+		return nil, ErrReceivedDTEMalformed
+	}
+	if sello, ok := fields["selloRecibido"]; ok {
+		var s string
+		if err := json.Unmarshal(sello, &s); err == nil && s != "" {
+			received.SelloRecibido = &s
+		}
+	}
+	for _, field := range receivedEnvelopeFields {
+		delete(fields, field)
+	}
+
+	cleaned, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-encode received DTE: %w", err)
+	}
+	received.JSON = cleaned
+
+	if err := json.Unmarshal(cleaned, &received.Document); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrReceivedDTEMalformed, err)
+	}
+
+	id := received.Document.Identificacion
+	if id.TipoDte != TipoDteFactura && id.TipoDte != TipoDteComprobanteCreditoFiscal {
+		return nil, ErrReceivedDTEUnsupported
+	}
+	if id.CodigoGeneracion == "" || id.NumeroControl == "" {
+		return nil, fmt.Errorf("%w: identificacion.codigoGeneracion and numeroControl are required", ErrReceivedDTEMalformed)
+	}
+	if received.Document.Emisor.NIT == "" {
+		return nil, fmt.Errorf("%w: emisor.nit is required", ErrReceivedDTEMalformed)
+	}
+	received.Document.Identificacion.CodigoGeneracion = strings.ToUpper(id.CodigoGeneracion)
+
+	return received, nil
+}
+
+// Validate checks the document against its Hacienda schema. The returned
+// error wraps *dte_schemas.SchemaValidationError.
+func (r *ReceivedDTE) Validate() error {
+	tipoDte := r.Document.Identificacion.TipoDte
+	if err := dte_schemas.Validate(tipoDte, r.JSON); err != nil {
+		return fmt.Errorf("received DTE %s failed schema validation: %w", tipoDte, err)
+	}
+	return nil
+}
+
+// TipoDte returns the document type, "01" or "03"
+func (r *ReceivedDTE) TipoDte() string {
+	return r.Document.Identificacion.TipoDte
+}
+
+// CodigoGeneracion returns the document's codigoGeneracion in upper case
+func (r *ReceivedDTE) CodigoGeneracion() string {
+	return r.Document.Identificacion.CodigoGeneracion
+}
+
+// IsCCF reports whether the document is a Comprobante de Crédito Fiscal
+func (r *ReceivedDTE) IsCCF() bool {
+	return r.TipoDte() == TipoDteComprobanteCreditoFiscal
+}
+
+// LineAmount is what a line cost the company. CCF prices exclude IVA, which
+// is credited; a Factura's include it, so its IVA is part of the cost.
+func (r *ReceivedDTE) LineAmount(item CuerpoDocumentoItem) float64 {
+	return RoundToResumenPrecision(item.VentaNoSuj + item.VentaExenta + item.VentaGravada)
+}
+
+// LineIVA is the IVA of a line: carried per item on a Factura, computed on
+// the taxed amount for a CCF.
+func (r *ReceivedDTE) LineIVA(item CuerpoDocumentoItem) float64 {
+	if r.IsCCF() {
+		return RoundToResumenPrecision(item.VentaGravada * IVARate)
+	}
+	return RoundToResumenPrecision(item.IvaItem)
+}
+
+// TotalTaxes sums the tributos of the resumen (CCF) or its totalIva (Factura)
+func (r *ReceivedDTE) TotalTaxes() float64 {
+	resumen := r.Document.Resumen
+	if !r.IsCCF() {
+		return RoundToResumenPrecision(resumen.TotalIva)
+	}
+	var total float64
+	if resumen.Tributos != nil {
+		for _, tributo := range *resumen.Tributos {
+			total += tributo.Valor
+		}
+	}
+	return RoundToResumenPrecision(total)
+}
+
+// VerifyReceivedDTE confirms with Hacienda that the supplier's DTE was
+// processed, and that its sello matches the one the supplier handed over.
+func VerifyReceivedDTE(ctx context.Context, lookup DTEStatusLookup, authToken string, received *ReceivedDTE) (*hacienda.ConsultaDTEResponse, error) {
+	consulta, err := lookup.ConsultarDTE(ctx, authToken,
+		tools.StripNIT(received.Document.Emisor.NIT),
+		received.TipoDte(),
+		received.CodigoGeneracion(),
+	)
+	if err != nil {
+		var hacErr *hacienda.HaciendaError
+		if errors.As(err, &hacErr) && hacErr.Type == "not_found" {
+			return nil, fmt.Errorf("%w: %s not found in Hacienda", ErrReceivedDTENotProcessed, received.CodigoGeneracion())
+		}
+		return nil, fmt.Errorf("failed to consult DTE with Hacienda: %w", err)
+	}
+
+	if consulta.Estado != haciendaEstadoProcesado {
+		return nil, fmt.Errorf("%w: estado %s", ErrReceivedDTENotProcessed, consulta.Estado)
+	}
+	if received.SelloRecibido != nil && consulta.SelloRecibido != "" &&
+		!strings.EqualFold(*received.SelloRecibido, consulta.SelloRecibido) {
+		return nil, ErrReceivedDTESelloMismatch
+	}
+
+	return consulta, nil
+}
+
+// VerifyReceivedDTE confirms a received DTE with Hacienda using the company's credentials
+func (s *DTEService) VerifyReceivedDTE(ctx context.Context, companyID string, received *ReceivedDTE) (*hacienda.ConsultaDTEResponse, error) {
+	authToken, err := s.haciendaService.GetAuthToken(ctx, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate with Hacienda: %w", err)
+	}
+	return VerifyReceivedDTE(ctx, s.hacienda, authToken, received)
+}
+
+// PurchaseDocument reduces a verified DTE to what the purchase records
+func (r *ReceivedDTE) PurchaseDocument(consulta *hacienda.ConsultaDTEResponse) (*models.ReceivedDTEDocument, error) {
+	doc := r.Document
+
+	fechaEmision, err := time.Parse("2006-01-02", doc.Identificacion.FecEmi)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid fecEmi %q", ErrReceivedDTEMalformed, doc.Identificacion.FecEmi)
+	}
+
+	consultaJSON, err := json.Marshal(consulta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Hacienda response: %w", err)
+	}
+
+	nit := tools.FormatNIT(doc.Emisor.NIT)
+	nrc := tools.FormatNRC(doc.Emisor.NRC)
+	purchase := &models.ReceivedDTEDocument{
+		TipoDte:          r.TipoDte(),
+		CodigoGeneracion: r.CodigoGeneracion(),
+		NumeroControl:    doc.Identificacion.NumeroControl,
+		FechaEmision:     fechaEmision,
+		SelloRecibido:    consulta.SelloRecibido,
+		EstadoHacienda:   consulta.Estado,
+		HaciendaResponse: consultaJSON,
+		DocumentJSON:     r.JSON,
+		Signed:           r.Signed,
+
+		Supplier: models.SupplierInfo{
+			Name:           doc.Emisor.Nombre,
+			DocumentType:   "36",
+			DocumentNumber: &nit,
+			NRC:            &nrc,
+			ActivityCode:   doc.Emisor.CodActividad,
+			ActivityDesc:   doc.Emisor.DescActividad,
+			Address: models.Address{
+				Department:   doc.Emisor.Direccion.Departamento,
+				Municipality: doc.Emisor.Direccion.Municipio,
+				Complement:   doc.Emisor.Direccion.Complemento,
+			},
+			Phone: nilIfEmpty(doc.Emisor.Telefono),
+			Email: nilIfEmpty(doc.Emisor.Correo),
+		},
+
+		TotalNoSujeta:     doc.Resumen.TotalNoSuj,
+		TotalExenta:       doc.Resumen.TotalExenta,
+		TotalGravada:      doc.Resumen.TotalGravada,
+		Subtotal:          doc.Resumen.SubTotalVentas,
+		TotalDiscount:     doc.Resumen.TotalDescu,
+		TotalTaxes:        r.TotalTaxes(),
+		IVARetained:       doc.Resumen.IvaRete1,
+		IncomeTaxRetained: doc.Resumen.ReteRenta,
+		Total:             doc.Resumen.TotalPagar,
+		PaymentCondition:  doc.Resumen.CondicionOperacion,
+	}
+
+	// A CCF names us by NIT; a Factura only when the receptor gave one
+	if doc.Receptor != nil {
+		if doc.Receptor.NIT != nil && *doc.Receptor.NIT != "" {
+			receptorNIT := tools.FormatNIT(*doc.Receptor.NIT)
+			purchase.ReceptorNIT = &receptorNIT
+		} else if doc.Receptor.TipoDocumento != nil && *doc.Receptor.TipoDocumento == "36" &&
+			doc.Receptor.NumDocumento != nil && *doc.Receptor.NumDocumento != "" {
+			receptorNIT := tools.FormatNIT(*doc.Receptor.NumDocumento)
+			purchase.ReceptorNIT = &receptorNIT
+		}
+	}
+
+	if doc.Resumen.Pagos != nil && len(*doc.Resumen.Pagos) > 0 {
+		pago := (*doc.Resumen.Pagos)[0]
+		purchase.PaymentMethod = &pago.Codigo
+		purchase.PaymentReference = pago.Referencia
+		purchase.PaymentTerm = pago.Plazo
+		if pago.Periodo != nil {
+			periodo := int(*pago.Periodo)
+			purchase.PaymentPeriod = &periodo
+		}
+	}
+
+	for _, item := range doc.CuerpoDocumento {
+		purchase.Lines = append(purchase.Lines, models.ReceivedDTELine{
+			NumItem:     item.NumItem,
+			TipoItem:    item.TipoItem,
+			Codigo:      item.Codigo,
+			Descripcion: item.Descripcion,
+			Cantidad:    item.Cantidad,
+			UniMedida:   item.UniMedida,
+			PrecioUni:   item.PrecioUni,
+			MontoDescu:  item.MontoDescu,
+			Amount:      r.LineAmount(item),
+			IVA:         r.LineIVA(item),
+		})
+	}
+
+	return purchase, nil
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// decodeJWSPayload returns the payload of a compact JWS (header.payload.signature)
+func decodeJWSPayload(jws string) ([]byte, error) {
+	parts := strings.Split(strings.TrimSpace(jws), ".")
+	if len(parts) != 3 || parts[1] == "" {
+		return nil, ErrReceivedDTEMalformed
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid JWS payload encoding", ErrReceivedDTEMalformed)
+	}
+	return payload, nil
+}
@@ -0,0 +1,171 @@
+package dte
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"cuentas/internal/hacienda"
+)
+
+const receivedCCF = `{
+	"identificacion": {"version": 3, "ambiente": "00", "tipoDte": "03",
+		"numeroControl": "DTE-03-M001P001-000000000000042",
+		"codigoGeneracion": "a1b2c3d4-0000-4000-8000-000000000001",
+		"tipoModelo": 1, "tipoOperacion": 1, "fecEmi": "2025-11-03", "horEmi": "09:15:00", "tipoMoneda": "USD"},
+	"emisor": {"nit": "06142305911306", "nrc": "1234567", "nombre": "Distribuidora Central SA de CV",
+		"codActividad": "46900", "descActividad": "Venta al por mayor",
+		"direccion": {"departamento": "06", "municipio": "14", "complemento": "Calle Arce"},
+		"telefono": "22223333", "correo": "ventas@example.com"},
+	"receptor": {"nit": "06140101001010", "nrc": "654321", "nombre": "Comercio Afiliado SA de CV"},
+	"cuerpoDocumento": [
+		{"numItem": 1, "tipoItem": 1, "codigo": "PROD-001", "cantidad": 4, "uniMedida": 59, "descripcion": "Cable UTP",
+			"precioUni": 10.00, "montoDescu": 2.00, "ventaNoSuj": 0, "ventaExenta": 0, "ventaGravada": 38.00},
+		{"numItem": 2, "tipoItem": 2, "cantidad": 1, "uniMedida": 99, "descripcion": "Flete",
+			"precioUni": 5.00, "montoDescu": 0, "ventaNoSuj": 0, "ventaExenta": 5.00, "ventaGravada": 0}],
+	"resumen": {"totalNoSuj": 0, "totalExenta": 5.00, "totalGravada": 38.00, "subTotalVentas": 43.00,
+		"totalDescu": 0, "tributos": [{"codigo": "20", "descripcion": "IVA 13%", "valor": 4.94}],
+		"subTotal": 43.00, "ivaRete1": 0, "reteRenta": 0, "montoTotalOperacion": 47.94, "totalPagar": 47.94,
+		"condicionOperacion": 2, "pagos": [{"codigo": "05", "montoPago": 47.94, "plazo": "01", "periodo": 30}]},
+	"selloRecibido": "2025ABCDEF0123456789"
+}`
+
+// stubLookup stands in for the Hacienda consulta service
+type stubLookup struct {
+	resp *hacienda.ConsultaDTEResponse
+	err  error
+	got  []string
+}
+
+func (s *stubLookup) ConsultarDTE(ctx context.Context, authToken, nitEmisor, tipoDte, codigoGeneracion string) (*hacienda.ConsultaDTEResponse, error) {
+	s.got = []string{nitEmisor, tipoDte, codigoGeneracion}
+	return s.resp, s.err
+}
+
+func TestParseReceivedDTE(t *testing.T) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS512"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(receivedCCF))
+	jws, _ := json.Marshal(header + "." + payload + ".c2lnbmF0dXJl")
+
+	for name, raw := range map[string]string{"json": receivedCCF, "signed": string(jws)} {
+		t.Run(name, func(t *testing.T) {
+			received, err := ParseReceivedDTE(json.RawMessage(raw))
+			if err != nil {
+				t.Fatalf("ParseReceivedDTE: %v", err)
+			}
+			if received.CodigoGeneracion() != "A1B2C3D4-0000-4000-8000-000000000001" {
+				t.Errorf("CodigoGeneracion = %s, want upper case", received.CodigoGeneracion())
+			}
+			if received.SelloRecibido == nil || *received.SelloRecibido != "2025ABCDEF0123456789" {
+				t.Errorf("SelloRecibido = %v", received.SelloRecibido)
+			}
+			if (received.Signed != nil) != (name == "signed") {
+				t.Errorf("Signed = %v for %s document", received.Signed, name)
+			}
+
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(received.JSON, &fields); err != nil {
+				t.Fatalf("unmarshal cleaned JSON: %v", err)
+			}
+			if _, ok := fields["selloRecibido"]; ok {
+				t.Error("selloRecibido should be stripped before schema validation")
+			}
+		})
+	}
+
+	if _, err := ParseReceivedDTE(json.RawMessage(`{"identificacion":{"tipoDte":"14","codigoGeneracion":"X","numeroControl":"Y"}}`)); !errors.Is(err, ErrReceivedDTEUnsupported) {
+		t.Errorf("FSE: err = %v, want ErrReceivedDTEUnsupported", err)
+	}
+	if _, err := ParseReceivedDTE(json.RawMessage(`"not-a-jws"`)); !errors.Is(err, ErrReceivedDTEMalformed) {
+		t.Errorf("bad JWS: err = %v, want ErrReceivedDTEMalformed", err)
+	}
+}
+
+func TestVerifyReceivedDTE(t *testing.T) {
+	received, err := ParseReceivedDTE(json.RawMessage(receivedCCF))
+	if err != nil {
+		t.Fatalf("ParseReceivedDTE: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		lookup  *stubLookup
+		wantErr error
+	}{
+		{
+			name:   "processed",
+			lookup: &stubLookup{resp: &hacienda.ConsultaDTEResponse{Estado: "PROCESADO", SelloRecibido: "2025abcdef0123456789"}},
+		},
+		{
+			name:    "rejected",
+			lookup:  &stubLookup{resp: &hacienda.ConsultaDTEResponse{Estado: "RECHAZADO"}},
+			wantErr: ErrReceivedDTENotProcessed,
+		},
+		{
+			name:    "not found",
+			lookup:  &stubLookup{err: &hacienda.HaciendaError{Type: "not_found"}},
+			wantErr: ErrReceivedDTENotProcessed,
+		},
+		{
+			name:    "sello mismatch",
+			lookup:  &stubLookup{resp: &hacienda.ConsultaDTEResponse{Estado: "PROCESADO", SelloRecibido: "OTHER"}},
+			wantErr: ErrReceivedDTESelloMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifyReceivedDTE(context.Background(), tt.lookup, "token", received)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("VerifyReceivedDTE: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			want := []string{"06142305911306", "03", "A1B2C3D4-0000-4000-8000-000000000001"}
+			for i := range want {
+				if tt.lookup.got[i] != want[i] {
+					t.Errorf("consulta arg %d = %s, want %s", i, tt.lookup.got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReceivedPurchaseDocument(t *testing.T) {
+	received, err := ParseReceivedDTE(json.RawMessage(receivedCCF))
+	if err != nil {
+		t.Fatalf("ParseReceivedDTE: %v", err)
+	}
+
+	doc, err := received.PurchaseDocument(&hacienda.ConsultaDTEResponse{Estado: "PROCESADO", SelloRecibido: "2025ABCDEF0123456789"})
+	if err != nil {
+		t.Fatalf("PurchaseDocument: %v", err)
+	}
+
+	if *doc.Supplier.DocumentNumber != "0614-230591-130-6" {
+		t.Errorf("supplier NIT = %s, want formatted", *doc.Supplier.DocumentNumber)
+	}
+	if doc.ReceptorNIT == nil || *doc.ReceptorNIT != "0614-010100-101-0" {
+		t.Errorf("ReceptorNIT = %v", doc.ReceptorNIT)
+	}
+	if doc.TotalTaxes != 4.94 || doc.Total != 47.94 {
+		t.Errorf("TotalTaxes = %.2f, Total = %.2f, want 4.94 and 47.94", doc.TotalTaxes, doc.Total)
+	}
+	if doc.PaymentCondition != 2 || doc.PaymentPeriod == nil || *doc.PaymentPeriod != 30 {
+		t.Errorf("payment = %d / %v, want credit at 30 days", doc.PaymentCondition, doc.PaymentPeriod)
+	}
+
+	// CCF line cost excludes IVA, which is credited
+	if len(doc.Lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(doc.Lines))
+	}
+	if doc.Lines[0].Amount != 38.00 || doc.Lines[0].IVA != 4.94 {
+		t.Errorf("line 1 amount = %.2f, IVA = %.2f, want 38.00 and 4.94", doc.Lines[0].Amount, doc.Lines[0].IVA)
+	}
+	if doc.Lines[1].Amount != 5.00 || doc.Lines[1].IVA != 0 {
+		t.Errorf("line 2 amount = %.2f, IVA = %.2f, want 5.00 and 0", doc.Lines[1].Amount, doc.Lines[1].IVA)
+	}
+}
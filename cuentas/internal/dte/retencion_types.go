@@ -0,0 +1,78 @@
+package dte
+
+// ============================================
+// TYPE 07 - COMPROBANTE DE RETENCIÓN TYPES
+// ============================================
+
+// ComprobanteRetencion represents a complete Comprobante de Retención (Type 07) DTE
+type ComprobanteRetencion struct {
+	Identificacion  Identificacion        `json:"identificacion"`
+	Emisor          RetencionEmisor       `json:"emisor"`
+	Receptor        RetencionReceptor     `json:"receptor"`
+	CuerpoDocumento []RetencionCuerpoItem `json:"cuerpoDocumento"`
+	Resumen         RetencionResumen      `json:"resumen"`
+	Extension       *RetencionExtension   `json:"extension"`
+	Apendice        *[]Apendice           `json:"apendice"`
+}
+
+// RetencionEmisor is the retention agent (our company)
+// NOTE: Type 07 uses codigoMH/codigo/puntoVentaMH/puntoVenta instead of codEstable*
+type RetencionEmisor struct {
+	NIT                 string    `json:"nit"`
+	NRC                 string    `json:"nrc"`
+	Nombre              string    `json:"nombre"`
+	CodActividad        string    `json:"codActividad"`
+	DescActividad       string    `json:"descActividad"`
+	NombreComercial     *string   `json:"nombreComercial"`
+	TipoEstablecimiento string    `json:"tipoEstablecimiento"`
+	Direccion           Direccion `json:"direccion"`
+	Telefono            *string   `json:"telefono"`
+	CodigoMH            *string   `json:"codigoMH"`
+	Codigo              *string   `json:"codigo"`
+	PuntoVentaMH        *string   `json:"puntoVentaMH"`
+	PuntoVenta          *string   `json:"puntoVenta"`
+	Correo              string    `json:"correo"`
+}
+
+// RetencionReceptor is the supplier whose IVA is being retained
+type RetencionReceptor struct {
+	TipoDocumento   string    `json:"tipoDocumento"` // "36" = NIT
+	NumDocumento    string    `json:"numDocumento"`
+	NRC             *string   `json:"nrc"`
+	Nombre          string    `json:"nombre"`
+	CodActividad    string    `json:"codActividad"`
+	DescActividad   string    `json:"descActividad"`
+	NombreComercial *string   `json:"nombreComercial"`
+	Direccion       Direccion `json:"direccion"`
+	Telefono        *string   `json:"telefono"`
+	Correo          string    `json:"correo"`
+}
+
+// RetencionCuerpoItem references one supplier document subject to retention
+type RetencionCuerpoItem struct {
+	NumItem           int     `json:"numItem"`
+	TipoDte           string  `json:"tipoDte"`      // "01", "03" or "14"
+	TipoDoc           int     `json:"tipoDoc"`      // 1 = Físico, 2 = Electrónico
+	NumDocumento      string  `json:"numDocumento"` // codigoGeneracion (electrónico) or correlativo (físico)
+	FechaEmision      string  `json:"fechaEmision"`
+	MontoSujetoGrav   float64 `json:"montoSujetoGrav"`
+	CodigoRetencionMH string  `json:"codigoRetencionMH"` // "22", "C4", "C9"
+	IvaRetenido       float64 `json:"ivaRetenido"`
+	Descripcion       string  `json:"descripcion"`
+}
+
+// RetencionResumen represents the retention totals
+type RetencionResumen struct {
+	TotalSujetoRetencion   float64 `json:"totalSujetoRetencion"`
+	TotalIVARetenido       float64 `json:"totalIVAretenido"`
+	TotalIVARetenidoLetras string  `json:"totalIVAretenidoLetras"`
+}
+
+// RetencionExtension holds optional delivery information
+type RetencionExtension struct {
+	NombEntrega   *string `json:"nombEntrega"`
+	DocuEntrega   *string `json:"docuEntrega"`
+	NombRecibe    *string `json:"nombRecibe"`
+	DocuRecibe    *string `json:"docuRecibe"`
+	Observaciones *string `json:"observaciones"`
+}
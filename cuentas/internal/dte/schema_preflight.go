@@ -0,0 +1,20 @@
+package dte
+
+import (
+	"fmt"
+	"log"
+
+	"cuentas/internal/dte_schemas"
+)
+
+// validateBeforeSigning is the mandatory pre-flight step of every Process* path:
+// a document that does not match its Hacienda schema never reaches firmador.
+// The returned error wraps *dte_schemas.SchemaValidationError.
+func validateBeforeSigning(schemaKey string, docJSON []byte) error {
+	if err := dte_schemas.Validate(schemaKey, docJSON); err != nil {
+		log.Printf("[SchemaPreflight] ❌ %s failed schema validation: %v", schemaKey, err)
+		return fmt.Errorf("DTE %s failed schema validation: %w", schemaKey, err)
+	}
+	log.Printf("[SchemaPreflight] ✅ %s schema validation passed", schemaKey)
+	return nil
+}
@@ -21,17 +21,22 @@ import (
 
 // DTEService handles DTE signing and submission
 type DTEService struct {
-	db                        *sql.DB
-	redis                     *redis.Client
-	firmador                  *firmador.Client
-	vault                     *services.VaultService
-	credCache                 *CredentialCache
-	hacienda                  *hacienda.Client
-	haciendaService           *services.HaciendaService
-	builder                   *Builder
-	contingencyHelper         *ContingencyHelper
-	contingencyHelperPurchase *ContingencyHelperPurchase
-	contingencyHelperNota     *ContingencyHelperNota
+	db                           *sql.DB
+	redis                        *redis.Client
+	firmador                     *firmador.Client
+	vault                        *services.VaultService
+	credCache                    *CredentialCache
+	hacienda                     *hacienda.Client
+	haciendaService              *services.HaciendaService
+	builder                      *Builder
+	contingencyHelper            *ContingencyHelper
+	contingencyHelperPurchase    *ContingencyHelperPurchase
+	contingencyHelperNota        *ContingencyHelperNota
+	contingencyHelperRetention   *ContingencyHelperRetention
+	contingencyHelperLiquidacion *ContingencyHelperLiquidacion
+	contingencyHelperDCL         *ContingencyHelperDCL
+	contingencyHelperDonation    *ContingencyHelperDonation
+	webhooks                     *services.WebhookService
 }
 
 // NewDTEService creates a new DTE service (singleton)
@@ -43,19 +48,25 @@ func NewDTEService(
 	haciendaClient *hacienda.Client,
 	haciendaService *services.HaciendaService,
 	contingencyService *services.ContingencyService,
+	webhooks *services.WebhookService,
 ) *DTEService {
 	return &DTEService{
-		db:                        db,
-		hacienda:                  haciendaClient,
-		redis:                     redis,
-		firmador:                  firmador,
-		vault:                     vault,
-		credCache:                 NewCredentialCache(redis),
-		builder:                   NewBuilder(db),
-		haciendaService:           haciendaService,
-		contingencyHelper:         NewContingencyHelper(contingencyService),
-		contingencyHelperPurchase: NewContingencyHelperPurchase(contingencyService),
-		contingencyHelperNota:     NewContingencyHelperNota(contingencyService),
+		db:                           db,
+		hacienda:                     haciendaClient,
+		redis:                        redis,
+		firmador:                     firmador,
+		vault:                        vault,
+		credCache:                    NewCredentialCache(redis),
+		builder:                      NewBuilder(db),
+		haciendaService:              haciendaService,
+		contingencyHelper:            NewContingencyHelper(contingencyService),
+		contingencyHelperPurchase:    NewContingencyHelperPurchase(contingencyService),
+		contingencyHelperNota:        NewContingencyHelperNota(contingencyService),
+		contingencyHelperRetention:   NewContingencyHelperRetention(contingencyService),
+		contingencyHelperLiquidacion: NewContingencyHelperLiquidacion(contingencyService),
+		contingencyHelperDCL:         NewContingencyHelperDCL(contingencyService),
+		contingencyHelperDonation:    NewContingencyHelperDonation(contingencyService),
+		webhooks:                     webhooks,
 	}
 }
 
@@ -67,6 +78,8 @@ func (s *DTEService) ProcessInvoice(ctx context.Context, invoice *models.Invoice
 		return nil, fmt.Errorf("failed to build DTE: %w", err)
 	}
 
+	factura.Identificacion.CodigoGeneracion = strings.ToUpper(factura.Identificacion.CodigoGeneracion)
+
 	// Pretty print the DTE for debugging
 	dteJSON, err := json.MarshalIndent(factura, "", "  ")
 	if err != nil {
@@ -75,6 +88,11 @@ func (s *DTEService) ProcessInvoice(ctx context.Context, invoice *models.Invoice
 	fmt.Println("DTE Generated:")
 	fmt.Println(string(dteJSON))
 
+	if err := validateBeforeSigning(factura.Identificacion.TipoDte, dteJSON); err != nil {
+		return nil, err
+	}
+	s.publishInvoiceFinalized(ctx, invoice, dteJSON)
+
 	// Step 2: Load company credentials and sign
 	fmt.Println("\nStep 2: Loading credentials and signing DTE...")
 	companyID, err := uuid.Parse(invoice.CompanyID)
@@ -86,7 +104,6 @@ func (s *DTEService) ProcessInvoice(ctx context.Context, invoice *models.Invoice
 	if err != nil {
 		return nil, fmt.Errorf("failed to load credentials: %w", err)
 	}
-	factura.Identificacion.CodigoGeneracion = strings.ToUpper(factura.Identificacion.CodigoGeneracion)
 
 	// === CONTINGENCY: Handle signing failure ===
 	signedDTE, err := s.firmador.Sign(ctx, creds.NIT, creds.Password, factura)
@@ -178,6 +195,7 @@ func (s *DTEService) ProcessInvoice(ctx context.Context, invoice *models.Invoice
 					}
 				}
 			}
+			s.publishHaciendaResult(ctx, invoice.CompanyID, dteJSON, response)
 			// Rejections are permanent - don't queue for contingency
 			return response, err
 		}
@@ -239,6 +257,8 @@ func (s *DTEService) ProcessInvoice(ctx context.Context, invoice *models.Invoice
 		fmt.Println("✅ DTE submission logged to commit log")
 	}
 
+	s.publishHaciendaResult(ctx, invoice.CompanyID, dteJSON, response)
+
 	return response, nil
 }
 
@@ -250,6 +270,8 @@ func (s *DTEService) _ProcessInvoice(ctx context.Context, invoice *models.Invoic
 		return nil, fmt.Errorf("failed to build DTE: %w", err)
 	}
 
+	factura.Identificacion.CodigoGeneracion = strings.ToUpper(factura.Identificacion.CodigoGeneracion)
+
 	// Pretty print the DTE for debugging
 	dteJSON, err := json.MarshalIndent(factura, "", "  ")
 	if err != nil {
@@ -258,6 +280,10 @@ func (s *DTEService) _ProcessInvoice(ctx context.Context, invoice *models.Invoic
 	fmt.Println("DTE Generated:")
 	fmt.Println(string(dteJSON))
 
+	if err := validateBeforeSigning(factura.Identificacion.TipoDte, dteJSON); err != nil {
+		return nil, err
+	}
+
 	// Step 2: Load company credentials and sign
 	fmt.Println("\nStep 2: Loading credentials and signing DTE...")
 	companyID, err := uuid.Parse(invoice.CompanyID)
@@ -269,7 +295,6 @@ func (s *DTEService) _ProcessInvoice(ctx context.Context, invoice *models.Invoic
 	if err != nil {
 		return nil, fmt.Errorf("failed to load credentials: %w", err)
 	}
-	factura.Identificacion.CodigoGeneracion = strings.ToUpper(factura.Identificacion.CodigoGeneracion)
 
 	signedDTE, err := s.firmador.Sign(ctx, creds.NIT, creds.Password, factura)
 	if err != nil {
@@ -559,6 +584,11 @@ func (s *DTEService) ProcessExportInvoice(ctx context.Context, invoice *models.I
 	fmt.Println("Export DTE Generated:")
 	fmt.Println(string(prettyJSON))
 
+	if err := validateBeforeSigning(TipoDteFacturaExportacion, dteJSON); err != nil {
+		return nil, err
+	}
+	s.publishInvoiceFinalized(ctx, invoice, dteJSON)
+
 	// Parse identificacion from JSON
 	var exportDTE struct {
 		Identificacion struct {
@@ -667,6 +697,7 @@ func (s *DTEService) ProcessExportInvoice(ctx context.Context, invoice *models.I
 					}
 				}
 			}
+			s.publishHaciendaResult(ctx, invoice.CompanyID, dteJSON, response)
 			// Rejections are permanent - don't queue for contingency
 			return response, err
 		}
@@ -733,6 +764,8 @@ func (s *DTEService) ProcessExportInvoice(ctx context.Context, invoice *models.I
 		fmt.Println("✅ Export invoice submission logged to commit log")
 	}
 
+	s.publishHaciendaResult(ctx, invoice.CompanyID, dteJSON, response)
+
 	return response, nil
 }
 
@@ -753,6 +786,10 @@ func (s *DTEService) _ProcessExportInvoice(ctx context.Context, invoice *models.
 	fmt.Println("Export DTE Generated:")
 	fmt.Println(string(prettyJSON))
 
+	if err := validateBeforeSigning(TipoDteFacturaExportacion, dteJSON); err != nil {
+		return nil, err
+	}
+
 	// Parse identificacion from JSON
 	var exportDTE struct {
 		Identificacion struct {
@@ -0,0 +1,357 @@
+package dte
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"cuentas/internal/hacienda"
+	"cuentas/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ============================================
+// PROCESS DOCUMENTO CONTABLE DE LIQUIDACIÓN (TYPE 09)
+// ============================================
+
+// ProcessDCL builds, signs, and submits a Documento Contable de Liquidación to Hacienda
+func (s *DTEService) ProcessDCL(ctx context.Context, dcl *models.DCL) (*hacienda.ReceptionResponse, error) {
+	log.Printf("[ProcessDCL] Starting process for DCL ID: %s", dcl.ID)
+
+	// Step 1: Build DTE 09 from DCL
+	log.Println("[ProcessDCL] Step 1: Building Documento Contable de Liquidación...")
+	doc, err := s.builder.BuildDocumentoContableLiquidacion(ctx, dcl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build documento contable de liquidación: %w", err)
+	}
+
+	docJSON, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal documento contable de liquidación: %w", err)
+	}
+
+	if err := validateBeforeSigning(TipoDteDocContableLiquidacion, docJSON); err != nil {
+		return nil, err
+	}
+
+	// Step 2: Load company credentials and sign
+	log.Println("[ProcessDCL] Step 2: Loading credentials and signing DTE...")
+	companyID, err := uuid.Parse(dcl.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid company ID: %w", err)
+	}
+
+	creds, err := s.LoadCredentials(ctx, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
+	}
+
+	// === CONTINGENCY: Handle signing failure ===
+	signedDTE, err := s.firmador.Sign(ctx, creds.NIT, creds.Password, doc)
+	if err != nil {
+		log.Printf("[ProcessDCL] ⚠️  Firmador failed: %v", err)
+
+		if s.contingencyHelperDCL != nil {
+			if queueErr := s.contingencyHelperDCL.HandleSigningFailure(
+				ctx,
+				dcl,
+				doc,
+				doc.Identificacion.Ambiente,
+			); queueErr != nil {
+				return nil, fmt.Errorf("firmador failed and contingency queue failed: %w", queueErr)
+			}
+			log.Println("[ProcessDCL] 📋 DCL queued for contingency (firmador unavailable)")
+			return nil, fmt.Errorf("%w: firmador unavailable", ErrQueuedForContingency)
+		}
+
+		return nil, fmt.Errorf("failed to sign documento contable de liquidación: %w", err)
+	}
+
+	log.Printf("[ProcessDCL] Step 3: Signed successfully (%d characters)", len(signedDTE))
+
+	// Step 4: Authenticate with Hacienda
+	log.Println("[ProcessDCL] Step 4: Authenticating with Hacienda...")
+	authResponse, err := s.haciendaService.AuthenticateCompany(ctx, companyID.String())
+	if err != nil {
+		log.Printf("[ProcessDCL] ⚠️  Hacienda auth failed: %v", err)
+
+		// === CONTINGENCY: Handle auth failure ===
+		if s.contingencyHelperDCL != nil {
+			if queueErr := s.contingencyHelperDCL.HandleAuthFailure(
+				ctx,
+				dcl,
+				doc,
+				signedDTE,
+				doc.Identificacion.Ambiente,
+			); queueErr != nil {
+				return nil, fmt.Errorf("auth failed and contingency queue failed: %w", queueErr)
+			}
+			log.Println("[ProcessDCL] 📋 DCL queued for contingency (Hacienda auth unavailable)")
+			return nil, fmt.Errorf("%w: Hacienda auth unavailable", ErrQueuedForContingency)
+		}
+
+		return nil, fmt.Errorf("failed to authenticate with Hacienda: %w", err)
+	}
+
+	// Step 5: Submit to Hacienda
+	log.Println("[ProcessDCL] Step 5: Submitting to Ministerio de Hacienda...")
+	response, err := s.hacienda.SubmitDTE(
+		ctx,
+		authResponse.Body.Token,
+		doc.Identificacion.Ambiente,
+		doc.Identificacion.TipoDte, // "09"
+		doc.Identificacion.CodigoGeneracion,
+		signedDTE,
+	)
+
+	if err != nil {
+		if hacErr, ok := err.(*hacienda.HaciendaError); ok && hacErr.Type == "rejection" {
+			log.Printf("[ProcessDCL] ❌ DCL REJECTED by Hacienda!")
+			if response != nil {
+				log.Printf("[ProcessDCL] Code: %s", response.CodigoMsg)
+				log.Printf("[ProcessDCL] Message: %s", response.DescripcionMsg)
+				for _, obs := range response.Observaciones {
+					log.Printf("[ProcessDCL]   - %s", obs)
+				}
+
+				if saveErr := s.saveDCLHaciendaResponse(ctx, dcl.ID, doc, docJSON, signedDTE, response); saveErr != nil {
+					log.Printf("[ProcessDCL] ⚠️  Warning: failed to save rejection: %v", saveErr)
+				}
+			}
+			s.publishHaciendaResult(ctx, dcl.CompanyID, docJSON, response)
+			// Rejections are permanent - don't queue for contingency
+			return response, err
+		}
+
+		// === CONTINGENCY: Handle submission failure (timeout, network) ===
+		log.Printf("[ProcessDCL] ⚠️  Hacienda submission failed: %v", err)
+		if s.contingencyHelperDCL != nil {
+			if queueErr := s.contingencyHelperDCL.HandleSubmissionFailure(
+				ctx,
+				dcl,
+				doc,
+				signedDTE,
+				doc.Identificacion.Ambiente,
+			); queueErr != nil {
+				return nil, fmt.Errorf("submission failed and contingency queue failed: %w", queueErr)
+			}
+			log.Println("[ProcessDCL] 📋 DCL queued for contingency (Hacienda unavailable)")
+			return nil, fmt.Errorf("%w: Hacienda unavailable", ErrQueuedForContingency)
+		}
+
+		return nil, fmt.Errorf("failed to submit to Hacienda: %w", err)
+	}
+
+	if response == nil {
+		return nil, fmt.Errorf("no response received from Hacienda")
+	}
+
+	// Step 6: Success
+	log.Println("[ProcessDCL] ✅ SUCCESS! DCL ACCEPTED BY HACIENDA!")
+	log.Printf("[ProcessDCL] Estado: %s", response.Estado)
+	log.Printf("[ProcessDCL] Sello Recibido: %s", response.SelloRecibido)
+
+	// Step 7: Save Hacienda response to DCL
+	if err := s.saveDCLHaciendaResponse(ctx, dcl.ID, doc, docJSON, signedDTE, response); err != nil {
+		// Log error but don't fail - DTE was accepted
+		log.Printf("[ProcessDCL] ⚠️  Warning: failed to save Hacienda response: %v", err)
+	}
+
+	if response.Estado == "PROCESADO" {
+		codigo := doc.Identificacion.CodigoGeneracion
+		UploadDTEToS3Async(docJSON, "unsigned", TipoDteDocContableLiquidacion, dcl.CompanyID, codigo)
+		UploadDTEToS3Async([]byte(signedDTE), "signed", TipoDteDocContableLiquidacion, dcl.CompanyID, codigo)
+		haciendaResponseJSON, _ := json.MarshalIndent(response, "", "  ")
+		UploadDTEToS3Async(haciendaResponseJSON, "hacienda_response", TipoDteDocContableLiquidacion, dcl.CompanyID, codigo)
+	}
+
+	// Step 8: Log to commit log
+	if err := s.logDCLToCommitLog(ctx, dcl, doc, docJSON, signedDTE, response); err != nil {
+		// Log error but don't fail - DTE was already accepted
+		log.Printf("[ProcessDCL] ⚠️  Warning: failed to log to commit log: %v", err)
+	} else {
+		log.Println("[ProcessDCL] ✅ DCL logged to commit log")
+	}
+
+	s.publishHaciendaResult(ctx, dcl.CompanyID, docJSON, response)
+
+	return response, nil
+}
+
+// ============================================
+// SAVE HACIENDA RESPONSE
+// ============================================
+
+// saveDCLHaciendaResponse stores the transmitted document and Hacienda's answer on the DCL
+func (s *DTEService) saveDCLHaciendaResponse(
+	ctx context.Context,
+	dclID string,
+	doc *DocumentoContableLiquidacion,
+	docJSON []byte,
+	signedDTE string,
+	response *hacienda.ReceptionResponse,
+) error {
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	fhProcesamiento := parseHaciendaTimestamp(response.FhProcesamiento)
+
+	transmissionStatus := models.DTEStatusRechazado
+	if response.Estado == "PROCESADO" {
+		transmissionStatus = models.DTEStatusProcesado
+	}
+
+	query := `
+		UPDATE dcl_documents
+		SET dte_json = $1,
+			dte_signed = $2,
+			fecha_emision = $3,
+			fecha_procesamiento = $4,
+			hacienda_estado = $5,
+			hacienda_sello_recibido = $6,
+			hacienda_fh_procesamiento = $4,
+			hacienda_codigo_msg = $7,
+			hacienda_descripcion_msg = $8,
+			hacienda_observaciones = $9,
+			hacienda_response = $10,
+			dte_transmission_status = $11,
+			submitted_at = NOW()
+		WHERE id = $12
+	`
+
+	_, err = s.db.ExecContext(ctx, query,
+		string(docJSON),
+		signedDTE,
+		doc.Identificacion.FecEmi,
+		fhProcesamiento,
+		response.Estado,
+		response.SelloRecibido,
+		response.CodigoMsg,
+		response.DescripcionMsg,
+		pq.Array(response.Observaciones),
+		string(responseJSON),
+		transmissionStatus,
+		dclID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update DCL: %w", err)
+	}
+
+	return nil
+}
+
+// ============================================
+// COMMIT LOG
+// ============================================
+
+// logDCLToCommitLog logs the DCL submission to the commit log.
+// The entry is linked to the affiliate; it references no invoice or purchase.
+func (s *DTEService) logDCLToCommitLog(
+	ctx context.Context,
+	dcl *models.DCL,
+	doc *DocumentoContableLiquidacion,
+	docJSON []byte,
+	signedDTE string,
+	response *hacienda.ReceptionResponse,
+) error {
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	fechaEmision, err := time.Parse("2006-01-02", doc.Identificacion.FecEmi)
+	if err != nil {
+		return fmt.Errorf("failed to parse fecha_emision: %w", err)
+	}
+
+	query := `
+        INSERT INTO dte_commit_log (
+            codigo_generacion,
+            purchase_id,
+            invoice_id,
+            invoice_number,
+            company_id,
+            client_id,
+            establishment_id,
+            point_of_sale_id,
+            subtotal,
+            total_discount,
+            total_taxes,
+            iva_amount,
+            total_amount,
+            currency,
+            payment_method,
+            payment_terms,
+            numero_control,
+            tipo_dte,
+            ambiente,
+            fecha_emision,
+            fiscal_year,
+            fiscal_month,
+            dte_url,
+            dte_unsigned,
+            dte_signed,
+            hacienda_estado,
+            hacienda_sello_recibido,
+            hacienda_fh_procesamiento,
+            hacienda_codigo_msg,
+            hacienda_descripcion_msg,
+            hacienda_observaciones,
+            hacienda_response_full,
+            created_by,
+            submitted_at
+        ) VALUES (
+            $1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+            $11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
+            $21, $22, $23, $24, $25, $26, $27, $28, $29, $30,
+            $31, $32, $33, NOW()
+        )
+    `
+
+	_, err = s.db.ExecContext(ctx, query,
+		doc.Identificacion.CodigoGeneracion, // $1 codigo_generacion
+		nil,                                 // $2 purchase_id
+		nil,                                 // $3 invoice_id (NULL for DCL)
+		nil,                                 // $4 invoice_number
+		dcl.CompanyID,                       // $5
+		dcl.ClientID,                        // $6 client_id (affiliate)
+		dcl.EstablishmentID,                 // $7
+		dcl.PointOfSaleID,                   // $8
+		dcl.SubTotal,                        // $9 subtotal
+		0.0,                                 // $10 total_discount
+		dcl.IVAPercibido,                    // $11 total_taxes (IVA percibido)
+		dcl.IVA,                             // $12 iva_amount
+		dcl.LiquidoAPagar,                   // $13 total_amount (líquido a pagar)
+		"USD",                               // $14
+		"99",                                // $15 payment_method (Otros - settled outside the DTE)
+		"cash",                              // $16 payment_terms
+		doc.Identificacion.NumeroControl,    // $17
+		doc.Identificacion.TipoDte,          // $18
+		doc.Identificacion.Ambiente,         // $19
+		fechaEmision,                        // $20
+		fechaEmision.Year(),                 // $21 fiscal_year
+		int(fechaEmision.Month()),           // $22 fiscal_month
+		"",                                  // $23 dte_url
+		string(docJSON),                     // $24 dte_unsigned
+		signedDTE,                           // $25 dte_signed
+		response.Estado,                     // $26
+		response.SelloRecibido,              // $27
+		parseHaciendaTimestamp(response.FhProcesamiento), // $28
+		response.CodigoMsg,               // $29
+		response.DescripcionMsg,          // $30
+		pq.Array(response.Observaciones), // $31
+		string(responseJSON),             // $32
+		dcl.CreatedBy,                    // $33 created_by
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert commit log: %w", err)
+	}
+
+	return nil
+}
@@ -41,6 +41,10 @@ func (s *DTEService) ProcessFSE(ctx context.Context, purchase *models.Purchase)
 	log.Println("[ProcessFSE] FSE DTE Generated:")
 	log.Println(string(fsePretty))
 
+	if err := validateBeforeSigning(TipoDteFacturaSujetoExcluido, fseJSON); err != nil {
+		return nil, err
+	}
+
 	// Step 2: Load company credentials and sign
 	log.Println("[ProcessFSE] Step 2: Loading credentials and signing DTE...")
 	companyID, err := uuid.Parse(purchase.CompanyID)
@@ -136,6 +140,7 @@ func (s *DTEService) ProcessFSE(ctx context.Context, purchase *models.Purchase)
 					}
 				}
 			}
+			s.publishHaciendaResult(ctx, purchase.CompanyID, fseJSON, response)
 			// Rejections are permanent - don't queue for contingency
 			return response, err
 		}
@@ -199,6 +204,8 @@ func (s *DTEService) ProcessFSE(ctx context.Context, purchase *models.Purchase)
 		log.Println("[ProcessFSE] ✅ FSE submission logged to commit log")
 	}
 
+	s.publishHaciendaResult(ctx, purchase.CompanyID, fseJSON, response)
+
 	return response, nil
 }
 
@@ -324,12 +331,13 @@ func (s *DTEService) logFSEToCommitLog(
             hacienda_descripcion_msg,
             hacienda_observaciones,
             hacienda_response_full,
+            created_by,
             submitted_at
         ) VALUES (
             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
             $11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
             $21, $22, $23, $24, $25, $26, $27, $28, $29, $30,
-            $31, $32, NOW()
+            $31, $32, $33, NOW()
         )
     `
 
@@ -371,6 +379,7 @@ func (s *DTEService) logFSEToCommitLog(
 		response.DescripcionMsg,          // $30 hacienda_descripcion_msg
 		observaciones,                    // $31 hacienda_observaciones (array)
 		string(responseJSON),             // $32 hacienda_response_full
+		purchase.CreatedBy,               // $33 created_by
 	)
 
 	if err != nil {
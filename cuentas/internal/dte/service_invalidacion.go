@@ -0,0 +1,359 @@
+// internal/dte/service_invalidacion.go
+package dte
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cuentas/internal/dte_schemas"
+	"cuentas/internal/hacienda"
+	"cuentas/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ============================================
+// INVALIDATE DTE (EVENTO DE INVALIDACIÓN)
+// ============================================
+
+// InvalidateDTE builds, signs, and submits an Evento de Invalidación for an accepted DTE.
+// Invalidation events cannot be transmitted in contingency, so signing or transmission
+// failures are returned to the caller instead of being queued.
+func (s *DTEService) InvalidateDTE(
+	ctx context.Context,
+	companyID, codigoGeneracion string,
+	req *models.InvalidateDTERequest,
+	userID string,
+) (*models.DTEInvalidation, error) {
+	log.Printf("[InvalidateDTE] Starting invalidation of DTE %s", codigoGeneracion)
+
+	// Step 1: Load the accepted DTE from the commit log
+	source, err := s.loadInvalidationSource(ctx, companyID, codigoGeneracion)
+	if err != nil {
+		return nil, err
+	}
+
+	var alreadyInvalidated bool
+	err = s.db.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM dte_invalidations
+			WHERE company_id = $1 AND original_codigo_generacion = $2 AND hacienda_estado = 'PROCESADO'
+		)
+	`, companyID, source.CodigoGeneracion).Scan(&alreadyInvalidated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing invalidations: %w", err)
+	}
+	if alreadyInvalidated {
+		return nil, ErrDTEAlreadyInvalidated
+	}
+
+	// Step 2: Types 1 and 3 must point to a replacement DTE already accepted by Hacienda
+	if req.RequiresReplacement() {
+		if strings.EqualFold(req.CodigoGeneracionR, source.CodigoGeneracion) {
+			return nil, ErrReplacementDTENotFound
+		}
+		if _, err := s.loadInvalidationSource(ctx, companyID, req.CodigoGeneracionR); err != nil {
+			if err == ErrDTENotFound {
+				return nil, ErrReplacementDTENotFound
+			}
+			return nil, err
+		}
+	}
+
+	// Step 3: Build and validate the event
+	log.Println("[InvalidateDTE] Step 3: Building evento de invalidación...")
+	invalidacion, err := s.builder.BuildInvalidacion(ctx, source, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build invalidation: %w", err)
+	}
+
+	invalidacionJSON, err := json.Marshal(invalidacion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal invalidation: %w", err)
+	}
+
+	if err := validateBeforeSigning(dte_schemas.SchemaAnulacion, invalidacionJSON); err != nil {
+		return nil, err
+	}
+
+	invalidation, err := s.insertInvalidation(ctx, source, req, invalidacion, invalidacionJSON, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 4: Sign
+	log.Println("[InvalidateDTE] Step 4: Loading credentials and signing event...")
+	companyUUID, err := uuid.Parse(companyID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid company ID: %w", err)
+	}
+
+	creds, err := s.LoadCredentials(ctx, companyUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
+	}
+
+	signedEvent, err := s.firmador.Sign(ctx, creds.NIT, creds.Password, invalidacion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign invalidation: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `UPDATE dte_invalidations SET dte_signed = $1 WHERE id = $2`, signedEvent, invalidation.ID); err != nil {
+		log.Printf("[InvalidateDTE] ⚠️  Warning: failed to save signed event: %v", err)
+	}
+	invalidation.DteSigned = &signedEvent
+
+	// Step 5: Authenticate with Hacienda
+	log.Println("[InvalidateDTE] Step 5: Authenticating with Hacienda...")
+	authResponse, err := s.haciendaService.AuthenticateCompany(ctx, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate with Hacienda: %w", err)
+	}
+
+	// Step 6: Submit to Hacienda
+	log.Println("[InvalidateDTE] Step 6: Submitting evento de invalidación to Ministerio de Hacienda...")
+	response, err := s.hacienda.InvalidarDTE(ctx, authResponse.Body.Token, invalidacion.Identificacion.Ambiente, signedEvent)
+	if err != nil {
+		hacErr, ok := err.(*hacienda.HaciendaError)
+		if !ok || hacErr.Type != "rejection" || response == nil {
+			return nil, fmt.Errorf("failed to submit invalidation to Hacienda: %w", err)
+		}
+		log.Printf("[InvalidateDTE] ❌ Invalidation REJECTED by Hacienda: %s - %s", response.CodigoMsg, response.DescripcionMsg)
+	}
+
+	// Step 7: Save Hacienda response (accepted or rejected)
+	if err := s.saveInvalidationResponse(ctx, invalidation, response); err != nil {
+		log.Printf("[InvalidateDTE] ⚠️  Warning: failed to save Hacienda response: %v", err)
+	}
+
+	if invalidation.IsAccepted() {
+		log.Printf("[InvalidateDTE] ✅ DTE %s invalidated (sello: %s)", source.CodigoGeneracion, response.SelloRecibido)
+
+		UploadDTEToS3Async(invalidacionJSON, "invalidacion_unsigned", source.TipoDte, companyID, invalidacion.Identificacion.CodigoGeneracion)
+		UploadDTEToS3Async([]byte(signedEvent), "invalidacion_signed", source.TipoDte, companyID, invalidacion.Identificacion.CodigoGeneracion)
+
+		s.publishInvalidationAccepted(ctx, invalidation, response)
+	}
+
+	return invalidation, nil
+}
+
+// loadInvalidationSource loads an accepted DTE from the commit log
+func (s *DTEService) loadInvalidationSource(ctx context.Context, companyID, codigoGeneracion string) (*InvalidationSource, error) {
+	query := `
+		SELECT codigo_generacion, tipo_dte, numero_control, hacienda_sello_recibido,
+		       fecha_emision, ambiente, establishment_id, point_of_sale_id,
+		       invoice_id, purchase_id, iva_amount, dte_unsigned
+		FROM dte_commit_log
+		WHERE company_id = $1
+		  AND UPPER(codigo_generacion) = UPPER($2)
+		  AND hacienda_estado = 'PROCESADO'
+		ORDER BY created_at
+		LIMIT 1
+	`
+
+	var (
+		source        InvalidationSource
+		selloRecibido sql.NullString
+		fechaEmision  time.Time
+		invoiceID     sql.NullString
+		purchaseID    sql.NullString
+	)
+
+	err := s.db.QueryRowContext(ctx, query, companyID, codigoGeneracion).Scan(
+		&source.CodigoGeneracion,
+		&source.TipoDte,
+		&source.NumeroControl,
+		&selloRecibido,
+		&fechaEmision,
+		&source.Ambiente,
+		&source.EstablishmentID,
+		&source.PointOfSaleID,
+		&invoiceID,
+		&purchaseID,
+		&source.IVAAmount,
+		&source.DteUnsigned,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrDTENotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load DTE from commit log: %w", err)
+	}
+
+	if !selloRecibido.Valid || selloRecibido.String == "" {
+		return nil, ErrDTENotFound
+	}
+
+	source.CompanyID = companyID
+	source.CodigoGeneracion = strings.ToUpper(source.CodigoGeneracion)
+	source.SelloRecibido = selloRecibido.String
+	source.FechaEmision = fechaEmision.Format("2006-01-02")
+
+	// Notas (05/06) store the referenced CCF in invoice_id, not the nota itself
+	if invoiceID.Valid && source.TipoDte != "05" && source.TipoDte != "06" {
+		source.InvoiceID = &invoiceID.String
+	}
+	if purchaseID.Valid {
+		source.PurchaseID = &purchaseID.String
+	}
+
+	return &source, nil
+}
+
+// insertInvalidation records the invalidation attempt before it is signed and transmitted
+func (s *DTEService) insertInvalidation(
+	ctx context.Context,
+	source *InvalidationSource,
+	req *models.InvalidateDTERequest,
+	invalidacion *Invalidacion,
+	invalidacionJSON []byte,
+	userID string,
+) (*models.DTEInvalidation, error) {
+	fechaEmision, err := time.Parse("2006-01-02", source.FechaEmision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fecha_emision: %w", err)
+	}
+
+	var createdBy *string // NULL when the API key isn't bound to a user
+	if userID != "" {
+		createdBy = &userID
+	}
+
+	invalidation := &models.DTEInvalidation{
+		CompanyID:                source.CompanyID,
+		CodigoGeneracion:         invalidacion.Identificacion.CodigoGeneracion,
+		Ambiente:                 invalidacion.Identificacion.Ambiente,
+		OriginalCodigoGeneracion: source.CodigoGeneracion,
+		OriginalTipoDte:          source.TipoDte,
+		OriginalNumeroControl:    source.NumeroControl,
+		OriginalSelloRecibido:    source.SelloRecibido,
+		OriginalFechaEmision:     fechaEmision,
+		InvoiceID:                source.InvoiceID,
+		PurchaseID:               source.PurchaseID,
+		CodigoGeneracionR:        invalidacion.Documento.CodigoGeneracionR,
+		TipoAnulacion:            req.TipoAnulacion,
+		MotivoAnulacion:          invalidacion.Motivo.MotivoAnulacion,
+		NombreResponsable:        req.NombreResponsable,
+		TipDocResponsable:        req.TipDocResponsable,
+		NumDocResponsable:        req.NumDocResponsable,
+		NombreSolicita:           req.NombreSolicita,
+		TipDocSolicita:           req.TipDocSolicita,
+		NumDocSolicita:           req.NumDocSolicita,
+		DteUnsigned:              string(invalidacionJSON),
+		CreatedBy:                createdBy,
+	}
+
+	query := `
+		INSERT INTO dte_invalidations (
+			company_id, codigo_generacion, ambiente,
+			original_codigo_generacion, original_tipo_dte, original_numero_control,
+			original_sello_recibido, original_fecha_emision, invoice_id, purchase_id,
+			codigo_generacion_r, tipo_anulacion, motivo_anulacion,
+			nombre_responsable, tip_doc_responsable, num_doc_responsable,
+			nombre_solicita, tip_doc_solicita, num_doc_solicita,
+			dte_unsigned, created_by
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+			$11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21
+		)
+		RETURNING id, created_at
+	`
+
+	err = s.db.QueryRowContext(ctx, query,
+		invalidation.CompanyID,
+		invalidation.CodigoGeneracion,
+		invalidation.Ambiente,
+		invalidation.OriginalCodigoGeneracion,
+		invalidation.OriginalTipoDte,
+		invalidation.OriginalNumeroControl,
+		invalidation.OriginalSelloRecibido,
+		invalidation.OriginalFechaEmision,
+		invalidation.InvoiceID,
+		invalidation.PurchaseID,
+		invalidation.CodigoGeneracionR,
+		invalidation.TipoAnulacion,
+		invalidation.MotivoAnulacion,
+		invalidation.NombreResponsable,
+		invalidation.TipDocResponsable,
+		invalidation.NumDocResponsable,
+		invalidation.NombreSolicita,
+		invalidation.TipDocSolicita,
+		invalidation.NumDocSolicita,
+		invalidation.DteUnsigned,
+		createdBy,
+	).Scan(&invalidation.ID, &invalidation.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert invalidation: %w", err)
+	}
+
+	return invalidation, nil
+}
+
+// saveInvalidationResponse stores Hacienda's response on the invalidation record
+func (s *DTEService) saveInvalidationResponse(ctx context.Context, invalidation *models.DTEInvalidation, response *hacienda.ReceptionResponse) error {
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	var fhProcesamiento *time.Time
+	if response.FhProcesamiento != "" {
+		t, err := time.Parse("02/01/2006 15:04:05", response.FhProcesamiento)
+		if err != nil {
+			log.Printf("[saveInvalidationResponse] Warning: failed to parse FhProcesamiento '%s': %v", response.FhProcesamiento, err)
+		} else {
+			fhProcesamiento = &t
+		}
+	}
+
+	observaciones := response.Observaciones
+	if observaciones == nil {
+		observaciones = []string{}
+	}
+
+	now := time.Now()
+	invalidation.HaciendaEstado = &response.Estado
+	invalidation.HaciendaSelloRecibido = &response.SelloRecibido
+	invalidation.HaciendaFhProcesamiento = fhProcesamiento
+	invalidation.HaciendaCodigoMsg = &response.CodigoMsg
+	invalidation.HaciendaDescripcionMsg = &response.DescripcionMsg
+	invalidation.HaciendaObservaciones = observaciones
+	invalidation.SubmittedAt = &now
+
+	query := `
+		UPDATE dte_invalidations
+		SET hacienda_estado = $1,
+		    hacienda_sello_recibido = $2,
+		    hacienda_fh_procesamiento = $3,
+		    hacienda_codigo_msg = $4,
+		    hacienda_descripcion_msg = $5,
+		    hacienda_observaciones = $6,
+		    hacienda_response_full = $7,
+		    submitted_at = $8
+		WHERE id = $9
+	`
+
+	_, err = s.db.ExecContext(ctx, query,
+		response.Estado,
+		response.SelloRecibido,
+		fhProcesamiento,
+		response.CodigoMsg,
+		response.DescripcionMsg,
+		pq.Array(observaciones),
+		string(responseJSON),
+		now,
+		invalidation.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update invalidation: %w", err)
+	}
+
+	return nil
+}
@@ -32,7 +32,10 @@ func (s *DTEService) ProcessRemision(ctx context.Context, remision *models.Invoi
 	fmt.Println("Remision DTE Generated:")
 	fmt.Println(string(prettyJSON))
 
-	fmt.Println("\n✅ Schema validation already passed in builder")
+	if err := validateBeforeSigning(TipoDteNotaRemision, dteJSON); err != nil {
+		return nil, err
+	}
+	s.publishInvoiceFinalized(ctx, remision, dteJSON)
 
 	// Parse identificacion from JSON
 	var remisionDTE struct {
@@ -142,6 +145,7 @@ func (s *DTEService) ProcessRemision(ctx context.Context, remision *models.Invoi
 					}
 				}
 			}
+			s.publishHaciendaResult(ctx, remision.CompanyID, dteJSON, response)
 			// Rejections are permanent - don't queue for contingency
 			return response, err
 		}
@@ -208,6 +212,8 @@ func (s *DTEService) ProcessRemision(ctx context.Context, remision *models.Invoi
 		fmt.Println("✅ Remision submission logged to commit log")
 	}
 
+	s.publishHaciendaResult(ctx, remision.CompanyID, dteJSON, response)
+
 	return response, nil
 }
 
@@ -34,6 +34,10 @@ func (s *DTEService) ProcessNotaDebito(ctx context.Context, nota *models.NotaDeb
 	fmt.Println("DTE Generated:")
 	fmt.Println(string(prettyJSON))
 
+	if err := validateBeforeSigning(TipoDteNotaDebito, dteJSON); err != nil {
+		return nil, err
+	}
+
 	// Step 2: Load credentials and sign
 	fmt.Println("\nStep 2: Loading credentials and signing DTE...")
 	companyID, err := uuid.Parse(nota.CompanyID)
@@ -126,6 +130,7 @@ func (s *DTEService) ProcessNotaDebito(ctx context.Context, nota *models.NotaDeb
 					}
 				}
 			}
+			s.publishHaciendaResult(ctx, nota.CompanyID, dteJSON, response)
 			return response, err
 		}
 
@@ -189,6 +194,8 @@ func (s *DTEService) ProcessNotaDebito(ctx context.Context, nota *models.NotaDeb
 		fmt.Println("✅ Nota submission logged to commit log")
 	}
 
+	s.publishHaciendaResult(ctx, nota.CompanyID, dteJSON, response)
+
 	return response, nil
 }
 
@@ -34,6 +34,10 @@ func (s *DTEService) ProcessNotaCredito(ctx context.Context, nota *models.NotaCr
 	fmt.Println("DTE Generated:")
 	fmt.Println(string(prettyJSON))
 
+	if err := validateBeforeSigning(TipoDteNotaCredito, dteJSON); err != nil {
+		return nil, err
+	}
+
 	// Step 2: Load credentials and sign
 	fmt.Println("\nStep 2: Loading credentials and signing DTE...")
 	companyID, err := uuid.Parse(nota.CompanyID)
@@ -126,6 +130,7 @@ func (s *DTEService) ProcessNotaCredito(ctx context.Context, nota *models.NotaCr
 					}
 				}
 			}
+			s.publishHaciendaResult(ctx, nota.CompanyID, dteJSON, response)
 			return response, err
 		}
 
@@ -189,6 +194,8 @@ func (s *DTEService) ProcessNotaCredito(ctx context.Context, nota *models.NotaCr
 		fmt.Println("✅ Nota submission logged to commit log")
 	}
 
+	s.publishHaciendaResult(ctx, nota.CompanyID, dteJSON, response)
+
 	return response, nil
 }
 
@@ -0,0 +1,371 @@
+package dte
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cuentas/internal/hacienda"
+	"cuentas/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ============================================
+// PROCESS COMPROBANTE DE RETENCIÓN (TYPE 07)
+// ============================================
+
+// ProcessRetention builds, signs, and submits a Comprobante de Retención to Hacienda
+func (s *DTEService) ProcessRetention(ctx context.Context, retention *models.Retention) (*hacienda.ReceptionResponse, error) {
+	log.Printf("[ProcessRetention] Starting process for retention ID: %s", retention.ID)
+
+	// Step 1: Build DTE 07 from retention
+	log.Println("[ProcessRetention] Step 1: Building Comprobante de Retención...")
+	doc, err := s.builder.BuildComprobanteRetencion(ctx, retention)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build comprobante de retención: %w", err)
+	}
+
+	docJSON, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal comprobante de retención: %w", err)
+	}
+
+	if err := validateBeforeSigning(TipoDteCompRetencion, docJSON); err != nil {
+		return nil, err
+	}
+
+	// Step 2: Load company credentials and sign
+	log.Println("[ProcessRetention] Step 2: Loading credentials and signing DTE...")
+	companyID, err := uuid.Parse(retention.CompanyID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid company ID: %w", err)
+	}
+
+	creds, err := s.LoadCredentials(ctx, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
+	}
+
+	// === CONTINGENCY: Handle signing failure ===
+	signedDTE, err := s.firmador.Sign(ctx, creds.NIT, creds.Password, doc)
+	if err != nil {
+		log.Printf("[ProcessRetention] ⚠️  Firmador failed: %v", err)
+
+		if s.contingencyHelperRetention != nil {
+			if queueErr := s.contingencyHelperRetention.HandleSigningFailure(
+				ctx,
+				retention,
+				doc,
+				doc.Identificacion.Ambiente,
+			); queueErr != nil {
+				return nil, fmt.Errorf("firmador failed and contingency queue failed: %w", queueErr)
+			}
+			log.Println("[ProcessRetention] 📋 Retention queued for contingency (firmador unavailable)")
+			return nil, fmt.Errorf("%w: firmador unavailable", ErrQueuedForContingency)
+		}
+
+		return nil, fmt.Errorf("failed to sign comprobante de retención: %w", err)
+	}
+
+	log.Printf("[ProcessRetention] Step 3: Signed successfully (%d characters)", len(signedDTE))
+
+	// Step 4: Authenticate with Hacienda
+	log.Println("[ProcessRetention] Step 4: Authenticating with Hacienda...")
+	authResponse, err := s.haciendaService.AuthenticateCompany(ctx, companyID.String())
+	if err != nil {
+		log.Printf("[ProcessRetention] ⚠️  Hacienda auth failed: %v", err)
+
+		// === CONTINGENCY: Handle auth failure ===
+		if s.contingencyHelperRetention != nil {
+			if queueErr := s.contingencyHelperRetention.HandleAuthFailure(
+				ctx,
+				retention,
+				doc,
+				signedDTE,
+				doc.Identificacion.Ambiente,
+			); queueErr != nil {
+				return nil, fmt.Errorf("auth failed and contingency queue failed: %w", queueErr)
+			}
+			log.Println("[ProcessRetention] 📋 Retention queued for contingency (Hacienda auth unavailable)")
+			return nil, fmt.Errorf("%w: Hacienda auth unavailable", ErrQueuedForContingency)
+		}
+
+		return nil, fmt.Errorf("failed to authenticate with Hacienda: %w", err)
+	}
+
+	// Step 5: Submit to Hacienda
+	log.Println("[ProcessRetention] Step 5: Submitting to Ministerio de Hacienda...")
+	response, err := s.hacienda.SubmitDTE(
+		ctx,
+		authResponse.Body.Token,
+		doc.Identificacion.Ambiente,
+		doc.Identificacion.TipoDte, // "07"
+		doc.Identificacion.CodigoGeneracion,
+		signedDTE,
+	)
+
+	if err != nil {
+		if hacErr, ok := err.(*hacienda.HaciendaError); ok && hacErr.Type == "rejection" {
+			log.Printf("[ProcessRetention] ❌ Retention REJECTED by Hacienda!")
+			if response != nil {
+				log.Printf("[ProcessRetention] Code: %s", response.CodigoMsg)
+				log.Printf("[ProcessRetention] Message: %s", response.DescripcionMsg)
+				for _, obs := range response.Observaciones {
+					log.Printf("[ProcessRetention]   - %s", obs)
+				}
+
+				if saveErr := s.saveRetentionHaciendaResponse(ctx, retention.ID, doc, docJSON, signedDTE, response); saveErr != nil {
+					log.Printf("[ProcessRetention] ⚠️  Warning: failed to save rejection: %v", saveErr)
+				}
+			}
+			s.publishHaciendaResult(ctx, retention.CompanyID, docJSON, response)
+			// Rejections are permanent - don't queue for contingency
+			return response, err
+		}
+
+		// === CONTINGENCY: Handle submission failure (timeout, network) ===
+		log.Printf("[ProcessRetention] ⚠️  Hacienda submission failed: %v", err)
+		if s.contingencyHelperRetention != nil {
+			if queueErr := s.contingencyHelperRetention.HandleSubmissionFailure(
+				ctx,
+				retention,
+				doc,
+				signedDTE,
+				doc.Identificacion.Ambiente,
+			); queueErr != nil {
+				return nil, fmt.Errorf("submission failed and contingency queue failed: %w", queueErr)
+			}
+			log.Println("[ProcessRetention] 📋 Retention queued for contingency (Hacienda unavailable)")
+			return nil, fmt.Errorf("%w: Hacienda unavailable", ErrQueuedForContingency)
+		}
+
+		return nil, fmt.Errorf("failed to submit to Hacienda: %w", err)
+	}
+
+	if response == nil {
+		return nil, fmt.Errorf("no response received from Hacienda")
+	}
+
+	// Step 6: Success
+	log.Println("[ProcessRetention] ✅ SUCCESS! RETENTION ACCEPTED BY HACIENDA!")
+	log.Printf("[ProcessRetention] Estado: %s", response.Estado)
+	log.Printf("[ProcessRetention] Sello Recibido: %s", response.SelloRecibido)
+
+	// Step 7: Save Hacienda response to retention
+	if err := s.saveRetentionHaciendaResponse(ctx, retention.ID, doc, docJSON, signedDTE, response); err != nil {
+		// Log error but don't fail - DTE was accepted
+		log.Printf("[ProcessRetention] ⚠️  Warning: failed to save Hacienda response: %v", err)
+	}
+
+	if response.Estado == "PROCESADO" {
+		codigo := doc.Identificacion.CodigoGeneracion
+		UploadDTEToS3Async(docJSON, "unsigned", TipoDteCompRetencion, retention.CompanyID, codigo)
+		UploadDTEToS3Async([]byte(signedDTE), "signed", TipoDteCompRetencion, retention.CompanyID, codigo)
+		haciendaResponseJSON, _ := json.MarshalIndent(response, "", "  ")
+		UploadDTEToS3Async(haciendaResponseJSON, "hacienda_response", TipoDteCompRetencion, retention.CompanyID, codigo)
+	}
+
+	// Step 8: Log to commit log
+	if err := s.logRetentionToCommitLog(ctx, retention, doc, docJSON, signedDTE, response); err != nil {
+		// Log error but don't fail - DTE was already accepted
+		log.Printf("[ProcessRetention] ⚠️  Warning: failed to log to commit log: %v", err)
+	} else {
+		log.Println("[ProcessRetention] ✅ Retention logged to commit log")
+	}
+
+	s.publishHaciendaResult(ctx, retention.CompanyID, docJSON, response)
+
+	return response, nil
+}
+
+// ============================================
+// SAVE HACIENDA RESPONSE
+// ============================================
+
+// saveRetentionHaciendaResponse stores the transmitted document and Hacienda's answer on the retention
+func (s *DTEService) saveRetentionHaciendaResponse(
+	ctx context.Context,
+	retentionID string,
+	doc *ComprobanteRetencion,
+	docJSON []byte,
+	signedDTE string,
+	response *hacienda.ReceptionResponse,
+) error {
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	fhProcesamiento := parseHaciendaTimestamp(response.FhProcesamiento)
+
+	transmissionStatus := models.DTEStatusRechazado
+	if response.Estado == "PROCESADO" {
+		transmissionStatus = models.DTEStatusProcesado
+	}
+
+	query := `
+		UPDATE retentions
+		SET dte_json = $1,
+			dte_signed = $2,
+			fecha_emision = $3,
+			fecha_procesamiento = $4,
+			hacienda_estado = $5,
+			hacienda_sello_recibido = $6,
+			hacienda_fh_procesamiento = $4,
+			hacienda_codigo_msg = $7,
+			hacienda_descripcion_msg = $8,
+			hacienda_observaciones = $9,
+			hacienda_response = $10,
+			dte_transmission_status = $11,
+			submitted_at = NOW()
+		WHERE id = $12
+	`
+
+	_, err = s.db.ExecContext(ctx, query,
+		string(docJSON),
+		signedDTE,
+		doc.Identificacion.FecEmi,
+		fhProcesamiento,
+		response.Estado,
+		response.SelloRecibido,
+		response.CodigoMsg,
+		response.DescripcionMsg,
+		pq.Array(response.Observaciones),
+		string(responseJSON),
+		transmissionStatus,
+		retentionID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update retention: %w", err)
+	}
+
+	return nil
+}
+
+// ============================================
+// COMMIT LOG
+// ============================================
+
+// logRetentionToCommitLog logs the retention submission to the commit log.
+// The entry is linked to the purchase whose IVA was retained.
+func (s *DTEService) logRetentionToCommitLog(
+	ctx context.Context,
+	retention *models.Retention,
+	doc *ComprobanteRetencion,
+	docJSON []byte,
+	signedDTE string,
+	response *hacienda.ReceptionResponse,
+) error {
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	fechaEmision, err := time.Parse("2006-01-02", doc.Identificacion.FecEmi)
+	if err != nil {
+		return fmt.Errorf("failed to parse fecha_emision: %w", err)
+	}
+
+	query := `
+        INSERT INTO dte_commit_log (
+            codigo_generacion,
+            purchase_id,
+            invoice_id,
+            invoice_number,
+            company_id,
+            client_id,
+            establishment_id,
+            point_of_sale_id,
+            subtotal,
+            total_discount,
+            total_taxes,
+            iva_amount,
+            total_amount,
+            currency,
+            payment_method,
+            payment_terms,
+            numero_control,
+            tipo_dte,
+            ambiente,
+            fecha_emision,
+            fiscal_year,
+            fiscal_month,
+            dte_url,
+            dte_unsigned,
+            dte_signed,
+            hacienda_estado,
+            hacienda_sello_recibido,
+            hacienda_fh_procesamiento,
+            hacienda_codigo_msg,
+            hacienda_descripcion_msg,
+            hacienda_observaciones,
+            hacienda_response_full,
+            created_by,
+            submitted_at
+        ) VALUES (
+            $1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+            $11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
+            $21, $22, $23, $24, $25, $26, $27, $28, $29, $30,
+            $31, $32, $33, NOW()
+        )
+    `
+
+	_, err = s.db.ExecContext(ctx, query,
+		doc.Identificacion.CodigoGeneracion, // $1 codigo_generacion
+		retention.PurchaseID,                // $2 purchase_id
+		nil,                                 // $3 invoice_id (NULL for retentions)
+		nil,                                 // $4 invoice_number
+		retention.CompanyID,                 // $5
+		nil,                                 // $6 client_id (suppliers are not clients; purchase_id links the supplier)
+		retention.EstablishmentID,           // $7
+		retention.PointOfSaleID,             // $8
+		retention.MontoSujetoGrav,           // $9 subtotal (monto sujeto a retención)
+		0.0,                                 // $10 total_discount
+		0.0,                                 // $11 total_taxes
+		retention.IVARetenido,               // $12 iva_amount (IVA retenido)
+		retention.IVARetenido,               // $13 total_amount
+		"USD",                               // $14
+		"99",                                // $15 payment_method (Otros - no payment involved)
+		"cash",                              // $16 payment_terms
+		doc.Identificacion.NumeroControl,    // $17
+		doc.Identificacion.TipoDte,          // $18
+		doc.Identificacion.Ambiente,         // $19
+		fechaEmision,                        // $20
+		fechaEmision.Year(),                 // $21 fiscal_year
+		int(fechaEmision.Month()),           // $22 fiscal_month
+		"",                                  // $23 dte_url
+		string(docJSON),                     // $24 dte_unsigned
+		signedDTE,                           // $25 dte_signed
+		response.Estado,                     // $26
+		response.SelloRecibido,              // $27
+		parseHaciendaTimestamp(response.FhProcesamiento), // $28
+		response.CodigoMsg,               // $29
+		response.DescripcionMsg,          // $30
+		pq.Array(response.Observaciones), // $31
+		string(responseJSON),             // $32
+		retention.CreatedBy,              // $33 created_by
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert commit log: %w", err)
+	}
+
+	return nil
+}
+
+// parseHaciendaTimestamp parses Hacienda's "DD/MM/YYYY HH:MM:SS" format, returning nil when absent or invalid
+func parseHaciendaTimestamp(value string) *time.Time {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	t, err := time.Parse("02/01/2006 15:04:05", value)
+	if err != nil {
+		log.Printf("Warning: failed to parse Hacienda timestamp '%s': %v", value, err)
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,101 @@
+package dte
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"cuentas/internal/hacienda"
+	"cuentas/internal/models"
+)
+
+// ============================================
+// WEBHOOK EVENTS
+// ============================================
+//
+// The Process* paths publish lifecycle events as they happen. Publishing only
+// queues rows for the webhook worker and never fails the transmission.
+
+// dteIdentificacion reads the identifiers of a built DTE
+func dteIdentificacion(dteJSON []byte) (tipoDte, codigoGeneracion, numeroControl, ambiente string) {
+	var doc struct {
+		Identificacion struct {
+			TipoDte          string `json:"tipoDte"`
+			CodigoGeneracion string `json:"codigoGeneracion"`
+			NumeroControl    string `json:"numeroControl"`
+			Ambiente         string `json:"ambiente"`
+		} `json:"identificacion"`
+	}
+	if err := json.Unmarshal(dteJSON, &doc); err != nil {
+		return "", "", "", ""
+	}
+	id := doc.Identificacion
+	return id.TipoDte, strings.ToUpper(id.CodigoGeneracion), id.NumeroControl, id.Ambiente
+}
+
+// publishInvoiceFinalized announces an invoice (01/03/11) or remisión (04) that
+// was finalized and is about to be transmitted
+func (s *DTEService) publishInvoiceFinalized(ctx context.Context, invoice *models.Invoice, dteJSON []byte) {
+	tipoDte, codigoGeneracion, numeroControl, _ := dteIdentificacion(dteJSON)
+
+	finalizedAt := time.Now()
+	if invoice.FinalizedAt != nil {
+		finalizedAt = *invoice.FinalizedAt
+	}
+
+	s.webhooks.Publish(ctx, invoice.CompanyID, models.WebhookEventInvoiceFinalized, map[string]interface{}{
+		"invoice_id":        invoice.ID,
+		"invoice_number":    invoice.InvoiceNumber,
+		"tipo_dte":          tipoDte,
+		"codigo_generacion": codigoGeneracion,
+		"numero_control":    numeroControl,
+		"client_id":         invoice.ClientID,
+		"establishment_id":  invoice.EstablishmentID,
+		"point_of_sale_id":  invoice.PointOfSaleID,
+		"total":             invoice.Total,
+		"finalized_at":      finalizedAt,
+	})
+}
+
+// publishHaciendaResult announces Hacienda's verdict on a DTE transmitted online
+func (s *DTEService) publishHaciendaResult(ctx context.Context, companyID string, dteJSON []byte, response *hacienda.ReceptionResponse) {
+	if response == nil {
+		return
+	}
+
+	eventType := models.WebhookEventDTERejected
+	if response.Estado == "PROCESADO" {
+		eventType = models.WebhookEventDTEAccepted
+	}
+
+	tipoDte, codigoGeneracion, numeroControl, ambiente := dteIdentificacion(dteJSON)
+	s.webhooks.Publish(ctx, companyID, eventType, models.WebhookDTEResult{
+		CodigoGeneracion: codigoGeneracion,
+		NumeroControl:    numeroControl,
+		TipoDte:          tipoDte,
+		Ambiente:         ambiente,
+		Estado:           response.Estado,
+		SelloRecibido:    response.SelloRecibido,
+		FhProcesamiento:  response.FhProcesamiento,
+		CodigoMsg:        response.CodigoMsg,
+		DescripcionMsg:   response.DescripcionMsg,
+		Observaciones:    response.Observaciones,
+		Source:           "online",
+	})
+}
+
+// publishInvalidationAccepted announces an accepted evento de invalidación
+func (s *DTEService) publishInvalidationAccepted(ctx context.Context, invalidation *models.DTEInvalidation, response *hacienda.ReceptionResponse) {
+	s.webhooks.Publish(ctx, invalidation.CompanyID, models.WebhookEventInvalidationAccepted, map[string]interface{}{
+		"invalidation_id":            invalidation.ID,
+		"codigo_generacion":          invalidation.CodigoGeneracion,
+		"original_codigo_generacion": invalidation.OriginalCodigoGeneracion,
+		"original_tipo_dte":          invalidation.OriginalTipoDte,
+		"original_numero_control":    invalidation.OriginalNumeroControl,
+		"codigo_generacion_r":        invalidation.CodigoGeneracionR,
+		"tipo_anulacion":             invalidation.TipoAnulacion,
+		"sello_recibido":             response.SelloRecibido,
+		"fh_procesamiento":           response.FhProcesamiento,
+	})
+}
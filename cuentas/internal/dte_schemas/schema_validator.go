@@ -2,6 +2,7 @@ package dte_schemas
 
 import (
 	"embed"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -17,6 +18,12 @@ type Validator struct {
 	schemas map[string]*gojsonschema.Schema
 }
 
+// Schema keys for eventos, which are not identified by a tipoDte
+const (
+	SchemaAnulacion    = "anulacion"
+	SchemaContingencia = "contingencia"
+)
+
 // ValidationError represents a single validation error
 type ValidationError struct {
 	Field   string      `json:"field"`
@@ -32,6 +39,21 @@ func (e ValidationError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Field, e.Message)
 }
 
+// SchemaValidationError is returned when a document does not match its schema.
+// It carries every violation so callers can return them as a list.
+type SchemaValidationError struct {
+	TipoDte string            `json:"tipo_dte"`
+	Errors  []ValidationError `json:"errors"`
+}
+
+func (e *SchemaValidationError) Error() string {
+	lines := []string{"DTE Schema Validation Failed:"}
+	for i, err := range e.Errors {
+		lines = append(lines, fmt.Sprintf("  [%d] %s", i+1, err.Error()))
+	}
+	return strings.Join(lines, "\n")
+}
+
 // NewValidator creates a validator and loads all schemas
 func NewValidator() (*Validator, error) {
 	validator := &Validator{
@@ -45,7 +67,16 @@ func NewValidator() (*Validator, error) {
 		"05": "schemas/fe-nc-v3.json",  // Nota de Crédito
 		"06": "schemas/fe-nd-v3.json",  // Nota de Débito
 		"11": "schemas/fe-fex-v1.json", // Factura Exportación
-		"04": "schemas/fe-nr-v3.json",
+		"04": "schemas/fe-nr-v3.json",  // Nota de Remisión
+		"14": "schemas/fe-fse-v1.json", // Factura Sujeto Excluido
+		"07": "schemas/fe-cr-v1.json",  // Comprobante de Retención
+		"08": "schemas/fe-cl-v1.json",  // Comprobante de Liquidación
+		"09": "schemas/fe-dcl-v1.json", // Documento Contable de Liquidación
+		"15": "schemas/fe-cd-v1.json",  // Comprobante de Donación
+
+		// Eventos (not DTE types, keyed by name)
+		SchemaAnulacion:    "schemas/anulacion-schema-v2.json",    // Evento de Invalidación
+		SchemaContingencia: "schemas/contingencia-schema-v3.json", // Evento de Contingencia
 	}
 
 	// Load and compile schemas
@@ -99,9 +130,9 @@ func (v *Validator) ValidateJSON(tipoDte string, jsonBytes []byte) error {
 	}
 
 	// Build error list
-	var errors []ValidationError
+	validationErr := &SchemaValidationError{TipoDte: tipoDte}
 	for _, err := range result.Errors() {
-		errors = append(errors, ValidationError{
+		validationErr.Errors = append(validationErr.Errors, ValidationError{
 			Field:   err.Field(),
 			Message: err.Description(),
 			Value:   err.Value(),
@@ -109,24 +140,16 @@ func (v *Validator) ValidateJSON(tipoDte string, jsonBytes []byte) error {
 		})
 	}
 
-	// Format and return error
-	return formatValidationErrors(errors)
+	return validationErr
 }
 
-// formatValidationErrors formats errors into a readable error message
-func formatValidationErrors(errors []ValidationError) error {
-	if len(errors) == 0 {
-		return nil
+// AsSchemaValidationError extracts the validation errors from an error chain
+func AsSchemaValidationError(err error) (*SchemaValidationError, bool) {
+	var validationErr *SchemaValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr, true
 	}
-
-	var lines []string
-	lines = append(lines, "DTE Schema Validation Failed:")
-
-	for i, err := range errors {
-		lines = append(lines, fmt.Sprintf("  [%d] %s", i+1, err.Error()))
-	}
-
-	return fmt.Errorf("%s", strings.Join(lines, "\n"))
+	return nil, false
 }
 
 // Global validator instance
@@ -0,0 +1,59 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+
+	"cuentas/internal/i18n"
+	"cuentas/internal/models"
+)
+
+// WriteAgingCSV writes the accounts receivable or payable aging report to CSV format with translations
+func WriteAgingCSV(report *models.AgingReport, lang string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	writer := csv.NewWriter(buf)
+
+	t := i18n.New(lang)
+
+	header := [][]string{
+		{t.AgingTitle(report.Ledger)},
+		{t.AsOfDateLabel(), report.AsOfDate},
+		{},
+		t.AgingHeaders(report.GroupBy),
+	}
+	for _, row := range header {
+		if err := writer.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, row := range report.Rows {
+		record := append([]string{row.ID, row.Name, fmt.Sprintf("%d", row.InvoiceCount)}, agingAmounts(row.AgingBuckets)...)
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	totals := append([]string{"", t.TotalsLabel(), ""}, agingAmounts(report.Totals)...)
+	if err := writer.Write(totals); err != nil {
+		return nil, err
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func agingAmounts(b models.AgingBuckets) []string {
+	return []string{
+		fmt.Sprintf("%.2f", b.Days0To30),
+		fmt.Sprintf("%.2f", b.Days31To60),
+		fmt.Sprintf("%.2f", b.Days61To90),
+		fmt.Sprintf("%.2f", b.Over90),
+		fmt.Sprintf("%.2f", b.Total),
+	}
+}
@@ -0,0 +1,46 @@
+package formats
+
+import (
+	"fmt"
+
+	"cuentas/internal/i18n"
+	"cuentas/internal/models"
+)
+
+// WriteBankReconciliationCSV writes a bank account's reconciliation to CSV
+// format with translations: the summary, then the statement lines not in the
+// books and the payments not in the bank
+func WriteBankReconciliationCSV(rec *models.BankReconciliation, lang string) ([]byte, error) {
+	t := i18n.New(lang)
+	money := func(v float64) string { return fmt.Sprintf("%.2f", v) }
+
+	records := [][]string{
+		{t.BankReconciliationTitle()},
+		{t.FormatCompanyLabel(), rec.CompanyName},
+		{"NIT", rec.NIT},
+		{"NRC", rec.NRC},
+		{t.BankAccountLabel(), bankAccountName(rec.BankAccount)},
+		{t.FormatPeriodLabel(), rec.FromDate + " - " + rec.ToDate},
+	}
+	if rec.StatementBalance != nil {
+		records = append(records, []string{t.StatementBalanceLabel(rec.StatementBalanceOn), money(*rec.StatementBalance)})
+	}
+	records = append(records, []string{})
+
+	labels := t.BankReconciliationSummaryLabels()
+	for i, value := range bankSummaryValues(rec.Summary, money) {
+		records = append(records, []string{labels[i], value})
+	}
+
+	records = append(records, []string{}, []string{t.UnmatchedBankLinesTitle()}, t.UnmatchedBankLinesHeaders())
+	for _, line := range rec.UnmatchedLines {
+		records = append(records, unmatchedBankLineCells(line, money))
+	}
+
+	records = append(records, []string{}, []string{t.UnmatchedBankItemsTitle()}, t.UnmatchedBankItemsHeaders())
+	for _, item := range rec.UnmatchedItems {
+		records = append(records, unmatchedBankItemCells(t, item, money))
+	}
+
+	return writeCSVRecords(records)
+}
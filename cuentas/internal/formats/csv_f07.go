@@ -0,0 +1,297 @@
+package formats
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"cuentas/internal/models"
+)
+
+// F-07 anexos are uploaded to Hacienda's portal as semicolon separated files
+// without a header row, one row per document, in the official column order.
+// Dates are DD/MM/AAAA, NIT and NRC go without dashes and amounts are positive
+// with two decimals: the document type tells notas de crédito apart. The renta
+// classification columns are left blank.
+
+// f07DocumentClass is the clase de documento of a DTE
+const f07DocumentClass = "4"
+
+// F07AnexoFilename returns the name of an anexo file, e.g.
+// F07_ANEXO1_06141234567890_202503.csv
+func F07AnexoFilename(decl *models.IVADeclaration, anexo int) string {
+	return fmt.Sprintf("F07_ANEXO%d_%s_%04d%02d.csv", anexo, f07ID(decl.NIT), decl.Year, decl.Month)
+}
+
+// F07AnexoRecords builds the rows of an anexo and the sum of its main amount:
+// the IVA for sales, purchases, retentions and percepciones, the total sales
+// for consumer sales and the operation amount for sujetos excluidos. Sums are
+// signed, so they match the IVA books' totals.
+func F07AnexoRecords(decl *models.IVADeclaration, anexo int) ([][]string, float64) {
+	records := [][]string{}
+	amount := 0.0
+	number := strconv.Itoa(anexo)
+
+	switch anexo {
+	case models.F07AnexoVentasContribuyentes:
+		for _, row := range decl.ContributorSales.Rows {
+			records = append(records, []string{
+				f07Date(row.Date),
+				f07DocumentClass,
+				row.TipoDte,
+				row.NumeroControl,
+				row.SelloRecibido,
+				row.CodigoGeneracion,
+				"",
+				f07Counterparty(row.ReceptorNIT, row.ReceptorNRC),
+				row.ReceptorName,
+				f07Amount(row.VentasExentas),
+				f07Amount(row.VentasNoSujetas),
+				f07Amount(row.VentasGravadas),
+				f07Amount(row.IVADebito),
+				f07Amount(0),
+				f07Amount(0),
+				f07Amount(row.VentasExentas + row.VentasNoSujetas + row.VentasGravadas + row.IVADebito),
+				"",
+				"",
+				"",
+				number,
+			})
+			amount += row.IVADebito
+		}
+
+	case models.F07AnexoVentasConsumidorFinal:
+		// Exports are reported as outside Central America
+		for _, row := range decl.ConsumerSales.Rows {
+			records = append(records, []string{
+				f07Date(row.Date),
+				f07DocumentClass,
+				row.TipoDte,
+				row.FirstNumeroControl,
+				"",
+				"",
+				"",
+				row.FirstNumeroControl,
+				row.LastNumeroControl,
+				"",
+				f07Amount(row.VentasExentas),
+				f07Amount(0),
+				f07Amount(row.VentasNoSujetas),
+				f07Amount(row.VentasGravadas),
+				f07Amount(0),
+				f07Amount(row.Exportaciones),
+				f07Amount(0),
+				f07Amount(0),
+				f07Amount(0),
+				f07Amount(row.Total),
+				"",
+				"",
+				number,
+			})
+			amount += row.Total
+		}
+
+	case models.F07AnexoCompras:
+		for _, row := range decl.Purchases.Rows {
+			if row.PurchaseType == "fse" {
+				continue
+			}
+			class, tipoDte, document := f07DocumentClass, "", row.DocumentNumber
+			if row.TipoDte != nil {
+				tipoDte = *row.TipoDte
+			}
+			if row.CodigoGeneracion != nil {
+				document = *row.CodigoGeneracion
+			}
+			if row.PurchaseType == "import" {
+				// Declaración de mercancías
+				class, tipoDte = "1", "12"
+			}
+			records = append(records, []string{
+				f07Date(row.Date),
+				class,
+				tipoDte,
+				document,
+				f07Counterparty(row.SupplierNRC, row.SupplierDocument),
+				row.SupplierName,
+				f07Amount(row.ComprasExentasInternas),
+				f07Amount(0),
+				f07Amount(row.ComprasExentasImportaciones),
+				f07Amount(row.ComprasGravadasInternas),
+				f07Amount(0),
+				f07Amount(row.ImportacionesGravadas),
+				f07Amount(0),
+				f07Amount(row.IVACreditoFiscal),
+				f07Amount(row.Total),
+				"",
+				"",
+				"",
+				"",
+				"",
+				number,
+			})
+			amount += row.IVACreditoFiscal
+		}
+
+	case models.F07AnexoSujetosExcluidos:
+		for _, row := range decl.Purchases.Rows {
+			if row.PurchaseType != "fse" {
+				continue
+			}
+			codigoGeneracion := ""
+			if row.CodigoGeneracion != nil {
+				codigoGeneracion = *row.CodigoGeneracion
+			}
+			records = append(records, []string{
+				f07SupplierDocumentType(row.SupplierDocumentType),
+				f07ID(row.SupplierDocument),
+				row.SupplierName,
+				f07Date(row.Date),
+				row.DocumentNumber,
+				codigoGeneracion,
+				f07Amount(row.SujetosExcluidos),
+				f07Amount(row.IVARetenido),
+				"",
+				"",
+				"",
+				"",
+				number,
+			})
+			amount += row.SujetosExcluidos
+		}
+
+	case models.F07AnexoRetencionesEfectuadas, models.F07AnexoRetencionesSufridas:
+		kind := models.RetentionIssued
+		if anexo == models.F07AnexoRetencionesSufridas {
+			kind = models.RetentionSuffered
+		}
+		for _, retention := range decl.Purchases.Retentions {
+			if retention.Kind != kind {
+				continue
+			}
+			records = append(records, f07AgentRecord(retention.CounterpartyNIT, retention.Date, retention.TipoDte,
+				retention.NumeroControl, retention.CodigoGeneracion, retention.MontoSujeto, retention.IVARetenido, number))
+			amount += retention.IVARetenido
+		}
+
+	case models.F07AnexoPercepcionesEfectuadas:
+		for _, row := range decl.ContributorSales.Rows {
+			if row.IVAPercibido == 0 {
+				continue
+			}
+			records = append(records, f07AgentRecord(row.ReceptorNIT, row.Date, row.TipoDte,
+				row.NumeroControl, row.CodigoGeneracion, row.VentasGravadas, row.IVAPercibido, number))
+			amount += row.IVAPercibido
+		}
+	}
+
+	return records, amount
+}
+
+// WriteF07AnexoCSV writes one F-07 anexo in Hacienda's upload layout
+func WriteF07AnexoCSV(decl *models.IVADeclaration, anexo int) ([]byte, error) {
+	records, _ := F07AnexoRecords(decl, anexo)
+
+	buf := new(bytes.Buffer)
+	writer := csv.NewWriter(buf)
+	writer.Comma = ';'
+	writer.UseCRLF = true
+
+	for _, record := range records {
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// WriteF07AnexosZip writes every F-07 anexo into a single ZIP archive
+func WriteF07AnexosZip(decl *models.IVADeclaration) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	archive := zip.NewWriter(buf)
+
+	for _, anexo := range models.F07Anexos {
+		data, err := WriteF07AnexoCSV(decl, anexo)
+		if err != nil {
+			return nil, err
+		}
+		file, err := archive.Create(F07AnexoFilename(decl, anexo))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := file.Write(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// f07AgentRecord formats a retention or percepción row: counterparty NIT,
+// date, document type, serie, document number, amount subject, IVA, DUI and
+// anexo number
+func f07AgentRecord(nit, date, tipoDte, numeroControl, codigoGeneracion string, montoSujeto, iva float64, number string) []string {
+	return []string{
+		f07ID(nit),
+		f07Date(date),
+		tipoDte,
+		numeroControl,
+		codigoGeneracion,
+		f07Amount(montoSujeto),
+		f07Amount(iva),
+		"",
+		number,
+	}
+}
+
+// f07Date converts YYYY-MM-DD to DD/MM/AAAA
+func f07Date(date string) string {
+	parts := strings.Split(date, "-")
+	if len(parts) != 3 {
+		return date
+	}
+	return parts[2] + "/" + parts[1] + "/" + parts[0]
+}
+
+// f07Amount formats an amount as positive with two decimals
+func f07Amount(v float64) string {
+	return fmt.Sprintf("%.2f", math.Abs(v))
+}
+
+// f07ID strips the dashes of a NIT, NRC or DUI
+func f07ID(id string) string {
+	return strings.ReplaceAll(id, "-", "")
+}
+
+// f07Counterparty returns the first of the identifiers that is set, without dashes
+func f07Counterparty(preferred, fallback string) string {
+	if preferred != "" {
+		return f07ID(preferred)
+	}
+	return f07ID(fallback)
+}
+
+// f07SupplierDocumentType maps a supplier document type to the anexo's codes:
+// 1 NIT, 2 DUI, 3 other
+func f07SupplierDocumentType(documentType string) string {
+	switch documentType {
+	case "36":
+		return "1"
+	case "13":
+		return "2"
+	}
+	return "3"
+}
@@ -0,0 +1,312 @@
+package formats
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"cuentas/internal/models"
+)
+
+func f07TestDeclaration() *models.IVADeclaration {
+	ccfTipo, ccfCodigo := "03", "5F2A9C1E-7B3D-4E8F-A1C2-3D4E5F6A7B8C"
+	fseTipo, fseCodigo := "14", "0C1D2E3F-4A5B-4C6D-8E7F-9A0B1C2D3E4F"
+
+	return &models.IVADeclaration{
+		NIT:   "0614-123456-789-0",
+		Year:  2025,
+		Month: 3,
+		ContributorSales: &models.ContributorSalesBook{
+			Rows: []models.ContributorSalesBookRow{
+				{
+					Date:             "2025-03-04",
+					TipoDte:          "03",
+					NumeroControl:    "DTE-03-M001P001-000000000000012",
+					CodigoGeneracion: "A1B2C3D4-E5F6-4A7B-8C9D-0E1F2A3B4C5D",
+					SelloRecibido:    "2025A1B2C3D4E5F6A7B8C9D0E1F2A3B4C5D6E7F8",
+					ReceptorNRC:      "123456-7",
+					ReceptorNIT:      "0614-010190-101-1",
+					ReceptorName:     "Distribuidora El Sol, S.A. de C.V.",
+					ContributorSalesAmounts: models.ContributorSalesAmounts{
+						VentasExentas:   10,
+						VentasNoSujetas: 5,
+						VentasGravadas:  1000,
+						IVADebito:       130,
+						IVAPercibido:    10,
+						Total:           1155,
+					},
+				},
+				{
+					Date:             "2025-03-20",
+					TipoDte:          "05",
+					NumeroControl:    "DTE-05-M001P001-000000000000003",
+					CodigoGeneracion: "B2C3D4E5-F6A7-4B8C-9D0E-1F2A3B4C5D6E",
+					SelloRecibido:    "2025B2C3D4E5F6A7B8C9D0E1F2A3B4C5D6E7F8A9",
+					ReceptorNIT:      "0614-010190-101-1",
+					ReceptorName:     "Distribuidora El Sol, S.A. de C.V.",
+					ContributorSalesAmounts: models.ContributorSalesAmounts{
+						VentasGravadas: -100,
+						IVADebito:      -13,
+						Total:          -113,
+					},
+				},
+			},
+		},
+		ConsumerSales: &models.ConsumerSalesBook{
+			Rows: []models.ConsumerSalesBookRow{
+				{
+					Date:               "2025-03-05",
+					TipoDte:            "01",
+					FirstNumeroControl: "DTE-01-M001P001-000000000000100",
+					LastNumeroControl:  "DTE-01-M001P001-000000000000142",
+					ConsumerSalesAmounts: models.ConsumerSalesAmounts{
+						DocumentCount:   43,
+						VentasExentas:   20,
+						VentasNoSujetas: 3.5,
+						VentasGravadas:  565,
+						Total:           588.5,
+						IVADebito:       65,
+					},
+				},
+				{
+					Date:               "2025-03-06",
+					TipoDte:            "11",
+					FirstNumeroControl: "DTE-11-M001P001-000000000000007",
+					LastNumeroControl:  "DTE-11-M001P001-000000000000007",
+					ConsumerSalesAmounts: models.ConsumerSalesAmounts{
+						DocumentCount: 1,
+						Exportaciones: 2500,
+						Total:         2500,
+					},
+				},
+			},
+		},
+		Purchases: &models.PurchaseBook{
+			Rows: []models.PurchaseBookRow{
+				{
+					PurchaseType:         "ccf",
+					Date:                 "2025-03-10",
+					TipoDte:              &ccfTipo,
+					DocumentNumber:       "DTE-03-S001P001-000000000000456",
+					CodigoGeneracion:     &ccfCodigo,
+					SupplierNRC:          "765432-1",
+					SupplierDocumentType: "36",
+					SupplierDocument:     "0614-020285-102-2",
+					SupplierName:         "Papelera Central, S.A. de C.V.",
+					PurchaseBookAmounts: models.PurchaseBookAmounts{
+						ComprasExentasInternas:  7.25,
+						ComprasGravadasInternas: 200,
+						IVACreditoFiscal:        26,
+						Total:                   233.25,
+						IVARetenido:             2,
+					},
+				},
+				{
+					PurchaseType:         "import",
+					Date:                 "2025-03-12",
+					DocumentNumber:       "4-2025-000123",
+					SupplierDocumentType: "37",
+					SupplierDocument:     "US-998877",
+					SupplierName:         "Acme Supply Inc.",
+					PurchaseBookAmounts: models.PurchaseBookAmounts{
+						ComprasExentasImportaciones: 50,
+						ImportacionesGravadas:       800,
+						IVACreditoFiscal:            104,
+						Total:                       954,
+					},
+				},
+				{
+					PurchaseType:         "fse",
+					Date:                 "2025-03-15",
+					TipoDte:              &fseTipo,
+					DocumentNumber:       "DTE-14-M001P001-000000000000009",
+					CodigoGeneracion:     &fseCodigo,
+					SupplierDocumentType: "13",
+					SupplierDocument:     "01234567-8",
+					SupplierName:         "Juan Pérez",
+					PurchaseBookAmounts: models.PurchaseBookAmounts{
+						SujetosExcluidos: 150,
+						Total:            150,
+						IVARetenido:      1.5,
+					},
+				},
+			},
+			Retentions: []models.PurchaseBookRetention{
+				{
+					Kind:             models.RetentionIssued,
+					Date:             "2025-03-10",
+					TipoDte:          "07",
+					NumeroControl:    "DTE-07-M001P001-000000000000021",
+					CodigoGeneracion: "C3D4E5F6-A7B8-4C9D-0E1F-2A3B4C5D6E7F",
+					CounterpartyNRC:  "765432-1",
+					CounterpartyNIT:  "0614-020285-102-2",
+					CounterpartyName: "Papelera Central, S.A. de C.V.",
+					RelatedDocument:  "DTE-03-S001P001-000000000000456",
+					MontoSujeto:      200,
+					IVARetenido:      2,
+				},
+				{
+					Kind:             models.RetentionSuffered,
+					Date:             "2025-03-04",
+					TipoDte:          "03",
+					NumeroControl:    "DTE-03-M001P001-000000000000012",
+					CodigoGeneracion: "A1B2C3D4-E5F6-4A7B-8C9D-0E1F2A3B4C5D",
+					CounterpartyNRC:  "123456-7",
+					CounterpartyNIT:  "0614-010190-101-1",
+					CounterpartyName: "Distribuidora El Sol, S.A. de C.V.",
+					MontoSujeto:      1000,
+					IVARetenido:      10,
+				},
+			},
+		},
+	}
+}
+
+func TestF07AnexoRecords(t *testing.T) {
+	decl := f07TestDeclaration()
+
+	tests := []struct {
+		name       string
+		anexo      int
+		wantRows   [][]string
+		wantAmount float64
+	}{
+		{
+			name:  "ventas a contribuyentes",
+			anexo: models.F07AnexoVentasContribuyentes,
+			wantRows: [][]string{
+				{
+					"04/03/2025", "4", "03", "DTE-03-M001P001-000000000000012",
+					"2025A1B2C3D4E5F6A7B8C9D0E1F2A3B4C5D6E7F8", "A1B2C3D4-E5F6-4A7B-8C9D-0E1F2A3B4C5D", "",
+					"06140101901011", "Distribuidora El Sol, S.A. de C.V.",
+					"10.00", "5.00", "1000.00", "130.00", "0.00", "0.00", "1145.00",
+					"", "", "", "1",
+				},
+				{
+					"20/03/2025", "4", "05", "DTE-05-M001P001-000000000000003",
+					"2025B2C3D4E5F6A7B8C9D0E1F2A3B4C5D6E7F8A9", "B2C3D4E5-F6A7-4B8C-9D0E-1F2A3B4C5D6E", "",
+					"06140101901011", "Distribuidora El Sol, S.A. de C.V.",
+					"0.00", "0.00", "100.00", "13.00", "0.00", "0.00", "113.00",
+					"", "", "", "1",
+				},
+			},
+			wantAmount: 117,
+		},
+		{
+			name:  "ventas a consumidor final",
+			anexo: models.F07AnexoVentasConsumidorFinal,
+			wantRows: [][]string{
+				{
+					"05/03/2025", "4", "01", "DTE-01-M001P001-000000000000100", "", "", "",
+					"DTE-01-M001P001-000000000000100", "DTE-01-M001P001-000000000000142", "",
+					"20.00", "0.00", "3.50", "565.00", "0.00", "0.00", "0.00", "0.00", "0.00", "588.50",
+					"", "", "2",
+				},
+				{
+					"06/03/2025", "4", "11", "DTE-11-M001P001-000000000000007", "", "", "",
+					"DTE-11-M001P001-000000000000007", "DTE-11-M001P001-000000000000007", "",
+					"0.00", "0.00", "0.00", "0.00", "0.00", "2500.00", "0.00", "0.00", "0.00", "2500.00",
+					"", "", "2",
+				},
+			},
+			wantAmount: 3088.5,
+		},
+		{
+			name:  "compras",
+			anexo: models.F07AnexoCompras,
+			wantRows: [][]string{
+				{
+					"10/03/2025", "4", "03", "5F2A9C1E-7B3D-4E8F-A1C2-3D4E5F6A7B8C",
+					"7654321", "Papelera Central, S.A. de C.V.",
+					"7.25", "0.00", "0.00", "200.00", "0.00", "0.00", "0.00", "26.00", "233.25",
+					"", "", "", "", "", "3",
+				},
+				{
+					"12/03/2025", "1", "12", "4-2025-000123",
+					"US998877", "Acme Supply Inc.",
+					"0.00", "0.00", "50.00", "0.00", "0.00", "800.00", "0.00", "104.00", "954.00",
+					"", "", "", "", "", "3",
+				},
+			},
+			wantAmount: 130,
+		},
+		{
+			name:  "retenciones efectuadas",
+			anexo: models.F07AnexoRetencionesEfectuadas,
+			wantRows: [][]string{
+				{
+					"06140202851022", "10/03/2025", "07", "DTE-07-M001P001-000000000000021",
+					"C3D4E5F6-A7B8-4C9D-0E1F-2A3B4C5D6E7F", "200.00", "2.00", "", "4",
+				},
+			},
+			wantAmount: 2,
+		},
+		{
+			name:  "sujetos excluidos",
+			anexo: models.F07AnexoSujetosExcluidos,
+			wantRows: [][]string{
+				{
+					"2", "012345678", "Juan Pérez", "15/03/2025", "DTE-14-M001P001-000000000000009",
+					"0C1D2E3F-4A5B-4C6D-8E7F-9A0B1C2D3E4F", "150.00", "1.50", "", "", "", "", "5",
+				},
+			},
+			wantAmount: 150,
+		},
+		{
+			name:  "percepciones efectuadas",
+			anexo: models.F07AnexoPercepcionesEfectuadas,
+			wantRows: [][]string{
+				{
+					"06140101901011", "04/03/2025", "03", "DTE-03-M001P001-000000000000012",
+					"A1B2C3D4-E5F6-4A7B-8C9D-0E1F2A3B4C5D", "1000.00", "10.00", "", "6",
+				},
+			},
+			wantAmount: 10,
+		},
+		{
+			name:  "retenciones sufridas",
+			anexo: models.F07AnexoRetencionesSufridas,
+			wantRows: [][]string{
+				{
+					"06140101901011", "04/03/2025", "03", "DTE-03-M001P001-000000000000012",
+					"A1B2C3D4-E5F6-4A7B-8C9D-0E1F2A3B4C5D", "1000.00", "10.00", "", "7",
+				},
+			},
+			wantAmount: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, amount := F07AnexoRecords(decl, tt.anexo)
+			if len(rows) != len(tt.wantRows) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(tt.wantRows))
+			}
+			for i := range rows {
+				if !reflect.DeepEqual(rows[i], tt.wantRows[i]) {
+					t.Errorf("row %d =\n%q\nwant\n%q", i, rows[i], tt.wantRows[i])
+				}
+			}
+			if math.Abs(amount-tt.wantAmount) > 0.005 {
+				t.Errorf("amount = %.2f, want %.2f", amount, tt.wantAmount)
+			}
+		})
+	}
+}
+
+func TestWriteF07AnexoCSV(t *testing.T) {
+	data, err := WriteF07AnexoCSV(f07TestDeclaration(), models.F07AnexoRetencionesEfectuadas)
+	if err != nil {
+		t.Fatalf("WriteF07AnexoCSV() error = %v", err)
+	}
+
+	want := "06140202851022;10/03/2025;07;DTE-07-M001P001-000000000000021;" +
+		"C3D4E5F6-A7B8-4C9D-0E1F-2A3B4C5D6E7F;200.00;2.00;;4\r\n"
+	if string(data) != want {
+		t.Errorf("WriteF07AnexoCSV() = %q, want %q", data, want)
+	}
+
+	if name := F07AnexoFilename(f07TestDeclaration(), models.F07AnexoCompras); name != "F07_ANEXO3_06141234567890_202503.csv" {
+		t.Errorf("F07AnexoFilename() = %q, want F07_ANEXO3_06141234567890_202503.csv", name)
+	}
+}
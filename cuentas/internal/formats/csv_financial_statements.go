@@ -0,0 +1,143 @@
+package formats
+
+import (
+	"fmt"
+	"strings"
+
+	"cuentas/internal/i18n"
+	"cuentas/internal/models"
+)
+
+// WriteTrialBalanceCSV writes the balance de comprobación to CSV format with
+// translations: one row per account, indented by level, then the totals of
+// the postable accounts
+func WriteTrialBalanceCSV(tb *models.TrialBalance, lang string) ([]byte, error) {
+	t := i18n.New(lang)
+
+	records := append(ledgerReportHeaderRecords(t, t.TrialBalanceTitle(), tb.CompanyName, tb.NIT, tb.NRC,
+		t.FormatPeriodLabel(), tb.FromDate+" - "+tb.ToDate, tb.EstablishmentID, tb.EstablishmentCode, tb.EstablishmentName),
+		t.TrialBalanceHeaders())
+
+	for _, row := range tb.Rows {
+		records = append(records, append([]string{
+			row.Code,
+			strings.Repeat("  ", row.Level) + row.Name,
+			t.AccountTypeName(row.AccountType),
+		}, trialBalanceCells(row.TrialBalanceAmounts)...))
+	}
+	records = append(records, append([]string{"", t.TotalsLabel(), ""}, trialBalanceCells(tb.Totals)...))
+
+	return writeCSVRecords(records)
+}
+
+// trialBalanceCells formats the amount columns of the trial balance
+func trialBalanceCells(a models.TrialBalanceAmounts) []string {
+	return []string{
+		fmt.Sprintf("%.2f", a.OpeningDebit),
+		fmt.Sprintf("%.2f", a.OpeningCredit),
+		fmt.Sprintf("%.2f", a.Debits),
+		fmt.Sprintf("%.2f", a.Credits),
+		fmt.Sprintf("%.2f", a.ClosingDebit),
+		fmt.Sprintf("%.2f", a.ClosingCredit),
+	}
+}
+
+// WriteFinancialStatementCSV writes an income statement or balance sheet to
+// CSV format with translations: one block per account type with its total,
+// then the net income or the total of liabilities and equity
+func WriteFinancialStatementCSV(fs *models.FinancialStatement, lang string) ([]byte, error) {
+	t := i18n.New(lang)
+
+	periodLabel, period := statementPeriod(fs, t)
+	records := append(ledgerReportHeaderRecords(t, t.FinancialStatementTitle(fs.Statement), fs.CompanyName, fs.NIT, fs.NRC,
+		periodLabel, period, fs.EstablishmentID, fs.EstablishmentCode, fs.EstablishmentName),
+		append([]string{"", t.AccountLabel()}, statementColumnHeaders(fs, t)...))
+
+	for _, section := range fs.Sections {
+		records = append(records, []string{"", t.AccountTypeName(section.AccountType)})
+		for _, line := range section.Lines {
+			records = append(records, append([]string{
+				line.Code,
+				strings.Repeat("  ", line.Level) + line.Name,
+			}, amountCells(line.Amounts)...))
+		}
+		if fs.Statement == models.StatementBalance && section.AccountType == models.AccountTypeEquity {
+			records = append(records, append([]string{"", t.NetIncomeLabel()}, amountCells(fs.NetIncome)...))
+		}
+		records = append(records,
+			append([]string{"", t.SectionTotalLabel(section.AccountType)}, amountCells(section.Totals)...),
+			[]string{},
+		)
+	}
+
+	if fs.Statement == models.StatementBalance {
+		records = append(records, append([]string{"", t.TotalLiabilitiesEquityLabel()}, amountCells(fs.TotalLiabilitiesEquity)...))
+	} else {
+		records = append(records, append([]string{"", t.NetIncomeLabel()}, amountCells(fs.NetIncome)...))
+	}
+
+	return writeCSVRecords(records)
+}
+
+// amountCells formats one amount per statement column
+func amountCells(amounts []float64) []string {
+	cells := make([]string, len(amounts))
+	for i, amount := range amounts {
+		cells[i] = fmt.Sprintf("%.2f", amount)
+	}
+	return cells
+}
+
+// ledgerReportHeaderRecords returns the title, company, period and cost
+// center lines that open a ledger report, followed by a blank line
+func ledgerReportHeaderRecords(t *i18n.Translations, title, companyName, nit, nrc, periodLabel, period, establishmentID, establishmentCode, establishmentName string) [][]string {
+	records := [][]string{
+		{title},
+		{t.FormatCompanyLabel(), companyName},
+		{"NIT", nit},
+		{"NRC", nrc},
+		{periodLabel, period},
+	}
+	if establishmentID != "" {
+		records = append(records, []string{t.CostCenterLabel(), costCenterName(t, establishmentID, establishmentCode, establishmentName)})
+	}
+	return append(records, []string{})
+}
+
+// statementPeriod returns the label and value of the statement's period, or
+// of its date for the balance sheet
+func statementPeriod(fs *models.FinancialStatement, t *i18n.Translations) (string, string) {
+	if fs.Statement == models.StatementBalance {
+		return t.AsOfDateLabel(), fs.ToDate
+	}
+	return t.FormatPeriodLabel(), fs.FromDate + " - " + fs.ToDate
+}
+
+// statementColumnHeaders titles each amount column with its period or cost
+// center
+func statementColumnHeaders(fs *models.FinancialStatement, t *i18n.Translations) []string {
+	headers := make([]string, len(fs.Columns))
+	for i, column := range fs.Columns {
+		switch {
+		case column.Total:
+			headers[i] = t.TotalsLabel()
+		case column.Unassigned:
+			headers[i] = t.UnassignedCostCenterLabel()
+		case column.EstablishmentID != "":
+			headers[i] = column.EstablishmentCode + " " + column.EstablishmentName
+		case column.FromDate == "":
+			headers[i] = column.ToDate
+		default:
+			headers[i] = column.FromDate + " - " + column.ToDate
+		}
+	}
+	return headers
+}
+
+// costCenterName names the establishment a report is narrowed to
+func costCenterName(t *i18n.Translations, establishmentID, code, name string) string {
+	if establishmentID == models.CostCenterNone {
+		return t.UnassignedCostCenterLabel()
+	}
+	return code + " " + name
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/csv"
 	"fmt"
+	"strings"
 	"time"
 
 	"cuentas/internal/i18n"
@@ -35,6 +36,7 @@ func WriteEventsCSV(events []models.InventoryEventWithItem, lang string) ([]byte
 			event.EventTimestamp.Format(time.RFC3339),
 			event.SKU,
 			event.ItemName,
+			strings.TrimSpace(event.EstablishmentCode + " " + event.EstablishmentName),
 			t.EventType(event.EventType), // Translate event type
 			fmt.Sprintf("%.2f", event.Quantity),
 			fmt.Sprintf("%.2f", event.UnitCost.Float64()),
@@ -134,6 +136,8 @@ func WriteInventoryStatesCSV(states []models.InventoryStateWithItem, lang string
 			state.SKU,
 			state.ItemName,
 			t.ItemType(state.TipoItem), // Translate item type
+			state.EstablishmentCode,
+			state.EstablishmentName,
 			fmt.Sprintf("%.2f", state.CurrentQuantity),
 			fmt.Sprintf("%.2f", state.CurrentAvgCost.Float64()),
 			fmt.Sprintf("%.2f", state.CurrentTotalCost.Float64()),
@@ -153,11 +157,50 @@ func WriteInventoryStatesCSV(states []models.InventoryStateWithItem, lang string
 	return buf.Bytes(), nil
 }
 
+// WriteInventoryTotalsCSV writes each item's stock summed over its locations
+func WriteInventoryTotalsCSV(totals []models.InventoryItemTotals, lang string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	writer := csv.NewWriter(buf)
+
+	// Get translations
+	t := i18n.New(lang)
+
+	// Write header
+	if err := writer.Write(t.InventoryTotalsHeaders()); err != nil {
+		return nil, err
+	}
+
+	// Write data rows
+	for _, total := range totals {
+		row := []string{
+			total.SKU,
+			total.ItemName,
+			t.ItemType(total.TipoItem), // Translate item type
+			fmt.Sprintf("%.2f", total.CurrentQuantity),
+			fmt.Sprintf("%.2f", total.CurrentAvgCost.Float64()),
+			fmt.Sprintf("%.2f", total.CurrentTotalCost.Float64()),
+			fmt.Sprintf("%d", total.Locations),
+			total.UpdatedAt.Format(time.RFC3339),
+		}
+
+		if err := writer.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
 // DetermineFormat determines output format from Accept header or query param
 func DetermineFormat(acceptHeader, formatParam string) string {
 	// Query param takes precedence
-	if formatParam == "csv" {
-		return "csv"
+	if formatParam == "csv" || formatParam == "pdf" {
+		return formatParam
 	}
 	if formatParam == "json" || formatParam == "" {
 		return "json"
@@ -167,6 +210,9 @@ func DetermineFormat(acceptHeader, formatParam string) string {
 	if acceptHeader == "text/csv" || acceptHeader == "application/csv" {
 		return "csv"
 	}
+	if acceptHeader == "application/pdf" {
+		return "pdf"
+	}
 
 	// Default to JSON
 	return "json"
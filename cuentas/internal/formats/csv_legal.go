@@ -9,10 +9,12 @@ import (
 	"strings"
 )
 
-// WriteLegalInventoryRegisterCSV writes a legal inventory register per item (Article 142-A compliant)
+// WriteLegalInventoryRegisterCSV writes a legal inventory register per item (Article 142-A compliant),
+// for one location or consolidated when location is nil
 func WriteLegalInventoryRegisterCSV(
 	companyInfo *models.CompanyLegalInfo,
 	item *models.InventoryItem,
+	location *models.Establishment,
 	events []models.InventoryEventWithItem,
 	startDate, endDate string,
 	lang string,
@@ -21,6 +23,14 @@ func WriteLegalInventoryRegisterCSV(
 	writer := csv.NewWriter(buf)
 	t := i18n.New(lang)
 
+	locationName := t.ConsolidatedLabel()
+	if location != nil {
+		locationName = location.Nombre
+		if location.CodEstablecimiento != "" {
+			locationName = fmt.Sprintf("%s - %s", location.CodEstablecimiento, location.Nombre)
+		}
+	}
+
 	// Header section with additional legal requirements
 	header := [][]string{
 		{t.FormatRegisterHeader()},
@@ -29,6 +39,7 @@ func WriteLegalInventoryRegisterCSV(
 		{"NRC", companyInfo.NRC},
 		{t.FormatPeriodLabel(), fmt.Sprintf("Del %s al %s", startDate, endDate)},
 		{t.FormatItemLabel(), fmt.Sprintf("%s - %s", item.SKU, item.Name)},
+		{t.FormatLocationLabel(), locationName},
 		{"Unidad de Medida", item.UnitOfMeasure},            // NEW
 		{"Método de Valuación", "Costo Promedio Ponderado"}, // NEW
 		{}, // Blank row
@@ -55,7 +66,9 @@ func WriteLegalInventoryRegisterCSV(
 			} else if event.Quantity < 0 {
 				unitsOut = fmt.Sprintf("%.2f", -event.Quantity)
 			}
-		} else if event.EventType == "SALE" || event.EventType == "RETURN" {
+		} else if event.EventType == "TRANSFER_IN" {
+			unitsIn = fmt.Sprintf("%.2f", event.Quantity)
+		} else if event.EventType == "TRANSFER_OUT" || event.EventType == "SALE" || event.EventType == "RETURN" {
 			if event.Quantity > 0 {
 				unitsOut = fmt.Sprintf("%.2f", event.Quantity)
 			}
@@ -0,0 +1,103 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"cuentas/internal/i18n"
+	"cuentas/internal/models"
+)
+
+// WriteStatementCSV writes a client statement to CSV format with translations:
+// the entries with their running balance, then the open documents
+func WriteStatementCSV(statement *models.ClientStatement, lang string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	writer := csv.NewWriter(buf)
+
+	t := i18n.New(lang)
+
+	records := [][]string{
+		{t.StatementTitle()},
+		{t.FormatCompanyLabel(), statement.CompanyName},
+		{t.ClientLabel(), statement.ClientName},
+		{t.FormatPeriodLabel(), statement.FromDate, statement.ToDate},
+		{},
+		t.StatementHeaders(),
+		{"", t.OpeningBalanceLabel(), "", "", "", "", "", fmt.Sprintf("%.2f", statement.OpeningBalance)},
+	}
+
+	for _, entry := range statement.Entries {
+		records = append(records, []string{
+			entry.Date.Format("2006-01-02"),
+			t.StatementEntryType(entry.Type, stringValue(entry.DteType)),
+			entry.DocumentNumber,
+			stringValue(entry.NumeroControl),
+			statementAppliedTo(entry),
+			fmt.Sprintf("%.2f", entry.Charge),
+			fmt.Sprintf("%.2f", entry.Credit),
+			fmt.Sprintf("%.2f", entry.Balance),
+		})
+	}
+
+	records = append(records,
+		[]string{"", t.TotalsLabel(), "", "", "",
+			fmt.Sprintf("%.2f", statement.TotalCharges),
+			fmt.Sprintf("%.2f", statement.TotalCredits),
+			""},
+		[]string{"", t.ClosingBalanceLabel(), "", "", "", "", "", fmt.Sprintf("%.2f", statement.ClosingBalance)},
+		[]string{},
+		[]string{t.OpenDocumentsTitle()},
+		t.OpenDocumentsHeaders(),
+	)
+
+	for _, doc := range statement.OpenDocuments {
+		records = append(records, []string{
+			doc.InvoiceNumber,
+			stringValue(doc.NumeroControl),
+			doc.Date,
+			doc.DueDate,
+			fmt.Sprintf("%d", doc.DaysPastDue),
+			fmt.Sprintf("%.2f", doc.Total),
+			fmt.Sprintf("%.2f", doc.DebitNotes),
+			fmt.Sprintf("%.2f", doc.CreditNotes),
+			fmt.Sprintf("%.2f", doc.Payments),
+			fmt.Sprintf("%.2f", doc.Balance),
+		})
+	}
+
+	for _, record := range records {
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// statementAppliedTo lists the documents a statement entry was applied to, with
+// the amount on each when it was split across several
+func statementAppliedTo(entry models.StatementEntry) string {
+	parts := make([]string, 0, len(entry.AppliedTo))
+	for _, applied := range entry.AppliedTo {
+		if len(entry.AppliedTo) == 1 {
+			parts = append(parts, applied.InvoiceNumber)
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s (%.2f)", applied.InvoiceNumber, applied.Amount))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,223 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cuentas/internal/i18n"
+	"cuentas/internal/models"
+)
+
+// WriteConsumerSalesBookCSV writes the libro de ventas a consumidor final to
+// CSV format with translations: one row per day and point of sale, the month's
+// totals, then the totals per point of sale
+func WriteConsumerSalesBookCSV(book *models.ConsumerSalesBook, lang string) ([]byte, error) {
+	t := i18n.New(lang)
+
+	records := [][]string{
+		{t.ConsumerSalesBookTitle()},
+		{t.FormatCompanyLabel(), book.CompanyName},
+		{"NIT", book.NIT},
+		{"NRC", book.NRC},
+		{t.FormatPeriodLabel(), models.TaxBookPeriod{Year: book.Year, Month: book.Month}.String()},
+		{},
+		t.ConsumerSalesBookHeaders(),
+	}
+
+	for _, row := range book.Rows {
+		records = append(records, append([]string{
+			row.Date,
+			row.EstablishmentCode + " " + row.EstablishmentName,
+			row.PointOfSaleCode + " " + row.PointOfSaleName,
+			t.StatementEntryType(models.StatementEntryInvoice, row.TipoDte),
+			row.FirstNumeroControl,
+			row.LastNumeroControl,
+		}, consumerSalesCells(row.ConsumerSalesAmounts)...))
+	}
+	records = append(records,
+		append([]string{"", t.TotalsLabel(), "", "", "", ""}, consumerSalesCells(book.Totals)...),
+		[]string{},
+		[]string{t.PointOfSaleTotalsTitle()},
+		t.PointOfSaleTotalsHeaders(),
+	)
+
+	for _, pos := range book.PointsOfSale {
+		records = append(records, append([]string{
+			pos.EstablishmentCode + " " + pos.EstablishmentName,
+			pos.PointOfSaleCode + " " + pos.PointOfSaleName,
+		}, consumerSalesCells(pos.ConsumerSalesAmounts)...))
+	}
+
+	return writeCSVRecords(records)
+}
+
+// consumerSalesCells formats the amount columns of the consumer sales book
+func consumerSalesCells(a models.ConsumerSalesAmounts) []string {
+	return []string{
+		strconv.Itoa(a.DocumentCount),
+		fmt.Sprintf("%.2f", a.VentasExentas),
+		fmt.Sprintf("%.2f", a.VentasNoSujetas),
+		fmt.Sprintf("%.2f", a.VentasGravadas),
+		fmt.Sprintf("%.2f", a.Exportaciones),
+		fmt.Sprintf("%.2f", a.Total),
+		fmt.Sprintf("%.2f", a.IVADebito),
+	}
+}
+
+// writeCSVRecords writes records as CSV
+func writeCSVRecords(records [][]string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	writer := csv.NewWriter(buf)
+
+	for _, record := range records {
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// WriteContributorSalesBookCSV writes the libro de ventas a contribuyentes to
+// CSV format with translations: one numbered row per document, the month's
+// totals, then the totals per DTE type
+func WriteContributorSalesBookCSV(book *models.ContributorSalesBook, lang string) ([]byte, error) {
+	t := i18n.New(lang)
+
+	records := [][]string{
+		{t.ContributorSalesBookTitle()},
+		{t.FormatCompanyLabel(), book.CompanyName},
+		{"NIT", book.NIT},
+		{"NRC", book.NRC},
+		{t.FormatPeriodLabel(), models.TaxBookPeriod{Year: book.Year, Month: book.Month}.String()},
+		{},
+		t.ContributorSalesBookHeaders(),
+	}
+
+	for i, row := range book.Rows {
+		records = append(records, append([]string{
+			strconv.Itoa(i + 1),
+			row.Date,
+			t.DocumentTypeName(row.TipoDte),
+			row.NumeroControl,
+			row.CodigoGeneracion,
+			row.ReceptorNRC,
+			row.ReceptorNIT,
+			row.ReceptorName,
+			strings.Join(row.RelatedDocuments, "; "),
+		}, contributorSalesCells(row.ContributorSalesAmounts)...))
+	}
+	records = append(records,
+		append([]string{"", t.TotalsLabel(), "", "", "", "", "", "", ""}, contributorSalesCells(book.Totals)...),
+		[]string{},
+		[]string{t.DocumentTypeTotalsTitle()},
+		t.ContributorSalesTotalsHeaders(),
+	)
+
+	for _, subtotal := range book.DocumentTypes {
+		records = append(records, append([]string{
+			t.DocumentTypeName(subtotal.TipoDte),
+			strconv.Itoa(subtotal.DocumentCount),
+		}, contributorSalesCells(subtotal.ContributorSalesAmounts)...))
+	}
+
+	return writeCSVRecords(records)
+}
+
+// contributorSalesCells formats the amount columns of the taxpayer sales book
+func contributorSalesCells(a models.ContributorSalesAmounts) []string {
+	return []string{
+		fmt.Sprintf("%.2f", a.VentasExentas),
+		fmt.Sprintf("%.2f", a.VentasNoSujetas),
+		fmt.Sprintf("%.2f", a.VentasGravadas),
+		fmt.Sprintf("%.2f", a.IVADebito),
+		fmt.Sprintf("%.2f", a.IVAPercibido),
+		fmt.Sprintf("%.2f", a.IVARetenido),
+		fmt.Sprintf("%.2f", a.Total),
+	}
+}
+
+// WritePurchaseBookCSV writes the libro de compras to CSV format with
+// translations: one numbered row per purchase, the month's totals, then the
+// IVA retentions issued and suffered
+func WritePurchaseBookCSV(book *models.PurchaseBook, lang string) ([]byte, error) {
+	t := i18n.New(lang)
+
+	records := [][]string{
+		{t.PurchaseBookTitle()},
+		{t.FormatCompanyLabel(), book.CompanyName},
+		{"NIT", book.NIT},
+		{"NRC", book.NRC},
+		{t.FormatPeriodLabel(), models.TaxBookPeriod{Year: book.Year, Month: book.Month}.String()},
+		{},
+		t.PurchaseBookHeaders(),
+	}
+
+	for i, row := range book.Rows {
+		codigoGeneracion := ""
+		if row.CodigoGeneracion != nil {
+			codigoGeneracion = *row.CodigoGeneracion
+		}
+		records = append(records, append([]string{
+			strconv.Itoa(i + 1),
+			row.Date,
+			t.PurchaseDocumentType(row.PurchaseType, row.TipoDte),
+			row.DocumentNumber,
+			codigoGeneracion,
+			row.SupplierNRC,
+			row.SupplierDocument,
+			row.SupplierName,
+		}, purchaseBookCells(row.PurchaseBookAmounts)...))
+	}
+	records = append(records,
+		append([]string{"", t.TotalsLabel(), "", "", "", "", "", ""}, purchaseBookCells(book.Totals)...),
+		[]string{},
+		[]string{t.RetentionsTitle()},
+		t.RetentionsHeaders(),
+	)
+
+	for _, retention := range book.Retentions {
+		records = append(records, []string{
+			t.RetentionKind(retention.Kind),
+			retention.Date,
+			t.DocumentTypeName(retention.TipoDte),
+			retention.NumeroControl,
+			retention.CounterpartyNRC,
+			retention.CounterpartyNIT,
+			retention.CounterpartyName,
+			retention.RelatedDocument,
+			fmt.Sprintf("%.2f", retention.MontoSujeto),
+			fmt.Sprintf("%.2f", retention.IVARetenido),
+		})
+	}
+
+	issuedLabel, sufferedLabel := t.RetentionTotalsLabels()
+	records = append(records,
+		[]string{issuedLabel, fmt.Sprintf("%.2f", book.RetencionesEmitidas)},
+		[]string{sufferedLabel, fmt.Sprintf("%.2f", book.RetencionesSufridas)},
+	)
+
+	return writeCSVRecords(records)
+}
+
+// purchaseBookCells formats the amount columns of the purchases book
+func purchaseBookCells(a models.PurchaseBookAmounts) []string {
+	return []string{
+		fmt.Sprintf("%.2f", a.ComprasExentasInternas),
+		fmt.Sprintf("%.2f", a.ComprasExentasImportaciones),
+		fmt.Sprintf("%.2f", a.ComprasGravadasInternas),
+		fmt.Sprintf("%.2f", a.ImportacionesGravadas),
+		fmt.Sprintf("%.2f", a.IVACreditoFiscal),
+		fmt.Sprintf("%.2f", a.SujetosExcluidos),
+		fmt.Sprintf("%.2f", a.Total),
+		fmt.Sprintf("%.2f", a.IVARetenido),
+	}
+}
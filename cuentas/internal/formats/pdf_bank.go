@@ -0,0 +1,126 @@
+package formats
+
+import (
+	"fmt"
+
+	"cuentas/internal/i18n"
+	"cuentas/internal/models"
+)
+
+// bankSummaryColumns is the label / value layout of the reconciliation summary
+var bankSummaryColumns = []pdfColumn{
+	{Width: 80},
+	{Width: 30, Kind: "money"},
+}
+
+// unmatchedBankLineColumns is the layout of the statement lines not in the
+// books (letter page, 195.9mm usable)
+var unmatchedBankLineColumns = []pdfColumn{
+	{Width: 20},
+	{Width: 115.9},
+	{Width: 35},
+	{Width: 25, Kind: "money"},
+}
+
+// unmatchedBankItemColumns is the layout of the payments not in the bank
+var unmatchedBankItemColumns = []pdfColumn{
+	{Width: 18},
+	{Width: 24},
+	{Width: 30},
+	{Width: 45.9},
+	{Width: 23},
+	{Width: 30},
+	{Width: 25, Kind: "money"},
+}
+
+// WriteBankReconciliationPDF renders a bank account's reconciliation: the
+// summary, then the statement lines not in the books and the payments not in
+// the bank
+func WriteBankReconciliationPDF(rec *models.BankReconciliation, lang string) ([]byte, error) {
+	t := i18n.New(lang)
+	r := newTaxBookPDF("P", lang)
+	pdf := r.pdf
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 6, r.tr(rec.CompanyName), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 6, r.tr(t.BankReconciliationTitle()), "", 1, "C", false, 0, "")
+	pdf.Ln(3)
+
+	rows := [][2]string{
+		{"NIT:", rec.NIT},
+		{"NRC:", rec.NRC},
+		{t.BankAccountLabel() + ":", bankAccountName(rec.BankAccount)},
+		{t.FormatPeriodLabel() + ":", rec.FromDate + " - " + rec.ToDate},
+	}
+	if rec.StatementBalance != nil {
+		rows = append(rows, [2]string{t.StatementBalanceLabel(rec.StatementBalanceOn) + ":", formatMoneyPDF(*rec.StatementBalance)})
+	}
+	r.labelBlock(10, pdf.GetY(), 110, rows)
+	pdf.SetXY(10, pdf.GetY()+3)
+
+	labels := t.BankReconciliationSummaryLabels()
+	for i, value := range bankSummaryValues(rec.Summary, formatMoneyPDF) {
+		r.statementRow(bankSummaryColumns, []string{labels[i], value}, i == 0)
+	}
+	pdf.Ln(3)
+
+	r.sectionBand(t.UnmatchedBankLinesTitle())
+	cols := withHeaders(unmatchedBankLineColumns, t.UnmatchedBankLinesHeaders())
+	r.tableHeader(cols)
+	for _, line := range rec.UnmatchedLines {
+		r.statementRow(cols, unmatchedBankLineCells(line, formatMoneyPDF), false)
+	}
+	pdf.Ln(3)
+
+	r.sectionBand(t.UnmatchedBankItemsTitle())
+	cols = withHeaders(unmatchedBankItemColumns, t.UnmatchedBankItemsHeaders())
+	r.tableHeader(cols)
+	for _, item := range rec.UnmatchedItems {
+		r.statementRow(cols, unmatchedBankItemCells(t, item, formatMoneyPDF), false)
+	}
+
+	return r.output()
+}
+
+// bankSummaryValues formats the reconciliation summary in the order of its
+// labels
+func bankSummaryValues(s models.BankReconciliationSummary, money func(float64) string) []string {
+	return []string{
+		fmt.Sprintf("%d", s.StatementLines),
+		fmt.Sprintf("%d", s.MatchedLines),
+		fmt.Sprintf("%d", s.IgnoredLines),
+		fmt.Sprintf("%d", s.UnmatchedLines),
+		money(s.Deposits),
+		money(s.Withdrawals),
+		money(s.UnmatchedDeposits),
+		money(s.UnmatchedWithdrawals),
+		money(s.UnmatchedReceipts),
+		money(s.UnmatchedDisbursements),
+	}
+}
+
+func unmatchedBankLineCells(line models.BankStatementLine, money func(float64) string) []string {
+	reference := ""
+	if line.Reference != nil {
+		reference = *line.Reference
+	}
+	return []string{line.TransactionDate, line.Description, reference, money(line.Amount)}
+}
+
+func unmatchedBankItemCells(t *i18n.Translations, item models.BankBookItem, money func(float64) string) []string {
+	reference := ""
+	if item.Reference != nil {
+		reference = *item.Reference
+	}
+	return []string{
+		item.Date, t.BankItemKind(item.Kind), item.Document, item.Counterparty,
+		item.PaymentMethod, reference, money(item.Amount),
+	}
+}
+
+// bankAccountName names a bank account by bank, number and the company's name
+// for it
+func bankAccountName(a models.BankAccount) string {
+	return fmt.Sprintf("%s %s (%s)", a.BankName, a.AccountNumber, a.Name)
+}
@@ -0,0 +1,881 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cuentas/internal/codigos"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/skip2/go-qrcode"
+)
+
+// consultaPublicaURL is Hacienda's public lookup page, encoded in every readable DTE's QR code
+const consultaPublicaURL = "https://admin.factura.gob.sv/consultaPublica?ambiente=%s&codGen=%s&fechaEmi=%s"
+
+// DTEPrintable holds what the readable version (versión legible) of a DTE is
+// rendered from: the unsigned JSON exactly as transmitted plus Hacienda's response
+type DTEPrintable struct {
+	Document        []byte     // Unsigned DTE JSON
+	SelloRecibido   *string    // Nil while the document has not been received by Hacienda
+	FhProcesamiento *time.Time // When Hacienda processed it
+	ConsultaURL     string     // Optional; built from identificacion when empty
+	Invalidated     bool       // Prints an ANULADO watermark
+}
+
+// ConsultaPublicaURL builds the Hacienda public lookup URL for a DTE
+func ConsultaPublicaURL(ambiente, codigoGeneracion, fecEmi string) string {
+	return fmt.Sprintf(consultaPublicaURL, ambiente, strings.ToUpper(codigoGeneracion), fecEmi)
+}
+
+// pdfColumn describes one column of the cuerpoDocumento table
+type pdfColumn struct {
+	Header string
+	Key    string  // Field in the cuerpoDocumento item
+	Width  float64 // mm
+	Kind   string  // "index", "text", "qty", "money", "unit", "tipoDte", "donacion"
+	SumKey string  // Resumen field printed in the SUMA row under this column
+}
+
+// dteColumns holds the item table layout per DTE type (letter page, 195.9mm usable)
+var dteColumns = map[string][]pdfColumn{
+	"default": {
+		{"N°", "numItem", 8, "index", ""},
+		{"Código", "codigo", 22, "text", ""},
+		{"Cantidad", "cantidad", 16, "qty", ""},
+		{"Unidad", "uniMedida", 18, "unit", ""},
+		{"Descripción", "descripcion", 55, "text", ""},
+		{"Precio Unitario", "precioUni", 18, "money", ""},
+		{"Descuento por ítem", "montoDescu", 16, "money", ""},
+		{"Ventas no sujetas", "ventaNoSuj", 14, "money", "totalNoSuj"},
+		{"Ventas exentas", "ventaExenta", 14, "money", "totalExenta"},
+		{"Ventas gravadas", "ventaGravada", 14.9, "money", "totalGravada"},
+	},
+	codigos.DocTypeComprobanteRetencion: {
+		{"N°", "numItem", 8, "index", ""},
+		{"Tipo de Documento", "tipoDte", 28, "tipoDte", ""},
+		{"N° de Documento", "numDocumento", 50, "text", ""},
+		{"Fecha", "fechaEmision", 20, "text", ""},
+		{"Descripción", "descripcion", 50, "text", ""},
+		{"Monto Sujeto a Retención", "montoSujetoGrav", 20, "money", "totalSujetoRetencion"},
+		{"IVA Retenido", "ivaRetenido", 19.9, "money", "totalIVAretenido"},
+	},
+	codigos.DocTypeComprobanteLiquidacion: {
+		{"N°", "numItem", 8, "index", ""},
+		{"Tipo de Documento", "tipoDte", 26, "tipoDte", ""},
+		{"N° de Documento", "numeroDocumento", 48, "text", ""},
+		{"Fecha", "fechaGeneracion", 18, "text", ""},
+		{"Ventas no sujetas", "ventaNoSuj", 19, "money", "totalNoSuj"},
+		{"Ventas exentas", "ventaExenta", 19, "money", "totalExenta"},
+		{"Ventas gravadas", "ventaGravada", 19, "money", "totalGravada"},
+		{"Exportaciones", "exportaciones", 19, "money", "totalExportacion"},
+		{"IVA", "ivaItem", 19.9, "money", ""},
+	},
+	codigos.DocTypeFacturasExportacion: {
+		{"N°", "numItem", 8, "index", ""},
+		{"Código", "codigo", 20, "text", ""},
+		{"Cantidad", "cantidad", 15, "qty", ""},
+		{"Unidad", "uniMedida", 18, "unit", ""},
+		{"Descripción", "descripcion", 62, "text", ""},
+		{"Precio Unitario", "precioUni", 18, "money", ""},
+		{"Descuento por ítem", "montoDescu", 17, "money", ""},
+		{"Otros montos no afectos", "noGravado", 18, "money", "totalNoGravado"},
+		{"Ventas gravadas", "ventaGravada", 19.9, "money", "totalGravada"},
+	},
+	codigos.DocTypeFacturaSujetoExcluido: {
+		{"N°", "numItem", 8, "index", ""},
+		{"Código", "codigo", 22, "text", ""},
+		{"Cantidad", "cantidad", 16, "qty", ""},
+		{"Unidad", "uniMedida", 18, "unit", ""},
+		{"Descripción", "descripcion", 75, "text", ""},
+		{"Precio Unitario", "precioUni", 20, "money", ""},
+		{"Descuento por ítem", "montoDescu", 18, "money", ""},
+		{"Compra", "compra", 18.9, "money", "totalCompra"},
+	},
+	codigos.DocTypeComprobanteDonacion: {
+		{"N°", "numItem", 8, "index", ""},
+		{"Tipo", "tipoDonacion", 18, "donacion", ""},
+		{"Código", "codigo", 20, "text", ""},
+		{"Cantidad", "cantidad", 15, "qty", ""},
+		{"Unidad", "uniMedida", 18, "unit", ""},
+		{"Descripción", "descripcion", 62, "text", ""},
+		{"Valor Unitario", "valorUni", 18, "money", ""},
+		{"Depreciación", "depreciacion", 17, "money", ""},
+		{"Valor Donado", "valor", 19.9, "money", "valorTotal"},
+	},
+}
+
+// resumenLabels lists, in print order, the resumen (or Type 09 cuerpoDocumento)
+// amounts shown below the item table. Fields absent from a document are skipped.
+var resumenLabels = []struct{ Key, Label string }{
+	{"subTotalVentas", "Suma Total de Operaciones:"},
+	{"valorOperaciones", "Valor de las Operaciones:"},
+	{"montoSinPercepcion", "Monto sin Percepción:"},
+	{"descuNoSuj", "Monto global Desc., Rebajas y otros a ventas no sujetas:"},
+	{"descuExenta", "Monto global Desc., Rebajas y otros a ventas Exentas:"},
+	{"descuGravada", "Monto global Desc., Rebajas y otros a ventas Gravadas:"},
+	{"descuento", "Monto global Desc., Rebajas y otros:"},
+	{"descu", "Monto global Desc., Rebajas y otros:"},
+	{"totalDescu", "Total Descuentos:"},
+	{"seguro", "Seguro:"},
+	{"flete", "Flete:"},
+	{"subTotal", "Sub-Total:"},
+	{"iva", "IVA:"},
+	{"totalIva", "IVA 13%:"},
+	{"ivaPerci1", "IVA Percibido:"},
+	{"ivaPerci", "IVA Percibido:"},
+	{"montoSujetoPercepcion", "Monto Sujeto a Percepción:"},
+	{"ivaPercibido", "IVA Percibido:"},
+	{"comision", "Comisión:"},
+	{"ivaComision", "IVA de la Comisión:"},
+	{"ivaRete1", "IVA Retenido:"},
+	{"reteRenta", "Retención Renta:"},
+	{"montoTotalOperacion", "Monto Total de la Operación:"},
+	{"totalNoGravado", "Total Otros Montos No Afectos:"},
+	{"totalCompra", "Total Compra:"},
+	{"total", "Total:"},
+	{"totalPagar", "Total a Pagar:"},
+	{"liquidoApagar", "Líquido a Pagar:"},
+	{"totalSujetoRetencion", "Total Monto Sujeto a Retención:"},
+	{"totalIVAretenido", "Total IVA Retenido:"},
+	{"valorTotal", "Valor Total Donado:"},
+}
+
+// extensionLabels are the extension fields printed in the APÉNDICES section
+var extensionLabels = []struct{ Key, Label string }{
+	{"nombEntrega", "Responsable por parte del emisor"},
+	{"docuEntrega", "N° de Documento (emisor)"},
+	{"nombRecibe", "Responsable por parte del receptor"},
+	{"docuRecibe", "N° de Documento (receptor)"},
+	{"codEmpleado", "Código de empleado"},
+	{"placaVehiculo", "Placa del vehículo"},
+	{"observaciones", "Observaciones"},
+}
+
+// receptorDocumentTypes is CAT-022 (tipo de documento de identificación del receptor)
+var receptorDocumentTypes = map[string]string{
+	"36": "NIT",
+	"13": "DUI",
+	"37": "Otro",
+	"03": "Pasaporte",
+	"02": "Carnet de Residente",
+}
+
+// dtePDF carries the state shared by the section renderers
+type dtePDF struct {
+	pdf     *fpdf.Fpdf
+	tr      func(string) string
+	doc     map[string]interface{}
+	tipoDte string
+}
+
+// WriteDTEPDF renders the readable version of a DTE: header with QR code, emisor
+// and receptor blocks, line items, resumen, sello de recepción and appendices.
+func WriteDTEPDF(p *DTEPrintable) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(p.Document, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse DTE JSON: %w", err)
+	}
+
+	ident := jsonObject(doc, "identificacion")
+	if ident == nil {
+		return nil, fmt.Errorf("DTE JSON has no identificacion")
+	}
+
+	qrURL := p.ConsultaURL
+	if qrURL == "" {
+		qrURL = ConsultaPublicaURL(jsonString(ident, "ambiente"), jsonString(ident, "codigoGeneracion"), jsonString(ident, "fecEmi"))
+	}
+	qr, err := qrcode.New(qrURL, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+
+	pdf := fpdf.New("P", "mm", "Letter", "")
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AliasNbPages("{nb}")
+
+	r := &dtePDF{
+		pdf:     pdf,
+		tr:      pdf.UnicodeTranslatorFromDescriptor(""),
+		doc:     doc,
+		tipoDte: jsonString(ident, "tipoDte"),
+	}
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 5, r.tr(fmt.Sprintf("Página %d/{nb}", pdf.PageNo())), "", 0, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+	if p.Invalidated {
+		pdf.SetHeaderFunc(r.writeInvalidatedWatermark)
+	}
+
+	pdf.AddPage()
+	r.writeHeader(ident, p, qr.Bitmap())
+	r.writeParties()
+	r.writeRelatedDocuments()
+	r.writeItems()
+	r.writeResumen()
+	r.writeAppendices()
+
+	if err := pdf.Error(); err != nil {
+		return nil, fmt.Errorf("failed to render PDF: %w", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := pdf.Output(buf); err != nil {
+		return nil, fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeHeader prints the title, the identification block and the QR code
+func (r *dtePDF) writeHeader(ident map[string]interface{}, p *DTEPrintable, qr [][]bool) {
+	pdf := r.pdf
+
+	docName, ok := codigos.GetDocumentTypeName(r.tipoDte)
+	if !ok {
+		docName = "Documento " + r.tipoDte
+	}
+
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 5, r.tr("DOCUMENTO TRIBUTARIO ELECTRÓNICO"), "", 1, "C", false, 0, "")
+	pdf.CellFormat(0, 5, r.tr(strings.ToUpper(docName)), "", 0, "C", false, 0, "")
+	pdf.SetX(170)
+	pdf.CellFormat(35.9, 5, fmt.Sprintf("Ver.%s", jsonText(ident, "version")), "", 1, "R", false, 0, "")
+	pdf.Ln(4)
+
+	top := pdf.GetY()
+
+	sello := "Pendiente de recepción"
+	if p.SelloRecibido != nil && *p.SelloRecibido != "" {
+		sello = *p.SelloRecibido
+	}
+	r.labelBlock(10, top, 80, [][2]string{
+		{"Código de Generación:", jsonString(ident, "codigoGeneracion")},
+		{"Número de Control:", jsonString(ident, "numeroControl")},
+		{"Sello de Recepción:", sello},
+	})
+
+	r.drawQR(qr, 93, top-2, 30)
+
+	modelo := "Modelo Facturación previo"
+	if jsonText(ident, "tipoModelo") == "2" {
+		modelo = "Modelo Facturación diferido"
+	}
+	transmision := "Transmisión normal"
+	if jsonText(ident, "tipoOperacion") == "2" {
+		transmision = "Transmisión por contingencia"
+	}
+	fecha := strings.TrimSpace(jsonString(ident, "fecEmi") + " " + jsonString(ident, "horEmi"))
+	rows := [][2]string{
+		{"Modelo de Facturación:", modelo},
+		{"Tipo de Transmisión:", transmision},
+		{"Fecha y Hora de Generación:", fecha},
+	}
+	if p.FhProcesamiento != nil {
+		rows = append(rows, [2]string{"Fecha y Hora de Procesamiento:", p.FhProcesamiento.Format("2006-01-02 15:04:05")})
+	}
+	r.labelBlock(130, top, 75.9, rows)
+
+	pdf.SetY(top + 32)
+}
+
+// labelBlock prints bold label / value pairs in a column
+func (r *dtePDF) labelBlock(x, y, w float64, rows [][2]string) {
+	pdf := r.pdf
+	pdf.SetY(y)
+	for _, row := range rows {
+		pdf.SetX(x)
+		pdf.SetFont("Helvetica", "B", 8)
+		pdf.CellFormat(w, 3.8, r.tr(row[0]), "", 1, "L", false, 0, "")
+		pdf.SetX(x)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.MultiCell(w, 3.8, r.tr(row[1]), "", "L", false)
+	}
+}
+
+// drawQR draws the QR modules as filled squares so the code stays sharp at any zoom
+func (r *dtePDF) drawQR(bitmap [][]bool, x, y, size float64) {
+	if len(bitmap) == 0 {
+		return
+	}
+	module := size / float64(len(bitmap))
+	r.pdf.SetFillColor(0, 0, 0)
+	for row, cells := range bitmap {
+		for col, dark := range cells {
+			if dark {
+				r.pdf.Rect(x+float64(col)*module, y+float64(row)*module, module, module, "F")
+			}
+		}
+	}
+}
+
+// writeParties prints the issuer and counterpart boxes side by side
+func (r *dtePDF) writeParties() {
+	issuerKey, issuerTitle := "emisor", "Emisor"
+	counterKey, counterTitle := "receptor", "Receptor"
+	switch r.tipoDte {
+	case codigos.DocTypeFacturaSujetoExcluido:
+		counterKey, counterTitle = "sujetoExcluido", "Sujeto Excluido"
+	case codigos.DocTypeComprobanteDonacion:
+		issuerKey, issuerTitle = "donatario", "Donatario"
+		counterKey, counterTitle = "donante", "Donante"
+	}
+
+	top := r.pdf.GetY()
+	leftBottom := r.partyBox(10, top, 95, issuerTitle, jsonObject(r.doc, issuerKey))
+	rightBottom := r.partyBox(110.9, top, 95, counterTitle, jsonObject(r.doc, counterKey))
+
+	bottom := leftBottom
+	if rightBottom > bottom {
+		bottom = rightBottom
+	}
+	r.pdf.RoundedRect(10, top, 95, bottom-top, 3, "1234", "D")
+	r.pdf.RoundedRect(110.9, top, 95, bottom-top, 3, "1234", "D")
+	r.pdf.SetY(bottom + 4)
+}
+
+// partyBox prints one party's lines and returns the Y where the box ends
+func (r *dtePDF) partyBox(x, y, w float64, title string, party map[string]interface{}) float64 {
+	pdf := r.pdf
+	pdf.SetXY(x, y+1)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.CellFormat(w, 4, r.tr(title), "B", 1, "C", false, 0, "")
+
+	if party == nil {
+		pdf.SetX(x + 2)
+		pdf.CellFormat(w-4, 4, r.tr("Sin receptor identificado"), "", 1, "L", false, 0, "")
+		return pdf.GetY() + 2
+	}
+
+	pdf.SetX(x + 2)
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.MultiCell(w-4, 4.2, r.tr(jsonString(party, "nombre")), "", "L", false)
+
+	var lines []string
+	if v := jsonString(party, "nombreComercial"); v != "" {
+		lines = append(lines, "Nombre comercial: "+v)
+	}
+	if v := jsonString(party, "nit"); v != "" {
+		lines = append(lines, "NIT: "+v)
+	}
+	if v := jsonString(party, "numDocumento"); v != "" {
+		tipo := jsonString(party, "tipoDocumento")
+		if name, ok := receptorDocumentTypes[tipo]; ok {
+			tipo = name
+		}
+		lines = append(lines,
+			"Tipo de Documento de Identificación: "+tipo,
+			"Número de Documento de Identificación: "+v)
+	}
+	if v := jsonString(party, "nrc"); v != "" {
+		lines = append(lines, "NRC: "+v)
+	}
+	if v := jsonString(party, "descActividad"); v != "" {
+		lines = append(lines, "Actividad económica: "+v)
+	}
+	if v := r.formatDireccion(party); v != "" {
+		lines = append(lines, "Dirección: "+v)
+	}
+	if v := jsonString(party, "nombrePais"); v != "" {
+		lines = append(lines, "País: "+v)
+	}
+	if v := jsonString(party, "telefono"); v != "" {
+		lines = append(lines, "Número de teléfono: "+v)
+	}
+	if v := jsonString(party, "correo"); v != "" {
+		lines = append(lines, "Correo electrónico: "+v)
+	}
+	if v := jsonString(party, "tipoEstablecimiento"); v != "" {
+		if name, ok := codigos.GetEstablishmentTypeName(v); ok {
+			v = name
+		}
+		lines = append(lines, "Tipo de establecimiento: "+v)
+	}
+
+	pdf.SetFont("Helvetica", "", 8)
+	for _, line := range lines {
+		pdf.SetX(x + 2)
+		pdf.MultiCell(w-4, 3.6, r.tr(line), "", "L", false)
+	}
+	return pdf.GetY() + 2
+}
+
+// formatDireccion renders a direccion object with catalog names, or a plain string
+// address as export receptors carry it
+func (r *dtePDF) formatDireccion(party map[string]interface{}) string {
+	if s, ok := party["direccion"].(string); ok {
+		return s
+	}
+	dir := jsonObject(party, "direccion")
+	if dir == nil {
+		return jsonString(party, "complemento")
+	}
+
+	parts := []string{jsonString(dir, "complemento")}
+	dep := jsonString(dir, "departamento")
+	if name, ok := codigos.GetMunicipalityName(dep + "." + jsonString(dir, "municipio")); ok {
+		parts = append(parts, strings.ToUpper(name))
+	}
+	if name, ok := codigos.GetDepartmentName(dep); ok {
+		parts = append(parts, strings.ToUpper(name))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeRelatedDocuments lists documentoRelacionado when the document carries one
+func (r *dtePDF) writeRelatedDocuments() {
+	related, _ := r.doc["documentoRelacionado"].([]interface{})
+	if len(related) == 0 {
+		return
+	}
+
+	cols := []pdfColumn{
+		{"Tipo de Documento", "tipoDocumento", 55, "tipoDte", ""},
+		{"N° de Documento", "numeroDocumento", 90, "text", ""},
+		{"Fecha de Emisión", "fechaEmision", 50.9, "text", ""},
+	}
+	r.sectionBand("DOCUMENTOS RELACIONADOS")
+	r.tableHeader(cols)
+	for _, item := range related {
+		if obj, ok := item.(map[string]interface{}); ok {
+			r.tableRow(cols, obj, 0)
+		}
+	}
+	r.pdf.Ln(3)
+}
+
+// writeItems prints cuerpoDocumento as a table followed by the SUMA row
+func (r *dtePDF) writeItems() {
+	items, ok := r.doc["cuerpoDocumento"].([]interface{})
+	if !ok {
+		return // Type 09 carries a single cuerpoDocumento object, printed with the resumen
+	}
+
+	cols, ok := dteColumns[r.tipoDte]
+	if !ok {
+		cols = dteColumns["default"]
+	}
+
+	r.tableHeader(cols)
+	for i, item := range items {
+		if obj, ok := item.(map[string]interface{}); ok {
+			r.tableRow(cols, obj, i+1)
+		}
+	}
+
+	// SUMA row: totals aligned under their columns
+	resumen := jsonObject(r.doc, "resumen")
+	pdf := r.pdf
+	pdf.SetFillColor(60, 60, 60)
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "", 7)
+
+	labelWidth := 0.0
+	first := len(cols)
+	for i, col := range cols {
+		if col.SumKey != "" {
+			first = i
+			break
+		}
+		labelWidth += col.Width
+	}
+	pdf.CellFormat(labelWidth, 4.5, r.tr("SUMA DE VENTAS:"), "", 0, "R", true, 0, "")
+	for _, col := range cols[first:] {
+		value := ""
+		if col.SumKey != "" {
+			if v, ok := jsonNumber(resumen, col.SumKey); ok {
+				value = formatMoneyPDF(v)
+			}
+		}
+		pdf.CellFormat(col.Width, 4.5, value, "", 0, "R", true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(3)
+}
+
+// tableHeader prints a dark header row for cols
+func (r *dtePDF) tableHeader(cols []pdfColumn) {
+	pdf := r.pdf
+	pdf.SetFillColor(60, 60, 60)
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "B", 7)
+
+	lineHeight := 3.2
+	maxLines := 1
+	for _, col := range cols {
+		if n := len(r.split(col.Header, col.Width-1)); n > maxLines {
+			maxLines = n
+		}
+	}
+	height := float64(maxLines)*lineHeight + 1
+
+	if pdf.GetY()+height > r.contentBottom() {
+		pdf.AddPage()
+	}
+
+	x, y := pdf.GetX(), pdf.GetY()
+	for _, col := range cols {
+		pdf.Rect(x, y, col.Width, height, "F")
+		lines := r.split(col.Header, col.Width-1)
+		offset := (height - float64(len(lines))*lineHeight) / 2
+		for i, line := range lines {
+			pdf.SetXY(x, y+offset+float64(i)*lineHeight)
+			pdf.CellFormat(col.Width, lineHeight, line, "", 0, "C", false, 0, "")
+		}
+		x += col.Width
+	}
+	pdf.SetXY(10, y+height)
+	pdf.SetTextColor(0, 0, 0)
+}
+
+// tableRow prints one item, wrapping long text cells and repeating the header
+// after a page break
+func (r *dtePDF) tableRow(cols []pdfColumn, item map[string]interface{}, index int) {
+	pdf := r.pdf
+	pdf.SetFont("Helvetica", "", 7)
+	lineHeight := 3.2
+
+	cells := make([][]string, len(cols))
+	maxLines := 1
+	for i, col := range cols {
+		cells[i] = r.split(r.cellValue(col, item, index), col.Width-1)
+		if len(cells[i]) > maxLines {
+			maxLines = len(cells[i])
+		}
+	}
+	height := float64(maxLines)*lineHeight + 0.8
+
+	if pdf.GetY()+height > 260 {
+		pdf.AddPage()
+		r.tableHeader(cols)
+		pdf.SetFont("Helvetica", "", 7)
+	}
+
+	x, y := pdf.GetX(), pdf.GetY()
+	for i, col := range cols {
+		align := "L"
+		if col.Kind == "money" || col.Kind == "qty" {
+			align = "R"
+		}
+		for j, line := range cells[i] {
+			pdf.SetXY(x, y+0.4+float64(j)*lineHeight)
+			pdf.CellFormat(col.Width, lineHeight, line, "", 0, align, false, 0, "")
+		}
+		x += col.Width
+	}
+	pdf.SetDrawColor(200, 200, 200)
+	pdf.Line(10, y+height, 205.9, y+height)
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetXY(10, y+height)
+}
+
+// split wraps text to width w and returns the lines already encoded for the core
+// fonts. SplitText measures rune by rune, so the cp1252 bytes are passed to it as
+// runes below 256 and turned back into bytes afterwards.
+func (r *dtePDF) split(text string, w float64) []string {
+	encoded := r.tr(text)
+	runes := make([]rune, len(encoded))
+	for i := 0; i < len(encoded); i++ {
+		runes[i] = rune(encoded[i])
+	}
+
+	lines := r.pdf.SplitText(string(runes), w)
+	for i, line := range lines {
+		b := make([]byte, 0, len(line))
+		for _, c := range line {
+			b = append(b, byte(c))
+		}
+		lines[i] = string(b)
+	}
+	return lines
+}
+
+// cellValue formats one table cell according to its column kind
+func (r *dtePDF) cellValue(col pdfColumn, item map[string]interface{}, index int) string {
+	switch col.Kind {
+	case "index":
+		if v := jsonText(item, col.Key); v != "" {
+			return v
+		}
+		return strconv.Itoa(index)
+	case "money":
+		if v, ok := jsonNumber(item, col.Key); ok {
+			return formatMoneyPDF(v)
+		}
+		return ""
+	case "qty":
+		if v, ok := jsonNumber(item, col.Key); ok {
+			return formatQuantityPDF(v)
+		}
+		return ""
+	case "unit":
+		code := jsonText(item, col.Key)
+		if name, ok := codigos.GetUnitOfMeasureName(code); ok {
+			return name
+		}
+		return code
+	case "tipoDte":
+		code := jsonText(item, col.Key)
+		if name, ok := codigos.GetDocumentTypeName(code); ok {
+			return name
+		}
+		return code
+	case "donacion":
+		switch jsonText(item, col.Key) {
+		case "1":
+			return "Efectivo"
+		case "2":
+			return "Bien"
+		case "3":
+			return "Servicio"
+		}
+		return jsonText(item, col.Key)
+	default:
+		return jsonText(item, col.Key)
+	}
+}
+
+// writeResumen prints the totals, the tributos, the amount in words and the
+// operation condition
+func (r *dtePDF) writeResumen() {
+	pdf := r.pdf
+	resumen := jsonObject(r.doc, "resumen")
+	if resumen == nil {
+		// Type 09 keeps its amounts in the cuerpoDocumento object
+		resumen = jsonObject(r.doc, "cuerpoDocumento")
+	}
+	if resumen == nil {
+		return
+	}
+
+	pdf.SetDrawColor(180, 180, 180)
+	pdf.Line(10, pdf.GetY(), 205.9, pdf.GetY())
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.Ln(2)
+
+	pdf.SetFont("Helvetica", "", 8)
+	row := func(label, value string) {
+		if pdf.GetY() > 255 {
+			pdf.AddPage()
+		}
+		pdf.SetX(60)
+		pdf.CellFormat(110, 4, r.tr(label), "", 0, "R", false, 0, "")
+		pdf.CellFormat(35.9, 4, r.tr(value), "", 1, "R", false, 0, "")
+	}
+
+	tributosPrinted := false
+	for _, entry := range resumenLabels {
+		// Tributos go right after the discounts, before the sub-total
+		if entry.Key == "subTotal" && !tributosPrinted {
+			r.writeTributos(resumen, row)
+			tributosPrinted = true
+		}
+		if v, ok := jsonNumber(resumen, entry.Key); ok {
+			row(entry.Label, formatMoneyPDF(v))
+		}
+	}
+	if !tributosPrinted {
+		r.writeTributos(resumen, row)
+	}
+	pdf.Ln(3)
+
+	letras := jsonString(resumen, "totalLetras")
+	if letras == "" {
+		letras = jsonString(resumen, "totalIVAretenidoLetras")
+	}
+	condicion := ""
+	if code := jsonText(resumen, "condicionOperacion"); code != "" {
+		condicion = code
+		if name, ok := codigos.GetOperationConditionName(code); ok {
+			condicion = name
+		}
+	}
+
+	pdf.SetFillColor(60, 60, 60)
+	pdf.SetTextColor(255, 255, 255)
+	y := pdf.GetY()
+	pdf.Rect(10, y, 195.9, 10, "F")
+	pdf.SetXY(11, y+1)
+	pdf.MultiCell(110, 4, r.tr("Valor en letras: "+letras), "", "L", false)
+	pdf.SetXY(125, y+1)
+	pdf.MultiCell(80, 4, r.tr("Condición de la operación: "+condicion), "", "L", false)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetXY(10, y+13)
+}
+
+// writeTributos prints each tributo of the resumen with its catalog name
+func (r *dtePDF) writeTributos(resumen map[string]interface{}, row func(label, value string)) {
+	tributos, _ := resumen["tributos"].([]interface{})
+	for _, t := range tributos {
+		tributo, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := jsonString(tributo, "descripcion")
+		if name == "" {
+			name, _ = codigos.GetTributoName(jsonString(tributo, "codigo"))
+		}
+		if v, ok := jsonNumber(tributo, "valor"); ok {
+			row(name+":", formatMoneyPDF(v))
+		}
+	}
+}
+
+// writeAppendices prints the extension fields and the apendice entries
+func (r *dtePDF) writeAppendices() {
+	var lines []string
+
+	if ext := jsonObject(r.doc, "extension"); ext != nil {
+		for _, entry := range extensionLabels {
+			if v := jsonString(ext, entry.Key); v != "" {
+				lines = append(lines, entry.Label+": "+v)
+			}
+		}
+	}
+	// Type 09 keeps its observaciones in the cuerpoDocumento object
+	if cuerpo := jsonObject(r.doc, "cuerpoDocumento"); cuerpo != nil {
+		if v := jsonString(cuerpo, "observaciones"); v != "" {
+			lines = append(lines, "Observaciones: "+v)
+		}
+	}
+	apendice, _ := r.doc["apendice"].([]interface{})
+	for _, a := range apendice {
+		if entry, ok := a.(map[string]interface{}); ok {
+			lines = append(lines, jsonString(entry, "etiqueta")+": "+jsonString(entry, "valor"))
+		}
+	}
+
+	if len(lines) == 0 {
+		return
+	}
+
+	r.sectionBand("APÉNDICES")
+	r.pdf.SetFont("Helvetica", "", 8)
+	for _, line := range lines {
+		r.pdf.MultiCell(0, 3.8, r.tr(line), "", "L", false)
+	}
+}
+
+// sectionBand prints a dark full-width band with a section title
+func (r *dtePDF) sectionBand(title string) {
+	pdf := r.pdf
+	if pdf.GetY() > r.contentBottom()-10 {
+		pdf.AddPage()
+	}
+	pdf.SetFillColor(60, 60, 60)
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.CellFormat(0, 5, r.tr(title), "", 1, "L", true, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(1)
+}
+
+// contentBottom is the lowest Y a table row may reach before a page break
+func (r *dtePDF) contentBottom() float64 {
+	_, h := r.pdf.GetPageSize()
+	return h - 19.4
+}
+
+// contentRight is the right edge of the printable area
+func (r *dtePDF) contentRight() float64 {
+	w, _ := r.pdf.GetPageSize()
+	return w - 10
+}
+
+// writeInvalidatedWatermark stamps ANULADO across every page of an invalidated DTE
+func (r *dtePDF) writeInvalidatedWatermark() {
+	pdf := r.pdf
+	x, y := pdf.GetXY()
+	pdf.SetFont("Helvetica", "B", 70)
+	pdf.SetTextColor(235, 190, 190)
+	pdf.TransformBegin()
+	pdf.TransformRotate(35, 108, 140)
+	pdf.Text(45, 160, "ANULADO")
+	pdf.TransformEnd()
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetXY(x, y)
+}
+
+// ============================================
+// JSON HELPERS
+// ============================================
+
+func jsonObject(m map[string]interface{}, key string) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	obj, _ := m[key].(map[string]interface{})
+	return obj
+}
+
+func jsonString(m map[string]interface{}, key string) string {
+	if m == nil {
+		return ""
+	}
+	s, _ := m[key].(string)
+	return s
+}
+
+func jsonNumber(m map[string]interface{}, key string) (float64, bool) {
+	if m == nil {
+		return 0, false
+	}
+	v, ok := m[key].(float64)
+	return v, ok
+}
+
+// jsonText renders any scalar field as text (numbers without trailing zeros)
+func jsonText(m map[string]interface{}, key string) string {
+	if m == nil {
+		return ""
+	}
+	switch v := m[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+// formatMoneyPDF formats an amount as $1,234.56
+func formatMoneyPDF(v float64) string {
+	sign := ""
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	intPart, decPart := s[:len(s)-3], s[len(s)-2:]
+
+	var grouped strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(c)
+	}
+	return fmt.Sprintf("%s$%s.%s", sign, grouped.String(), decPart)
+}
+
+// formatQuantityPDF prints two decimals unless the quantity needs more
+func formatQuantityPDF(v float64) string {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if dot := strings.IndexByte(s, '.'); dot == -1 || len(s)-dot-1 <= 2 {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	return s
+}
@@ -0,0 +1,141 @@
+package formats
+
+import (
+	"strings"
+
+	"cuentas/internal/i18n"
+	"cuentas/internal/models"
+)
+
+// trialBalanceColumns is the account table layout of the trial balance
+// (letter page, 195.9mm usable). The account type is in the CSV only.
+var trialBalanceColumns = []pdfColumn{
+	{Width: 18},
+	{Width: 69.9},
+	{Width: 18, Kind: "money"},
+	{Width: 18, Kind: "money"},
+	{Width: 18, Kind: "money"},
+	{Width: 18, Kind: "money"},
+	{Width: 18, Kind: "money"},
+	{Width: 18, Kind: "money"},
+}
+
+// WriteTrialBalancePDF renders the balance de comprobación: one row per
+// account, indented by level, then the totals of the postable accounts
+func WriteTrialBalancePDF(tb *models.TrialBalance, lang string) ([]byte, error) {
+	t := i18n.New(lang)
+	r := newTaxBookPDF("P", lang)
+
+	r.ledgerReportHeader(t, t.TrialBalanceTitle(), tb.CompanyName, tb.NIT, tb.NRC,
+		t.FormatPeriodLabel(), tb.FromDate+" - "+tb.ToDate, tb.EstablishmentID, tb.EstablishmentCode, tb.EstablishmentName)
+
+	headers := t.TrialBalanceHeaders()
+	cols := withHeaders(trialBalanceColumns, append(headers[:2:2], headers[3:]...))
+	r.tableHeader(cols)
+	for _, row := range tb.Rows {
+		r.statementRow(cols, append([]string{
+			row.Code,
+			strings.Repeat("   ", row.Level) + row.Name,
+		}, trialBalancePDFCells(row.TrialBalanceAmounts)...), !row.Postable)
+	}
+	r.statementRow(cols, append([]string{"", t.TotalsLabel()}, trialBalancePDFCells(tb.Totals)...), true)
+
+	return r.output()
+}
+
+// trialBalancePDFCells formats the amount columns of the trial balance
+func trialBalancePDFCells(a models.TrialBalanceAmounts) []string {
+	return []string{
+		formatMoneyPDF(a.OpeningDebit),
+		formatMoneyPDF(a.OpeningCredit),
+		formatMoneyPDF(a.Debits),
+		formatMoneyPDF(a.Credits),
+		formatMoneyPDF(a.ClosingDebit),
+		formatMoneyPDF(a.ClosingCredit),
+	}
+}
+
+// WriteFinancialStatementPDF renders an income statement or balance sheet:
+// one block per account type with its total, then the net income or the
+// total of liabilities and equity. Group accounts are printed in bold. More
+// than four amount columns switch to a landscape page.
+func WriteFinancialStatementPDF(fs *models.FinancialStatement, lang string) ([]byte, error) {
+	t := i18n.New(lang)
+
+	orientation, usable := "P", 195.9
+	if len(fs.Columns) > 4 {
+		orientation, usable = "L", 259.4
+	}
+	r := newTaxBookPDF(orientation, lang)
+
+	periodLabel, period := statementPeriod(fs, t)
+	r.ledgerReportHeader(t, t.FinancialStatementTitle(fs.Statement), fs.CompanyName, fs.NIT, fs.NRC,
+		periodLabel, period, fs.EstablishmentID, fs.EstablishmentCode, fs.EstablishmentName)
+
+	// Amount columns share what the code and a 60mm account column leave,
+	// up to 26mm each
+	moneyWidth := (usable - 18 - 60) / float64(len(fs.Columns))
+	if moneyWidth > 26 {
+		moneyWidth = 26
+	}
+	cols := []pdfColumn{{Width: 18}, {Width: usable - 18 - moneyWidth*float64(len(fs.Columns))}}
+	for range fs.Columns {
+		cols = append(cols, pdfColumn{Width: moneyWidth, Kind: "money"})
+	}
+	cols = withHeaders(cols, append([]string{"", t.AccountLabel()}, statementColumnHeaders(fs, t)...))
+
+	for _, section := range fs.Sections {
+		r.sectionBand(t.AccountTypeName(section.AccountType))
+		r.tableHeader(cols)
+		for _, line := range section.Lines {
+			r.statementRow(cols, append([]string{
+				line.Code,
+				strings.Repeat("   ", line.Level) + line.Name,
+			}, amountPDFCells(line.Amounts)...), !line.Postable)
+		}
+		if fs.Statement == models.StatementBalance && section.AccountType == models.AccountTypeEquity {
+			r.statementRow(cols, append([]string{"", t.NetIncomeLabel()}, amountPDFCells(fs.NetIncome)...), false)
+		}
+		r.statementRow(cols, append([]string{"", t.SectionTotalLabel(section.AccountType)}, amountPDFCells(section.Totals)...), true)
+		r.pdf.Ln(3)
+	}
+
+	if fs.Statement == models.StatementBalance {
+		r.statementRow(cols, append([]string{"", t.TotalLiabilitiesEquityLabel()}, amountPDFCells(fs.TotalLiabilitiesEquity)...), true)
+	} else {
+		r.statementRow(cols, append([]string{"", t.NetIncomeLabel()}, amountPDFCells(fs.NetIncome)...), true)
+	}
+
+	return r.output()
+}
+
+// amountPDFCells formats one amount per statement column
+func amountPDFCells(amounts []float64) []string {
+	cells := make([]string, len(amounts))
+	for i, amount := range amounts {
+		cells[i] = formatMoneyPDF(amount)
+	}
+	return cells
+}
+
+// ledgerReportHeader prints the company, the report's title, its period and
+// the cost center it is narrowed to
+func (r *dtePDF) ledgerReportHeader(t *i18n.Translations, title, companyName, nit, nrc, periodLabel, period, establishmentID, establishmentCode, establishmentName string) {
+	pdf := r.pdf
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 6, r.tr(companyName), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 6, r.tr(title), "", 1, "C", false, 0, "")
+	pdf.Ln(3)
+
+	rows := [][2]string{
+		{"NIT:", nit},
+		{"NRC:", nrc},
+		{periodLabel + ":", period},
+	}
+	if establishmentID != "" {
+		rows = append(rows, [2]string{t.CostCenterLabel() + ":", costCenterName(t, establishmentID, establishmentCode, establishmentName)})
+	}
+	r.labelBlock(10, pdf.GetY(), 110, rows)
+	pdf.SetXY(10, pdf.GetY()+3)
+}
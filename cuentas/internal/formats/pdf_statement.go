@@ -0,0 +1,204 @@
+package formats
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"cuentas/internal/i18n"
+	"cuentas/internal/models"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// statementColumns is the entry table layout (letter page, 195.9mm usable)
+var statementColumns = []pdfColumn{
+	{Width: 18},
+	{Width: 30},
+	{Width: 30},
+	{Width: 36},
+	{Width: 24.9},
+	{Width: 19, Kind: "money"},
+	{Width: 19, Kind: "money"},
+	{Width: 19, Kind: "money"},
+}
+
+// openDocumentColumns is the open documents table layout
+var openDocumentColumns = []pdfColumn{
+	{Width: 28},
+	{Width: 36},
+	{Width: 17},
+	{Width: 17},
+	{Width: 12, Kind: "qty"},
+	{Width: 17.2, Kind: "money"},
+	{Width: 17.2, Kind: "money"},
+	{Width: 17.2, Kind: "money"},
+	{Width: 17.2, Kind: "money"},
+	{Width: 17.1, Kind: "money"},
+}
+
+// WriteStatementPDF renders a client statement: header and balance summary,
+// the entries with their running balance, and the open documents
+func WriteStatementPDF(statement *models.ClientStatement, lang string) ([]byte, error) {
+	t := i18n.New(lang)
+
+	pdf := fpdf.New("P", "mm", "Letter", "")
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AliasNbPages("{nb}")
+
+	r := &dtePDF{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+
+	pageLabel := "Página"
+	if lang == "en" {
+		pageLabel = "Page"
+	}
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 5, r.tr(fmt.Sprintf("%s %d/{nb}", pageLabel, pdf.PageNo())), "", 0, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 6, r.tr(statement.CompanyName), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 6, r.tr(t.StatementTitle()), "", 1, "C", false, 0, "")
+	pdf.Ln(3)
+
+	top := pdf.GetY()
+	client := statement.ClientName
+	if statement.ClientLegalName != "" && statement.ClientLegalName != statement.ClientName {
+		client += " (" + statement.ClientLegalName + ")"
+	}
+	r.labelBlock(10, top, 110, [][2]string{
+		{t.ClientLabel() + ":", client},
+		{t.FormatPeriodLabel() + ":", statement.FromDate + " - " + statement.ToDate},
+	})
+	bottom := pdf.GetY()
+
+	r.labelBlock(130, top, 75.9, [][2]string{
+		{t.OpeningBalanceLabel() + ":", formatMoneyPDF(statement.OpeningBalance)},
+		{t.ClosingBalanceLabel() + ":", formatMoneyPDF(statement.ClosingBalance)},
+	})
+	if pdf.GetY() > bottom {
+		bottom = pdf.GetY()
+	}
+	pdf.SetXY(10, bottom+3)
+
+	headers := t.StatementHeaders()
+	cols := withHeaders(statementColumns, headers)
+	r.tableHeader(cols)
+	r.statementRow(cols, []string{"", t.OpeningBalanceLabel(), "", "", "", "", "", formatMoneyPDF(statement.OpeningBalance)}, false)
+	for _, entry := range statement.Entries {
+		charge, credit := "", ""
+		if entry.Charge != 0 {
+			charge = formatMoneyPDF(entry.Charge)
+		}
+		if entry.Credit != 0 {
+			credit = formatMoneyPDF(entry.Credit)
+		}
+		r.statementRow(cols, []string{
+			entry.Date.Format("2006-01-02"),
+			t.StatementEntryType(entry.Type, stringValue(entry.DteType)),
+			entry.DocumentNumber,
+			stringValue(entry.NumeroControl),
+			statementAppliedTo(entry),
+			charge,
+			credit,
+			formatMoneyPDF(entry.Balance),
+		}, false)
+	}
+	r.statementRow(cols, []string{"", t.TotalsLabel(), "", "", "",
+		formatMoneyPDF(statement.TotalCharges), formatMoneyPDF(statement.TotalCredits), ""}, true)
+	r.statementRow(cols, []string{"", t.ClosingBalanceLabel(), "", "", "", "", "", formatMoneyPDF(statement.ClosingBalance)}, true)
+	pdf.Ln(4)
+
+	r.sectionBand(t.OpenDocumentsTitle())
+	cols = withHeaders(openDocumentColumns, t.OpenDocumentsHeaders())
+	r.tableHeader(cols)
+	for _, doc := range statement.OpenDocuments {
+		r.statementRow(cols, []string{
+			doc.InvoiceNumber,
+			stringValue(doc.NumeroControl),
+			doc.Date,
+			doc.DueDate,
+			strconv.Itoa(doc.DaysPastDue),
+			formatMoneyPDF(doc.Total),
+			formatMoneyPDF(doc.DebitNotes),
+			formatMoneyPDF(doc.CreditNotes),
+			formatMoneyPDF(doc.Payments),
+			formatMoneyPDF(doc.Balance),
+		}, false)
+	}
+
+	if err := pdf.Error(); err != nil {
+		return nil, fmt.Errorf("failed to render PDF: %w", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := pdf.Output(buf); err != nil {
+		return nil, fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// withHeaders returns a copy of cols titled with headers
+func withHeaders(cols []pdfColumn, headers []string) []pdfColumn {
+	out := make([]pdfColumn, len(cols))
+	copy(out, cols)
+	for i := range out {
+		if i < len(headers) {
+			out[i].Header = headers[i]
+		}
+	}
+	return out
+}
+
+// statementRow prints one row of already formatted cells, wrapping long text
+// and repeating the header after a page break
+func (r *dtePDF) statementRow(cols []pdfColumn, cells []string, bold bool) {
+	pdf := r.pdf
+	style := ""
+	if bold {
+		style = "B"
+	}
+	pdf.SetFont("Helvetica", style, 7)
+	lineHeight := 3.2
+
+	lines := make([][]string, len(cols))
+	maxLines := 1
+	for i, col := range cols {
+		lines[i] = r.split(cells[i], col.Width-1)
+		if len(lines[i]) > maxLines {
+			maxLines = len(lines[i])
+		}
+	}
+	height := float64(maxLines)*lineHeight + 0.8
+
+	if pdf.GetY()+height > r.contentBottom() {
+		pdf.AddPage()
+		r.tableHeader(cols)
+		pdf.SetFont("Helvetica", style, 7)
+	}
+
+	x, y := pdf.GetX(), pdf.GetY()
+	for i, col := range cols {
+		align := "L"
+		if col.Kind == "money" || col.Kind == "qty" {
+			align = "R"
+		}
+		for j, line := range lines[i] {
+			pdf.SetXY(x, y+0.4+float64(j)*lineHeight)
+			pdf.CellFormat(col.Width, lineHeight, line, "", 0, align, false, 0, "")
+		}
+		x += col.Width
+	}
+	pdf.SetDrawColor(200, 200, 200)
+	pdf.Line(10, y+height, r.contentRight(), y+height)
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetXY(10, y+height)
+}
@@ -0,0 +1,360 @@
+package formats
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cuentas/internal/i18n"
+	"cuentas/internal/models"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// consumerSalesColumns is the daily table layout of the consumer sales book
+// (letter page, 195.9mm usable). Débito fiscal is printed in the summary only.
+var consumerSalesColumns = []pdfColumn{
+	{Width: 15},
+	{Width: 18},
+	{Width: 14},
+	{Width: 32},
+	{Width: 32},
+	{Width: 10, Kind: "qty"},
+	{Width: 15, Kind: "money"},
+	{Width: 15, Kind: "money"},
+	{Width: 16, Kind: "money"},
+	{Width: 14, Kind: "money"},
+	{Width: 14.9, Kind: "money"},
+}
+
+// pointOfSaleTotalsColumns is the layout of an IVA book's totals per point of sale
+var pointOfSaleTotalsColumns = []pdfColumn{
+	{Width: 52},
+	{Width: 14, Kind: "qty"},
+	{Width: 21.65, Kind: "money"},
+	{Width: 21.65, Kind: "money"},
+	{Width: 21.65, Kind: "money"},
+	{Width: 21.65, Kind: "money"},
+	{Width: 21.65, Kind: "money"},
+	{Width: 21.65, Kind: "money"},
+}
+
+// contributorSalesColumns is the document table layout of the taxpayer sales
+// book (landscape letter page, 259.4mm usable). The código de generación and
+// receptor NIT are in the CSV only.
+var contributorSalesColumns = []pdfColumn{
+	{Width: 8, Kind: "qty"},
+	{Width: 15},
+	{Width: 18},
+	{Width: 34},
+	{Width: 14},
+	{Width: 34},
+	{Width: 30},
+	{Width: 15.2, Kind: "money"},
+	{Width: 15.2, Kind: "money"},
+	{Width: 15.2, Kind: "money"},
+	{Width: 15.2, Kind: "money"},
+	{Width: 15.2, Kind: "money"},
+	{Width: 15.2, Kind: "money"},
+	{Width: 15.2, Kind: "money"},
+}
+
+// documentTypeTotalsColumns is the layout of the taxpayer sales book's totals
+// per DTE type
+var documentTypeTotalsColumns = []pdfColumn{
+	{Width: 60},
+	{Width: 17, Kind: "qty"},
+	{Width: 26, Kind: "money"},
+	{Width: 26, Kind: "money"},
+	{Width: 26, Kind: "money"},
+	{Width: 26, Kind: "money"},
+	{Width: 26, Kind: "money"},
+	{Width: 26, Kind: "money"},
+	{Width: 26.4, Kind: "money"},
+}
+
+// purchaseBookColumns is the purchase table layout of the purchases book
+// (landscape letter page, 259.4mm usable). The código de generación is in the
+// CSV only.
+var purchaseBookColumns = []pdfColumn{
+	{Width: 8, Kind: "qty"},
+	{Width: 15},
+	{Width: 18},
+	{Width: 32},
+	{Width: 14},
+	{Width: 24},
+	{Width: 28},
+	{Width: 15.05, Kind: "money"},
+	{Width: 15.05, Kind: "money"},
+	{Width: 15.05, Kind: "money"},
+	{Width: 15.05, Kind: "money"},
+	{Width: 15.05, Kind: "money"},
+	{Width: 15.05, Kind: "money"},
+	{Width: 15.05, Kind: "money"},
+	{Width: 15.05, Kind: "money"},
+}
+
+// retentionsColumns is the layout of the purchases book's IVA retentions
+var retentionsColumns = []pdfColumn{
+	{Width: 16},
+	{Width: 15},
+	{Width: 30},
+	{Width: 34},
+	{Width: 14},
+	{Width: 28},
+	{Width: 50},
+	{Width: 34},
+	{Width: 19.2, Kind: "money"},
+	{Width: 19.2, Kind: "money"},
+}
+
+// WriteConsumerSalesBookPDF renders the libro de ventas a consumidor final:
+// header with the month's totals, one row per day and point of sale, and the
+// totals per point of sale
+func WriteConsumerSalesBookPDF(book *models.ConsumerSalesBook, lang string) ([]byte, error) {
+	t := i18n.New(lang)
+	r := newTaxBookPDF("P", lang)
+	pdf := r.pdf
+
+	r.taxBookHeader(t.ConsumerSalesBookTitle(), book.CompanyName, book.NIT, book.NRC,
+		models.TaxBookPeriod{Year: book.Year, Month: book.Month}, t)
+
+	headers := t.ConsumerSalesBookHeaders()
+	pdfHeaders := append([]string{headers[0], headers[1] + " / " + headers[2]}, headers[3:12]...)
+	cols := withHeaders(consumerSalesColumns, pdfHeaders)
+	r.tableHeader(cols)
+	for _, row := range book.Rows {
+		r.statementRow(cols, append([]string{
+			row.Date,
+			row.EstablishmentCode + " " + row.PointOfSaleCode,
+			t.StatementEntryType(models.StatementEntryInvoice, row.TipoDte),
+			row.FirstNumeroControl,
+			row.LastNumeroControl,
+		}, consumerSalesPDFCells(row.ConsumerSalesAmounts, false)...), false)
+	}
+	r.statementRow(cols, append([]string{"", t.TotalsLabel(), "", "", ""},
+		consumerSalesPDFCells(book.Totals, false)...), true)
+	pdf.Ln(2)
+
+	r.labelBlock(130, pdf.GetY(), 75.9, [][2]string{
+		{headers[11] + ":", formatMoneyPDF(book.Totals.Total)},
+		{headers[12] + ":", formatMoneyPDF(book.Totals.IVADebito)},
+	})
+	pdf.Ln(4)
+
+	r.sectionBand(t.PointOfSaleTotalsTitle())
+	posHeaders := t.PointOfSaleTotalsHeaders()
+	cols = withHeaders(pointOfSaleTotalsColumns, append([]string{posHeaders[0] + " / " + posHeaders[1]}, posHeaders[2:]...))
+	r.tableHeader(cols)
+	for _, pos := range book.PointsOfSale {
+		r.statementRow(cols, append([]string{
+			pos.EstablishmentCode + " " + pos.EstablishmentName + " / " + pos.PointOfSaleCode + " " + pos.PointOfSaleName,
+		}, consumerSalesPDFCells(pos.ConsumerSalesAmounts, true)...), false)
+	}
+
+	return r.output()
+}
+
+// consumerSalesPDFCells formats the amount columns of the consumer sales book,
+// with débito fiscal when withIVA is set
+func consumerSalesPDFCells(a models.ConsumerSalesAmounts, withIVA bool) []string {
+	cells := []string{
+		strconv.Itoa(a.DocumentCount),
+		formatMoneyPDF(a.VentasExentas),
+		formatMoneyPDF(a.VentasNoSujetas),
+		formatMoneyPDF(a.VentasGravadas),
+		formatMoneyPDF(a.Exportaciones),
+		formatMoneyPDF(a.Total),
+	}
+	if withIVA {
+		cells = append(cells, formatMoneyPDF(a.IVADebito))
+	}
+	return cells
+}
+
+// WriteContributorSalesBookPDF renders the libro de ventas a contribuyentes in
+// landscape: one numbered row per document, the month's totals, and the totals
+// per DTE type
+func WriteContributorSalesBookPDF(book *models.ContributorSalesBook, lang string) ([]byte, error) {
+	t := i18n.New(lang)
+	r := newTaxBookPDF("L", lang)
+	pdf := r.pdf
+
+	r.taxBookHeader(t.ContributorSalesBookTitle(), book.CompanyName, book.NIT, book.NRC,
+		models.TaxBookPeriod{Year: book.Year, Month: book.Month}, t)
+
+	headers := t.ContributorSalesBookHeaders()
+	pdfHeaders := append(append([]string{}, headers[0:4]...), headers[5])
+	pdfHeaders = append(pdfHeaders, headers[7:]...)
+	cols := withHeaders(contributorSalesColumns, pdfHeaders)
+	r.tableHeader(cols)
+	for i, row := range book.Rows {
+		r.statementRow(cols, append([]string{
+			strconv.Itoa(i + 1),
+			row.Date,
+			t.DocumentTypeName(row.TipoDte),
+			row.NumeroControl,
+			row.ReceptorNRC,
+			row.ReceptorName,
+			strings.Join(row.RelatedDocuments, " "),
+		}, contributorSalesPDFCells(row.ContributorSalesAmounts)...), false)
+	}
+	r.statementRow(cols, append([]string{"", "", t.TotalsLabel(), "", "", "", ""},
+		contributorSalesPDFCells(book.Totals)...), true)
+	pdf.Ln(4)
+
+	r.sectionBand(t.DocumentTypeTotalsTitle())
+	cols = withHeaders(documentTypeTotalsColumns, t.ContributorSalesTotalsHeaders())
+	r.tableHeader(cols)
+	for _, subtotal := range book.DocumentTypes {
+		r.statementRow(cols, append([]string{
+			t.DocumentTypeName(subtotal.TipoDte),
+			strconv.Itoa(subtotal.DocumentCount),
+		}, contributorSalesPDFCells(subtotal.ContributorSalesAmounts)...), false)
+	}
+	r.statementRow(cols, append([]string{t.TotalsLabel(), ""}, contributorSalesPDFCells(book.Totals)...), true)
+
+	return r.output()
+}
+
+// contributorSalesPDFCells formats the amount columns of the taxpayer sales book
+func contributorSalesPDFCells(a models.ContributorSalesAmounts) []string {
+	return []string{
+		formatMoneyPDF(a.VentasExentas),
+		formatMoneyPDF(a.VentasNoSujetas),
+		formatMoneyPDF(a.VentasGravadas),
+		formatMoneyPDF(a.IVADebito),
+		formatMoneyPDF(a.IVAPercibido),
+		formatMoneyPDF(a.IVARetenido),
+		formatMoneyPDF(a.Total),
+	}
+}
+
+// WritePurchaseBookPDF renders the libro de compras in landscape: one numbered
+// row per purchase, the month's totals, and the IVA retentions issued and
+// suffered
+func WritePurchaseBookPDF(book *models.PurchaseBook, lang string) ([]byte, error) {
+	t := i18n.New(lang)
+	r := newTaxBookPDF("L", lang)
+	pdf := r.pdf
+
+	r.taxBookHeader(t.PurchaseBookTitle(), book.CompanyName, book.NIT, book.NRC,
+		models.TaxBookPeriod{Year: book.Year, Month: book.Month}, t)
+
+	headers := t.PurchaseBookHeaders()
+	pdfHeaders := append(append([]string{}, headers[0:4]...), headers[5:]...)
+	cols := withHeaders(purchaseBookColumns, pdfHeaders)
+	r.tableHeader(cols)
+	for i, row := range book.Rows {
+		r.statementRow(cols, append([]string{
+			strconv.Itoa(i + 1),
+			row.Date,
+			t.PurchaseDocumentType(row.PurchaseType, row.TipoDte),
+			row.DocumentNumber,
+			row.SupplierNRC,
+			row.SupplierDocument,
+			row.SupplierName,
+		}, purchaseBookPDFCells(row.PurchaseBookAmounts)...), false)
+	}
+	r.statementRow(cols, append([]string{"", "", t.TotalsLabel(), "", "", "", ""},
+		purchaseBookPDFCells(book.Totals)...), true)
+	pdf.Ln(4)
+
+	r.sectionBand(t.RetentionsTitle())
+	cols = withHeaders(retentionsColumns, t.RetentionsHeaders())
+	r.tableHeader(cols)
+	for _, retention := range book.Retentions {
+		r.statementRow(cols, []string{
+			t.RetentionKind(retention.Kind),
+			retention.Date,
+			t.DocumentTypeName(retention.TipoDte),
+			retention.NumeroControl,
+			retention.CounterpartyNRC,
+			retention.CounterpartyNIT,
+			retention.CounterpartyName,
+			retention.RelatedDocument,
+			formatMoneyPDF(retention.MontoSujeto),
+			formatMoneyPDF(retention.IVARetenido),
+		}, false)
+	}
+	pdf.Ln(2)
+
+	issuedLabel, sufferedLabel := t.RetentionTotalsLabels()
+	r.labelBlock(r.contentRight()-75.9, pdf.GetY(), 75.9, [][2]string{
+		{issuedLabel + ":", formatMoneyPDF(book.RetencionesEmitidas)},
+		{sufferedLabel + ":", formatMoneyPDF(book.RetencionesSufridas)},
+	})
+
+	return r.output()
+}
+
+// purchaseBookPDFCells formats the amount columns of the purchases book
+func purchaseBookPDFCells(a models.PurchaseBookAmounts) []string {
+	return []string{
+		formatMoneyPDF(a.ComprasExentasInternas),
+		formatMoneyPDF(a.ComprasExentasImportaciones),
+		formatMoneyPDF(a.ComprasGravadasInternas),
+		formatMoneyPDF(a.ImportacionesGravadas),
+		formatMoneyPDF(a.IVACreditoFiscal),
+		formatMoneyPDF(a.SujetosExcluidos),
+		formatMoneyPDF(a.Total),
+		formatMoneyPDF(a.IVARetenido),
+	}
+}
+
+// newTaxBookPDF starts a letter page IVA book with numbered pages, in portrait
+// ("P") or landscape ("L") orientation
+func newTaxBookPDF(orientation, lang string) *dtePDF {
+	pdf := fpdf.New(orientation, "mm", "Letter", "")
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AliasNbPages("{nb}")
+
+	r := &dtePDF{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+
+	pageLabel := "Página"
+	if lang == "en" {
+		pageLabel = "Page"
+	}
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 5, r.tr(fmt.Sprintf("%s %d/{nb}", pageLabel, pdf.PageNo())), "", 0, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+
+	pdf.AddPage()
+	return r
+}
+
+// taxBookHeader prints the company, the book's title and the fiscal month
+func (r *dtePDF) taxBookHeader(title, companyName, nit, nrc string, period models.TaxBookPeriod, t *i18n.Translations) {
+	pdf := r.pdf
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 6, r.tr(companyName), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 6, r.tr(title), "", 1, "C", false, 0, "")
+	pdf.Ln(3)
+
+	r.labelBlock(10, pdf.GetY(), 110, [][2]string{
+		{"NIT:", nit},
+		{"NRC:", nrc},
+		{t.FormatPeriodLabel() + ":", period.String()},
+	})
+	pdf.SetXY(10, pdf.GetY()+3)
+}
+
+// output returns the rendered PDF
+func (r *dtePDF) output() ([]byte, error) {
+	if err := r.pdf.Error(); err != nil {
+		return nil, fmt.Errorf("failed to render PDF: %w", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := r.pdf.Output(buf); err != nil {
+		return nil, fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,148 @@
+package hacienda
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// AnulacionRequest represents the request to submit an Evento de Invalidación
+type AnulacionRequest struct {
+	Ambiente  string `json:"ambiente"`  // "00" = test, "01" = production
+	IDEnvio   int    `json:"idEnvio"`   // Sequential ID for this submission
+	Version   int    `json:"version"`   // Always 2 for anulación
+	Documento string `json:"documento"` // The signed JWT from Firmador
+}
+
+// GetAnulacionURL returns the URL for the anulardte endpoint, derived from the base URL
+func (c *Client) GetAnulacionURL() string {
+	if strings.HasSuffix(c.baseURL, "/recepciondte") {
+		return strings.TrimSuffix(c.baseURL, "/recepciondte") + "/anulardte"
+	}
+	return strings.TrimSuffix(c.baseURL, "/") + "/fesv/anulardte"
+}
+
+// InvalidarDTE submits a signed Evento de Invalidación to Hacienda
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout
+//   - authToken: The authentication token from HaciendaService
+//   - ambiente: "00" for test, "01" for production
+//   - signedJWT: The signed invalidation event returned from Firmador
+//
+// Returns the reception response or an error
+func (c *Client) InvalidarDTE(
+	ctx context.Context,
+	authToken string,
+	ambiente string,
+	signedJWT string,
+) (*ReceptionResponse, error) {
+	reqPayload := AnulacionRequest{
+		Ambiente:  ambiente,
+		IDEnvio:   1,
+		Version:   2,
+		Documento: signedJWT,
+	}
+
+	reqBody, err := json.Marshal(reqPayload)
+	if err != nil {
+		return nil, &HaciendaError{
+			Type:      "validation",
+			Code:      "MARSHAL_ERROR",
+			Message:   fmt.Sprintf("failed to marshal request: %v", err),
+			Timestamp: time.Now(),
+		}
+	}
+
+	req, err := retryablehttp.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		c.GetAnulacionURL(),
+		bytes.NewBuffer(reqBody),
+	)
+	if err != nil {
+		return nil, &HaciendaError{
+			Type:      "network",
+			Code:      "REQUEST_CREATE_ERROR",
+			Message:   fmt.Sprintf("failed to create request: %v", err),
+			Timestamp: time.Now(),
+		}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CuentasApp/1.0")
+	req.Header.Set("Authorization", authToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, &HaciendaError{
+			Type:      "network",
+			Code:      "CONNECTION_ERROR",
+			Message:   fmt.Sprintf("failed to connect to Hacienda: %v", err),
+			Timestamp: time.Now(),
+		}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &HaciendaError{
+			Type:      "network",
+			Code:      "RESPONSE_READ_ERROR",
+			Message:   fmt.Sprintf("failed to read response: %v", err),
+			Timestamp: time.Now(),
+		}
+	}
+
+	// Hacienda answers rejections of the event with HTTP 400 and a regular reception body
+	var recepResp ReceptionResponse
+	parseErr := json.Unmarshal(respBody, &recepResp)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		if parseErr == nil && recepResp.Estado == "RECHAZADO" {
+			return &recepResp, &HaciendaError{
+				Type:      "rejection",
+				Code:      recepResp.CodigoMsg,
+				Message:   fmt.Sprintf("invalidation rejected: %s", recepResp.DescripcionMsg),
+				Details:   recepResp,
+				Timestamp: time.Now(),
+			}
+		}
+		return nil, &HaciendaError{
+			Type:      "server",
+			Code:      fmt.Sprintf("HTTP_%d", resp.StatusCode),
+			Message:   fmt.Sprintf("Hacienda returned status %d: %s", resp.StatusCode, string(respBody)),
+			Details:   string(respBody),
+			Timestamp: time.Now(),
+		}
+	}
+
+	if parseErr != nil {
+		return nil, &HaciendaError{
+			Type:      "validation",
+			Code:      "RESPONSE_PARSE_ERROR",
+			Message:   fmt.Sprintf("failed to parse response: %v", parseErr),
+			Details:   string(respBody),
+			Timestamp: time.Now(),
+		}
+	}
+
+	if recepResp.Estado == "RECHAZADO" {
+		return &recepResp, &HaciendaError{
+			Type:      "rejection",
+			Code:      recepResp.CodigoMsg,
+			Message:   fmt.Sprintf("invalidation rejected: %s", recepResp.DescripcionMsg),
+			Details:   recepResp,
+			Timestamp: time.Now(),
+		}
+	}
+
+	return &recepResp, nil
+}
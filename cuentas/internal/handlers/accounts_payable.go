@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cuentas/internal/formats"
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountsPayableHandler struct {
+	apService *services.AccountsPayableService
+}
+
+func NewAccountsPayableHandler(svc *services.AccountsPayableService) *AccountsPayableHandler {
+	return &AccountsPayableHandler{
+		apService: svc,
+	}
+}
+
+// RecordSupplierPayment handles POST /v1/purchases/:id/payments
+// Applies a (possibly partial) payment to a finalized purchase.
+func (h *AccountsPayableHandler) RecordSupplierPayment(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.CreateSupplierPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.apService.RecordSupplierPayment(c.Request.Context(), companyID, c.Param("id"), c.GetString("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+// ListPurchasePayments handles GET /v1/purchases/:id/payments
+// Voided payments are included.
+func (h *AccountsPayableHandler) ListPurchasePayments(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	payments, err := h.apService.ListSupplierPayments(c.Request.Context(), companyID, &models.SupplierPaymentFilters{
+		PurchaseID:    c.Param("id"),
+		IncludeVoided: true,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"payments": payments,
+		"count":    len(payments),
+	})
+}
+
+// ListSupplierPayments handles GET /v1/supplier-payments
+// Filters: supplier_id, purchase_id, from_date, to_date, include_voided.
+func (h *AccountsPayableHandler) ListSupplierPayments(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	filters := &models.SupplierPaymentFilters{
+		SupplierID:    c.Query("supplier_id"),
+		PurchaseID:    c.Query("purchase_id"),
+		FromDate:      c.Query("from_date"),
+		ToDate:        c.Query("to_date"),
+		IncludeVoided: c.Query("include_voided") == "true",
+		Limit:         limit,
+		Offset:        offset,
+	}
+	for _, d := range []string{filters.FromDate, filters.ToDate} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+			return
+		}
+	}
+
+	payments, err := h.apService.ListSupplierPayments(c.Request.Context(), companyID, filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"payments": payments,
+		"count":    len(payments),
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+// GetSupplierPayment handles GET /v1/supplier-payments/:id
+func (h *AccountsPayableHandler) GetSupplierPayment(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	payment, err := h.apService.GetSupplierPayment(c.Request.Context(), companyID, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// VoidSupplierPayment handles POST /v1/supplier-payments/:id/void
+func (h *AccountsPayableHandler) VoidSupplierPayment(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.VoidPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.apService.VoidSupplierPayment(c.Request.Context(), companyID, c.Param("id"), c.GetString("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// ListPurchasesDue handles GET /v1/purchases/due
+// Query: as_of_date (default today), supplier_id, overdue_only.
+func (h *AccountsPayableHandler) ListPurchasesDue(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	asOf, ok := parseAsOfDate(c)
+	if !ok {
+		return
+	}
+
+	purchases, err := h.apService.ListPurchasesDue(c.Request.Context(), companyID, &models.PurchasesDueFilters{
+		AsOfDate:    asOf,
+		SupplierID:  c.Query("supplier_id"),
+		OverdueOnly: c.Query("overdue_only") == "true",
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	var total float64
+	for _, p := range purchases {
+		total += p.BalanceDue
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"as_of_date":  asOf.Format("2006-01-02"),
+		"purchases":   purchases,
+		"count":       len(purchases),
+		"balance_due": math.Round(total*100) / 100,
+	})
+}
+
+// GetAgingReport handles GET /v1/reports/ap-aging
+// Query: as_of_date (default today), group_by (supplier or establishment),
+// supplier_id, establishment_id, format (json or csv), language (es or en).
+func (h *AccountsPayableHandler) GetAgingReport(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	asOf, ok := parseAsOfDate(c)
+	if !ok {
+		return
+	}
+
+	groupBy := c.DefaultQuery("group_by", models.AgingGroupBySupplier)
+	if groupBy != models.AgingGroupBySupplier && groupBy != models.AgingGroupByEstablishment {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by must be supplier or establishment"})
+		return
+	}
+
+	report, err := h.apService.GetAgingReport(c.Request.Context(), companyID, &models.APAgingFilters{
+		AsOfDate:        asOf,
+		GroupBy:         groupBy,
+		SupplierID:      c.Query("supplier_id"),
+		EstablishmentID: c.Query("establishment_id"),
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if formats.DetermineFormat(c.GetHeader("Accept"), c.Query("format")) == "csv" {
+		csvData, err := formats.WriteAgingCSV(report, formats.DetermineLanguage(c.Query("language")))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate CSV"})
+			return
+		}
+
+		filename := fmt.Sprintf("ap_aging_%s_%s.csv", groupBy, report.AsOfDate)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", csvData)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *AccountsPayableHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrSupplierPaymentNotFound), errors.Is(err, services.ErrPurchaseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSupplierPaymentAlreadyVoided), errors.Is(err, services.ErrPurchaseAlreadyVoid),
+		errors.Is(err, services.ErrInvalidPurchaseStatus), errors.Is(err, services.ErrPaymentReconciled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSupplierOverpayment):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// parseAsOfDate reads the as_of_date query parameter (default today) as a UTC
+// date, writing a 400 when it is malformed
+func parseAsOfDate(c *gin.Context) (time.Time, bool) {
+	asOf := time.Now()
+	if d := c.Query("as_of_date"); d != "" {
+		parsed, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+			return time.Time{}, false
+		}
+		asOf = parsed
+	}
+	return time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC), true
+}
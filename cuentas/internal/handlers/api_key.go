@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+}
+
+func NewAPIKeyHandler(svc *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: svc,
+	}
+}
+
+// CreateAPIKey handles POST /v1/api-keys
+// The response carries the plain key; only its hash is stored. A key created by
+// a logged-in user acts as that user.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	h.createKey(c, companyID, c.GetString("api_key_id"), c.GetString("user_id"))
+}
+
+// IssueCompanyAPIKey handles POST /v1/admin/companies/:id/api-keys
+// Operator endpoint (admin token) to give a new or locked-out company a key.
+func (h *APIKeyHandler) IssueCompanyAPIKey(c *gin.Context) {
+	h.createKey(c, c.Param("id"), "", "")
+}
+
+func (h *APIKeyHandler) createKey(c *gin.Context, companyID, createdByKeyID, userID string) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, err := h.apiKeyService.CreateKey(c.Request.Context(), companyID, &req, createdByKeyID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// ListAPIKeys handles GET /v1/api-keys
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	keys, err := h.apiKeyService.ListKeys(c.Request.Context(), companyID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"api_keys": keys,
+		"count":    len(keys),
+	})
+}
+
+// GetAPIKey handles GET /v1/api-keys/:id
+func (h *APIKeyHandler) GetAPIKey(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	key, err := h.apiKeyService.GetKey(c.Request.Context(), companyID, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// RevokeAPIKey handles DELETE /v1/api-keys/:id
+// The key stays listed with revoked_at set.
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	key, err := h.apiKeyService.RevokeKey(c.Request.Context(), companyID, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+func (h *APIKeyHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrAPIKeyNotFound), errors.Is(err, services.ErrAPIKeyCompanyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
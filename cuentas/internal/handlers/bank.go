@@ -0,0 +1,472 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cuentas/internal/formats"
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBankStatementSize caps an uploaded statement file
+const maxBankStatementSize = 10 << 20
+
+type BankHandler struct {
+	bankService *services.BankService
+}
+
+func NewBankHandler(svc *services.BankService) *BankHandler {
+	return &BankHandler{
+		bankService: svc,
+	}
+}
+
+// ============================================
+// ACCOUNTS
+// ============================================
+
+// CreateAccount handles POST /v1/bank/accounts
+func (h *BankHandler) CreateAccount(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.CreateBankAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.bankService.CreateAccount(c.Request.Context(), companyID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// ListAccounts handles GET /v1/bank/accounts
+// Filters: include_inactive.
+func (h *BankHandler) ListAccounts(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	accounts, err := h.bankService.ListAccounts(c.Request.Context(), companyID, c.Query("include_inactive") == "true")
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"accounts": accounts,
+		"count":    len(accounts),
+	})
+}
+
+// GetAccount handles GET /v1/bank/accounts/:id
+func (h *BankHandler) GetAccount(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	account, err := h.bankService.GetAccount(c.Request.Context(), companyID, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// UpdateAccount handles PATCH /v1/bank/accounts/:id
+func (h *BankHandler) UpdateAccount(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.UpdateBankAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.bankService.UpdateAccount(c.Request.Context(), companyID, c.Param("id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// ============================================
+// STATEMENTS
+// ============================================
+
+// ImportStatement handles POST /v1/bank/accounts/:id/statements
+// Multipart form: file (CSV or OFX), optional format (csv, ofx; detected when
+// omitted) and layout (a JSON CSV layout overriding the account's).
+func (h *BankHandler) ImportStatement(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBankStatementSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read uploaded file"})
+		return
+	}
+	if len(data) > maxBankStatementSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "statement file must be at most 10 MB"})
+		return
+	}
+
+	var layout *models.BankCSVLayout
+	if raw := c.PostForm("layout"); raw != "" {
+		layout = &models.BankCSVLayout{}
+		if err := json.Unmarshal([]byte(raw), layout); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid layout: " + err.Error()})
+			return
+		}
+	}
+
+	statement, err := h.bankService.ImportStatement(c.Request.Context(), companyID, c.Param("id"), c.GetString("user_id"),
+		strings.ToLower(c.PostForm("format")), header.Filename, data, layout)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, statement)
+}
+
+// ListStatements handles GET /v1/bank/accounts/:id/statements
+func (h *BankHandler) ListStatements(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	statements, err := h.bankService.ListStatements(c.Request.Context(), companyID, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statements": statements,
+		"count":      len(statements),
+	})
+}
+
+// DeleteStatement handles DELETE /v1/bank/statements/:id
+func (h *BankHandler) DeleteStatement(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	if err := h.bankService.DeleteStatement(c.Request.Context(), companyID, c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ============================================
+// STATEMENT LINES
+// ============================================
+
+// ListLines handles GET /v1/bank/accounts/:id/lines
+// Filters: from_date, to_date (YYYY-MM-DD), status, statement_id.
+func (h *BankHandler) ListLines(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	filters := &models.BankLineFilters{
+		FromDate:    c.Query("from_date"),
+		ToDate:      c.Query("to_date"),
+		Status:      c.Query("status"),
+		StatementID: c.Query("statement_id"),
+		Limit:       limit,
+		Offset:      offset,
+	}
+	for _, date := range []string{filters.FromDate, filters.ToDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+			return
+		}
+	}
+	switch filters.Status {
+	case "", models.BankLineUnmatched, models.BankLineMatched, models.BankLineIgnored:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be unmatched, matched or ignored"})
+		return
+	}
+
+	lines, err := h.bankService.ListLines(c.Request.Context(), companyID, c.Param("id"), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"lines":  lines,
+		"count":  len(lines),
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// AutoMatch handles POST /v1/bank/accounts/:id/auto-match
+// Query: tolerance_days, how far apart a line and a payment may be dated
+// (default 3).
+func (h *BankHandler) AutoMatch(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	tolerance, err := strconv.Atoi(c.DefaultQuery("tolerance_days", strconv.Itoa(models.DefaultBankMatchToleranceDays)))
+	if err != nil || tolerance < 0 || tolerance > 30 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tolerance_days must be between 0 and 30"})
+		return
+	}
+
+	result, err := h.bankService.AutoMatch(c.Request.Context(), companyID, c.Param("id"), tolerance)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ListCandidates handles GET /v1/bank/lines/:id/candidates
+// Lists the unmatched payments the line could be matched with.
+func (h *BankHandler) ListCandidates(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	candidates, err := h.bankService.ListCandidates(c.Request.Context(), companyID, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"candidates": candidates,
+		"count":      len(candidates),
+	})
+}
+
+// MatchLine handles POST /v1/bank/lines/:id/match
+func (h *BankHandler) MatchLine(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.MatchBankLineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	line, err := h.bankService.MatchLine(c.Request.Context(), companyID, c.Param("id"), c.GetString("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, line)
+}
+
+// UnmatchLine handles DELETE /v1/bank/lines/:id/match
+// Returns a matched or ignored line to unmatched.
+func (h *BankHandler) UnmatchLine(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	line, err := h.bankService.UnmatchLine(c.Request.Context(), companyID, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, line)
+}
+
+// IgnoreLine handles POST /v1/bank/lines/:id/ignore
+func (h *BankHandler) IgnoreLine(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.IgnoreBankLineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	line, err := h.bankService.IgnoreLine(c.Request.Context(), companyID, c.Param("id"), c.GetString("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, line)
+}
+
+// ============================================
+// RECONCILIATION REPORT
+// ============================================
+
+// GetReconciliation handles GET /v1/bank/accounts/:id/reconciliation
+// Query: from_date, to_date (YYYY-MM-DD; default the current month),
+// format (json, csv, pdf), language (es, en).
+func (h *BankHandler) GetReconciliation(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	now := time.Now()
+	fromDate := c.DefaultQuery("from_date", time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02"))
+	toDate := c.DefaultQuery("to_date", now.Format("2006-01-02"))
+	from, err := time.Parse("2006-01-02", fromDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from_date format, use YYYY-MM-DD"})
+		return
+	}
+	to, err := time.Parse("2006-01-02", toDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to_date format, use YYYY-MM-DD"})
+		return
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to_date must not be before from_date"})
+		return
+	}
+
+	rec, err := h.bankService.GetReconciliation(c.Request.Context(), companyID, c.Param("id"), fromDate, toDate)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.respond(c, rec, fmt.Sprintf("conciliacion_bancaria_%s_%s_%s", rec.BankAccount.AccountNumber, fromDate, toDate),
+		func(lang string) ([]byte, error) { return formats.WriteBankReconciliationCSV(rec, lang) },
+		func(lang string) ([]byte, error) { return formats.WriteBankReconciliationPDF(rec, lang) })
+}
+
+// respond writes a report as JSON, or as CSV or PDF when requested
+func (h *BankHandler) respond(c *gin.Context, report interface{}, filename string, writeCSV, writePDF func(lang string) ([]byte, error)) {
+	lang := formats.DetermineLanguage(c.Query("language"))
+
+	switch formats.DetermineFormat(c.GetHeader("Accept"), c.Query("format")) {
+	case "csv":
+		csvData, err := writeCSV(lang)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate CSV"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", filename))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", csvData)
+	case "pdf":
+		pdfBytes, err := writePDF(lang)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate PDF"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%s.pdf", filename))
+		c.Data(http.StatusOK, "application/pdf", pdfBytes)
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+func (h *BankHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrBankAccountNotFound),
+		errors.Is(err, services.ErrBankStatementNotFound),
+		errors.Is(err, services.ErrBankLineNotFound),
+		errors.Is(err, services.ErrPaymentNotFound),
+		errors.Is(err, services.ErrSupplierPaymentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrBankAccountNumberTaken),
+		errors.Is(err, services.ErrBankStatementReconciled),
+		errors.Is(err, services.ErrBankLineNotUnmatched),
+		errors.Is(err, services.ErrBankPaymentUnavailable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrBankMatchAmountMismatch),
+		errors.Is(err, services.ErrBankLayoutMissing):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "validation failed"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"cuentas/internal/dte"
+	"cuentas/internal/hacienda"
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DCLHandler struct {
+	dclService *services.DCLService
+}
+
+func NewDCLHandler(svc *services.DCLService) *DCLHandler {
+	return &DCLHandler{
+		dclService: svc,
+	}
+}
+
+// CreateDCL handles POST /v1/dcl
+// Computes the settlement (IVA, 2% percepción, commission) for the period;
+// transmission happens on finalize
+func (h *DCLHandler) CreateDCL(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.CreateDCLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+
+	dcl, err := h.dclService.CreateDCL(c.Request.Context(), companyID, &req, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrClientNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "client not found"})
+		case errors.Is(err, services.ErrPointOfSaleNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "establishment or point of sale not found"})
+		case errors.Is(err, services.ErrDCLInvalidAmount),
+			errors.Is(err, services.ErrDCLReceptor):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, dcl)
+}
+
+// GetDCL handles GET /v1/dcl/:id
+func (h *DCLHandler) GetDCL(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	dcl, err := h.dclService.GetDCLByID(c.Request.Context(), companyID, c.Param("id"))
+	if err != nil {
+		if err == services.ErrDCLNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "DCL not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dcl)
+}
+
+// ListDCLs handles GET /v1/dcl
+// Optional filter: client_id
+func (h *DCLHandler) ListDCLs(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	dcls, err := h.dclService.ListDCLs(c.Request.Context(), companyID, c.Query("client_id"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dcls":   dcls,
+		"count":  len(dcls),
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// FinalizeDCL handles POST /v1/dcl/:id/finalize
+// Builds, signs and transmits the DTE 09. Failures reaching firmador or Hacienda
+// queue the DCL in the POS contingency period.
+func (h *DCLHandler) FinalizeDCL(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	dclID := c.Param("id")
+
+	dcl, err := h.dclService.GetDCLByID(ctx, companyID, dclID)
+	if err != nil {
+		if err == services.ErrDCLNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "DCL not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if dcl.IsProcessed() {
+		c.JSON(http.StatusConflict, gin.H{"error": services.ErrDCLAlreadyIssued.Error()})
+		return
+	}
+
+	dteServiceInterface, exists := c.Get("dteService")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DTE service not available"})
+		return
+	}
+	dteService := dteServiceInterface.(*dte.DTEService)
+
+	_, processErr := dteService.ProcessDCL(ctx, dcl)
+
+	// Reload to return what was persisted (Hacienda response or contingency status)
+	dcl, err = h.dclService.GetDCLByID(ctx, companyID, dclID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if processErr != nil {
+		if respondSchemaValidationError(c, processErr, gin.H{"dcl": dcl}) {
+			return
+		}
+
+		var hacErr *hacienda.HaciendaError
+		switch {
+		case errors.Is(processErr, dte.ErrQueuedForContingency):
+			c.JSON(http.StatusAccepted, gin.H{
+				"dcl":     dcl,
+				"message": processErr.Error(),
+			})
+		case errors.As(processErr, &hacErr) && hacErr.Type == "rejection":
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error": "DCL rejected by Hacienda",
+				"dcl":   dcl,
+			})
+		default:
+			log.Printf("[FinalizeDCL] ❌ DTE processing failed for DCL %s: %v", dclID, processErr)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": processErr.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"dcl": dcl})
+}
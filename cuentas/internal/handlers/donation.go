@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cuentas/internal/dte"
+	"cuentas/internal/hacienda"
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DonationHandler struct {
+	donationService *services.DonationService
+}
+
+func NewDonationHandler(svc *services.DonationService) *DonationHandler {
+	return &DonationHandler{
+		donationService: svc,
+	}
+}
+
+// CreateDonation handles POST /v1/donations
+// Values each donated line (cash, goods, services) and totals the receipt;
+// transmission happens on finalize
+func (h *DonationHandler) CreateDonation(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.CreateDonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+
+	donation, err := h.donationService.CreateDonation(c.Request.Context(), companyID, &req, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrClientNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "client not found"})
+		case errors.Is(err, services.ErrPointOfSaleNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "establishment or point of sale not found"})
+		case errors.Is(err, services.ErrDonationDonor):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, donation)
+}
+
+// GetDonation handles GET /v1/donations/:id
+func (h *DonationHandler) GetDonation(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	donation, err := h.donationService.GetDonationByID(c.Request.Context(), companyID, c.Param("id"))
+	if err != nil {
+		if err == services.ErrDonationNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "donation not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, donation)
+}
+
+// ListDonations handles GET /v1/donations
+// Optional filter: client_id
+func (h *DonationHandler) ListDonations(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	donations, err := h.donationService.ListDonations(c.Request.Context(), companyID, c.Query("client_id"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"donations": donations,
+		"count":     len(donations),
+		"limit":     limit,
+		"offset":    offset,
+	})
+}
+
+// FinalizeDonation handles POST /v1/donations/:id/finalize
+// Builds, signs and transmits the DTE 15. Failures reaching firmador or Hacienda
+// queue the donation receipt in the POS contingency period.
+func (h *DonationHandler) FinalizeDonation(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	donationID := c.Param("id")
+
+	donation, err := h.donationService.GetDonationByID(ctx, companyID, donationID)
+	if err != nil {
+		if err == services.ErrDonationNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "donation not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if donation.IsProcessed() {
+		c.JSON(http.StatusConflict, gin.H{"error": services.ErrDonationAlreadyIssued.Error()})
+		return
+	}
+
+	dteServiceInterface, exists := c.Get("dteService")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DTE service not available"})
+		return
+	}
+	dteService := dteServiceInterface.(*dte.DTEService)
+
+	_, processErr := dteService.ProcessDonation(ctx, donation)
+
+	// Reload to return what was persisted (Hacienda response or contingency status)
+	donation, err = h.donationService.GetDonationByID(ctx, companyID, donationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if processErr != nil {
+		if respondSchemaValidationError(c, processErr, gin.H{"donation": donation}) {
+			return
+		}
+
+		var hacErr *hacienda.HaciendaError
+		switch {
+		case errors.Is(processErr, dte.ErrQueuedForContingency):
+			c.JSON(http.StatusAccepted, gin.H{
+				"donation": donation,
+				"message":  processErr.Error(),
+			})
+		case errors.As(processErr, &hacErr) && hacErr.Type == "rejection":
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":    "donation receipt rejected by Hacienda",
+				"donation": donation,
+			})
+		default:
+			log.Printf("[FinalizeDonation] ❌ DTE processing failed for donation %s: %v", donationID, processErr)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": processErr.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"donation": donation})
+}
+
+// GetDonorAnnualReport handles GET /v1/donations/reports/annual
+// Totals per donor the receipts accepted by Hacienda in the year (default: current year).
+// Optional filter: client_id
+func (h *DonationHandler) GetDonorAnnualReport(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	year := time.Now().Year()
+	if yearParam := c.Query("year"); yearParam != "" {
+		parsed, err := strconv.Atoi(yearParam)
+		if err != nil || parsed < 2000 || parsed > 9999 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "year must be a valid year (e.g. 2025)"})
+			return
+		}
+		year = parsed
+	}
+
+	report, err := h.donationService.GetDonorAnnualReport(c.Request.Context(), companyID, year, c.Query("client_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
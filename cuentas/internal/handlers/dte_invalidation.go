@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"cuentas/internal/dte"
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvalidationHandler struct {
+	invalidationService *services.InvalidationService
+}
+
+func NewInvalidationHandler(svc *services.InvalidationService) *InvalidationHandler {
+	return &InvalidationHandler{
+		invalidationService: svc,
+	}
+}
+
+// InvalidateDTE handles POST /v1/dte/:codigo_generacion/invalidate
+func (h *InvalidationHandler) InvalidateDTE(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	codigoGeneracion := strings.ToUpper(c.Param("codigo_generacion"))
+
+	var req models.InvalidateDTERequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+
+	dteServiceInterface, exists := c.Get("dteService")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DTE service not available"})
+		return
+	}
+	dteService := dteServiceInterface.(*dte.DTEService)
+
+	invalidation, err := dteService.InvalidateDTE(c.Request.Context(), companyID, codigoGeneracion, &req, userID)
+	if err != nil {
+		if respondSchemaValidationError(c, err, nil) {
+			return
+		}
+
+		switch err {
+		case dte.ErrDTENotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case dte.ErrDTEAlreadyInvalidated:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case dte.ErrReplacementDTENotFound:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if !invalidation.IsAccepted() {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":        "invalidation rejected by Hacienda",
+			"invalidation": invalidation,
+		})
+		return
+	}
+
+	// Hacienda accepted the invalidation - undo inventory and balance effects
+	if err := h.invalidationService.ReverseDocumentEffects(c.Request.Context(), companyID, invalidation.ID, userID); err != nil {
+		log.Printf("[InvalidateDTE] ⚠️  Invalidation %s accepted but reversing effects failed: %v", invalidation.ID, err)
+		c.JSON(http.StatusOK, gin.H{
+			"invalidation": invalidation,
+			"warning":      "invalidation accepted by Hacienda but reversing document effects failed: " + err.Error(),
+		})
+		return
+	}
+
+	invalidation, err = h.invalidationService.GetInvalidation(c.Request.Context(), companyID, invalidation.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invalidation": invalidation})
+}
+
+// ReverseEffects handles POST /v1/dte/invalidations/:id/reverse-effects
+// Retries the reversal of an accepted invalidation whose effects were not reversed
+func (h *InvalidationHandler) ReverseEffects(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	invalidationID := c.Param("id")
+
+	userID := c.GetString("user_id")
+
+	if err := h.invalidationService.ReverseDocumentEffects(c.Request.Context(), companyID, invalidationID, userID); err != nil {
+		switch err {
+		case services.ErrInvalidationNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case services.ErrInvalidationNotAccepted:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	invalidation, err := h.invalidationService.GetInvalidation(c.Request.Context(), companyID, invalidationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invalidation": invalidation})
+}
+
+// GetInvalidation handles GET /v1/dte/invalidations/:id
+func (h *InvalidationHandler) GetInvalidation(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	invalidation, err := h.invalidationService.GetInvalidation(c.Request.Context(), companyID, c.Param("id"))
+	if err != nil {
+		if err == services.ErrInvalidationNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invalidation)
+}
+
+// ListInvalidations handles GET /v1/dte/invalidations
+func (h *InvalidationHandler) ListInvalidations(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	invalidations, err := h.invalidationService.ListInvalidations(
+		c.Request.Context(),
+		companyID,
+		c.Query("codigo_generacion"),
+		limit,
+		offset,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invalidations": invalidations,
+		"count":         len(invalidations),
+		"limit":         limit,
+		"offset":        offset,
+	})
+}
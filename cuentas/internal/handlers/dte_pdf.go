@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"cuentas/internal/formats"
+	"cuentas/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDTEPDFHandler handles GET /v1/dte/:codigo_generacion/pdf
+// Renders the readable version (versión legible) of a transmitted DTE from its
+// commit log entry, with the sello de recepción and the consulta pública QR code.
+func GetDTEPDFHandler(c *gin.Context) {
+	codigoGeneracion := strings.ToUpper(c.Param("codigo_generacion"))
+
+	companyIDValue, exists := c.Get("company_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "company_id not found in context",
+			Code:  "unauthorized",
+		})
+		return
+	}
+	companyID := companyIDValue.(string)
+
+	db := c.MustGet("db").(*sql.DB)
+
+	query := `
+		SELECT
+			cl.numero_control, cl.dte_url, cl.dte_unsigned,
+			cl.hacienda_sello_recibido, cl.hacienda_fh_procesamiento,
+			EXISTS (
+				SELECT 1 FROM dte_invalidations inv
+				WHERE inv.original_codigo_generacion = cl.codigo_generacion
+				  AND inv.hacienda_estado = 'PROCESADO'
+			)
+		FROM dte_commit_log cl
+		WHERE cl.codigo_generacion = $1 AND cl.company_id = $2
+	`
+
+	var numeroControl, dteURL, dteUnsigned string
+	var sello *string
+	var fhProcesamiento *time.Time
+	var invalidated bool
+
+	err := db.QueryRowContext(c.Request.Context(), query, codigoGeneracion, companyID).Scan(
+		&numeroControl, &dteURL, &dteUnsigned, &sello, &fhProcesamiento, &invalidated,
+	)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "commit log entry not found",
+			Code:  "not_found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "failed to get commit log entry",
+			Code:  "internal_error",
+		})
+		return
+	}
+
+	pdfBytes, err := formats.WriteDTEPDF(&formats.DTEPrintable{
+		Document:        []byte(dteUnsigned),
+		SelloRecibido:   sello,
+		FhProcesamiento: fhProcesamiento,
+		ConsultaURL:     dteURL,
+		Invalidated:     invalidated,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: fmt.Sprintf("failed to render DTE PDF: %v", err),
+			Code:  "internal_error",
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%s.pdf", numeroControl))
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
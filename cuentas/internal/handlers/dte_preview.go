@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"cuentas/internal/dte"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dteServiceFromContext returns the DTE service set by the middleware. Previews
+// cannot fall back to skipping DTE processing the way finalize does, so a missing
+// service answers 503.
+func dteServiceFromContext(c *gin.Context) (*dte.DTEService, bool) {
+	dteServiceInterface, exists := c.Get("dteService")
+	if !exists {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "DTE service not available"})
+		return nil, false
+	}
+	return dteServiceInterface.(*dte.DTEService), true
+}
+
+// respondDTEPreview answers a preview request. Schema and calculator findings are
+// part of a successful preview; an error means the document could not be built
+// at all (e.g. the client has no valid address), which the caller must fix first.
+func respondDTEPreview(c *gin.Context, result *dte.PreviewResult, err error) {
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "DTE could not be built",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
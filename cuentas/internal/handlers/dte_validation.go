@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"cuentas/internal/dte"
+	"cuentas/internal/dte_schemas"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondSchemaValidationError answers 422 with the list of schema violations when err
+// comes from the pre-flight validation that runs before signing. Keys in extra are added
+// to the body (e.g. the document that was processed). Returns false for any other error.
+func respondSchemaValidationError(c *gin.Context, err error, extra gin.H) bool {
+	validationErr, ok := dte_schemas.AsSchemaValidationError(err)
+	if !ok {
+		return false
+	}
+
+	body := gin.H{
+		"error":             "DTE failed schema validation",
+		"tipo_dte":          validationErr.TipoDte,
+		"validation_errors": validationErr.Errors,
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+
+	c.JSON(http.StatusUnprocessableEntity, body)
+	return true
+}
+
+// preflightDTE checks the DTE a draft would be finalized with before the finalize
+// commits anything: sequence, inventory and balances are only touched once the
+// document builds and passes its schema. Otherwise it answers 422, the document
+// stays a draft the caller can correct, and it returns false.
+func preflightDTE(c *gin.Context, result *dte.PreviewResult, err error) bool {
+	if err != nil {
+		respondDTEPreview(c, nil, err)
+		return false
+	}
+	if schemaErr := result.SchemaError(); schemaErr != nil {
+		respondSchemaValidationError(c, schemaErr, nil)
+		return false
+	}
+	return true
+}
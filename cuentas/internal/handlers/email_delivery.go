@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailDeliveryHandler struct {
+	emailService *services.EmailDeliveryService
+}
+
+func NewEmailDeliveryHandler(svc *services.EmailDeliveryService) *EmailDeliveryHandler {
+	return &EmailDeliveryHandler{
+		emailService: svc,
+	}
+}
+
+// GetEmailSettings handles GET /v1/email/settings
+func (h *EmailDeliveryHandler) GetEmailSettings(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	settings, err := h.emailService.GetSettings(c.Request.Context(), companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateEmailSettings handles PUT /v1/email/settings
+// Enables or disables delivery and replaces the sender and templates. Templates
+// are Go text/template sources; see services.EmailTemplateData for the fields.
+func (h *EmailDeliveryHandler) UpdateEmailSettings(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.UpdateEmailSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.emailService.UpdateSettings(c.Request.Context(), companyID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrEmailTemplate) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// ListEmailDeliveries handles GET /v1/dte/email-deliveries
+// Optional filters: codigo_generacion, status
+func (h *EmailDeliveryHandler) ListEmailDeliveries(c *gin.Context) {
+	h.listDeliveries(c, c.Query("codigo_generacion"))
+}
+
+// GetDTEEmailDeliveries handles GET /v1/dte/:codigo_generacion/emails
+func (h *EmailDeliveryHandler) GetDTEEmailDeliveries(c *gin.Context) {
+	h.listDeliveries(c, c.Param("codigo_generacion"))
+}
+
+func (h *EmailDeliveryHandler) listDeliveries(c *gin.Context, codigoGeneracion string) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	deliveries, err := h.emailService.ListDeliveries(
+		c.Request.Context(), companyID, codigoGeneracion, c.Query("status"), limit, offset,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+		"limit":      limit,
+		"offset":     offset,
+	})
+}
+
+// ResendDTEEmail handles POST /v1/dte/:codigo_generacion/emails/resend
+// Queues a new delivery of the JSON and PDF, to the receptor or to the
+// recipient given in the body. Works whether or not automatic delivery is enabled.
+func (h *EmailDeliveryHandler) ResendDTEEmail(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.ResendEmailRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+
+	delivery, err := h.emailService.Resend(c.Request.Context(), companyID, c.Param("codigo_generacion"), req.Recipient, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEmailDTENotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrEmailDTENotAccepted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
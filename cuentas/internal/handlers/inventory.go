@@ -3,6 +3,7 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -371,7 +372,7 @@ func (h *InventoryHandler) RecordPurchaseHandler(c *gin.Context) {
 	}
 
 	// Record purchase
-	event, err := h.service.RecordPurchase(c.Request.Context(), companyID, itemID, &req)
+	event, err := h.service.RecordPurchase(c.Request.Context(), companyID, itemID, c.GetString("user_id"), &req)
 	if err != nil {
 		log.Printf("[ERROR] RecordPurchase failed: %v", err)
 		if strings.Contains(err.Error(), "item not found") {
@@ -381,6 +382,20 @@ func (h *InventoryHandler) RecordPurchaseHandler(c *gin.Context) {
 			})
 			return
 		}
+		if err == services.ErrSupplierNotFound || err == models.ErrEstablishmentNotFound {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "not_found",
+			})
+			return
+		}
+		if err == services.ErrSupplierInactive || strings.Contains(err.Error(), "validation failed") {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "validation_failed",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: fmt.Sprintf("failed to record purchase: %v", err),
 			Code:  "internal_error",
@@ -417,7 +432,7 @@ func (h *InventoryHandler) RecordAdjustmentHandler(c *gin.Context) {
 	}
 
 	// Record adjustment
-	event, err := h.service.RecordAdjustment(c.Request.Context(), companyID, itemID, &req)
+	event, err := h.service.RecordAdjustment(c.Request.Context(), companyID, itemID, c.GetString("user_id"), &req)
 	if err != nil {
 		if strings.Contains(err.Error(), "item not found") {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{
@@ -426,6 +441,13 @@ func (h *InventoryHandler) RecordAdjustmentHandler(c *gin.Context) {
 			})
 			return
 		}
+		if err == models.ErrEstablishmentNotFound {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "not_found",
+			})
+			return
+		}
 		if strings.Contains(err.Error(), "negative quantity") {
 			c.JSON(http.StatusBadRequest, models.ErrorResponse{
 				Error: err.Error(),
@@ -444,6 +466,132 @@ func (h *InventoryHandler) RecordAdjustmentHandler(c *gin.Context) {
 	c.JSON(http.StatusCreated, event)
 }
 
+// RecordTransferHandler handles POST /v1/inventory/items/:id/transfers
+func (h *InventoryHandler) RecordTransferHandler(c *gin.Context) {
+	itemID := c.Param("id")
+	companyID := c.MustGet("company_id").(string)
+
+	// Parse request
+	var req models.RecordTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "invalid JSON format",
+			Code:  "invalid_json",
+		})
+		return
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "validation_failed",
+		})
+		return
+	}
+
+	// Record transfer
+	transfer, err := h.service.RecordTransfer(c.Request.Context(), companyID, itemID, c.GetString("user_id"), &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "item not found") {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: "item not found",
+				Code:  "not_found",
+			})
+			return
+		}
+		if err == models.ErrEstablishmentNotFound {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "not_found",
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "insufficient stock") {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "invalid_quantity",
+			})
+			return
+		}
+		if err == models.ErrInventoryPooled {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error: err.Error() + "; allocate it with POST /v1/inventory/locations/allocate",
+				Code:  "inventory_pooled",
+			})
+			return
+		}
+
+		log.Printf("[ERROR] RecordTransfer failed: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "failed to record transfer",
+			Code:  "internal_error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, transfer)
+}
+
+// AllocateInventoryLocationsHandler handles POST /v1/inventory/locations/allocate
+func (h *InventoryHandler) AllocateInventoryLocationsHandler(c *gin.Context) {
+	companyID := c.MustGet("company_id").(string)
+
+	// Parse request
+	var req models.AllocateInventoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "invalid JSON format",
+			Code:  "invalid_json",
+		})
+		return
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "validation_failed",
+		})
+		return
+	}
+
+	// Allocate stock
+	result, err := h.service.AllocateInventoryLocations(c.Request.Context(), companyID, c.GetString("user_id"), &req)
+	if err != nil {
+		if err == models.ErrInventoryNotPooled {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "inventory_not_pooled",
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "item not found") || errors.Is(err, models.ErrEstablishmentNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "not_found",
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "insufficient stock") {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "invalid_quantity",
+			})
+			return
+		}
+
+		log.Printf("[ERROR] AllocateInventoryLocations failed: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "failed to allocate inventory",
+			Code:  "internal_error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // GetInventoryStateHandler handles GET /v1/inventory/items/:id/state
 func (h *InventoryHandler) GetInventoryStateHandler(c *gin.Context) {
 	itemID := c.Param("id")
@@ -451,7 +599,7 @@ func (h *InventoryHandler) GetInventoryStateHandler(c *gin.Context) {
 
 	fmt.Println("we are calling the new inventory service")
 	fmt.Println("we are creating a state")
-	state, err := h.service.GetInventoryState(c.Request.Context(), companyID, itemID)
+	state, err := h.service.GetInventoryState(c.Request.Context(), companyID, itemID, c.Query("establishment_id"))
 	if err != nil {
 		if strings.Contains(err.Error(), "item not found") {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{
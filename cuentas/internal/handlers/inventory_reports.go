@@ -37,9 +37,10 @@ func (h *InventoryHandler) GetCostHistoryHandler(c *gin.Context) {
 	// Parse date range filters
 	startDate := c.Query("start_date") // ISO format: 2024-01-01
 	endDate := c.Query("end_date")     // ISO format: 2024-12-31
+	establishmentID := c.Query("establishment_id")
 
 	// Get cost history
-	events, err := h.service.GetCostHistory(c.Request.Context(), companyID, itemID, limit, sortOrder, startDate, endDate)
+	events, err := h.service.GetCostHistory(c.Request.Context(), companyID, itemID, establishmentID, limit, sortOrder, startDate, endDate)
 	if err != nil {
 		if strings.Contains(err.Error(), "item not found") {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{
@@ -76,6 +77,7 @@ func (h *InventoryHandler) GetAllEventsHandler(c *gin.Context) {
 	startDate := c.Query("start_date")
 	endDate := c.Query("end_date")
 	eventType := c.Query("event_type")
+	establishmentID := c.Query("establishment_id")
 	sortOrder := c.DefaultQuery("sort", "desc")
 	format := formats.DetermineFormat(c.GetHeader("Accept"), c.Query("format"))
 	language := formats.DetermineLanguage(c.Query("language")) // Default: Spanish
@@ -85,7 +87,7 @@ func (h *InventoryHandler) GetAllEventsHandler(c *gin.Context) {
 	}
 
 	// Get all events
-	events, err := h.service.GetAllEvents(c.Request.Context(), companyID, startDate, endDate, eventType, sortOrder)
+	events, err := h.service.GetAllEvents(c.Request.Context(), companyID, startDate, endDate, eventType, establishmentID, sortOrder)
 	if err != nil {
 		if strings.Contains(err.Error(), "invalid date") {
 			c.JSON(http.StatusBadRequest, models.ErrorResponse{
@@ -160,7 +162,7 @@ func (h *InventoryHandler) GetInventoryValuationHandler(c *gin.Context) {
 	language := formats.DetermineLanguage(c.Query("language")) // Default: Spanish
 
 	// Get valuation
-	valuation, err := h.service.GetInventoryValuationAtDate(c.Request.Context(), companyID, asOfDate)
+	valuation, err := h.service.GetInventoryValuationAtDate(c.Request.Context(), companyID, asOfDate, c.Query("establishment_id"))
 	if err != nil {
 		log.Printf("[ERROR] GetInventoryValuation failed: %v", err)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -194,16 +196,20 @@ func (h *InventoryHandler) GetInventoryValuationHandler(c *gin.Context) {
 }
 
 // ListInventoryStatesHandler handles GET /v1/inventory/states
+// Returns the stock at each location and each item's consolidated stock.
+// The CSV holds the locations, or the consolidated stock with consolidated=true.
 func (h *InventoryHandler) ListInventoryStatesHandler(c *gin.Context) {
 	companyID := c.MustGet("company_id").(string)
 
 	// Parse query params
 	inStockOnly := c.Query("in_stock_only") == "true"
+	establishmentID := c.Query("establishment_id")
+	consolidated := c.Query("consolidated") == "true"
 	format := formats.DetermineFormat(c.GetHeader("Accept"), c.Query("format"))
 	language := formats.DetermineLanguage(c.Query("language")) // Default: Spanish
 
 	// Get states
-	states, err := h.service.ListInventoryStates(c.Request.Context(), companyID, inStockOnly)
+	states, err := h.service.ListInventoryStates(c.Request.Context(), companyID, inStockOnly, establishmentID)
 	if err != nil {
 		log.Printf("[ERROR] ListInventoryStates failed: %v", err)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -213,9 +219,25 @@ func (h *InventoryHandler) ListInventoryStatesHandler(c *gin.Context) {
 		return
 	}
 
+	totals, err := h.service.ListInventoryTotals(c.Request.Context(), companyID, inStockOnly)
+	if err != nil {
+		log.Printf("[ERROR] ListInventoryTotals failed: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "failed to list inventory states",
+			Code:  "internal_error",
+		})
+		return
+	}
+
 	// Return based on format
 	if format == "csv" {
-		csvData, err := formats.WriteInventoryStatesCSV(states, language) // Pass language
+		var csvData []byte
+		var err error
+		if consolidated {
+			csvData, err = formats.WriteInventoryTotalsCSV(totals, language)
+		} else {
+			csvData, err = formats.WriteInventoryStatesCSV(states, language)
+		}
 		if err != nil {
 			log.Printf("[ERROR] Failed to generate CSV: %v", err)
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -225,10 +247,14 @@ func (h *InventoryHandler) ListInventoryStatesHandler(c *gin.Context) {
 			return
 		}
 
-		filename := "inventory_states.csv"
+		filename := "inventory_states"
 		if inStockOnly {
-			filename = "inventory_in_stock.csv"
+			filename = "inventory_in_stock"
 		}
+		if consolidated {
+			filename += "_consolidated"
+		}
+		filename += ".csv"
 
 		c.Header("Content-Type", "text/csv; charset=utf-8")
 		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
@@ -238,13 +264,15 @@ func (h *InventoryHandler) ListInventoryStatesHandler(c *gin.Context) {
 
 	// Default: JSON
 	c.JSON(http.StatusOK, gin.H{
-		"states": states,
+		"states":       states,
+		"consolidated": totals,
 	})
 }
 
 // Generates Article 142-A compliant inventory register for a specific item
 // GetLegalInventoryRegisterHandler handles GET /v1/inventory/items/:id/legal-register
-// Generates Article 142-A compliant inventory register for a specific item
+// Generates Article 142-A compliant inventory register for a specific item,
+// for one location with establishment_id or consolidated without it
 func (h *InventoryHandler) GetLegalInventoryRegisterHandler(c *gin.Context) {
 	itemID := c.Param("id")
 	companyID := c.MustGet("company_id").(string)
@@ -253,6 +281,7 @@ func (h *InventoryHandler) GetLegalInventoryRegisterHandler(c *gin.Context) {
 	// Parse query params
 	startDate := c.Query("start_date")
 	endDate := c.Query("end_date")
+	establishmentID := c.Query("establishment_id")
 	language := formats.DetermineLanguage(c.Query("language"))
 
 	// Validate required parameters
@@ -307,10 +336,23 @@ func (h *InventoryHandler) GetLegalInventoryRegisterHandler(c *gin.Context) {
 		return
 	}
 
+	// Get location info; the register is consolidated without one
+	var location *models.Establishment
+	if establishmentID != "" {
+		location, err = services.NewEstablishmentService().GetEstablishment(c.Request.Context(), companyID, establishmentID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: "establishment not found",
+				Code:  "not_found",
+			})
+			return
+		}
+	}
+
 	// Get events for this item in date range
-	events, err := inventoryService.GetCostHistory(c.Request.Context(), companyID, itemID, 10000, "asc", startDate, endDate)
+	events, err := inventoryService.GetLegalRegisterEvents(c.Request.Context(), companyID, itemID, establishmentID, startDate, endDate)
 	if err != nil {
-		log.Printf("[ERROR] GetCostHistory failed: %v", err)
+		log.Printf("[ERROR] GetLegalRegisterEvents failed: %v", err)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "failed to get cost history",
 			Code:  "internal_error",
@@ -329,7 +371,7 @@ func (h *InventoryHandler) GetLegalInventoryRegisterHandler(c *gin.Context) {
 	}
 
 	// Generate legal CSV register
-	csvData, err := formats.WriteLegalInventoryRegisterCSV(&companyInfo, item, eventsWithItem, startDate, endDate, language)
+	csvData, err := formats.WriteLegalInventoryRegisterCSV(&companyInfo, item, location, eventsWithItem, startDate, endDate, language)
 	if err != nil {
 		log.Printf("[ERROR] Failed to generate legal register: %v", err)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -341,6 +383,9 @@ func (h *InventoryHandler) GetLegalInventoryRegisterHandler(c *gin.Context) {
 
 	// Return CSV file
 	filename := fmt.Sprintf("registro_inventario_%s_%s_a_%s.csv", item.SKU, startDate, endDate)
+	if location != nil && location.CodEstablecimiento != "" {
+		filename = fmt.Sprintf("registro_inventario_%s_%s_%s_a_%s.csv", item.SKU, location.CodEstablecimiento, startDate, endDate)
+	}
 	c.Header("Content-Type", "text/csv; charset=utf-8")
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
 	c.Data(http.StatusOK, "text/csv", csvData)
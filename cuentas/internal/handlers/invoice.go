@@ -187,8 +187,20 @@ func (h *InvoiceHandler) FinalizeInvoice(c *gin.Context) {
 		return
 	}
 
-	// TODO: Get user ID from auth context when auth is implemented
-	userID := "00000000-0000-0000-0000-000000000000" // Placeholder
+	// Build and validate the DTE while the invoice is still a draft; finalize
+	// rejects any other status itself
+	if dteService, ok := c.Get("dteService"); ok && existingInvoice.Status == "draft" {
+		draft, err := h.invoiceService.GetInvoiceExport(c.Request.Context(), companyID, invoiceID)
+		if err != nil {
+			draft = existingInvoice
+		}
+		result, err := h.previewInvoiceDTE(c, dteService.(*dte.DTEService), companyID, draft)
+		if !preflightDTE(c, result, err) {
+			return
+		}
+	}
+
+	userID := c.GetString("user_id")
 
 	// Finalize invoice with payment info
 	invoice, err := h.invoiceService.FinalizeInvoice(c.Request.Context(), companyID, invoiceID, userID, &req.Payment)
@@ -276,3 +288,67 @@ func (h *InvoiceHandler) FinalizeInvoice(c *gin.Context) {
 
 	c.JSON(http.StatusOK, invoice)
 }
+
+// PreviewInvoiceDTE handles POST /v1/invoices/:id/preview-dte
+// Builds the DTE a draft invoice would be finalized with, without consuming a
+// sequence number, signing or transmitting it.
+func (h *InvoiceHandler) PreviewInvoiceDTE(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	invoiceID := c.Param("id")
+	if invoiceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invoice_id is required"})
+		return
+	}
+
+	// Load with export fields so IsExportInvoice() routes to the right builder
+	invoice, err := h.invoiceService.GetInvoiceExport(c.Request.Context(), companyID, invoiceID)
+	if err != nil {
+		invoice, err = h.invoiceService.GetInvoice(c.Request.Context(), companyID, invoiceID)
+	}
+	if err != nil {
+		if err == services.ErrInvoiceNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if invoice.Status != "draft" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only draft invoices can be previewed"})
+		return
+	}
+	if invoice.RemisionType != nil && *invoice.RemisionType != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "document is a remision; use /v1/remisiones/:id/preview-dte"})
+		return
+	}
+
+	dteService, ok := dteServiceFromContext(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.previewInvoiceDTE(c, dteService, companyID, invoice)
+	respondDTEPreview(c, result, err)
+}
+
+// previewInvoiceDTE builds the DTE of a draft invoice with a provisional numero
+// control, for the preview and for the finalize pre-flight
+func (h *InvoiceHandler) previewInvoiceDTE(c *gin.Context, dteService *dte.DTEService, companyID string, invoice *models.Invoice) (*dte.PreviewResult, error) {
+	tipoDte := h.invoiceService.InvoiceDTEType(invoice)
+	numeroControl, err := h.invoiceService.PreviewNumeroControl(c.Request.Context(), companyID, invoice.PointOfSaleID, tipoDte)
+	if err != nil {
+		return nil, err
+	}
+	invoice.DteNumeroControl = &numeroControl
+
+	if invoice.IsExportInvoice() {
+		return dteService.PreviewExportInvoice(c.Request.Context(), invoice)
+	}
+	return dteService.PreviewInvoice(c.Request.Context(), invoice)
+}
@@ -0,0 +1,546 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cuentas/internal/formats"
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LedgerHandler struct {
+	ledgerService *services.LedgerService
+}
+
+func NewLedgerHandler(svc *services.LedgerService) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerService: svc,
+	}
+}
+
+// ============================================
+// ACCOUNTS
+// ============================================
+
+// CreateAccount handles POST /v1/ledger/accounts
+func (h *LedgerHandler) CreateAccount(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.CreateLedgerAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.ledgerService.CreateAccount(c.Request.Context(), companyID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// ListAccounts handles GET /v1/ledger/accounts
+// Filters: include_inactive.
+func (h *LedgerHandler) ListAccounts(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	accounts, err := h.ledgerService.ListAccounts(c.Request.Context(), companyID, c.Query("include_inactive") == "true")
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"accounts": accounts,
+		"count":    len(accounts),
+	})
+}
+
+// GetAccount handles GET /v1/ledger/accounts/:id
+func (h *LedgerHandler) GetAccount(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	account, err := h.ledgerService.GetAccount(c.Request.Context(), companyID, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// UpdateAccount handles PATCH /v1/ledger/accounts/:id
+func (h *LedgerHandler) UpdateAccount(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.UpdateLedgerAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.ledgerService.UpdateAccount(c.Request.Context(), companyID, c.Param("id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// SeedDefaultChart handles POST /v1/ledger/accounts/default-chart
+// Loads the starter chart of accounts and its posting rules into a company
+// without accounts.
+func (h *LedgerHandler) SeedDefaultChart(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	accounts, err := h.ledgerService.SeedDefaultChart(c.Request.Context(), companyID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"accounts": accounts,
+		"count":    len(accounts),
+	})
+}
+
+// ============================================
+// POSTING RULES
+// ============================================
+
+// ListPostingRules handles GET /v1/ledger/posting-rules
+// Also returns the roles each document type posts to.
+func (h *LedgerHandler) ListPostingRules(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	rules, err := h.ledgerService.ListPostingRules(c.Request.Context(), companyID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules":          rules,
+		"count":          len(rules),
+		"document_roles": models.LedgerDocumentRoles,
+	})
+}
+
+// SetPostingRule handles PUT /v1/ledger/posting-rules
+func (h *LedgerHandler) SetPostingRule(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.SetPostingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.ledgerService.SetPostingRule(c.Request.Context(), companyID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeletePostingRule handles DELETE /v1/ledger/posting-rules/:id
+func (h *LedgerHandler) DeletePostingRule(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	if err := h.ledgerService.DeletePostingRule(c.Request.Context(), companyID, c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "posting rule deleted successfully"})
+}
+
+// ============================================
+// SETTINGS AND POSTING
+// ============================================
+
+// GetSettings handles GET /v1/ledger/settings
+func (h *LedgerHandler) GetSettings(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	settings, err := h.ledgerService.GetSettings(c.Request.Context(), companyID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings handles PUT /v1/ledger/settings
+func (h *LedgerHandler) UpdateSettings(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.UpdateLedgerSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.ledgerService.UpdateSettings(c.Request.Context(), companyID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// PostPending handles POST /v1/ledger/post
+// Posts the company's pending documents now instead of waiting for the worker.
+func (h *LedgerHandler) PostPending(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	result, err := h.ledgerService.PostCompany(c.Request.Context(), companyID)
+	if result == nil {
+		h.handleError(c, err)
+		return
+	}
+	if err != nil {
+		// Documents that did post are kept; report what failed with the counts
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    err.Error(),
+			"posted":   result.Posted,
+			"reversed": result.Reversed,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ============================================
+// JOURNAL
+// ============================================
+
+// ListJournal handles GET /v1/ledger/journal
+// Filters: from_date, to_date (YYYY-MM-DD), source_type, source_id, account_id,
+// establishment_id.
+func (h *LedgerHandler) ListJournal(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	filters := &models.JournalFilters{
+		FromDate:        c.Query("from_date"),
+		ToDate:          c.Query("to_date"),
+		SourceType:      c.Query("source_type"),
+		SourceID:        c.Query("source_id"),
+		AccountID:       c.Query("account_id"),
+		EstablishmentID: c.Query("establishment_id"),
+		Limit:           limit,
+		Offset:          offset,
+	}
+	for _, date := range []string{filters.FromDate, filters.ToDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+			return
+		}
+	}
+
+	entries, err := h.ledgerService.ListJournal(c.Request.Context(), companyID, filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"count":   len(entries),
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// GetJournalEntry handles GET /v1/ledger/journal/:id
+func (h *LedgerHandler) GetJournalEntry(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	entry, err := h.ledgerService.GetJournalEntry(c.Request.Context(), companyID, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// ============================================
+// REPORTS
+// ============================================
+
+// GetTrialBalance handles GET /v1/ledger/reports/trial-balance
+// Query: from_date and to_date (YYYY-MM-DD, default January 1 to today),
+// establishment_id (or "none" for entries without one), exclude_closing,
+// format (json, csv or pdf), language (es or en).
+func (h *LedgerHandler) GetTrialBalance(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	req, err := models.ParseStatementRequest(c.Query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tb, err := h.ledgerService.GetTrialBalance(c.Request.Context(), companyID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.respond(c, tb, fmt.Sprintf("balance_comprobacion_%s_%s", req.FromDate, req.ToDate),
+		func(lang string) ([]byte, error) { return formats.WriteTrialBalanceCSV(tb, lang) },
+		func(lang string) ([]byte, error) { return formats.WriteTrialBalancePDF(tb, lang) },
+	)
+}
+
+// GetIncomeStatement handles GET /v1/ledger/reports/income-statement
+// Query: from_date and to_date (YYYY-MM-DD, default January 1 to today),
+// compare (previous_period or previous_year) with periods (1 to 12), or
+// breakdown=establishment for one column per cost center, establishment_id,
+// format (json, csv or pdf), language (es or en).
+func (h *LedgerHandler) GetIncomeStatement(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	req, err := models.ParseStatementRequest(c.Query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fs, err := h.ledgerService.GetIncomeStatement(c.Request.Context(), companyID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.respond(c, fs, fmt.Sprintf("estado_resultados_%s_%s", req.FromDate, req.ToDate),
+		func(lang string) ([]byte, error) { return formats.WriteFinancialStatementCSV(fs, lang) },
+		func(lang string) ([]byte, error) { return formats.WriteFinancialStatementPDF(fs, lang) },
+	)
+}
+
+// GetBalanceSheet handles GET /v1/ledger/reports/balance-sheet
+// Takes the same query as the income statement; balances are as of to_date,
+// and comparative columns are as of the end of each earlier period.
+func (h *LedgerHandler) GetBalanceSheet(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	req, err := models.ParseStatementRequest(c.Query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fs, err := h.ledgerService.GetBalanceSheet(c.Request.Context(), companyID, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.respond(c, fs, fmt.Sprintf("balance_general_%s", req.ToDate),
+		func(lang string) ([]byte, error) { return formats.WriteFinancialStatementCSV(fs, lang) },
+		func(lang string) ([]byte, error) { return formats.WriteFinancialStatementPDF(fs, lang) },
+	)
+}
+
+// respond writes a report as JSON, or as CSV or PDF when requested
+func (h *LedgerHandler) respond(c *gin.Context, report interface{}, filename string, writeCSV, writePDF func(lang string) ([]byte, error)) {
+	lang := formats.DetermineLanguage(c.Query("language"))
+
+	switch formats.DetermineFormat(c.GetHeader("Accept"), c.Query("format")) {
+	case "csv":
+		csvData, err := writeCSV(lang)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate CSV"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", filename))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", csvData)
+	case "pdf":
+		pdfBytes, err := writePDF(lang)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate PDF"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%s.pdf", filename))
+		c.Data(http.StatusOK, "application/pdf", pdfBytes)
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// ============================================
+// CLOSINGS
+// ============================================
+
+// CloseFiscalYear handles POST /v1/ledger/closings
+// Posts pending documents, then the closing entries of the fiscal year.
+func (h *LedgerHandler) CloseFiscalYear(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.CloseFiscalYearRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	closing, err := h.ledgerService.CloseFiscalYear(c.Request.Context(), companyID, c.GetString("user_id"), req.FiscalYear)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, closing)
+}
+
+// ListClosings handles GET /v1/ledger/closings
+func (h *LedgerHandler) ListClosings(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	closings, err := h.ledgerService.ListClosings(c.Request.Context(), companyID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"closings": closings,
+		"count":    len(closings),
+	})
+}
+
+func (h *LedgerHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrLedgerAccountNotFound),
+		errors.Is(err, services.ErrPostingRuleNotFound),
+		errors.Is(err, services.ErrJournalEntryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrLedgerAccountCodeTaken),
+		errors.Is(err, services.ErrLedgerChartExists),
+		errors.Is(err, services.ErrLedgerAccountInUse),
+		errors.Is(err, services.ErrFiscalYearClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrLedgerAccountNotPostable),
+		errors.Is(err, services.ErrLedgerParentInvalid),
+		errors.Is(err, services.ErrLedgerRulesIncomplete),
+		errors.Is(err, services.ErrLedgerDisabled),
+		errors.Is(err, services.ErrFiscalYearNotEnded),
+		errors.Is(err, services.ErrFiscalYearOutOfOrder):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "validation failed"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
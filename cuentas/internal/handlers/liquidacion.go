@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"cuentas/internal/dte"
+	"cuentas/internal/hacienda"
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LiquidacionHandler struct {
+	liquidacionService *services.LiquidacionService
+}
+
+func NewLiquidacionHandler(svc *services.LiquidacionService) *LiquidacionHandler {
+	return &LiquidacionHandler{
+		liquidacionService: svc,
+	}
+}
+
+// CreateLiquidacion handles POST /v1/liquidaciones
+// Summarizes accepted DTEs from the commit log and computes totals and commission;
+// transmission happens on finalize
+func (h *LiquidacionHandler) CreateLiquidacion(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.CreateLiquidacionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+
+	liquidacion, err := h.liquidacionService.CreateLiquidacion(c.Request.Context(), companyID, &req, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrClientNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "client not found"})
+		case errors.Is(err, services.ErrPointOfSaleNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "establishment or point of sale not found"})
+		case errors.Is(err, services.ErrLiquidacionInvalidSource),
+			errors.Is(err, services.ErrLiquidacionReceptor):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, liquidacion)
+}
+
+// GetLiquidacion handles GET /v1/liquidaciones/:id
+func (h *LiquidacionHandler) GetLiquidacion(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	liquidacion, err := h.liquidacionService.GetLiquidacionByID(c.Request.Context(), companyID, c.Param("id"))
+	if err != nil {
+		if err == services.ErrLiquidacionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "liquidación not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, liquidacion)
+}
+
+// ListLiquidaciones handles GET /v1/liquidaciones
+func (h *LiquidacionHandler) ListLiquidaciones(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	liquidaciones, err := h.liquidacionService.ListLiquidaciones(c.Request.Context(), companyID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"liquidaciones": liquidaciones,
+		"count":         len(liquidaciones),
+		"limit":         limit,
+		"offset":        offset,
+	})
+}
+
+// FinalizeLiquidacion handles POST /v1/liquidaciones/:id/finalize
+// Builds, signs and transmits the DTE 08. Failures reaching firmador or Hacienda
+// queue the liquidación in the POS contingency period.
+func (h *LiquidacionHandler) FinalizeLiquidacion(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	liquidacionID := c.Param("id")
+
+	liquidacion, err := h.liquidacionService.GetLiquidacionByID(ctx, companyID, liquidacionID)
+	if err != nil {
+		if err == services.ErrLiquidacionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "liquidación not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if liquidacion.IsProcessed() {
+		c.JSON(http.StatusConflict, gin.H{"error": services.ErrLiquidacionAlreadyIssued.Error()})
+		return
+	}
+
+	dteServiceInterface, exists := c.Get("dteService")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DTE service not available"})
+		return
+	}
+	dteService := dteServiceInterface.(*dte.DTEService)
+
+	_, processErr := dteService.ProcessLiquidacion(ctx, liquidacion)
+
+	// Reload to return what was persisted (Hacienda response or contingency status)
+	liquidacion, err = h.liquidacionService.GetLiquidacionByID(ctx, companyID, liquidacionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if processErr != nil {
+		if respondSchemaValidationError(c, processErr, gin.H{"liquidacion": liquidacion}) {
+			return
+		}
+
+		var hacErr *hacienda.HaciendaError
+		switch {
+		case errors.Is(processErr, dte.ErrQueuedForContingency):
+			c.JSON(http.StatusAccepted, gin.H{
+				"liquidacion": liquidacion,
+				"message":     processErr.Error(),
+			})
+		case errors.As(processErr, &hacErr) && hacErr.Type == "rejection":
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":       "liquidación rejected by Hacienda",
+				"liquidacion": liquidacion,
+			})
+		default:
+			log.Printf("[FinalizeLiquidacion] ❌ DTE processing failed for liquidación %s: %v", liquidacionID, processErr)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": processErr.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"liquidacion": liquidacion})
+}
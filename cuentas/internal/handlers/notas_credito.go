@@ -27,6 +27,7 @@ func (h *NotaHandler) CreateNotaCredito(c *gin.Context) {
 	nota, err := h.notaCreditoService.CreateNotaCredito(
 		c.Request.Context(),
 		companyID,
+		c.GetString("user_id"),
 		&request,
 		h.invoiceService,
 	)
@@ -73,6 +74,58 @@ func (h *NotaHandler) GetNotaCredito(c *gin.Context) {
 	c.JSON(http.StatusOK, nota)
 }
 
+// PreviewNotaCreditoDTE handles POST /v1/notas/credito/:id/preview-dte
+// Builds the Type 05 DTE a draft nota would be finalized with, without saving
+// a numero control, signing or transmitting it.
+func (h *NotaHandler) PreviewNotaCreditoDTE(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	notaID := c.Param("id")
+	if notaID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nota_id is required"})
+		return
+	}
+
+	nota, err := h.notaCreditoService.GetNotaCredito(c.Request.Context(), notaID, companyID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "nota not found" {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	if nota.Status != "draft" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only draft notas can be previewed"})
+		return
+	}
+
+	dteService, ok := dteServiceFromContext(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.previewNotaCredito(c, dteService, companyID, nota)
+	respondDTEPreview(c, result, err)
+}
+
+// previewNotaCredito builds the DTE of a draft nota with a provisional numero
+// control, for the preview and for the finalize pre-flight
+func (h *NotaHandler) previewNotaCredito(c *gin.Context, dteService *dte.DTEService, companyID string, nota *models.NotaCredito) (*dte.PreviewResult, error) {
+	numeroControl, err := h.notaCreditoService.PreviewNumeroControl(c.Request.Context(), companyID, nota)
+	if err != nil {
+		return nil, err
+	}
+	nota.DteNumeroControl = &numeroControl
+
+	return dteService.PreviewNotaCredito(c.Request.Context(), nota)
+}
+
 // FinalizeNotaCredito handles POST /v1/notas/credito/:id/finalize
 func (h *NotaHandler) FinalizeNotaCredito(c *gin.Context) {
 	companyID := c.GetString("company_id")
@@ -87,11 +140,32 @@ func (h *NotaHandler) FinalizeNotaCredito(c *gin.Context) {
 		return
 	}
 
+	// Build and validate the DTE while the nota is still a draft; finalize
+	// rejects any other status itself
+	if dteService, ok := c.Get("dteService"); ok {
+		draft, err := h.notaCreditoService.GetNotaCredito(c.Request.Context(), notaID, companyID)
+		if err != nil {
+			statusCode := http.StatusInternalServerError
+			if err.Error() == "nota not found" {
+				statusCode = http.StatusNotFound
+			}
+			c.JSON(statusCode, gin.H{"error": err.Error()})
+			return
+		}
+		if draft.Status == "draft" {
+			result, err := h.previewNotaCredito(c, dteService.(*dte.DTEService), companyID, draft)
+			if !preflightDTE(c, result, err) {
+				return
+			}
+		}
+	}
+
 	// Finalize the nota (generates numero control, updates status)
 	nota, err := h.notaCreditoService.FinalizeNotaCredito(
 		c.Request.Context(),
 		notaID,
 		companyID,
+		c.GetString("user_id"),
 	)
 	if err != nil {
 		if err.Error() == "nota not found" {
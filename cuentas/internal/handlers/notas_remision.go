@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 
+	"cuentas/internal/codigos"
 	"cuentas/internal/dte"
 	"cuentas/internal/models"
 	"cuentas/internal/services"
@@ -218,8 +219,16 @@ func (h *RemisionHandler) FinalizeRemision(c *gin.Context) {
 
 	log.Printf("[INFO] FinalizeRemision Handler: Validation passed - RemisionType=%s", *existingRemision.RemisionType)
 
-	// TODO: Get user ID from auth context when auth is implemented
-	userID := "00000000-0000-0000-0000-000000000000" // Placeholder
+	// Build and validate the DTE while the remision is still a draft; finalize
+	// rejects any other status itself
+	if dteService, ok := c.Get("dteService"); ok && existingRemision.Status == "draft" {
+		result, err := h.previewRemisionDTE(c, dteService.(*dte.DTEService), companyID, existingRemision)
+		if !preflightDTE(c, result, err) {
+			return
+		}
+	}
+
+	userID := c.GetString("user_id")
 
 	// Finalize remision (generates DTE identifiers)
 	log.Printf("[DEBUG] FinalizeRemision Handler: Calling service to finalize remision")
@@ -305,6 +314,65 @@ func (h *RemisionHandler) FinalizeRemision(c *gin.Context) {
 	})
 }
 
+// PreviewRemisionDTE handles POST /v1/remisiones/:id/preview-dte
+// Builds the Type 04 DTE a draft remision would be finalized with, without
+// consuming a sequence number, signing or transmitting it.
+func (h *RemisionHandler) PreviewRemisionDTE(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	remisionID := c.Param("id")
+	if remisionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "remision_id is required"})
+		return
+	}
+
+	remision, err := h.invoiceService.GetInvoice(c.Request.Context(), companyID, remisionID)
+	if err != nil {
+		if err == services.ErrInvoiceNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "remision not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Check RemisionType field instead of IsRemision() (which checks DteType that's NULL until finalized)
+	if remision.RemisionType == nil || *remision.RemisionType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "document is not a remision"})
+		return
+	}
+	if remision.Status != "draft" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only draft remisiones can be previewed"})
+		return
+	}
+
+	dteService, ok := dteServiceFromContext(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.previewRemisionDTE(c, dteService, companyID, remision)
+	respondDTEPreview(c, result, err)
+}
+
+// previewRemisionDTE builds the Type 04 DTE of a draft remision with a
+// provisional numero control, for the preview and for the finalize pre-flight
+func (h *RemisionHandler) previewRemisionDTE(c *gin.Context, dteService *dte.DTEService, companyID string, remision *models.Invoice) (*dte.PreviewResult, error) {
+	numeroControl, err := h.invoiceService.PreviewNumeroControl(c.Request.Context(), companyID, remision.PointOfSaleID, codigos.DocTypeNotaRemision)
+	if err != nil {
+		return nil, err
+	}
+	tipoDte := codigos.DocTypeNotaRemision
+	remision.DteNumeroControl = &numeroControl
+	remision.DteType = &tipoDte
+
+	return dteService.PreviewRemision(c.Request.Context(), remision)
+}
+
 // LinkRemisionToInvoice handles POST /v1/remisiones/:id/link-invoice
 func (h *RemisionHandler) LinkRemisionToInvoice(c *gin.Context) {
 	companyID := c.GetString("company_id")
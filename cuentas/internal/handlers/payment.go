@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cuentas/internal/formats"
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentHandler struct {
+	paymentService *services.PaymentService
+}
+
+func NewPaymentHandler(svc *services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: svc,
+	}
+}
+
+// RecordPayment handles POST /v1/invoices/:id/payments
+// Applies a (possibly partial) payment to a finalized invoice.
+func (h *PaymentHandler) RecordPayment(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.paymentService.RecordPayment(c.Request.Context(), companyID, c.Param("id"), c.GetString("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+// ListInvoicePayments handles GET /v1/invoices/:id/payments
+// Voided payments are included.
+func (h *PaymentHandler) ListInvoicePayments(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	payments, err := h.paymentService.ListPayments(c.Request.Context(), companyID, &models.PaymentFilters{
+		InvoiceID:     c.Param("id"),
+		IncludeVoided: true,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"payments": payments,
+		"count":    len(payments),
+	})
+}
+
+// ListPayments handles GET /v1/payments
+// Filters: client_id, invoice_id, from_date, to_date, include_voided.
+func (h *PaymentHandler) ListPayments(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	filters := &models.PaymentFilters{
+		ClientID:      c.Query("client_id"),
+		InvoiceID:     c.Query("invoice_id"),
+		FromDate:      c.Query("from_date"),
+		ToDate:        c.Query("to_date"),
+		IncludeVoided: c.Query("include_voided") == "true",
+		Limit:         limit,
+		Offset:        offset,
+	}
+	for _, d := range []string{filters.FromDate, filters.ToDate} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+			return
+		}
+	}
+
+	payments, err := h.paymentService.ListPayments(c.Request.Context(), companyID, filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"payments": payments,
+		"count":    len(payments),
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+// GetPayment handles GET /v1/payments/:id
+func (h *PaymentHandler) GetPayment(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	payment, err := h.paymentService.GetPayment(c.Request.Context(), companyID, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// VoidPayment handles POST /v1/payments/:id/void
+func (h *PaymentHandler) VoidPayment(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.VoidPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.paymentService.VoidPayment(c.Request.Context(), companyID, c.Param("id"), c.GetString("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// GetAgingReport handles GET /v1/reports/ar-aging
+// Query: as_of_date (default today), group_by (client or establishment),
+// client_id, establishment_id, format (json or csv), language (es or en).
+func (h *PaymentHandler) GetAgingReport(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	asOf := time.Now()
+	if d := c.Query("as_of_date"); d != "" {
+		parsed, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+			return
+		}
+		asOf = parsed
+	}
+	asOf = time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC)
+
+	groupBy := c.DefaultQuery("group_by", models.AgingGroupByClient)
+	if groupBy != models.AgingGroupByClient && groupBy != models.AgingGroupByEstablishment {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by must be client or establishment"})
+		return
+	}
+
+	report, err := h.paymentService.GetAgingReport(c.Request.Context(), companyID, &models.AgingFilters{
+		AsOfDate:        asOf,
+		GroupBy:         groupBy,
+		ClientID:        c.Query("client_id"),
+		EstablishmentID: c.Query("establishment_id"),
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if formats.DetermineFormat(c.GetHeader("Accept"), c.Query("format")) == "csv" {
+		csvData, err := formats.WriteAgingCSV(report, formats.DetermineLanguage(c.Query("language")))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate CSV"})
+			return
+		}
+
+		filename := fmt.Sprintf("ar_aging_%s_%s.csv", groupBy, report.AsOfDate)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", csvData)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *PaymentHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrPaymentNotFound), errors.Is(err, services.ErrInvoiceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPaymentAlreadyVoided), errors.Is(err, services.ErrInvoiceAlreadyVoid),
+		errors.Is(err, services.ErrInvalidInvoiceStatus), errors.Is(err, services.ErrPaymentReconciled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInsufficientPayment):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
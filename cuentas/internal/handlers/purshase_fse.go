@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"cuentas/internal/dte"
 	"cuentas/internal/hacienda"
@@ -42,13 +44,101 @@ func (h *PurchaseHandler) CreateFSE(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "point of sale not found"})
 			return
 		}
+		if err == services.ErrSupplierNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == services.ErrSupplierInactive {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, purchase)
+}
+
+// CreateFromReceivedDTE handles POST /api/v1/purchases/received-dte
+// Loads a Factura or CCF a supplier issued to us (JSON document or signed JWS)
+// as a finalized regular purchase, after checking it against its schema and
+// confirming with Hacienda that it was processed.
+func (h *PurchaseHandler) CreateFromReceivedDTE(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.CreateReceivedDTEPurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	received, err := dte.ParseReceivedDTE(req.Document)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := received.Validate(); err != nil {
+		if respondSchemaValidationError(c, err, nil) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	ctx := c.Request.Context()
+	if err := h.purchaseService.EnsureReceivedDTENotLoaded(ctx, companyID, received.CodigoGeneracion()); err != nil {
+		h.handleReceivedDTEError(c, err)
+		return
+	}
+
+	dteService, ok := dteServiceFromContext(c)
+	if !ok {
+		return
+	}
+	consulta, err := dteService.VerifyReceivedDTE(ctx, companyID, received)
+	if err != nil {
+		h.handleReceivedDTEError(c, err)
+		return
+	}
+
+	doc, err := received.PurchaseDocument(consulta)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	purchase, err := h.purchaseService.CreateFromReceivedDTE(ctx, companyID, c.GetString("user_id"), &req, doc)
+	if err != nil {
+		h.handleReceivedDTEError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusCreated, purchase)
 }
 
+func (h *PurchaseHandler) handleReceivedDTEError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrReceivedDTEDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, dte.ErrReceivedDTENotProcessed), errors.Is(err, dte.ErrReceivedDTESelloMismatch),
+		errors.Is(err, services.ErrReceivedDTENotForCompany), errors.Is(err, services.ErrSupplierInactive):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPointOfSaleNotFound), errors.Is(err, services.ErrSupplierNotFound),
+		errors.Is(err, services.ErrInventoryItemNotFound), errors.Is(err, models.ErrEstablishmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrReceivedDTELineNotFound), strings.Contains(err.Error(), "validation failed"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "Hacienda"):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 // GetPurchase handles GET /api/v1/purchases/:id
 func (h *PurchaseHandler) GetPurchase(c *gin.Context) {
 	companyID := c.GetString("company_id")
@@ -115,8 +205,27 @@ func (h *PurchaseHandler) FinalizePurchase(c *gin.Context) {
 		return
 	}
 
-	// TODO: Get user ID from auth context when auth is implemented
-	userID := "00000000-0000-0000-0000-000000000000" // Placeholder
+	// Build and validate the FSE while the purchase is still a draft; finalize
+	// rejects any other status itself
+	if dteService, ok := c.Get("dteService"); ok {
+		draft, err := h.purchaseService.GetPurchaseByID(c.Request.Context(), companyID, purchaseID)
+		if err != nil {
+			if err == services.ErrPurchaseNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "purchase not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if draft.Status == "draft" && draft.IsFSE() {
+			result, err := h.previewFSE(c, dteService.(*dte.DTEService), draft)
+			if !preflightDTE(c, result, err) {
+				return
+			}
+		}
+	}
+
+	userID := c.GetString("user_id")
 
 	// Finalize purchase (generates numero control, updates status)
 	purchase, err := h.purchaseService.FinalizePurchase(c.Request.Context(), companyID, purchaseID, userID)
@@ -176,3 +285,59 @@ func (h *PurchaseHandler) FinalizePurchase(c *gin.Context) {
 
 	c.JSON(http.StatusOK, purchase)
 }
+
+// PreviewPurchaseDTE handles POST /api/v1/purchases/:id/preview-dte
+// Builds the FSE (Type 14) a draft purchase would be finalized with, without
+// assigning a numero control, signing or transmitting it.
+func (h *PurchaseHandler) PreviewPurchaseDTE(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	purchaseID := c.Param("id")
+	if purchaseID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "purchase_id is required"})
+		return
+	}
+
+	purchase, err := h.purchaseService.GetPurchaseByID(c.Request.Context(), companyID, purchaseID)
+	if err != nil {
+		if err == services.ErrPurchaseNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "purchase not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if purchase.Status != "draft" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only draft purchases can be previewed"})
+		return
+	}
+	if !purchase.IsFSE() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only FSE purchases issue a DTE"})
+		return
+	}
+
+	dteService, ok := dteServiceFromContext(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.previewFSE(c, dteService, purchase)
+	respondDTEPreview(c, result, err)
+}
+
+// previewFSE builds the FSE of a draft purchase with a provisional numero
+// control, for the preview and for the finalize pre-flight
+func (h *PurchaseHandler) previewFSE(c *gin.Context, dteService *dte.DTEService, purchase *models.Purchase) (*dte.PreviewResult, error) {
+	numeroControl, err := h.purchaseService.PreviewNumeroControl(c.Request.Context(), purchase)
+	if err != nil {
+		return nil, err
+	}
+	purchase.DteNumeroControl = &numeroControl
+
+	return dteService.PreviewFSE(c.Request.Context(), purchase)
+}
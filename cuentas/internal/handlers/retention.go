@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"cuentas/internal/dte"
+	"cuentas/internal/hacienda"
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RetentionHandler struct {
+	retentionService *services.RetentionService
+}
+
+func NewRetentionHandler(svc *services.RetentionService) *RetentionHandler {
+	return &RetentionHandler{
+		retentionService: svc,
+	}
+}
+
+// ValidateEligibility handles POST /v1/retentions/validate
+func (h *RetentionHandler) ValidateEligibility(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.CreateRetentionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.retentionService.ValidateRetentionEligibility(c.Request.Context(), companyID, req.PurchaseID)
+	if err != nil {
+		if errors.Is(err, services.ErrPurchaseNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "purchase not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateRetention handles POST /v1/retentions
+// Creates the retention record (amounts, numero de control); transmission happens on finalize
+func (h *RetentionHandler) CreateRetention(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.CreateRetentionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+
+	retention, err := h.retentionService.CreateRetention(c.Request.Context(), companyID, &req, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPurchaseNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "purchase not found"})
+		case errors.Is(err, services.ErrPurchaseNotEligible):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, retention)
+}
+
+// GetRetention handles GET /v1/retentions/:id
+func (h *RetentionHandler) GetRetention(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	retention, err := h.retentionService.GetRetentionByID(c.Request.Context(), companyID, c.Param("id"))
+	if err != nil {
+		if err == services.ErrRetentionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "retention not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, retention)
+}
+
+// ListRetentions handles GET /v1/retentions
+func (h *RetentionHandler) ListRetentions(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	retentions, err := h.retentionService.ListRetentions(c.Request.Context(), companyID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"retentions": retentions,
+		"count":      len(retentions),
+		"limit":      limit,
+		"offset":     offset,
+	})
+}
+
+// FinalizeRetention handles POST /v1/retentions/:id/finalize
+// Builds, signs and transmits the DTE 07. Failures reaching firmador or Hacienda
+// queue the retention in the POS contingency period.
+func (h *RetentionHandler) FinalizeRetention(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	retentionID := c.Param("id")
+
+	retention, err := h.retentionService.GetRetentionByID(ctx, companyID, retentionID)
+	if err != nil {
+		if err == services.ErrRetentionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "retention not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if retention.IsProcessed() {
+		c.JSON(http.StatusConflict, gin.H{"error": services.ErrRetentionAlreadyIssued.Error()})
+		return
+	}
+
+	dteServiceInterface, exists := c.Get("dteService")
+	if !exists {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DTE service not available"})
+		return
+	}
+	dteService := dteServiceInterface.(*dte.DTEService)
+
+	_, processErr := dteService.ProcessRetention(ctx, retention)
+
+	// Reload to return what was persisted (Hacienda response or contingency status)
+	retention, err = h.retentionService.GetRetentionByID(ctx, companyID, retentionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if processErr != nil {
+		if respondSchemaValidationError(c, processErr, gin.H{"retention": retention}) {
+			return
+		}
+
+		var hacErr *hacienda.HaciendaError
+		switch {
+		case errors.Is(processErr, dte.ErrQueuedForContingency):
+			c.JSON(http.StatusAccepted, gin.H{
+				"retention": retention,
+				"message":   processErr.Error(),
+			})
+		case errors.As(processErr, &hacErr) && hacErr.Type == "rejection":
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":     "retention rejected by Hacienda",
+				"retention": retention,
+			})
+		default:
+			log.Printf("[FinalizeRetention] ❌ DTE processing failed for retention %s: %v", retentionID, processErr)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": processErr.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"retention": retention})
+}
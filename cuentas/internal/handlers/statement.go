@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"cuentas/internal/formats"
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatementHandler struct {
+	statementService *services.StatementService
+}
+
+func NewStatementHandler(svc *services.StatementService) *StatementHandler {
+	return &StatementHandler{
+		statementService: svc,
+	}
+}
+
+// GetStatement handles GET /v1/clients/:id/statement
+// Query: from_date (default first of to_date's month), to_date (default today),
+// format (json, csv or pdf), language (es or en).
+func (h *StatementHandler) GetStatement(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	period, err := models.ParseStatementPeriod(c.Query("from_date"), c.Query("to_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	statement, err := h.statementService.GetStatement(c.Request.Context(), companyID, c.Param("id"), period)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	lang := formats.DetermineLanguage(c.Query("language"))
+	filename := fmt.Sprintf("estado_de_cuenta_%s_%s", statement.FromDate, statement.ToDate)
+
+	switch formats.DetermineFormat(c.GetHeader("Accept"), c.Query("format")) {
+	case "csv":
+		csvData, err := formats.WriteStatementCSV(statement, lang)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate CSV"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", filename))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", csvData)
+	case "pdf":
+		pdfBytes, err := formats.WriteStatementPDF(statement, lang)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate PDF"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%s.pdf", filename))
+		c.Data(http.StatusOK, "application/pdf", pdfBytes)
+	default:
+		c.JSON(http.StatusOK, statement)
+	}
+}
+
+// EmailStatement handles POST /v1/clients/:id/statement/email
+// Mails the statement PDF to the recipient, or to the client's correo.
+func (h *StatementHandler) EmailStatement(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	var req models.EmailStatementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := models.ParseStatementPeriod(req.FromDate, req.ToDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.statementService.EmailStatement(c.Request.Context(), companyID, c.Param("id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *StatementHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrClientNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrStatementNoRecipient):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrStatementSendFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"cuentas/internal/formats"
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaxBookHandler struct {
+	taxBookService *services.TaxBookService
+}
+
+func NewTaxBookHandler(svc *services.TaxBookService) *TaxBookHandler {
+	return &TaxBookHandler{
+		taxBookService: svc,
+	}
+}
+
+// GetConsumerSalesBook handles GET /v1/reports/consumer-sales-book
+// Query: year and month (default current month), establishment_id,
+// format (json, csv or pdf), language (es or en).
+func (h *TaxBookHandler) GetConsumerSalesBook(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	period, err := models.ParseTaxBookPeriod(c.Query("year"), c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	book, err := h.taxBookService.GetConsumerSalesBook(c.Request.Context(), companyID, period, c.Query("establishment_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respond(c, book, fmt.Sprintf("libro_ventas_consumidor_%s", period),
+		func(lang string) ([]byte, error) { return formats.WriteConsumerSalesBookCSV(book, lang) },
+		func(lang string) ([]byte, error) { return formats.WriteConsumerSalesBookPDF(book, lang) },
+	)
+}
+
+// GetContributorSalesBook handles GET /v1/reports/contributor-sales-book
+// Query: year and month (default current month), establishment_id,
+// format (json, csv or pdf), language (es or en).
+func (h *TaxBookHandler) GetContributorSalesBook(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	period, err := models.ParseTaxBookPeriod(c.Query("year"), c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	book, err := h.taxBookService.GetContributorSalesBook(c.Request.Context(), companyID, period, c.Query("establishment_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respond(c, book, fmt.Sprintf("libro_ventas_contribuyentes_%s", period),
+		func(lang string) ([]byte, error) { return formats.WriteContributorSalesBookCSV(book, lang) },
+		func(lang string) ([]byte, error) { return formats.WriteContributorSalesBookPDF(book, lang) },
+	)
+}
+
+// GetPurchaseBook handles GET /v1/reports/purchase-book
+// Query: year and month (default current month), establishment_id,
+// format (json, csv or pdf), language (es or en).
+func (h *TaxBookHandler) GetPurchaseBook(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return
+	}
+
+	period, err := models.ParseTaxBookPeriod(c.Query("year"), c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	book, err := h.taxBookService.GetPurchaseBook(c.Request.Context(), companyID, period, c.Query("establishment_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respond(c, book, fmt.Sprintf("libro_compras_%s", period),
+		func(lang string) ([]byte, error) { return formats.WritePurchaseBookCSV(book, lang) },
+		func(lang string) ([]byte, error) { return formats.WritePurchaseBookPDF(book, lang) },
+	)
+}
+
+// GetIVADeclaration handles GET /v1/reports/f07
+// Query: year and month (default current month), remanente_anterior (crédito
+// fiscal carried over from the previous month), format (json, or zip for every
+// anexo file).
+func (h *TaxBookHandler) GetIVADeclaration(c *gin.Context) {
+	decl, ok := h.loadIVADeclaration(c)
+	if !ok {
+		return
+	}
+
+	if c.Query("format") == "zip" {
+		zipData, err := formats.WriteF07AnexosZip(decl)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate anexos"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=F07_%s.zip",
+			models.TaxBookPeriod{Year: decl.Year, Month: decl.Month}))
+		c.Data(http.StatusOK, "application/zip", zipData)
+		return
+	}
+
+	c.JSON(http.StatusOK, decl)
+}
+
+// GetF07Anexo handles GET /v1/reports/f07/anexos/:anexo
+// Returns one anexo file in Hacienda's upload layout. Query: year and month.
+func (h *TaxBookHandler) GetF07Anexo(c *gin.Context) {
+	anexo, err := strconv.Atoi(c.Param("anexo"))
+	if err != nil || !models.IsValidF07Anexo(anexo) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("anexo must be one of %v", models.F07Anexos)})
+		return
+	}
+
+	decl, ok := h.loadIVADeclaration(c)
+	if !ok {
+		return
+	}
+
+	csvData, err := formats.WriteF07AnexoCSV(decl, anexo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate anexo"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", formats.F07AnexoFilename(decl, anexo)))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", csvData)
+}
+
+// loadIVADeclaration computes the F-07 of the requested month, writing the
+// error response when it fails
+func (h *TaxBookHandler) loadIVADeclaration(c *gin.Context) (*models.IVADeclaration, bool) {
+	companyID := c.GetString("company_id")
+	if companyID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "company_id not found in context"})
+		return nil, false
+	}
+
+	period, err := models.ParseTaxBookPeriod(c.Query("year"), c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	remanente := 0.0
+	if v := c.Query("remanente_anterior"); v != "" {
+		remanente, err = strconv.ParseFloat(v, 64)
+		if err != nil || remanente < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "remanente_anterior must be a non-negative amount"})
+			return nil, false
+		}
+	}
+
+	decl, err := h.taxBookService.GetIVADeclaration(c.Request.Context(), companyID, period, remanente)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return decl, true
+}
+
+// respond writes an IVA book as JSON, or as CSV or PDF when requested
+func (h *TaxBookHandler) respond(c *gin.Context, book interface{}, filename string, writeCSV, writePDF func(lang string) ([]byte, error)) {
+	lang := formats.DetermineLanguage(c.Query("language"))
+
+	switch formats.DetermineFormat(c.GetHeader("Accept"), c.Query("format")) {
+	case "csv":
+		csvData, err := writeCSV(lang)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate CSV"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", filename))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", csvData)
+	case "pdf":
+		pdfBytes, err := writePDF(lang)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate PDF"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%s.pdf", filename))
+		c.Data(http.StatusOK, "application/pdf", pdfBytes)
+	default:
+		c.JSON(http.StatusOK, book)
+	}
+}
@@ -30,9 +30,11 @@ func (t *Translations) EventType(eventType string) string {
 
 	// Spanish translations
 	translations := map[string]string{
-		"PURCHASE":   "COMPRA",
-		"SALE":       "VENTA",
-		"ADJUSTMENT": "AJUSTE",
+		"PURCHASE":     "COMPRA",
+		"SALE":         "VENTA",
+		"ADJUSTMENT":   "AJUSTE",
+		"TRANSFER_OUT": "TRASLADO SALIDA",
+		"TRANSFER_IN":  "TRASLADO ENTRADA",
 	}
 
 	if translated, ok := translations[eventType]; ok {
@@ -61,7 +63,7 @@ func (t *Translations) ItemType(tipoItem string) string {
 func (t *Translations) InventoryEventsHeaders() []string {
 	if t.lang == English {
 		return []string{
-			"Event ID", "Timestamp", "SKU", "Item Name", "Event Type",
+			"Event ID", "Timestamp", "SKU", "Item Name", "Location", "Event Type",
 			"Quantity", "Unit Cost", "Total Cost",
 			"Avg Cost Before", "Avg Cost After",
 			"Balance Qty", "Balance Value", "Notes",
@@ -70,7 +72,7 @@ func (t *Translations) InventoryEventsHeaders() []string {
 
 	// Spanish headers
 	return []string{
-		"ID de Evento", "Fecha y Hora", "SKU", "Nombre del Artículo", "Tipo de Evento",
+		"ID de Evento", "Fecha y Hora", "SKU", "Nombre del Artículo", "Establecimiento", "Tipo de Evento",
 		"Cantidad", "Costo Unitario", "Costo Total",
 		"Costo Promedio Antes", "Costo Promedio Después",
 		"Cantidad en Existencia", "Valor en Existencia", "Notas",
@@ -81,13 +83,27 @@ func (t *Translations) InventoryEventsHeaders() []string {
 func (t *Translations) InventoryStatesHeaders() []string {
 	if t.lang == English {
 		return []string{
-			"SKU", "Item Name", "Type", "Quantity", "Avg Cost", "Total Value", "Last Updated",
+			"SKU", "Item Name", "Type", "Location Code", "Location", "Quantity", "Avg Cost", "Total Value", "Last Updated",
 		}
 	}
 
 	// Spanish headers
 	return []string{
-		"SKU", "Nombre del Artículo", "Tipo", "Cantidad", "Costo Promedio", "Valor Total", "Última Actualización",
+		"SKU", "Nombre del Artículo", "Tipo", "Código Establecimiento", "Establecimiento", "Cantidad", "Costo Promedio", "Valor Total", "Última Actualización",
+	}
+}
+
+// InventoryTotalsHeaders returns CSV headers for consolidated inventory states
+func (t *Translations) InventoryTotalsHeaders() []string {
+	if t.lang == English {
+		return []string{
+			"SKU", "Item Name", "Type", "Quantity", "Avg Cost", "Total Value", "Locations", "Last Updated",
+		}
+	}
+
+	// Spanish headers
+	return []string{
+		"SKU", "Nombre del Artículo", "Tipo", "Cantidad", "Costo Promedio", "Valor Total", "Establecimientos", "Última Actualización",
 	}
 }
 
@@ -205,3 +221,548 @@ func (t *Translations) FormatItemLabel() string {
 	}
 	return "Artículo"
 }
+
+// FormatLocationLabel returns label for the register's establishment
+func (t *Translations) FormatLocationLabel() string {
+	if t.lang == English {
+		return "Location"
+	}
+	return "Establecimiento"
+}
+
+// ConsolidatedLabel names a register covering every establishment
+func (t *Translations) ConsolidatedLabel() string {
+	if t.lang == English {
+		return "Consolidated (all locations)"
+	}
+	return "Consolidado (todos los establecimientos)"
+}
+
+// AgingHeaders returns CSV headers for the accounts receivable aging report
+func (t *Translations) AgingHeaders(groupBy string) []string {
+	if t.lang == English {
+		first := "Client"
+		switch groupBy {
+		case "establishment":
+			first = "Establishment"
+		case "supplier":
+			first = "Supplier"
+		}
+		return []string{first + " ID", first, "Invoices", "0-30 Days", "31-60 Days", "61-90 Days", "Over 90 Days", "Total"}
+	}
+
+	first := "Cliente"
+	switch groupBy {
+	case "establishment":
+		first = "Establecimiento"
+	case "supplier":
+		first = "Proveedor"
+	}
+	return []string{"ID " + first, first, "Facturas", "0-30 Días", "31-60 Días", "61-90 Días", "Más de 90 Días", "Total"}
+}
+
+// AgingTitle returns the title line for the receivable or payable aging report
+func (t *Translations) AgingTitle(ledger string) string {
+	if t.lang == English {
+		if ledger == "payable" {
+			return "ACCOUNTS PAYABLE AGING"
+		}
+		return "ACCOUNTS RECEIVABLE AGING"
+	}
+	if ledger == "payable" {
+		return "ANTIGÜEDAD DE SALDOS DE CUENTAS POR PAGAR"
+	}
+	return "ANTIGÜEDAD DE SALDOS DE CUENTAS POR COBRAR"
+}
+
+// AsOfDateLabel returns label for a report's cut-off date
+func (t *Translations) AsOfDateLabel() string {
+	if t.lang == English {
+		return "As of Date"
+	}
+	return "Fecha de Corte"
+}
+
+// TotalsLabel returns label for a report's totals row
+func (t *Translations) TotalsLabel() string {
+	if t.lang == English {
+		return "TOTALS"
+	}
+	return "TOTALES"
+}
+
+// StatementTitle returns the title line for a client statement
+func (t *Translations) StatementTitle() string {
+	if t.lang == English {
+		return "ACCOUNT STATEMENT"
+	}
+	return "ESTADO DE CUENTA"
+}
+
+// ClientLabel returns label for client name
+func (t *Translations) ClientLabel() string {
+	if t.lang == English {
+		return "Client"
+	}
+	return "Cliente"
+}
+
+// OpeningBalanceLabel returns label for a statement's opening balance
+func (t *Translations) OpeningBalanceLabel() string {
+	if t.lang == English {
+		return "Opening Balance"
+	}
+	return "Saldo Inicial"
+}
+
+// ClosingBalanceLabel returns label for a statement's closing balance
+func (t *Translations) ClosingBalanceLabel() string {
+	if t.lang == English {
+		return "Closing Balance"
+	}
+	return "Saldo Final"
+}
+
+// StatementHeaders returns CSV headers for client statement entries
+func (t *Translations) StatementHeaders() []string {
+	if t.lang == English {
+		return []string{"Date", "Type", "Document", "Control Number", "Applied To", "Charges", "Credits", "Balance"}
+	}
+	return []string{"Fecha", "Tipo", "Documento", "Número de Control", "Aplicado A", "Cargos", "Abonos", "Saldo"}
+}
+
+// StatementEntryType translates a statement entry type; invoices are named by DTE type
+func (t *Translations) StatementEntryType(entryType, dteType string) string {
+	if t.lang == English {
+		switch entryType {
+		case "invoice":
+			if dteType == "03" {
+				return "Tax Credit Invoice (CCF)"
+			}
+			if dteType == "11" {
+				return "Export Invoice"
+			}
+			return "Invoice"
+		case "nota_debito":
+			return "Debit Note"
+		case "nota_credito":
+			return "Credit Note"
+		case "payment":
+			return "Payment"
+		}
+		return entryType
+	}
+
+	switch entryType {
+	case "invoice":
+		if dteType == "03" {
+			return "Comprobante de Crédito Fiscal"
+		}
+		if dteType == "11" {
+			return "Factura de Exportación"
+		}
+		return "Factura"
+	case "nota_debito":
+		return "Nota de Débito"
+	case "nota_credito":
+		return "Nota de Crédito"
+	case "payment":
+		return "Pago"
+	}
+	return entryType
+}
+
+// OpenDocumentsTitle returns the title of a statement's open documents section
+func (t *Translations) OpenDocumentsTitle() string {
+	if t.lang == English {
+		return "OPEN DOCUMENTS"
+	}
+	return "DOCUMENTOS PENDIENTES"
+}
+
+// OpenDocumentsHeaders returns CSV headers for a statement's open documents
+func (t *Translations) OpenDocumentsHeaders() []string {
+	if t.lang == English {
+		return []string{"Document", "Control Number", "Date", "Due Date", "Days Past Due", "Total", "Debit Notes", "Credit Notes", "Payments", "Balance"}
+	}
+	return []string{"Documento", "Número de Control", "Fecha", "Vencimiento", "Días Vencido", "Total", "Notas de Débito", "Notas de Crédito", "Pagos", "Saldo"}
+}
+
+// StatementEmailSubject returns the subject of a mailed statement
+func (t *Translations) StatementEmailSubject(companyName, fromDate, toDate string) string {
+	if t.lang == English {
+		return "Account statement " + fromDate + " to " + toDate + " - " + companyName
+	}
+	return "Estado de cuenta del " + fromDate + " al " + toDate + " - " + companyName
+}
+
+// StatementEmailBody returns the body of a mailed statement
+func (t *Translations) StatementEmailBody(clientName, companyName, fromDate, toDate, closingBalance string) string {
+	if t.lang == English {
+		return "Dear " + clientName + ":\n\n" +
+			"Attached is your account statement with " + companyName + " for " + fromDate + " to " + toDate + ".\n\n" +
+			"  Balance as of " + toDate + ": " + closingBalance + "\n\n" +
+			"If you have any questions about this statement, please reply to this email.\n"
+	}
+	return "Estimado(a) " + clientName + ":\n\n" +
+		"Adjuntamos su estado de cuenta con " + companyName + " del " + fromDate + " al " + toDate + ".\n\n" +
+		"  Saldo al " + toDate + ": " + closingBalance + "\n\n" +
+		"Si tiene alguna consulta sobre este estado de cuenta, puede responder a este correo.\n"
+}
+
+// ConsumerSalesBookTitle returns the title line for the libro de ventas a consumidor final
+func (t *Translations) ConsumerSalesBookTitle() string {
+	if t.lang == English {
+		return "CONSUMER SALES BOOK (IVA)"
+	}
+	return "LIBRO DE VENTAS A CONSUMIDOR FINAL"
+}
+
+// ConsumerSalesBookHeaders returns CSV headers for the daily rows of the consumer sales book
+func (t *Translations) ConsumerSalesBookHeaders() []string {
+	if t.lang == English {
+		return []string{"Date", "Establishment", "Point of Sale", "Document Type", "From No.", "To No.", "Documents",
+			"Exempt Sales", "Non-Subject Sales", "Taxable Sales", "Exports", "Total Sales", "Output IVA"}
+	}
+	return []string{"Fecha", "Establecimiento", "Punto de Venta", "Tipo de Documento", "Del No.", "Al No.", "Documentos",
+		"Ventas Exentas", "Ventas No Sujetas", "Ventas Gravadas", "Exportaciones", "Total Ventas", "Débito Fiscal"}
+}
+
+// PointOfSaleTotalsTitle returns the title of an IVA book's totals per point of sale
+func (t *Translations) PointOfSaleTotalsTitle() string {
+	if t.lang == English {
+		return "TOTALS BY POINT OF SALE"
+	}
+	return "TOTALES POR PUNTO DE VENTA"
+}
+
+// PointOfSaleTotalsHeaders returns CSV headers for an IVA book's totals per point of sale
+func (t *Translations) PointOfSaleTotalsHeaders() []string {
+	if t.lang == English {
+		return []string{"Establishment", "Point of Sale", "Documents",
+			"Exempt Sales", "Non-Subject Sales", "Taxable Sales", "Exports", "Total Sales", "Output IVA"}
+	}
+	return []string{"Establecimiento", "Punto de Venta", "Documentos",
+		"Ventas Exentas", "Ventas No Sujetas", "Ventas Gravadas", "Exportaciones", "Total Ventas", "Débito Fiscal"}
+}
+
+// DocumentTypeName names a DTE type in an IVA book
+func (t *Translations) DocumentTypeName(tipoDte string) string {
+	switch tipoDte {
+	case "05":
+		return t.StatementEntryType("nota_credito", tipoDte)
+	case "06":
+		return t.StatementEntryType("nota_debito", tipoDte)
+	case "07":
+		if t.lang == English {
+			return "Withholding Receipt"
+		}
+		return "Comprobante de Retención"
+	case "14":
+		if t.lang == English {
+			return "Excluded Subject Invoice"
+		}
+		return "Factura de Sujeto Excluido"
+	}
+	return t.StatementEntryType("invoice", tipoDte)
+}
+
+// ContributorSalesBookTitle returns the title line for the libro de ventas a contribuyentes
+func (t *Translations) ContributorSalesBookTitle() string {
+	if t.lang == English {
+		return "TAXPAYER SALES BOOK (IVA)"
+	}
+	return "LIBRO DE VENTAS A CONTRIBUYENTES"
+}
+
+// ContributorSalesBookHeaders returns CSV headers for the documents of the taxpayer sales book
+func (t *Translations) ContributorSalesBookHeaders() []string {
+	if t.lang == English {
+		return []string{"No.", "Date", "Document Type", "Control Number", "Generation Code", "NRC", "NIT", "Client",
+			"Related Documents", "Exempt Sales", "Non-Subject Sales", "Taxable Sales", "Output IVA", "IVA Perceived", "IVA Withheld", "Total"}
+	}
+	return []string{"No.", "Fecha", "Tipo de Documento", "Número de Control", "Código de Generación", "NRC", "NIT", "Cliente",
+		"Documentos Relacionados", "Ventas Exentas", "Ventas No Sujetas", "Ventas Gravadas", "Débito Fiscal", "IVA Percibido", "IVA Retenido", "Total"}
+}
+
+// DocumentTypeTotalsTitle returns the title of an IVA book's totals per DTE type
+func (t *Translations) DocumentTypeTotalsTitle() string {
+	if t.lang == English {
+		return "TOTALS BY DOCUMENT TYPE"
+	}
+	return "TOTALES POR TIPO DE DOCUMENTO"
+}
+
+// ContributorSalesTotalsHeaders returns CSV headers for the taxpayer sales book's totals per DTE type
+func (t *Translations) ContributorSalesTotalsHeaders() []string {
+	if t.lang == English {
+		return []string{"Document Type", "Documents", "Exempt Sales", "Non-Subject Sales", "Taxable Sales", "Output IVA", "IVA Perceived", "IVA Withheld", "Total"}
+	}
+	return []string{"Tipo de Documento", "Documentos", "Ventas Exentas", "Ventas No Sujetas", "Ventas Gravadas", "Débito Fiscal", "IVA Percibido", "IVA Retenido", "Total"}
+}
+
+// PurchaseBookTitle returns the title line for the libro de compras
+func (t *Translations) PurchaseBookTitle() string {
+	if t.lang == English {
+		return "PURCHASES BOOK (IVA)"
+	}
+	return "LIBRO DE COMPRAS"
+}
+
+// PurchaseBookHeaders returns CSV headers for the purchases of the purchases book
+func (t *Translations) PurchaseBookHeaders() []string {
+	if t.lang == English {
+		return []string{"No.", "Date", "Document Type", "Document Number", "Generation Code", "NRC", "NIT / Document", "Supplier",
+			"Exempt Domestic Purchases", "Exempt Imports", "Taxable Domestic Purchases", "Taxable Imports", "Input IVA",
+			"Excluded Subjects", "Total", "IVA Withheld"}
+	}
+	return []string{"No.", "Fecha", "Tipo de Documento", "Número de Documento", "Código de Generación", "NRC", "NIT / Documento", "Proveedor",
+		"Compras Exentas Internas", "Importaciones Exentas", "Compras Gravadas Internas", "Importaciones Gravadas", "Crédito Fiscal",
+		"Sujetos Excluidos", "Total", "IVA Retenido"}
+}
+
+// PurchaseDocumentType returns the document type of a purchases book row:
+// imports have no DTE
+func (t *Translations) PurchaseDocumentType(purchaseType string, tipoDte *string) string {
+	if purchaseType == "import" || tipoDte == nil {
+		if t.lang == English {
+			return "Import"
+		}
+		return "Importación"
+	}
+	return t.DocumentTypeName(*tipoDte)
+}
+
+// RetentionsTitle returns the title of the purchases book's IVA retentions section
+func (t *Translations) RetentionsTitle() string {
+	if t.lang == English {
+		return "IVA RETENTIONS"
+	}
+	return "RETENCIONES DE IVA"
+}
+
+// RetentionsHeaders returns CSV headers for the purchases book's IVA retentions
+func (t *Translations) RetentionsHeaders() []string {
+	if t.lang == English {
+		return []string{"Type", "Date", "Document Type", "Control Number", "NRC", "NIT", "Counterparty", "Related Document", "Subject Amount", "IVA Withheld"}
+	}
+	return []string{"Tipo", "Fecha", "Tipo de Documento", "Número de Control", "NRC", "NIT", "Contraparte", "Documento Relacionado", "Monto Sujeto", "IVA Retenido"}
+}
+
+// RetentionKind returns the label of an IVA retention issued to a supplier or
+// suffered from a client
+func (t *Translations) RetentionKind(kind string) string {
+	if t.lang == English {
+		if kind == "issued" {
+			return "Issued"
+		}
+		return "Suffered"
+	}
+	if kind == "issued" {
+		return "Emitida"
+	}
+	return "Sufrida"
+}
+
+// RetentionTotalsLabels returns the labels of the issued and suffered IVA retention totals
+func (t *Translations) RetentionTotalsLabels() (string, string) {
+	if t.lang == English {
+		return "Retentions issued", "Retentions suffered"
+	}
+	return "Retenciones emitidas", "Retenciones sufridas"
+}
+
+// TrialBalanceTitle returns the title line for the trial balance
+func (t *Translations) TrialBalanceTitle() string {
+	if t.lang == English {
+		return "TRIAL BALANCE"
+	}
+	return "BALANCE DE COMPROBACIÓN"
+}
+
+// TrialBalanceHeaders returns CSV headers for the trial balance
+func (t *Translations) TrialBalanceHeaders() []string {
+	if t.lang == English {
+		return []string{"Code", "Account", "Type", "Opening Debit", "Opening Credit", "Debits", "Credits", "Closing Debit", "Closing Credit"}
+	}
+	return []string{"Código", "Cuenta", "Tipo", "Saldo Inicial Deudor", "Saldo Inicial Acreedor", "Cargos", "Abonos", "Saldo Final Deudor", "Saldo Final Acreedor"}
+}
+
+// FinancialStatementTitle returns the title line for an income statement or
+// balance sheet
+func (t *Translations) FinancialStatementTitle(statement string) string {
+	if statement == "balance_sheet" {
+		if t.lang == English {
+			return "BALANCE SHEET"
+		}
+		return "BALANCE GENERAL"
+	}
+	if t.lang == English {
+		return "INCOME STATEMENT"
+	}
+	return "ESTADO DE RESULTADOS"
+}
+
+// AccountTypeName returns the plural name of a ledger account type, used as a
+// statement section title
+func (t *Translations) AccountTypeName(accountType string) string {
+	en := map[string]string{
+		"asset":     "Assets",
+		"liability": "Liabilities",
+		"equity":    "Equity",
+		"income":    "Income",
+		"expense":   "Costs and Expenses",
+	}
+	es := map[string]string{
+		"asset":     "Activo",
+		"liability": "Pasivo",
+		"equity":    "Patrimonio",
+		"income":    "Ingresos",
+		"expense":   "Costos y Gastos",
+	}
+	if t.lang == English {
+		return en[accountType]
+	}
+	return es[accountType]
+}
+
+// SectionTotalLabel returns the label of a statement section's total
+func (t *Translations) SectionTotalLabel(accountType string) string {
+	return "Total " + t.AccountTypeName(accountType)
+}
+
+// NetIncomeLabel returns the label of the period's result
+func (t *Translations) NetIncomeLabel() string {
+	if t.lang == English {
+		return "Net Income for the Period"
+	}
+	return "Resultado del Ejercicio"
+}
+
+// TotalLiabilitiesEquityLabel returns the label of the balance sheet's
+// liabilities and equity total
+func (t *Translations) TotalLiabilitiesEquityLabel() string {
+	if t.lang == English {
+		return "Total Liabilities and Equity"
+	}
+	return "Total Pasivo y Patrimonio"
+}
+
+// AccountLabel returns the header of a statement's account column
+func (t *Translations) AccountLabel() string {
+	if t.lang == English {
+		return "Account"
+	}
+	return "Cuenta"
+}
+
+// CostCenterLabel returns label for the establishment a report is narrowed to
+func (t *Translations) CostCenterLabel() string {
+	if t.lang == English {
+		return "Cost Center"
+	}
+	return "Centro de Costo"
+}
+
+// UnassignedCostCenterLabel returns the name of the entries without an
+// establishment
+func (t *Translations) UnassignedCostCenterLabel() string {
+	if t.lang == English {
+		return "Unassigned"
+	}
+	return "Sin asignar"
+}
+
+// BankReconciliationTitle returns the title line for the bank reconciliation
+func (t *Translations) BankReconciliationTitle() string {
+	if t.lang == English {
+		return "BANK RECONCILIATION"
+	}
+	return "CONCILIACIÓN BANCARIA"
+}
+
+// BankAccountLabel returns label for the bank account a report covers
+func (t *Translations) BankAccountLabel() string {
+	if t.lang == English {
+		return "Bank Account"
+	}
+	return "Cuenta Bancaria"
+}
+
+// StatementBalanceLabel returns label for the closing balance of the last
+// statement imported for the period
+func (t *Translations) StatementBalanceLabel(date string) string {
+	if t.lang == English {
+		return "Statement balance at " + date
+	}
+	return "Saldo según estado de cuenta al " + date
+}
+
+// BankReconciliationSummaryLabels returns the labels of the reconciliation
+// summary: statement line counts by status, statement totals, then the
+// unmatched amounts on each side
+func (t *Translations) BankReconciliationSummaryLabels() []string {
+	if t.lang == English {
+		return []string{
+			"Statement lines", "Matched", "Ignored", "Unmatched",
+			"Deposits", "Withdrawals",
+			"Deposits not in the books", "Withdrawals not in the books",
+			"Receipts not in the bank", "Disbursements not in the bank",
+		}
+	}
+	return []string{
+		"Movimientos del estado de cuenta", "Conciliados", "Ignorados", "Sin conciliar",
+		"Depósitos", "Retiros",
+		"Depósitos no registrados", "Retiros no registrados",
+		"Cobros no depositados", "Pagos no debitados",
+	}
+}
+
+// UnmatchedBankLinesTitle returns the title of the statement lines still
+// unmatched
+func (t *Translations) UnmatchedBankLinesTitle() string {
+	if t.lang == English {
+		return "STATEMENT LINES NOT IN THE BOOKS"
+	}
+	return "MOVIMIENTOS BANCARIOS NO REGISTRADOS"
+}
+
+// UnmatchedBankLinesHeaders returns CSV headers for the unmatched statement
+// lines
+func (t *Translations) UnmatchedBankLinesHeaders() []string {
+	if t.lang == English {
+		return []string{"Date", "Description", "Reference", "Amount"}
+	}
+	return []string{"Fecha", "Descripción", "Referencia", "Monto"}
+}
+
+// UnmatchedBankItemsTitle returns the title of the payments not found on any
+// statement
+func (t *Translations) UnmatchedBankItemsTitle() string {
+	if t.lang == English {
+		return "PAYMENTS NOT IN THE BANK"
+	}
+	return "PAGOS REGISTRADOS NO REFLEJADOS EN EL BANCO"
+}
+
+// UnmatchedBankItemsHeaders returns CSV headers for the unmatched payments
+func (t *Translations) UnmatchedBankItemsHeaders() []string {
+	if t.lang == English {
+		return []string{"Date", "Type", "Document", "Counterparty", "Method", "Reference", "Amount"}
+	}
+	return []string{"Fecha", "Tipo", "Documento", "Contraparte", "Forma de Pago", "Referencia", "Monto"}
+}
+
+// BankItemKind returns the label of a client or supplier payment
+func (t *Translations) BankItemKind(kind string) string {
+	if t.lang == English {
+		if kind == "supplier_payment" {
+			return "Supplier payment"
+		}
+		return "Client payment"
+	}
+	if kind == "supplier_payment" {
+		return "Pago a proveedor"
+	}
+	return "Cobro a cliente"
+}
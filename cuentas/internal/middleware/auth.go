@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticate verifies the request's credentials and sets company_id, role and
+// (when a person is behind the request) user_id in the context. It accepts:
+//   - a session token from POST /v1/auth/login, as "Authorization: Bearer cs_..."
+//   - an API key, as "Authorization: Bearer ck_..." or "X-API-Key: ck_..."
+//
+// API keys bound to a user act with that user's id and role; unbound keys act as
+// admin within their scopes (GET and HEAD need read, other methods write).
+// X-Company-ID is optional; when present it must name the authenticated company.
+func Authenticate(keys *services.APIKeyService, users *services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := presentedToken(c)
+		if token == "" {
+			abortUnauthorized(c, "missing credentials: send Authorization: Bearer <token> or X-API-Key")
+			return
+		}
+
+		var (
+			companyID string
+			user      *models.User
+			role      = models.RoleAdmin
+		)
+
+		if services.IsSessionToken(token) {
+			u, sessionID, err := users.AuthenticateSession(c.Request.Context(), token)
+			if err != nil {
+				if errors.Is(err, services.ErrInvalidSession) {
+					abortUnauthorized(c, err.Error())
+					return
+				}
+				abortInternal(c, "failed to verify session")
+				return
+			}
+			companyID, user = u.CompanyID, u
+			c.Set("session_id", sessionID)
+		} else {
+			key, err := keys.Authenticate(c.Request.Context(), token)
+			if err != nil {
+				if errors.Is(err, services.ErrInvalidAPIKey) {
+					abortUnauthorized(c, err.Error())
+					return
+				}
+				abortInternal(c, "failed to verify API key")
+				return
+			}
+
+			scope := models.APIKeyScopeWrite
+			if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+				scope = models.APIKeyScopeRead
+			}
+			if !key.HasScope(scope) {
+				c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+					Error: "API key lacks the " + scope + " scope",
+					Code:  "insufficient_scope",
+				})
+				return
+			}
+
+			if key.UserID != nil {
+				u, err := users.GetActiveUser(c.Request.Context(), key.CompanyID, *key.UserID)
+				if err != nil {
+					if errors.Is(err, services.ErrUserNotFound) {
+						abortUnauthorized(c, "the user this API key belongs to is inactive")
+						return
+					}
+					abortInternal(c, "failed to verify API key")
+					return
+				}
+				user = u
+			}
+
+			if err := keys.TouchLastUsed(c.Request.Context(), key.ID, c.ClientIP()); err != nil {
+				log.Printf("[Auth] Failed to record use of API key %s: %v", key.ID, err)
+			}
+
+			companyID = key.CompanyID
+			c.Set("api_key_id", key.ID)
+			c.Set("api_key", key)
+		}
+
+		if header := c.GetHeader("X-Company-ID"); header != "" && !strings.EqualFold(header, companyID) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error: "X-Company-ID does not match the authenticated company",
+				Code:  "company_mismatch",
+			})
+			return
+		}
+
+		if user != nil {
+			role = user.Role
+			c.Set("user_id", user.ID)
+			c.Set("user", user)
+		}
+		c.Set("company_id", companyID)
+		c.Set("role", role)
+		c.Next()
+	}
+}
+
+// RequirePermission rejects requests whose role lacks the permission. Must run
+// after Authenticate.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if !models.RoleHasPermission(role, permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error: "role " + role + " lacks the " + permission + " permission",
+				Code:  "forbidden",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope rejects API-key requests whose key lacks the scope. Session
+// requests are governed by their role alone. Must run after Authenticate.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if v, exists := c.Get("api_key"); exists {
+			if key, ok := v.(*models.APIKey); !ok || !key.HasScope(scope) {
+				c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+					Error: "API key lacks the " + scope + " scope",
+					Code:  "insufficient_scope",
+				})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireOwnCompany rejects routes that name a company (e.g. /companies/:id)
+// other than the authenticated one
+func RequireOwnCompany(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.EqualFold(c.Param(param), c.GetString("company_id")) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error: "credentials do not belong to this company",
+				Code:  "company_mismatch",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// AdminToken protects platform provisioning (creating and listing companies,
+// issuing a company's first keys and users) with the operator token from
+// configuration. With no token configured these routes are disabled.
+func AdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error: "provisioning is disabled: set CUENTAS_ADMIN_TOKEN to enable it",
+				Code:  "provisioning_disabled",
+			})
+			return
+		}
+
+		presented := strings.TrimSpace(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			abortUnauthorized(c, "invalid admin token")
+			return
+		}
+		c.Next()
+	}
+}
+
+func presentedToken(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+	auth := c.GetHeader("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+		Error: message,
+		Code:  "unauthorized",
+	})
+}
+
+func abortInternal(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error: message,
+		Code:  "internal_error",
+	})
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"cuentas/internal/models"
+	"cuentas/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxIdempotencyKeyLen = 255
+
+// Idempotency makes a create or finalize endpoint safe to retry. When the
+// request carries an Idempotency-Key header:
+//   - a request with the same key already completed gets its stored response
+//     back, with Idempotent-Replayed: true, and the handler does not run
+//   - a request racing one with the same key waits for it (Redis lock), then
+//     gets the stored response
+//   - a key reused for a different request is rejected with 422
+//
+// Server errors (5xx) are not stored, so the client can retry them. Keys are
+// scoped to the authenticated company; must run after Authenticate.
+func Idempotency(svc *services.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(models.IdempotencyKeyHeader))
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "Idempotency-Key must be at most 255 characters",
+				Code:  "invalid_idempotency_key",
+			})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "failed to read request body",
+				Code:  "invalid_request",
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		companyID := c.GetString("company_id")
+		method, path := c.Request.Method, c.Request.URL.Path
+		requestHash := services.HashRequest(method, path, body)
+
+		unlock, err := svc.Lock(c.Request.Context(), companyID, key)
+		if err != nil {
+			if errors.Is(err, services.ErrIdempotencyKeyInProgress) {
+				c.Header("Retry-After", "5")
+				c.AbortWithStatusJSON(http.StatusConflict, models.ErrorResponse{
+					Error: err.Error(),
+					Code:  "idempotency_key_in_progress",
+				})
+				return
+			}
+			abortInternal(c, "failed to lock Idempotency-Key")
+			return
+		}
+		defer unlock()
+
+		record, err := svc.GetRecord(c.Request.Context(), companyID, key)
+		if err != nil {
+			abortInternal(c, "failed to look up Idempotency-Key")
+			return
+		}
+		if record != nil {
+			if !record.Matches(method, path, requestHash) {
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, models.ErrorResponse{
+					Error: "Idempotency-Key was already used for a different request",
+					Code:  "idempotency_key_reused",
+				})
+				return
+			}
+
+			contentType := "application/json; charset=utf-8"
+			if record.ContentType != nil {
+				contentType = *record.ContentType
+			}
+			c.Header(models.IdempotentReplayedHeader, "true")
+			c.Data(record.StatusCode, contentType, record.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		var contentType *string
+		if ct := recorder.Header().Get("Content-Type"); ct != "" {
+			contentType = &ct
+		}
+		// Store even if the client has gone away: that client is the one who retries.
+		// The response has already been sent, so a failure here only costs replayability.
+		if err := svc.SaveRecord(context.WithoutCancel(c.Request.Context()), &models.IdempotencyRecord{
+			CompanyID:    companyID,
+			Key:          key,
+			Method:       method,
+			Path:         path,
+			RequestHash:  requestHash,
+			StatusCode:   status,
+			ContentType:  contentType,
+			ResponseBody: recorder.body.Bytes(),
+		}); err != nil {
+			log.Printf("[Idempotency] Failed to store response for key %q: %v", key, err)
+		}
+	}
+}
+
+// responseRecorder copies the response body as the handler writes it
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
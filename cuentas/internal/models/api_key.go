@@ -0,0 +1,98 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// API key scopes. Admin implies the other two.
+const (
+	APIKeyScopeRead  = "read"  // GET and HEAD requests
+	APIKeyScopeWrite = "write" // Every other method
+	APIKeyScopeAdmin = "admin" // API key and webhook management
+)
+
+// APIKeyScopes lists the scopes a key can be given
+var APIKeyScopes = []string{APIKeyScopeRead, APIKeyScopeWrite, APIKeyScopeAdmin}
+
+// APIKey is a company credential. The key itself is only known at creation;
+// the database keeps its SHA-256.
+type APIKey struct {
+	ID             string     `json:"id"`
+	CompanyID      string     `json:"company_id"`
+	UserID         *string    `json:"user_id,omitempty"` // Requests act as this user when set
+	Name           string     `json:"name"`
+	Prefix         string     `json:"prefix"`
+	Scopes         []string   `json:"scopes"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP     *string    `json:"last_used_ip,omitempty"`
+	CreatedByKeyID *string    `json:"created_by_key_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// HasScope reports whether the key grants the scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == APIKeyScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive reports whether the key can still authenticate
+func (k *APIKey) IsActive(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// CreatedAPIKey is returned once, when the key is issued
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKeyRequest issues a new key for the authenticated company
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes"` // Defaults to read and write
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Validate checks the name, scopes and expiry
+func (r *CreateAPIKeyRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(r.Name) > 100 {
+		return fmt.Errorf("name must be at most 100 characters")
+	}
+
+	if len(r.Scopes) == 0 {
+		r.Scopes = []string{APIKeyScopeRead, APIKeyScopeWrite}
+	}
+	for _, scope := range r.Scopes {
+		known := false
+		for _, s := range APIKeyScopes {
+			if scope == s {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown scope %q (valid: read, write, admin)", scope)
+		}
+	}
+
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("expires_at must be in the future")
+	}
+	return nil
+}
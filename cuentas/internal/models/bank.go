@@ -0,0 +1,330 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Bank statement formats
+const (
+	BankFormatCSV = "csv"
+	BankFormatOFX = "ofx"
+)
+
+// Bank statement line statuses
+const (
+	BankLineUnmatched = "unmatched"
+	BankLineMatched   = "matched"
+	BankLineIgnored   = "ignored" // Bank fees, interest and other lines without a payment
+)
+
+// How a statement line was matched
+const (
+	BankMatchAuto   = "auto"
+	BankMatchManual = "manual"
+)
+
+// Book items a statement line is matched with
+const (
+	BankItemPayment         = "payment"          // Client payment, matched with deposits
+	BankItemSupplierPayment = "supplier_payment" // Supplier payment, matched with withdrawals
+)
+
+// DefaultBankMatchToleranceDays is how far apart, in days, a payment and a
+// statement line may be dated and still be matched automatically
+const DefaultBankMatchToleranceDays = 3
+
+// bankDateFormats maps the accepted CSV date formats to Go layouts
+var bankDateFormats = map[string]string{
+	"DD/MM/YYYY": "02/01/2006",
+	"MM/DD/YYYY": "01/02/2006",
+	"YYYY-MM-DD": "2006-01-02",
+	"DD-MM-YYYY": "02-01-2006",
+	"YYYYMMDD":   "20060102",
+}
+
+// BankAccount is a company bank account statements are imported into
+type BankAccount struct {
+	ID            string         `json:"id"`
+	CompanyID     string         `json:"company_id"`
+	Name          string         `json:"name"`
+	BankName      string         `json:"bank_name"`
+	AccountNumber string         `json:"account_number"`
+	Currency      string         `json:"currency"`
+	CSVLayout     *BankCSVLayout `json:"csv_layout,omitempty"`
+	Active        bool           `json:"active"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// BankCSVLayout describes a bank's CSV statement. Columns are zero-based.
+// Amounts come from one signed column, or from a debit (withdrawals) and a
+// credit (deposits) column.
+type BankCSVLayout struct {
+	Delimiter         string `json:"delimiter,omitempty"` // Defaults to ","
+	HeaderRows        int    `json:"header_rows"`         // Rows skipped at the top
+	DateColumn        int    `json:"date_column"`
+	DateFormat        string `json:"date_format,omitempty"` // DD/MM/YYYY (default), MM/DD/YYYY, YYYY-MM-DD, DD-MM-YYYY or YYYYMMDD
+	DescriptionColumn int    `json:"description_column"`
+	ReferenceColumn   *int   `json:"reference_column,omitempty"`
+	AmountColumn      *int   `json:"amount_column,omitempty"`
+	DebitColumn       *int   `json:"debit_column,omitempty"`
+	CreditColumn      *int   `json:"credit_column,omitempty"`
+	DecimalComma      bool   `json:"decimal_comma"` // 1.234,56 instead of 1,234.56
+}
+
+// Validate checks the columns and formats and fills in the defaults
+func (l *BankCSVLayout) Validate() error {
+	if l.Delimiter == "" {
+		l.Delimiter = ","
+	}
+	if len([]rune(l.Delimiter)) != 1 {
+		return fmt.Errorf("delimiter must be a single character")
+	}
+	if l.DateFormat == "" {
+		l.DateFormat = "DD/MM/YYYY"
+	}
+	if _, ok := bankDateFormats[l.DateFormat]; !ok {
+		return fmt.Errorf("date_format must be one of: DD/MM/YYYY, MM/DD/YYYY, YYYY-MM-DD, DD-MM-YYYY, YYYYMMDD")
+	}
+	if l.HeaderRows < 0 {
+		return fmt.Errorf("header_rows cannot be negative")
+	}
+
+	if l.AmountColumn != nil {
+		if l.DebitColumn != nil || l.CreditColumn != nil {
+			return fmt.Errorf("use amount_column or debit_column and credit_column, not both")
+		}
+	} else if l.DebitColumn == nil || l.CreditColumn == nil {
+		return fmt.Errorf("amount_column, or debit_column and credit_column, are required")
+	}
+
+	for _, column := range []*int{&l.DateColumn, &l.DescriptionColumn, l.ReferenceColumn, l.AmountColumn, l.DebitColumn, l.CreditColumn} {
+		if column != nil && *column < 0 {
+			return fmt.Errorf("columns cannot be negative")
+		}
+	}
+	return nil
+}
+
+// DateLayout returns the Go layout of the date format
+func (l *BankCSVLayout) DateLayout() string {
+	return bankDateFormats[l.DateFormat]
+}
+
+// CreateBankAccountRequest registers a bank account
+type CreateBankAccountRequest struct {
+	Name          string         `json:"name" binding:"required"`
+	BankName      string         `json:"bank_name" binding:"required"`
+	AccountNumber string         `json:"account_number" binding:"required"`
+	Currency      string         `json:"currency"` // Defaults to USD
+	CSVLayout     *BankCSVLayout `json:"csv_layout"`
+}
+
+// Validate checks the account fields and CSV layout
+func (r *CreateBankAccountRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.BankName = strings.TrimSpace(r.BankName)
+	r.AccountNumber = strings.TrimSpace(r.AccountNumber)
+	r.Currency = strings.ToUpper(strings.TrimSpace(r.Currency))
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if r.BankName == "" {
+		return fmt.Errorf("bank_name is required")
+	}
+	if r.AccountNumber == "" || len(r.AccountNumber) > 40 {
+		return fmt.Errorf("account_number is required and must be at most 40 characters")
+	}
+	if r.Currency == "" {
+		r.Currency = "USD"
+	}
+	if len(r.Currency) != 3 {
+		return fmt.Errorf("currency must be a 3 letter ISO code")
+	}
+	if r.CSVLayout != nil {
+		if err := r.CSVLayout.Validate(); err != nil {
+			return fmt.Errorf("csv_layout: %w", err)
+		}
+	}
+	return nil
+}
+
+// UpdateBankAccountRequest changes a bank account. The account number and
+// currency are fixed once created. Omitted fields are left as they are.
+type UpdateBankAccountRequest struct {
+	Name      *string        `json:"name"`
+	BankName  *string        `json:"bank_name"`
+	CSVLayout *BankCSVLayout `json:"csv_layout"`
+	Active    *bool          `json:"active"`
+}
+
+// Apply validates the changes against the current account and applies them
+func (r *UpdateBankAccountRequest) Apply(a *BankAccount) error {
+	if r.Name != nil {
+		name := strings.TrimSpace(*r.Name)
+		if name == "" {
+			return fmt.Errorf("name cannot be empty")
+		}
+		a.Name = name
+	}
+	if r.BankName != nil {
+		bankName := strings.TrimSpace(*r.BankName)
+		if bankName == "" {
+			return fmt.Errorf("bank_name cannot be empty")
+		}
+		a.BankName = bankName
+	}
+	if r.CSVLayout != nil {
+		if err := r.CSVLayout.Validate(); err != nil {
+			return fmt.Errorf("csv_layout: %w", err)
+		}
+		a.CSVLayout = r.CSVLayout
+	}
+	if r.Active != nil {
+		a.Active = *r.Active
+	}
+	return nil
+}
+
+// BankStatement is an imported statement file. Lines already imported by an
+// earlier, overlapping statement are skipped.
+type BankStatement struct {
+	ID             string    `json:"id"`
+	CompanyID      string    `json:"company_id"`
+	BankAccountID  string    `json:"bank_account_id"`
+	Format         string    `json:"format"`
+	Filename       *string   `json:"filename,omitempty"`
+	FromDate       string    `json:"from_date"`
+	ToDate         string    `json:"to_date"`
+	ClosingBalance *float64  `json:"closing_balance,omitempty"`
+	LineCount      int       `json:"line_count"`
+	SkippedCount   int       `json:"skipped_count"`
+	AutoMatched    int       `json:"auto_matched"` // Lines matched right after the import
+	ImportedBy     *string   `json:"imported_by,omitempty"`
+	ImportedAt     time.Time `json:"imported_at"`
+}
+
+// BankStatementLine is a transaction of a bank statement. Amount is positive
+// for deposits and negative for withdrawals.
+type BankStatementLine struct {
+	ID              string      `json:"id"`
+	BankAccountID   string      `json:"bank_account_id"`
+	StatementID     string      `json:"statement_id"`
+	TransactionDate string      `json:"transaction_date"`
+	Amount          float64     `json:"amount"`
+	Description     string      `json:"description"`
+	Reference       *string     `json:"reference,omitempty"`
+	TransactionID   string      `json:"transaction_id"`
+	Status          string      `json:"status"`
+	MatchMethod     *string     `json:"match_method,omitempty"`
+	Note            *string     `json:"note,omitempty"`
+	MatchedBy       *string     `json:"matched_by,omitempty"`
+	MatchedAt       *time.Time  `json:"matched_at,omitempty"`
+	Matches         []BankMatch `json:"matches"`
+}
+
+// BankMatch is a payment a statement line is reconciled with
+type BankMatch struct {
+	ID string `json:"id"`
+	BankBookItem
+}
+
+// BankBookItem is a payment recorded in the books, as matched against the
+// bank: client payments are receipts (positive), supplier payments are
+// disbursements (negative)
+type BankBookItem struct {
+	Kind          string  `json:"kind"` // payment or supplier_payment
+	PaymentID     string  `json:"payment_id"`
+	Date          string  `json:"date"`
+	Amount        float64 `json:"amount"`
+	PaymentMethod string  `json:"payment_method"`
+	Reference     *string `json:"reference,omitempty"`
+	Counterparty  string  `json:"counterparty"`
+	Document      string  `json:"document"` // Invoice number or purchase document number
+}
+
+// BankLineFilters narrows GET /v1/bank/accounts/:id/lines
+type BankLineFilters struct {
+	FromDate    string // YYYY-MM-DD, inclusive
+	ToDate      string // YYYY-MM-DD, inclusive
+	Status      string
+	StatementID string
+	Limit       int
+	Offset      int
+}
+
+// MatchBankLineRequest reconciles a statement line with payments by hand.
+// Deposits take client payments and withdrawals supplier payments; their
+// amounts must add up to the line amount.
+type MatchBankLineRequest struct {
+	PaymentIDs         []string `json:"payment_ids"`
+	SupplierPaymentIDs []string `json:"supplier_payment_ids"`
+}
+
+// Validate checks that there is something to match
+func (r *MatchBankLineRequest) Validate() error {
+	if len(r.PaymentIDs) == 0 && len(r.SupplierPaymentIDs) == 0 {
+		return fmt.Errorf("payment_ids or supplier_payment_ids is required")
+	}
+	return nil
+}
+
+// IgnoreBankLineRequest marks a statement line that no payment will match
+type IgnoreBankLineRequest struct {
+	Note string `json:"note" binding:"required"`
+}
+
+// Validate checks the note
+func (r *IgnoreBankLineRequest) Validate() error {
+	r.Note = strings.TrimSpace(r.Note)
+	if r.Note == "" {
+		return fmt.Errorf("note is required")
+	}
+	if len(r.Note) > 500 {
+		return fmt.Errorf("note must be at most 500 characters")
+	}
+	return nil
+}
+
+// BankAutoMatchResult counts the lines an automatic matching pass reconciled
+type BankAutoMatchResult struct {
+	Matched   int `json:"matched"`
+	Unmatched int `json:"unmatched"` // Lines still unmatched
+}
+
+// BankReconciliationSummary totals the reconciliation report. Deposits and
+// receipts are positive; withdrawals and disbursements negative.
+type BankReconciliationSummary struct {
+	StatementLines         int     `json:"statement_lines"`
+	MatchedLines           int     `json:"matched_lines"`
+	IgnoredLines           int     `json:"ignored_lines"`
+	UnmatchedLines         int     `json:"unmatched_lines"`
+	Deposits               float64 `json:"deposits"`
+	Withdrawals            float64 `json:"withdrawals"`
+	UnmatchedDeposits      float64 `json:"unmatched_deposits"`
+	UnmatchedWithdrawals   float64 `json:"unmatched_withdrawals"`
+	UnmatchedReceipts      float64 `json:"unmatched_receipts"`
+	UnmatchedDisbursements float64 `json:"unmatched_disbursements"`
+}
+
+// BankReconciliation lists what is unmatched on both sides for a bank account
+// and period: statement lines without a payment, and payments not found on
+// the statement. Payments carry no bank account, so the book side lists every
+// unmatched non-cash payment of the company.
+type BankReconciliation struct {
+	CompanyID          string                    `json:"company_id"`
+	CompanyName        string                    `json:"company_name"`
+	NIT                string                    `json:"nit"`
+	NRC                string                    `json:"nrc"`
+	BankAccount        BankAccount               `json:"bank_account"`
+	FromDate           string                    `json:"from_date"`
+	ToDate             string                    `json:"to_date"`
+	StatementBalance   *float64                  `json:"statement_balance,omitempty"` // Closing balance of the last statement in the period that states one
+	StatementBalanceOn string                    `json:"statement_balance_date,omitempty"`
+	Summary            BankReconciliationSummary `json:"summary"`
+	UnmatchedLines     []BankStatementLine       `json:"unmatched_lines"`
+	UnmatchedItems     []BankBookItem            `json:"unmatched_items"`
+	GeneratedAt        time.Time                 `json:"generated_at"`
+}
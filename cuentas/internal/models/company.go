@@ -12,8 +12,8 @@ import (
 type Company struct {
 	ID                   string    `json:"id"`
 	Name                 string    `json:"name"`
-	CodActividad         string    `json:"cod_actividad" binding:"required"`     // NEW
-	NombreComercial      *string   `json:"nombre_comercial" binding: "required"` // NEW: Optional
+	CodActividad         string    `json:"cod_actividad" binding:"required"` // NEW
+	NombreComercial      *string   `json:"nombre_comercial"`                 // NEW: Optional
 	DTEAmbiente          string    `json:"dte_ambiente" binding:"required"`
 	NIT                  string    `json:"nit"` // Store as int, don't expose directly
 	NCR                  int64     `json:"-"`   // Store as int, don't expose directly
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DCL represents a DTE 09 (Documento Contable de Liquidación) issued by the company,
+// acting as card-payment processor or collection agent, to an affiliate
+type DCL struct {
+	ID                          string `json:"id"`
+	CompanyID                   string `json:"company_id"`
+	EstablishmentID             string `json:"establishment_id"`
+	PointOfSaleID               string `json:"point_of_sale_id"`
+	ClientID                    string `json:"client_id"` // Affiliate (receptor)
+	ReceptorTipoEstablecimiento string `json:"receptor_tipo_establecimiento"`
+
+	// DTE identifiers
+	CodigoGeneracion string `json:"codigo_generacion"`
+	NumeroControl    string `json:"numero_control"`
+	TipoDte          string `json:"tipo_dte"` // Always "09"
+	Ambiente         string `json:"ambiente"`
+
+	// Liquidation period
+	PeriodoInicio  time.Time `json:"periodo_inicio"`
+	PeriodoFin     time.Time `json:"periodo_fin"`
+	CodLiquidacion *string   `json:"cod_liquidacion,omitempty"`
+	CantidadDoc    *int      `json:"cantidad_doc,omitempty"`
+
+	// Amounts
+	ValorOperaciones      float64 `json:"valor_operaciones"` // Gross value of the settled operations (IVA included)
+	MontoSinPercepcion    float64 `json:"monto_sin_percepcion"`
+	DescripSinPercepcion  *string `json:"descrip_sin_percepcion,omitempty"`
+	SubTotal              float64 `json:"sub_total"`
+	IVA                   float64 `json:"iva"`
+	MontoSujetoPercepcion float64 `json:"monto_sujeto_percepcion"`
+	IVAPercibido          float64 `json:"iva_percibido"` // 2% percepción
+	CommissionRate        float64 `json:"commission_rate"`
+	Comision              float64 `json:"comision"`
+	IVAComision           float64 `json:"iva_comision"`
+	LiquidoAPagar         float64 `json:"liquido_a_pagar"` // Net amount paid to the affiliate
+	Observaciones         *string `json:"observaciones,omitempty"`
+
+	// Extension
+	NombEntrega string  `json:"nomb_entrega"`
+	DocuEntrega string  `json:"docu_entrega"`
+	CodEmpleado *string `json:"cod_empleado,omitempty"`
+
+	// Dates
+	FechaEmision       time.Time  `json:"fecha_emision"`
+	FechaProcesamiento *time.Time `json:"fecha_procesamiento,omitempty"`
+
+	// DTE data
+	DteJSON   string `json:"dte_json"`
+	DteSigned string `json:"dte_signed"`
+
+	// Hacienda response
+	HaciendaEstado          *string    `json:"hacienda_estado,omitempty"`
+	HaciendaSelloRecibido   *string    `json:"hacienda_sello_recibido,omitempty"`
+	HaciendaFhProcesamiento *time.Time `json:"hacienda_fh_procesamiento,omitempty"`
+	HaciendaCodigoMsg       *string    `json:"hacienda_codigo_msg,omitempty"`
+	HaciendaDescripcionMsg  *string    `json:"hacienda_descripcion_msg,omitempty"`
+	HaciendaObservaciones   []string   `json:"hacienda_observaciones,omitempty"`
+	HaciendaResponse        *string    `json:"hacienda_response,omitempty"`
+
+	// Contingency
+	ContingencyPeriodID   *string `json:"contingency_period_id,omitempty"`
+	DteTransmissionStatus string  `json:"dte_transmission_status"`
+
+	// Audit
+	CreatedBy   *string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	SubmittedAt *time.Time `json:"submitted_at,omitempty"`
+}
+
+// CreateDCLRequest represents the request to create a DCL (DTE 09)
+type CreateDCLRequest struct {
+	ClientID                    string  `json:"client_id" binding:"required"`
+	EstablishmentID             string  `json:"establishment_id" binding:"required"`
+	PointOfSaleID               string  `json:"point_of_sale_id" binding:"required"`
+	ReceptorTipoEstablecimiento string  `json:"receptor_tipo_establecimiento,omitempty"` // CAT-009, defaults to "02" (Casa Matriz)
+	PeriodoInicio               string  `json:"periodo_inicio" binding:"required"`       // YYYY-MM-DD
+	PeriodoFin                  string  `json:"periodo_fin" binding:"required"`          // YYYY-MM-DD
+	CodLiquidacion              *string `json:"cod_liquidacion,omitempty"`
+	CantidadDoc                 *int    `json:"cantidad_doc,omitempty"`
+	ValorOperaciones            float64 `json:"valor_operaciones" binding:"required"`
+	MontoSinPercepcion          float64 `json:"monto_sin_percepcion"`
+	DescripSinPercepcion        *string `json:"descrip_sin_percepcion,omitempty"`
+	CommissionRate              float64 `json:"commission_rate"` // Percentage, e.g. 3.50
+	Observaciones               *string `json:"observaciones,omitempty"`
+	NombEntrega                 string  `json:"nomb_entrega" binding:"required"`
+	DocuEntrega                 string  `json:"docu_entrega" binding:"required"`
+	CodEmpleado                 *string `json:"cod_empleado,omitempty"`
+}
+
+// Validate validates the create DCL request
+func (r *CreateDCLRequest) Validate() error {
+	if strings.TrimSpace(r.ClientID) == "" {
+		return fmt.Errorf("client_id is required")
+	}
+	if strings.TrimSpace(r.EstablishmentID) == "" {
+		return fmt.Errorf("establishment_id is required")
+	}
+	if strings.TrimSpace(r.PointOfSaleID) == "" {
+		return fmt.Errorf("point_of_sale_id is required")
+	}
+
+	inicio, err := time.Parse("2006-01-02", r.PeriodoInicio)
+	if err != nil {
+		return fmt.Errorf("periodo_inicio must be YYYY-MM-DD")
+	}
+	fin, err := time.Parse("2006-01-02", r.PeriodoFin)
+	if err != nil {
+		return fmt.Errorf("periodo_fin must be YYYY-MM-DD")
+	}
+	if fin.Before(inicio) {
+		return fmt.Errorf("periodo_fin cannot be before periodo_inicio")
+	}
+
+	if r.ValorOperaciones <= 0 {
+		return fmt.Errorf("valor_operaciones must be greater than 0")
+	}
+	if r.MontoSinPercepcion < 0 || r.MontoSinPercepcion >= r.ValorOperaciones {
+		return fmt.Errorf("monto_sin_percepcion must be between 0 and valor_operaciones")
+	}
+	if r.CommissionRate < 0 || r.CommissionRate > 100 {
+		return fmt.Errorf("commission_rate must be between 0 and 100")
+	}
+	if r.CantidadDoc != nil && *r.CantidadDoc <= 0 {
+		return fmt.Errorf("cantidad_doc must be greater than 0")
+	}
+	if r.CodLiquidacion != nil && len(*r.CodLiquidacion) > 30 {
+		return fmt.Errorf("cod_liquidacion cannot exceed 30 characters")
+	}
+	if r.Observaciones != nil && len(*r.Observaciones) > 200 {
+		return fmt.Errorf("observaciones cannot exceed 200 characters")
+	}
+
+	if l := len(strings.TrimSpace(r.NombEntrega)); l < 5 || l > 100 {
+		return fmt.Errorf("nomb_entrega must be between 5 and 100 characters")
+	}
+	if l := len(strings.TrimSpace(r.DocuEntrega)); l < 5 || l > 25 {
+		return fmt.Errorf("docu_entrega must be between 5 and 25 characters")
+	}
+
+	if r.ReceptorTipoEstablecimiento == "" {
+		r.ReceptorTipoEstablecimiento = "02"
+	}
+	switch r.ReceptorTipoEstablecimiento {
+	case "01", "02", "04", "07", "20":
+	default:
+		return fmt.Errorf("receptor_tipo_establecimiento must be one of 01, 02, 04, 07, 20")
+	}
+
+	return nil
+}
+
+// Helper methods
+
+// IsProcessed checks if the DCL was successfully processed by Hacienda
+func (d *DCL) IsProcessed() bool {
+	return d.HaciendaEstado != nil && *d.HaciendaEstado == "PROCESADO"
+}
+
+// IsRejected checks if the DCL was rejected by Hacienda
+func (d *DCL) IsRejected() bool {
+	return d.HaciendaEstado != nil && *d.HaciendaEstado == "RECHAZADO"
+}
+
+// IsQueuedForContingency checks if the DCL is waiting in a contingency period
+func (d *DCL) IsQueuedForContingency() bool {
+	return d.ContingencyPeriodID != nil && !d.IsProcessed()
+}
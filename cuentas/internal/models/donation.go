@@ -0,0 +1,346 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"cuentas/internal/codigos"
+)
+
+// Donation valuation methods. Cash is always valued at its nominal amount;
+// goods and services must state how their value was determined.
+const (
+	DonationValuationNominal         = "nominal"
+	DonationValuationMarketValue     = "market_value"
+	DonationValuationAcquisitionCost = "acquisition_cost"
+	DonationValuationBookValue       = "book_value"
+	DonationValuationAppraisal       = "appraisal"
+)
+
+// donationValuationMethods lists the valuation methods allowed per CAT-026 donation type
+var donationValuationMethods = map[int][]string{
+	1: {DonationValuationNominal},
+	2: {DonationValuationMarketValue, DonationValuationAcquisitionCost, DonationValuationBookValue, DonationValuationAppraisal},
+	3: {DonationValuationMarketValue, DonationValuationAppraisal},
+}
+
+// DonationValuationLabels holds the Spanish labels printed on the DTE for each valuation method
+var DonationValuationLabels = map[string]string{
+	DonationValuationNominal:         "Valor nominal",
+	DonationValuationMarketValue:     "Valor de mercado",
+	DonationValuationAcquisitionCost: "Costo de adquisición",
+	DonationValuationBookValue:       "Valor en libros",
+	DonationValuationAppraisal:       "Avalúo",
+}
+
+// Donation represents a DTE 15 (Comprobante de Donación) issued by the company,
+// as a qualified non-profit (donatario), to a donor
+type Donation struct {
+	ID              string `json:"id"`
+	CompanyID       string `json:"company_id"`
+	EstablishmentID string `json:"establishment_id"`
+	PointOfSaleID   string `json:"point_of_sale_id"`
+	ClientID        string `json:"client_id"`       // Donor (donante)
+	CodDomiciliado  int    `json:"cod_domiciliado"` // 1 = domiciled, 2 = not domiciled
+	CodPais         string `json:"cod_pais"`        // CAT-020
+
+	// DTE identifiers
+	CodigoGeneracion string `json:"codigo_generacion"`
+	NumeroControl    string `json:"numero_control"`
+	TipoDte          string `json:"tipo_dte"` // Always "15"
+	Ambiente         string `json:"ambiente"`
+
+	// Totals
+	ValorTotal     float64 `json:"valor_total"`
+	TotalEfectivo  float64 `json:"total_efectivo"`
+	TotalBienes    float64 `json:"total_bienes"`
+	TotalServicios float64 `json:"total_servicios"`
+
+	// Cash payment
+	FormaPago      *string `json:"forma_pago,omitempty"` // CAT-017
+	ReferenciaPago *string `json:"referencia_pago,omitempty"`
+
+	OtrosDocumentos []DonationAssociatedDocument `json:"otros_documentos"`
+	Items           []DonationItem               `json:"items"`
+
+	// Dates
+	FechaEmision       time.Time  `json:"fecha_emision"`
+	FechaProcesamiento *time.Time `json:"fecha_procesamiento,omitempty"`
+
+	// DTE data
+	DteJSON   string `json:"dte_json"`
+	DteSigned string `json:"dte_signed"`
+
+	// Hacienda response
+	HaciendaEstado          *string    `json:"hacienda_estado,omitempty"`
+	HaciendaSelloRecibido   *string    `json:"hacienda_sello_recibido,omitempty"`
+	HaciendaFhProcesamiento *time.Time `json:"hacienda_fh_procesamiento,omitempty"`
+	HaciendaCodigoMsg       *string    `json:"hacienda_codigo_msg,omitempty"`
+	HaciendaDescripcionMsg  *string    `json:"hacienda_descripcion_msg,omitempty"`
+	HaciendaObservaciones   []string   `json:"hacienda_observaciones,omitempty"`
+	HaciendaResponse        *string    `json:"hacienda_response,omitempty"`
+
+	// Contingency
+	ContingencyPeriodID   *string `json:"contingency_period_id,omitempty"`
+	DteTransmissionStatus string  `json:"dte_transmission_status"`
+
+	// Audit
+	CreatedBy   *string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	SubmittedAt *time.Time `json:"submitted_at,omitempty"`
+}
+
+// DonationItem represents one donated line (cash, good or service)
+type DonationItem struct {
+	ID              string  `json:"id"`
+	DonationID      string  `json:"donation_id"`
+	LineNumber      int     `json:"line_number"`
+	TipoDonacion    int     `json:"tipo_donacion"` // CAT-026: 1 efectivo, 2 bien, 3 servicio
+	ValuationMethod string  `json:"valuation_method"`
+	Codigo          *string `json:"codigo,omitempty"`
+	Descripcion     string  `json:"descripcion"`
+	UniMedida       int     `json:"uni_medida"` // CAT-014
+	Cantidad        float64 `json:"cantidad"`
+	ValorUni        float64 `json:"valor_uni"`
+	Depreciacion    float64 `json:"depreciacion"`
+	Valor           float64 `json:"valor"` // cantidad × valor_uni − depreciacion
+}
+
+// DonationAssociatedDocument is an otrosDocumentos entry (e.g. the authorization
+// qualifying the donatario to receive deductible donations)
+type DonationAssociatedDocument struct {
+	CodDocAsociado   int    `json:"cod_doc_asociado"` // 1 = emisor, 2 = receptor
+	DescDocumento    string `json:"desc_documento"`
+	DetalleDocumento string `json:"detalle_documento"`
+}
+
+// CreateDonationRequest represents the request to create a Comprobante de Donación
+type CreateDonationRequest struct {
+	ClientID        string                       `json:"client_id" binding:"required"`
+	EstablishmentID string                       `json:"establishment_id" binding:"required"`
+	PointOfSaleID   string                       `json:"point_of_sale_id" binding:"required"`
+	CodDomiciliado  int                          `json:"cod_domiciliado,omitempty"` // Defaults to 1 (domiciled)
+	CodPais         string                       `json:"cod_pais,omitempty"`        // Defaults to "9300" (El Salvador)
+	FormaPago       *string                      `json:"forma_pago,omitempty"`      // Required when there are cash lines, defaults to "01"
+	ReferenciaPago  *string                      `json:"referencia_pago,omitempty"`
+	OtrosDocumentos []DonationAssociatedDocument `json:"otros_documentos" binding:"required"`
+	Items           []CreateDonationItemRequest  `json:"items" binding:"required"`
+}
+
+// CreateDonationItemRequest represents one donated line in the create request
+type CreateDonationItemRequest struct {
+	TipoDonacion    int     `json:"tipo_donacion" binding:"required"`
+	ValuationMethod string  `json:"valuation_method,omitempty"` // Defaults to nominal (cash) or market_value
+	Codigo          *string `json:"codigo,omitempty"`
+	Descripcion     string  `json:"descripcion" binding:"required"`
+	UniMedida       int     `json:"uni_medida,omitempty"` // Goods only; cash and services always use 99
+	Cantidad        float64 `json:"cantidad" binding:"required"`
+	ValorUni        float64 `json:"valor_uni"`
+	Depreciacion    float64 `json:"depreciacion"` // Goods only
+}
+
+// Validate validates the create donation request and applies defaults
+func (r *CreateDonationRequest) Validate() error {
+	if strings.TrimSpace(r.ClientID) == "" {
+		return fmt.Errorf("client_id is required")
+	}
+	if strings.TrimSpace(r.EstablishmentID) == "" {
+		return fmt.Errorf("establishment_id is required")
+	}
+	if strings.TrimSpace(r.PointOfSaleID) == "" {
+		return fmt.Errorf("point_of_sale_id is required")
+	}
+
+	if r.CodDomiciliado == 0 {
+		r.CodDomiciliado = 1
+	}
+	if r.CodDomiciliado != 1 && r.CodDomiciliado != 2 {
+		return fmt.Errorf("cod_domiciliado must be 1 or 2")
+	}
+	if r.CodPais == "" {
+		r.CodPais = "9300"
+	}
+	if len(r.CodPais) != 4 || strings.Trim(r.CodPais, "0123456789") != "" {
+		return fmt.Errorf("cod_pais must be a 4-digit CAT-020 code")
+	}
+
+	if len(r.OtrosDocumentos) == 0 || len(r.OtrosDocumentos) > 10 {
+		return fmt.Errorf("otros_documentos must have between 1 and 10 entries")
+	}
+	for i, doc := range r.OtrosDocumentos {
+		if doc.CodDocAsociado != 1 && doc.CodDocAsociado != 2 {
+			return fmt.Errorf("otros_documentos[%d]: cod_doc_asociado must be 1 or 2", i)
+		}
+		if strings.TrimSpace(doc.DescDocumento) == "" || len(doc.DescDocumento) > 100 {
+			return fmt.Errorf("otros_documentos[%d]: desc_documento must be between 1 and 100 characters", i)
+		}
+		if strings.TrimSpace(doc.DetalleDocumento) == "" || len(doc.DetalleDocumento) > 300 {
+			return fmt.Errorf("otros_documentos[%d]: detalle_documento must be between 1 and 300 characters", i)
+		}
+	}
+
+	if len(r.Items) == 0 {
+		return fmt.Errorf("at least one item is required")
+	}
+	if len(r.Items) > 2000 {
+		return fmt.Errorf("a donation cannot have more than 2000 items")
+	}
+
+	hasCash := false
+	for i := range r.Items {
+		if err := r.Items[i].validate(); err != nil {
+			return fmt.Errorf("items[%d]: %w", i, err)
+		}
+		if r.Items[i].TipoDonacion == 1 {
+			hasCash = true
+		}
+	}
+
+	if hasCash {
+		if r.FormaPago == nil || *r.FormaPago == "" {
+			formaPago := codigos.PaymentBilletesMonedas
+			r.FormaPago = &formaPago
+		}
+		if !codigos.IsValidPaymentMethod(*r.FormaPago) {
+			return fmt.Errorf("invalid forma_pago: %s", *r.FormaPago)
+		}
+	} else {
+		r.FormaPago = nil
+	}
+	if r.ReferenciaPago != nil && len(*r.ReferenciaPago) > 50 {
+		return fmt.Errorf("referencia_pago cannot exceed 50 characters")
+	}
+
+	return nil
+}
+
+// validate enforces the CAT-026 rules: cash and services use unit 99 and carry
+// no depreciation; every line states an allowed valuation method
+func (i *CreateDonationItemRequest) validate() error {
+	if !codigos.IsValidDonationType(fmt.Sprintf("%d", i.TipoDonacion)) {
+		return fmt.Errorf("tipo_donacion must be 1 (efectivo), 2 (bien) or 3 (servicio)")
+	}
+
+	i.Descripcion = strings.TrimSpace(i.Descripcion)
+	if i.Descripcion == "" || len(i.Descripcion) > 900 {
+		return fmt.Errorf("descripcion must be between 1 and 900 characters")
+	}
+	if i.Codigo != nil && (len(*i.Codigo) == 0 || len(*i.Codigo) > 25) {
+		return fmt.Errorf("codigo must be between 1 and 25 characters")
+	}
+	if i.Cantidad <= 0 {
+		return fmt.Errorf("cantidad must be greater than 0")
+	}
+	if i.ValorUni < 0 {
+		return fmt.Errorf("valor_uni cannot be negative")
+	}
+	if i.Depreciacion < 0 {
+		return fmt.Errorf("depreciacion cannot be negative")
+	}
+
+	allowed := donationValuationMethods[i.TipoDonacion]
+	if i.ValuationMethod == "" {
+		i.ValuationMethod = allowed[0]
+	}
+	valid := false
+	for _, m := range allowed {
+		if m == i.ValuationMethod {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("valuation_method must be one of %s for tipo_donacion %d",
+			strings.Join(allowed, ", "), i.TipoDonacion)
+	}
+
+	if i.TipoDonacion == 2 {
+		if i.UniMedida == 0 {
+			i.UniMedida = 59 // Unidad (CAT-014)
+		}
+		if !codigos.IsValidUnitOfMeasure(fmt.Sprintf("%d", i.UniMedida)) {
+			return fmt.Errorf("invalid uni_medida: %d", i.UniMedida)
+		}
+	} else {
+		if i.Depreciacion != 0 {
+			return fmt.Errorf("depreciacion only applies to donated goods")
+		}
+		if i.UniMedida != 0 && i.UniMedida != 99 {
+			return fmt.Errorf("cash and service donations must use uni_medida 99")
+		}
+		i.UniMedida = 99
+	}
+
+	if i.Depreciacion > i.Cantidad*i.ValorUni {
+		return fmt.Errorf("depreciacion cannot exceed cantidad × valor_uni")
+	}
+	if i.Cantidad*i.ValorUni-i.Depreciacion <= 0 {
+		return fmt.Errorf("donated value must be greater than 0")
+	}
+
+	return nil
+}
+
+// DonationItemValue computes a line's donated value rounded to 8 decimals
+func DonationItemValue(cantidad, valorUni, depreciacion float64) float64 {
+	return math.Round((cantidad*valorUni-depreciacion)*1e8) / 1e8
+}
+
+// Helper methods
+
+// IsProcessed checks if the donation receipt was successfully processed by Hacienda
+func (d *Donation) IsProcessed() bool {
+	return d.HaciendaEstado != nil && *d.HaciendaEstado == "PROCESADO"
+}
+
+// IsRejected checks if the donation receipt was rejected by Hacienda
+func (d *Donation) IsRejected() bool {
+	return d.HaciendaEstado != nil && *d.HaciendaEstado == "RECHAZADO"
+}
+
+// IsQueuedForContingency checks if the donation receipt is waiting in a contingency period
+func (d *Donation) IsQueuedForContingency() bool {
+	return d.ContingencyPeriodID != nil && !d.IsProcessed()
+}
+
+// ============================================
+// ANNUAL DONOR REPORT
+// ============================================
+
+// DonorAnnualReport summarizes the donations received from each donor in a fiscal year
+type DonorAnnualReport struct {
+	CompanyID      string               `json:"company_id"`
+	Year           int                  `json:"year"`
+	Donors         []DonorAnnualSummary `json:"donors"`
+	TotalDonors    int                  `json:"total_donors"`
+	TotalEfectivo  float64              `json:"total_efectivo"`
+	TotalBienes    float64              `json:"total_bienes"`
+	TotalServicios float64              `json:"total_servicios"`
+	Total          float64              `json:"total"`
+}
+
+// DonorAnnualSummary is one donor's line in the annual report
+type DonorAnnualSummary struct {
+	ClientID       string                `json:"client_id"`
+	Nombre         string                `json:"nombre"`
+	TipoDocumento  string                `json:"tipo_documento"` // "36" NIT, "13" DUI
+	NumDocumento   string                `json:"num_documento"`
+	DonationCount  int                   `json:"donation_count"`
+	TotalEfectivo  float64               `json:"total_efectivo"`
+	TotalBienes    float64               `json:"total_bienes"`
+	TotalServicios float64               `json:"total_servicios"`
+	Total          float64               `json:"total"`
+	Donations      []DonorAnnualDocument `json:"donations"`
+}
+
+// DonorAnnualDocument is a processed donation receipt included in the report
+type DonorAnnualDocument struct {
+	DonationID       string    `json:"donation_id"`
+	CodigoGeneracion string    `json:"codigo_generacion"`
+	NumeroControl    string    `json:"numero_control"`
+	SelloRecibido    *string   `json:"sello_recibido,omitempty"`
+	FechaEmision     time.Time `json:"fecha_emision"`
+	ValorTotal       float64   `json:"valor_total"`
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"time"
+)
+
+// Email delivery statuses
+const (
+	EmailStatusPending = "pending"
+	EmailStatusSending = "sending"
+	EmailStatusSent    = "sent"
+	EmailStatusFailed  = "failed"
+	EmailStatusSkipped = "skipped"
+)
+
+// Email delivery origins
+const (
+	EmailOriginAuto   = "auto"   // Queued when Hacienda accepted the DTE
+	EmailOriginResend = "resend" // Requested through the resend endpoint
+)
+
+// CompanyEmailSettings configures how a company mails accepted DTEs to the receptor
+type CompanyEmailSettings struct {
+	CompanyID       string     `json:"company_id"`
+	Enabled         bool       `json:"enabled"`
+	EnabledAt       *time.Time `json:"enabled_at,omitempty"`
+	FromName        *string    `json:"from_name,omitempty"`
+	FromAddress     *string    `json:"from_address,omitempty"` // Falls back to the server's smtp_from
+	ReplyTo         *string    `json:"reply_to,omitempty"`
+	Bcc             *string    `json:"bcc,omitempty"`
+	SubjectTemplate *string    `json:"subject_template,omitempty"` // Go text/template; nil uses the default
+	BodyTemplate    *string    `json:"body_template,omitempty"`
+	AttachJSON      bool       `json:"attach_json"`
+	AttachPDF       bool       `json:"attach_pdf"`
+	MaxAttempts     int        `json:"max_attempts"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// UpdateEmailSettingsRequest replaces a company's email delivery settings
+type UpdateEmailSettingsRequest struct {
+	Enabled         bool    `json:"enabled"`
+	FromName        *string `json:"from_name"`
+	FromAddress     *string `json:"from_address"`
+	ReplyTo         *string `json:"reply_to"`
+	Bcc             *string `json:"bcc"`
+	SubjectTemplate *string `json:"subject_template"`
+	BodyTemplate    *string `json:"body_template"`
+	AttachJSON      *bool   `json:"attach_json"` // Defaults to true
+	AttachPDF       *bool   `json:"attach_pdf"`  // Defaults to true
+	MaxAttempts     int     `json:"max_attempts"`
+}
+
+// Validate checks the addresses and limits; templates are checked by the service
+func (r *UpdateEmailSettingsRequest) Validate() error {
+	for field, addr := range map[string]*string{
+		"from_address": r.FromAddress,
+		"reply_to":     r.ReplyTo,
+		"bcc":          r.Bcc,
+	} {
+		if addr == nil || *addr == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(*addr); err != nil {
+			return fmt.Errorf("%s is not a valid email address", field)
+		}
+	}
+
+	if r.MaxAttempts == 0 {
+		r.MaxAttempts = 5
+	}
+	if r.MaxAttempts < 1 || r.MaxAttempts > 20 {
+		return fmt.Errorf("max_attempts must be between 1 and 20")
+	}
+
+	if r.AttachJSON != nil && r.AttachPDF != nil && !*r.AttachJSON && !*r.AttachPDF {
+		return fmt.Errorf("at least one of attach_json or attach_pdf must be enabled")
+	}
+
+	return nil
+}
+
+// EmailDelivery is one attempt series to mail a DTE to its receptor
+type EmailDelivery struct {
+	ID               string     `json:"id"`
+	CompanyID        string     `json:"company_id"`
+	CodigoGeneracion string     `json:"codigo_generacion"`
+	TipoDte          string     `json:"tipo_dte"`
+	Recipient        *string    `json:"recipient"`
+	Status           string     `json:"status"`
+	Origin           string     `json:"origin"`
+	Attempts         int        `json:"attempts"`
+	MaxAttempts      int        `json:"max_attempts"`
+	NextAttemptAt    time.Time  `json:"next_attempt_at"`
+	LastError        *string    `json:"last_error,omitempty"`
+	MessageID        *string    `json:"message_id,omitempty"`
+	SentAt           *time.Time `json:"sent_at,omitempty"`
+	RequestedBy      *string    `json:"requested_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// ResendEmailRequest queues a new delivery for a DTE, optionally to another address
+type ResendEmailRequest struct {
+	Recipient *string `json:"recipient"` // Defaults to the receptor's address
+}
+
+// Validate checks the override address
+func (r *ResendEmailRequest) Validate() error {
+	if r.Recipient != nil && *r.Recipient != "" {
+		if _, err := mail.ParseAddress(*r.Recipient); err != nil {
+			return fmt.Errorf("recipient is not a valid email address")
+		}
+	}
+	return nil
+}
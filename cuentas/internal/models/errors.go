@@ -11,6 +11,10 @@ var (
 	ErrInvalidCodEstablecimiento   = errors.New("cod establecimiento must be 1-10 characters")
 	ErrEstablishmentNotFound       = errors.New("establishment not found")
 
+	// Inventory location errors
+	ErrInventoryPooled    = errors.New("stock is pooled at one location until it is allocated to the establishments")
+	ErrInventoryNotPooled = errors.New("stock is already kept per location")
+
 	// Point of Sale errors
 	ErrInvalidCodPuntoVentaMH = errors.New("cod punto venta MH must be 4 characters")
 	ErrInvalidCodPuntoVenta   = errors.New("cod punto venta must be 1-15 characters")
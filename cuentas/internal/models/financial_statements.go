@@ -0,0 +1,212 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Comparisons and breakdowns of a financial statement
+const (
+	StatementComparePreviousPeriod  = "previous_period" // The periods right before, of the same length
+	StatementComparePreviousYear    = "previous_year"   // The same period of the years before
+	StatementBreakdownEstablishment = "establishment"   // One column per cost center
+)
+
+// CostCenterNone selects the entries without an establishment (inventory
+// events and their closings)
+const CostCenterNone = "none"
+
+// StatementRequest selects the period and columns of a trial balance or
+// financial statement. The balance sheet uses the end of each period only.
+type StatementRequest struct {
+	FromDate        string // YYYY-MM-DD, defaults to January 1 of the year of ToDate
+	ToDate          string // YYYY-MM-DD, defaults to today
+	Compare         string // previous_period or previous_year
+	Periods         int    // Comparative periods, 1 to 12
+	EstablishmentID string // Cost center, or "none"
+	Breakdown       string // establishment
+	ExcludeClosing  bool   // Trial balance only: leave out the closing entries
+}
+
+// ParseStatementRequest reads a statement request from its query values
+func ParseStatementRequest(query func(string) string) (*StatementRequest, error) {
+	req := &StatementRequest{
+		FromDate:        query("from_date"),
+		ToDate:          query("to_date"),
+		Compare:         query("compare"),
+		EstablishmentID: query("establishment_id"),
+		Breakdown:       query("breakdown"),
+		ExcludeClosing:  query("exclude_closing") == "true",
+	}
+	if periods := query("periods"); periods != "" {
+		n, err := strconv.Atoi(periods)
+		if err != nil {
+			return nil, fmt.Errorf("periods must be a number between 1 and 12")
+		}
+		req.Periods = n
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// Validate checks the dates and options and fills in the defaults
+func (r *StatementRequest) Validate() error {
+	to := time.Now()
+	if r.ToDate != "" {
+		t, err := time.Parse("2006-01-02", r.ToDate)
+		if err != nil {
+			return fmt.Errorf("invalid date format, use YYYY-MM-DD")
+		}
+		to = t
+	}
+	r.ToDate = to.Format("2006-01-02")
+
+	if r.FromDate == "" {
+		r.FromDate = fmt.Sprintf("%d-01-01", to.Year())
+	} else if _, err := time.Parse("2006-01-02", r.FromDate); err != nil {
+		return fmt.Errorf("invalid date format, use YYYY-MM-DD")
+	}
+	if r.FromDate > r.ToDate {
+		return fmt.Errorf("from_date must be on or before to_date")
+	}
+
+	switch r.Compare {
+	case "":
+		if r.Periods != 0 {
+			return fmt.Errorf("periods requires compare")
+		}
+	case StatementComparePreviousPeriod, StatementComparePreviousYear:
+		if r.Periods == 0 {
+			r.Periods = 1
+		}
+		if r.Periods < 1 || r.Periods > 12 {
+			return fmt.Errorf("periods must be a number between 1 and 12")
+		}
+	default:
+		return fmt.Errorf("compare must be %s or %s", StatementComparePreviousPeriod, StatementComparePreviousYear)
+	}
+
+	if r.Breakdown != "" && r.Breakdown != StatementBreakdownEstablishment {
+		return fmt.Errorf("breakdown must be %s", StatementBreakdownEstablishment)
+	}
+	if r.Breakdown != "" && r.Compare != "" {
+		return fmt.Errorf("compare and breakdown cannot be combined")
+	}
+	if r.Breakdown != "" && r.EstablishmentID != "" {
+		return fmt.Errorf("establishment_id and breakdown cannot be combined")
+	}
+	return nil
+}
+
+// StatementColumn is one amount column of a financial statement: a period,
+// or a cost center of the period. With a breakdown, the last column is the
+// total of all cost centers.
+type StatementColumn struct {
+	FromDate          string `json:"from_date,omitempty"` // Empty on the balance sheet
+	ToDate            string `json:"to_date"`
+	EstablishmentID   string `json:"establishment_id,omitempty"`
+	EstablishmentCode string `json:"establishment_code,omitempty"`
+	EstablishmentName string `json:"establishment_name,omitempty"`
+	Unassigned        bool   `json:"unassigned,omitempty"` // Entries without an establishment
+	Total             bool   `json:"total,omitempty"`      // All cost centers
+}
+
+// StatementLine is an account of a financial statement with one amount per
+// column. Group accounts carry the sum of their subaccounts. Amounts have the
+// account type's natural sign: a contra account shows as negative.
+type StatementLine struct {
+	AccountID string    `json:"account_id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Level     int       `json:"level"` // 0 for top level accounts
+	Postable  bool      `json:"postable"`
+	Amounts   []float64 `json:"amounts"`
+}
+
+// StatementSection groups the accounts of one type
+type StatementSection struct {
+	AccountType string          `json:"account_type"`
+	Lines       []StatementLine `json:"lines"`
+	Totals      []float64       `json:"totals"`
+}
+
+// FinancialStatement is an income statement (estado de resultados) or a
+// balance sheet (balance general). On the balance sheet, the equity totals
+// include NetIncome, the result not yet closed into retained earnings.
+type FinancialStatement struct {
+	CompanyID              string             `json:"company_id"`
+	CompanyName            string             `json:"company_name"`
+	NIT                    string             `json:"nit"`
+	NRC                    string             `json:"nrc"`
+	Statement              string             `json:"statement"` // income_statement or balance_sheet
+	FromDate               string             `json:"from_date"`
+	ToDate                 string             `json:"to_date"`
+	EstablishmentID        string             `json:"establishment_id,omitempty"`
+	EstablishmentCode      string             `json:"establishment_code,omitempty"`
+	EstablishmentName      string             `json:"establishment_name,omitempty"`
+	Columns                []StatementColumn  `json:"columns"`
+	Sections               []StatementSection `json:"sections"`
+	NetIncome              []float64          `json:"net_income"`
+	TotalLiabilitiesEquity []float64          `json:"total_liabilities_equity,omitempty"` // Balance sheet only
+	GeneratedAt            time.Time          `json:"generated_at"`
+}
+
+// Financial statements
+const (
+	StatementIncome  = "income_statement"
+	StatementBalance = "balance_sheet"
+)
+
+// TrialBalanceAmounts are the columns of the trial balance (balance de
+// comprobación): the balance before the period, the period's movements and
+// the balance at its end
+type TrialBalanceAmounts struct {
+	OpeningDebit  float64 `json:"opening_debit"`
+	OpeningCredit float64 `json:"opening_credit"`
+	Debits        float64 `json:"debits"`
+	Credits       float64 `json:"credits"`
+	ClosingDebit  float64 `json:"closing_debit"`
+	ClosingCredit float64 `json:"closing_credit"`
+}
+
+// Add adds another account's amounts
+func (a *TrialBalanceAmounts) Add(other TrialBalanceAmounts) {
+	a.OpeningDebit += other.OpeningDebit
+	a.OpeningCredit += other.OpeningCredit
+	a.Debits += other.Debits
+	a.Credits += other.Credits
+	a.ClosingDebit += other.ClosingDebit
+	a.ClosingCredit += other.ClosingCredit
+}
+
+// TrialBalanceRow is an account of the trial balance. Group accounts carry
+// the sum of their subaccounts and are left out of the totals.
+type TrialBalanceRow struct {
+	AccountID   string `json:"account_id"`
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	AccountType string `json:"account_type"`
+	Level       int    `json:"level"`
+	Postable    bool   `json:"postable"`
+	TrialBalanceAmounts
+}
+
+// TrialBalance is the balance de comprobación of a period
+type TrialBalance struct {
+	CompanyID         string              `json:"company_id"`
+	CompanyName       string              `json:"company_name"`
+	NIT               string              `json:"nit"`
+	NRC               string              `json:"nrc"`
+	FromDate          string              `json:"from_date"`
+	ToDate            string              `json:"to_date"`
+	EstablishmentID   string              `json:"establishment_id,omitempty"`
+	EstablishmentCode string              `json:"establishment_code,omitempty"`
+	EstablishmentName string              `json:"establishment_name,omitempty"`
+	ExcludeClosing    bool                `json:"exclude_closing"`
+	Rows              []TrialBalanceRow   `json:"rows"`
+	Totals            TrialBalanceAmounts `json:"totals"`
+	GeneratedAt       time.Time           `json:"generated_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// IdempotencyKeyHeader is the request header that makes a POST safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set on responses replayed from a stored result
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// IdempotencyRecord is the stored outcome of the first request made with a key
+type IdempotencyRecord struct {
+	CompanyID    string
+	Key          string
+	Method       string
+	Path         string
+	RequestHash  string
+	StatusCode   int
+	ContentType  *string
+	ResponseBody []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+// Matches reports whether a retry is the same request the key was first used for
+func (r *IdempotencyRecord) Matches(method, path, requestHash string) bool {
+	return r.Method == method && r.Path == path && r.RequestHash == requestHash
+}
@@ -0,0 +1,147 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cuentas/internal/codigos"
+)
+
+// DTEInvalidation represents an Evento de Invalidación (anulación) of a previously accepted DTE
+type DTEInvalidation struct {
+	ID        string `json:"id"`
+	CompanyID string `json:"company_id"`
+
+	// Event identifiers
+	CodigoGeneracion string `json:"codigo_generacion"`
+	Ambiente         string `json:"ambiente"`
+
+	// Document being invalidated (snapshot from dte_commit_log)
+	OriginalCodigoGeneracion string    `json:"original_codigo_generacion"`
+	OriginalTipoDte          string    `json:"original_tipo_dte"`
+	OriginalNumeroControl    string    `json:"original_numero_control"`
+	OriginalSelloRecibido    string    `json:"original_sello_recibido"`
+	OriginalFechaEmision     time.Time `json:"original_fecha_emision"`
+	InvoiceID                *string   `json:"invoice_id,omitempty"`
+	PurchaseID               *string   `json:"purchase_id,omitempty"`
+
+	// Replacement document (tipo_anulacion 1 and 3)
+	CodigoGeneracionR *string `json:"codigo_generacion_r,omitempty"`
+
+	// Motivo (CAT-024)
+	TipoAnulacion     int     `json:"tipo_anulacion"`
+	MotivoAnulacion   *string `json:"motivo_anulacion,omitempty"`
+	NombreResponsable string  `json:"nombre_responsable"`
+	TipDocResponsable string  `json:"tip_doc_responsable"`
+	NumDocResponsable string  `json:"num_doc_responsable"`
+	NombreSolicita    string  `json:"nombre_solicita"`
+	TipDocSolicita    string  `json:"tip_doc_solicita"`
+	NumDocSolicita    string  `json:"num_doc_solicita"`
+
+	// Event content
+	DteUnsigned string  `json:"dte_unsigned"`
+	DteSigned   *string `json:"dte_signed,omitempty"`
+
+	// Hacienda response
+	HaciendaEstado          *string    `json:"hacienda_estado,omitempty"`
+	HaciendaSelloRecibido   *string    `json:"hacienda_sello_recibido,omitempty"`
+	HaciendaFhProcesamiento *time.Time `json:"hacienda_fh_procesamiento,omitempty"`
+	HaciendaCodigoMsg       *string    `json:"hacienda_codigo_msg,omitempty"`
+	HaciendaDescripcionMsg  *string    `json:"hacienda_descripcion_msg,omitempty"`
+	HaciendaObservaciones   []string   `json:"hacienda_observaciones,omitempty"`
+
+	// Reversal of the original document's effects
+	EffectsReversed   bool       `json:"effects_reversed"`
+	EffectsReversedAt *time.Time `json:"effects_reversed_at,omitempty"`
+
+	// Audit
+	CreatedBy   *string    `json:"created_by,omitempty"`
+	SubmittedAt *time.Time `json:"submitted_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// IsAccepted returns true if Hacienda accepted the invalidation
+func (i *DTEInvalidation) IsAccepted() bool {
+	return i.HaciendaEstado != nil && *i.HaciendaEstado == "PROCESADO"
+}
+
+// InvalidateDTERequest represents the request to invalidate a DTE
+type InvalidateDTERequest struct {
+	// CAT-024: 1 = error en la información, 2 = rescindir la operación, 3 = otro
+	TipoAnulacion   int    `json:"tipo_anulacion" binding:"required"`
+	MotivoAnulacion string `json:"motivo_anulacion"`
+
+	// Replacement document, required for tipo_anulacion 1 and 3
+	CodigoGeneracionR string `json:"codigo_generacion_r"`
+
+	// Person responsible for the invalidation (company side)
+	NombreResponsable string `json:"nombre_responsable" binding:"required"`
+	TipDocResponsable string `json:"tip_doc_responsable" binding:"required"`
+	NumDocResponsable string `json:"num_doc_responsable" binding:"required"`
+
+	// Person requesting the invalidation (usually the receptor)
+	NombreSolicita string `json:"nombre_solicita" binding:"required"`
+	TipDocSolicita string `json:"tip_doc_solicita" binding:"required"`
+	NumDocSolicita string `json:"num_doc_solicita" binding:"required"`
+}
+
+// validInvalidationDocTypes are the identification document types accepted
+// by the anulación schema for responsable / solicitante
+var validInvalidationDocTypes = map[string]bool{
+	"36": true, // NIT
+	"13": true, // DUI
+	"02": true, // Carnet de residente
+	"03": true, // Pasaporte
+	"37": true, // Otro
+}
+
+// RequiresReplacement returns true if the invalidation type needs a replacement DTE
+func (r *InvalidateDTERequest) RequiresReplacement() bool {
+	tipo := fmt.Sprintf("%d", r.TipoAnulacion)
+	return tipo == codigos.InvalidationError || tipo == codigos.InvalidationOtro
+}
+
+// Validate validates the invalidation request
+func (r *InvalidateDTERequest) Validate() error {
+	if !codigos.IsValidInvalidationType(fmt.Sprintf("%d", r.TipoAnulacion)) {
+		return fmt.Errorf("invalid tipo_anulacion: %d (must be 1, 2 or 3)", r.TipoAnulacion)
+	}
+
+	r.CodigoGeneracionR = strings.ToUpper(strings.TrimSpace(r.CodigoGeneracionR))
+	if r.RequiresReplacement() && r.CodigoGeneracionR == "" {
+		return fmt.Errorf("codigo_generacion_r is required for tipo_anulacion %d", r.TipoAnulacion)
+	}
+	if !r.RequiresReplacement() && r.CodigoGeneracionR != "" {
+		return fmt.Errorf("codigo_generacion_r must be empty for tipo_anulacion %d", r.TipoAnulacion)
+	}
+
+	if fmt.Sprintf("%d", r.TipoAnulacion) == codigos.InvalidationOtro && strings.TrimSpace(r.MotivoAnulacion) == "" {
+		return fmt.Errorf("motivo_anulacion is required for tipo_anulacion 3")
+	}
+	if len(r.MotivoAnulacion) > 250 {
+		return fmt.Errorf("motivo_anulacion must be at most 250 characters")
+	}
+
+	if strings.TrimSpace(r.NombreResponsable) == "" {
+		return fmt.Errorf("nombre_responsable is required")
+	}
+	if !validInvalidationDocTypes[r.TipDocResponsable] {
+		return fmt.Errorf("invalid tip_doc_responsable: %s", r.TipDocResponsable)
+	}
+	if strings.TrimSpace(r.NumDocResponsable) == "" {
+		return fmt.Errorf("num_doc_responsable is required")
+	}
+
+	if strings.TrimSpace(r.NombreSolicita) == "" {
+		return fmt.Errorf("nombre_solicita is required")
+	}
+	if !validInvalidationDocTypes[r.TipDocSolicita] {
+		return fmt.Errorf("invalid tip_doc_solicita: %s", r.TipDocSolicita)
+	}
+	if strings.TrimSpace(r.NumDocSolicita) == "" {
+		return fmt.Errorf("num_doc_solicita is required")
+	}
+
+	return nil
+}
@@ -19,6 +19,7 @@ type InventoryEvent struct {
 	EventID               int64     `json:"event_id"`
 	CompanyID             string    `json:"company_id"`
 	ItemID                string    `json:"item_id"`
+	EstablishmentID       string    `json:"establishment_id"` // Location the goods entered or left
 	EventType             string    `json:"event_type"`
 	EventTimestamp        time.Time `json:"event_timestamp"`
 	AggregateVersion      int       `json:"aggregate_version"`
@@ -33,6 +34,7 @@ type InventoryEvent struct {
 	// Purchase/Document fields
 	DocumentType        *string `json:"document_type,omitempty"`
 	DocumentNumber      *string `json:"document_number,omitempty"`
+	SupplierID          *string `json:"supplier_id,omitempty"`
 	SupplierName        *string `json:"supplier_name,omitempty"`
 	SupplierNIT         *string `json:"supplier_nit,omitempty"`
 	SupplierNationality *string `json:"supplier_nationality,omitempty"`
@@ -61,43 +63,67 @@ type InventoryEvent struct {
 	CreatedAt       time.Time       `json:"created_at"`
 }
 
-// InventoryState represents the current state of inventory for an item
+// InventoryState represents the current state of inventory for an item at a
+// location. Without an establishment it is the item's company-wide state: the
+// sum of its locations, listed in Locations.
 type InventoryState struct {
-	CompanyID        string    `json:"company_id"`
-	ItemID           string    `json:"item_id"`
-	CurrentQuantity  float64   `json:"current_quantity"`
-	CurrentTotalCost Money     `json:"current_total_cost"`
-	CurrentAvgCost   Money     `json:"current_avg_cost"`
-	LastEventID      *int64    `json:"last_event_id,omitempty"`
-	AggregateVersion int       `json:"aggregate_version"`
-	UpdatedAt        time.Time `json:"updated_at"`
+	CompanyID        string           `json:"company_id"`
+	ItemID           string           `json:"item_id"`
+	EstablishmentID  string           `json:"establishment_id,omitempty"`
+	CurrentQuantity  float64          `json:"current_quantity"`
+	CurrentTotalCost Money            `json:"current_total_cost"`
+	CurrentAvgCost   Money            `json:"current_avg_cost"`
+	LastEventID      *int64           `json:"last_event_id,omitempty"`
+	AggregateVersion int              `json:"aggregate_version"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+	Locations        []InventoryState `json:"locations,omitempty"`
 }
 
-// InventoryStateWithItem includes item details with the state
+// InventoryStateWithItem includes item and location details with the state
 type InventoryStateWithItem struct {
+	CompanyID         string    `json:"company_id"`
+	ItemID            string    `json:"item_id"`
+	SKU               string    `json:"sku"`
+	ItemName          string    `json:"item_name"`
+	TipoItem          string    `json:"tipo_item"`
+	EstablishmentID   string    `json:"establishment_id"`
+	EstablishmentCode string    `json:"establishment_code"`
+	EstablishmentName string    `json:"establishment_name"`
+	CurrentQuantity   float64   `json:"current_quantity"`
+	CurrentTotalCost  Money     `json:"current_total_cost"` // Changed from float64 to Money
+	CurrentAvgCost    Money     `json:"current_avg_cost"`   // Changed from float64 to Money
+	LastEventID       *int64    `json:"last_event_id,omitempty"`
+	AggregateVersion  int       `json:"aggregate_version"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// InventoryItemTotals is an item's stock summed over its locations. The
+// average cost is the consolidated one: total cost over total quantity.
+type InventoryItemTotals struct {
 	CompanyID        string    `json:"company_id"`
 	ItemID           string    `json:"item_id"`
 	SKU              string    `json:"sku"`
 	ItemName         string    `json:"item_name"`
 	TipoItem         string    `json:"tipo_item"`
 	CurrentQuantity  float64   `json:"current_quantity"`
-	CurrentTotalCost Money     `json:"current_total_cost"` // Changed from float64 to Money
-	CurrentAvgCost   Money     `json:"current_avg_cost"`   // Changed from float64 to Money
-	LastEventID      *int64    `json:"last_event_id,omitempty"`
-	AggregateVersion int       `json:"aggregate_version"`
+	CurrentTotalCost Money     `json:"current_total_cost"`
+	CurrentAvgCost   Money     `json:"current_avg_cost"`
+	Locations        int       `json:"locations"` // Locations holding the item
 	UpdatedAt        time.Time `json:"updated_at"`
 }
 
 // RecordPurchaseRequest represents a request to record a purchase
 type RecordPurchaseRequest struct {
-	Quantity float64 `json:"quantity" binding:"required,gt=0"`
-	UnitCost Money   `json:"unit_cost" binding:"required,gt=0"`
+	Quantity        float64 `json:"quantity" binding:"required,gt=0"`
+	UnitCost        Money   `json:"unit_cost" binding:"required,gt=0"`
+	EstablishmentID string  `json:"establishment_id"` // Location receiving the goods
 
 	// Legal compliance fields (Article 142-A)
 	DocumentType   string  `json:"document_type" binding:"required"`
 	DocumentNumber string  `json:"document_number" binding:"required"`
-	SupplierName   string  `json:"supplier_name" binding:"required"`
-	SupplierNIT    *string `json:"supplier_nit"` // Required if DocumentType == CCF (03)
+	SupplierID     *string `json:"supplier_id"`   // Registered supplier; fills name, NIT and nationality
+	SupplierName   string  `json:"supplier_name"` // Required without supplier_id
+	SupplierNIT    *string `json:"supplier_nit"`  // Required if DocumentType == CCF (03) without supplier_id
 	CostSourceRef  *string `json:"cost_source_ref"`
 
 	// Existing optional fields
@@ -109,12 +135,13 @@ type RecordPurchaseRequest struct {
 
 // RecordAdjustmentRequest represents a request to record an inventory adjustment
 type RecordAdjustmentRequest struct {
-	Quantity      float64 `json:"quantity" binding:"required,ne=0"`
-	UnitCost      *Money  `json:"unit_cost"`
-	Reason        string  `json:"reason" binding:"required"`
-	ReferenceType *string `json:"reference_type"`
-	ReferenceID   *string `json:"reference_id"`
-	CorrelationID *string `json:"correlation_id"`
+	Quantity        float64 `json:"quantity" binding:"required,ne=0"`
+	UnitCost        *Money  `json:"unit_cost"`
+	EstablishmentID string  `json:"establishment_id"` // Location being adjusted
+	Reason          string  `json:"reason" binding:"required"`
+	ReferenceType   *string `json:"reference_type"`
+	ReferenceID     *string `json:"reference_id"`
+	CorrelationID   *string `json:"correlation_id"`
 }
 
 func (r *RecordAdjustmentRequest) Validate() error {
@@ -122,6 +149,10 @@ func (r *RecordAdjustmentRequest) Validate() error {
 		return fmt.Errorf("quantity cannot be zero")
 	}
 
+	if r.EstablishmentID == "" {
+		return fmt.Errorf("establishment_id is required")
+	}
+
 	// If adding inventory (positive quantity), unit cost is required
 	if r.Quantity > 0 {
 		if r.UnitCost == nil {
@@ -151,14 +182,18 @@ func (r *RecordPurchaseRequest) Validate() error {
 		return fmt.Errorf("unit_cost cannot be negative")
 	}
 
+	if r.EstablishmentID == "" {
+		return fmt.Errorf("establishment_id is required")
+	}
+
 	// Validate document type using constants
 	if r.DocumentType != codigos.DocTypeFactura && r.DocumentType != codigos.DocTypeComprobanteCredito {
 		return fmt.Errorf("document_type must be %s (Factura) or %s (CCF)",
 			codigos.DocTypeFactura, codigos.DocTypeComprobanteCredito)
 	}
 
-	// CCF (03) requires supplier NIT
-	if r.DocumentType == codigos.DocTypeComprobanteCredito {
+	// CCF (03) requires supplier NIT; a registered supplier's is checked on record
+	if r.DocumentType == codigos.DocTypeComprobanteCredito && !r.HasSupplierID() {
 		if r.SupplierNIT == nil || *r.SupplierNIT == "" {
 			return fmt.Errorf("supplier_nit is required for document type %s (CCF)",
 				codigos.DocTypeComprobanteCredito)
@@ -178,16 +213,22 @@ func (r *RecordPurchaseRequest) Validate() error {
 		return fmt.Errorf("document_number is required")
 	}
 
-	if r.SupplierName == "" {
-		return fmt.Errorf("supplier_name is required")
+	if r.SupplierName == "" && !r.HasSupplierID() {
+		return fmt.Errorf("supplier_id or supplier_name is required")
 	}
 
 	return nil
 }
 
+// HasSupplierID reports whether the purchase names a registered supplier
+func (r *RecordPurchaseRequest) HasSupplierID() bool {
+	return r.SupplierID != nil && *r.SupplierID != ""
+}
+
 type RecordSaleRequest struct {
 	// Inventory
-	Quantity float64 `json:"quantity" binding:"required"`
+	Quantity        float64 `json:"quantity" binding:"required"`
+	EstablishmentID string  `json:"establishment_id" binding:"required"` // Location the goods leave, the invoice's
 
 	// Pricing (all amounts are per unit, tax-exclusive unless noted)
 	UnitSalePrice  Money  `json:"unit_sale_price" binding:"required"`
@@ -230,6 +271,10 @@ func (r *RecordSaleRequest) Validate() error {
 		return fmt.Errorf("el precio neto no puede ser negativo")
 	}
 
+	if r.EstablishmentID == "" {
+		return fmt.Errorf("el establecimiento es requerido")
+	}
+
 	// Validate document type
 	if r.DocumentType != codigos.DocTypeFactura && r.DocumentType != codigos.DocTypeComprobanteCredito && r.DocumentType != codigos.DocTypeFacturasExportacion {
 		return fmt.Errorf("document_type debe ser %s (Factura) o %s (CCF)",
@@ -268,6 +313,117 @@ func (r *RecordSaleRequest) Validate() error {
 	return nil
 }
 
+// RecordSaleReturnRequest represents goods returning to inventory because the
+// sale that removed them was invalidated
+type RecordSaleReturnRequest struct {
+	Quantity        float64 `json:"quantity"`
+	UnitCost        Money   `json:"unit_cost"`        // Cost at which the original sale removed the goods
+	EstablishmentID string  `json:"establishment_id"` // Location the original sale removed them from
+
+	DocumentType   string `json:"document_type"`
+	DocumentNumber string `json:"document_number"`
+
+	InvoiceID     string `json:"invoice_id"`
+	InvoiceLineID string `json:"invoice_line_id"`
+
+	ReferenceType string  `json:"reference_type"`
+	ReferenceID   string  `json:"reference_id"`
+	Notes         *string `json:"notes"`
+}
+
+// Validate validates the record sale return request
+func (r *RecordSaleReturnRequest) Validate() error {
+	if r.Quantity <= 0 {
+		return fmt.Errorf("quantity must be greater than 0")
+	}
+
+	if r.UnitCost < 0 {
+		return fmt.Errorf("unit_cost cannot be negative")
+	}
+
+	if r.InvoiceID == "" {
+		return fmt.Errorf("invoice_id is required")
+	}
+
+	if r.EstablishmentID == "" {
+		return fmt.Errorf("establishment_id is required")
+	}
+
+	if r.ReferenceType == "" || r.ReferenceID == "" {
+		return fmt.Errorf("reference_type and reference_id are required")
+	}
+
+	return nil
+}
+
+// RecordTransferRequest moves goods between two locations at the source's
+// average cost
+type RecordTransferRequest struct {
+	FromEstablishmentID string  `json:"from_establishment_id" binding:"required"`
+	ToEstablishmentID   string  `json:"to_establishment_id" binding:"required"`
+	Quantity            float64 `json:"quantity" binding:"required,gt=0"`
+	Notes               *string `json:"notes"`
+}
+
+// Validate validates the record transfer request
+func (r *RecordTransferRequest) Validate() error {
+	if r.Quantity <= 0 {
+		return fmt.Errorf("quantity must be greater than 0")
+	}
+
+	if r.FromEstablishmentID == "" || r.ToEstablishmentID == "" {
+		return fmt.Errorf("from_establishment_id and to_establishment_id are required")
+	}
+
+	if r.FromEstablishmentID == r.ToEstablishmentID {
+		return fmt.Errorf("from_establishment_id and to_establishment_id must differ")
+	}
+
+	return nil
+}
+
+// InventoryTransfer is a transfer's pair of events, which share its ID as
+// reference_id
+type InventoryTransfer struct {
+	TransferID string         `json:"transfer_id"`
+	Out        InventoryEvent `json:"transfer_out"`
+	In         InventoryEvent `json:"transfer_in"`
+}
+
+// AllocateInventoryRequest spreads a pooled company's stock over its
+// locations; what is not allocated stays at the pool
+type AllocateInventoryRequest struct {
+	Allocations []InventoryAllocation `json:"allocations"`
+	Notes       *string               `json:"notes"`
+}
+
+// InventoryAllocation is the quantity of an item moved to a location
+type InventoryAllocation struct {
+	ItemID          string  `json:"item_id"`
+	EstablishmentID string  `json:"establishment_id"`
+	Quantity        float64 `json:"quantity"`
+}
+
+// Validate validates the allocate inventory request
+func (r *AllocateInventoryRequest) Validate() error {
+	for i, allocation := range r.Allocations {
+		if allocation.ItemID == "" || allocation.EstablishmentID == "" {
+			return fmt.Errorf("allocations[%d]: item_id and establishment_id are required", i)
+		}
+		if allocation.Quantity <= 0 {
+			return fmt.Errorf("allocations[%d]: quantity must be greater than 0", i)
+		}
+	}
+	return nil
+}
+
+// InventoryAllocationResult lists the transfers that moved the stock out of
+// the pool
+type InventoryAllocationResult struct {
+	PoolEstablishmentID string              `json:"pool_establishment_id"`
+	Transfers           []InventoryTransfer `json:"transfers"`
+}
+
 // GetCostHistoryRequest represents query parameters for cost history
 type GetCostHistoryRequest struct {
 	Limit int    `form:"limit"` // Default will be 50
@@ -277,18 +433,21 @@ type GetCostHistoryRequest struct {
 // InventoryEventWithItem includes item details with the event
 type InventoryEventWithItem struct {
 	InventoryEvent
-	SKU      string `json:"sku"`
-	ItemName string `json:"item_name"`
+	SKU               string `json:"sku"`
+	ItemName          string `json:"item_name"`
+	EstablishmentCode string `json:"establishment_code,omitempty"`
+	EstablishmentName string `json:"establishment_name,omitempty"`
 }
 
 // InventoryValuation represents inventory value at a specific point in time
 type InventoryValuation struct {
-	AsOfDate      time.Time       `json:"as_of_date"`
-	CompanyID     string          `json:"company_id"`
-	TotalValue    Money           `json:"total_value"`
-	TotalQuantity float64         `json:"total_quantity"`
-	ItemCount     int             `json:"item_count"`
-	ItemValues    []ItemValuation `json:"item_values"`
+	AsOfDate        time.Time       `json:"as_of_date"`
+	CompanyID       string          `json:"company_id"`
+	EstablishmentID string          `json:"establishment_id,omitempty"` // Location valued; all of them when empty
+	TotalValue      Money           `json:"total_value"`
+	TotalQuantity   float64         `json:"total_quantity"`
+	ItemCount       int             `json:"item_count"`
+	ItemValues      []ItemValuation `json:"item_values"`
 }
 
 // ItemValuation represents a single item's valuation at a point in time
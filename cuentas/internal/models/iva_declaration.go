@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+// F-07 anexos, numbered as in Hacienda's upload portal
+const (
+	F07AnexoVentasContribuyentes   = 1 // Detalle de ventas a contribuyentes
+	F07AnexoVentasConsumidorFinal  = 2 // Detalle de ventas a consumidor final
+	F07AnexoCompras                = 3 // Detalle de compras
+	F07AnexoRetencionesEfectuadas  = 4 // Retenciones de IVA efectuadas por el declarante
+	F07AnexoSujetosExcluidos       = 5 // Compras a sujetos excluidos
+	F07AnexoPercepcionesEfectuadas = 6 // Percepciones de IVA efectuadas por el declarante
+	F07AnexoRetencionesSufridas    = 7 // Retenciones de IVA efectuadas al declarante
+)
+
+// F07Anexos lists the anexos in upload order
+var F07Anexos = []int{
+	F07AnexoVentasContribuyentes,
+	F07AnexoVentasConsumidorFinal,
+	F07AnexoCompras,
+	F07AnexoRetencionesEfectuadas,
+	F07AnexoSujetosExcluidos,
+	F07AnexoPercepcionesEfectuadas,
+	F07AnexoRetencionesSufridas,
+}
+
+// IsValidF07Anexo checks if the number is one of the F-07 anexos we generate
+func IsValidF07Anexo(anexo int) bool {
+	for _, a := range F07Anexos {
+		if a == anexo {
+			return true
+		}
+	}
+	return false
+}
+
+// F07AnexoSummary describes one anexo file of the declaration
+type F07AnexoSummary struct {
+	Anexo    int     `json:"anexo"`
+	Filename string  `json:"filename"`
+	Rows     int     `json:"rows"`
+	Amount   float64 `json:"amount"` // Sum of the anexo's main amount column
+}
+
+// IVADeclarationLines are the F-07 declaration lines computed from the month's
+// IVA books. Débito fiscal is the sales books' IVA, crédito fiscal the
+// purchases book's; the remanente from the previous month is given by the
+// caller. Retentions suffered reduce the tax, retentions and percepciones made
+// as agent are added to it.
+type IVADeclarationLines struct {
+	VentasGravadasContribuyentes float64 `json:"ventas_gravadas_contribuyentes"`
+	VentasGravadasConsumidor     float64 `json:"ventas_gravadas_consumidor"` // IVA included
+	VentasExentas                float64 `json:"ventas_exentas"`
+	VentasNoSujetas              float64 `json:"ventas_no_sujetas"`
+	Exportaciones                float64 `json:"exportaciones"`
+	DebitoFiscalContribuyentes   float64 `json:"debito_fiscal_contribuyentes"`
+	DebitoFiscalConsumidor       float64 `json:"debito_fiscal_consumidor"`
+	DebitoFiscal                 float64 `json:"debito_fiscal"`
+
+	ComprasGravadasInternas float64 `json:"compras_gravadas_internas"`
+	ImportacionesGravadas   float64 `json:"importaciones_gravadas"`
+	ComprasExentas          float64 `json:"compras_exentas"`
+	SujetosExcluidos        float64 `json:"sujetos_excluidos"`
+	CreditoFiscal           float64 `json:"credito_fiscal"`
+	RemanenteAnterior       float64 `json:"remanente_anterior"`
+
+	ImpuestoDeterminado    float64 `json:"impuesto_determinado"`
+	RemanenteSiguiente     float64 `json:"remanente_siguiente"` // Crédito fiscal carried to next month
+	RetencionesSufridas    float64 `json:"retenciones_sufridas"`
+	RetencionesEfectuadas  float64 `json:"retenciones_efectuadas"`
+	PercepcionesEfectuadas float64 `json:"percepciones_efectuadas"`
+	ImpuestoAPagar         float64 `json:"impuesto_a_pagar"`
+	ExcedenteRetenciones   float64 `json:"excedente_retenciones"` // Retentions suffered beyond the tax due
+}
+
+// IVADeclaration is the F-07 for a fiscal month: the declaration lines, the
+// anexo files and the IVA books they come from
+type IVADeclaration struct {
+	CompanyID   string              `json:"company_id"`
+	CompanyName string              `json:"company_name"`
+	NIT         string              `json:"nit"`
+	NRC         string              `json:"nrc"`
+	Year        int                 `json:"year"`
+	Month       int                 `json:"month"`
+	Lines       IVADeclarationLines `json:"lines"`
+	Anexos      []F07AnexoSummary   `json:"anexos"`
+	GeneratedAt time.Time           `json:"generated_at"`
+
+	ContributorSales *ContributorSalesBook `json:"-"`
+	ConsumerSales    *ConsumerSalesBook    `json:"-"`
+	Purchases        *PurchaseBook         `json:"-"`
+}
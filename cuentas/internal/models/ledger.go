@@ -0,0 +1,337 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Ledger account types. Assets and expenses have a debit balance; liabilities,
+// equity and income a credit balance.
+const (
+	AccountTypeAsset     = "asset"
+	AccountTypeLiability = "liability"
+	AccountTypeEquity    = "equity"
+	AccountTypeIncome    = "income"
+	AccountTypeExpense   = "expense"
+)
+
+var accountTypes = []string{AccountTypeAsset, AccountTypeLiability, AccountTypeEquity, AccountTypeIncome, AccountTypeExpense}
+
+// IsDebitNormal reports whether accounts of the type increase with debits
+func IsDebitNormal(accountType string) bool {
+	return accountType == AccountTypeAsset || accountType == AccountTypeExpense
+}
+
+// Journal entry sources: the table a posted document comes from
+const (
+	LedgerSourceSale            = "sale"             // DTE in the commit log (01, 03, 05, 06, 11)
+	LedgerSourcePurchase        = "purchase"         // purchases
+	LedgerSourceRetention       = "retention"        // comprobantes de retención (07) we issued
+	LedgerSourcePayment         = "payment"          // client payments
+	LedgerSourceSupplierPayment = "supplier_payment" // supplier payments
+	LedgerSourceInventory       = "inventory_event"  // inventory cost events
+	LedgerSourceClosing         = "closing"          // fiscal year closings
+)
+
+// Ledger document types. Posting rules map each document type's roles to
+// accounts; the "default" document type applies when a type has no rule of
+// its own for a role.
+const (
+	LedgerDocDefault         = "default"
+	LedgerDocFactura         = "factura"
+	LedgerDocCCF             = "ccf"
+	LedgerDocNotaCredito     = "nota_credito"
+	LedgerDocNotaDebito      = "nota_debito"
+	LedgerDocExportacion     = "exportacion"
+	LedgerDocPurchase        = "purchase"
+	LedgerDocFSE             = "fse"
+	LedgerDocImport          = "import"
+	LedgerDocRetention       = "retention"
+	LedgerDocPayment         = "payment"
+	LedgerDocSupplierPayment = "supplier_payment"
+	LedgerDocInventory       = "inventory"
+	LedgerDocClosing         = "closing"
+)
+
+// Posting roles: the part a line plays in a document's entry
+const (
+	RoleCash                    = "cash"
+	RoleReceivable              = "receivable"
+	RoleRevenueGravada          = "revenue_gravada"
+	RoleRevenueExenta           = "revenue_exenta"
+	RoleRevenueNoSujeta         = "revenue_no_sujeta"
+	RoleRevenueExport           = "revenue_export"
+	RoleIVADebito               = "iva_debito"
+	RoleIVAPercibido            = "iva_percibido"             // Percepción charged to clients
+	RoleIVARetenidoClientes     = "iva_retenido_clientes"     // 1% withheld by clients, recoverable
+	RolePurchases               = "purchases"                 // Purchases, costs and expenses
+	RoleIVACredito              = "iva_credito"               // IVA crédito fiscal
+	RoleIVAPercibidoProveedores = "iva_percibido_proveedores" // Percepción charged by suppliers, recoverable
+	RolePayable                 = "payable"
+	RoleIVARetenidoProveedores  = "iva_retenido_proveedores" // IVA withheld from suppliers, owed to Hacienda
+	RoleRentaRetenida           = "renta_retenida"           // Income tax withheld, owed to Hacienda
+	RoleInventory               = "inventory"
+	RoleCOGS                    = "cogs"
+	RoleInventoryReceived       = "inventory_received" // Counterpart of inventory purchase events
+	RoleInventoryAdjustment     = "inventory_adjustment"
+	RoleOpeningBalance          = "opening_balance"   // Counterpart of initial inventory
+	RoleRetainedEarnings        = "retained_earnings" // Receives the year's result on closing
+	RoleClosingBalance          = "closing_balance"   // Closing lines that zero income and expense accounts; not mapped by rules
+)
+
+var salesRoles = []string{
+	RoleReceivable, RoleRevenueGravada, RoleRevenueExenta, RoleRevenueNoSujeta, RoleRevenueExport,
+	RoleIVADebito, RoleIVAPercibido, RoleIVARetenidoClientes,
+}
+
+var purchaseRoles = []string{
+	RolePurchases, RoleIVACredito, RoleIVAPercibidoProveedores, RolePayable,
+	RoleIVARetenidoProveedores, RoleRentaRetenida, RoleCash,
+}
+
+// LedgerDocumentRoles lists the roles each document type posts to. Every one
+// must resolve to an account before the ledger can be enabled.
+var LedgerDocumentRoles = map[string][]string{
+	LedgerDocFactura:         salesRoles,
+	LedgerDocCCF:             salesRoles,
+	LedgerDocNotaCredito:     salesRoles,
+	LedgerDocNotaDebito:      salesRoles,
+	LedgerDocExportacion:     salesRoles,
+	LedgerDocPurchase:        purchaseRoles,
+	LedgerDocFSE:             purchaseRoles,
+	LedgerDocImport:          purchaseRoles,
+	LedgerDocRetention:       {RolePayable, RoleIVARetenidoProveedores},
+	LedgerDocPayment:         {RoleCash, RoleReceivable},
+	LedgerDocSupplierPayment: {RoleCash, RolePayable},
+	LedgerDocInventory:       {RoleInventory, RoleCOGS, RoleInventoryReceived, RoleInventoryAdjustment, RoleOpeningBalance},
+	LedgerDocClosing:         {RoleRetainedEarnings},
+}
+
+// IsValidLedgerRule checks a posting rule's document type and role
+func IsValidLedgerRule(documentType, role string) bool {
+	if documentType == LedgerDocDefault {
+		for _, roles := range LedgerDocumentRoles {
+			for _, r := range roles {
+				if r == role {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for _, r := range LedgerDocumentRoles[documentType] {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// LedgerDocumentTypes returns the document types in a stable order
+func LedgerDocumentTypes() []string {
+	types := make([]string, 0, len(LedgerDocumentRoles))
+	for t := range LedgerDocumentRoles {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// LedgerAccount is an account of the company's chart of accounts (catálogo de
+// cuentas). Only postable accounts take journal lines; the others group them.
+type LedgerAccount struct {
+	ID          string    `json:"id"`
+	CompanyID   string    `json:"company_id"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	AccountType string    `json:"account_type"`
+	ParentID    *string   `json:"parent_id,omitempty"`
+	Postable    bool      `json:"postable"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CreateLedgerAccountRequest adds an account to the chart
+type CreateLedgerAccountRequest struct {
+	Code        string  `json:"code" binding:"required"`
+	Name        string  `json:"name" binding:"required"`
+	AccountType string  `json:"account_type" binding:"required"`
+	ParentID    *string `json:"parent_id"`
+	Postable    *bool   `json:"postable"` // Defaults to true
+}
+
+// Validate checks the code, name and account type
+func (r *CreateLedgerAccountRequest) Validate() error {
+	r.Code = strings.TrimSpace(r.Code)
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Code == "" {
+		return fmt.Errorf("code is required")
+	}
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	return validateAccountType(r.AccountType)
+}
+
+// UpdateLedgerAccountRequest changes an account. The code and type are fixed
+// once created. Omitted fields are left as they are.
+type UpdateLedgerAccountRequest struct {
+	Name     *string `json:"name"`
+	ParentID *string `json:"parent_id"`
+	Postable *bool   `json:"postable"`
+	Active   *bool   `json:"active"`
+}
+
+// Apply validates the changes against the current account and applies them
+func (r *UpdateLedgerAccountRequest) Apply(a *LedgerAccount) error {
+	if r.Name != nil {
+		name := strings.TrimSpace(*r.Name)
+		if name == "" {
+			return fmt.Errorf("name cannot be empty")
+		}
+		a.Name = name
+	}
+	if r.ParentID != nil {
+		if *r.ParentID == "" {
+			a.ParentID = nil
+		} else {
+			if *r.ParentID == a.ID {
+				return fmt.Errorf("an account cannot be its own parent")
+			}
+			a.ParentID = r.ParentID
+		}
+	}
+	if r.Postable != nil {
+		a.Postable = *r.Postable
+	}
+	if r.Active != nil {
+		a.Active = *r.Active
+	}
+	return nil
+}
+
+func validateAccountType(accountType string) error {
+	for _, t := range accountTypes {
+		if t == accountType {
+			return nil
+		}
+	}
+	return fmt.Errorf("account_type must be one of: %s", strings.Join(accountTypes, ", "))
+}
+
+// LedgerPostingRule maps a document type's role to an account
+type LedgerPostingRule struct {
+	ID           string    `json:"id"`
+	DocumentType string    `json:"document_type"`
+	Role         string    `json:"role"`
+	AccountID    string    `json:"account_id"`
+	AccountCode  string    `json:"account_code"`
+	AccountName  string    `json:"account_name"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SetPostingRuleRequest creates or replaces the rule of a document type and role
+type SetPostingRuleRequest struct {
+	DocumentType string `json:"document_type" binding:"required"`
+	Role         string `json:"role" binding:"required"`
+	AccountID    string `json:"account_id" binding:"required"`
+}
+
+// Validate checks the document type and role
+func (r *SetPostingRuleRequest) Validate() error {
+	if r.DocumentType != LedgerDocDefault && LedgerDocumentRoles[r.DocumentType] == nil {
+		return fmt.Errorf("document_type must be default or one of: %s", strings.Join(LedgerDocumentTypes(), ", "))
+	}
+	if !IsValidLedgerRule(r.DocumentType, r.Role) {
+		return fmt.Errorf("role %q is not posted by document type %s", r.Role, r.DocumentType)
+	}
+	return nil
+}
+
+// LedgerSettings turns automatic posting on for a company. Documents dated
+// before the start date are never posted; documents dated in a closed fiscal
+// year are posted on the day after ClosedThrough.
+type LedgerSettings struct {
+	CompanyID     string    `json:"company_id"`
+	Enabled       bool      `json:"enabled"`
+	StartDate     DateOnly  `json:"start_date"`
+	ClosedThrough *DateOnly `json:"closed_through,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// UpdateLedgerSettingsRequest changes the ledger settings. Omitted fields are
+// left as they are.
+type UpdateLedgerSettingsRequest struct {
+	Enabled   *bool     `json:"enabled"`
+	StartDate *DateOnly `json:"start_date"`
+}
+
+// JournalEntry is a balanced journal entry (partida) posted from a document,
+// or the reversal of one when the document was invalidated or voided
+type JournalEntry struct {
+	ID              string        `json:"id"`
+	CompanyID       string        `json:"company_id"`
+	EntryNumber     int64         `json:"entry_number"`
+	EntryDate       string        `json:"entry_date"`
+	Description     string        `json:"description"`
+	SourceType      string        `json:"source_type"`
+	SourceID        string        `json:"source_id"`
+	DocumentType    string        `json:"document_type"`
+	Reference       *string       `json:"reference,omitempty"` // Numero de control or document number
+	Reversal        bool          `json:"reversal"`
+	ReversesEntryID *string       `json:"reverses_entry_id,omitempty"`
+	EstablishmentID *string       `json:"establishment_id,omitempty"` // Cost center
+	TotalDebit      float64       `json:"total_debit"`
+	TotalCredit     float64       `json:"total_credit"`
+	CreatedAt       time.Time     `json:"created_at"`
+	Lines           []JournalLine `json:"lines"`
+}
+
+// JournalLine is a debit or a credit to one account
+type JournalLine struct {
+	LineNumber  int     `json:"line_number"`
+	AccountID   string  `json:"account_id"`
+	AccountCode string  `json:"account_code"`
+	AccountName string  `json:"account_name"`
+	Role        string  `json:"role"`
+	Debit       float64 `json:"debit"`
+	Credit      float64 `json:"credit"`
+}
+
+// JournalFilters narrows GET /v1/ledger/journal
+type JournalFilters struct {
+	FromDate        string // YYYY-MM-DD, inclusive
+	ToDate          string // YYYY-MM-DD, inclusive
+	SourceType      string
+	SourceID        string
+	AccountID       string // Entries with a line on the account
+	EstablishmentID string
+	Limit           int
+	Offset          int
+}
+
+// LedgerPostingResult counts the entries a posting pass wrote
+type LedgerPostingResult struct {
+	Posted   int `json:"posted"`
+	Reversed int `json:"reversed"`
+}
+
+// LedgerClosing is a closed fiscal year. Its closing entries, one per cost
+// center, move the year's income and expense balances to retained earnings.
+type LedgerClosing struct {
+	ID         string    `json:"id"`
+	CompanyID  string    `json:"company_id"`
+	FiscalYear int       `json:"fiscal_year"`
+	NetIncome  float64   `json:"net_income"`
+	EntryCount int       `json:"entry_count"`
+	ClosedBy   *string   `json:"closed_by,omitempty"`
+	ClosedAt   time.Time `json:"closed_at"`
+}
+
+// CloseFiscalYearRequest closes a fiscal year (January to December)
+type CloseFiscalYearRequest struct {
+	FiscalYear int `json:"fiscal_year" binding:"required"`
+}
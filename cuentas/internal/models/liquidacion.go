@@ -0,0 +1,165 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Liquidacion represents a DTE 08 (Comprobante de Liquidación) summarizing the
+// DTEs sold on consignment through a third-party seller
+type Liquidacion struct {
+	ID              string `json:"id"`
+	CompanyID       string `json:"company_id"`
+	EstablishmentID string `json:"establishment_id"`
+	PointOfSaleID   string `json:"point_of_sale_id"`
+	ClientID        string `json:"client_id"` // Third-party seller (receptor)
+
+	// DTE identifiers
+	CodigoGeneracion string `json:"codigo_generacion"`
+	NumeroControl    string `json:"numero_control"`
+	TipoDte          string `json:"tipo_dte"` // Always "08"
+	Ambiente         string `json:"ambiente"`
+
+	// Liquidation totals
+	TotalNoSuj          float64 `json:"total_no_suj"`
+	TotalExenta         float64 `json:"total_exenta"`
+	TotalGravada        float64 `json:"total_gravada"`
+	TotalExportacion    float64 `json:"total_exportacion"`
+	SubTotalVentas      float64 `json:"sub_total_ventas"`
+	TotalIVA            float64 `json:"total_iva"`
+	MontoTotalOperacion float64 `json:"monto_total_operacion"`
+
+	// Seller commission
+	CommissionRate   float64 `json:"commission_rate"`
+	CommissionAmount float64 `json:"commission_amount"`
+	CommissionIVA    float64 `json:"commission_iva"`
+	NetAmount        float64 `json:"net_amount"` // Amount the seller owes after commission
+
+	CondicionOperacion int     `json:"condicion_operacion"`
+	Observaciones      *string `json:"observaciones,omitempty"`
+
+	// Dates
+	FechaEmision       time.Time  `json:"fecha_emision"`
+	FechaProcesamiento *time.Time `json:"fecha_procesamiento,omitempty"`
+
+	// DTE data
+	DteJSON   string `json:"dte_json"`
+	DteSigned string `json:"dte_signed"`
+
+	// Hacienda response
+	HaciendaEstado          *string    `json:"hacienda_estado,omitempty"`
+	HaciendaSelloRecibido   *string    `json:"hacienda_sello_recibido,omitempty"`
+	HaciendaFhProcesamiento *time.Time `json:"hacienda_fh_procesamiento,omitempty"`
+	HaciendaCodigoMsg       *string    `json:"hacienda_codigo_msg,omitempty"`
+	HaciendaDescripcionMsg  *string    `json:"hacienda_descripcion_msg,omitempty"`
+	HaciendaObservaciones   []string   `json:"hacienda_observaciones,omitempty"`
+	HaciendaResponse        *string    `json:"hacienda_response,omitempty"`
+
+	// Contingency
+	ContingencyPeriodID   *string `json:"contingency_period_id,omitempty"`
+	DteTransmissionStatus string  `json:"dte_transmission_status"`
+
+	// Audit
+	CreatedBy   *string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	SubmittedAt *time.Time `json:"submitted_at,omitempty"`
+
+	// Relationships
+	Documents []LiquidacionDocument `json:"documents,omitempty"`
+}
+
+// LiquidacionDocument is one DTE summarized by a liquidación (snapshot from dte_commit_log)
+type LiquidacionDocument struct {
+	ID               string    `json:"id"`
+	LiquidacionID    string    `json:"liquidacion_id"`
+	LineNumber       int       `json:"line_number"`
+	CodigoGeneracion string    `json:"codigo_generacion"`
+	TipoDte          string    `json:"tipo_dte"`        // "01", "03", "05", "06" or "11"
+	TipoGeneracion   int       `json:"tipo_generacion"` // 1 = Físico, 2 = Electrónico
+	NumeroControl    string    `json:"numero_control"`
+	FechaGeneracion  time.Time `json:"fecha_generacion"`
+
+	// Amounts (negative for notas de crédito)
+	VentaNoSuj    float64 `json:"venta_no_suj"`
+	VentaExenta   float64 `json:"venta_exenta"`
+	VentaGravada  float64 `json:"venta_gravada"`
+	Exportaciones float64 `json:"exportaciones"`
+	IvaItem       float64 `json:"iva_item"`
+	ObsItem       string  `json:"obs_item"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateLiquidacionRequest represents the request to create a liquidación (DTE 08)
+type CreateLiquidacionRequest struct {
+	ClientID           string   `json:"client_id" binding:"required"`
+	EstablishmentID    string   `json:"establishment_id" binding:"required"`
+	PointOfSaleID      string   `json:"point_of_sale_id" binding:"required"`
+	CodigosGeneracion  []string `json:"codigos_generacion" binding:"required"`
+	CommissionRate     float64  `json:"commission_rate"`               // Percentage, e.g. 10.00
+	CondicionOperacion int      `json:"condicion_operacion,omitempty"` // 1 = Contado (default), 2 = Crédito, 3 = Otro
+	Observaciones      *string  `json:"observaciones,omitempty"`
+}
+
+// Validate validates the create liquidación request
+func (r *CreateLiquidacionRequest) Validate() error {
+	if strings.TrimSpace(r.ClientID) == "" {
+		return fmt.Errorf("client_id is required")
+	}
+	if strings.TrimSpace(r.EstablishmentID) == "" {
+		return fmt.Errorf("establishment_id is required")
+	}
+	if strings.TrimSpace(r.PointOfSaleID) == "" {
+		return fmt.Errorf("point_of_sale_id is required")
+	}
+	if len(r.CodigosGeneracion) == 0 {
+		return fmt.Errorf("at least one codigo_generacion is required")
+	}
+	if len(r.CodigosGeneracion) > 500 {
+		return fmt.Errorf("a liquidación can reference at most 500 documents")
+	}
+
+	seen := make(map[string]bool, len(r.CodigosGeneracion))
+	for i, codigo := range r.CodigosGeneracion {
+		codigo = strings.ToUpper(strings.TrimSpace(codigo))
+		if codigo == "" {
+			return fmt.Errorf("codigos_generacion[%d] is empty", i)
+		}
+		if seen[codigo] {
+			return fmt.Errorf("codigos_generacion[%d] is duplicated: %s", i, codigo)
+		}
+		seen[codigo] = true
+		r.CodigosGeneracion[i] = codigo
+	}
+
+	if r.CommissionRate < 0 || r.CommissionRate > 100 {
+		return fmt.Errorf("commission_rate must be between 0 and 100")
+	}
+
+	if r.CondicionOperacion == 0 {
+		r.CondicionOperacion = 1
+	}
+	if r.CondicionOperacion < 1 || r.CondicionOperacion > 3 {
+		return fmt.Errorf("condicion_operacion must be 1, 2 or 3")
+	}
+
+	return nil
+}
+
+// Helper methods
+
+// IsProcessed checks if the liquidación was successfully processed by Hacienda
+func (l *Liquidacion) IsProcessed() bool {
+	return l.HaciendaEstado != nil && *l.HaciendaEstado == "PROCESADO"
+}
+
+// IsRejected checks if the liquidación was rejected by Hacienda
+func (l *Liquidacion) IsRejected() bool {
+	return l.HaciendaEstado != nil && *l.HaciendaEstado == "RECHAZADO"
+}
+
+// IsQueuedForContingency checks if the liquidación is waiting in a contingency period
+func (l *Liquidacion) IsQueuedForContingency() bool {
+	return l.ContingencyPeriodID != nil && !l.IsProcessed()
+}
@@ -136,8 +136,9 @@ type NotaDebito struct {
 	VoidedAt    *time.Time `json:"voided_at,omitempty"`
 
 	// Audit
-	CreatedBy *string `json:"created_by,omitempty"`
-	Notes     *string `json:"notes,omitempty"`
+	CreatedBy   *string `json:"created_by,omitempty"`
+	FinalizedBy *string `json:"finalized_by,omitempty"`
+	Notes       *string `json:"notes,omitempty"`
 
 	// Relationships
 	LineItems     []NotaDebitoLineItem     `json:"line_items,omitempty"`
@@ -159,8 +159,9 @@ type NotaCredito struct {
 	VoidedAt    *time.Time `json:"voided_at,omitempty"`
 
 	// Audit
-	CreatedBy *string `json:"created_by,omitempty"`
-	Notes     *string `json:"notes,omitempty"`
+	CreatedBy   *string `json:"created_by,omitempty"`
+	FinalizedBy *string `json:"finalized_by,omitempty"`
+	Notes       *string `json:"notes,omitempty"`
 
 	// Relationships
 	LineItems     []NotaCreditoLineItem     `json:"line_items,omitempty"`
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Payment is money received against a finalized invoice (payments table)
+type Payment struct {
+	ID               string     `json:"id"`
+	CompanyID        string     `json:"company_id"`
+	InvoiceID        string     `json:"invoice_id"`
+	InvoiceNumber    string     `json:"invoice_number"`
+	ClientID         string     `json:"client_id"`
+	ClientName       string     `json:"client_name"`
+	Amount           float64    `json:"amount"`
+	PaymentMethod    string     `json:"payment_method"` // CAT-017 code
+	PaymentReference *string    `json:"payment_reference,omitempty"`
+	PaymentDate      time.Time  `json:"payment_date"`
+	Notes            *string    `json:"notes,omitempty"`
+	CreatedBy        *string    `json:"created_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	VoidedAt         *time.Time `json:"voided_at,omitempty"`
+	VoidedBy         *string    `json:"voided_by,omitempty"`
+	VoidReason       *string    `json:"void_reason,omitempty"`
+}
+
+// IsVoided reports whether the payment was voided
+func (p *Payment) IsVoided() bool {
+	return p.VoidedAt != nil
+}
+
+// PaymentFilters narrows GET /v1/payments
+type PaymentFilters struct {
+	ClientID      string
+	InvoiceID     string
+	FromDate      string // YYYY-MM-DD, inclusive
+	ToDate        string // YYYY-MM-DD, inclusive
+	IncludeVoided bool
+	Limit         int
+	Offset        int
+}
+
+// VoidPaymentRequest voids a payment entered by mistake
+type VoidPaymentRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// Validate checks the reason
+func (r *VoidPaymentRequest) Validate() error {
+	r.Reason = strings.TrimSpace(r.Reason)
+	if r.Reason == "" {
+		return fmt.Errorf("reason is required")
+	}
+	if len(r.Reason) > 500 {
+		return fmt.Errorf("reason must be at most 500 characters")
+	}
+	return nil
+}
+
+// Aging report groupings
+const (
+	AgingGroupByClient        = "client"
+	AgingGroupByEstablishment = "establishment"
+)
+
+// AgingBuckets holds outstanding balances by days past due. Invoices not yet
+// due count as 0-30.
+type AgingBuckets struct {
+	Days0To30  float64 `json:"days_0_30"`
+	Days31To60 float64 `json:"days_31_60"`
+	Days61To90 float64 `json:"days_61_90"`
+	Over90     float64 `json:"over_90"`
+	Total      float64 `json:"total"`
+}
+
+// Add puts an outstanding amount in the bucket for its days past due
+func (b *AgingBuckets) Add(daysPastDue int, amount float64) {
+	switch {
+	case daysPastDue <= 30:
+		b.Days0To30 += amount
+	case daysPastDue <= 60:
+		b.Days31To60 += amount
+	case daysPastDue <= 90:
+		b.Days61To90 += amount
+	default:
+		b.Over90 += amount
+	}
+	b.Total += amount
+}
+
+// AgingRow is one client, supplier or establishment in the aging report
+type AgingRow struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	InvoiceCount int    `json:"invoice_count"`
+	AgingBuckets
+}
+
+// Aging report ledgers
+const (
+	AgingLedgerReceivable = "receivable"
+	AgingLedgerPayable    = "payable"
+)
+
+// AgingReport is the accounts receivable or payable aging as of a date
+type AgingReport struct {
+	Ledger   string       `json:"ledger"`
+	AsOfDate string       `json:"as_of_date"`
+	GroupBy  string       `json:"group_by"`
+	Rows     []AgingRow   `json:"rows"`
+	Totals   AgingBuckets `json:"totals"`
+}
+
+// AgingFilters narrows the aging report
+type AgingFilters struct {
+	AsOfDate        time.Time
+	GroupBy         string
+	ClientID        string
+	EstablishmentID string
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"cuentas/internal/codigos"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -19,10 +20,10 @@ type Purchase struct {
 	PurchaseType   string    `json:"purchase_type"` // 'fse', 'regular', 'import', 'other'
 	PurchaseDate   time.Time `json:"purchase_date"`
 
-	// Supplier reference (for regular purchases from registered suppliers)
+	// Supplier reference (suppliers table)
 	SupplierID *string `json:"supplier_id,omitempty"`
 
-	// FSE-specific: Informal supplier info (when supplier_id is NULL)
+	// Supplier snapshot as printed on the DTE (copied from the registry when supplier_id is set)
 	SupplierName              *string `json:"supplier_name,omitempty"`
 	SupplierDocumentType      *string `json:"supplier_document_type,omitempty"` // '36' (NIT), '13' (DUI), '37' (Otro), etc.
 	SupplierDocumentNumber    *string `json:"supplier_document_number,omitempty"`
@@ -39,7 +40,10 @@ type Purchase struct {
 	Subtotal           float64 `json:"subtotal"`
 	TotalDiscount      float64 `json:"total_discount"`
 	DiscountPercentage float64 `json:"discount_percentage"`
-	TotalTaxes         float64 `json:"total_taxes"`         // For regular purchases with IVA
+	TotalTaxes         float64 `json:"total_taxes"`     // For regular purchases with IVA
+	TotalNoSujeta      float64 `json:"total_no_sujeta"` // Resumen split of a received DTE
+	TotalExenta        float64 `json:"total_exenta"`
+	TotalGravada       float64 `json:"total_gravada"`
 	IVARetained        float64 `json:"iva_retained"`        // IVA retenido
 	IncomeTaxRetained  float64 `json:"income_tax_retained"` // Retención de renta
 	Total              float64 `json:"total"`
@@ -62,7 +66,8 @@ type Purchase struct {
 	DteHaciendaResponse *string    `json:"dte_hacienda_response,omitempty"`
 	DteSelloRecibido    *string    `json:"dte_sello_recibido,omitempty"`
 	DteSubmittedAt      *time.Time `json:"dte_submitted_at,omitempty"`
-	DteType             string     `json:"dte_type"` // '14' for FSE, future types
+	DteType             string     `json:"dte_type"`                        // '14' for FSE; '01' or '03' when received from the supplier
+	DteCodigoGeneracion *string    `json:"dte_codigo_generacion,omitempty"` // Supplier's codigoGeneracion (received DTEs)
 
 	// Status
 	Status string `json:"status"` // 'draft', 'finalized', 'voided'
@@ -179,7 +184,8 @@ type CreateFSERequest struct {
 	EstablishmentID    string                     `json:"establishment_id" binding:"required"`
 	PointOfSaleID      string                     `json:"point_of_sale_id" binding:"required"`
 	PurchaseDate       DateOnly                   `json:"purchase_date" binding:"required"`
-	Supplier           SupplierInfo               `json:"supplier" binding:"required"`
+	SupplierID         *string                    `json:"supplier_id,omitempty"` // Registered supplier; fills the supplier block
+	Supplier           *SupplierInfo              `json:"supplier,omitempty"`    // Required without supplier_id
 	LineItems          []CreateFSELineItemRequest `json:"line_items" binding:"required,min=1"`
 	Payment            PaymentInfo                `json:"payment" binding:"required"`
 	DiscountPercentage float64                    `json:"discount_percentage"`
@@ -203,9 +209,18 @@ func (r *CreateFSERequest) Validate() error {
 		return fmt.Errorf("purchase_date is required")
 	}
 
-	// Validate supplier
-	if err := r.Supplier.Validate(); err != nil {
-		return fmt.Errorf("supplier validation failed: %w", err)
+	// Validate supplier: a registered supplier or the embedded block
+	if r.SupplierID != nil && strings.TrimSpace(*r.SupplierID) != "" {
+		if r.Supplier != nil {
+			return fmt.Errorf("provide supplier_id or supplier, not both")
+		}
+	} else {
+		if r.Supplier == nil {
+			return fmt.Errorf("supplier_id or supplier is required")
+		}
+		if err := r.Supplier.Validate(); err != nil {
+			return fmt.Errorf("supplier validation failed: %w", err)
+		}
 	}
 
 	// Validate line items
@@ -273,6 +288,104 @@ func (r *CreateFSELineItemRequest) Validate() error {
 	return nil
 }
 
+// CreateReceivedDTEPurchaseRequest loads a Factura or CCF a supplier issued to
+// the company as a regular purchase
+type CreateReceivedDTEPurchaseRequest struct {
+	EstablishmentID          string                   `json:"establishment_id" binding:"required"`
+	PointOfSaleID            string                   `json:"point_of_sale_id" binding:"required"`
+	InventoryEstablishmentID *string                  `json:"inventory_establishment_id,omitempty"` // Location receiving the goods; defaults to establishment_id
+	Document                 json.RawMessage          `json:"document" binding:"required"`          // DTE JSON object, or the signed JWS as a string
+	SupplierID               *string                  `json:"supplier_id,omitempty"`                // Defaults to the registered supplier with the emisor's NIT
+	Lines                    []ReceivedDTELineMapping `json:"lines,omitempty"`
+	Notes                    *string                  `json:"notes,omitempty"`
+}
+
+// ReceivedDTELineMapping ties a cuerpoDocumento line to an inventory item.
+// Lines without a mapping are matched by codigo against item SKUs; lines
+// left unmatched are recorded without an inventory effect.
+type ReceivedDTELineMapping struct {
+	NumItem int    `json:"num_item" binding:"required"`
+	ItemID  string `json:"item_id" binding:"required"`
+}
+
+// ReceivedDTEDocument is a supplier DTE after parsing, schema validation and
+// confirmation with Hacienda, reduced to what a purchase records
+type ReceivedDTEDocument struct {
+	TipoDte          string
+	CodigoGeneracion string
+	NumeroControl    string
+	FechaEmision     time.Time
+	SelloRecibido    string
+	EstadoHacienda   string
+	HaciendaResponse []byte // Consulta response, stored as dte_hacienda_response
+	DocumentJSON     []byte
+	Signed           *string
+
+	Supplier    SupplierInfo // Emisor block; DocumentNumber is the formatted NIT
+	ReceptorNIT *string      // Formatted; nil when the document does not identify the receptor
+
+	Lines []ReceivedDTELine
+
+	TotalNoSujeta     float64
+	TotalExenta       float64
+	TotalGravada      float64
+	Subtotal          float64 // subTotalVentas
+	TotalDiscount     float64
+	TotalTaxes        float64
+	IVARetained       float64
+	IncomeTaxRetained float64
+	Total             float64 // totalPagar
+
+	PaymentCondition int
+	PaymentMethod    *string
+	PaymentReference *string
+	PaymentTerm      *string
+	PaymentPeriod    *int
+}
+
+// ReceivedDTELine is one cuerpoDocumento line of a received DTE
+type ReceivedDTELine struct {
+	NumItem     int
+	TipoItem    int
+	Codigo      *string
+	Descripcion string
+	Cantidad    float64
+	UniMedida   int
+	PrecioUni   float64
+	MontoDescu  float64
+	Amount      float64 // Cost to the company: excludes IVA on a CCF, includes it on a Factura
+	IVA         float64
+}
+
+// Validate validates the received DTE purchase request
+func (r *CreateReceivedDTEPurchaseRequest) Validate() error {
+	if strings.TrimSpace(r.EstablishmentID) == "" {
+		return fmt.Errorf("establishment_id is required")
+	}
+	if strings.TrimSpace(r.PointOfSaleID) == "" {
+		return fmt.Errorf("point_of_sale_id is required")
+	}
+	if len(r.Document) == 0 {
+		return fmt.Errorf("document is required")
+	}
+
+	seen := make(map[int]bool, len(r.Lines))
+	for i, line := range r.Lines {
+		if line.NumItem < 1 {
+			return fmt.Errorf("lines[%d]: num_item must be greater than 0", i)
+		}
+		if strings.TrimSpace(line.ItemID) == "" {
+			return fmt.Errorf("lines[%d]: item_id is required", i)
+		}
+		if seen[line.NumItem] {
+			return fmt.Errorf("lines[%d]: num_item %d is mapped more than once", i, line.NumItem)
+		}
+		seen[line.NumItem] = true
+	}
+
+	return nil
+}
+
 // Validate validates the supplier info
 func (s *SupplierInfo) Validate() error {
 	if strings.TrimSpace(s.Name) == "" {
@@ -362,6 +475,11 @@ func (p *Purchase) IsFSE() bool {
 	return p.PurchaseType == "fse"
 }
 
+// IsReceivedDTE checks if this purchase was loaded from a supplier's DTE
+func (p *Purchase) IsReceivedDTE() bool {
+	return p.DteCodigoGeneracion != nil && *p.DteCodigoGeneracion != ""
+}
+
 // IsRegular checks if this is a regular purchase
 func (p *Purchase) IsRegular() bool {
 	return p.PurchaseType == "regular"
@@ -55,6 +55,10 @@ type Retention struct {
 	HaciendaObservaciones   []string   `json:"hacienda_observaciones,omitempty"`
 	HaciendaResponse        *string    `json:"hacienda_response,omitempty"`
 
+	// Contingency
+	ContingencyPeriodID   *string `json:"contingency_period_id,omitempty"`
+	DteTransmissionStatus string  `json:"dte_transmission_status"`
+
 	// Audit
 	CreatedBy   *string    `json:"created_by,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
@@ -93,6 +97,11 @@ func (r *Retention) IsRejected() bool {
 	return r.HaciendaEstado != nil && *r.HaciendaEstado == "RECHAZADO"
 }
 
+// IsQueuedForContingency checks if the retention is waiting in a contingency period
+func (r *Retention) IsQueuedForContingency() bool {
+	return r.ContingencyPeriodID != nil && !r.IsProcessed()
+}
+
 // HasSupplierID checks if retention references a registered supplier
 func (r *Retention) HasSupplierID() bool {
 	return r.SupplierID != nil && *r.SupplierID != ""
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Statement entry types
+const (
+	StatementEntryInvoice     = "invoice"
+	StatementEntryNotaDebito  = "nota_debito"
+	StatementEntryNotaCredito = "nota_credito"
+	StatementEntryPayment     = "payment"
+)
+
+// StatementApplication is the part of a nota or payment applied to one CCF or factura
+type StatementApplication struct {
+	InvoiceID     string  `json:"invoice_id"`
+	InvoiceNumber string  `json:"invoice_number"`
+	Amount        float64 `json:"amount"`
+}
+
+// StatementEntry is one line of a client statement. Invoices and notas de
+// débito are charges; notas de crédito and payments are credits.
+type StatementEntry struct {
+	Date           time.Time              `json:"date"`
+	Type           string                 `json:"type"`
+	DocumentID     string                 `json:"document_id"`
+	DocumentNumber string                 `json:"document_number"`
+	DteType        *string                `json:"dte_type,omitempty"`
+	NumeroControl  *string                `json:"numero_control,omitempty"`
+	Reference      *string                `json:"reference,omitempty"` // Payment reference
+	AppliedTo      []StatementApplication `json:"applied_to,omitempty"`
+	Charge         float64                `json:"charge"`
+	Credit         float64                `json:"credit"`
+	Balance        float64                `json:"balance"`
+}
+
+// StatementOpenDocument is a CCF or factura with a balance at the end of the
+// period, after the notas and payments applied to it
+type StatementOpenDocument struct {
+	InvoiceID     string  `json:"invoice_id"`
+	InvoiceNumber string  `json:"invoice_number"`
+	DteType       *string `json:"dte_type,omitempty"`
+	NumeroControl *string `json:"numero_control,omitempty"`
+	Date          string  `json:"date"`
+	DueDate       string  `json:"due_date"`
+	DaysPastDue   int     `json:"days_past_due"`
+	Total         float64 `json:"total"`
+	DebitNotes    float64 `json:"debit_notes"`
+	CreditNotes   float64 `json:"credit_notes"`
+	Payments      float64 `json:"payments"`
+	Balance       float64 `json:"balance"`
+}
+
+// ClientStatement is a client's estado de cuenta over a date range
+type ClientStatement struct {
+	CompanyID       string                  `json:"company_id"`
+	CompanyName     string                  `json:"company_name"`
+	ClientID        string                  `json:"client_id"`
+	ClientName      string                  `json:"client_name"`
+	ClientLegalName string                  `json:"client_legal_name"`
+	ClientEmail     *string                 `json:"client_email,omitempty"`
+	FromDate        string                  `json:"from_date"`
+	ToDate          string                  `json:"to_date"`
+	OpeningBalance  float64                 `json:"opening_balance"`
+	TotalCharges    float64                 `json:"total_charges"`
+	TotalCredits    float64                 `json:"total_credits"`
+	ClosingBalance  float64                 `json:"closing_balance"`
+	Entries         []StatementEntry        `json:"entries"`
+	OpenDocuments   []StatementOpenDocument `json:"open_documents"`
+	GeneratedAt     time.Time               `json:"generated_at"`
+}
+
+// StatementPeriod is the inclusive date range of a statement
+type StatementPeriod struct {
+	FromDate time.Time
+	ToDate   time.Time
+}
+
+// ParseStatementPeriod parses from_date and to_date (YYYY-MM-DD). to_date
+// defaults to today and from_date to the first day of to_date's month.
+func ParseStatementPeriod(fromDate, toDate string) (*StatementPeriod, error) {
+	now := time.Now()
+	p := &StatementPeriod{ToDate: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)}
+
+	if toDate != "" {
+		t, err := time.Parse("2006-01-02", toDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to_date format, use YYYY-MM-DD")
+		}
+		p.ToDate = t
+	}
+	p.FromDate = time.Date(p.ToDate.Year(), p.ToDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if fromDate != "" {
+		t, err := time.Parse("2006-01-02", fromDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from_date format, use YYYY-MM-DD")
+		}
+		p.FromDate = t
+	}
+
+	if p.FromDate.After(p.ToDate) {
+		return nil, fmt.Errorf("from_date must be on or before to_date")
+	}
+	return p, nil
+}
+
+// EmailStatementRequest mails a client statement as a PDF
+type EmailStatementRequest struct {
+	FromDate  string  `json:"from_date"`
+	ToDate    string  `json:"to_date"`
+	Recipient *string `json:"recipient"` // Defaults to the client's correo
+	Language  string  `json:"language"`  // es (default) or en
+}
+
+// Validate checks the recipient and language
+func (r *EmailStatementRequest) Validate() error {
+	if r.Recipient != nil {
+		trimmed := strings.TrimSpace(*r.Recipient)
+		if trimmed == "" {
+			r.Recipient = nil
+		} else {
+			if _, err := mail.ParseAddress(trimmed); err != nil {
+				return fmt.Errorf("recipient is not a valid email address")
+			}
+			r.Recipient = &trimmed
+		}
+	}
+	if r.Language != "" && r.Language != "es" && r.Language != "en" {
+		return fmt.Errorf("language must be es or en")
+	}
+	return nil
+}
+
+// StatementEmailResult reports a mailed statement
+type StatementEmailResult struct {
+	Recipient      string    `json:"recipient"`
+	MessageID      string    `json:"message_id"`
+	FromDate       string    `json:"from_date"`
+	ToDate         string    `json:"to_date"`
+	ClosingBalance float64   `json:"closing_balance"`
+	SentAt         time.Time `json:"sent_at"`
+}
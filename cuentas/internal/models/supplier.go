@@ -0,0 +1,335 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"cuentas/internal/codigos"
+	"cuentas/internal/tools"
+)
+
+// Supplier document types (CAT-022)
+var supplierDocumentTypes = []string{"36", "13", "02", "03", "37"}
+
+// Supplier nationalities, as printed in the inventory register
+const (
+	SupplierNacional   = "Nacional"
+	SupplierExtranjero = "Extranjero"
+)
+
+// Supplier is a registered supplier (proveedor)
+type Supplier struct {
+	ID                string    `json:"id"`
+	CompanyID         string    `json:"company_id"`
+	Name              string    `json:"name"`
+	LegalName         *string   `json:"legal_name,omitempty"`
+	DocumentType      string    `json:"document_type"` // '36' (NIT), '13' (DUI), '02', '03', '37'
+	DocumentNumber    *string   `json:"document_number,omitempty"`
+	NRC               *string   `json:"nrc,omitempty"`
+	ActivityCode      *string   `json:"activity_code,omitempty"`
+	ActivityDesc      *string   `json:"activity_description,omitempty"`
+	Nationality       string    `json:"nationality"`
+	AddressDept       *string   `json:"address_department,omitempty"`
+	AddressMuni       *string   `json:"address_municipality,omitempty"`
+	AddressComplement *string   `json:"address_complement,omitempty"`
+	Phone             *string   `json:"phone,omitempty"`
+	Email             *string   `json:"email,omitempty"`
+	ContactName       *string   `json:"contact_name,omitempty"`
+	PaymentTermDays   int       `json:"payment_term_days"`
+	Active            bool      `json:"active"`
+	Notes             *string   `json:"notes,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// NIT returns the supplier's NIT, or nil when identified by another document
+func (s *Supplier) NIT() *string {
+	if s.DocumentType == "36" && s.DocumentNumber != nil && *s.DocumentNumber != "" {
+		return s.DocumentNumber
+	}
+	return nil
+}
+
+// FSESupplierInfo builds the sujeto excluido block of an FSE from the registry.
+// FSE needs the activity and the address, which are optional on a supplier.
+func (s *Supplier) FSESupplierInfo() (*SupplierInfo, error) {
+	info := &SupplierInfo{
+		Name:           s.Name,
+		DocumentType:   s.DocumentType,
+		DocumentNumber: s.DocumentNumber,
+		NRC:            s.NRC,
+		Phone:          s.Phone,
+		Email:          s.Email,
+	}
+	if s.ActivityCode != nil {
+		info.ActivityCode = *s.ActivityCode
+	}
+	if s.ActivityDesc != nil {
+		info.ActivityDesc = *s.ActivityDesc
+	}
+	if s.AddressDept != nil {
+		info.Address.Department = *s.AddressDept
+	}
+	if s.AddressMuni != nil {
+		info.Address.Municipality = *s.AddressMuni
+	}
+	if s.AddressComplement != nil {
+		info.Address.Complement = *s.AddressComplement
+	}
+	if err := info.Validate(); err != nil {
+		return nil, fmt.Errorf("supplier %s is missing FSE data: %w", s.Name, err)
+	}
+	return info, nil
+}
+
+// CreateSupplierRequest registers a supplier
+type CreateSupplierRequest struct {
+	Name              string  `json:"name" binding:"required"`
+	LegalName         *string `json:"legal_name"`
+	DocumentType      string  `json:"document_type" binding:"required"`
+	DocumentNumber    *string `json:"document_number"`
+	NRC               *string `json:"nrc"`
+	ActivityCode      *string `json:"activity_code"`
+	ActivityDesc      *string `json:"activity_description"`
+	Nationality       string  `json:"nationality"` // Defaults to Nacional
+	AddressDept       *string `json:"address_department"`
+	AddressMuni       *string `json:"address_municipality"`
+	AddressComplement *string `json:"address_complement"`
+	Phone             *string `json:"phone"`
+	Email             *string `json:"email"`
+	ContactName       *string `json:"contact_name"`
+	PaymentTermDays   int     `json:"payment_term_days"`
+	Notes             *string `json:"notes"`
+}
+
+// Validate checks the identification, NRC, email and credit term
+func (r *CreateSupplierRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if r.Nationality == "" {
+		r.Nationality = SupplierNacional
+	}
+	return validateSupplierFields(r.DocumentType, r.DocumentNumber, r.NRC, r.Email, r.Nationality, r.PaymentTermDays)
+}
+
+// UpdateSupplierRequest changes a supplier. Omitted fields are left as they are.
+type UpdateSupplierRequest struct {
+	Name              *string `json:"name"`
+	LegalName         *string `json:"legal_name"`
+	DocumentType      *string `json:"document_type"`
+	DocumentNumber    *string `json:"document_number"`
+	NRC               *string `json:"nrc"`
+	ActivityCode      *string `json:"activity_code"`
+	ActivityDesc      *string `json:"activity_description"`
+	Nationality       *string `json:"nationality"`
+	AddressDept       *string `json:"address_department"`
+	AddressMuni       *string `json:"address_municipality"`
+	AddressComplement *string `json:"address_complement"`
+	Phone             *string `json:"phone"`
+	Email             *string `json:"email"`
+	ContactName       *string `json:"contact_name"`
+	PaymentTermDays   *int    `json:"payment_term_days"`
+	Active            *bool   `json:"active"`
+	Notes             *string `json:"notes"`
+}
+
+// Apply validates the changes against the current supplier and applies them
+func (r *UpdateSupplierRequest) Apply(s *Supplier) error {
+	if r.Name != nil {
+		name := strings.TrimSpace(*r.Name)
+		if name == "" {
+			return fmt.Errorf("name cannot be empty")
+		}
+		s.Name = name
+	}
+	if r.LegalName != nil {
+		s.LegalName = r.LegalName
+	}
+	if r.DocumentType != nil {
+		s.DocumentType = *r.DocumentType
+	}
+	if r.DocumentNumber != nil {
+		s.DocumentNumber = r.DocumentNumber
+	}
+	if r.NRC != nil {
+		s.NRC = r.NRC
+	}
+	if r.ActivityCode != nil {
+		s.ActivityCode = r.ActivityCode
+	}
+	if r.ActivityDesc != nil {
+		s.ActivityDesc = r.ActivityDesc
+	}
+	if r.Nationality != nil {
+		s.Nationality = *r.Nationality
+	}
+	if r.AddressDept != nil {
+		s.AddressDept = r.AddressDept
+	}
+	if r.AddressMuni != nil {
+		s.AddressMuni = r.AddressMuni
+	}
+	if r.AddressComplement != nil {
+		s.AddressComplement = r.AddressComplement
+	}
+	if r.Phone != nil {
+		s.Phone = r.Phone
+	}
+	if r.Email != nil {
+		s.Email = r.Email
+	}
+	if r.ContactName != nil {
+		s.ContactName = r.ContactName
+	}
+	if r.PaymentTermDays != nil {
+		s.PaymentTermDays = *r.PaymentTermDays
+	}
+	if r.Active != nil {
+		s.Active = *r.Active
+	}
+	if r.Notes != nil {
+		s.Notes = r.Notes
+	}
+	return validateSupplierFields(s.DocumentType, s.DocumentNumber, s.NRC, s.Email, s.Nationality, s.PaymentTermDays)
+}
+
+func validateSupplierFields(documentType string, documentNumber, nrc, email *string, nationality string, paymentTermDays int) error {
+	if !contains(supplierDocumentTypes, documentType) {
+		return fmt.Errorf("invalid document_type: must be one of %v", supplierDocumentTypes)
+	}
+	if documentNumber != nil && *documentNumber != "" {
+		switch documentType {
+		case "36":
+			if !tools.ValidateNIT(*documentNumber) {
+				return fmt.Errorf("invalid NIT format, must be XXXX-XXXXXX-XXX-X")
+			}
+		case "13":
+			if !tools.ValidateDUI(*documentNumber) {
+				return fmt.Errorf("invalid DUI format, must be XXXXXXXX-X")
+			}
+		}
+	} else if documentType == "36" || documentType == "13" {
+		return fmt.Errorf("document_number is required for document_type %s", documentType)
+	}
+	if nrc != nil && *nrc != "" && !tools.ValidateNRC(*nrc) {
+		return fmt.Errorf("invalid nrc format, must be XXXXXX-X")
+	}
+	if email != nil && *email != "" {
+		if _, err := mail.ParseAddress(*email); err != nil {
+			return fmt.Errorf("invalid email address")
+		}
+	}
+	if nationality != SupplierNacional && nationality != SupplierExtranjero {
+		return fmt.Errorf("nationality must be %s or %s", SupplierNacional, SupplierExtranjero)
+	}
+	if paymentTermDays < 0 {
+		return fmt.Errorf("payment_term_days cannot be negative")
+	}
+	return nil
+}
+
+// SupplierFilters narrows GET /v1/suppliers
+type SupplierFilters struct {
+	Search          string // Name, legal name or document number
+	IncludeInactive bool
+	Limit           int
+	Offset          int
+}
+
+// ============================================
+// ACCOUNTS PAYABLE
+// ============================================
+
+// SupplierPayment is money paid to a supplier against a finalized purchase
+type SupplierPayment struct {
+	ID               string     `json:"id"`
+	CompanyID        string     `json:"company_id"`
+	PurchaseID       string     `json:"purchase_id"`
+	PurchaseNumber   string     `json:"purchase_number"`
+	SupplierID       *string    `json:"supplier_id,omitempty"`
+	SupplierName     string     `json:"supplier_name"`
+	Amount           float64    `json:"amount"`
+	PaymentMethod    string     `json:"payment_method"` // CAT-017 code
+	PaymentReference *string    `json:"payment_reference,omitempty"`
+	PaymentDate      time.Time  `json:"payment_date"`
+	Notes            *string    `json:"notes,omitempty"`
+	CreatedBy        *string    `json:"created_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	VoidedAt         *time.Time `json:"voided_at,omitempty"`
+	VoidedBy         *string    `json:"voided_by,omitempty"`
+	VoidReason       *string    `json:"void_reason,omitempty"`
+}
+
+// CreateSupplierPaymentRequest records a payment to a supplier
+type CreateSupplierPaymentRequest struct {
+	Amount           float64   `json:"amount" binding:"required"`
+	PaymentMethod    string    `json:"payment_method" binding:"required"`
+	PaymentReference *string   `json:"payment_reference"`
+	PaymentDate      *DateOnly `json:"payment_date"` // Defaults to today
+	Notes            *string   `json:"notes"`
+}
+
+// Validate checks the amount and payment method
+func (r *CreateSupplierPaymentRequest) Validate() error {
+	if r.Amount <= 0 {
+		return fmt.Errorf("amount must be greater than 0")
+	}
+	if !codigos.IsValidPaymentMethod(r.PaymentMethod) {
+		return fmt.Errorf("invalid payment_method: must be a valid CAT-017 code")
+	}
+	if r.PaymentDate == nil || r.PaymentDate.IsZero() {
+		now := time.Now()
+		r.PaymentDate = &DateOnly{Time: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)}
+	}
+	return nil
+}
+
+// SupplierPaymentFilters narrows GET /v1/supplier-payments
+type SupplierPaymentFilters struct {
+	SupplierID    string
+	PurchaseID    string
+	FromDate      string // YYYY-MM-DD, inclusive
+	ToDate        string // YYYY-MM-DD, inclusive
+	IncludeVoided bool
+	Limit         int
+	Offset        int
+}
+
+// PurchaseDue is a finalized purchase with a balance still to pay
+type PurchaseDue struct {
+	PurchaseID       string  `json:"purchase_id"`
+	PurchaseNumber   string  `json:"purchase_number"`
+	PurchaseType     string  `json:"purchase_type"`
+	DteType          string  `json:"dte_type"`
+	DteNumeroControl *string `json:"dte_numero_control,omitempty"`
+	SupplierID       *string `json:"supplier_id,omitempty"`
+	SupplierName     string  `json:"supplier_name"`
+	PurchaseDate     string  `json:"purchase_date"`
+	DueDate          string  `json:"due_date"`
+	DaysPastDue      int     `json:"days_past_due"` // Negative while not yet due
+	Total            float64 `json:"total"`
+	AmountPaid       float64 `json:"amount_paid"`
+	BalanceDue       float64 `json:"balance_due"`
+}
+
+// PurchasesDueFilters narrows GET /v1/purchases/due
+type PurchasesDueFilters struct {
+	AsOfDate    time.Time
+	SupplierID  string
+	OverdueOnly bool
+}
+
+// Aging report grouping by supplier (accounts payable)
+const AgingGroupBySupplier = "supplier"
+
+// APAgingFilters narrows the accounts payable aging report
+type APAgingFilters struct {
+	AsOfDate        time.Time
+	GroupBy         string // supplier or establishment
+	SupplierID      string
+	EstablishmentID string
+}
@@ -0,0 +1,249 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TaxBookPeriod is the fiscal month an IVA book (libro de IVA) covers
+type TaxBookPeriod struct {
+	Year  int
+	Month int
+}
+
+// ParseTaxBookPeriod parses the year and month query values. Both default to
+// the current month.
+func ParseTaxBookPeriod(year, month string) (*TaxBookPeriod, error) {
+	now := time.Now()
+	p := &TaxBookPeriod{Year: now.Year(), Month: int(now.Month())}
+
+	if year != "" {
+		y, err := strconv.Atoi(year)
+		if err != nil || y < 2000 || y > 9999 {
+			return nil, fmt.Errorf("year must be a valid year (e.g. 2025)")
+		}
+		p.Year = y
+	}
+	if month != "" {
+		m, err := strconv.Atoi(month)
+		if err != nil || m < 1 || m > 12 {
+			return nil, fmt.Errorf("month must be between 1 and 12")
+		}
+		p.Month = m
+	}
+	return p, nil
+}
+
+// String returns the period as YYYY-MM
+func (p TaxBookPeriod) String() string {
+	return fmt.Sprintf("%04d-%02d", p.Year, p.Month)
+}
+
+// ConsumerSalesAmounts are the columns of the libro de ventas a consumidor
+// final. Ventas gravadas include IVA, as printed on the facturas; IVADebito is
+// the débito fiscal contained in them.
+type ConsumerSalesAmounts struct {
+	DocumentCount   int     `json:"document_count"`
+	VentasExentas   float64 `json:"ventas_exentas"`
+	VentasNoSujetas float64 `json:"ventas_no_sujetas"`
+	VentasGravadas  float64 `json:"ventas_gravadas"`
+	Exportaciones   float64 `json:"exportaciones"`
+	Total           float64 `json:"total"`
+	IVADebito       float64 `json:"iva_debito"`
+}
+
+// Add adds another document or group to the amounts
+func (a *ConsumerSalesAmounts) Add(other ConsumerSalesAmounts) {
+	a.DocumentCount += other.DocumentCount
+	a.VentasExentas += other.VentasExentas
+	a.VentasNoSujetas += other.VentasNoSujetas
+	a.VentasGravadas += other.VentasGravadas
+	a.Exportaciones += other.Exportaciones
+	a.Total += other.Total
+	a.IVADebito += other.IVADebito
+}
+
+// ConsumerSalesBookRow is one day of facturas (01) or facturas de exportación
+// (11) issued from a point of sale, numbered from the first to the last
+// numero de control
+type ConsumerSalesBookRow struct {
+	Date               string `json:"date"`
+	EstablishmentID    string `json:"establishment_id"`
+	EstablishmentCode  string `json:"establishment_code"`
+	EstablishmentName  string `json:"establishment_name"`
+	PointOfSaleID      string `json:"point_of_sale_id"`
+	PointOfSaleCode    string `json:"point_of_sale_code"`
+	PointOfSaleName    string `json:"point_of_sale_name"`
+	TipoDte            string `json:"tipo_dte"`
+	FirstNumeroControl string `json:"first_numero_control"`
+	LastNumeroControl  string `json:"last_numero_control"`
+	ConsumerSalesAmounts
+}
+
+// ConsumerSalesBookSubtotal totals the month for one point of sale
+type ConsumerSalesBookSubtotal struct {
+	EstablishmentID   string `json:"establishment_id"`
+	EstablishmentCode string `json:"establishment_code"`
+	EstablishmentName string `json:"establishment_name"`
+	PointOfSaleID     string `json:"point_of_sale_id"`
+	PointOfSaleCode   string `json:"point_of_sale_code"`
+	PointOfSaleName   string `json:"point_of_sale_name"`
+	ConsumerSalesAmounts
+}
+
+// ConsumerSalesBook is the libro de ventas a consumidor final for a fiscal month
+type ConsumerSalesBook struct {
+	CompanyID    string                      `json:"company_id"`
+	CompanyName  string                      `json:"company_name"`
+	NIT          string                      `json:"nit"`
+	NRC          string                      `json:"nrc"`
+	Year         int                         `json:"year"`
+	Month        int                         `json:"month"`
+	Rows         []ConsumerSalesBookRow      `json:"rows"`
+	PointsOfSale []ConsumerSalesBookSubtotal `json:"points_of_sale"`
+	Totals       ConsumerSalesAmounts        `json:"totals"`
+	GeneratedAt  time.Time                   `json:"generated_at"`
+}
+
+// ContributorSalesAmounts are the columns of the libro de ventas a
+// contribuyentes. Notas de crédito are negative, so the totals net them
+// against the CCFs they reference. Total is the amount charged: sales, débito
+// fiscal and IVA percibido; IVA retenido by the receptor is shown apart.
+type ContributorSalesAmounts struct {
+	VentasExentas   float64 `json:"ventas_exentas"`
+	VentasNoSujetas float64 `json:"ventas_no_sujetas"`
+	VentasGravadas  float64 `json:"ventas_gravadas"`
+	IVADebito       float64 `json:"iva_debito"`
+	IVAPercibido    float64 `json:"iva_percibido"`
+	IVARetenido     float64 `json:"iva_retenido"`
+	Total           float64 `json:"total"`
+}
+
+// Add adds another document or group to the amounts
+func (a *ContributorSalesAmounts) Add(other ContributorSalesAmounts) {
+	a.VentasExentas += other.VentasExentas
+	a.VentasNoSujetas += other.VentasNoSujetas
+	a.VentasGravadas += other.VentasGravadas
+	a.IVADebito += other.IVADebito
+	a.IVAPercibido += other.IVAPercibido
+	a.IVARetenido += other.IVARetenido
+	a.Total += other.Total
+}
+
+// ContributorSalesBookRow is one CCF (03), nota de crédito (05) or nota de
+// débito (06). Notas list the numeros de control of the CCFs they adjust.
+type ContributorSalesBookRow struct {
+	Date             string   `json:"date"`
+	TipoDte          string   `json:"tipo_dte"`
+	NumeroControl    string   `json:"numero_control"`
+	CodigoGeneracion string   `json:"codigo_generacion"`
+	SelloRecibido    string   `json:"sello_recibido"`
+	ReceptorNRC      string   `json:"receptor_nrc"`
+	ReceptorNIT      string   `json:"receptor_nit"`
+	ReceptorName     string   `json:"receptor_name"`
+	RelatedDocuments []string `json:"related_documents,omitempty"`
+	ContributorSalesAmounts
+}
+
+// ContributorSalesBookSubtotal totals the month for one DTE type
+type ContributorSalesBookSubtotal struct {
+	TipoDte       string `json:"tipo_dte"`
+	DocumentCount int    `json:"document_count"`
+	ContributorSalesAmounts
+}
+
+// ContributorSalesBook is the libro de ventas a contribuyentes for a fiscal month
+type ContributorSalesBook struct {
+	CompanyID     string                         `json:"company_id"`
+	CompanyName   string                         `json:"company_name"`
+	NIT           string                         `json:"nit"`
+	NRC           string                         `json:"nrc"`
+	Year          int                            `json:"year"`
+	Month         int                            `json:"month"`
+	Rows          []ContributorSalesBookRow      `json:"rows"`
+	DocumentTypes []ContributorSalesBookSubtotal `json:"document_types"`
+	Totals        ContributorSalesAmounts        `json:"totals"`
+	GeneratedAt   time.Time                      `json:"generated_at"`
+}
+
+// PurchaseBookAmounts are the columns of the libro de compras. Exempt
+// purchases include non-subject ones. Compras a sujetos excluidos (FSE) have
+// their own column and carry no crédito fiscal. IVARetenido is the IVA we
+// withheld from the supplier; it is not part of Total.
+type PurchaseBookAmounts struct {
+	ComprasExentasInternas      float64 `json:"compras_exentas_internas"`
+	ComprasExentasImportaciones float64 `json:"compras_exentas_importaciones"`
+	ComprasGravadasInternas     float64 `json:"compras_gravadas_internas"`
+	ImportacionesGravadas       float64 `json:"importaciones_gravadas"`
+	IVACreditoFiscal            float64 `json:"iva_credito_fiscal"`
+	SujetosExcluidos            float64 `json:"sujetos_excluidos"`
+	Total                       float64 `json:"total"`
+	IVARetenido                 float64 `json:"iva_retenido"`
+}
+
+// Add adds another document to the amounts
+func (a *PurchaseBookAmounts) Add(other PurchaseBookAmounts) {
+	a.ComprasExentasInternas += other.ComprasExentasInternas
+	a.ComprasExentasImportaciones += other.ComprasExentasImportaciones
+	a.ComprasGravadasInternas += other.ComprasGravadasInternas
+	a.ImportacionesGravadas += other.ImportacionesGravadas
+	a.IVACreditoFiscal += other.IVACreditoFiscal
+	a.SujetosExcluidos += other.SujetosExcluidos
+	a.Total += other.Total
+	a.IVARetenido += other.IVARetenido
+}
+
+// PurchaseBookRow is one purchase: a received CCF (03), an FSE (14) or an import
+type PurchaseBookRow struct {
+	PurchaseID           string  `json:"purchase_id"`
+	PurchaseNumber       string  `json:"purchase_number"`
+	PurchaseType         string  `json:"purchase_type"`
+	Date                 string  `json:"date"`
+	TipoDte              *string `json:"tipo_dte,omitempty"`
+	DocumentNumber       string  `json:"document_number"` // Numero de control, or the purchase number without a DTE
+	CodigoGeneracion     *string `json:"codigo_generacion,omitempty"`
+	SupplierNRC          string  `json:"supplier_nrc"`
+	SupplierDocumentType string  `json:"supplier_document_type"` // 36 (NIT), 13 (DUI), 37 (Otro)...
+	SupplierDocument     string  `json:"supplier_document"`      // NIT, or DUI/other for sujetos excluidos
+	SupplierName         string  `json:"supplier_name"`
+	PurchaseBookAmounts
+}
+
+// Purchase book retention kinds
+const (
+	RetentionIssued   = "issued"   // Comprobante de retención (07) we issued to a supplier
+	RetentionSuffered = "suffered" // IVA a client withheld from us on a CCF or nota
+)
+
+// PurchaseBookRetention is an IVA retention issued to a supplier or suffered
+// from a client in the month
+type PurchaseBookRetention struct {
+	Kind             string  `json:"kind"`
+	Date             string  `json:"date"`
+	TipoDte          string  `json:"tipo_dte"`
+	NumeroControl    string  `json:"numero_control"`
+	CodigoGeneracion string  `json:"codigo_generacion"`
+	CounterpartyNRC  string  `json:"counterparty_nrc"`
+	CounterpartyNIT  string  `json:"counterparty_nit"`
+	CounterpartyName string  `json:"counterparty_name"`
+	RelatedDocument  string  `json:"related_document,omitempty"` // Purchase retained on, for issued retentions
+	MontoSujeto      float64 `json:"monto_sujeto"`
+	IVARetenido      float64 `json:"iva_retenido"`
+}
+
+// PurchaseBook is the libro de compras for a fiscal month
+type PurchaseBook struct {
+	CompanyID           string                  `json:"company_id"`
+	CompanyName         string                  `json:"company_name"`
+	NIT                 string                  `json:"nit"`
+	NRC                 string                  `json:"nrc"`
+	Year                int                     `json:"year"`
+	Month               int                     `json:"month"`
+	Rows                []PurchaseBookRow       `json:"rows"`
+	Totals              PurchaseBookAmounts     `json:"totals"`
+	Retentions          []PurchaseBookRetention `json:"retentions"`
+	RetencionesEmitidas float64                 `json:"retenciones_emitidas"`
+	RetencionesSufridas float64                 `json:"retenciones_sufridas"`
+	GeneratedAt         time.Time               `json:"generated_at"`
+}
@@ -0,0 +1,164 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// User roles
+const (
+	RoleCashier    = "cashier"
+	RoleAccountant = "accountant"
+	RoleAuditor    = "auditor"
+	RoleAdmin      = "admin"
+)
+
+// Roles lists every valid role
+var Roles = []string{RoleCashier, RoleAccountant, RoleAuditor, RoleAdmin}
+
+// Permissions checked on route groups
+const (
+	PermSalesRead      = "sales:read"         // Invoices, remisiones, notas, clients, items, establishments, DTE copies
+	PermSalesWrite     = "sales:write"        // Create and finalize invoices and remisiones, manage clients, resend emails
+	PermSalesAdjust    = "sales:adjust"       // Notas de crédito y débito, payment voids
+	PermPurchasesRead  = "purchases:read"     // Purchases, retentions, liquidaciones, DCL, donations, suppliers
+	PermPurchasesWrite = "purchases:write"    // Create and finalize those documents, manage suppliers, pay suppliers
+	PermInventoryWrite = "inventory:write"    // Items, item taxes, purchase and adjustment events
+	PermInvalidate     = "dte:invalidate"     // Eventos de invalidación and their reversals
+	PermReports        = "reports:read"       // Commit log, inventory and AR reports, reconciliation, contingency, deliveries, ledger, bank statements
+	PermContingency    = "contingency:manage" // Close contingency periods
+	PermBankReconcile  = "bank:reconcile"     // Import bank statements and match their lines with payments
+	PermSettings       = "settings:manage"    // Company, establishments, email, webhooks, API keys, users, ledger setup and bank accounts
+)
+
+var rolePermissions = map[string][]string{
+	RoleCashier: {
+		PermSalesRead, PermSalesWrite,
+	},
+	RoleAccountant: {
+		PermSalesRead, PermSalesWrite, PermSalesAdjust,
+		PermPurchasesRead, PermPurchasesWrite, PermInventoryWrite,
+		PermInvalidate, PermReports, PermContingency, PermBankReconcile,
+	},
+	RoleAuditor: {
+		PermSalesRead, PermPurchasesRead, PermReports,
+	},
+}
+
+// RoleHasPermission reports whether the role grants the permission. Admins
+// have every permission.
+func RoleHasPermission(role, permission string) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidRole reports whether role is one of Roles
+func IsValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// User is a person who works for a company
+type User struct {
+	ID          string     `json:"id"`
+	CompanyID   string     `json:"company_id"`
+	Email       string     `json:"email"`
+	FullName    string     `json:"full_name"`
+	Role        string     `json:"role"`
+	Active      bool       `json:"active"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// CreateUserRequest adds a user to the authenticated company
+type CreateUserRequest struct {
+	Email    string `json:"email" binding:"required"`
+	FullName string `json:"full_name" binding:"required"`
+	Role     string `json:"role" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// Validate checks the email, role and password
+func (r *CreateUserRequest) Validate() error {
+	r.Email = strings.ToLower(strings.TrimSpace(r.Email))
+	r.FullName = strings.TrimSpace(r.FullName)
+
+	if _, err := mail.ParseAddress(r.Email); err != nil {
+		return fmt.Errorf("email is not a valid address")
+	}
+	if r.FullName == "" {
+		return fmt.Errorf("full_name is required")
+	}
+	if !IsValidRole(r.Role) {
+		return fmt.Errorf("role must be one of: cashier, accountant, auditor, admin")
+	}
+	return ValidatePassword(r.Password)
+}
+
+// UpdateUserRequest changes a user; nil fields are left unchanged
+type UpdateUserRequest struct {
+	FullName *string `json:"full_name"`
+	Role     *string `json:"role"`
+	Active   *bool   `json:"active"`
+}
+
+// Validate checks the fields being changed
+func (r *UpdateUserRequest) Validate() error {
+	if r.FullName != nil && strings.TrimSpace(*r.FullName) == "" {
+		return fmt.Errorf("full_name cannot be empty")
+	}
+	if r.Role != nil && !IsValidRole(*r.Role) {
+		return fmt.Errorf("role must be one of: cashier, accountant, auditor, admin")
+	}
+	return nil
+}
+
+// LoginRequest exchanges credentials for a session token
+type LoginRequest struct {
+	CompanyID string `json:"company_id" binding:"required"`
+	Email     string `json:"email" binding:"required"`
+	Password  string `json:"password" binding:"required"`
+}
+
+// LoginResponse carries the bearer token for later requests
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      *User     `json:"user"`
+}
+
+// ChangePasswordRequest changes the logged-in user's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ResetPasswordRequest sets another user's password (admins only)
+type ResetPasswordRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// ValidatePassword enforces the minimum password policy
+func ValidatePassword(password string) error {
+	if len(password) < 10 {
+		return fmt.Errorf("password must be at least 10 characters")
+	}
+	if len(password) > 72 {
+		return fmt.Errorf("password must be at most 72 bytes")
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Webhook event types
+const (
+	WebhookEventInvoiceFinalized        = "invoice.finalized"
+	WebhookEventDTEAccepted             = "dte.accepted"
+	WebhookEventDTERejected             = "dte.rejected"
+	WebhookEventContingencyPeriodOpened = "contingency.period_opened"
+	WebhookEventContingencyPeriodClosed = "contingency.period_closed"
+	WebhookEventLoteCompleted           = "contingency.lote_completed"
+	WebhookEventInvalidationAccepted    = "invalidation.accepted"
+)
+
+// WebhookEventTypes lists every event a subscription can ask for
+var WebhookEventTypes = []string{
+	WebhookEventInvoiceFinalized,
+	WebhookEventDTEAccepted,
+	WebhookEventDTERejected,
+	WebhookEventContingencyPeriodOpened,
+	WebhookEventContingencyPeriodClosed,
+	WebhookEventLoteCompleted,
+	WebhookEventInvalidationAccepted,
+}
+
+// Webhook delivery statuses
+const (
+	WebhookStatusPending   = "pending"
+	WebhookStatusSending   = "sending"
+	WebhookStatusDelivered = "delivered"
+	WebhookStatusFailed    = "failed"
+)
+
+// WebhookSubscription is an endpoint a company registered for lifecycle events
+type WebhookSubscription struct {
+	ID          string    `json:"id"`
+	CompanyID   string    `json:"company_id"`
+	URL         string    `json:"url"`
+	Description *string   `json:"description,omitempty"`
+	EventTypes  []string  `json:"event_types"` // Empty means all events
+	Secret      string    `json:"secret,omitempty"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CreateWebhookRequest registers a new endpoint
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Description *string  `json:"description"`
+	EventTypes  []string `json:"event_types"`
+}
+
+// Validate checks the URL and event types
+func (r *CreateWebhookRequest) Validate() error {
+	if err := validateWebhookURL(r.URL); err != nil {
+		return err
+	}
+	return validateWebhookEventTypes(r.EventTypes)
+}
+
+// UpdateWebhookRequest changes an endpoint; nil fields are left unchanged
+type UpdateWebhookRequest struct {
+	URL         *string   `json:"url"`
+	Description *string   `json:"description"`
+	EventTypes  *[]string `json:"event_types"`
+	Active      *bool     `json:"active"`
+}
+
+// Validate checks the fields being changed
+func (r *UpdateWebhookRequest) Validate() error {
+	if r.URL != nil {
+		if err := validateWebhookURL(*r.URL); err != nil {
+			return err
+		}
+	}
+	if r.EventTypes != nil {
+		return validateWebhookEventTypes(*r.EventTypes)
+	}
+	return nil
+}
+
+// WebhookEvent is the envelope POSTed to subscribers
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CompanyID string      `json:"company_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookDelivery is the delivery log entry for one event and subscription
+type WebhookDelivery struct {
+	ID             string     `json:"id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	SubscriptionID string     `json:"subscription_id"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	MaxAttempts    int        `json:"max_attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	ResponseStatus *int       `json:"response_status,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	DurationMs     *int       `json:"duration_ms,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// WebhookDTEResult is the data of dte.accepted and dte.rejected
+type WebhookDTEResult struct {
+	CodigoGeneracion string   `json:"codigo_generacion"`
+	NumeroControl    string   `json:"numero_control,omitempty"`
+	TipoDte          string   `json:"tipo_dte,omitempty"`
+	Ambiente         string   `json:"ambiente,omitempty"`
+	Estado           string   `json:"estado"`
+	SelloRecibido    string   `json:"sello_recibido,omitempty"`
+	FhProcesamiento  string   `json:"fh_procesamiento,omitempty"`
+	CodigoMsg        string   `json:"codigo_msg,omitempty"`
+	DescripcionMsg   string   `json:"descripcion_msg,omitempty"`
+	Observaciones    []string `json:"observaciones,omitempty"`
+	Source           string   `json:"source"` // "online" or "contingency"
+	LoteID           *string  `json:"lote_id,omitempty"`
+}
+
+// validateWebhookURL accepts absolute https URLs whose host is not a local
+// name or an address the public internet cannot reach. Names are resolved
+// and checked again when the subscription is saved and on every connection.
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.Scheme != "https" {
+		return fmt.Errorf("url must be an absolute https URL")
+	}
+	if u.User != nil {
+		return fmt.Errorf("url must not contain credentials")
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".local") || strings.HasSuffix(host, ".internal") {
+		return fmt.Errorf("url host %q is not publicly reachable", host)
+	}
+	if ip := net.ParseIP(host); ip != nil && !WebhookAddressAllowed(ip) {
+		return fmt.Errorf("url host %q is not publicly reachable", host)
+	}
+	return nil
+}
+
+// webhookBlockedNets are the special-use ranges the Is* methods of net.IP do
+// not cover
+var webhookBlockedNets = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",     // "This" network
+		"100.64.0.0/10", // Carrier-grade NAT
+		"192.0.0.0/24",  // IETF protocol assignments
+		"198.18.0.0/15", // Benchmarking
+		"240.0.0.0/4",   // Reserved
+		"64:ff9b::/96",  // NAT64, which embeds IPv4 addresses
+	} {
+		_, n, _ := net.ParseCIDR(cidr)
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// WebhookAddressAllowed reports whether webhooks may connect to the address:
+// not loopback, private, link-local (cloud metadata), multicast or unspecified
+func WebhookAddressAllowed(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range webhookBlockedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func validateWebhookEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		known := false
+		for _, t := range WebhookEventTypes {
+			if eventType == t {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown event type %q", eventType)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,464 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"cuentas/internal/models"
+)
+
+// ============================================
+// ERRORS
+// ============================================
+
+var (
+	ErrSupplierPaymentNotFound      = errors.New("supplier payment not found")
+	ErrSupplierPaymentAlreadyVoided = errors.New("supplier payment is already voided")
+	ErrSupplierOverpayment          = errors.New("payment amount exceeds the purchase balance due")
+)
+
+// ============================================
+// SERVICE DEFINITION
+// ============================================
+
+// AccountsPayableService records payments made to suppliers against finalized
+// purchases, voids mistaken ones, and reports what is owed: purchases due and
+// accounts payable aging. Every change keeps the purchase's amount_paid,
+// balance_due and payment_status in step.
+type AccountsPayableService struct {
+	db *sql.DB
+}
+
+// NewAccountsPayableService creates a new accounts payable service
+func NewAccountsPayableService(db *sql.DB) *AccountsPayableService {
+	return &AccountsPayableService{db: db}
+}
+
+const supplierPaymentColumns = `
+	sp.id, sp.company_id, sp.purchase_id, pu.purchase_number,
+	sp.supplier_id, COALESCE(pu.supplier_name, s.name, ''),
+	sp.amount, sp.payment_method, sp.payment_reference, sp.payment_date, sp.notes,
+	sp.created_by, sp.created_at, sp.voided_at, sp.voided_by, sp.void_reason
+`
+
+const supplierPaymentFrom = `
+	FROM supplier_payments sp
+	JOIN purchases pu ON pu.id = sp.purchase_id
+	LEFT JOIN suppliers s ON s.id = sp.supplier_id
+`
+
+// ============================================
+// PAYMENTS
+// ============================================
+
+// RecordSupplierPayment applies a payment to a finalized purchase. The amount
+// cannot exceed the balance due.
+func (s *AccountsPayableService) RecordSupplierPayment(ctx context.Context, companyID, purchaseID, userID string, req *models.CreateSupplierPaymentRequest) (*models.SupplierPayment, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	amount := round(req.Amount)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	pur, err := lockPurchaseForPaymentTx(ctx, tx, companyID, purchaseID)
+	if err != nil {
+		return nil, err
+	}
+	if pur.status != "finalized" {
+		return nil, ErrInvalidPurchaseStatus
+	}
+	if amount > round(pur.balanceDue) {
+		return nil, ErrSupplierOverpayment
+	}
+
+	var paymentID string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO supplier_payments (
+			company_id, purchase_id, supplier_id, amount, payment_method,
+			payment_reference, payment_date, created_by, notes
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, companyID, purchaseID, pur.supplierID, amount, req.PaymentMethod,
+		nullIfBlank(req.PaymentReference), req.PaymentDate.Time, nullIfBlank(&userID), req.Notes,
+	).Scan(&paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record supplier payment: %w", err)
+	}
+
+	if err := applySupplierPaymentTx(ctx, tx, purchaseID, amount); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return s.GetSupplierPayment(ctx, companyID, paymentID)
+}
+
+// VoidSupplierPayment reverses a payment entered by mistake: the amount goes
+// back onto the purchase's balance. The payment row is kept, marked voided.
+func (s *AccountsPayableService) VoidSupplierPayment(ctx context.Context, companyID, paymentID, userID string, req *models.VoidPaymentRequest) (*models.SupplierPayment, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		purchaseID string
+		amount     float64
+		voidedAt   sql.NullTime
+	)
+	err = tx.QueryRowContext(ctx, `
+		SELECT purchase_id, amount, voided_at
+		FROM supplier_payments
+		WHERE id = $1 AND company_id = $2
+		FOR UPDATE
+	`, paymentID, companyID).Scan(&purchaseID, &amount, &voidedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSupplierPaymentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load supplier payment: %w", err)
+	}
+	if voidedAt.Valid {
+		return nil, ErrSupplierPaymentAlreadyVoided
+	}
+	// A payment found on a bank statement has to be unmatched first
+	if err := checkPaymentUnreconciledTx(ctx, tx, models.BankItemSupplierPayment, paymentID); err != nil {
+		return nil, err
+	}
+
+	pur, err := lockPurchaseForPaymentTx(ctx, tx, companyID, purchaseID)
+	if err != nil {
+		return nil, err
+	}
+	// A voided purchase no longer carries a balance to restore
+	if pur.status == "voided" {
+		return nil, ErrPurchaseAlreadyVoid
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE supplier_payments
+		SET voided_at = NOW(), voided_by = $1, void_reason = $2
+		WHERE id = $3
+	`, nullIfBlank(&userID), req.Reason, paymentID); err != nil {
+		return nil, fmt.Errorf("failed to void supplier payment: %w", err)
+	}
+
+	if err := applySupplierPaymentTx(ctx, tx, purchaseID, -amount); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return s.GetSupplierPayment(ctx, companyID, paymentID)
+}
+
+// GetSupplierPayment returns one supplier payment
+func (s *AccountsPayableService) GetSupplierPayment(ctx context.Context, companyID, paymentID string) (*models.SupplierPayment, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+supplierPaymentColumns+supplierPaymentFrom+`
+		WHERE sp.id = $1 AND sp.company_id = $2
+	`, paymentID, companyID)
+
+	payment, err := scanSupplierPayment(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSupplierPaymentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get supplier payment: %w", err)
+	}
+	return payment, nil
+}
+
+// ListSupplierPayments returns supplier payments, newest first
+func (s *AccountsPayableService) ListSupplierPayments(ctx context.Context, companyID string, filters *models.SupplierPaymentFilters) ([]models.SupplierPayment, error) {
+	query := `SELECT ` + supplierPaymentColumns + supplierPaymentFrom + `
+		WHERE sp.company_id = $1
+	`
+	args := []interface{}{companyID}
+
+	if filters.SupplierID != "" {
+		args = append(args, filters.SupplierID)
+		query += fmt.Sprintf(" AND sp.supplier_id = $%d", len(args))
+	}
+	if filters.PurchaseID != "" {
+		args = append(args, filters.PurchaseID)
+		query += fmt.Sprintf(" AND sp.purchase_id = $%d", len(args))
+	}
+	if filters.FromDate != "" {
+		args = append(args, filters.FromDate)
+		query += fmt.Sprintf(" AND sp.payment_date >= $%d", len(args))
+	}
+	if filters.ToDate != "" {
+		args = append(args, filters.ToDate)
+		query += fmt.Sprintf(" AND sp.payment_date <= $%d", len(args))
+	}
+	if !filters.IncludeVoided {
+		query += " AND sp.voided_at IS NULL"
+	}
+
+	query += " ORDER BY sp.payment_date DESC, sp.created_at DESC"
+	if filters.Limit > 0 {
+		args = append(args, filters.Limit, filters.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list supplier payments: %w", err)
+	}
+	defer rows.Close()
+
+	payments := []models.SupplierPayment{}
+	for rows.Next() {
+		payment, err := scanSupplierPayment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan supplier payment: %w", err)
+		}
+		payments = append(payments, *payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating supplier payments: %w", err)
+	}
+	return payments, nil
+}
+
+// ============================================
+// PURCHASES DUE
+// ============================================
+
+// ListPurchasesDue returns finalized purchases with a balance still to pay,
+// oldest due date first. A purchase without a due date is due on its
+// purchase date.
+func (s *AccountsPayableService) ListPurchasesDue(ctx context.Context, companyID string, filters *models.PurchasesDueFilters) ([]models.PurchaseDue, error) {
+	asOf := filters.AsOfDate.Format("2006-01-02")
+
+	query := `
+		SELECT pu.id, pu.purchase_number, pu.purchase_type, pu.dte_type, pu.dte_numero_control,
+		       pu.supplier_id, COALESCE(pu.supplier_name, s.name, ''),
+		       pu.purchase_date, COALESCE(pu.due_date, pu.purchase_date) AS due,
+		       pu.total, pu.amount_paid, pu.balance_due
+		FROM purchases pu
+		LEFT JOIN suppliers s ON s.id = pu.supplier_id
+		WHERE pu.company_id = $1
+		  AND pu.status = 'finalized'
+		  AND pu.balance_due > 0
+	`
+	args := []interface{}{companyID}
+	if filters.SupplierID != "" {
+		args = append(args, filters.SupplierID)
+		query += fmt.Sprintf(" AND pu.supplier_id = $%d", len(args))
+	}
+	if filters.OverdueOnly {
+		args = append(args, asOf)
+		query += fmt.Sprintf(" AND COALESCE(pu.due_date, pu.purchase_date) < $%d", len(args))
+	}
+	query += " ORDER BY due, pu.purchase_number"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list purchases due: %w", err)
+	}
+	defer rows.Close()
+
+	due := []models.PurchaseDue{}
+	for rows.Next() {
+		var (
+			d                     models.PurchaseDue
+			purchaseDate, dueDate time.Time
+		)
+		if err := rows.Scan(
+			&d.PurchaseID, &d.PurchaseNumber, &d.PurchaseType, &d.DteType, &d.DteNumeroControl,
+			&d.SupplierID, &d.SupplierName,
+			&purchaseDate, &dueDate,
+			&d.Total, &d.AmountPaid, &d.BalanceDue,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan purchase due: %w", err)
+		}
+		d.PurchaseDate = purchaseDate.Format("2006-01-02")
+		d.DueDate = dueDate.Format("2006-01-02")
+		d.DaysPastDue = int(filters.AsOfDate.Sub(dueDate).Hours() / 24)
+		due = append(due, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating purchases due: %w", err)
+	}
+	return due, nil
+}
+
+// ============================================
+// AGING
+// ============================================
+
+// GetAgingReport buckets what the company owed each supplier (or owed per
+// establishment) on the as-of date by days past due. A purchase's outstanding
+// amount is its balance due plus the unvoided payments dated after that day;
+// days past due count from its due_date, or from the purchase date when it has
+// none. Purchases from unregistered suppliers are grouped by supplier name.
+func (s *AccountsPayableService) GetAgingReport(ctx context.Context, companyID string, filters *models.APAgingFilters) (*models.AgingReport, error) {
+	asOf := filters.AsOfDate.Format("2006-01-02")
+
+	query := `
+		SELECT COALESCE(pu.supplier_id::text, ''), COALESCE(s.name, pu.supplier_name, ''),
+		       pu.establishment_id, e.nombre,
+		       COALESCE(pu.due_date, pu.purchase_date) AS due,
+		       pu.balance_due + COALESCE((
+		           SELECT SUM(sp.amount) FROM supplier_payments sp
+		           WHERE sp.purchase_id = pu.id
+		             AND sp.voided_at IS NULL
+		             AND sp.payment_date > $2
+		       ), 0) AS outstanding
+		FROM purchases pu
+		JOIN establishments e ON e.id = pu.establishment_id
+		LEFT JOIN suppliers s ON s.id = pu.supplier_id
+		WHERE pu.company_id = $1
+		  AND pu.status = 'finalized'
+		  AND pu.purchase_date <= $2
+	`
+	args := []interface{}{companyID, asOf}
+	if filters.SupplierID != "" {
+		args = append(args, filters.SupplierID)
+		query += fmt.Sprintf(" AND pu.supplier_id = $%d", len(args))
+	}
+	if filters.EstablishmentID != "" {
+		args = append(args, filters.EstablishmentID)
+		query += fmt.Sprintf(" AND pu.establishment_id = $%d", len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payables: %w", err)
+	}
+	defer rows.Close()
+
+	report := &models.AgingReport{Ledger: models.AgingLedgerPayable, AsOfDate: asOf, GroupBy: filters.GroupBy, Rows: []models.AgingRow{}}
+	byKey := map[string]*models.AgingRow{}
+	for rows.Next() {
+		var (
+			supplierID, supplierName, establishmentID, establishmentName string
+			due                                                          time.Time
+			outstanding                                                  float64
+		)
+		if err := rows.Scan(&supplierID, &supplierName, &establishmentID, &establishmentName, &due, &outstanding); err != nil {
+			return nil, fmt.Errorf("failed to scan payable: %w", err)
+		}
+		outstanding = round(outstanding)
+		if outstanding <= 0 {
+			continue
+		}
+
+		id, name, key := supplierID, supplierName, supplierID
+		if supplierID == "" {
+			key = "name:" + supplierName
+		}
+		if filters.GroupBy == models.AgingGroupByEstablishment {
+			id, name, key = establishmentID, establishmentName, establishmentID
+		}
+		row, ok := byKey[key]
+		if !ok {
+			row = &models.AgingRow{ID: id, Name: name}
+			byKey[key] = row
+		}
+
+		daysPastDue := int(filters.AsOfDate.Sub(due).Hours() / 24)
+		row.Add(daysPastDue, outstanding)
+		row.InvoiceCount++
+		report.Totals.Add(daysPastDue, outstanding)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payables: %w", err)
+	}
+
+	for _, row := range byKey {
+		row.AgingBuckets = roundBuckets(row.AgingBuckets)
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].Total != report.Rows[j].Total {
+			return report.Rows[i].Total > report.Rows[j].Total
+		}
+		return report.Rows[i].Name < report.Rows[j].Name
+	})
+	report.Totals = roundBuckets(report.Totals)
+
+	return report, nil
+}
+
+// ============================================
+// HELPERS
+// ============================================
+
+type paymentPurchase struct {
+	status     string
+	supplierID *string
+	balanceDue float64
+}
+
+func lockPurchaseForPaymentTx(ctx context.Context, tx *sql.Tx, companyID, purchaseID string) (*paymentPurchase, error) {
+	pur := &paymentPurchase{}
+	err := tx.QueryRowContext(ctx, `
+		SELECT status, supplier_id, balance_due
+		FROM purchases
+		WHERE id = $1 AND company_id = $2
+		FOR UPDATE
+	`, purchaseID, companyID).Scan(&pur.status, &pur.supplierID, &pur.balanceDue)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPurchaseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load purchase: %w", err)
+	}
+	return pur, nil
+}
+
+// applySupplierPaymentTx moves amount from the purchase's balance due to its
+// amount paid (negative to reverse a payment)
+func applySupplierPaymentTx(ctx context.Context, tx *sql.Tx, purchaseID string, amount float64) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE purchases
+		SET amount_paid = amount_paid + $1,
+		    balance_due = balance_due - $1,
+		    payment_status = CASE
+		        WHEN balance_due - $1 <= 0 THEN 'paid'
+		        WHEN amount_paid + $1 <= 0 THEN 'pending'
+		        ELSE 'partial'
+		    END
+		WHERE id = $2
+	`, amount, purchaseID)
+	if err != nil {
+		return fmt.Errorf("failed to update purchase balance: %w", err)
+	}
+	return nil
+}
+
+func scanSupplierPayment(row rowScanner) (*models.SupplierPayment, error) {
+	var p models.SupplierPayment
+	err := row.Scan(
+		&p.ID, &p.CompanyID, &p.PurchaseID, &p.PurchaseNumber,
+		&p.SupplierID, &p.SupplierName,
+		&p.Amount, &p.PaymentMethod, &p.PaymentReference, &p.PaymentDate, &p.Notes,
+		&p.CreatedBy, &p.CreatedAt, &p.VoidedAt, &p.VoidedBy, &p.VoidReason,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
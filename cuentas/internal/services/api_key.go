@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cuentas/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ============================================
+// ERRORS
+// ============================================
+
+var (
+	ErrAPIKeyNotFound        = errors.New("api key not found")
+	ErrAPIKeyCompanyNotFound = errors.New("company not found")
+	ErrInvalidAPIKey         = errors.New("invalid, expired or revoked api key")
+)
+
+const (
+	apiKeyPrefix      = "ck_"
+	apiKeyPrefixLen   = 11 // "ck_" plus 8 characters, shown in listings
+	apiKeySecretBytes = 24 // Hex encoded after the prefix
+
+	// last_used_at is written at most once per interval per key
+	apiKeyTouchInterval = time.Minute
+)
+
+// ============================================
+// SERVICE DEFINITION
+// ============================================
+
+// APIKeyService issues, verifies and revokes per-company API keys. Keys are
+// random and high-entropy, so a plain SHA-256 is enough to store them.
+type APIKeyService struct {
+	db *sql.DB
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(db *sql.DB) *APIKeyService {
+	return &APIKeyService{db: db}
+}
+
+const apiKeyColumns = `
+	id, company_id, user_id, name, prefix, scopes, expires_at, revoked_at,
+	last_used_at, last_used_ip, created_by_key_id, created_at, updated_at
+`
+
+// ============================================
+// MANAGEMENT
+// ============================================
+
+// CreateKey issues a key for the company. The plain key is only returned here.
+// createdByKeyID is empty when the key is issued during company provisioning;
+// userID binds the key to the user who created it, empty for unbound keys.
+func (s *APIKeyService) CreateKey(ctx context.Context, companyID string, req *models.CreateAPIKeyRequest, createdByKeyID, userID string) (*models.CreatedAPIKey, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if _, err := uuid.Parse(companyID); err != nil {
+		return nil, ErrAPIKeyCompanyNotFound
+	}
+
+	key, prefix, keyHash, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO api_keys (company_id, user_id, name, prefix, key_hash, scopes, expires_at, created_by_key_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+apiKeyColumns,
+		companyID, nullIfBlank(&userID), req.Name, prefix, keyHash, pq.Array(req.Scopes),
+		req.ExpiresAt, nullIfBlank(&createdByKeyID),
+	)
+	apiKey, err := scanAPIKey(row)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return nil, ErrAPIKeyCompanyNotFound
+		}
+		return nil, err
+	}
+
+	return &models.CreatedAPIKey{APIKey: *apiKey, Key: key}, nil
+}
+
+// ListKeys lists the company's keys, revoked ones included
+func (s *APIKeyService) ListKeys(ctx context.Context, companyID string) ([]models.APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE company_id = $1
+		ORDER BY created_at DESC
+	`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// GetKey returns one of the company's keys
+func (s *APIKeyService) GetKey(ctx context.Context, companyID, id string) (*models.APIKey, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrAPIKeyNotFound
+	}
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE id = $1 AND company_id = $2
+	`, id, companyID)
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+// RevokeKey stops a key from authenticating. Revoking twice is a no-op.
+func (s *APIKeyService) RevokeKey(ctx context.Context, companyID, id string) (*models.APIKey, error) {
+	if _, err := s.GetKey(ctx, companyID, id); err != nil {
+		return nil, err
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE api_keys
+		SET revoked_at = COALESCE(revoked_at, NOW()), updated_at = NOW()
+		WHERE id = $1 AND company_id = $2
+		RETURNING `+apiKeyColumns,
+		id, companyID,
+	)
+	return scanAPIKey(row)
+}
+
+// ============================================
+// AUTHENTICATION
+// ============================================
+
+// Authenticate resolves a presented key to its record. Unknown, revoked and
+// expired keys all return ErrInvalidAPIKey.
+func (s *APIKeyService) Authenticate(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	keyHash, ok := apiKeyHash(rawKey)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE key_hash = $1
+	`, keyHash)
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !key.IsActive(time.Now()) {
+		return nil, ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+// TouchLastUsed records that the key was used, at most once per minute
+func (s *APIKeyService) TouchLastUsed(ctx context.Context, id, clientIP string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE api_keys
+		SET last_used_at = NOW(), last_used_ip = $2
+		WHERE id = $1
+		  AND (last_used_at IS NULL OR last_used_at < NOW() - $3 * INTERVAL '1 second')
+	`, id, nullIfBlank(&clientIP), int(apiKeyTouchInterval.Seconds()))
+	return err
+}
+
+// ============================================
+// HELPERS
+// ============================================
+
+// generateAPIKey returns a new key, the prefix shown in listings and the hash
+// stored in its place
+func generateAPIKey() (key, prefix, keyHash string, err error) {
+	b := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	key = apiKeyPrefix + hex.EncodeToString(b)
+	return key, key[:apiKeyPrefixLen], hashToken(key), nil
+}
+
+// apiKeyHash returns the stored hash of a presented key, or false when the
+// key does not have the shape of an issued one
+func apiKeyHash(rawKey string) (string, bool) {
+	rawKey = strings.TrimSpace(rawKey)
+	secret, ok := strings.CutPrefix(rawKey, apiKeyPrefix)
+	if !ok || len(secret) != hex.EncodedLen(apiKeySecretBytes) {
+		return "", false
+	}
+	if _, err := hex.DecodeString(secret); err != nil {
+		return "", false
+	}
+	return hashToken(rawKey), true
+}
+
+// hashToken returns the hex SHA-256 stored for API keys and session tokens
+func hashToken(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	err := row.Scan(
+		&key.ID, &key.CompanyID, &key.UserID, &key.Name, &key.Prefix, pq.Array(&key.Scopes),
+		&key.ExpiresAt, &key.RevokedAt, &key.LastUsedAt, &key.LastUsedIP,
+		&key.CreatedByKeyID, &key.CreatedAt, &key.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan api key: %w", err)
+	}
+	if key.Scopes == nil {
+		key.Scopes = []string{}
+	}
+	return key, nil
+}
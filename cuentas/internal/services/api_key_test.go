@@ -0,0 +1,94 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAPIKeyRoundTrip(t *testing.T) {
+	key, prefix, keyHash, err := generateAPIKey()
+	if err != nil {
+		t.Fatalf("generateAPIKey() error = %v", err)
+	}
+
+	if !strings.HasPrefix(key, apiKeyPrefix) || len(key) != len(apiKeyPrefix)+2*apiKeySecretBytes {
+		t.Errorf("key %q does not have the issued shape", key)
+	}
+	if prefix != key[:apiKeyPrefixLen] {
+		t.Errorf("prefix = %q, want %q", prefix, key[:apiKeyPrefixLen])
+	}
+	if strings.Contains(keyHash, key[len(apiKeyPrefix):]) {
+		t.Error("stored hash contains the secret")
+	}
+
+	got, ok := apiKeyHash(key)
+	if !ok || got != keyHash {
+		t.Errorf("apiKeyHash(key) = %q, %v; want %q, true", got, ok, keyHash)
+	}
+
+	// Surrounding whitespace from a pasted header still authenticates
+	if got, ok := apiKeyHash(" " + key + "\n"); !ok || got != keyHash {
+		t.Errorf("apiKeyHash(padded key) = %q, %v; want %q, true", got, ok, keyHash)
+	}
+
+	other, _, otherHash, err := generateAPIKey()
+	if err != nil {
+		t.Fatalf("generateAPIKey() error = %v", err)
+	}
+	if other == key || otherHash == keyHash {
+		t.Error("two generated keys are the same")
+	}
+}
+
+func TestAPIKeyWrongSecret(t *testing.T) {
+	key, _, keyHash, err := generateAPIKey()
+	if err != nil {
+		t.Fatalf("generateAPIKey() error = %v", err)
+	}
+
+	last := key[len(key)-1]
+	flipped := byte('0')
+	if last == '0' {
+		flipped = '1'
+	}
+	wrong := key[:len(key)-1] + string(flipped)
+
+	got, ok := apiKeyHash(wrong)
+	if !ok {
+		t.Fatalf("apiKeyHash(%q) rejected a well-formed key", wrong)
+	}
+	if got == keyHash {
+		t.Error("a different secret hashes to the stored hash")
+	}
+}
+
+func TestAPIKeyMalformed(t *testing.T) {
+	valid := apiKeyPrefix + strings.Repeat("ab", apiKeySecretBytes)
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{name: "empty", key: ""},
+		{name: "prefix only", key: apiKeyPrefix},
+		{name: "missing prefix", key: strings.TrimPrefix(valid, apiKeyPrefix)},
+		{name: "other prefix", key: "sk_" + strings.TrimPrefix(valid, apiKeyPrefix)},
+		{name: "session token", key: sessionTokenPrefix + strings.Repeat("ab", 32)},
+		{name: "too short", key: valid[:len(valid)-2]},
+		{name: "too long", key: valid + "ab"},
+		{name: "not hex", key: valid[:len(valid)-1] + "z"},
+		{name: "bearer scheme left in", key: "Bearer " + valid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, ok := apiKeyHash(tt.key); ok {
+				t.Errorf("apiKeyHash(%q) = %q, true; want rejected", tt.key, got)
+			}
+		})
+	}
+
+	if _, ok := apiKeyHash(valid); !ok {
+		t.Errorf("apiKeyHash(%q) rejected a well-formed key", valid)
+	}
+}
@@ -0,0 +1,1138 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"cuentas/internal/codigos"
+	"cuentas/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ============================================
+// ERRORS
+// ============================================
+
+var (
+	ErrBankAccountNotFound     = errors.New("bank account not found")
+	ErrBankAccountNumberTaken  = errors.New("a bank account with this number already exists")
+	ErrBankLayoutMissing       = errors.New("the bank account has no CSV layout; set csv_layout or send one with the file")
+	ErrBankStatementNotFound   = errors.New("bank statement not found")
+	ErrBankStatementReconciled = errors.New("the statement has matched or ignored lines; unmatch them first")
+	ErrBankLineNotFound        = errors.New("bank statement line not found")
+	ErrBankLineNotUnmatched    = errors.New("the statement line is already matched or ignored")
+	ErrBankMatchAmountMismatch = errors.New("the payments do not add up to the statement line amount")
+	ErrBankPaymentUnavailable  = errors.New("the payment is voided or already matched to a statement line")
+	ErrPaymentReconciled       = errors.New("the payment is matched to a bank statement line; unmatch it first")
+)
+
+// bankCandidateWindowDays is how far from a statement line's date payments
+// are offered for manual matching
+const bankCandidateWindowDays = 30
+
+// ============================================
+// SERVICE DEFINITION
+// ============================================
+
+// BankService keeps the company's bank accounts, imports their statements and
+// reconciles statement lines with the payments recorded against invoices
+// (deposits) and purchases (withdrawals)
+type BankService struct {
+	db *sql.DB
+}
+
+// NewBankService creates a new bank service
+func NewBankService(db *sql.DB) *BankService {
+	return &BankService{db: db}
+}
+
+const bankAccountColumns = `
+	id, company_id, name, bank_name, account_number, currency, csv_layout, active, created_at, updated_at
+`
+
+const bankLineColumns = `
+	id, bank_account_id, statement_id, to_char(transaction_date, 'YYYY-MM-DD'), amount, description,
+	reference, transaction_id, status, match_method, note, matched_by, matched_at
+`
+
+const bankStatementColumns = `
+	id, company_id, bank_account_id, format, filename, to_char(from_date, 'YYYY-MM-DD'),
+	to_char(to_date, 'YYYY-MM-DD'), closing_balance, line_count, skipped_count, imported_by, imported_at
+`
+
+// ============================================
+// ACCOUNTS
+// ============================================
+
+// CreateAccount registers a bank account
+func (s *BankService) CreateAccount(ctx context.Context, companyID string, req *models.CreateBankAccountRequest) (*models.BankAccount, error) {
+	layout, err := marshalBankLayout(req.CSVLayout)
+	if err != nil {
+		return nil, err
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO bank_accounts (company_id, name, bank_name, account_number, currency, csv_layout)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+bankAccountColumns,
+		companyID, req.Name, req.BankName, req.AccountNumber, req.Currency, layout,
+	)
+	account, err := scanBankAccount(row)
+	if err != nil {
+		return nil, bankAccountWriteError(err)
+	}
+	return account, nil
+}
+
+// ListAccounts returns the company's bank accounts by name
+func (s *BankService) ListAccounts(ctx context.Context, companyID string, includeInactive bool) ([]models.BankAccount, error) {
+	query := `SELECT ` + bankAccountColumns + ` FROM bank_accounts WHERE company_id = $1`
+	if !includeInactive {
+		query += " AND active = true"
+	}
+	query += " ORDER BY name"
+
+	rows, err := s.db.QueryContext(ctx, query, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bank accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []models.BankAccount{}
+	for rows.Next() {
+		account, err := scanBankAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bank account: %w", err)
+		}
+		accounts = append(accounts, *account)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating bank accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+// GetAccount returns one bank account
+func (s *BankService) GetAccount(ctx context.Context, companyID, accountID string) (*models.BankAccount, error) {
+	if _, err := uuid.Parse(accountID); err != nil {
+		return nil, ErrBankAccountNotFound
+	}
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+bankAccountColumns+` FROM bank_accounts WHERE id = $1 AND company_id = $2
+	`, accountID, companyID)
+
+	account, err := scanBankAccount(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBankAccountNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bank account: %w", err)
+	}
+	return account, nil
+}
+
+// UpdateAccount changes a bank account's names, CSV layout or active flag
+func (s *BankService) UpdateAccount(ctx context.Context, companyID, accountID string, req *models.UpdateBankAccountRequest) (*models.BankAccount, error) {
+	account, err := s.GetAccount(ctx, companyID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Apply(account); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	layout, err := marshalBankLayout(account.CSVLayout)
+	if err != nil {
+		return nil, err
+	}
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE bank_accounts
+		SET name = $1, bank_name = $2, csv_layout = $3, active = $4, updated_at = NOW()
+		WHERE id = $5 AND company_id = $6
+		RETURNING `+bankAccountColumns,
+		account.Name, account.BankName, layout, account.Active, accountID, companyID,
+	)
+	account, err = scanBankAccount(row)
+	if err != nil {
+		return nil, bankAccountWriteError(err)
+	}
+	return account, nil
+}
+
+// ============================================
+// STATEMENTS
+// ============================================
+
+// ImportStatement stores a statement file's lines and runs an automatic
+// matching pass over the account. format is csv or ofx, or empty to detect it
+// from the filename and content; a CSV is read with layout, or with the
+// account's layout when nil. Lines already imported from an overlapping file
+// are skipped.
+func (s *BankService) ImportStatement(ctx context.Context, companyID, accountID, userID, format, filename string, data []byte, layout *models.BankCSVLayout) (*models.BankStatement, error) {
+	account, err := s.GetAccount(ctx, companyID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "" {
+		format = detectBankFormat(filename, data)
+	}
+	var parsed *parsedStatement
+	switch format {
+	case models.BankFormatOFX:
+		parsed, err = parseOFX(data)
+	case models.BankFormatCSV:
+		if layout == nil {
+			layout = account.CSVLayout
+		}
+		if layout == nil {
+			return nil, ErrBankLayoutMissing
+		}
+		if err := layout.Validate(); err != nil {
+			return nil, fmt.Errorf("validation failed: csv_layout: %w", err)
+		}
+		parsed, err = parseBankCSV(data, layout)
+	default:
+		return nil, fmt.Errorf("validation failed: format must be %s or %s", models.BankFormatCSV, models.BankFormatOFX)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if len(parsed.lines) == 0 {
+		return nil, fmt.Errorf("validation failed: the file has no transactions")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var statementID string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO bank_statements (
+			company_id, bank_account_id, format, filename, from_date, to_date,
+			closing_balance, line_count, skipped_count, imported_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, 0, 0, $8)
+		RETURNING id
+	`, companyID, accountID, format, nullIfBlank(&filename), parsed.fromDate, parsed.toDate,
+		parsed.closingBalance, nullIfBlank(&userID)).Scan(&statementID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bank statement: %w", err)
+	}
+
+	imported, skipped := 0, 0
+	for _, line := range parsed.lines {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO bank_statement_lines (
+				company_id, bank_account_id, statement_id, transaction_date, amount,
+				description, reference, transaction_id
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (bank_account_id, transaction_id) DO NOTHING
+		`, companyID, accountID, statementID, line.date, line.amount,
+			line.description, line.reference, line.transactionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert statement line: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			skipped++
+		} else {
+			imported++
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE bank_statements SET line_count = $1, skipped_count = $2 WHERE id = $3
+	`, imported, skipped, statementID); err != nil {
+		return nil, fmt.Errorf("failed to update bank statement: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	statement, err := s.getStatement(ctx, companyID, statementID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.AutoMatch(ctx, companyID, accountID, models.DefaultBankMatchToleranceDays)
+	if err != nil {
+		return nil, fmt.Errorf("statement imported, automatic matching failed: %w", err)
+	}
+	statement.AutoMatched = result.Matched
+	return statement, nil
+}
+
+// ListStatements returns the statements imported into a bank account, latest
+// first
+func (s *BankService) ListStatements(ctx context.Context, companyID, accountID string) ([]models.BankStatement, error) {
+	if _, err := s.GetAccount(ctx, companyID, accountID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+bankStatementColumns+`
+		FROM bank_statements
+		WHERE bank_account_id = $1 AND company_id = $2
+		ORDER BY to_date DESC, imported_at DESC
+	`, accountID, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bank statements: %w", err)
+	}
+	defer rows.Close()
+
+	statements := []models.BankStatement{}
+	for rows.Next() {
+		statement, err := scanBankStatement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bank statement: %w", err)
+		}
+		statements = append(statements, *statement)
+	}
+	return statements, rows.Err()
+}
+
+// DeleteStatement removes an imported statement and its lines, so a file read
+// with the wrong layout can be imported again. None of its lines may be
+// matched or ignored.
+func (s *BankService) DeleteStatement(ctx context.Context, companyID, statementID string) error {
+	if _, err := uuid.Parse(statementID); err != nil {
+		return ErrBankStatementNotFound
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var reconciled bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM bank_statement_lines l
+			WHERE l.statement_id = st.id AND l.status <> $3
+		)
+		FROM bank_statements st
+		WHERE st.id = $1 AND st.company_id = $2
+		FOR UPDATE
+	`, statementID, companyID, models.BankLineUnmatched).Scan(&reconciled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrBankStatementNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load bank statement: %w", err)
+	}
+	if reconciled {
+		return ErrBankStatementReconciled
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM bank_statements WHERE id = $1`, statementID); err != nil {
+		return fmt.Errorf("failed to delete bank statement: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *BankService) getStatement(ctx context.Context, companyID, statementID string) (*models.BankStatement, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+bankStatementColumns+` FROM bank_statements WHERE id = $1 AND company_id = $2
+	`, statementID, companyID)
+
+	statement, err := scanBankStatement(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBankStatementNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bank statement: %w", err)
+	}
+	return statement, nil
+}
+
+// ============================================
+// STATEMENT LINES
+// ============================================
+
+// ListLines returns a bank account's statement lines by date, with the
+// payments each one is matched with
+func (s *BankService) ListLines(ctx context.Context, companyID, accountID string, filters *models.BankLineFilters) ([]models.BankStatementLine, error) {
+	if _, err := s.GetAccount(ctx, companyID, accountID); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + bankLineColumns + ` FROM bank_statement_lines WHERE bank_account_id = $1 AND company_id = $2`
+	args := []interface{}{accountID, companyID}
+	if filters.FromDate != "" {
+		args = append(args, filters.FromDate)
+		query += fmt.Sprintf(" AND transaction_date >= $%d", len(args))
+	}
+	if filters.ToDate != "" {
+		args = append(args, filters.ToDate)
+		query += fmt.Sprintf(" AND transaction_date <= $%d", len(args))
+	}
+	if filters.Status != "" {
+		args = append(args, filters.Status)
+		query += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	if filters.StatementID != "" {
+		args = append(args, filters.StatementID)
+		query += fmt.Sprintf(" AND statement_id::text = $%d", len(args))
+	}
+
+	query += " ORDER BY transaction_date, id"
+	if filters.Limit > 0 {
+		args = append(args, filters.Limit, filters.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	lines, err := s.queryLines(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachBankMatches(ctx, lines); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// GetLine returns one statement line with its matches
+func (s *BankService) GetLine(ctx context.Context, companyID, lineID string) (*models.BankStatementLine, error) {
+	if _, err := uuid.Parse(lineID); err != nil {
+		return nil, ErrBankLineNotFound
+	}
+	lines, err := s.queryLines(ctx, `
+		SELECT `+bankLineColumns+` FROM bank_statement_lines WHERE id = $1 AND company_id = $2
+	`, lineID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, ErrBankLineNotFound
+	}
+	if err := s.attachBankMatches(ctx, lines); err != nil {
+		return nil, err
+	}
+	return &lines[0], nil
+}
+
+func (s *BankService) queryLines(ctx context.Context, query string, args ...interface{}) ([]models.BankStatementLine, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statement lines: %w", err)
+	}
+	defer rows.Close()
+
+	lines := []models.BankStatementLine{}
+	for rows.Next() {
+		line, err := scanBankLine(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan statement line: %w", err)
+		}
+		lines = append(lines, *line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating statement lines: %w", err)
+	}
+	return lines, nil
+}
+
+// attachBankMatches loads the matches of the lines in one query
+func (s *BankService) attachBankMatches(ctx context.Context, lines []models.BankStatementLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	ids := make([]string, len(lines))
+	index := make(map[string]int, len(lines))
+	for i := range lines {
+		ids[i] = lines[i].ID
+		index[lines[i].ID] = i
+		lines[i].Matches = []models.BankMatch{}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT m.statement_line_id, m.id,
+		       CASE WHEN m.payment_id IS NOT NULL THEN $2 ELSE $3 END,
+		       COALESCE(p.id, sp.id)::text,
+		       to_char(COALESCE(p.payment_date::date, sp.payment_date), 'YYYY-MM-DD'),
+		       CASE WHEN m.payment_id IS NOT NULL THEN p.amount ELSE -sp.amount END,
+		       COALESCE(p.payment_method, sp.payment_method),
+		       COALESCE(p.payment_reference, sp.payment_reference),
+		       COALESCE(i.client_name, pu.supplier_name, su.name, ''),
+		       COALESCE(i.invoice_number, pu.dte_numero_control, pu.purchase_number, '')
+		FROM bank_matches m
+		LEFT JOIN payments p ON p.id = m.payment_id
+		LEFT JOIN invoices i ON i.id = p.invoice_id
+		LEFT JOIN supplier_payments sp ON sp.id = m.supplier_payment_id
+		LEFT JOIN purchases pu ON pu.id = sp.purchase_id
+		LEFT JOIN suppliers su ON su.id = sp.supplier_id
+		WHERE m.statement_line_id = ANY($1::uuid[])
+		ORDER BY m.statement_line_id, m.created_at
+	`, pq.Array(ids), models.BankItemPayment, models.BankItemSupplierPayment)
+	if err != nil {
+		return fmt.Errorf("failed to load bank matches: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var lineID string
+		var m models.BankMatch
+		if err := rows.Scan(&lineID, &m.ID, &m.Kind, &m.PaymentID, &m.Date, &m.Amount, &m.PaymentMethod,
+			&m.Reference, &m.Counterparty, &m.Document); err != nil {
+			return fmt.Errorf("failed to scan bank match: %w", err)
+		}
+		i := index[lineID]
+		lines[i].Matches = append(lines[i].Matches, m)
+	}
+	return rows.Err()
+}
+
+// ============================================
+// MATCHING
+// ============================================
+
+// AutoMatch reconciles a bank account's unmatched lines with unmatched
+// payments of the same amount dated at most toleranceDays apart: deposits
+// with client payments and withdrawals with supplier payments. A payment
+// whose reference (or document number) appears on the line wins; otherwise
+// the line is matched only when a single payment fits, and ambiguous lines
+// are left for manual matching.
+func (s *BankService) AutoMatch(ctx context.Context, companyID, accountID string, toleranceDays int) (*models.BankAutoMatchResult, error) {
+	if _, err := s.GetAccount(ctx, companyID, accountID); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+bankLineColumns+`
+		FROM bank_statement_lines
+		WHERE bank_account_id = $1 AND company_id = $2 AND status = $3
+		ORDER BY transaction_date, id
+		FOR UPDATE
+	`, accountID, companyID, models.BankLineUnmatched)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load unmatched lines: %w", err)
+	}
+	lines := []models.BankStatementLine{}
+	for rows.Next() {
+		line, err := scanBankLine(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan statement line: %w", err)
+		}
+		lines = append(lines, *line)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating statement lines: %w", err)
+	}
+
+	result := &models.BankAutoMatchResult{}
+	if len(lines) == 0 {
+		return result, nil
+	}
+
+	first, _ := time.Parse("2006-01-02", lines[0].TransactionDate)
+	last, _ := time.Parse("2006-01-02", lines[len(lines)-1].TransactionDate)
+	items, err := bankBookItems(ctx, tx, companyID,
+		first.AddDate(0, 0, -toleranceDays).Format("2006-01-02"),
+		last.AddDate(0, 0, toleranceDays).Format("2006-01-02"), "")
+	if err != nil {
+		return nil, err
+	}
+
+	used := map[string]bool{}
+	for _, line := range lines {
+		item := pickAutoMatch(line, items, used, toleranceDays)
+		if item == nil {
+			result.Unmatched++
+			continue
+		}
+		used[item.PaymentID] = true
+		if err := insertBankMatchTx(ctx, tx, companyID, line.ID, item); err != nil {
+			return nil, err
+		}
+		if err := markBankLineTx(ctx, tx, line.ID, models.BankLineMatched, models.BankMatchAuto, nil, ""); err != nil {
+			return nil, err
+		}
+		result.Matched++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// pickAutoMatch returns the payment a line is matched with automatically, or
+// nil when none or several fit
+func pickAutoMatch(line models.BankStatementLine, items []models.BankBookItem, used map[string]bool, toleranceDays int) *models.BankBookItem {
+	var fits, byReference []*models.BankBookItem
+	for i := range items {
+		item := &items[i]
+		if used[item.PaymentID] || toCents(item.Amount) != toCents(line.Amount) {
+			continue
+		}
+		if daysApart(item.Date, line.TransactionDate) > toleranceDays {
+			continue
+		}
+		fits = append(fits, item)
+		if bankReferencesMatch(line, *item) {
+			byReference = append(byReference, item)
+		}
+	}
+	if len(byReference) == 1 {
+		return byReference[0]
+	}
+	if len(byReference) == 0 && len(fits) == 1 {
+		return fits[0]
+	}
+	return nil
+}
+
+// bankReferencesMatch reports whether the payment's reference or document
+// number appears on the statement line, or the line's reference in the
+// payment's. Short references are ignored; they match by accident.
+func bankReferencesMatch(line models.BankStatementLine, item models.BankBookItem) bool {
+	lineText := normalizeBankReference(stringOrEmpty(line.Reference) + " " + line.Description)
+	itemRef := normalizeBankReference(stringOrEmpty(item.Reference))
+	document := normalizeBankReference(item.Document)
+
+	if len(itemRef) >= 4 && strings.Contains(lineText, itemRef) {
+		return true
+	}
+	if len(document) >= 4 && strings.Contains(lineText, document) {
+		return true
+	}
+	lineRef := normalizeBankReference(stringOrEmpty(line.Reference))
+	return len(lineRef) >= 4 && strings.Contains(itemRef, lineRef)
+}
+
+// normalizeBankReference keeps the letters and digits of a reference, in
+// upper case
+func normalizeBankReference(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// MatchLine reconciles a statement line with payments by hand. Deposits take
+// client payments and withdrawals supplier payments; the payments must be
+// neither voided nor matched and must add up to the line amount.
+func (s *BankService) MatchLine(ctx context.Context, companyID, lineID, userID string, req *models.MatchBankLineRequest) (*models.BankStatementLine, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	line, err := lockBankLineTx(ctx, tx, companyID, lineID)
+	if err != nil {
+		return nil, err
+	}
+	if line.Status != models.BankLineUnmatched {
+		return nil, ErrBankLineNotUnmatched
+	}
+	if line.Amount > 0 && len(req.SupplierPaymentIDs) > 0 {
+		return nil, fmt.Errorf("validation failed: deposits are matched with client payments")
+	}
+	if line.Amount < 0 && len(req.PaymentIDs) > 0 {
+		return nil, fmt.Errorf("validation failed: withdrawals are matched with supplier payments")
+	}
+
+	kind, ids := models.BankItemPayment, req.PaymentIDs
+	if line.Amount < 0 {
+		kind, ids = models.BankItemSupplierPayment, req.SupplierPaymentIDs
+	}
+
+	var totalCents int64
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		item, err := lockBankBookItemTx(ctx, tx, companyID, kind, id)
+		if err != nil {
+			return nil, err
+		}
+		totalCents += toCents(item.Amount)
+		if err := insertBankMatchTx(ctx, tx, companyID, line.ID, item); err != nil {
+			return nil, err
+		}
+	}
+	if totalCents != toCents(line.Amount) {
+		return nil, fmt.Errorf("%w: payments %.2f, line %.2f", ErrBankMatchAmountMismatch,
+			float64(totalCents)/100, line.Amount)
+	}
+
+	if err := markBankLineTx(ctx, tx, line.ID, models.BankLineMatched, models.BankMatchManual, nil, userID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return s.GetLine(ctx, companyID, lineID)
+}
+
+// IgnoreLine marks an unmatched statement line that no payment will match,
+// such as bank fees or interest, with a note saying why
+func (s *BankService) IgnoreLine(ctx context.Context, companyID, lineID, userID string, req *models.IgnoreBankLineRequest) (*models.BankStatementLine, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	line, err := lockBankLineTx(ctx, tx, companyID, lineID)
+	if err != nil {
+		return nil, err
+	}
+	if line.Status != models.BankLineUnmatched {
+		return nil, ErrBankLineNotUnmatched
+	}
+	if err := markBankLineTx(ctx, tx, line.ID, models.BankLineIgnored, "", &req.Note, userID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return s.GetLine(ctx, companyID, lineID)
+}
+
+// UnmatchLine returns a matched or ignored line to unmatched, releasing its
+// payments
+func (s *BankService) UnmatchLine(ctx context.Context, companyID, lineID string) (*models.BankStatementLine, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	line, err := lockBankLineTx(ctx, tx, companyID, lineID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM bank_matches WHERE statement_line_id = $1`, line.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete bank matches: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE bank_statement_lines
+		SET status = $1, match_method = NULL, note = NULL, matched_by = NULL, matched_at = NULL
+		WHERE id = $2
+	`, models.BankLineUnmatched, line.ID); err != nil {
+		return nil, fmt.Errorf("failed to update statement line: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return s.GetLine(ctx, companyID, lineID)
+}
+
+// ListCandidates returns the unmatched payments a line could be matched
+// with, dated within 30 days of it: closest amount first, then closest date
+func (s *BankService) ListCandidates(ctx context.Context, companyID, lineID string) ([]models.BankBookItem, error) {
+	line, err := s.GetLine(ctx, companyID, lineID)
+	if err != nil {
+		return nil, err
+	}
+
+	kind := models.BankItemPayment
+	if line.Amount < 0 {
+		kind = models.BankItemSupplierPayment
+	}
+	date, _ := time.Parse("2006-01-02", line.TransactionDate)
+	items, err := bankBookItems(ctx, s.db, companyID,
+		date.AddDate(0, 0, -bankCandidateWindowDays).Format("2006-01-02"),
+		date.AddDate(0, 0, bankCandidateWindowDays).Format("2006-01-02"), kind)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		di := math.Abs(items[i].Amount - line.Amount)
+		dj := math.Abs(items[j].Amount - line.Amount)
+		if di != dj {
+			return di < dj
+		}
+		return daysApart(items[i].Date, line.TransactionDate) < daysApart(items[j].Date, line.TransactionDate)
+	})
+	if len(items) > 50 {
+		items = items[:50]
+	}
+	return items, nil
+}
+
+// ============================================
+// RECONCILIATION REPORT
+// ============================================
+
+// GetReconciliation reports a bank account's reconciliation for a period:
+// statement totals by status, the statement lines still unmatched, and the
+// payments of the period not found on any statement
+func (s *BankService) GetReconciliation(ctx context.Context, companyID, accountID, fromDate, toDate string) (*models.BankReconciliation, error) {
+	account, err := s.GetAccount(ctx, companyID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.BankReconciliation{
+		CompanyID:      companyID,
+		BankAccount:    *account,
+		FromDate:       fromDate,
+		ToDate:         toDate,
+		UnmatchedLines: []models.BankStatementLine{},
+		GeneratedAt:    time.Now(),
+	}
+	if err := loadReportCompany(ctx, s.db, companyID, &report.CompanyName, &report.NIT, &report.NRC); err != nil {
+		return nil, err
+	}
+
+	lines, err := s.queryLines(ctx, `
+		SELECT `+bankLineColumns+`
+		FROM bank_statement_lines
+		WHERE bank_account_id = $1 AND company_id = $2 AND transaction_date BETWEEN $3 AND $4
+		ORDER BY transaction_date, id
+	`, accountID, companyID, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := &report.Summary
+	for _, line := range lines {
+		sum.StatementLines++
+		if line.Amount > 0 {
+			sum.Deposits += line.Amount
+		} else {
+			sum.Withdrawals += line.Amount
+		}
+		switch line.Status {
+		case models.BankLineMatched:
+			sum.MatchedLines++
+		case models.BankLineIgnored:
+			sum.IgnoredLines++
+		default:
+			sum.UnmatchedLines++
+			if line.Amount > 0 {
+				sum.UnmatchedDeposits += line.Amount
+			} else {
+				sum.UnmatchedWithdrawals += line.Amount
+			}
+			line.Matches = []models.BankMatch{}
+			report.UnmatchedLines = append(report.UnmatchedLines, line)
+		}
+	}
+
+	report.UnmatchedItems, err = bankBookItems(ctx, s.db, companyID, fromDate, toDate, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range report.UnmatchedItems {
+		if item.Amount > 0 {
+			sum.UnmatchedReceipts += item.Amount
+		} else {
+			sum.UnmatchedDisbursements += item.Amount
+		}
+	}
+
+	sum.Deposits = round(sum.Deposits)
+	sum.Withdrawals = round(sum.Withdrawals)
+	sum.UnmatchedDeposits = round(sum.UnmatchedDeposits)
+	sum.UnmatchedWithdrawals = round(sum.UnmatchedWithdrawals)
+	sum.UnmatchedReceipts = round(sum.UnmatchedReceipts)
+	sum.UnmatchedDisbursements = round(sum.UnmatchedDisbursements)
+
+	var balance sql.NullFloat64
+	var balanceOn string
+	err = s.db.QueryRowContext(ctx, `
+		SELECT closing_balance, to_char(to_date, 'YYYY-MM-DD')
+		FROM bank_statements
+		WHERE bank_account_id = $1 AND closing_balance IS NOT NULL AND to_date BETWEEN $2 AND $3
+		ORDER BY to_date DESC, imported_at DESC
+		LIMIT 1
+	`, accountID, fromDate, toDate).Scan(&balance, &balanceOn)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to load statement balance: %w", err)
+	}
+	if balance.Valid {
+		report.StatementBalance = &balance.Float64
+		report.StatementBalanceOn = balanceOn
+	}
+	return report, nil
+}
+
+// ============================================
+// HELPERS
+// ============================================
+
+// bankBookItems returns the payments dated from..to that are neither voided
+// nor matched: client payments as receipts and supplier payments as
+// disbursements. kind narrows them to one of the two. Cash payments never
+// reach the bank one by one and are left out.
+func bankBookItems(ctx context.Context, q ledgerQuerier, companyID, from, to, kind string) ([]models.BankBookItem, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT kind, id, date, amount, payment_method, reference, counterparty, document
+		FROM (
+			SELECT $4 AS kind, p.id::text AS id, to_char(p.payment_date, 'YYYY-MM-DD') AS date,
+			       p.amount, p.payment_method, p.payment_reference AS reference,
+			       COALESCE(i.client_name, '') AS counterparty, i.invoice_number AS document
+			FROM payments p
+			JOIN invoices i ON i.id = p.invoice_id
+			WHERE p.company_id = $1
+			  AND p.payment_date::date BETWEEN $2 AND $3
+			  AND p.voided_at IS NULL
+			  AND p.payment_method <> $7
+			  AND NOT EXISTS (SELECT 1 FROM bank_matches m WHERE m.payment_id = p.id)
+			UNION ALL
+			SELECT $5, sp.id::text, to_char(sp.payment_date, 'YYYY-MM-DD'),
+			       -sp.amount, sp.payment_method, sp.payment_reference,
+			       COALESCE(pu.supplier_name, su.name, ''), COALESCE(pu.dte_numero_control, pu.purchase_number, '')
+			FROM supplier_payments sp
+			JOIN purchases pu ON pu.id = sp.purchase_id
+			LEFT JOIN suppliers su ON su.id = sp.supplier_id
+			WHERE sp.company_id = $1
+			  AND sp.payment_date BETWEEN $2 AND $3
+			  AND sp.voided_at IS NULL
+			  AND sp.payment_method <> $7
+			  AND NOT EXISTS (SELECT 1 FROM bank_matches m WHERE m.supplier_payment_id = sp.id)
+		) b
+		WHERE ($6 = '' OR kind = $6)
+		ORDER BY date, document
+	`, companyID, from, to, models.BankItemPayment, models.BankItemSupplierPayment, kind, codigos.PaymentBilletesMonedas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load payments: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.BankBookItem{}
+	for rows.Next() {
+		var item models.BankBookItem
+		if err := rows.Scan(&item.Kind, &item.PaymentID, &item.Date, &item.Amount, &item.PaymentMethod,
+			&item.Reference, &item.Counterparty, &item.Document); err != nil {
+			return nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// lockBankBookItemTx locks a payment being matched by hand and checks it is
+// available
+func lockBankBookItemTx(ctx context.Context, tx *sql.Tx, companyID, kind, paymentID string) (*models.BankBookItem, error) {
+	query := `
+		SELECT p.amount, p.voided_at IS NOT NULL,
+		       EXISTS (SELECT 1 FROM bank_matches m WHERE m.payment_id = p.id)
+		FROM payments p
+		WHERE p.id::text = $1 AND p.company_id = $2
+		FOR UPDATE
+	`
+	notFound := ErrPaymentNotFound
+	if kind == models.BankItemSupplierPayment {
+		query = `
+			SELECT -sp.amount, sp.voided_at IS NOT NULL,
+			       EXISTS (SELECT 1 FROM bank_matches m WHERE m.supplier_payment_id = sp.id)
+			FROM supplier_payments sp
+			WHERE sp.id::text = $1 AND sp.company_id = $2
+			FOR UPDATE
+		`
+		notFound = ErrSupplierPaymentNotFound
+	}
+
+	item := &models.BankBookItem{Kind: kind, PaymentID: paymentID}
+	var voided, matched bool
+	err := tx.QueryRowContext(ctx, query, paymentID, companyID).Scan(&item.Amount, &voided, &matched)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load payment: %w", err)
+	}
+	if voided || matched {
+		return nil, fmt.Errorf("%w: %s", ErrBankPaymentUnavailable, paymentID)
+	}
+	return item, nil
+}
+
+// lockBankLineTx locks a statement line being matched, ignored or unmatched
+func lockBankLineTx(ctx context.Context, tx *sql.Tx, companyID, lineID string) (*models.BankStatementLine, error) {
+	if _, err := uuid.Parse(lineID); err != nil {
+		return nil, ErrBankLineNotFound
+	}
+	row := tx.QueryRowContext(ctx, `
+		SELECT `+bankLineColumns+` FROM bank_statement_lines WHERE id = $1 AND company_id = $2 FOR UPDATE
+	`, lineID, companyID)
+
+	line, err := scanBankLine(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBankLineNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load statement line: %w", err)
+	}
+	return line, nil
+}
+
+func insertBankMatchTx(ctx context.Context, tx *sql.Tx, companyID, lineID string, item *models.BankBookItem) error {
+	var paymentID, supplierPaymentID *string
+	if item.Kind == models.BankItemSupplierPayment {
+		supplierPaymentID = &item.PaymentID
+	} else {
+		paymentID = &item.PaymentID
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO bank_matches (company_id, statement_line_id, payment_id, supplier_payment_id, amount)
+		VALUES ($1, $2, $3, $4, $5)
+	`, companyID, lineID, paymentID, supplierPaymentID, math.Abs(item.Amount))
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return fmt.Errorf("%w: %s", ErrBankPaymentUnavailable, item.PaymentID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert bank match: %w", err)
+	}
+	return nil
+}
+
+func markBankLineTx(ctx context.Context, tx *sql.Tx, lineID, status, method string, note *string, userID string) error {
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE bank_statement_lines
+		SET status = $1, match_method = $2, note = $3, matched_by = $4, matched_at = NOW()
+		WHERE id = $5
+	`, status, nullIfBlank(&method), note, nullIfBlank(&userID), lineID); err != nil {
+		return fmt.Errorf("failed to update statement line: %w", err)
+	}
+	return nil
+}
+
+// checkPaymentUnreconciledTx returns ErrPaymentReconciled when a client or
+// supplier payment about to be voided is matched to a statement line
+func checkPaymentUnreconciledTx(ctx context.Context, tx *sql.Tx, kind, paymentID string) error {
+	column := "payment_id"
+	if kind == models.BankItemSupplierPayment {
+		column = "supplier_payment_id"
+	}
+	var matched bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM bank_matches WHERE `+column+`::text = $1)
+	`, paymentID).Scan(&matched)
+	if err != nil {
+		return fmt.Errorf("failed to check bank matches: %w", err)
+	}
+	if matched {
+		return ErrPaymentReconciled
+	}
+	return nil
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func daysApart(a, b string) int {
+	ta, _ := time.Parse("2006-01-02", a)
+	tb, _ := time.Parse("2006-01-02", b)
+	days := int(ta.Sub(tb).Hours() / 24)
+	if days < 0 {
+		return -days
+	}
+	return days
+}
+
+func marshalBankLayout(layout *models.BankCSVLayout) ([]byte, error) {
+	if layout == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(layout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode csv_layout: %w", err)
+	}
+	return data, nil
+}
+
+func bankAccountWriteError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrBankAccountNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrBankAccountNumberTaken
+	}
+	return fmt.Errorf("failed to save bank account: %w", err)
+}
+
+func scanBankAccount(row rowScanner) (*models.BankAccount, error) {
+	var a models.BankAccount
+	var layout []byte
+	err := row.Scan(
+		&a.ID, &a.CompanyID, &a.Name, &a.BankName, &a.AccountNumber, &a.Currency,
+		&layout, &a.Active, &a.CreatedAt, &a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(layout) > 0 {
+		a.CSVLayout = &models.BankCSVLayout{}
+		if err := json.Unmarshal(layout, a.CSVLayout); err != nil {
+			return nil, fmt.Errorf("invalid csv_layout: %w", err)
+		}
+	}
+	return &a, nil
+}
+
+func scanBankStatement(row rowScanner) (*models.BankStatement, error) {
+	var st models.BankStatement
+	err := row.Scan(
+		&st.ID, &st.CompanyID, &st.BankAccountID, &st.Format, &st.Filename, &st.FromDate,
+		&st.ToDate, &st.ClosingBalance, &st.LineCount, &st.SkippedCount, &st.ImportedBy, &st.ImportedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func scanBankLine(row rowScanner) (*models.BankStatementLine, error) {
+	var l models.BankStatementLine
+	err := row.Scan(
+		&l.ID, &l.BankAccountID, &l.StatementID, &l.TransactionDate, &l.Amount, &l.Description,
+		&l.Reference, &l.TransactionID, &l.Status, &l.MatchMethod, &l.Note, &l.MatchedBy, &l.MatchedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	l.Matches = []models.BankMatch{}
+	return &l, nil
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"cuentas/internal/models"
+)
+
+// parsedStatement is a statement file read into lines, before it is stored
+type parsedStatement struct {
+	fromDate       string
+	toDate         string
+	closingBalance *float64
+	lines          []parsedBankLine
+}
+
+// parsedBankLine is a statement transaction; amount is negative for
+// withdrawals
+type parsedBankLine struct {
+	date          string
+	amount        float64
+	description   string
+	reference     *string
+	transactionID string
+}
+
+// detectBankFormat picks OFX for .ofx and .qfx files and for content that
+// looks like OFX, and CSV otherwise
+func detectBankFormat(filename string, data []byte) string {
+	lower := strings.ToLower(filename)
+	if strings.HasSuffix(lower, ".ofx") || strings.HasSuffix(lower, ".qfx") {
+		return models.BankFormatOFX
+	}
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	if bytes.Contains(bytes.ToUpper(head), []byte("<OFX>")) || bytes.Contains(head, []byte("OFXHEADER")) {
+		return models.BankFormatOFX
+	}
+	return models.BankFormatCSV
+}
+
+// ============================================
+// CSV
+// ============================================
+
+// parseBankCSV reads a CSV statement with the account's layout. Blank rows
+// are skipped; any other row that cannot be read fails the import with its
+// row number. Rows carry no transaction ID, so one is derived from the row's
+// content and its repetition within the file.
+func parseBankCSV(data []byte, layout *models.BankCSVLayout) (*parsedStatement, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.Comma = []rune(layout.Delimiter)[0]
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+
+	statement := &parsedStatement{}
+	seen := map[string]int{}
+	for i := 0; ; i++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		if i < layout.HeaderRows || blankRecord(record) {
+			continue
+		}
+		// The reader drops empty lines, so report the row as the file's line
+		row, _ := reader.FieldPos(0)
+
+		field := func(column int) string {
+			if column < len(record) {
+				return strings.TrimSpace(record[column])
+			}
+			return ""
+		}
+
+		date, err := time.Parse(layout.DateLayout(), field(layout.DateColumn))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid date %q, expected %s", row, field(layout.DateColumn), layout.DateFormat)
+		}
+
+		var amount float64
+		if layout.AmountColumn != nil {
+			amount, err = parseBankAmount(field(*layout.AmountColumn), layout.DecimalComma)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", row, err)
+			}
+		} else {
+			debit, err := parseBankAmount(field(*layout.DebitColumn), layout.DecimalComma)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", row, err)
+			}
+			credit, err := parseBankAmount(field(*layout.CreditColumn), layout.DecimalComma)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", row, err)
+			}
+			amount = credit - math.Abs(debit)
+		}
+		amount = round(amount)
+		if amount == 0 {
+			continue
+		}
+
+		line := parsedBankLine{
+			date:        date.Format("2006-01-02"),
+			amount:      amount,
+			description: field(layout.DescriptionColumn),
+		}
+		if layout.ReferenceColumn != nil {
+			if ref := field(*layout.ReferenceColumn); ref != "" {
+				line.reference = &ref
+			}
+		}
+
+		key := fmt.Sprintf("%s|%.2f|%s|%s", line.date, line.amount, line.description, stringOrEmpty(line.reference))
+		seen[key]++
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", key, seen[key])))
+		line.transactionID = "csv:" + hex.EncodeToString(sum[:16])
+
+		statement.add(line)
+	}
+	return statement, nil
+}
+
+// parseBankAmount reads an amount as banks print it: with a currency sign,
+// thousands separators, or a negative in parentheses. Empty is zero.
+func parseBankAmount(s string, decimalComma bool) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+	s = strings.NewReplacer("$", "", "USD", "", " ", "").Replace(s)
+	if decimalComma {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.ReplaceAll(s, ",", ".")
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	if strings.HasSuffix(s, "-") {
+		negative = !negative
+		s = strings.TrimSuffix(s, "-")
+	}
+
+	amount, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func blankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================
+// OFX
+// ============================================
+
+var (
+	ofxTransactionPattern = regexp.MustCompile(`(?is)<STMTTRN>(.*?)</STMTTRN>`)
+	ofxLedgerBalPattern   = regexp.MustCompile(`(?is)<LEDGERBAL>(.*?)</LEDGERBAL>`)
+)
+
+// parseOFX reads the bank transactions of an OFX file, in the SGML (1.x) or
+// XML (2.x) flavour. Aggregates are closed in both; elements are read up to
+// the next tag or line end, since SGML leaves them unclosed.
+func parseOFX(data []byte) (*parsedStatement, error) {
+	content := string(data)
+	statement := &parsedStatement{}
+
+	for _, match := range ofxTransactionPattern.FindAllStringSubmatch(content, -1) {
+		block := match[1]
+
+		date, err := parseOFXDate(ofxValue(block, "DTPOSTED"))
+		if err != nil {
+			return nil, fmt.Errorf("transaction %s: %w", ofxValue(block, "FITID"), err)
+		}
+		amount, err := parseBankAmount(ofxValue(block, "TRNAMT"), false)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %s: %w", ofxValue(block, "FITID"), err)
+		}
+		amount = round(amount)
+		if amount == 0 {
+			continue
+		}
+
+		line := parsedBankLine{
+			date:          date,
+			amount:        amount,
+			transactionID: ofxValue(block, "FITID"),
+		}
+		name, memo := ofxValue(block, "NAME"), ofxValue(block, "MEMO")
+		line.description = strings.TrimSpace(name + " " + memo)
+		if name != "" && memo != "" && strings.Contains(memo, name) {
+			line.description = memo
+		}
+		for _, tag := range []string{"CHECKNUM", "REFNUM"} {
+			if ref := ofxValue(block, tag); ref != "" {
+				line.reference = &ref
+				break
+			}
+		}
+		if line.transactionID == "" {
+			sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%.2f|%s", line.date, line.amount, line.description)))
+			line.transactionID = "ofx:" + hex.EncodeToString(sum[:16])
+		}
+
+		statement.add(line)
+	}
+
+	if start, err := parseOFXDate(ofxValue(content, "DTSTART")); err == nil && (statement.fromDate == "" || start < statement.fromDate) {
+		statement.fromDate = start
+	}
+	if end, err := parseOFXDate(ofxValue(content, "DTEND")); err == nil && end > statement.toDate {
+		statement.toDate = end
+	}
+	if m := ofxLedgerBalPattern.FindStringSubmatch(content); m != nil {
+		if value := ofxValue(m[1], "BALAMT"); value != "" {
+			if balance, err := parseBankAmount(value, false); err == nil {
+				balance = round(balance)
+				statement.closingBalance = &balance
+			}
+		}
+	}
+	return statement, nil
+}
+
+// ofxValue returns the text of the first element with the tag: up to its
+// closing tag, the next tag or the end of the line. Tags are matched without
+// regard to case on the original content, so offsets stay valid whatever the
+// text around them holds.
+func ofxValue(content, tag string) string {
+	for offset := 0; offset < len(content); {
+		i := strings.IndexByte(content[offset:], '<')
+		if i < 0 {
+			return ""
+		}
+		start := offset + i + 1
+		end := start + len(tag)
+		if end < len(content) && content[end] == '>' && strings.EqualFold(content[start:end], tag) {
+			value := content[end+1:]
+			if stop := strings.IndexAny(value, "<\r\n"); stop >= 0 {
+				value = value[:stop]
+			}
+			return strings.TrimSpace(strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">").Replace(value))
+		}
+		offset = start
+	}
+	return ""
+}
+
+// parseOFXDate reads the date of an OFX datetime (YYYYMMDD, optionally
+// followed by the time and time zone)
+func parseOFXDate(s string) (string, error) {
+	if len(s) < 8 {
+		return "", fmt.Errorf("invalid date %q", s)
+	}
+	date, err := time.Parse("20060102", s[:8])
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q", s)
+	}
+	return date.Format("2006-01-02"), nil
+}
+
+// add appends a line and widens the statement's dates to cover it
+func (p *parsedStatement) add(line parsedBankLine) {
+	p.lines = append(p.lines, line)
+	if p.fromDate == "" || line.date < p.fromDate {
+		p.fromDate = line.date
+	}
+	if line.date > p.toDate {
+		p.toDate = line.date
+	}
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,363 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"cuentas/internal/models"
+)
+
+func TestParseBankAmount(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		decimalComma bool
+		want         float64
+		wantErr      bool
+	}{
+		{name: "empty", input: "", want: 0},
+		{name: "plain", input: "125.50", want: 125.5},
+		{name: "negative", input: "-125.50", want: -125.5},
+		{name: "thousands separator", input: "1,234.56", want: 1234.56},
+		{name: "currency sign", input: "$ 1,234.56", want: 1234.56},
+		{name: "currency code", input: "USD 80.00", want: 80},
+		{name: "parenthesised negative", input: "(1,234.56)", want: -1234.56},
+		{name: "trailing minus", input: "1,234.56-", want: -1234.56},
+		{name: "decimal comma", input: "1.234,56", decimalComma: true, want: 1234.56},
+		{name: "decimal comma without thousands", input: "0,75", decimalComma: true, want: 0.75},
+		{name: "decimal comma parenthesised", input: "(1.234,56)", decimalComma: true, want: -1234.56},
+		{name: "decimal comma trailing minus", input: "12,50-", decimalComma: true, want: -12.5},
+		{name: "not a number", input: "N/A", wantErr: true},
+		{name: "two decimal points", input: "1.2.3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBankAmount(tt.input, tt.decimalComma)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBankAmount(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseBankAmount(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBankCSV(t *testing.T) {
+	column := func(i int) *int { return &i }
+	ref := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		data     string
+		layout   models.BankCSVLayout
+		want     []parsedBankLine
+		wantFrom string
+		wantTo   string
+	}{
+		{
+			name: "signed amount column",
+			data: "Fecha,Descripcion,Referencia,Monto\n" +
+				"03/02/2025,Deposito cliente,D-100,\"1,500.00\"\n" +
+				"\n" +
+				"05/02/2025,Pago proveedor,,(250.75)\n" +
+				"06/02/2025,Sin movimiento,,0.00\n",
+			layout: models.BankCSVLayout{
+				Delimiter: ",", HeaderRows: 1, DateFormat: "DD/MM/YYYY",
+				DateColumn: 0, DescriptionColumn: 1, ReferenceColumn: column(2), AmountColumn: column(3),
+			},
+			want: []parsedBankLine{
+				{date: "2025-02-03", amount: 1500, description: "Deposito cliente", reference: ref("D-100")},
+				{date: "2025-02-05", amount: -250.75, description: "Pago proveedor"},
+			},
+			wantFrom: "2025-02-03",
+			wantTo:   "2025-02-05",
+		},
+		{
+			name: "split debit and credit columns",
+			data: "Estado de cuenta\nFecha;Concepto;Cargo;Abono\n" +
+				"2025-02-10;Comision;3,50;\n" +
+				"2025-02-11;Transferencia;;1.200,00\n" +
+				"2025-02-12;Cheque 0045;-80,00;\n",
+			layout: models.BankCSVLayout{
+				Delimiter: ";", HeaderRows: 2, DateFormat: "YYYY-MM-DD", DecimalComma: true,
+				DateColumn: 0, DescriptionColumn: 1, DebitColumn: column(2), CreditColumn: column(3),
+			},
+			want: []parsedBankLine{
+				{date: "2025-02-10", amount: -3.5, description: "Comision"},
+				{date: "2025-02-11", amount: 1200, description: "Transferencia"},
+				{date: "2025-02-12", amount: -80, description: "Cheque 0045"},
+			},
+			wantFrom: "2025-02-10",
+			wantTo:   "2025-02-12",
+		},
+		{
+			name: "byte order mark and trailing minus",
+			data: "\xef\xbb\xbf20250301,Retiro ATM,40.00-\n",
+			layout: models.BankCSVLayout{
+				Delimiter: ",", DateFormat: "YYYYMMDD",
+				DateColumn: 0, DescriptionColumn: 1, AmountColumn: column(2),
+			},
+			want: []parsedBankLine{
+				{date: "2025-03-01", amount: -40, description: "Retiro ATM"},
+			},
+			wantFrom: "2025-03-01",
+			wantTo:   "2025-03-01",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBankCSV([]byte(tt.data), &tt.layout)
+			if err != nil {
+				t.Fatalf("parseBankCSV() error = %v", err)
+			}
+			if len(got.lines) != len(tt.want) {
+				t.Fatalf("got %d lines, want %d", len(got.lines), len(tt.want))
+			}
+			for i, want := range tt.want {
+				line := got.lines[i]
+				if line.date != want.date || line.amount != want.amount || line.description != want.description ||
+					stringOrEmpty(line.reference) != stringOrEmpty(want.reference) {
+					t.Errorf("line %d = %+v (ref %q), want %+v (ref %q)",
+						i, line, stringOrEmpty(line.reference), want, stringOrEmpty(want.reference))
+				}
+				if !strings.HasPrefix(line.transactionID, "csv:") {
+					t.Errorf("line %d transaction ID = %q, want a csv: ID", i, line.transactionID)
+				}
+			}
+			if got.fromDate != tt.wantFrom || got.toDate != tt.wantTo {
+				t.Errorf("period = %s..%s, want %s..%s", got.fromDate, got.toDate, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestParseBankCSVRepeatedRows(t *testing.T) {
+	amount := 2
+	layout := &models.BankCSVLayout{Delimiter: ",", DateFormat: "DD/MM/YYYY", DescriptionColumn: 1, AmountColumn: &amount}
+	data := "03/02/2025,Cafe,-2.50\n03/02/2025,Cafe,-2.50\n"
+
+	first, err := parseBankCSV([]byte(data), layout)
+	if err != nil {
+		t.Fatalf("parseBankCSV() error = %v", err)
+	}
+	if len(first.lines) != 2 || first.lines[0].transactionID == first.lines[1].transactionID {
+		t.Fatalf("identical rows must get distinct transaction IDs: %+v", first.lines)
+	}
+
+	// Re-importing the same file yields the same IDs, so lines are not duplicated
+	second, err := parseBankCSV([]byte(data), layout)
+	if err != nil {
+		t.Fatalf("parseBankCSV() error = %v", err)
+	}
+	for i := range first.lines {
+		if first.lines[i].transactionID != second.lines[i].transactionID {
+			t.Errorf("line %d transaction ID changed between imports", i)
+		}
+	}
+}
+
+func TestParseBankCSVErrors(t *testing.T) {
+	amount := 2
+	layout := &models.BankCSVLayout{Delimiter: ",", HeaderRows: 1, DateFormat: "DD/MM/YYYY", DescriptionColumn: 1, AmountColumn: &amount}
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "bad date", data: "h\n03/02/2025,Ok,1.00\n2025-02-04,Mal,1.00\n", wantErr: "row 3: invalid date"},
+		{name: "bad amount", data: "h\n\n03/02/2025,Mal,abc\n", wantErr: "row 3: invalid amount"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseBankCSV([]byte(tt.data), layout)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseBankCSV() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+const testOFXSGML = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<BANKTRANLIST>
+<DTSTART>20250201
+<DTEND>20250228120000[-6:CST]
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20250203
+<TRNAMT>1500.00
+<FITID>202502030001
+<NAME>Cliente
+<MEMO>Deposito Cliente S.A.
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20250205093000
+<TRNAMT>-250.75
+<FITID>202502050002
+<CHECKNUM>0045
+<NAME>Papelería &amp; Cía
+</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL>
+<BALAMT>10250.25
+<DTASOF>20250228
+</LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+const testOFXXML = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<BANKTRANLIST>
+<DTSTART>20250301</DTSTART><DTEND>20250331</DTEND>
+<stmttrn><trntype>DEBIT</trntype><dtposted>20250310</dtposted><trnamt>-12.00</trnamt><fitid>X-1</fitid><name>Comisión</name><refnum>R77</refnum></stmttrn>
+<STMTTRN><TRNTYPE>CREDIT</TRNTYPE><DTPOSTED>20250315</DTPOSTED><TRNAMT>300.00</TRNAMT><FITID></FITID><NAME>Abono</NAME></STMTTRN>
+<STMTTRN><TRNTYPE>OTHER</TRNTYPE><DTPOSTED>20250316</DTPOSTED><TRNAMT>0.00</TRNAMT><FITID>X-3</FITID></STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>-42.10</BALAMT><DTASOF>20250331</DTASOF></LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>
+`
+
+func TestParseOFX(t *testing.T) {
+	ref := func(s string) *string { return &s }
+
+	tests := []struct {
+		name        string
+		data        string
+		want        []parsedBankLine
+		wantFrom    string
+		wantTo      string
+		wantBalance float64
+	}{
+		{
+			name: "SGML",
+			data: testOFXSGML,
+			want: []parsedBankLine{
+				{date: "2025-02-03", amount: 1500, description: "Deposito Cliente S.A.", transactionID: "202502030001"},
+				{date: "2025-02-05", amount: -250.75, description: "Papelería & Cía", reference: ref("0045"), transactionID: "202502050002"},
+			},
+			wantFrom:    "2025-02-01",
+			wantTo:      "2025-02-28",
+			wantBalance: 10250.25,
+		},
+		{
+			name: "XML",
+			data: testOFXXML,
+			want: []parsedBankLine{
+				{date: "2025-03-10", amount: -12, description: "Comisión", reference: ref("R77"), transactionID: "X-1"},
+				{date: "2025-03-15", amount: 300, description: "Abono"},
+			},
+			wantFrom:    "2025-03-01",
+			wantTo:      "2025-03-31",
+			wantBalance: -42.10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseOFX([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("parseOFX() error = %v", err)
+			}
+			if len(got.lines) != len(tt.want) {
+				t.Fatalf("got %d lines, want %d", len(got.lines), len(tt.want))
+			}
+			for i, want := range tt.want {
+				line := got.lines[i]
+				if line.date != want.date || line.amount != want.amount || line.description != want.description ||
+					stringOrEmpty(line.reference) != stringOrEmpty(want.reference) {
+					t.Errorf("line %d = %+v (ref %q), want %+v (ref %q)",
+						i, line, stringOrEmpty(line.reference), want, stringOrEmpty(want.reference))
+				}
+				if want.transactionID != "" && line.transactionID != want.transactionID {
+					t.Errorf("line %d transaction ID = %q, want %q", i, line.transactionID, want.transactionID)
+				}
+				if want.transactionID == "" && !strings.HasPrefix(line.transactionID, "ofx:") {
+					t.Errorf("line %d without FITID got transaction ID %q, want an ofx: ID", i, line.transactionID)
+				}
+			}
+			if got.fromDate != tt.wantFrom || got.toDate != tt.wantTo {
+				t.Errorf("period = %s..%s, want %s..%s", got.fromDate, got.toDate, tt.wantFrom, tt.wantTo)
+			}
+			if got.closingBalance == nil || *got.closingBalance != tt.wantBalance {
+				t.Errorf("closing balance = %v, want %v", got.closingBalance, tt.wantBalance)
+			}
+		})
+	}
+}
+
+func TestParseOFXFITIDFallbackIsStable(t *testing.T) {
+	first, err := parseOFX([]byte(testOFXXML))
+	if err != nil {
+		t.Fatalf("parseOFX() error = %v", err)
+	}
+	second, err := parseOFX([]byte(testOFXXML))
+	if err != nil {
+		t.Fatalf("parseOFX() error = %v", err)
+	}
+	if first.lines[1].transactionID != second.lines[1].transactionID {
+		t.Errorf("fallback transaction ID changed between imports: %q, %q", first.lines[1].transactionID, second.lines[1].transactionID)
+	}
+}
+
+func TestOFXValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		tag     string
+		want    string
+	}{
+		{name: "SGML unclosed", content: "<NAME>Tienda\n<MEMO>Compra", tag: "NAME", want: "Tienda"},
+		{name: "XML closed", content: "<NAME>Tienda</NAME><MEMO>Compra</MEMO>", tag: "MEMO", want: "Compra"},
+		{name: "lower case tag", content: "<name>Tienda</name>", tag: "NAME", want: "Tienda"},
+		{name: "missing", content: "<NAME>Tienda", tag: "MEMO", want: ""},
+		{name: "prefix of another tag", content: "<NAMEX>No\n<NAME>Si", tag: "NAME", want: "Si"},
+		{name: "entities", content: "<NAME>A &amp; B &lt;C&gt;\n", tag: "NAME", want: "A & B <C>"},
+		// "ı" and "ſ" are two bytes but upper-case to one, shifting byte
+		// offsets in an upper-cased copy of the content
+		{name: "text that shrinks when upper-cased", content: "<MEMO>ıſıſ\n<NAME>Kırıkkale", tag: "NAME", want: "Kırıkkale"},
+		{name: "tag at end of content", content: "<MEMO>x\n<NAME>", tag: "NAME", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ofxValue(tt.content, tt.tag); got != tt.want {
+				t.Errorf("ofxValue(%q, %q) = %q, want %q", tt.content, tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectBankFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		data     string
+		want     string
+	}{
+		{name: "ofx extension", filename: "estado.OFX", data: "", want: models.BankFormatOFX},
+		{name: "qfx extension", filename: "estado.qfx", data: "", want: models.BankFormatOFX},
+		{name: "SGML header", filename: "estado.txt", data: "OFXHEADER:100\nDATA:OFXSGML\n", want: models.BankFormatOFX},
+		{name: "XML body", filename: "download", data: "<?xml version=\"1.0\"?>\n<ofx><bankmsgsrsv1>", want: models.BankFormatOFX},
+		{name: "csv", filename: "estado.csv", data: "Fecha,Monto\n03/02/2025,1.00\n", want: models.BankFormatCSV},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectBankFormat(tt.filename, []byte(tt.data)); got != tt.want {
+				t.Errorf("detectBankFormat(%q) = %q, want %q", tt.filename, got, tt.want)
+			}
+		})
+	}
+}
@@ -16,12 +16,14 @@ import (
 
 // ContingencyService handles contingency period management and invoice queueing
 type ContingencyService struct {
-	db *sql.DB
+	db       *sql.DB
+	webhooks *WebhookService
 }
 
-// NewContingencyService creates a new contingency service
-func NewContingencyService(db *sql.DB) *ContingencyService {
-	return &ContingencyService{db: db}
+// NewContingencyService creates a new contingency service. Opening and closing
+// periods is announced through webhooks; pass nil to skip publishing.
+func NewContingencyService(db *sql.DB, webhooks *WebhookService) *ContingencyService {
+	return &ContingencyService{db: db, webhooks: webhooks}
 }
 
 // QueueInvoiceForContingency queues a failed invoice for contingency processing
@@ -203,6 +205,9 @@ func (s *ContingencyService) createPeriod(
 	}
 
 	log.Printf("[Contingency] ✅ Created new period %s (tipo: %d)", period.ID, tipoContingencia)
+
+	s.webhooks.Publish(ctx, period.CompanyID, models.WebhookEventContingencyPeriodOpened, period)
+
 	return &period, nil
 }
 
@@ -235,9 +240,15 @@ func (s *ContingencyService) GetPeriodByID(ctx context.Context, periodID string)
 	return &period, nil
 }
 
-// GetInvoicesForPeriod retrieves all invoices in a contingency period
-// GetInvoicesForPeriod retrieves all invoices in a contingency period
-func (s *ContingencyService) GetInvoicesForPeriod(ctx context.Context, periodID string) ([]models.Invoice, error) {
+// contingencyDocumentTables are the tables besides invoices whose DTEs are
+// queued for contingency (retenciones, liquidaciones, DCL and donaciones).
+// They share one layout: the unsigned DTE in dte_json, dte_signed empty until
+// signed, and Hacienda's verdict in the hacienda_* columns.
+var contingencyDocumentTables = []string{"retentions", "liquidaciones", "dcl_documents", "donations"}
+
+// contingencyDocumentsQuery selects the invoices and other documents matching
+// the condition, all in the invoice columns the worker reads
+func contingencyDocumentsQuery(condition string) string {
 	query := `
 		SELECT id, company_id, establishment_id, point_of_sale_id,
 			   invoice_number, dte_type, dte_codigo_generacion,
@@ -246,13 +257,27 @@ func (s *ContingencyService) GetInvoicesForPeriod(ctx context.Context, periodID
 			   dte_sello_recibido, hacienda_observaciones, signature_retry_count,
 			   finalized_at
 		FROM invoices
-		WHERE contingency_period_id = $1
-		ORDER BY finalized_at ASC
-	`
+		WHERE ` + condition
+	for _, table := range contingencyDocumentTables {
+		query += `
+		UNION ALL
+		SELECT id, company_id, establishment_id, point_of_sale_id,
+			   numero_control, tipo_dte, codigo_generacion,
+			   contingency_period_id, contingency_event_id, lote_id,
+			   dte_transmission_status, dte_json, NULLIF(dte_signed, ''),
+			   hacienda_sello_recibido, hacienda_observaciones, signature_retry_count,
+			   created_at
+		FROM ` + table + `
+		WHERE ` + condition
+	}
+	return query
+}
 
-	rows, err := s.db.QueryContext(ctx, query, periodID)
+// queryContingencyDocuments runs a contingencyDocumentsQuery
+func (s *ContingencyService) queryContingencyDocuments(ctx context.Context, query string, args ...interface{}) ([]models.Invoice, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query invoices: %w", err)
+		return nil, fmt.Errorf("failed to query documents: %w", err)
 	}
 	defer rows.Close()
 
@@ -270,95 +295,103 @@ func (s *ContingencyService) GetInvoicesForPeriod(ctx context.Context, periodID
 			&inv.FinalizedAt,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan invoice: %w", err)
+			return nil, fmt.Errorf("failed to scan document: %w", err)
 		}
 
 		inv.HaciendaObservaciones = haciendaObs
 		invoices = append(invoices, inv)
 	}
 
-	return invoices, nil
+	return invoices, rows.Err()
+}
+
+// updateContingencyDocuments applies an UPDATE's SET clause to the invoice
+// or other document with each ID; $1 is the SET clause's value, $2 the IDs
+func (s *ContingencyService) updateContingencyDocuments(ctx context.Context, set string, value interface{}, ids []string) error {
+	for _, table := range append([]string{"invoices"}, contingencyDocumentTables...) {
+		query := `UPDATE ` + table + ` SET ` + set + ` WHERE id = ANY($2)`
+		if _, err := s.db.ExecContext(ctx, query, value, pq.Array(ids)); err != nil {
+			return fmt.Errorf("failed to update %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// GetInvoicesForPeriod retrieves all invoices and other documents in a
+// contingency period
+func (s *ContingencyService) GetInvoicesForPeriod(ctx context.Context, periodID string) ([]models.Invoice, error) {
+	query := contingencyDocumentsQuery(`contingency_period_id = $1`) + `
+		ORDER BY finalized_at ASC
+	`
+	return s.queryContingencyDocuments(ctx, query, periodID)
 }
 
-// GetUnreportedInvoicesForPeriod gets invoices not yet in an event
+// GetUnreportedInvoicesForPeriod gets invoices and other documents not yet in
+// an event
 func (s *ContingencyService) GetUnreportedInvoicesForPeriod(ctx context.Context, periodID string, limit int) ([]models.Invoice, error) {
-	query := `
-		SELECT id, company_id, establishment_id, point_of_sale_id,
-			   invoice_number, dte_type, dte_codigo_generacion,
-			   contingency_period_id, contingency_event_id, lote_id,
-			   dte_transmission_status, dte_unsigned, dte_signed,
-			   dte_sello_recibido, hacienda_observaciones, signature_retry_count,
-			   finalized_at
-		FROM invoices
-		WHERE contingency_period_id = $1
+	query := contingencyDocumentsQuery(`contingency_period_id = $1
 		  AND contingency_event_id IS NULL
-		  AND dte_transmission_status IN ($2, $3, $4)
+		  AND dte_transmission_status IN ($2, $3, $4)`) + `
 		ORDER BY finalized_at ASC
 		LIMIT $5
 	`
-
-	rows, err := s.db.QueryContext(ctx, query,
+	return s.queryContingencyDocuments(ctx, query,
 		periodID,
 		models.DTEStatusPendingSignature,
 		models.DTEStatusFailedRetry,
 		models.DTEStatusContingencyQueue,
 		limit,
 	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query unreported invoices: %w", err)
-	}
-	defer rows.Close()
-
-	var invoices []models.Invoice
-	for rows.Next() {
-		var inv models.Invoice
-		var haciendaObs pq.StringArray
-
-		err := rows.Scan(
-			&inv.ID, &inv.CompanyID, &inv.EstablishmentID, &inv.PointOfSaleID,
-			&inv.InvoiceNumber, &inv.DteType, &inv.DteCodigoGeneracion,
-			&inv.ContingencyPeriodID, &inv.ContingencyEventID, &inv.LoteID,
-			&inv.DteTransmissionStatus, &inv.DteUnsigned, &inv.DteSigned,
-			&inv.DteSello, &haciendaObs, &inv.SignatureRetryCount,
-			&inv.FinalizedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan invoice: %w", err)
-		}
-
-		inv.HaciendaObservaciones = haciendaObs
-		invoices = append(invoices, inv)
-	}
-
-	return invoices, nil
 }
 
-// UpdateInvoiceSignature updates an invoice with a new signature
-func (s *ContingencyService) UpdateInvoiceSignature(ctx context.Context, invoiceID string, signedDTE string) error {
-	query := `
-		UPDATE invoices
-		SET dte_signed = $1,
-			dte_transmission_status = $2,
-			signature_retry_count = 0
-		WHERE id = $3
+// GetUnsignedDocuments gets invoices and other documents still waiting for a
+// signature, oldest first
+func (s *ContingencyService) GetUnsignedDocuments(ctx context.Context, maxRetries, limit int) ([]models.Invoice, error) {
+	query := contingencyDocumentsQuery(`dte_transmission_status = $1
+		  AND signature_retry_count < $2`) + `
+		ORDER BY finalized_at ASC
+		LIMIT $3
 	`
+	return s.queryContingencyDocuments(ctx, query, models.DTEStatusPendingSignature, maxRetries, limit)
+}
 
-	_, err := s.db.ExecContext(ctx, query, signedDTE, models.DTEStatusContingencyQueue, invoiceID)
-	return err
+// UpdateInvoiceSignature updates an invoice or other document with a new
+// signature
+func (s *ContingencyService) UpdateInvoiceSignature(ctx context.Context, invoiceID string, signedDTE string) error {
+	for _, table := range append([]string{"invoices"}, contingencyDocumentTables...) {
+		query := `
+			UPDATE ` + table + `
+			SET dte_signed = $1,
+				dte_transmission_status = $2,
+				signature_retry_count = 0
+			WHERE id = $3
+		`
+		if _, err := s.db.ExecContext(ctx, query, signedDTE, models.DTEStatusContingencyQueue, invoiceID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// IncrementSignatureRetryCount increments the retry count for an invoice
+// IncrementSignatureRetryCount increments the retry count for an invoice or
+// other document
 func (s *ContingencyService) IncrementSignatureRetryCount(ctx context.Context, invoiceID string) (int, error) {
-	query := `
-		UPDATE invoices
-		SET signature_retry_count = signature_retry_count + 1
-		WHERE id = $1
-		RETURNING signature_retry_count
-	`
-
-	var count int
-	err := s.db.QueryRowContext(ctx, query, invoiceID).Scan(&count)
-	return count, err
+	for _, table := range append([]string{"invoices"}, contingencyDocumentTables...) {
+		query := `
+			UPDATE ` + table + `
+			SET signature_retry_count = signature_retry_count + 1
+			WHERE id = $1
+			RETURNING signature_retry_count
+		`
+
+		var count int
+		err := s.db.QueryRowContext(ctx, query, invoiceID).Scan(&count)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		return count, err
+	}
+	return 0, sql.ErrNoRows
 }
 
 // ClosePeriod closes a period (sets f_fin, h_fin, status='reporting')
@@ -376,14 +409,33 @@ func (s *ContingencyService) ClosePeriod(ctx context.Context, periodID string) e
 			status = 'reporting'
 		WHERE id = $3
 		  AND status = 'active'
+		RETURNING id, company_id, establishment_id, point_of_sale_id, ambiente,
+				  f_inicio, h_inicio, f_fin, h_fin,
+				  tipo_contingencia, motivo_contingencia, status, processing,
+				  created_at, updated_at
 	`
 
-	_, err := s.db.ExecContext(ctx, query,
+	var period models.ContingencyPeriod
+	err := s.db.QueryRowContext(ctx, query,
 		now.Format("2006-01-02"),
 		now.Format("15:04:05"),
 		periodID,
+	).Scan(
+		&period.ID, &period.CompanyID, &period.EstablishmentID, &period.PointOfSaleID,
+		&period.Ambiente, &period.FInicio, &period.HInicio, &period.FFin, &period.HFin,
+		&period.TipoContingencia, &period.MotivoContingencia, &period.Status, &period.Processing,
+		&period.CreatedAt, &period.UpdatedAt,
 	)
-	return err
+	if err == sql.ErrNoRows {
+		// Already closed
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.webhooks.Publish(ctx, period.CompanyID, models.WebhookEventContingencyPeriodClosed, period)
+	return nil
 }
 
 // CompletePeriod marks a period as completed
@@ -474,20 +526,12 @@ func (s *ContingencyService) UpdateEventWithHaciendaResponse(
 	return err
 }
 
-// LinkInvoicesToEvent links invoices to a contingency event
+// LinkInvoicesToEvent links invoices and other documents to a contingency event
 func (s *ContingencyService) LinkInvoicesToEvent(ctx context.Context, invoiceIDs []string, eventID string) error {
 	if len(invoiceIDs) == 0 {
 		return nil
 	}
-
-	query := `
-		UPDATE invoices
-		SET contingency_event_id = $1
-		WHERE id = ANY($2)
-	`
-
-	_, err := s.db.ExecContext(ctx, query, eventID, pq.Array(invoiceIDs))
-	return err
+	return s.updateContingencyDocuments(ctx, `contingency_event_id = $1`, eventID, invoiceIDs)
 }
 
 // CreateLote creates a new lote for batch submission
@@ -529,20 +573,12 @@ func (s *ContingencyService) CreateLote(
 	return &lote, nil
 }
 
-// LinkInvoicesToLote links invoices to a lote
+// LinkInvoicesToLote links invoices and other documents to a lote
 func (s *ContingencyService) LinkInvoicesToLote(ctx context.Context, invoiceIDs []string, loteID string) error {
 	if len(invoiceIDs) == 0 {
 		return nil
 	}
-
-	query := `
-		UPDATE invoices
-		SET lote_id = $1
-		WHERE id = ANY($2)
-	`
-
-	_, err := s.db.ExecContext(ctx, query, loteID, pq.Array(invoiceIDs))
-	return err
+	return s.updateContingencyDocuments(ctx, `lote_id = $1`, loteID, invoiceIDs)
 }
 
 // UpdateLoteSubmitted updates lote after submission to Hacienda
@@ -572,7 +608,8 @@ func (s *ContingencyService) UpdateLoteCompleted(ctx context.Context, loteID str
 	return err
 }
 
-// UpdateInvoiceFromHaciendaResult updates invoice based on Hacienda lote result
+// UpdateInvoiceFromHaciendaResult updates the invoice or other document based
+// on Hacienda lote result
 func (s *ContingencyService) UpdateInvoiceFromHaciendaResult(
 	ctx context.Context,
 	codigoGeneracion string,
@@ -594,7 +631,31 @@ func (s *ContingencyService) UpdateInvoiceFromHaciendaResult(
 		pq.Array(observaciones),
 		codigoGeneracion,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	// The other documents also keep Hacienda's estado, which reports read
+	for _, table := range contingencyDocumentTables {
+		query := `
+			UPDATE ` + table + `
+			SET dte_transmission_status = $1,
+				hacienda_estado = UPPER($1),
+				hacienda_sello_recibido = NULLIF($2, ''),
+				hacienda_observaciones = $3
+			WHERE UPPER(codigo_generacion) = UPPER($4)
+		`
+		_, err := s.db.ExecContext(ctx, query,
+			status,
+			selloRecibido,
+			pq.Array(observaciones),
+			codigoGeneracion,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // GetCompanyAmbiente gets the dte_ambiente for a company
@@ -714,79 +775,45 @@ func (s *ContingencyService) UpdateLoteLastPolled(ctx context.Context, loteID st
 	return err
 }
 
-// GetInvoicesForLote gets all invoices in a lote
+// GetInvoicesForLote gets all invoices and other documents in a lote
 func (s *ContingencyService) GetInvoicesForLote(ctx context.Context, loteID string) ([]models.Invoice, error) {
-	query := `
-		SELECT id, company_id, establishment_id, point_of_sale_id,
-			   invoice_number, dte_type, dte_codigo_generacion,
-			   contingency_period_id, contingency_event_id, lote_id,
-			   dte_transmission_status, dte_unsigned, dte_signed,
-			   dte_sello_recibido, hacienda_observaciones, signature_retry_count
-		FROM invoices
-		WHERE lote_id = $1
+	query := contingencyDocumentsQuery(`lote_id = $1`) + `
 		ORDER BY finalized_at ASC
 	`
-
-	rows, err := s.db.QueryContext(ctx, query, loteID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query invoices: %w", err)
-	}
-	defer rows.Close()
-
-	var invoices []models.Invoice
-	for rows.Next() {
-		var inv models.Invoice
-		var haciendaObs pq.StringArray
-
-		err := rows.Scan(
-			&inv.ID, &inv.CompanyID, &inv.EstablishmentID, &inv.PointOfSaleID,
-			&inv.InvoiceNumber, &inv.DteType, &inv.DteCodigoGeneracion,
-			&inv.ContingencyPeriodID, &inv.ContingencyEventID, &inv.LoteID,
-			&inv.DteTransmissionStatus, &inv.DteUnsigned, &inv.DteSigned,
-			&inv.DteSello, &haciendaObs, &inv.SignatureRetryCount,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan invoice: %w", err)
-		}
-
-		inv.HaciendaObservaciones = haciendaObs
-		invoices = append(invoices, inv)
-	}
-
-	return invoices, nil
+	return s.queryContingencyDocuments(ctx, query, loteID)
 }
 
-// CheckLoteCompletion checks if all invoices in a lote are finalized
-func (s *ContingencyService) CheckLoteCompletion(ctx context.Context, loteID string) (bool, error) {
+// countUnfinishedDocuments counts the invoices and other documents matching
+// the condition that Hacienda has neither processed nor rejected
+func (s *ContingencyService) countUnfinishedDocuments(ctx context.Context, condition, id string) (int, error) {
 	query := `
-		SELECT COUNT(*) FROM invoices
-		WHERE lote_id = $1
-		  AND dte_transmission_status NOT IN ($2, $3)
+		SELECT COUNT(*) FROM (` + contingencyDocumentsQuery(condition) + `
+		) d
+		WHERE d.dte_transmission_status NOT IN ($2, $3)
 	`
 
 	var remaining int
-	err := s.db.QueryRowContext(ctx, query, loteID, models.DTEStatusProcesado, models.DTEStatusRechazado).Scan(&remaining)
+	err := s.db.QueryRowContext(ctx, query, id, models.DTEStatusProcesado, models.DTEStatusRechazado).Scan(&remaining)
+	return remaining, err
+}
+
+// CheckLoteCompletion checks if all invoices and other documents in a lote
+// are finalized
+func (s *ContingencyService) CheckLoteCompletion(ctx context.Context, loteID string) (bool, error) {
+	remaining, err := s.countUnfinishedDocuments(ctx, `lote_id = $1`, loteID)
 	if err != nil {
 		return false, err
 	}
-
 	return remaining == 0, nil
 }
 
-// CheckPeriodCompletion checks if all invoices in a period are finalized
+// CheckPeriodCompletion checks if all invoices and other documents in a
+// period are finalized
 func (s *ContingencyService) CheckPeriodCompletion(ctx context.Context, periodID string) (bool, error) {
-	query := `
-		SELECT COUNT(*) FROM invoices
-		WHERE contingency_period_id = $1
-		  AND dte_transmission_status NOT IN ($2, $3)
-	`
-
-	var remaining int
-	err := s.db.QueryRowContext(ctx, query, periodID, models.DTEStatusProcesado, models.DTEStatusRechazado).Scan(&remaining)
+	remaining, err := s.countUnfinishedDocuments(ctx, `contingency_period_id = $1`, periodID)
 	if err != nil {
 		return false, err
 	}
-
 	return remaining == 0, nil
 }
 
@@ -967,3 +994,259 @@ func (s *ContingencyService) QueueNotaCreditoForContingency(
 	log.Printf("[Contingency] ✅ Nota Credito %s queued in period %s (status: %s)", nota.ID, period.ID, status)
 	return nil
 }
+
+// QueueRetentionForContingency queues a failed retention (DTE 07) for contingency processing
+func (s *ContingencyService) QueueRetentionForContingency(
+	ctx context.Context,
+	retention *models.Retention,
+	failureType string,
+	dteUnsigned []byte,
+	dteSigned *string,
+	ambiente string,
+) error {
+	log.Printf("[Contingency] Queueing retention %s for contingency (failure: %s)", retention.ID, failureType)
+
+	tipoContingencia, motivoContingencia := s.determineContingencyType(failureType)
+
+	period, err := s.findOrCreatePeriod(
+		ctx,
+		retention.CompanyID,
+		retention.EstablishmentID,
+		retention.PointOfSaleID,
+		ambiente,
+		tipoContingencia,
+		motivoContingencia,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to find/create contingency period: %w", err)
+	}
+
+	var status string
+	if dteSigned != nil && *dteSigned != "" {
+		status = models.DTEStatusFailedRetry
+	} else {
+		status = models.DTEStatusPendingSignature
+	}
+
+	// retentions.dte_signed is NOT NULL - keep it empty until signed
+	signed := ""
+	if dteSigned != nil {
+		signed = *dteSigned
+	}
+
+	query := `
+		UPDATE retentions
+		SET contingency_period_id = $1,
+			dte_transmission_status = $2,
+			dte_json = $3,
+			dte_signed = $4,
+			signature_retry_count = COALESCE(signature_retry_count, 0)
+		WHERE id = $5
+	`
+
+	_, err = s.db.ExecContext(ctx, query,
+		period.ID,
+		status,
+		dteUnsigned,
+		signed,
+		retention.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update retention for contingency: %w", err)
+	}
+
+	log.Printf("[Contingency] ✅ Retention %s queued in period %s (status: %s)", retention.ID, period.ID, status)
+	return nil
+}
+
+// QueueLiquidacionForContingency queues a failed liquidación (DTE 08) for contingency processing
+func (s *ContingencyService) QueueLiquidacionForContingency(
+	ctx context.Context,
+	liquidacion *models.Liquidacion,
+	failureType string,
+	dteUnsigned []byte,
+	dteSigned *string,
+	ambiente string,
+) error {
+	log.Printf("[Contingency] Queueing liquidación %s for contingency (failure: %s)", liquidacion.ID, failureType)
+
+	tipoContingencia, motivoContingencia := s.determineContingencyType(failureType)
+
+	period, err := s.findOrCreatePeriod(
+		ctx,
+		liquidacion.CompanyID,
+		liquidacion.EstablishmentID,
+		liquidacion.PointOfSaleID,
+		ambiente,
+		tipoContingencia,
+		motivoContingencia,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to find/create contingency period: %w", err)
+	}
+
+	var status string
+	if dteSigned != nil && *dteSigned != "" {
+		status = models.DTEStatusFailedRetry
+	} else {
+		status = models.DTEStatusPendingSignature
+	}
+
+	// liquidaciones.dte_signed is NOT NULL - keep it empty until signed
+	signed := ""
+	if dteSigned != nil {
+		signed = *dteSigned
+	}
+
+	query := `
+		UPDATE liquidaciones
+		SET contingency_period_id = $1,
+			dte_transmission_status = $2,
+			dte_json = $3,
+			dte_signed = $4,
+			signature_retry_count = COALESCE(signature_retry_count, 0)
+		WHERE id = $5
+	`
+
+	_, err = s.db.ExecContext(ctx, query,
+		period.ID,
+		status,
+		dteUnsigned,
+		signed,
+		liquidacion.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update liquidación for contingency: %w", err)
+	}
+
+	log.Printf("[Contingency] ✅ Liquidación %s queued in period %s (status: %s)", liquidacion.ID, period.ID, status)
+	return nil
+}
+
+// QueueDCLForContingency queues a failed DCL (DTE 09) for contingency processing
+func (s *ContingencyService) QueueDCLForContingency(
+	ctx context.Context,
+	dcl *models.DCL,
+	failureType string,
+	dteUnsigned []byte,
+	dteSigned *string,
+	ambiente string,
+) error {
+	log.Printf("[Contingency] Queueing DCL %s for contingency (failure: %s)", dcl.ID, failureType)
+
+	tipoContingencia, motivoContingencia := s.determineContingencyType(failureType)
+
+	period, err := s.findOrCreatePeriod(
+		ctx,
+		dcl.CompanyID,
+		dcl.EstablishmentID,
+		dcl.PointOfSaleID,
+		ambiente,
+		tipoContingencia,
+		motivoContingencia,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to find/create contingency period: %w", err)
+	}
+
+	var status string
+	if dteSigned != nil && *dteSigned != "" {
+		status = models.DTEStatusFailedRetry
+	} else {
+		status = models.DTEStatusPendingSignature
+	}
+
+	// dcl_documents.dte_signed is NOT NULL - keep it empty until signed
+	signed := ""
+	if dteSigned != nil {
+		signed = *dteSigned
+	}
+
+	query := `
+		UPDATE dcl_documents
+		SET contingency_period_id = $1,
+			dte_transmission_status = $2,
+			dte_json = $3,
+			dte_signed = $4,
+			signature_retry_count = COALESCE(signature_retry_count, 0)
+		WHERE id = $5
+	`
+
+	_, err = s.db.ExecContext(ctx, query,
+		period.ID,
+		status,
+		dteUnsigned,
+		signed,
+		dcl.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update DCL for contingency: %w", err)
+	}
+
+	log.Printf("[Contingency] ✅ DCL %s queued in period %s (status: %s)", dcl.ID, period.ID, status)
+	return nil
+}
+
+// QueueDonationForContingency queues a failed Comprobante de Donación (DTE 15) for contingency processing
+func (s *ContingencyService) QueueDonationForContingency(
+	ctx context.Context,
+	donation *models.Donation,
+	failureType string,
+	dteUnsigned []byte,
+	dteSigned *string,
+	ambiente string,
+) error {
+	log.Printf("[Contingency] Queueing donation %s for contingency (failure: %s)", donation.ID, failureType)
+
+	tipoContingencia, motivoContingencia := s.determineContingencyType(failureType)
+
+	period, err := s.findOrCreatePeriod(
+		ctx,
+		donation.CompanyID,
+		donation.EstablishmentID,
+		donation.PointOfSaleID,
+		ambiente,
+		tipoContingencia,
+		motivoContingencia,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to find/create contingency period: %w", err)
+	}
+
+	var status string
+	if dteSigned != nil && *dteSigned != "" {
+		status = models.DTEStatusFailedRetry
+	} else {
+		status = models.DTEStatusPendingSignature
+	}
+
+	// donations.dte_signed is NOT NULL - keep it empty until signed
+	signed := ""
+	if dteSigned != nil {
+		signed = *dteSigned
+	}
+
+	query := `
+		UPDATE donations
+		SET contingency_period_id = $1,
+			dte_transmission_status = $2,
+			dte_json = $3,
+			dte_signed = $4,
+			signature_retry_count = COALESCE(signature_retry_count, 0)
+		WHERE id = $5
+	`
+
+	_, err = s.db.ExecContext(ctx, query,
+		period.ID,
+		status,
+		dteUnsigned,
+		signed,
+		donation.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update donation for contingency: %w", err)
+	}
+
+	log.Printf("[Contingency] ✅ Donation %s queued in period %s (status: %s)", donation.ID, period.ID, status)
+	return nil
+}
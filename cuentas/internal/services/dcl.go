@@ -0,0 +1,359 @@
+package services
+
+import (
+	"context"
+	"cuentas/internal/database"
+	"cuentas/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ============================================
+// ERRORS
+// ============================================
+
+var (
+	ErrDCLNotFound      = errors.New("documento contable de liquidación not found")
+	ErrDCLAlreadyIssued = errors.New("documento contable de liquidación has already been accepted by Hacienda")
+	ErrDCLReceptor      = errors.New("affiliate must be a contributor with NIT and NRC")
+	ErrDCLInvalidAmount = errors.New("invalid DCL amounts")
+)
+
+const (
+	// dclIVAPercepcionRate is the IVA percepción applied by card processors (2%)
+	dclIVAPercepcionRate = 0.02
+	// dclIVARate is the IVA rate used to split operations and charge commission IVA (13%)
+	dclIVARate = 0.13
+)
+
+// ============================================
+// SERVICE DEFINITION
+// ============================================
+
+type DCLService struct{}
+
+func NewDCLService() *DCLService {
+	return &DCLService{}
+}
+
+// ============================================
+// CREATE DCL
+// ============================================
+
+// CreateDCL creates a DTE 09 for the operations settled with an affiliate during a period.
+// Transmission happens when the handler finalizes it.
+func (s *DCLService) CreateDCL(
+	ctx context.Context,
+	companyID string,
+	req *models.CreateDCLRequest,
+	userID string,
+) (*models.DCL, error) {
+	// 1. Validate request
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// 2. Affiliate must be a formal contributor
+	if err := s.validateReceptor(ctx, companyID, req.ClientID); err != nil {
+		return nil, err
+	}
+
+	var ambiente string
+	err := database.DB.QueryRowContext(ctx, `SELECT dte_ambiente FROM companies WHERE id = $1`, companyID).Scan(&ambiente)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query company: %w", err)
+	}
+
+	periodoInicio, _ := time.Parse("2006-01-02", req.PeriodoInicio)
+	periodoFin, _ := time.Parse("2006-01-02", req.PeriodoFin)
+
+	// 3. Build the DCL and compute amounts
+	now := time.Now()
+	dcl := &models.DCL{
+		ID:                          strings.ToUpper(uuid.New().String()),
+		CompanyID:                   companyID,
+		EstablishmentID:             req.EstablishmentID,
+		PointOfSaleID:               req.PointOfSaleID,
+		ClientID:                    req.ClientID,
+		ReceptorTipoEstablecimiento: req.ReceptorTipoEstablecimiento,
+		CodigoGeneracion:            strings.ToUpper(uuid.New().String()),
+		TipoDte:                     "09",
+		Ambiente:                    ambiente,
+		PeriodoInicio:               periodoInicio,
+		PeriodoFin:                  periodoFin,
+		CodLiquidacion:              req.CodLiquidacion,
+		CantidadDoc:                 req.CantidadDoc,
+		ValorOperaciones:            round(req.ValorOperaciones),
+		MontoSinPercepcion:          round(req.MontoSinPercepcion),
+		DescripSinPercepcion:        req.DescripSinPercepcion,
+		CommissionRate:              req.CommissionRate,
+		Observaciones:               req.Observaciones,
+		NombEntrega:                 strings.TrimSpace(req.NombEntrega),
+		DocuEntrega:                 strings.TrimSpace(req.DocuEntrega),
+		CodEmpleado:                 req.CodEmpleado,
+		FechaEmision:                now,
+		DteJSON:                     "{}",
+		DteSigned:                   "",
+		DteTransmissionStatus:       "pending",
+		CreatedBy:                   nullIfBlank(&userID),
+		CreatedAt:                   now,
+	}
+	calculateDCLAmounts(dcl)
+
+	if dcl.IVAPercibido <= 0 || dcl.LiquidoAPagar <= 0 {
+		return nil, fmt.Errorf("%w: operations subject to percepción are too small or commission exceeds the settled value", ErrDCLInvalidAmount)
+	}
+
+	// 4. Assign numero de control and insert
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	dcl.NumeroControl, err = s.generateDCLNumeroControl(ctx, tx, companyID, req.EstablishmentID, req.PointOfSaleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate numero control: %w", err)
+	}
+
+	if err := s.insertDCL(ctx, tx, dcl); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return dcl, nil
+}
+
+// calculateDCLAmounts splits the settled operations into base and IVA, applies the
+// 2% percepción to the portion subject to it and deducts the commission and its IVA.
+// ValorOperaciones includes IVA; MontoSinPercepcion is excluded from the percepción base.
+func calculateDCLAmounts(d *models.DCL) {
+	sujeto := d.ValorOperaciones - d.MontoSinPercepcion
+
+	d.SubTotal = round(sujeto / (1 + dclIVARate))
+	d.IVA = round(sujeto - d.SubTotal)
+	d.MontoSujetoPercepcion = d.SubTotal
+	d.IVAPercibido = round(d.MontoSujetoPercepcion * dclIVAPercepcionRate)
+
+	d.Comision = round(d.ValorOperaciones * d.CommissionRate / 100)
+	d.IVAComision = round(d.Comision * dclIVARate)
+
+	d.LiquidoAPagar = round(d.ValorOperaciones - d.IVAPercibido - d.Comision - d.IVAComision)
+}
+
+// ============================================
+// DATABASE OPERATIONS
+// ============================================
+
+func (s *DCLService) insertDCL(ctx context.Context, tx *sql.Tx, d *models.DCL) error {
+	query := `
+        INSERT INTO dcl_documents (
+            id, company_id, establishment_id, point_of_sale_id, client_id, receptor_tipo_establecimiento,
+            codigo_generacion, numero_control, tipo_dte, ambiente,
+            periodo_inicio, periodo_fin, cod_liquidacion, cantidad_doc,
+            valor_operaciones, monto_sin_percepcion, descrip_sin_percepcion,
+            sub_total, iva, monto_sujeto_percepcion, iva_percibido,
+            commission_rate, comision, iva_comision, liquido_a_pagar, observaciones,
+            nomb_entrega, docu_entrega, cod_empleado,
+            fecha_emision, dte_json, dte_signed, dte_transmission_status,
+            created_by, created_at
+        ) VALUES (
+            $1, $2, $3, $4, $5, $6,
+            $7, $8, $9, $10,
+            $11, $12, $13, $14,
+            $15, $16, $17,
+            $18, $19, $20, $21,
+            $22, $23, $24, $25, $26,
+            $27, $28, $29,
+            $30, $31, $32, $33,
+            $34, $35
+        )
+    `
+
+	_, err := tx.ExecContext(ctx, query,
+		d.ID, d.CompanyID, d.EstablishmentID, d.PointOfSaleID, d.ClientID, d.ReceptorTipoEstablecimiento,
+		d.CodigoGeneracion, d.NumeroControl, d.TipoDte, d.Ambiente,
+		d.PeriodoInicio, d.PeriodoFin, d.CodLiquidacion, d.CantidadDoc,
+		d.ValorOperaciones, d.MontoSinPercepcion, d.DescripSinPercepcion,
+		d.SubTotal, d.IVA, d.MontoSujetoPercepcion, d.IVAPercibido,
+		d.CommissionRate, d.Comision, d.IVAComision, d.LiquidoAPagar, d.Observaciones,
+		d.NombEntrega, d.DocuEntrega, d.CodEmpleado,
+		d.FechaEmision, d.DteJSON, d.DteSigned, d.DteTransmissionStatus,
+		d.CreatedBy, d.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert DCL: %w", err)
+	}
+
+	return nil
+}
+
+// ============================================
+// QUERY OPERATIONS
+// ============================================
+
+const dclColumns = `
+            id, company_id, establishment_id, point_of_sale_id, client_id, receptor_tipo_establecimiento,
+            codigo_generacion, numero_control, tipo_dte, ambiente,
+            periodo_inicio, periodo_fin, cod_liquidacion, cantidad_doc,
+            valor_operaciones, monto_sin_percepcion, descrip_sin_percepcion,
+            sub_total, iva, monto_sujeto_percepcion, iva_percibido,
+            commission_rate, comision, iva_comision, liquido_a_pagar, observaciones,
+            nomb_entrega, docu_entrega, cod_empleado,
+            fecha_emision, fecha_procesamiento,
+            dte_json, dte_signed,
+            hacienda_estado, hacienda_sello_recibido, hacienda_fh_procesamiento,
+            hacienda_codigo_msg, hacienda_descripcion_msg, hacienda_observaciones, hacienda_response,
+            contingency_period_id, COALESCE(dte_transmission_status, 'pending'),
+            created_by, created_at, submitted_at`
+
+func scanDCL(scanner interface{ Scan(...interface{}) error }, d *models.DCL) error {
+	var observaciones []string
+	err := scanner.Scan(
+		&d.ID, &d.CompanyID, &d.EstablishmentID, &d.PointOfSaleID, &d.ClientID, &d.ReceptorTipoEstablecimiento,
+		&d.CodigoGeneracion, &d.NumeroControl, &d.TipoDte, &d.Ambiente,
+		&d.PeriodoInicio, &d.PeriodoFin, &d.CodLiquidacion, &d.CantidadDoc,
+		&d.ValorOperaciones, &d.MontoSinPercepcion, &d.DescripSinPercepcion,
+		&d.SubTotal, &d.IVA, &d.MontoSujetoPercepcion, &d.IVAPercibido,
+		&d.CommissionRate, &d.Comision, &d.IVAComision, &d.LiquidoAPagar, &d.Observaciones,
+		&d.NombEntrega, &d.DocuEntrega, &d.CodEmpleado,
+		&d.FechaEmision, &d.FechaProcesamiento,
+		&d.DteJSON, &d.DteSigned,
+		&d.HaciendaEstado, &d.HaciendaSelloRecibido, &d.HaciendaFhProcesamiento,
+		&d.HaciendaCodigoMsg, &d.HaciendaDescripcionMsg, pq.Array(&observaciones), &d.HaciendaResponse,
+		&d.ContingencyPeriodID, &d.DteTransmissionStatus,
+		&d.CreatedBy, &d.CreatedAt, &d.SubmittedAt,
+	)
+	if err != nil {
+		return err
+	}
+	d.HaciendaObservaciones = observaciones
+	return nil
+}
+
+// GetDCLByID retrieves a DCL by ID
+func (s *DCLService) GetDCLByID(ctx context.Context, companyID, dclID string) (*models.DCL, error) {
+	query := `SELECT ` + dclColumns + `
+        FROM dcl_documents
+        WHERE id = $1 AND company_id = $2
+    `
+
+	dcl := &models.DCL{}
+	err := scanDCL(database.DB.QueryRowContext(ctx, query, dclID, companyID), dcl)
+	if err == sql.ErrNoRows {
+		return nil, ErrDCLNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query DCL: %w", err)
+	}
+
+	return dcl, nil
+}
+
+// ListDCLs retrieves the DCLs of a company with pagination, optionally filtered by affiliate
+func (s *DCLService) ListDCLs(ctx context.Context, companyID, clientID string, limit, offset int) ([]models.DCL, error) {
+	query := `SELECT ` + dclColumns + `
+        FROM dcl_documents
+        WHERE company_id = $1
+          AND ($2 = '' OR client_id::text = $2)
+        ORDER BY created_at DESC
+        LIMIT $3 OFFSET $4
+    `
+
+	rows, err := database.DB.QueryContext(ctx, query, companyID, clientID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query DCLs: %w", err)
+	}
+	defer rows.Close()
+
+	var dcls []models.DCL
+	for rows.Next() {
+		var d models.DCL
+		if err := scanDCL(rows, &d); err != nil {
+			return nil, fmt.Errorf("failed to scan DCL: %w", err)
+		}
+		dcls = append(dcls, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating DCLs: %w", err)
+	}
+
+	return dcls, nil
+}
+
+// ============================================
+// HELPER FUNCTIONS
+// ============================================
+
+// validateReceptor checks the affiliate is a contributor (NIT and NRC)
+func (s *DCLService) validateReceptor(ctx context.Context, companyID, clientID string) error {
+	var nit, nrc sql.NullString
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT nit, ncr FROM clients WHERE id = $1 AND company_id = $2`,
+		clientID, companyID,
+	).Scan(&nit, &nrc)
+	if err == sql.ErrNoRows {
+		return ErrClientNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to query client: %w", err)
+	}
+
+	if !nit.Valid || nit.String == "" || !nrc.Valid || nrc.String == "" {
+		return ErrDCLReceptor
+	}
+
+	return nil
+}
+
+// generateDCLNumeroControl generates a numero control for DCL DTE 09
+func (s *DCLService) generateDCLNumeroControl(ctx context.Context, tx *sql.Tx, companyID, establishmentID, posID string) (string, error) {
+	var codEstablecimiento, codPuntoVenta string
+	query := `
+        SELECT e.cod_establecimiento, p.cod_punto_venta
+        FROM establishments e
+        JOIN point_of_sale p ON p.establishment_id = e.id
+        WHERE e.id = $1 AND p.id = $2 AND e.company_id = $3
+    `
+
+	err := tx.QueryRowContext(ctx, query, establishmentID, posID, companyID).Scan(&codEstablecimiento, &codPuntoVenta)
+	if err == sql.ErrNoRows {
+		return "", ErrPointOfSaleNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load establishment codes: %w", err)
+	}
+
+	var lastSeq sql.NullInt64
+	seqQuery := `
+        SELECT MAX(CAST(SUBSTRING(numero_control FROM 21 FOR 15) AS BIGINT))
+        FROM dcl_documents
+        WHERE establishment_id = $1
+          AND point_of_sale_id = $2
+          AND numero_control IS NOT NULL
+    `
+
+	err = tx.QueryRowContext(ctx, seqQuery, establishmentID, posID).Scan(&lastSeq)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to query last sequence: %w", err)
+	}
+
+	nextSeq := int64(1)
+	if lastSeq.Valid {
+		nextSeq = lastSeq.Int64 + 1
+	}
+
+	// Format: DTE-09-{codEstable}{codPOS}-{sequence}
+	// Example: DTE-09-M001P001-000000000000001
+	return fmt.Sprintf("DTE-09-%s%s-%015d", codEstablecimiento, codPuntoVenta, nextSeq), nil
+}
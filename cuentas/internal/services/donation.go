@@ -0,0 +1,505 @@
+package services
+
+import (
+	"context"
+	"cuentas/internal/database"
+	"cuentas/internal/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ============================================
+// ERRORS
+// ============================================
+
+var (
+	ErrDonationNotFound      = errors.New("comprobante de donación not found")
+	ErrDonationAlreadyIssued = errors.New("comprobante de donación has already been accepted by Hacienda")
+	ErrDonationDonor         = errors.New("donor must be identified by NIT or DUI")
+)
+
+// ============================================
+// SERVICE DEFINITION
+// ============================================
+
+type DonationService struct{}
+
+func NewDonationService() *DonationService {
+	return &DonationService{}
+}
+
+// ============================================
+// CREATE DONATION
+// ============================================
+
+// CreateDonation creates a DTE 15 for the cash, goods and services received from a donor.
+// Transmission happens when the handler finalizes it.
+func (s *DonationService) CreateDonation(
+	ctx context.Context,
+	companyID string,
+	req *models.CreateDonationRequest,
+	userID string,
+) (*models.Donation, error) {
+	// 1. Validate request
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// 2. Donor must be identifiable
+	if err := s.validateDonor(ctx, companyID, req.ClientID); err != nil {
+		return nil, err
+	}
+
+	var ambiente string
+	err := database.DB.QueryRowContext(ctx, `SELECT dte_ambiente FROM companies WHERE id = $1`, companyID).Scan(&ambiente)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query company: %w", err)
+	}
+
+	// 3. Build the donation and its lines
+	now := time.Now()
+	donation := &models.Donation{
+		ID:                    strings.ToUpper(uuid.New().String()),
+		CompanyID:             companyID,
+		EstablishmentID:       req.EstablishmentID,
+		PointOfSaleID:         req.PointOfSaleID,
+		ClientID:              req.ClientID,
+		CodDomiciliado:        req.CodDomiciliado,
+		CodPais:               req.CodPais,
+		CodigoGeneracion:      strings.ToUpper(uuid.New().String()),
+		TipoDte:               "15",
+		Ambiente:              ambiente,
+		FormaPago:             req.FormaPago,
+		ReferenciaPago:        req.ReferenciaPago,
+		OtrosDocumentos:       req.OtrosDocumentos,
+		FechaEmision:          now,
+		DteJSON:               "{}",
+		DteSigned:             "",
+		DteTransmissionStatus: "pending",
+		CreatedBy:             nullIfBlank(&userID),
+		CreatedAt:             now,
+	}
+
+	for i, item := range req.Items {
+		donation.Items = append(donation.Items, models.DonationItem{
+			ID:              strings.ToUpper(uuid.New().String()),
+			DonationID:      donation.ID,
+			LineNumber:      i + 1,
+			TipoDonacion:    item.TipoDonacion,
+			ValuationMethod: item.ValuationMethod,
+			Codigo:          item.Codigo,
+			Descripcion:     item.Descripcion,
+			UniMedida:       item.UniMedida,
+			Cantidad:        item.Cantidad,
+			ValorUni:        item.ValorUni,
+			Depreciacion:    item.Depreciacion,
+			Valor:           models.DonationItemValue(item.Cantidad, item.ValorUni, item.Depreciacion),
+		})
+	}
+	calculateDonationTotals(donation)
+
+	// 4. Assign numero de control and insert
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	donation.NumeroControl, err = s.generateDonationNumeroControl(ctx, tx, companyID, req.EstablishmentID, req.PointOfSaleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate numero control: %w", err)
+	}
+
+	if err := s.insertDonation(ctx, tx, donation); err != nil {
+		return nil, err
+	}
+
+	for i := range donation.Items {
+		if err := s.insertDonationItem(ctx, tx, &donation.Items[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return donation, nil
+}
+
+// calculateDonationTotals adds up the donated value per CAT-026 type
+func calculateDonationTotals(d *models.Donation) {
+	var efectivo, bienes, servicios float64
+	for _, item := range d.Items {
+		switch item.TipoDonacion {
+		case 1:
+			efectivo += item.Valor
+		case 2:
+			bienes += item.Valor
+		case 3:
+			servicios += item.Valor
+		}
+	}
+
+	d.TotalEfectivo = round(efectivo)
+	d.TotalBienes = round(bienes)
+	d.TotalServicios = round(servicios)
+	d.ValorTotal = round(efectivo + bienes + servicios)
+}
+
+// ============================================
+// DATABASE OPERATIONS
+// ============================================
+
+func (s *DonationService) insertDonation(ctx context.Context, tx *sql.Tx, d *models.Donation) error {
+	otrosDocumentos, err := json.Marshal(d.OtrosDocumentos)
+	if err != nil {
+		return fmt.Errorf("failed to marshal otros_documentos: %w", err)
+	}
+
+	query := `
+        INSERT INTO donations (
+            id, company_id, establishment_id, point_of_sale_id, client_id, cod_domiciliado, cod_pais,
+            codigo_generacion, numero_control, tipo_dte, ambiente,
+            valor_total, total_efectivo, total_bienes, total_servicios,
+            forma_pago, referencia_pago, otros_documentos,
+            fecha_emision, dte_json, dte_signed, dte_transmission_status,
+            created_by, created_at
+        ) VALUES (
+            $1, $2, $3, $4, $5, $6, $7,
+            $8, $9, $10, $11,
+            $12, $13, $14, $15,
+            $16, $17, $18,
+            $19, $20, $21, $22,
+            $23, $24
+        )
+    `
+
+	_, err = tx.ExecContext(ctx, query,
+		d.ID, d.CompanyID, d.EstablishmentID, d.PointOfSaleID, d.ClientID, d.CodDomiciliado, d.CodPais,
+		d.CodigoGeneracion, d.NumeroControl, d.TipoDte, d.Ambiente,
+		d.ValorTotal, d.TotalEfectivo, d.TotalBienes, d.TotalServicios,
+		d.FormaPago, d.ReferenciaPago, string(otrosDocumentos),
+		d.FechaEmision, d.DteJSON, d.DteSigned, d.DteTransmissionStatus,
+		d.CreatedBy, d.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert donation: %w", err)
+	}
+
+	return nil
+}
+
+func (s *DonationService) insertDonationItem(ctx context.Context, tx *sql.Tx, item *models.DonationItem) error {
+	query := `
+        INSERT INTO donation_items (
+            id, donation_id, line_number, tipo_donacion, valuation_method,
+            codigo, descripcion, uni_medida, cantidad, valor_uni, depreciacion, valor
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+    `
+
+	_, err := tx.ExecContext(ctx, query,
+		item.ID, item.DonationID, item.LineNumber, item.TipoDonacion, item.ValuationMethod,
+		item.Codigo, item.Descripcion, item.UniMedida, item.Cantidad, item.ValorUni, item.Depreciacion, item.Valor,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert donation item %d: %w", item.LineNumber, err)
+	}
+
+	return nil
+}
+
+// ============================================
+// QUERY OPERATIONS
+// ============================================
+
+const donationColumns = `
+            id, company_id, establishment_id, point_of_sale_id, client_id, cod_domiciliado, cod_pais,
+            codigo_generacion, numero_control, tipo_dte, ambiente,
+            valor_total, total_efectivo, total_bienes, total_servicios,
+            forma_pago, referencia_pago, otros_documentos,
+            fecha_emision, fecha_procesamiento,
+            dte_json, dte_signed,
+            hacienda_estado, hacienda_sello_recibido, hacienda_fh_procesamiento,
+            hacienda_codigo_msg, hacienda_descripcion_msg, hacienda_observaciones, hacienda_response,
+            contingency_period_id, COALESCE(dte_transmission_status, 'pending'),
+            created_by, created_at, submitted_at`
+
+func scanDonation(scanner interface{ Scan(...interface{}) error }, d *models.Donation) error {
+	var observaciones []string
+	var otrosDocumentos []byte
+	err := scanner.Scan(
+		&d.ID, &d.CompanyID, &d.EstablishmentID, &d.PointOfSaleID, &d.ClientID, &d.CodDomiciliado, &d.CodPais,
+		&d.CodigoGeneracion, &d.NumeroControl, &d.TipoDte, &d.Ambiente,
+		&d.ValorTotal, &d.TotalEfectivo, &d.TotalBienes, &d.TotalServicios,
+		&d.FormaPago, &d.ReferenciaPago, &otrosDocumentos,
+		&d.FechaEmision, &d.FechaProcesamiento,
+		&d.DteJSON, &d.DteSigned,
+		&d.HaciendaEstado, &d.HaciendaSelloRecibido, &d.HaciendaFhProcesamiento,
+		&d.HaciendaCodigoMsg, &d.HaciendaDescripcionMsg, pq.Array(&observaciones), &d.HaciendaResponse,
+		&d.ContingencyPeriodID, &d.DteTransmissionStatus,
+		&d.CreatedBy, &d.CreatedAt, &d.SubmittedAt,
+	)
+	if err != nil {
+		return err
+	}
+	d.HaciendaObservaciones = observaciones
+	if err := json.Unmarshal(otrosDocumentos, &d.OtrosDocumentos); err != nil {
+		return fmt.Errorf("failed to decode otros_documentos: %w", err)
+	}
+	return nil
+}
+
+// GetDonationByID retrieves a donation receipt with its items
+func (s *DonationService) GetDonationByID(ctx context.Context, companyID, donationID string) (*models.Donation, error) {
+	query := `SELECT ` + donationColumns + `
+        FROM donations
+        WHERE id = $1 AND company_id = $2
+    `
+
+	donation := &models.Donation{}
+	err := scanDonation(database.DB.QueryRowContext(ctx, query, donationID, companyID), donation)
+	if err == sql.ErrNoRows {
+		return nil, ErrDonationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query donation: %w", err)
+	}
+
+	donation.Items, err = s.getDonationItems(ctx, donation.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return donation, nil
+}
+
+// getDonationItems retrieves the donated lines in order
+func (s *DonationService) getDonationItems(ctx context.Context, donationID string) ([]models.DonationItem, error) {
+	query := `
+        SELECT id, donation_id, line_number, tipo_donacion, valuation_method,
+               codigo, descripcion, uni_medida, cantidad, valor_uni, depreciacion, valor
+        FROM donation_items
+        WHERE donation_id = $1
+        ORDER BY line_number
+    `
+
+	rows, err := database.DB.QueryContext(ctx, query, donationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query donation items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []models.DonationItem
+	for rows.Next() {
+		var item models.DonationItem
+		if err := rows.Scan(
+			&item.ID, &item.DonationID, &item.LineNumber, &item.TipoDonacion, &item.ValuationMethod,
+			&item.Codigo, &item.Descripcion, &item.UniMedida, &item.Cantidad, &item.ValorUni, &item.Depreciacion, &item.Valor,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan donation item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating donation items: %w", err)
+	}
+
+	return items, nil
+}
+
+// ListDonations retrieves the donation receipts of a company with pagination, optionally filtered by donor.
+// Items are not loaded; use GetDonationByID for the full document.
+func (s *DonationService) ListDonations(ctx context.Context, companyID, clientID string, limit, offset int) ([]models.Donation, error) {
+	query := `SELECT ` + donationColumns + `
+        FROM donations
+        WHERE company_id = $1
+          AND ($2 = '' OR client_id::text = $2)
+        ORDER BY created_at DESC
+        LIMIT $3 OFFSET $4
+    `
+
+	rows, err := database.DB.QueryContext(ctx, query, companyID, clientID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query donations: %w", err)
+	}
+	defer rows.Close()
+
+	var donations []models.Donation
+	for rows.Next() {
+		var d models.Donation
+		if err := scanDonation(rows, &d); err != nil {
+			return nil, fmt.Errorf("failed to scan donation: %w", err)
+		}
+		donations = append(donations, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating donations: %w", err)
+	}
+
+	return donations, nil
+}
+
+// ============================================
+// ANNUAL DONOR REPORT
+// ============================================
+
+// GetDonorAnnualReport totals, per donor, the donation receipts accepted by Hacienda
+// during the year. Invalidated receipts are excluded.
+func (s *DonationService) GetDonorAnnualReport(ctx context.Context, companyID string, year int, clientID string) (*models.DonorAnnualReport, error) {
+	query := `
+        SELECT d.id, d.client_id, c.business_name, c.nit, c.dui,
+               d.codigo_generacion, d.numero_control, d.hacienda_sello_recibido, d.fecha_emision,
+               d.valor_total, d.total_efectivo, d.total_bienes, d.total_servicios
+        FROM donations d
+        JOIN clients c ON c.id = d.client_id
+        WHERE d.company_id = $1
+          AND d.hacienda_estado = 'PROCESADO'
+          AND EXTRACT(YEAR FROM d.fecha_emision) = $2
+          AND ($3 = '' OR d.client_id::text = $3)
+          AND NOT EXISTS (
+              SELECT 1 FROM dte_invalidations i
+              WHERE i.original_codigo_generacion = d.codigo_generacion
+                AND i.hacienda_estado = 'PROCESADO'
+          )
+        ORDER BY c.business_name, d.client_id, d.fecha_emision, d.numero_control
+    `
+
+	rows, err := database.DB.QueryContext(ctx, query, companyID, year, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query donations: %w", err)
+	}
+	defer rows.Close()
+
+	report := &models.DonorAnnualReport{
+		CompanyID: companyID,
+		Year:      year,
+		Donors:    []models.DonorAnnualSummary{},
+	}
+
+	var current *models.DonorAnnualSummary
+	for rows.Next() {
+		var doc models.DonorAnnualDocument
+		var donorID, nombre string
+		var nit, dui sql.NullInt64
+		var efectivo, bienes, servicios float64
+		if err := rows.Scan(
+			&doc.DonationID, &donorID, &nombre, &nit, &dui,
+			&doc.CodigoGeneracion, &doc.NumeroControl, &doc.SelloRecibido, &doc.FechaEmision,
+			&doc.ValorTotal, &efectivo, &bienes, &servicios,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan donation: %w", err)
+		}
+
+		if current == nil || current.ClientID != donorID {
+			report.Donors = append(report.Donors, models.DonorAnnualSummary{
+				ClientID:  donorID,
+				Nombre:    nombre,
+				Donations: []models.DonorAnnualDocument{},
+			})
+			current = &report.Donors[len(report.Donors)-1]
+			if nit.Valid {
+				current.TipoDocumento = "36"
+				current.NumDocumento = fmt.Sprintf("%014d", nit.Int64)
+			} else if dui.Valid {
+				current.TipoDocumento = "13"
+				current.NumDocumento = fmt.Sprintf("%08d-%d", dui.Int64/10, dui.Int64%10)
+			}
+		}
+
+		current.DonationCount++
+		current.TotalEfectivo = round(current.TotalEfectivo + efectivo)
+		current.TotalBienes = round(current.TotalBienes + bienes)
+		current.TotalServicios = round(current.TotalServicios + servicios)
+		current.Total = round(current.Total + doc.ValorTotal)
+		current.Donations = append(current.Donations, doc)
+
+		report.TotalEfectivo = round(report.TotalEfectivo + efectivo)
+		report.TotalBienes = round(report.TotalBienes + bienes)
+		report.TotalServicios = round(report.TotalServicios + servicios)
+		report.Total = round(report.Total + doc.ValorTotal)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating donations: %w", err)
+	}
+
+	report.TotalDonors = len(report.Donors)
+	return report, nil
+}
+
+// ============================================
+// HELPER FUNCTIONS
+// ============================================
+
+// validateDonor checks the donor has a NIT or DUI to put on the receipt
+func (s *DonationService) validateDonor(ctx context.Context, companyID, clientID string) error {
+	var nit, dui sql.NullInt64
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT nit, dui FROM clients WHERE id = $1 AND company_id = $2`,
+		clientID, companyID,
+	).Scan(&nit, &dui)
+	if err == sql.ErrNoRows {
+		return ErrClientNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to query client: %w", err)
+	}
+
+	if !nit.Valid && !dui.Valid {
+		return ErrDonationDonor
+	}
+
+	return nil
+}
+
+// generateDonationNumeroControl generates a numero control for Comprobante de Donación DTE 15
+func (s *DonationService) generateDonationNumeroControl(ctx context.Context, tx *sql.Tx, companyID, establishmentID, posID string) (string, error) {
+	var codEstablecimiento, codPuntoVenta string
+	query := `
+        SELECT e.cod_establecimiento, p.cod_punto_venta
+        FROM establishments e
+        JOIN point_of_sale p ON p.establishment_id = e.id
+        WHERE e.id = $1 AND p.id = $2 AND e.company_id = $3
+    `
+
+	err := tx.QueryRowContext(ctx, query, establishmentID, posID, companyID).Scan(&codEstablecimiento, &codPuntoVenta)
+	if err == sql.ErrNoRows {
+		return "", ErrPointOfSaleNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load establishment codes: %w", err)
+	}
+
+	var lastSeq sql.NullInt64
+	seqQuery := `
+        SELECT MAX(CAST(SUBSTRING(numero_control FROM 21 FOR 15) AS BIGINT))
+        FROM donations
+        WHERE establishment_id = $1
+          AND point_of_sale_id = $2
+          AND numero_control IS NOT NULL
+    `
+
+	err = tx.QueryRowContext(ctx, seqQuery, establishmentID, posID).Scan(&lastSeq)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to query last sequence: %w", err)
+	}
+
+	nextSeq := int64(1)
+	if lastSeq.Valid {
+		nextSeq = lastSeq.Int64 + 1
+	}
+
+	// Format: DTE-15-{codEstable}{codPOS}-{sequence}
+	// Example: DTE-15-M001P001-000000000000001
+	return fmt.Sprintf("DTE-15-%s%s-%015d", codEstablecimiento, codPuntoVenta, nextSeq), nil
+}
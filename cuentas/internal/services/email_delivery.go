@@ -0,0 +1,697 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"text/template"
+	"time"
+
+	"cuentas/internal/codigos"
+	"cuentas/internal/formats"
+	"cuentas/internal/models"
+	"cuentas/internal/services/mailer"
+)
+
+// ============================================
+// ERRORS
+// ============================================
+
+var (
+	ErrEmailDTENotFound    = errors.New("DTE not found in commit log")
+	ErrEmailDTENotAccepted = errors.New("DTE has not been accepted by Hacienda")
+	ErrEmailTemplate       = errors.New("invalid email template")
+)
+
+// placeholderEmail is what the builders put in receptor.correo when the client
+// has no address; it must never be mailed
+const placeholderEmail = "sincorreo@example.com"
+
+// Retry backoff: 1m, 2m, 4m, ... capped at emailMaxBackoff
+const (
+	emailBaseBackoff = time.Minute
+	emailMaxBackoff  = 2 * time.Hour
+
+	// A delivery left in "sending" longer than this (worker crashed mid-send)
+	// is picked up again
+	emailStaleSending = 10 * time.Minute
+)
+
+const defaultSubjectTemplate = `{{.TipoDteNombre}} {{.NumeroControl}} - {{.EmisorNombre}}`
+
+const defaultBodyTemplate = `Estimado(a) {{.ReceptorNombre}}:
+
+{{.EmisorNombre}} le ha emitido el siguiente documento tributario electrónico:
+
+  Tipo de documento:    {{.TipoDteNombre}}
+  Número de control:    {{.NumeroControl}}
+  Código de generación: {{.CodigoGeneracion}}
+  Fecha de emisión:     {{.FechaEmision}}
+{{- if .Total}}
+  Total:                {{.Total}}
+{{- end}}
+  Sello de recepción:   {{.SelloRecibido}}
+
+Se adjuntan el documento en formato JSON firmado y su versión legible en PDF.
+Puede verificar su validez en el portal del Ministerio de Hacienda:
+{{.ConsultaURL}}
+
+Este es un mensaje automático, por favor no responda a este correo.
+`
+
+// EmailTemplateData is what subject and body templates can reference
+type EmailTemplateData struct {
+	EmisorNombre     string
+	ReceptorNombre   string
+	TipoDte          string
+	TipoDteNombre    string
+	NumeroControl    string
+	CodigoGeneracion string
+	FechaEmision     string
+	Total            string
+	SelloRecibido    string
+	ConsultaURL      string
+}
+
+// sampleTemplateData is used to check templates before they are saved
+var sampleTemplateData = EmailTemplateData{
+	EmisorNombre:     "EMPRESA DE EJEMPLO, S.A. DE C.V.",
+	ReceptorNombre:   "CLIENTE DE EJEMPLO",
+	TipoDte:          codigos.DocTypeFactura,
+	TipoDteNombre:    "Factura",
+	NumeroControl:    "DTE-01-M001P001-000000000000001",
+	CodigoGeneracion: "00000000-0000-0000-0000-000000000000",
+	FechaEmision:     "2025-01-01",
+	Total:            "$1.00",
+	SelloRecibido:    "2025000000000000000000000000000000000",
+	ConsultaURL:      "https://admin.factura.gob.sv/consultaPublica",
+}
+
+// ============================================
+// SERVICE DEFINITION
+// ============================================
+
+// EmailDeliveryService mails accepted DTEs (signed JSON + readable PDF) to the receptor
+type EmailDeliveryService struct {
+	db     *sql.DB
+	mailer *mailer.Client
+}
+
+// NewEmailDeliveryService creates a new email delivery service
+func NewEmailDeliveryService(db *sql.DB, mailerClient *mailer.Client) *EmailDeliveryService {
+	return &EmailDeliveryService{db: db, mailer: mailerClient}
+}
+
+// ============================================
+// SETTINGS
+// ============================================
+
+// GetSettings returns the company's email settings, or the disabled defaults
+// when the company never configured them
+func (s *EmailDeliveryService) GetSettings(ctx context.Context, companyID string) (*models.CompanyEmailSettings, error) {
+	settings := &models.CompanyEmailSettings{}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT company_id, enabled, enabled_at, from_name, from_address, reply_to, bcc,
+		       subject_template, body_template, attach_json, attach_pdf, max_attempts,
+		       created_at, updated_at
+		FROM company_email_settings
+		WHERE company_id = $1
+	`, companyID).Scan(
+		&settings.CompanyID, &settings.Enabled, &settings.EnabledAt, &settings.FromName,
+		&settings.FromAddress, &settings.ReplyTo, &settings.Bcc,
+		&settings.SubjectTemplate, &settings.BodyTemplate, &settings.AttachJSON,
+		&settings.AttachPDF, &settings.MaxAttempts, &settings.CreatedAt, &settings.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return &models.CompanyEmailSettings{
+			CompanyID:   companyID,
+			AttachJSON:  true,
+			AttachPDF:   true,
+			MaxAttempts: 5,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email settings: %w", err)
+	}
+	return settings, nil
+}
+
+// UpdateSettings replaces the company's email settings. Templates are parsed and
+// rendered against sample data so a broken template is rejected here rather than
+// failing every delivery later.
+func (s *EmailDeliveryService) UpdateSettings(ctx context.Context, companyID string, req *models.UpdateEmailSettingsRequest) (*models.CompanyEmailSettings, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	for name, source := range map[string]*string{"subject_template": req.SubjectTemplate, "body_template": req.BodyTemplate} {
+		if source == nil || strings.TrimSpace(*source) == "" {
+			continue
+		}
+		if _, err := renderEmailTemplate(name, *source, &sampleTemplateData); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrEmailTemplate, name, err)
+		}
+	}
+
+	attachJSON, attachPDF := true, true
+	if req.AttachJSON != nil {
+		attachJSON = *req.AttachJSON
+	}
+	if req.AttachPDF != nil {
+		attachPDF = *req.AttachPDF
+	}
+
+	// enabled_at moves only when delivery goes from off to on, so turning it
+	// on does not mail the backlog accepted while it was off
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO company_email_settings (
+			company_id, enabled, enabled_at, from_name, from_address, reply_to, bcc,
+			subject_template, body_template, attach_json, attach_pdf, max_attempts
+		) VALUES ($1, $2, CASE WHEN $2 THEN NOW() END, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (company_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			enabled_at = CASE
+				WHEN EXCLUDED.enabled AND NOT company_email_settings.enabled THEN NOW()
+				ELSE company_email_settings.enabled_at
+			END,
+			from_name = EXCLUDED.from_name,
+			from_address = EXCLUDED.from_address,
+			reply_to = EXCLUDED.reply_to,
+			bcc = EXCLUDED.bcc,
+			subject_template = EXCLUDED.subject_template,
+			body_template = EXCLUDED.body_template,
+			attach_json = EXCLUDED.attach_json,
+			attach_pdf = EXCLUDED.attach_pdf,
+			max_attempts = EXCLUDED.max_attempts,
+			updated_at = NOW()
+	`,
+		companyID, req.Enabled, nullIfBlank(req.FromName), nullIfBlank(req.FromAddress),
+		nullIfBlank(req.ReplyTo), nullIfBlank(req.Bcc), templateOrNil(req.SubjectTemplate),
+		templateOrNil(req.BodyTemplate), attachJSON, attachPDF, req.MaxAttempts,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save email settings: %w", err)
+	}
+
+	return s.GetSettings(ctx, companyID)
+}
+
+// ============================================
+// DELIVERY STATUS
+// ============================================
+
+const emailDeliveryColumns = `
+	id, company_id, codigo_generacion, tipo_dte, recipient, status, origin,
+	attempts, max_attempts, next_attempt_at, last_error, message_id, sent_at,
+	requested_by, created_at, updated_at
+`
+
+// ListDeliveries lists the company's deliveries, newest first.
+// Optional filters: codigo_generacion and status.
+func (s *EmailDeliveryService) ListDeliveries(ctx context.Context, companyID, codigoGeneracion, status string, limit, offset int) ([]models.EmailDelivery, error) {
+	query := `SELECT ` + emailDeliveryColumns + ` FROM dte_email_deliveries WHERE company_id = $1`
+	args := []interface{}{companyID}
+
+	if codigoGeneracion != "" {
+		args = append(args, strings.ToUpper(codigoGeneracion))
+		query += fmt.Sprintf(" AND codigo_generacion = $%d", len(args))
+	}
+	if status != "" {
+		args = append(args, status)
+		query += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+
+	args = append(args, limit, offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.EmailDelivery{}
+	for rows.Next() {
+		d, err := scanEmailDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+// Resend queues a new delivery for an accepted DTE. The worker sends it on its
+// next pass with the same retry policy as automatic deliveries.
+func (s *EmailDeliveryService) Resend(ctx context.Context, companyID, codigoGeneracion string, recipient *string, userID string) (*models.EmailDelivery, error) {
+	codigoGeneracion = strings.ToUpper(codigoGeneracion)
+
+	var tipoDte string
+	var estado sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT tipo_dte, hacienda_estado FROM dte_commit_log
+		WHERE codigo_generacion = $1 AND company_id = $2
+		ORDER BY (hacienda_estado = 'PROCESADO') DESC, created_at DESC
+		LIMIT 1
+	`, codigoGeneracion, companyID).Scan(&tipoDte, &estado)
+	if err == sql.ErrNoRows {
+		return nil, ErrEmailDTENotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit log entry: %w", err)
+	}
+	if estado.String != "PROCESADO" {
+		return nil, ErrEmailDTENotAccepted
+	}
+
+	settings, err := s.GetSettings(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO dte_email_deliveries (
+			company_id, codigo_generacion, tipo_dte, recipient, origin, max_attempts, requested_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+emailDeliveryColumns,
+		companyID, codigoGeneracion, tipoDte, nullIfBlank(recipient),
+		models.EmailOriginResend, settings.MaxAttempts, nullIfBlank(&userID),
+	)
+	return scanEmailDelivery(row)
+}
+
+// ============================================
+// WORKER OPERATIONS
+// ============================================
+
+// QueueAcceptedDTEs creates the automatic delivery for every DTE Hacienda
+// accepted since the company enabled email delivery. Returns how many were queued.
+func (s *EmailDeliveryService) QueueAcceptedDTEs(ctx context.Context, limit int) (int, error) {
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO dte_email_deliveries (company_id, codigo_generacion, tipo_dte, origin, max_attempts)
+		SELECT company_id, codigo_generacion, tipo_dte, $1, max_attempts
+		FROM (
+			-- A nota shares its code across several commit log rows; queue it once
+			SELECT DISTINCT ON (cl.company_id, cl.codigo_generacion)
+				cl.company_id, cl.codigo_generacion, cl.tipo_dte, es.max_attempts, cl.created_at
+			FROM dte_commit_log cl
+			JOIN company_email_settings es ON es.company_id = cl.company_id
+			WHERE es.enabled
+			  AND cl.hacienda_estado = 'PROCESADO'
+			  AND cl.created_at >= es.enabled_at
+			  AND NOT EXISTS (
+				SELECT 1 FROM dte_email_deliveries d
+				WHERE d.company_id = cl.company_id
+				  AND d.codigo_generacion = cl.codigo_generacion
+				  AND d.origin = $1
+			  )
+			ORDER BY cl.company_id, cl.codigo_generacion, cl.created_at DESC
+		) accepted
+		ORDER BY created_at
+		LIMIT $2
+		ON CONFLICT DO NOTHING
+	`, models.EmailOriginAuto, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to queue accepted DTEs: %w", err)
+	}
+
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+// ClaimDueDeliveries marks up to limit due deliveries as sending and returns
+// them. SKIP LOCKED keeps several workers from sending the same email.
+func (s *EmailDeliveryService) ClaimDueDeliveries(ctx context.Context, limit int) ([]models.EmailDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE dte_email_deliveries
+		SET status = $1, attempts = attempts + 1, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM dte_email_deliveries
+			WHERE (status = $2 AND next_attempt_at <= NOW())
+			   OR (status = $1 AND updated_at < $3)
+			ORDER BY next_attempt_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+emailDeliveryColumns,
+		models.EmailStatusSending, models.EmailStatusPending, time.Now().Add(-emailStaleSending), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim email deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.EmailDelivery
+	for rows.Next() {
+		d, err := scanEmailDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+// Deliver sends one claimed delivery and records the outcome. Failures are
+// retried with exponential backoff until max_attempts, then marked failed.
+func (s *EmailDeliveryService) Deliver(ctx context.Context, delivery *models.EmailDelivery) error {
+	recipient, messageID, err := s.send(ctx, delivery)
+	if err == nil {
+		_, dbErr := s.db.ExecContext(ctx, `
+			UPDATE dte_email_deliveries
+			SET status = $1, recipient = $2, message_id = $3, sent_at = NOW(),
+			    last_error = NULL, updated_at = NOW()
+			WHERE id = $4
+		`, models.EmailStatusSent, recipient, messageID, delivery.ID)
+		return dbErr
+	}
+
+	if errors.Is(err, errNoRecipient) {
+		_, dbErr := s.db.ExecContext(ctx, `
+			UPDATE dte_email_deliveries
+			SET status = $1, last_error = $2, updated_at = NOW()
+			WHERE id = $3
+		`, models.EmailStatusSkipped, err.Error(), delivery.ID)
+		if dbErr != nil {
+			return dbErr
+		}
+		return err
+	}
+
+	status := models.EmailStatusPending
+	if delivery.Attempts >= delivery.MaxAttempts {
+		status = models.EmailStatusFailed
+	}
+	_, dbErr := s.db.ExecContext(ctx, `
+		UPDATE dte_email_deliveries
+		SET status = $1, recipient = COALESCE($2, recipient), last_error = $3,
+		    next_attempt_at = $4, updated_at = NOW()
+		WHERE id = $5
+	`, status, nullIfBlank(&recipient), err.Error(), time.Now().Add(exponentialBackoff(emailBaseBackoff, emailMaxBackoff, delivery.Attempts)), delivery.ID)
+	if dbErr != nil {
+		log.Printf("[EmailDelivery] Failed to record error for delivery %s: %v", delivery.ID, dbErr)
+	}
+	return err
+}
+
+var errNoRecipient = errors.New("receptor has no email address")
+
+// emailDocument is what a delivery needs from the commit log
+type emailDocument struct {
+	companyName      string
+	codigoGeneracion string
+	numeroControl    string
+	tipoDte          string
+	fechaEmision     time.Time
+	dteURL           string
+	dteUnsigned      []byte
+	dteSigned        string
+	sello            *string
+	fhProcesamiento  *time.Time
+	contactEmail     *string
+	invalidated      bool
+}
+
+// send renders and mails a delivery, returning the address used and the Message-ID
+func (s *EmailDeliveryService) send(ctx context.Context, delivery *models.EmailDelivery) (string, string, error) {
+	doc := &emailDocument{codigoGeneracion: delivery.CodigoGeneracion}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			c.name, cl.numero_control, cl.tipo_dte, cl.fecha_emision, cl.dte_url,
+			cl.dte_unsigned, cl.dte_signed, cl.hacienda_sello_recibido,
+			cl.hacienda_fh_procesamiento, i.contact_email,
+			EXISTS (
+				SELECT 1 FROM dte_invalidations inv
+				WHERE inv.original_codigo_generacion = cl.codigo_generacion
+				  AND inv.company_id = cl.company_id
+				  AND inv.hacienda_estado = 'PROCESADO'
+			)
+		FROM dte_commit_log cl
+		JOIN companies c ON c.id = cl.company_id
+		LEFT JOIN invoices i ON i.id = cl.invoice_id
+		WHERE cl.codigo_generacion = $1
+		  AND cl.company_id = $2
+		  AND cl.hacienda_estado = 'PROCESADO'
+		ORDER BY cl.created_at DESC
+		LIMIT 1
+	`, delivery.CodigoGeneracion, delivery.CompanyID).Scan(
+		&doc.companyName, &doc.numeroControl, &doc.tipoDte, &doc.fechaEmision, &doc.dteURL,
+		&doc.dteUnsigned, &doc.dteSigned, &doc.sello, &doc.fhProcesamiento,
+		&doc.contactEmail, &doc.invalidated,
+	)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load commit log entry: %w", err)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(doc.dteUnsigned, &body); err != nil {
+		return "", "", fmt.Errorf("failed to parse DTE: %w", err)
+	}
+
+	recipient := ""
+	if delivery.Recipient != nil {
+		recipient = *delivery.Recipient
+	} else {
+		recipient = receptorEmail(body, doc.contactEmail)
+	}
+	if recipient == "" {
+		return "", "", errNoRecipient
+	}
+
+	settings, err := s.GetSettings(ctx, delivery.CompanyID)
+	if err != nil {
+		return recipient, "", err
+	}
+
+	data := emailTemplateData(doc, body)
+
+	subjectSource, bodySource := defaultSubjectTemplate, defaultBodyTemplate
+	if settings.SubjectTemplate != nil {
+		subjectSource = *settings.SubjectTemplate
+	}
+	if settings.BodyTemplate != nil {
+		bodySource = *settings.BodyTemplate
+	}
+	subject, err := renderEmailTemplate("subject", subjectSource, data)
+	if err != nil {
+		return recipient, "", fmt.Errorf("%w: subject: %v", ErrEmailTemplate, err)
+	}
+	text, err := renderEmailTemplate("body", bodySource, data)
+	if err != nil {
+		return recipient, "", fmt.Errorf("%w: body: %v", ErrEmailTemplate, err)
+	}
+
+	msg := &mailer.Message{
+		To:      []string{recipient},
+		Subject: strings.TrimSpace(subject),
+		Body:    text,
+	}
+
+	from := s.mailer.DefaultFrom()
+	if settings.FromAddress != nil {
+		from.Address = *settings.FromAddress
+	}
+	from.Name = doc.companyName
+	if settings.FromName != nil {
+		from.Name = *settings.FromName
+	}
+	msg.From = &from
+
+	if settings.ReplyTo != nil {
+		msg.ReplyTo = *settings.ReplyTo
+	}
+	if settings.Bcc != nil {
+		msg.Bcc = []string{*settings.Bcc}
+	}
+
+	codigo := delivery.CodigoGeneracion
+	if settings.AttachJSON {
+		signedJSON, err := signedDocumentJSON(body, doc.dteSigned, doc.sello)
+		if err != nil {
+			return recipient, "", err
+		}
+		msg.Attachments = append(msg.Attachments, mailer.Attachment{
+			Filename:    codigo + ".json",
+			ContentType: "application/json",
+			Data:        signedJSON,
+		})
+	}
+	if settings.AttachPDF {
+		pdfBytes, err := formats.WriteDTEPDF(&formats.DTEPrintable{
+			Document:        doc.dteUnsigned,
+			SelloRecibido:   doc.sello,
+			FhProcesamiento: doc.fhProcesamiento,
+			ConsultaURL:     doc.dteURL,
+			Invalidated:     doc.invalidated,
+		})
+		if err != nil {
+			return recipient, "", fmt.Errorf("failed to render DTE PDF: %w", err)
+		}
+		msg.Attachments = append(msg.Attachments, mailer.Attachment{
+			Filename:    codigo + ".pdf",
+			ContentType: "application/pdf",
+			Data:        pdfBytes,
+		})
+	}
+
+	messageID, err := s.mailer.Send(ctx, msg)
+	return recipient, messageID, err
+}
+
+// ============================================
+// HELPERS
+// ============================================
+
+// receptorEmail picks the receptor's address: the contact email snapshotted on
+// the invoice first, then the correo of whichever party block the DTE type uses
+func receptorEmail(body map[string]interface{}, contactEmail *string) string {
+	candidates := []string{}
+	if contactEmail != nil {
+		candidates = append(candidates, *contactEmail)
+	}
+	for _, party := range []string{"receptor", "sujetoExcluido", "donante"} {
+		if block, ok := body[party].(map[string]interface{}); ok {
+			if correo, ok := block["correo"].(string); ok {
+				candidates = append(candidates, correo)
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "" || strings.EqualFold(candidate, placeholderEmail) {
+			continue
+		}
+		if _, err := mail.ParseAddress(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// emailTemplateData collects the template fields from the commit log entry
+func emailTemplateData(doc *emailDocument, body map[string]interface{}) *EmailTemplateData {
+	data := &EmailTemplateData{
+		EmisorNombre:     doc.companyName,
+		TipoDte:          doc.tipoDte,
+		NumeroControl:    doc.numeroControl,
+		CodigoGeneracion: doc.codigoGeneracion,
+		FechaEmision:     doc.fechaEmision.Format("2006-01-02"),
+		ConsultaURL:      doc.dteURL,
+	}
+	if name, ok := codigos.GetDocumentTypeName(doc.tipoDte); ok {
+		data.TipoDteNombre = name
+	} else {
+		data.TipoDteNombre = "DTE " + doc.tipoDte
+	}
+	if doc.sello != nil {
+		data.SelloRecibido = *doc.sello
+	}
+
+	if emisor, ok := body["emisor"].(map[string]interface{}); ok {
+		if nombre, ok := emisor["nombre"].(string); ok && nombre != "" {
+			data.EmisorNombre = nombre
+		}
+	}
+	for _, party := range []string{"receptor", "sujetoExcluido", "donante"} {
+		if block, ok := body[party].(map[string]interface{}); ok {
+			if nombre, ok := block["nombre"].(string); ok && nombre != "" {
+				data.ReceptorNombre = nombre
+				break
+			}
+		}
+	}
+	if data.ReceptorNombre == "" {
+		data.ReceptorNombre = "cliente"
+	}
+
+	if resumen, ok := body["resumen"].(map[string]interface{}); ok {
+		for _, key := range []string{"totalPagar", "montoTotalOperacion", "valorTotal", "totalCompra", "totalIVAretenido"} {
+			if v, ok := resumen[key].(float64); ok {
+				data.Total = fmt.Sprintf("$%.2f", v)
+				break
+			}
+		}
+	}
+
+	return data
+}
+
+// signedDocumentJSON is the document the receptor keeps: the DTE with the
+// firmaElectronica and the selloRecibido Hacienda assigned
+func signedDocumentJSON(body map[string]interface{}, dteSigned string, sello *string) ([]byte, error) {
+	signed := make(map[string]interface{}, len(body)+2)
+	for k, v := range body {
+		signed[k] = v
+	}
+	signed["firmaElectronica"] = dteSigned
+	if sello != nil {
+		signed["selloRecibido"] = *sello
+	}
+
+	out, err := json.MarshalIndent(signed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal signed DTE: %w", err)
+	}
+	return out, nil
+}
+
+func renderEmailTemplate(name, source string, data *EmailTemplateData) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// exponentialBackoff returns the wait before the next attempt after n failed
+// attempts: base, 2×base, 4×base, ... capped at max
+func exponentialBackoff(base, max time.Duration, attempts int) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		wait = max
+	}
+	return wait
+}
+
+func scanEmailDelivery(row rowScanner) (*models.EmailDelivery, error) {
+	d := &models.EmailDelivery{}
+	err := row.Scan(
+		&d.ID, &d.CompanyID, &d.CodigoGeneracion, &d.TipoDte, &d.Recipient, &d.Status,
+		&d.Origin, &d.Attempts, &d.MaxAttempts, &d.NextAttemptAt, &d.LastError,
+		&d.MessageID, &d.SentAt, &d.RequestedBy, &d.CreatedAt, &d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan email delivery: %w", err)
+	}
+	return d, nil
+}
+
+func nullIfBlank(s *string) *string {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	return &trimmed
+}
+
+// templateOrNil keeps a template verbatim (its whitespace is significant) unless it is blank
+func templateOrNil(s *string) *string {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,489 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"cuentas/internal/models"
+)
+
+// ledgerOpenStart stands in for "since the first entry" in balance queries
+const ledgerOpenStart = "0001-01-01"
+
+// ledgerBalance is the debits and credits posted to an account
+type ledgerBalance struct {
+	debit  float64
+	credit float64
+}
+
+// ============================================
+// TRIAL BALANCE
+// ============================================
+
+// GetTrialBalance builds the balance de comprobación: for every account with
+// a balance or movements, its balance before the period, the period's debits
+// and credits, and its balance at the end. Closing entries are included
+// unless req.ExcludeClosing is set, which shows the result accounts as they
+// were before the year was closed.
+func (s *LedgerService) GetTrialBalance(ctx context.Context, companyID string, req *models.StatementRequest) (*models.TrialBalance, error) {
+	tb := &models.TrialBalance{
+		CompanyID:       companyID,
+		FromDate:        req.FromDate,
+		ToDate:          req.ToDate,
+		EstablishmentID: req.EstablishmentID,
+		ExcludeClosing:  req.ExcludeClosing,
+		Rows:            []models.TrialBalanceRow{},
+		GeneratedAt:     time.Now(),
+	}
+	if err := loadReportCompany(ctx, s.db, companyID, &tb.CompanyName, &tb.NIT, &tb.NRC); err != nil {
+		return nil, err
+	}
+	if err := s.loadCostCenter(ctx, companyID, req.EstablishmentID, &tb.EstablishmentCode, &tb.EstablishmentName); err != nil {
+		return nil, err
+	}
+
+	chart, err := s.loadChart(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	from, _ := time.Parse("2006-01-02", req.FromDate)
+	opening, err := s.accountMovements(ctx, companyID, ledgerOpenStart, from.AddDate(0, 0, -1).Format("2006-01-02"),
+		req.EstablishmentID, req.ExcludeClosing)
+	if err != nil {
+		return nil, err
+	}
+	movements, err := s.accountMovements(ctx, companyID, req.FromDate, req.ToDate, req.EstablishmentID, req.ExcludeClosing)
+	if err != nil {
+		return nil, err
+	}
+	openingTotals := mergeCostCenters(opening)
+	movementTotals := mergeCostCenters(movements)
+
+	amounts := make(map[string]models.TrialBalanceAmounts, len(chart.accounts))
+	for _, account := range chart.accounts {
+		if !account.Postable {
+			continue
+		}
+		o, m := openingTotals[account.ID], movementTotals[account.ID]
+		openingNet := o.debit - o.credit
+		closingNet := openingNet + m.debit - m.credit
+		a := models.TrialBalanceAmounts{Debits: m.debit, Credits: m.credit}
+		a.OpeningDebit, a.OpeningCredit = splitBalance(openingNet)
+		a.ClosingDebit, a.ClosingCredit = splitBalance(closingNet)
+
+		tb.Totals.Add(a)
+		for _, id := range chart.lineage(account.ID) {
+			sum := amounts[id]
+			sum.Add(a)
+			amounts[id] = sum
+		}
+	}
+
+	for _, account := range chart.accounts {
+		a := roundTrialBalance(amounts[account.ID])
+		if a == (models.TrialBalanceAmounts{}) {
+			continue
+		}
+		tb.Rows = append(tb.Rows, models.TrialBalanceRow{
+			AccountID:           account.ID,
+			Code:                account.Code,
+			Name:                account.Name,
+			AccountType:         account.AccountType,
+			Level:               chart.levels[account.ID],
+			Postable:            account.Postable,
+			TrialBalanceAmounts: a,
+		})
+	}
+	tb.Totals = roundTrialBalance(tb.Totals)
+	return tb, nil
+}
+
+// splitBalance returns a net debit balance as a debit or a credit
+func splitBalance(net float64) (float64, float64) {
+	if net >= 0 {
+		return net, 0
+	}
+	return 0, -net
+}
+
+func roundTrialBalance(a models.TrialBalanceAmounts) models.TrialBalanceAmounts {
+	return models.TrialBalanceAmounts{
+		OpeningDebit:  round(a.OpeningDebit),
+		OpeningCredit: round(a.OpeningCredit),
+		Debits:        round(a.Debits),
+		Credits:       round(a.Credits),
+		ClosingDebit:  round(a.ClosingDebit),
+		ClosingCredit: round(a.ClosingCredit),
+	}
+}
+
+// ============================================
+// FINANCIAL STATEMENTS
+// ============================================
+
+// GetIncomeStatement builds the estado de resultados: the income and expense
+// movements of each column's period. Closing entries are left out, so a
+// closed year still shows its result.
+func (s *LedgerService) GetIncomeStatement(ctx context.Context, companyID string, req *models.StatementRequest) (*models.FinancialStatement, error) {
+	return s.financialStatement(ctx, companyID, models.StatementIncome, req)
+}
+
+// GetBalanceSheet builds the balance general: asset, liability and equity
+// balances at the end of each column's period. Income and expense not yet
+// closed into retained earnings are shown as the result in equity.
+func (s *LedgerService) GetBalanceSheet(ctx context.Context, companyID string, req *models.StatementRequest) (*models.FinancialStatement, error) {
+	return s.financialStatement(ctx, companyID, models.StatementBalance, req)
+}
+
+func (s *LedgerService) financialStatement(ctx context.Context, companyID, statement string, req *models.StatementRequest) (*models.FinancialStatement, error) {
+	fs := &models.FinancialStatement{
+		CompanyID:       companyID,
+		Statement:       statement,
+		FromDate:        req.FromDate,
+		ToDate:          req.ToDate,
+		EstablishmentID: req.EstablishmentID,
+		Sections:        []models.StatementSection{},
+		GeneratedAt:     time.Now(),
+	}
+	if err := loadReportCompany(ctx, s.db, companyID, &fs.CompanyName, &fs.NIT, &fs.NRC); err != nil {
+		return nil, err
+	}
+	if err := s.loadCostCenter(ctx, companyID, req.EstablishmentID, &fs.EstablishmentCode, &fs.EstablishmentName); err != nil {
+		return nil, err
+	}
+
+	chart, err := s.loadChart(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	accountTypes := []string{models.AccountTypeIncome, models.AccountTypeExpense}
+	if statement == models.StatementBalance {
+		accountTypes = []string{models.AccountTypeAsset, models.AccountTypeLiability, models.AccountTypeEquity}
+	}
+
+	// Signed balances (debit - credit) per column and account
+	var balances []map[string]float64
+	for _, period := range statementPeriods(req) {
+		from, excludeClosing := period[0], true
+		if statement == models.StatementBalance {
+			from, excludeClosing = ledgerOpenStart, false
+		}
+		movements, err := s.accountMovements(ctx, companyID, from, period[1], req.EstablishmentID, excludeClosing)
+		if err != nil {
+			return nil, err
+		}
+
+		if req.Breakdown != models.StatementBreakdownEstablishment {
+			column := models.StatementColumn{FromDate: period[0], ToDate: period[1]}
+			if statement == models.StatementBalance {
+				column.FromDate = ""
+			}
+			fs.Columns = append(fs.Columns, column)
+			balances = append(balances, signedBalances(mergeCostCenters(movements)))
+			continue
+		}
+
+		columns, err := s.costCenterColumns(ctx, companyID, movements)
+		if err != nil {
+			return nil, err
+		}
+		for _, column := range columns {
+			column.FromDate, column.ToDate = period[0], period[1]
+			if statement == models.StatementBalance {
+				column.FromDate = ""
+			}
+			fs.Columns = append(fs.Columns, column)
+			if column.Total {
+				balances = append(balances, signedBalances(mergeCostCenters(movements)))
+			} else {
+				balances = append(balances, signedBalances(movements[column.EstablishmentID]))
+			}
+		}
+	}
+
+	// Net income is the credit balance of the income and expense accounts
+	fs.NetIncome = make([]float64, len(balances))
+	for i, column := range balances {
+		for _, account := range chart.accounts {
+			if account.AccountType == models.AccountTypeIncome || account.AccountType == models.AccountTypeExpense {
+				fs.NetIncome[i] -= column[account.ID]
+			}
+		}
+		fs.NetIncome[i] = round(fs.NetIncome[i])
+	}
+
+	rolled := make([]map[string]float64, len(balances))
+	for i, column := range balances {
+		rolled[i] = chart.rollup(column)
+	}
+
+	sections := map[string]*models.StatementSection{}
+	for _, accountType := range accountTypes {
+		section := &models.StatementSection{
+			AccountType: accountType,
+			Lines:       []models.StatementLine{},
+			Totals:      make([]float64, len(balances)),
+		}
+		for _, account := range chart.accounts {
+			if account.AccountType != accountType {
+				continue
+			}
+			line := models.StatementLine{
+				AccountID: account.ID,
+				Code:      account.Code,
+				Name:      account.Name,
+				Level:     chart.levels[account.ID],
+				Postable:  account.Postable,
+				Amounts:   make([]float64, len(balances)),
+			}
+			nonZero := false
+			for i := range rolled {
+				amount := rolled[i][account.ID]
+				if !models.IsDebitNormal(accountType) {
+					amount = -amount
+				}
+				line.Amounts[i] = round(amount)
+				if line.Amounts[i] != 0 {
+					nonZero = true
+				}
+				if line.Level == 0 {
+					section.Totals[i] += amount
+				}
+			}
+			if nonZero {
+				section.Lines = append(section.Lines, line)
+			}
+		}
+		if accountType == models.AccountTypeEquity {
+			for i := range section.Totals {
+				section.Totals[i] += fs.NetIncome[i]
+			}
+		}
+		for i := range section.Totals {
+			section.Totals[i] = round(section.Totals[i])
+		}
+		sections[accountType] = section
+		fs.Sections = append(fs.Sections, *section)
+	}
+
+	if statement == models.StatementBalance {
+		fs.TotalLiabilitiesEquity = make([]float64, len(balances))
+		for i := range balances {
+			fs.TotalLiabilitiesEquity[i] = round(sections[models.AccountTypeLiability].Totals[i] +
+				sections[models.AccountTypeEquity].Totals[i])
+		}
+	}
+	return fs, nil
+}
+
+// costCenterColumns returns a column for each establishment with movements,
+// by code, then one for the entries without an establishment and one for the
+// total
+func (s *LedgerService) costCenterColumns(ctx context.Context, companyID string, movements map[string]map[string]ledgerBalance) ([]models.StatementColumn, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, cod_establecimiento, nombre FROM establishments WHERE company_id = $1 ORDER BY cod_establecimiento
+	`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load establishments: %w", err)
+	}
+	defer rows.Close()
+
+	columns := []models.StatementColumn{}
+	for rows.Next() {
+		var column models.StatementColumn
+		if err := rows.Scan(&column.EstablishmentID, &column.EstablishmentCode, &column.EstablishmentName); err != nil {
+			return nil, fmt.Errorf("failed to scan establishment: %w", err)
+		}
+		if _, ok := movements[column.EstablishmentID]; ok {
+			columns = append(columns, column)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating establishments: %w", err)
+	}
+
+	if _, ok := movements[""]; ok {
+		columns = append(columns, models.StatementColumn{Unassigned: true})
+	}
+	return append(columns, models.StatementColumn{Total: true}), nil
+}
+
+// loadCostCenter loads the code and name of the establishment a report is
+// narrowed to
+func (s *LedgerService) loadCostCenter(ctx context.Context, companyID, establishmentID string, code, name *string) error {
+	if establishmentID == "" || establishmentID == models.CostCenterNone {
+		return nil
+	}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT cod_establecimiento, nombre FROM establishments WHERE id::text = $1 AND company_id = $2
+	`, establishmentID, companyID).Scan(code, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("validation failed: establishment not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load establishment: %w", err)
+	}
+	return nil
+}
+
+// statementPeriods returns the requested period followed by its comparative
+// periods, as from and to dates
+func statementPeriods(req *models.StatementRequest) [][2]string {
+	from, _ := time.Parse("2006-01-02", req.FromDate)
+	to, _ := time.Parse("2006-01-02", req.ToDate)
+
+	// Whole months shift by months, so that a quarter is compared with the
+	// quarter before and not with the 91 days before
+	months := 0
+	if from.Day() == 1 && to.AddDate(0, 0, 1).Day() == 1 {
+		months = (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+	}
+	days := int(to.Sub(from).Hours()/24) + 1
+
+	periods := [][2]string{{req.FromDate, req.ToDate}}
+	for n := 1; n <= req.Periods; n++ {
+		var pFrom, pTo time.Time
+		switch {
+		case req.Compare == models.StatementComparePreviousYear:
+			pFrom, pTo = shiftMonths(from, -12*n), shiftMonths(to, -12*n)
+		case months > 0:
+			pFrom, pTo = shiftMonths(from, -months*n), shiftMonths(to, -months*n)
+		default:
+			pFrom, pTo = from.AddDate(0, 0, -days*n), to.AddDate(0, 0, -days*n)
+		}
+		periods = append(periods, [2]string{pFrom.Format("2006-01-02"), pTo.Format("2006-01-02")})
+	}
+	return periods
+}
+
+// shiftMonths moves a date by whole months, keeping month ends at the end of
+// the month (February 29 to February 28, April 30 to May 31)
+func shiftMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, months, 0)
+	last := first.AddDate(0, 1, -1)
+	if t.AddDate(0, 0, 1).Day() == 1 || t.Day() > last.Day() {
+		return last
+	}
+	return first.AddDate(0, 0, t.Day()-1)
+}
+
+// ============================================
+// BALANCES
+// ============================================
+
+// accountMovements sums the debits and credits of the entries dated from
+// from to to, by cost center ("" for entries without an establishment) and
+// account. costCenter narrows them to one establishment, or to the entries
+// without one when it is "none".
+func (s *LedgerService) accountMovements(ctx context.Context, companyID, from, to, costCenter string, excludeClosing bool) (map[string]map[string]ledgerBalance, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT COALESCE(e.establishment_id::text, ''), l.account_id, SUM(l.debit), SUM(l.credit)
+		FROM journal_lines l
+		JOIN journal_entries e ON e.id = l.entry_id
+		WHERE e.company_id = $1
+		  AND e.entry_date BETWEEN $2 AND $3
+		  AND ($4 = '' OR ($4 = $5 AND e.establishment_id IS NULL) OR e.establishment_id::text = $4)
+		  AND NOT ($6 AND e.source_type = $7)
+		GROUP BY 1, 2
+	`, companyID, from, to, costCenter, models.CostCenterNone, excludeClosing, models.LedgerSourceClosing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load account balances: %w", err)
+	}
+	defer rows.Close()
+
+	movements := map[string]map[string]ledgerBalance{}
+	for rows.Next() {
+		var (
+			establishmentID, accountID string
+			b                          ledgerBalance
+		)
+		if err := rows.Scan(&establishmentID, &accountID, &b.debit, &b.credit); err != nil {
+			return nil, fmt.Errorf("failed to scan account balance: %w", err)
+		}
+		if movements[establishmentID] == nil {
+			movements[establishmentID] = map[string]ledgerBalance{}
+		}
+		movements[establishmentID][accountID] = b
+	}
+	return movements, rows.Err()
+}
+
+// mergeCostCenters adds up the balances of every cost center
+func mergeCostCenters(movements map[string]map[string]ledgerBalance) map[string]ledgerBalance {
+	merged := map[string]ledgerBalance{}
+	for _, accounts := range movements {
+		for id, b := range accounts {
+			sum := merged[id]
+			sum.debit += b.debit
+			sum.credit += b.credit
+			merged[id] = sum
+		}
+	}
+	return merged
+}
+
+// signedBalances returns each account's debit minus credit
+func signedBalances(balances map[string]ledgerBalance) map[string]float64 {
+	signed := make(map[string]float64, len(balances))
+	for id, b := range balances {
+		signed[id] = b.debit - b.credit
+	}
+	return signed
+}
+
+// ledgerChart is the chart of accounts, inactive accounts included, with each
+// account's depth in the hierarchy
+type ledgerChart struct {
+	accounts []models.LedgerAccount // By code
+	parents  map[string]string
+	levels   map[string]int
+}
+
+func (s *LedgerService) loadChart(ctx context.Context, companyID string) (*ledgerChart, error) {
+	accounts, err := s.ListAccounts(ctx, companyID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	chart := &ledgerChart{
+		accounts: accounts,
+		parents:  make(map[string]string, len(accounts)),
+		levels:   make(map[string]int, len(accounts)),
+	}
+	for _, account := range accounts {
+		if account.ParentID != nil {
+			chart.parents[account.ID] = *account.ParentID
+		}
+	}
+	for _, account := range accounts {
+		chart.levels[account.ID] = len(chart.lineage(account.ID)) - 1
+	}
+	return chart, nil
+}
+
+// lineage returns the account followed by its ancestors
+func (c *ledgerChart) lineage(accountID string) []string {
+	ids := []string{accountID}
+	seen := map[string]bool{accountID: true}
+	for id := c.parents[accountID]; id != "" && !seen[id]; id = c.parents[id] {
+		ids = append(ids, id)
+		seen[id] = true
+	}
+	return ids
+}
+
+// rollup adds each account's amount to its ancestors
+func (c *ledgerChart) rollup(amounts map[string]float64) map[string]float64 {
+	rolled := make(map[string]float64, len(amounts))
+	for id, amount := range amounts {
+		for _, ancestor := range c.lineage(id) {
+			rolled[ancestor] += amount
+		}
+	}
+	return rolled
+}
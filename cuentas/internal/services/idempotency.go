@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"cuentas/internal/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================
+// ERRORS
+// ============================================
+
+var (
+	ErrIdempotencyKeyInProgress = errors.New("a request with this Idempotency-Key is still being processed")
+)
+
+const (
+	// How long a stored response is replayed
+	idempotencyRecordTTL = 24 * time.Hour
+
+	// The lock outlives the slowest finalize (signing plus Hacienda retries);
+	// it is released as soon as the request completes
+	idempotencyLockTTL = 5 * time.Minute
+
+	// How long a concurrent duplicate waits for the first request before giving up
+	idempotencyLockWait = 30 * time.Second
+	idempotencyLockPoll = 100 * time.Millisecond
+)
+
+// Deletes the lock only if it still holds our token, so a request whose lock
+// expired can't release the next holder's
+var idempotencyUnlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// ============================================
+// SERVICE DEFINITION
+// ============================================
+
+// IdempotencyService stores the first response for each Idempotency-Key and
+// serializes concurrent requests that share a key
+type IdempotencyService struct {
+	db    *sql.DB
+	redis *redis.Client
+}
+
+// NewIdempotencyService creates a new idempotency service
+func NewIdempotencyService(db *sql.DB, redisClient *redis.Client) *IdempotencyService {
+	return &IdempotencyService{db: db, redis: redisClient}
+}
+
+// HashRequest fingerprints a request so a key reused for a different request
+// can be told apart from a retry
+func HashRequest(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ============================================
+// LOCKING
+// ============================================
+
+// Lock takes the Redis lock for a key, waiting while another request holds it.
+// The returned function releases the lock.
+func (s *IdempotencyService) Lock(ctx context.Context, companyID, key string) (func(), error) {
+	lockKey := fmt.Sprintf("idempotency:%s:%s", companyID, key)
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	deadline := time.Now().Add(idempotencyLockWait)
+	for {
+		ok, err := s.redis.SetNX(ctx, lockKey, token, idempotencyLockTTL).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire idempotency lock: %w", err)
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrIdempotencyKeyInProgress
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(idempotencyLockPoll):
+		}
+	}
+
+	return func() {
+		// Release even if the request context was cancelled
+		if err := idempotencyUnlockScript.Run(context.Background(), s.redis, []string{lockKey}, token).Err(); err != nil {
+			log.Printf("[Idempotency] Failed to release lock %s: %v", lockKey, err)
+		}
+	}, nil
+}
+
+// ============================================
+// STORED RESPONSES
+// ============================================
+
+// GetRecord returns the unexpired stored response for a key, or nil
+func (s *IdempotencyService) GetRecord(ctx context.Context, companyID, key string) (*models.IdempotencyRecord, error) {
+	var r models.IdempotencyRecord
+	err := s.db.QueryRowContext(ctx, `
+		SELECT company_id, idempotency_key, method, path, request_hash,
+		       status_code, content_type, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE company_id = $1 AND idempotency_key = $2 AND expires_at > NOW()
+	`, companyID, key).Scan(
+		&r.CompanyID, &r.Key, &r.Method, &r.Path, &r.RequestHash,
+		&r.StatusCode, &r.ContentType, &r.ResponseBody, &r.CreatedAt, &r.ExpiresAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load idempotency key: %w", err)
+	}
+	return &r, nil
+}
+
+// SaveRecord stores the response for a key. An expired record for the same key
+// is replaced.
+func (s *IdempotencyService) SaveRecord(ctx context.Context, r *models.IdempotencyRecord) error {
+	r.ExpiresAt = time.Now().Add(idempotencyRecordTTL)
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (
+			company_id, idempotency_key, method, path, request_hash,
+			status_code, content_type, response_body, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (company_id, idempotency_key) DO UPDATE SET
+			method = EXCLUDED.method,
+			path = EXCLUDED.path,
+			request_hash = EXCLUDED.request_hash,
+			status_code = EXCLUDED.status_code,
+			content_type = EXCLUDED.content_type,
+			response_body = EXCLUDED.response_body,
+			created_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+	`, r.CompanyID, r.Key, r.Method, r.Path, r.RequestHash,
+		r.StatusCode, r.ContentType, r.ResponseBody, r.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotency key: %w", err)
+	}
+	return nil
+}
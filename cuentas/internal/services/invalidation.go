@@ -0,0 +1,405 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"cuentas/internal/codigos"
+	"cuentas/internal/database"
+	"cuentas/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrInvalidationNotFound    = errors.New("invalidation not found")
+	ErrInvalidationNotAccepted = errors.New("invalidation has not been accepted by Hacienda")
+)
+
+// InvalidationService reverses the business effects of invalidated DTEs
+type InvalidationService struct {
+	inventoryService *InventoryService
+}
+
+func NewInvalidationService(inventoryService *InventoryService) *InvalidationService {
+	return &InvalidationService{
+		inventoryService: inventoryService,
+	}
+}
+
+// ReverseDocumentEffects undoes what finalizing the original document did:
+// goods sold go back to inventory and goods bought (FSE) leave it, credit
+// balances are released from the client, a nota stops adjusting the CCFs it
+// references, and the source document is marked void. Notas move no goods.
+// Safe to call again after a partial failure.
+func (s *InvalidationService) ReverseDocumentEffects(ctx context.Context, companyID, invalidationID, userID string) error {
+	invalidation, err := s.GetInvalidation(ctx, companyID, invalidationID)
+	if err != nil {
+		return err
+	}
+
+	if !invalidation.IsAccepted() {
+		return ErrInvalidationNotAccepted
+	}
+
+	if invalidation.EffectsReversed {
+		return nil
+	}
+
+	// 1. Return goods to inventory (each event commits on its own, so skip lines already returned)
+	if invalidation.InvoiceID != nil {
+		if err := s.returnInvoiceInventory(ctx, companyID, userID, invalidation); err != nil {
+			return err
+		}
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	voidReason, _ := codigos.GetInvalidationTypeName(fmt.Sprintf("%d", invalidation.TipoAnulacion))
+	if invalidation.MotivoAnulacion != nil && *invalidation.MotivoAnulacion != "" {
+		voidReason = *invalidation.MotivoAnulacion
+	}
+
+	// 2. Void the source document and release client balance
+	switch {
+	case invalidation.InvoiceID != nil:
+		if err := s.voidInvoiceTx(ctx, tx, companyID, *invalidation.InvoiceID, voidReason, userID); err != nil {
+			return err
+		}
+	case invalidation.PurchaseID != nil:
+		if err := s.reversePurchaseInventoryTx(ctx, tx, companyID, userID, invalidation); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE purchases
+			SET status = 'voided', voided_at = NOW(), voided_by = $1
+			WHERE id = $2 AND company_id = $3
+		`, nullIfBlank(&userID), *invalidation.PurchaseID, companyID)
+		if err != nil {
+			return fmt.Errorf("failed to void purchase: %w", err)
+		}
+	case invalidation.OriginalTipoDte == codigos.DocTypeNotaCredito:
+		var notaID string
+		err = tx.QueryRowContext(ctx, `
+			UPDATE notas_credito
+			SET status = 'voided', voided_at = NOW()
+			WHERE UPPER(dte_codigo_generacion) = $1 AND company_id = $2
+			RETURNING id
+		`, invalidation.OriginalCodigoGeneracion, companyID).Scan(&notaID)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to void nota de crédito: %w", err)
+		}
+		// The credit no longer comes off the CCFs, nor off the client's balance
+		if notaID != "" {
+			if err := settleNotaCCFsTx(ctx, tx, models.StatementEntryNotaCredito, companyID, notaID); err != nil {
+				return err
+			}
+		}
+	case invalidation.OriginalTipoDte == codigos.DocTypeNotaDebito:
+		var notaID string
+		err = tx.QueryRowContext(ctx, `
+			UPDATE notas_debito
+			SET status = 'voided', voided_at = NOW(), voided_by = $1
+			WHERE UPPER(dte_codigo_generacion) = $2 AND company_id = $3
+			RETURNING id
+		`, nullIfBlank(&userID), invalidation.OriginalCodigoGeneracion, companyID).Scan(&notaID)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to void nota de débito: %w", err)
+		}
+		// The charge no longer adds to the CCFs, nor to the client's balance
+		if notaID != "" {
+			if err := settleNotaCCFsTx(ctx, tx, models.StatementEntryNotaDebito, companyID, notaID); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 3. Mark effects as reversed
+	_, err = tx.ExecContext(ctx, `
+		UPDATE dte_invalidations
+		SET effects_reversed = true, effects_reversed_at = NOW()
+		WHERE id = $1
+	`, invalidation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to mark invalidation effects reversed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// returnInvoiceInventory records a RETURN event for every SALE event of the
+// invoice, at the location the sale took the goods from
+func (s *InvalidationService) returnInvoiceInventory(ctx context.Context, companyID, userID string, invalidation *models.DTEInvalidation) error {
+	query := `
+		SELECT e.item_id, e.establishment_id, e.quantity, e.unit_cost, COALESCE(e.invoice_line_id::text, '')
+		FROM inventory_events e
+		WHERE e.company_id = $1
+		  AND e.invoice_id = $2
+		  AND e.event_type = 'SALE'
+		  AND NOT EXISTS (
+			SELECT 1 FROM inventory_events r
+			WHERE r.company_id = e.company_id
+			  AND r.item_id = e.item_id
+			  AND r.event_type = 'RETURN'
+			  AND r.reference_type = 'dte_invalidation'
+			  AND r.reference_id = $3
+			  AND r.invoice_line_id IS NOT DISTINCT FROM e.invoice_line_id
+		  )
+		ORDER BY e.event_id
+	`
+
+	rows, err := database.DB.QueryContext(ctx, query, companyID, *invalidation.InvoiceID, invalidation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to query sale events: %w", err)
+	}
+
+	type saleLine struct {
+		itemID          string
+		establishmentID string
+		quantity        float64
+		unitCost        models.Money
+		invoiceLineID   string
+	}
+
+	var lines []saleLine
+	for rows.Next() {
+		var line saleLine
+		if err := rows.Scan(&line.itemID, &line.establishmentID, &line.quantity, &line.unitCost, &line.invoiceLineID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan sale event: %w", err)
+		}
+		lines = append(lines, line)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating sale events: %w", err)
+	}
+
+	notes := fmt.Sprintf("Invalidación %s del DTE %s", invalidation.CodigoGeneracion, invalidation.OriginalNumeroControl)
+	for _, line := range lines {
+		req := &models.RecordSaleReturnRequest{
+			Quantity:        line.quantity,
+			UnitCost:        line.unitCost,
+			EstablishmentID: line.establishmentID,
+			DocumentType:    invalidation.OriginalTipoDte,
+			DocumentNumber:  invalidation.OriginalNumeroControl,
+			InvoiceID:       *invalidation.InvoiceID,
+			InvoiceLineID:   line.invoiceLineID,
+			ReferenceType:   "dte_invalidation",
+			ReferenceID:     invalidation.ID,
+			Notes:           &notes,
+		}
+
+		if _, err := s.inventoryService.RecordSaleReturn(ctx, companyID, line.itemID, userID, req); err != nil {
+			return fmt.Errorf("failed to return item %s to inventory: %w", line.itemID, err)
+		}
+		log.Printf("[ReverseDocumentEffects] Returned %.2f units of item %s to inventory", line.quantity, line.itemID)
+	}
+
+	return nil
+}
+
+// reversePurchaseInventoryTx takes back out of inventory the goods the
+// purchase's PURCHASE events put in, in the same transaction that voids it
+func (s *InvalidationService) reversePurchaseInventoryTx(ctx context.Context, tx *sql.Tx, companyID, userID string, invalidation *models.DTEInvalidation) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT event_id, item_id, establishment_id, quantity, unit_cost,
+		       document_type, document_number, correlation_id
+		FROM inventory_events
+		WHERE company_id = $1
+		  AND event_type = 'PURCHASE'
+		  AND reference_type = 'purchase'
+		  AND reference_id = $2
+		ORDER BY event_id
+	`, companyID, *invalidation.PurchaseID)
+	if err != nil {
+		return fmt.Errorf("failed to query purchase events: %w", err)
+	}
+
+	var purchases []models.InventoryEvent
+	for rows.Next() {
+		var event models.InventoryEvent
+		if err := rows.Scan(
+			&event.EventID, &event.ItemID, &event.EstablishmentID, &event.Quantity, &event.UnitCost,
+			&event.DocumentType, &event.DocumentNumber, &event.CorrelationID,
+		); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan purchase event: %w", err)
+		}
+		purchases = append(purchases, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating purchase events: %w", err)
+	}
+
+	notes := fmt.Sprintf("Invalidación %s del DTE %s", invalidation.CodigoGeneracion, invalidation.OriginalNumeroControl)
+	for i := range purchases {
+		if _, err := s.inventoryService.reversePurchaseTx(ctx, tx, companyID, userID, &purchases[i], "dte_invalidation", invalidation.ID, notes); err != nil {
+			return fmt.Errorf("failed to take item %s back out of inventory: %w", purchases[i].ItemID, err)
+		}
+		log.Printf("[ReverseDocumentEffects] Took %.2f units of item %s back out of inventory", purchases[i].Quantity, purchases[i].ItemID)
+	}
+
+	return nil
+}
+
+// voidInvoiceTx marks the invoice void and takes its outstanding balance off the client
+func (s *InvalidationService) voidInvoiceTx(ctx context.Context, tx *sql.Tx, companyID, invoiceID, voidReason, userID string) error {
+	var (
+		clientID     string
+		paymentTerms sql.NullString
+		balanceDue   float64
+		status       string
+	)
+
+	err := tx.QueryRowContext(ctx, `
+		SELECT client_id, payment_terms, balance_due, status
+		FROM invoices
+		WHERE id = $1 AND company_id = $2
+		FOR UPDATE
+	`, invoiceID, companyID).Scan(&clientID, &paymentTerms, &balanceDue, &status)
+	if err == sql.ErrNoRows {
+		return ErrInvoiceNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load invoice: %w", err)
+	}
+
+	if status == "void" {
+		return nil
+	}
+
+	terms := paymentTerms.String
+	if (terms == "cuenta" || terms == "net_30" || terms == "net_60") && balanceDue > 0 {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE clients
+			SET current_balance = GREATEST(current_balance - $1, 0),
+			    credit_status = CASE
+			        WHEN credit_status = 'suspended' THEN credit_status
+			        WHEN GREATEST(current_balance - $1, 0) > credit_limit THEN 'over_limit'
+			        ELSE 'good_standing'
+			    END
+			WHERE id = $2
+		`, balanceDue, clientID)
+		if err != nil {
+			return fmt.Errorf("failed to update client balance: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE invoices
+		SET status = 'void',
+		    balance_due = 0,
+		    void_reason = $1,
+		    voided_at = $2,
+		    voided_by = $3
+		WHERE id = $4 AND company_id = $5
+	`, voidReason, time.Now(), nullIfBlank(&userID), invoiceID, companyID)
+	if err != nil {
+		return fmt.Errorf("failed to void invoice: %w", err)
+	}
+
+	return nil
+}
+
+const invalidationColumns = `
+	id, company_id, codigo_generacion, ambiente,
+	original_codigo_generacion, original_tipo_dte, original_numero_control,
+	original_sello_recibido, original_fecha_emision, invoice_id, purchase_id,
+	codigo_generacion_r, tipo_anulacion, motivo_anulacion,
+	nombre_responsable, tip_doc_responsable, num_doc_responsable,
+	nombre_solicita, tip_doc_solicita, num_doc_solicita,
+	dte_unsigned, dte_signed,
+	hacienda_estado, hacienda_sello_recibido, hacienda_fh_procesamiento,
+	hacienda_codigo_msg, hacienda_descripcion_msg, hacienda_observaciones,
+	effects_reversed, effects_reversed_at,
+	created_by, submitted_at, created_at
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanInvalidation(row rowScanner) (*models.DTEInvalidation, error) {
+	var inv models.DTEInvalidation
+	var observaciones []string
+
+	err := row.Scan(
+		&inv.ID, &inv.CompanyID, &inv.CodigoGeneracion, &inv.Ambiente,
+		&inv.OriginalCodigoGeneracion, &inv.OriginalTipoDte, &inv.OriginalNumeroControl,
+		&inv.OriginalSelloRecibido, &inv.OriginalFechaEmision, &inv.InvoiceID, &inv.PurchaseID,
+		&inv.CodigoGeneracionR, &inv.TipoAnulacion, &inv.MotivoAnulacion,
+		&inv.NombreResponsable, &inv.TipDocResponsable, &inv.NumDocResponsable,
+		&inv.NombreSolicita, &inv.TipDocSolicita, &inv.NumDocSolicita,
+		&inv.DteUnsigned, &inv.DteSigned,
+		&inv.HaciendaEstado, &inv.HaciendaSelloRecibido, &inv.HaciendaFhProcesamiento,
+		&inv.HaciendaCodigoMsg, &inv.HaciendaDescripcionMsg, pq.Array(&observaciones),
+		&inv.EffectsReversed, &inv.EffectsReversedAt,
+		&inv.CreatedBy, &inv.SubmittedAt, &inv.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	inv.HaciendaObservaciones = observaciones
+	return &inv, nil
+}
+
+// GetInvalidation retrieves an invalidation by ID
+func (s *InvalidationService) GetInvalidation(ctx context.Context, companyID, invalidationID string) (*models.DTEInvalidation, error) {
+	query := `SELECT ` + invalidationColumns + ` FROM dte_invalidations WHERE id = $1 AND company_id = $2`
+
+	inv, err := scanInvalidation(database.DB.QueryRowContext(ctx, query, invalidationID, companyID))
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invalidation: %w", err)
+	}
+
+	return inv, nil
+}
+
+// ListInvalidations lists invalidations for a company, optionally for one original DTE
+func (s *InvalidationService) ListInvalidations(ctx context.Context, companyID, originalCodigoGeneracion string, limit, offset int) ([]models.DTEInvalidation, error) {
+	query := `SELECT ` + invalidationColumns + ` FROM dte_invalidations WHERE company_id = $1`
+	args := []interface{}{companyID}
+
+	if originalCodigoGeneracion != "" {
+		query += ` AND original_codigo_generacion = UPPER($2)`
+		args = append(args, originalCodigoGeneracion)
+	}
+
+	query += fmt.Sprintf(` ORDER BY created_at DESC LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invalidations: %w", err)
+	}
+	defer rows.Close()
+
+	invalidations := []models.DTEInvalidation{}
+	for rows.Next() {
+		inv, err := scanInvalidation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invalidation: %w", err)
+		}
+		invalidations = append(invalidations, *inv)
+	}
+
+	return invalidations, rows.Err()
+}
@@ -8,13 +8,16 @@ import (
 	"log"
 	"time"
 
+	"cuentas/internal/codigos"
 	"cuentas/internal/models"
+
+	"github.com/google/uuid"
 )
 
 // RecordPurchase adds inventory with cost tracking via event sourcing
 func (s *InventoryService) RecordPurchase(
 	ctx context.Context,
-	companyID, itemID string,
+	companyID, itemID, userID string,
 	req *models.RecordPurchaseRequest,
 ) (*models.InventoryEvent, error) {
 	// Validate request
@@ -28,6 +31,23 @@ func (s *InventoryService) RecordPurchase(
 		return nil, fmt.Errorf("item not found: %w", err)
 	}
 
+	// Suppliers are Salvadoran unless the registry says otherwise
+	supplierNationality := models.SupplierNacional
+	if req.HasSupplierID() {
+		supplier, err := getActiveSupplier(ctx, s.db, companyID, *req.SupplierID)
+		if err != nil {
+			return nil, err
+		}
+		if req.DocumentType == codigos.DocTypeComprobanteCredito && supplier.NIT() == nil {
+			return nil, fmt.Errorf("validation failed: supplier %s has no NIT, required for document type %s (CCF)",
+				supplier.Name, codigos.DocTypeComprobanteCredito)
+		}
+		req.SupplierID = &supplier.ID
+		req.SupplierName = supplier.Name
+		req.SupplierNIT = supplier.NIT()
+		supplierNationality = supplier.Nationality
+	}
+
 	// Start transaction
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -35,8 +55,37 @@ func (s *InventoryService) RecordPurchase(
 	}
 	defer tx.Rollback()
 
+	if err := checkInventoryLocationTx(ctx, tx, companyID, req.EstablishmentID); err != nil {
+		return nil, err
+	}
+	if req.EstablishmentID, err = bookingLocationTx(ctx, tx, companyID, req.EstablishmentID); err != nil {
+		return nil, err
+	}
+
+	event, err := s.recordPurchaseTx(ctx, tx, companyID, itemID, userID, supplierNationality, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return event, nil
+}
+
+// recordPurchaseTx writes a PURCHASE event and the new inventory state inside
+// the caller's transaction. The request must already be validated, and its
+// supplier and location resolved.
+func (s *InventoryService) recordPurchaseTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	companyID, itemID, userID, supplierNationality string,
+	req *models.RecordPurchaseRequest,
+) (*models.InventoryEvent, error) {
 	// Get or create current state
-	currentState, err := s.getOrCreateInventoryStateTx(ctx, tx, companyID, itemID)
+	currentState, err := s.getOrCreateInventoryStateTx(ctx, tx, companyID, itemID, req.EstablishmentID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current state: %w", err)
 	}
@@ -74,36 +123,32 @@ func (s *InventoryService) RecordPurchase(
 		return nil, fmt.Errorf("failed to marshal event data: %w", err)
 	}
 
-	// Insert event
-	// Hardcode nationality to Nacional (all suppliers are Salvadoran for now)
-	supplierNationality := "Nacional"
-
 	// Insert event
 	eventQuery := `
 	INSERT INTO inventory_events (
-		company_id, item_id, event_type, event_timestamp,
+		company_id, item_id, establishment_id, event_type, event_timestamp,
 		aggregate_version, quantity, unit_cost, total_cost,
 		balance_quantity_after, balance_total_cost_after,
 		moving_avg_cost_before, moving_avg_cost_after,
-		document_type, document_number, supplier_name, supplier_nit, 
+		document_type, document_number, supplier_id, supplier_name, supplier_nit,
 		supplier_nationality, cost_source_ref,
 		reference_type, reference_id, correlation_id,
 		event_data, notes, created_by_user_id, created_at
 	) VALUES (
-		$1, $2, $3, NOW(),
-		$4, $5, $6, $7,
-		$8, $9,
-		$10, $11,
-		$12, $13, $14, $15,
-		$16, $17,
-		$18, $19, $20,
-		$21, $22, $23, NOW()
+		$1, $2, $3, $4, NOW(),
+		$5, $6, $7, $8,
+		$9, $10,
+		$11, $12,
+		$13, $14, $15, $16, $17,
+		$18, $19,
+		$20, $21, $22,
+		$23, $24, $25, NOW()
 	)
-	RETURNING event_id, company_id, item_id, event_type, event_timestamp,
+	RETURNING event_id, company_id, item_id, establishment_id, event_type, event_timestamp,
 			  aggregate_version, quantity, unit_cost, total_cost,
 			  balance_quantity_after, balance_total_cost_after,
 			  moving_avg_cost_before, moving_avg_cost_after,
-			  document_type, document_number, supplier_name, supplier_nit,
+			  document_type, document_number, supplier_id, supplier_name, supplier_nit,
 			  supplier_nationality, cost_source_ref,
 			  reference_type, reference_id, correlation_id,
 			  event_data, notes, created_by_user_id, created_at
@@ -111,20 +156,20 @@ func (s *InventoryService) RecordPurchase(
 
 	var event models.InventoryEvent
 	err = tx.QueryRowContext(ctx, eventQuery,
-		companyID, itemID, "PURCHASE",
+		companyID, itemID, req.EstablishmentID, "PURCHASE",
 		nextVersion, req.Quantity, req.UnitCost.Float64(), purchaseTotal.Float64(),
 		newQuantity, newTotalCost.Float64(),
 		currentState.CurrentAvgCost.Float64(), newAvgCost.Float64(),
-		req.DocumentType, req.DocumentNumber, req.SupplierName, req.SupplierNIT,
+		req.DocumentType, req.DocumentNumber, req.SupplierID, req.SupplierName, req.SupplierNIT,
 		supplierNationality, req.CostSourceRef,
 		req.ReferenceType, req.ReferenceID, req.CorrelationID,
-		eventDataJSON, req.Notes, nil,
+		eventDataJSON, req.Notes, nullIfBlank(&userID),
 	).Scan(
-		&event.EventID, &event.CompanyID, &event.ItemID, &event.EventType, &event.EventTimestamp,
+		&event.EventID, &event.CompanyID, &event.ItemID, &event.EstablishmentID, &event.EventType, &event.EventTimestamp,
 		&event.AggregateVersion, &event.Quantity, &event.UnitCost, &event.TotalCost,
 		&event.BalanceQuantityAfter, &event.BalanceTotalCostAfter,
 		&event.MovingAvgCostBefore, &event.MovingAvgCostAfter,
-		&event.DocumentType, &event.DocumentNumber, &event.SupplierName, &event.SupplierNIT,
+		&event.DocumentType, &event.DocumentNumber, &event.SupplierID, &event.SupplierName, &event.SupplierNIT,
 		&event.SupplierNationality, &event.CostSourceRef,
 		&event.ReferenceType, &event.ReferenceID, &event.CorrelationID,
 		&event.EventData, &event.Notes, &event.CreatedByUserID, &event.CreatedAt,
@@ -134,23 +179,18 @@ func (s *InventoryService) RecordPurchase(
 	}
 
 	// Update state
-	err = s.updateInventoryStateTx(ctx, tx, companyID, itemID, newQuantity, newTotalCost.Float64(), event.EventID, nextVersion, currentState.AggregateVersion) // Changed to .Float64()
+	err = s.updateInventoryStateTx(ctx, tx, companyID, itemID, req.EstablishmentID, newQuantity, newTotalCost.Float64(), event.EventID, nextVersion, currentState.AggregateVersion)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update state: %w", err)
 	}
 
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
 	return &event, nil
 }
 
 // RecordSale records a sale transaction (deducts from inventory)
 func (s *InventoryService) RecordSale(
 	ctx context.Context,
-	companyID, itemID string,
+	companyID, itemID, userID string,
 	req *models.RecordSaleRequest,
 ) (*models.InventoryEvent, error) {
 	log.Printf("[DEBUG] RecordSale called - ItemID: %s, CompanyID: %s", itemID, companyID)
@@ -174,16 +214,20 @@ func (s *InventoryService) RecordSale(
 	}
 	defer tx.Rollback()
 
+	if req.EstablishmentID, err = bookingLocationTx(ctx, tx, companyID, req.EstablishmentID); err != nil {
+		return nil, err
+	}
+
 	// Get current state
-	currentState, err := s.getOrCreateInventoryStateTx(ctx, tx, companyID, itemID)
+	currentState, err := s.getOrCreateInventoryStateTx(ctx, tx, companyID, itemID, req.EstablishmentID)
 	if err != nil {
 		return nil, fmt.Errorf("no se pudo obtener el estado actual: %w", err)
 	}
 
-	// Validate sufficient quantity
+	// Validate sufficient quantity at the invoice's establishment
 	if currentState.CurrentQuantity < req.Quantity {
-		log.Printf("[ERROR] Insufficient stock - Need: %f, Have: %f", req.Quantity, currentState.CurrentQuantity)
-		return nil, fmt.Errorf("stock insuficiente para %s: necesita %.2f, disponible %.2f",
+		log.Printf("[ERROR] Insufficient stock - Establishment: %s, Need: %f, Have: %f", req.EstablishmentID, req.Quantity, currentState.CurrentQuantity)
+		return nil, fmt.Errorf("stock insuficiente para %s en el establecimiento: necesita %.2f, disponible %.2f",
 			item.Name, req.Quantity, currentState.CurrentQuantity)
 	}
 
@@ -219,7 +263,7 @@ func (s *InventoryService) RecordSale(
 	// Insert event with sales columns
 	eventQuery := `
 	INSERT INTO inventory_events (
-		company_id, item_id, event_type, event_timestamp,
+		company_id, item_id, establishment_id, event_type, event_timestamp,
 		aggregate_version, quantity, unit_cost, total_cost,
 		balance_quantity_after, balance_total_cost_after,
 		moving_avg_cost_before, moving_avg_cost_after,
@@ -228,20 +272,20 @@ func (s *InventoryService) RecordSale(
 		tax_exempt, tax_rate, tax_amount,
 		invoice_id, invoice_line_id,
 		customer_name, customer_nit, customer_tax_exempt,
-		correlation_id, event_data, notes, created_at
+		correlation_id, event_data, notes, created_by_user_id, created_at
 	) VALUES (
-		$1, $2, $3, NOW(),
-		$4, $5, $6, $7,
-		$8, $9,
-		$10, $11,
-		$12, $13,
-		$14, $15, $16,
-		$17, $18, $19,
-		$20, $21,
-		$22, $23, $24,
-		$25, $26, $27, NOW()
+		$1, $2, $3, $4, NOW(),
+		$5, $6, $7, $8,
+		$9, $10,
+		$11, $12,
+		$13, $14,
+		$15, $16, $17,
+		$18, $19, $20,
+		$21, $22,
+		$23, $24, $25,
+		$26, $27, $28, $29, NOW()
 	)
-	RETURNING event_id, company_id, item_id, event_type, event_timestamp,
+	RETURNING event_id, company_id, item_id, establishment_id, event_type, event_timestamp,
 			  aggregate_version, quantity, unit_cost, total_cost,
 			  balance_quantity_after, balance_total_cost_after,
 			  moving_avg_cost_before, moving_avg_cost_after,
@@ -250,12 +294,12 @@ func (s *InventoryService) RecordSale(
 			  tax_exempt, tax_rate, tax_amount,
 			  invoice_id, invoice_line_id,
 			  customer_name, customer_nit, customer_tax_exempt,
-			  correlation_id, event_data, notes, created_at
+			  correlation_id, event_data, notes, created_by_user_id, created_at
 	`
 
 	var event models.InventoryEvent
 	err = tx.QueryRowContext(ctx, eventQuery,
-		companyID, itemID, "SALE",
+		companyID, itemID, req.EstablishmentID, "SALE",
 		nextVersion, req.Quantity, costPerUnit.Float64(), saleTotal.Float64(),
 		newQuantity, newTotalCost.Float64(),
 		currentState.CurrentAvgCost.Float64(), newAvgCost.Float64(),
@@ -271,9 +315,9 @@ func (s *InventoryService) RecordSale(
 		req.TaxExempt, req.TaxRate, req.TaxAmount.Float64(),
 		req.InvoiceID, req.InvoiceLineID,
 		req.CustomerName, req.CustomerNIT, req.CustomerTaxExempt,
-		req.InvoiceID, eventDataJSON, req.Notes,
+		req.InvoiceID, eventDataJSON, req.Notes, nullIfBlank(&userID),
 	).Scan(
-		&event.EventID, &event.CompanyID, &event.ItemID, &event.EventType, &event.EventTimestamp,
+		&event.EventID, &event.CompanyID, &event.ItemID, &event.EstablishmentID, &event.EventType, &event.EventTimestamp,
 		&event.AggregateVersion, &event.Quantity, &event.UnitCost, &event.TotalCost,
 		&event.BalanceQuantityAfter, &event.BalanceTotalCostAfter,
 		&event.MovingAvgCostBefore, &event.MovingAvgCostAfter,
@@ -282,7 +326,7 @@ func (s *InventoryService) RecordSale(
 		&event.TaxExempt, &event.TaxRate, &event.TaxAmount,
 		&event.InvoiceID, &event.InvoiceLineID,
 		&event.CustomerName, &event.CustomerNIT, &event.CustomerTaxExempt,
-		&event.CorrelationID, &event.EventData, &event.Notes, &event.CreatedAt,
+		&event.CorrelationID, &event.EventData, &event.Notes, &event.CreatedByUserID, &event.CreatedAt,
 	)
 	if err != nil {
 		log.Printf("[ERROR] Failed to insert sale event: %v", err)
@@ -290,7 +334,7 @@ func (s *InventoryService) RecordSale(
 	}
 
 	// Update state
-	err = s.updateInventoryStateTx(ctx, tx, companyID, itemID, newQuantity, newTotalCost.Float64(), event.EventID, nextVersion, currentState.AggregateVersion)
+	err = s.updateInventoryStateTx(ctx, tx, companyID, itemID, req.EstablishmentID, newQuantity, newTotalCost.Float64(), event.EventID, nextVersion, currentState.AggregateVersion)
 	if err != nil {
 		return nil, fmt.Errorf("no se pudo actualizar el estado: %w", err)
 	}
@@ -307,7 +351,7 @@ func (s *InventoryService) RecordSale(
 // RecordAdjustment corrects inventory quantities (add or remove)
 func (s *InventoryService) RecordAdjustment(
 	ctx context.Context,
-	companyID, itemID string,
+	companyID, itemID, userID string,
 	req *models.RecordAdjustmentRequest,
 ) (*models.InventoryEvent, error) {
 	// Validate request
@@ -328,8 +372,15 @@ func (s *InventoryService) RecordAdjustment(
 	}
 	defer tx.Rollback()
 
+	if err := checkInventoryLocationTx(ctx, tx, companyID, req.EstablishmentID); err != nil {
+		return nil, err
+	}
+	if req.EstablishmentID, err = bookingLocationTx(ctx, tx, companyID, req.EstablishmentID); err != nil {
+		return nil, err
+	}
+
 	// Get current state
-	currentState, err := s.getOrCreateInventoryStateTx(ctx, tx, companyID, itemID)
+	currentState, err := s.getOrCreateInventoryStateTx(ctx, tx, companyID, itemID, req.EstablishmentID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current state: %w", err)
 	}
@@ -387,21 +438,21 @@ func (s *InventoryService) RecordAdjustment(
 	// Insert event
 	eventQuery := `
 		INSERT INTO inventory_events (
-			company_id, item_id, event_type, event_timestamp,
+			company_id, item_id, establishment_id, event_type, event_timestamp,
 			aggregate_version, quantity, unit_cost, total_cost,
 			balance_quantity_after, balance_total_cost_after,
 			moving_avg_cost_before, moving_avg_cost_after,
 			reference_type, reference_id, correlation_id,
 			event_data, notes, created_by_user_id, created_at
 		) VALUES (
-			$1, $2, $3, NOW(),
-			$4, $5, $6, $7,
-			$8, $9,
-			$10, $11,
-			$12, $13, $14,
-			$15, $16, $17, NOW()
+			$1, $2, $3, $4, NOW(),
+			$5, $6, $7, $8,
+			$9, $10,
+			$11, $12,
+			$13, $14, $15,
+			$16, $17, $18, NOW()
 		)
-		RETURNING event_id, company_id, item_id, event_type, event_timestamp,
+		RETURNING event_id, company_id, item_id, establishment_id, event_type, event_timestamp,
 				  aggregate_version, quantity, unit_cost, total_cost,
 				  balance_quantity_after, balance_total_cost_after,
 				  moving_avg_cost_before, moving_avg_cost_after,
@@ -411,14 +462,14 @@ func (s *InventoryService) RecordAdjustment(
 
 	var event models.InventoryEvent
 	err = tx.QueryRowContext(ctx, eventQuery,
-		companyID, itemID, "ADJUSTMENT",
+		companyID, itemID, req.EstablishmentID, "ADJUSTMENT",
 		nextVersion, req.Quantity, unitCost.Float64(), adjustmentTotal.Float64(),
 		newQuantity, newTotalCost.Float64(),
 		currentState.CurrentAvgCost.Float64(), newAvgCost.Float64(),
 		req.ReferenceType, req.ReferenceID, req.CorrelationID,
-		eventDataJSON, req.Reason, nil,
+		eventDataJSON, req.Reason, nullIfBlank(&userID),
 	).Scan(
-		&event.EventID, &event.CompanyID, &event.ItemID, &event.EventType, &event.EventTimestamp,
+		&event.EventID, &event.CompanyID, &event.ItemID, &event.EstablishmentID, &event.EventType, &event.EventTimestamp,
 		&event.AggregateVersion, &event.Quantity, &event.UnitCost, &event.TotalCost,
 		&event.BalanceQuantityAfter, &event.BalanceTotalCostAfter,
 		&event.MovingAvgCostBefore, &event.MovingAvgCostAfter,
@@ -430,7 +481,7 @@ func (s *InventoryService) RecordAdjustment(
 	}
 
 	// Update state
-	err = s.updateInventoryStateTx(ctx, tx, companyID, itemID, newQuantity, newTotalCost.Float64(), event.EventID, nextVersion, currentState.AggregateVersion) // Fixed: added .Float64()
+	err = s.updateInventoryStateTx(ctx, tx, companyID, itemID, req.EstablishmentID, newQuantity, newTotalCost.Float64(), event.EventID, nextVersion, currentState.AggregateVersion) // Fixed: added .Float64()
 	if err != nil {
 		return nil, fmt.Errorf("failed to update state: %w", err)
 	}
@@ -443,74 +494,367 @@ func (s *InventoryService) RecordAdjustment(
 	return &event, nil
 }
 
-// GetInventoryState retrieves the current inventory state for an item
+// RecordSaleReturn puts goods back into inventory when the sale that removed them
+// is invalidated. The goods come back to the location the sale removed them
+// from, at the cost it removed them at.
+func (s *InventoryService) RecordSaleReturn(
+	ctx context.Context,
+	companyID, itemID, userID string,
+	req *models.RecordSaleReturnRequest,
+) (*models.InventoryEvent, error) {
+	// Validate request
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// Verify item exists
+	_, err := s.GetItemByID(ctx, companyID, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("item not found: %w", err)
+	}
+
+	// Start transaction
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if req.EstablishmentID, err = bookingLocationTx(ctx, tx, companyID, req.EstablishmentID); err != nil {
+		return nil, err
+	}
+
+	// Get current state
+	currentState, err := s.getOrCreateInventoryStateTx(ctx, tx, companyID, itemID, req.EstablishmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current state: %w", err)
+	}
+
+	// Calculate new values (return increases inventory)
+	returnTotal := req.UnitCost.Mul(req.Quantity)
+	newTotalCost := currentState.CurrentTotalCost.Add(returnTotal)
+	newQuantity := currentState.CurrentQuantity + req.Quantity
+
+	var newAvgCost models.Money
+	if newQuantity > 0 {
+		newAvgCost = newTotalCost.Div(newQuantity)
+	}
+
+	nextVersion := currentState.AggregateVersion + 1
+
+	// Build event data
+	eventData := map[string]interface{}{
+		"sale_return":     true,
+		"invoice_id":      req.InvoiceID,
+		"invoice_line_id": req.InvoiceLineID,
+		"quantity":        req.Quantity,
+		"unit_cost":       req.UnitCost.Float64(),
+		"total_cost":      returnTotal.Float64(),
+	}
+	if req.Notes != nil {
+		eventData["notes"] = *req.Notes
+	}
+
+	eventDataJSON, err := json.Marshal(eventData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	var invoiceLineID interface{}
+	if req.InvoiceLineID != "" {
+		invoiceLineID = req.InvoiceLineID
+	}
+
+	// Insert event
+	eventQuery := `
+		INSERT INTO inventory_events (
+			company_id, item_id, establishment_id, event_type, event_timestamp,
+			aggregate_version, quantity, unit_cost, total_cost,
+			balance_quantity_after, balance_total_cost_after,
+			moving_avg_cost_before, moving_avg_cost_after,
+			document_type, document_number,
+			invoice_id, invoice_line_id,
+			reference_type, reference_id, correlation_id,
+			event_data, notes, created_by_user_id, created_at
+		) VALUES (
+			$1, $2, $3, $4, NOW(),
+			$5, $6, $7, $8,
+			$9, $10,
+			$11, $12,
+			$13, $14,
+			$15, $16,
+			$17, $18, $19,
+			$20, $21, $22, NOW()
+		)
+		RETURNING event_id, company_id, item_id, establishment_id, event_type, event_timestamp,
+				  aggregate_version, quantity, unit_cost, total_cost,
+				  balance_quantity_after, balance_total_cost_after,
+				  moving_avg_cost_before, moving_avg_cost_after,
+				  document_type, document_number,
+				  invoice_id, invoice_line_id,
+				  reference_type, reference_id, correlation_id,
+				  event_data, notes, created_by_user_id, created_at
+	`
+
+	var event models.InventoryEvent
+	err = tx.QueryRowContext(ctx, eventQuery,
+		companyID, itemID, req.EstablishmentID, "RETURN",
+		nextVersion, req.Quantity, req.UnitCost.Float64(), returnTotal.Float64(),
+		newQuantity, newTotalCost.Float64(),
+		currentState.CurrentAvgCost.Float64(), newAvgCost.Float64(),
+		req.DocumentType, req.DocumentNumber,
+		req.InvoiceID, invoiceLineID,
+		req.ReferenceType, req.ReferenceID, req.InvoiceID,
+		eventDataJSON, req.Notes, nullIfBlank(&userID),
+	).Scan(
+		&event.EventID, &event.CompanyID, &event.ItemID, &event.EstablishmentID, &event.EventType, &event.EventTimestamp,
+		&event.AggregateVersion, &event.Quantity, &event.UnitCost, &event.TotalCost,
+		&event.BalanceQuantityAfter, &event.BalanceTotalCostAfter,
+		&event.MovingAvgCostBefore, &event.MovingAvgCostAfter,
+		&event.DocumentType, &event.DocumentNumber,
+		&event.InvoiceID, &event.InvoiceLineID,
+		&event.ReferenceType, &event.ReferenceID, &event.CorrelationID,
+		&event.EventData, &event.Notes, &event.CreatedByUserID, &event.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert event: %w", err)
+	}
+
+	// Update state
+	err = s.updateInventoryStateTx(ctx, tx, companyID, itemID, req.EstablishmentID, newQuantity, newTotalCost.Float64(), event.EventID, nextVersion, currentState.AggregateVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update state: %w", err)
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &event, nil
+}
+
+// reversePurchaseTx takes the goods of a PURCHASE event back out of inventory
+// when the purchase document is invalidated. They leave the location the
+// purchase put them in, at the cost it put them in at, as an ADJUSTMENT tied
+// to the reference (the invalidation).
+func (s *InventoryService) reversePurchaseTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	companyID, userID string,
+	purchase *models.InventoryEvent,
+	referenceType, referenceID, notes string,
+) (*models.InventoryEvent, error) {
+	currentState, err := s.getOrCreateInventoryStateTx(ctx, tx, companyID, purchase.ItemID, purchase.EstablishmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current state: %w", err)
+	}
+
+	newQuantity := currentState.CurrentQuantity - purchase.Quantity
+	if newQuantity < 0 {
+		return nil, fmt.Errorf("insufficient stock to reverse the purchase of item %s: need %.2f, available %.2f",
+			purchase.ItemID, purchase.Quantity, currentState.CurrentQuantity)
+	}
+
+	reversalTotal := purchase.UnitCost.Mul(purchase.Quantity)
+	newTotalCost := currentState.CurrentTotalCost.Sub(reversalTotal)
+	var newAvgCost models.Money
+	if newQuantity > 0 && newTotalCost > 0 {
+		newAvgCost = newTotalCost.Div(newQuantity)
+	} else {
+		newTotalCost = 0
+	}
+
+	nextVersion := currentState.AggregateVersion + 1
+
+	// Build event data
+	eventData := map[string]interface{}{
+		"purchase_reversal": true,
+		"purchase_event_id": purchase.EventID,
+		"quantity":          -purchase.Quantity,
+		"unit_cost":         purchase.UnitCost.Float64(),
+		"total_cost":        -reversalTotal.Float64(),
+		"reason":            notes,
+	}
+
+	eventDataJSON, err := json.Marshal(eventData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	// Insert event
+	eventQuery := `
+		INSERT INTO inventory_events (
+			company_id, item_id, establishment_id, event_type, event_timestamp,
+			aggregate_version, quantity, unit_cost, total_cost,
+			balance_quantity_after, balance_total_cost_after,
+			moving_avg_cost_before, moving_avg_cost_after,
+			document_type, document_number,
+			reference_type, reference_id, correlation_id,
+			event_data, notes, created_by_user_id, created_at
+		) VALUES (
+			$1, $2, $3, 'ADJUSTMENT', NOW(),
+			$4, $5, $6, $7,
+			$8, $9,
+			$10, $11,
+			$12, $13,
+			$14, $15, $16,
+			$17, $18, $19, NOW()
+		)
+		RETURNING event_id, company_id, item_id, establishment_id, event_type, event_timestamp,
+				  aggregate_version, quantity, unit_cost, total_cost,
+				  balance_quantity_after, balance_total_cost_after,
+				  moving_avg_cost_before, moving_avg_cost_after,
+				  document_type, document_number,
+				  reference_type, reference_id, correlation_id,
+				  event_data, notes, created_by_user_id, created_at
+	`
+
+	var event models.InventoryEvent
+	err = tx.QueryRowContext(ctx, eventQuery,
+		companyID, purchase.ItemID, purchase.EstablishmentID,
+		nextVersion, -purchase.Quantity, purchase.UnitCost.Float64(), -reversalTotal.Float64(),
+		newQuantity, newTotalCost.Float64(),
+		currentState.CurrentAvgCost.Float64(), newAvgCost.Float64(),
+		purchase.DocumentType, purchase.DocumentNumber,
+		referenceType, referenceID, purchase.CorrelationID,
+		eventDataJSON, notes, nullIfBlank(&userID),
+	).Scan(
+		&event.EventID, &event.CompanyID, &event.ItemID, &event.EstablishmentID, &event.EventType, &event.EventTimestamp,
+		&event.AggregateVersion, &event.Quantity, &event.UnitCost, &event.TotalCost,
+		&event.BalanceQuantityAfter, &event.BalanceTotalCostAfter,
+		&event.MovingAvgCostBefore, &event.MovingAvgCostAfter,
+		&event.DocumentType, &event.DocumentNumber,
+		&event.ReferenceType, &event.ReferenceID, &event.CorrelationID,
+		&event.EventData, &event.Notes, &event.CreatedByUserID, &event.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert event: %w", err)
+	}
+
+	// Update state
+	err = s.updateInventoryStateTx(ctx, tx, companyID, purchase.ItemID, purchase.EstablishmentID, newQuantity, newTotalCost.Float64(), event.EventID, nextVersion, currentState.AggregateVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update state: %w", err)
+	}
+
+	return &event, nil
+}
+
+// GetInventoryState retrieves the current inventory state for an item at a
+// location. Without a location it returns the company-wide state, with the
+// state at each location in Locations.
 func (s *InventoryService) GetInventoryState(
 	ctx context.Context,
-	companyID, itemID string,
+	companyID, itemID, establishmentID string,
 ) (*models.InventoryState, error) {
 	// Verify item exists
-	fmt.Println("we are here... inside the state")
 	_, err := s.GetItemByID(ctx, companyID, itemID)
 	if err != nil {
 		return nil, fmt.Errorf("item not found: %w", err)
 	}
 
 	query := `
-		SELECT company_id, item_id, current_quantity, current_total_cost,
+		SELECT company_id, item_id, establishment_id, current_quantity, current_total_cost,
 			   current_avg_cost, last_event_id, aggregate_version, updated_at
 		FROM inventory_state
 		WHERE company_id = $1 AND item_id = $2
 	`
+	args := []interface{}{companyID, itemID}
+	if establishmentID != "" {
+		query += " AND establishment_id::text = $3"
+		args = append(args, establishmentID)
+	}
+	query += " ORDER BY establishment_id"
 
-	var state models.InventoryState
-	err = s.db.QueryRowContext(ctx, query, companyID, itemID).Scan(
-		&state.CompanyID, &state.ItemID, &state.CurrentQuantity, &state.CurrentTotalCost,
-		&state.CurrentAvgCost, &state.LastEventID, &state.AggregateVersion, &state.UpdatedAt,
-	)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		fmt.Println("we failed to get inventory state", err)
-		if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get inventory state: %w", err)
+	}
+	defer rows.Close()
+
+	var locations []models.InventoryState
+	for rows.Next() {
+		var state models.InventoryState
+		if err := rows.Scan(
+			&state.CompanyID, &state.ItemID, &state.EstablishmentID, &state.CurrentQuantity, &state.CurrentTotalCost,
+			&state.CurrentAvgCost, &state.LastEventID, &state.AggregateVersion, &state.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan inventory state: %w", err)
+		}
+		locations = append(locations, state)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating inventory states: %w", err)
+	}
+
+	if establishmentID != "" {
+		if len(locations) == 0 {
 			// No state yet - return zero state
 			return &models.InventoryState{
-				CompanyID:        companyID,
-				ItemID:           itemID,
-				CurrentQuantity:  0,
-				CurrentTotalCost: 0,
-				CurrentAvgCost:   0,
-				AggregateVersion: 0,
+				CompanyID:       companyID,
+				ItemID:          itemID,
+				EstablishmentID: establishmentID,
 			}, nil
 		}
-		return nil, fmt.Errorf("failed to get inventory state: %w", err)
+		return &locations[0], nil
 	}
 
-	return &state, nil
+	// Company-wide: the sum of the locations
+	state := &models.InventoryState{
+		CompanyID: companyID,
+		ItemID:    itemID,
+		Locations: locations,
+	}
+	for _, location := range locations {
+		state.CurrentQuantity += location.CurrentQuantity
+		state.CurrentTotalCost = state.CurrentTotalCost.Add(location.CurrentTotalCost)
+		state.AggregateVersion += location.AggregateVersion
+		if location.UpdatedAt.After(state.UpdatedAt) {
+			state.UpdatedAt = location.UpdatedAt
+		}
+	}
+	if state.CurrentQuantity > 0 {
+		state.CurrentAvgCost = state.CurrentTotalCost.Div(state.CurrentQuantity)
+	}
+
+	return state, nil
 }
 
-// ListInventoryStates retrieves all inventory states for a company
-// ListInventoryStates gets inventory states with item details
+// ListInventoryStates gets inventory states with item and location details,
+// one per item and location, optionally for a single location
 func (s *InventoryService) ListInventoryStates(
 	ctx context.Context,
 	companyID string,
 	inStockOnly bool,
+	establishmentID string,
 ) ([]models.InventoryStateWithItem, error) {
 	query := `
 		SELECT 
 			s.company_id, s.item_id, 
 			i.sku, i.name as item_name, i.tipo_item,
+			s.establishment_id, COALESCE(e.cod_establecimiento, ''), e.nombre,
 			s.current_quantity, s.current_total_cost, s.current_avg_cost,
 			s.last_event_id, s.aggregate_version, s.updated_at
 		FROM inventory_state s
 		JOIN inventory_items i ON s.item_id = i.id
+		JOIN establishments e ON s.establishment_id = e.id
 		WHERE s.company_id = $1
 	`
+	args := []interface{}{companyID}
+
+	if establishmentID != "" {
+		query += " AND s.establishment_id::text = $2"
+		args = append(args, establishmentID)
+	}
 
 	if inStockOnly {
 		query += " AND s.current_quantity > 0"
 	}
 
-	query += " ORDER BY i.sku"
+	query += " ORDER BY i.sku, e.cod_establecimiento, e.nombre"
 
-	rows, err := s.db.QueryContext(ctx, query, companyID)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list inventory states: %w", err)
 	}
@@ -526,6 +870,9 @@ func (s *InventoryService) ListInventoryStates(
 			&state.SKU,
 			&state.ItemName,
 			&state.TipoItem,
+			&state.EstablishmentID,
+			&state.EstablishmentCode,
+			&state.EstablishmentName,
 			&state.CurrentQuantity,
 			&state.CurrentTotalCost, // Money.Scan() handles conversion
 			&state.CurrentAvgCost,   // Money.Scan() handles conversion
@@ -543,27 +890,130 @@ func (s *InventoryService) ListInventoryStates(
 	return states, nil
 }
 
+// ListInventoryTotals gets each item's stock summed over all its locations
+func (s *InventoryService) ListInventoryTotals(
+	ctx context.Context,
+	companyID string,
+	inStockOnly bool,
+) ([]models.InventoryItemTotals, error) {
+	query := `
+		SELECT
+			s.company_id, s.item_id,
+			i.sku, i.name as item_name, i.tipo_item,
+			SUM(s.current_quantity), SUM(s.current_total_cost),
+			COUNT(*) FILTER (WHERE s.current_quantity <> 0),
+			MAX(s.updated_at)
+		FROM inventory_state s
+		JOIN inventory_items i ON s.item_id = i.id
+		WHERE s.company_id = $1
+		GROUP BY s.company_id, s.item_id, i.sku, i.name, i.tipo_item
+	`
+
+	if inStockOnly {
+		query += " HAVING SUM(s.current_quantity) > 0"
+	}
+
+	query += " ORDER BY i.sku"
+
+	rows, err := s.db.QueryContext(ctx, query, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inventory totals: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []models.InventoryItemTotals
+	for rows.Next() {
+		var total models.InventoryItemTotals
+		err := rows.Scan(
+			&total.CompanyID,
+			&total.ItemID,
+			&total.SKU,
+			&total.ItemName,
+			&total.TipoItem,
+			&total.CurrentQuantity,
+			&total.CurrentTotalCost,
+			&total.Locations,
+			&total.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan inventory totals: %w", err)
+		}
+		if total.CurrentQuantity > 0 {
+			total.CurrentAvgCost = total.CurrentTotalCost.Div(total.CurrentQuantity)
+		}
+		totals = append(totals, total)
+	}
+
+	return totals, nil
+}
+
 // ========================================
 // Helper Functions
 // ========================================
 
-// getOrCreateInventoryStateTx gets existing state or creates initial zero state within transaction
+// checkInventoryLocationTx verifies that an establishment belongs to the
+// company and is active, so goods can be put into or taken out of it
+func checkInventoryLocationTx(ctx context.Context, tx *sql.Tx, companyID, establishmentID string) error {
+	var active bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(active, true) FROM establishments WHERE id::text = $1 AND company_id = $2
+	`, establishmentID, companyID).Scan(&active)
+	if err == sql.ErrNoRows || (err == nil && !active) {
+		return models.ErrEstablishmentNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get establishment: %w", err)
+	}
+	return nil
+}
+
+// inventoryPoolTx returns the establishment where the company's stock, which
+// predates locations, is pooled until it is allocated to them, or "" once it
+// is kept per location. The company row is key-share locked so an allocation
+// waits for movements that were booked at the pool.
+func inventoryPoolTx(ctx context.Context, tx *sql.Tx, companyID string) (string, error) {
+	var pool sql.NullString
+	err := tx.QueryRowContext(ctx, `
+		SELECT inventory_pool_establishment_id FROM companies WHERE id = $1 FOR KEY SHARE
+	`, companyID).Scan(&pool)
+	if err != nil {
+		return "", fmt.Errorf("failed to get inventory pool: %w", err)
+	}
+	return pool.String, nil
+}
+
+// bookingLocationTx returns the location a movement at an establishment is
+// booked at: the company's inventory pool while it has one, else the
+// establishment itself
+func bookingLocationTx(ctx context.Context, tx *sql.Tx, companyID, establishmentID string) (string, error) {
+	pool, err := inventoryPoolTx(ctx, tx, companyID)
+	if err != nil {
+		return "", err
+	}
+	if pool != "" {
+		return pool, nil
+	}
+	return establishmentID, nil
+}
+
+// getOrCreateInventoryStateTx gets existing state of an item at a location or
+// creates initial zero state within transaction
 func (s *InventoryService) getOrCreateInventoryStateTx(
 	ctx context.Context,
 	tx *sql.Tx,
-	companyID, itemID string,
+	companyID, itemID, establishmentID string,
 ) (*models.InventoryState, error) {
 	query := `
-		SELECT company_id, item_id, current_quantity, current_total_cost,
+		SELECT company_id, item_id, establishment_id, current_quantity, current_total_cost,
 			   current_avg_cost, last_event_id, aggregate_version, updated_at
 		FROM inventory_state
-		WHERE company_id = $1 AND item_id = $2
+		WHERE company_id = $1 AND item_id = $2 AND establishment_id = $3
 		FOR UPDATE
 	`
 
 	var state models.InventoryState
-	err := tx.QueryRowContext(ctx, query, companyID, itemID).Scan(
-		&state.CompanyID, &state.ItemID, &state.CurrentQuantity, &state.CurrentTotalCost,
+	err := tx.QueryRowContext(ctx, query, companyID, itemID, establishmentID).Scan(
+		&state.CompanyID, &state.ItemID, &state.EstablishmentID, &state.CurrentQuantity, &state.CurrentTotalCost,
 		&state.CurrentAvgCost, &state.LastEventID, &state.AggregateVersion, &state.UpdatedAt,
 	)
 
@@ -571,15 +1021,15 @@ func (s *InventoryService) getOrCreateInventoryStateTx(
 		// Create initial state
 		insertQuery := `
 			INSERT INTO inventory_state (
-				company_id, item_id, current_quantity, current_total_cost,
+				company_id, item_id, establishment_id, current_quantity, current_total_cost,
 				aggregate_version, updated_at
-			) VALUES ($1, $2, 0, 0, 0, NOW())
-			RETURNING company_id, item_id, current_quantity, current_total_cost,
+			) VALUES ($1, $2, $3, 0, 0, 0, NOW())
+			RETURNING company_id, item_id, establishment_id, current_quantity, current_total_cost,
 					  current_avg_cost, last_event_id, aggregate_version, updated_at
 		`
 
-		err = tx.QueryRowContext(ctx, insertQuery, companyID, itemID).Scan(
-			&state.CompanyID, &state.ItemID, &state.CurrentQuantity, &state.CurrentTotalCost,
+		err = tx.QueryRowContext(ctx, insertQuery, companyID, itemID, establishmentID).Scan(
+			&state.CompanyID, &state.ItemID, &state.EstablishmentID, &state.CurrentQuantity, &state.CurrentTotalCost,
 			&state.CurrentAvgCost, &state.LastEventID, &state.AggregateVersion, &state.UpdatedAt,
 		)
 		if err != nil {
@@ -600,7 +1050,7 @@ func (s *InventoryService) getOrCreateInventoryStateTx(
 func (s *InventoryService) updateInventoryStateTx(
 	ctx context.Context,
 	tx *sql.Tx,
-	companyID, itemID string,
+	companyID, itemID, establishmentID string,
 	newQuantity, newTotalCost float64,
 	eventID int64,
 	newVersion, expectedVersion int,
@@ -612,14 +1062,15 @@ func (s *InventoryService) updateInventoryStateTx(
 			last_event_id = $3,
 			aggregate_version = $4,
 			updated_at = NOW()
-		WHERE company_id = $5 
+		WHERE company_id = $5
 		  AND item_id = $6
-		  AND aggregate_version = $7
+		  AND establishment_id = $7
+		  AND aggregate_version = $8
 	`
 
 	result, err := tx.ExecContext(ctx, query,
 		newQuantity, newTotalCost, eventID, newVersion,
-		companyID, itemID, expectedVersion,
+		companyID, itemID, establishmentID, expectedVersion,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update state: %w", err)
@@ -637,10 +1088,11 @@ func (s *InventoryService) updateInventoryStateTx(
 	return nil
 }
 
-// GetCostHistory gets the cost event history for an item with date filtering
+// GetCostHistory gets the cost event history for an item with date filtering,
+// optionally at a single location
 func (s *InventoryService) GetCostHistory(
 	ctx context.Context,
-	companyID, itemID string,
+	companyID, itemID, establishmentID string,
 	limit int,
 	sortOrder string,
 	startDate, endDate string,
@@ -660,12 +1112,12 @@ func (s *InventoryService) GetCostHistory(
 	// Build query with optional date filters
 	query := `
 	SELECT 
-		event_id, company_id, item_id, event_type, event_timestamp,
+		event_id, company_id, item_id, establishment_id, event_type, event_timestamp,
 		aggregate_version, quantity, unit_cost, total_cost,
 		balance_quantity_after, balance_total_cost_after,
 		moving_avg_cost_before, moving_avg_cost_after,
-		document_type, document_number, 
-		supplier_name, supplier_nit, supplier_nationality, cost_source_ref,
+		document_type, document_number,
+		supplier_id, supplier_name, supplier_nit, supplier_nationality, cost_source_ref,
 		customer_name, customer_nit, customer_tax_exempt,
 		invoice_id, invoice_line_id,
 		sale_price, discount_amount, net_sale_price,
@@ -677,6 +1129,11 @@ func (s *InventoryService) GetCostHistory(
 `
 	args := []interface{}{companyID, itemID}
 	argCount := 2
+	if establishmentID != "" {
+		argCount++
+		query += fmt.Sprintf(" AND establishment_id::text = $%d", argCount)
+		args = append(args, establishmentID)
+	}
 	// Add date filters if provided
 	if startDate != "" {
 		// Validate date format
@@ -708,12 +1165,12 @@ func (s *InventoryService) GetCostHistory(
 	for rows.Next() {
 		var event models.InventoryEvent
 		err := rows.Scan(
-			&event.EventID, &event.CompanyID, &event.ItemID, &event.EventType, &event.EventTimestamp,
+			&event.EventID, &event.CompanyID, &event.ItemID, &event.EstablishmentID, &event.EventType, &event.EventTimestamp,
 			&event.AggregateVersion, &event.Quantity, &event.UnitCost, &event.TotalCost,
 			&event.BalanceQuantityAfter, &event.BalanceTotalCostAfter,
 			&event.MovingAvgCostBefore, &event.MovingAvgCostAfter,
 			&event.DocumentType, &event.DocumentNumber,
-			&event.SupplierName, &event.SupplierNIT, &event.SupplierNationality, &event.CostSourceRef,
+			&event.SupplierID, &event.SupplierName, &event.SupplierNIT, &event.SupplierNationality, &event.CostSourceRef,
 			&event.CustomerName, &event.CustomerNIT, &event.CustomerTaxExempt,
 			&event.InvoiceID, &event.InvoiceLineID,
 			&event.SalePrice, &event.DiscountAmount, &event.NetSalePrice,
@@ -735,6 +1192,7 @@ func (s *InventoryService) GetAllEvents(
 	companyID string,
 	startDate, endDate string,
 	eventType string,
+	establishmentID string,
 	sortOrder string,
 ) ([]models.InventoryEventWithItem, error) {
 	// Validate sort order
@@ -745,17 +1203,19 @@ func (s *InventoryService) GetAllEvents(
 	// Build query
 	query := `
 		SELECT
-			e.event_id, e.company_id, e.item_id, e.event_type, e.event_timestamp,
+			e.event_id, e.company_id, e.item_id, e.establishment_id, e.event_type, e.event_timestamp,
 			e.aggregate_version, e.quantity, e.unit_cost, e.total_cost,
 			e.balance_quantity_after, e.balance_total_cost_after,
 			e.moving_avg_cost_before, e.moving_avg_cost_after,
-			e.document_type, e.document_number, e.supplier_name, e.supplier_nit,
+			e.document_type, e.document_number, e.supplier_id, e.supplier_name, e.supplier_nit,
 			e.supplier_nationality, e.cost_source_ref,
 			e.reference_type, e.reference_id, e.correlation_id,
 			e.event_data, e.notes, e.created_by_user_id, e.created_at,
-			i.sku, i.name as item_name
+			i.sku, i.name as item_name,
+			COALESCE(est.cod_establecimiento, ''), est.nombre
 		FROM inventory_events e
 		JOIN inventory_items i ON e.item_id = i.id
+		JOIN establishments est ON e.establishment_id = est.id
 		WHERE e.company_id = $1
 	`
 
@@ -788,6 +1248,13 @@ func (s *InventoryService) GetAllEvents(
 		args = append(args, eventType)
 	}
 
+	// Add location filter
+	if establishmentID != "" {
+		argCount++
+		query += fmt.Sprintf(" AND e.establishment_id::text = $%d", argCount)
+		args = append(args, establishmentID)
+	}
+
 	// Add ordering
 	query += fmt.Sprintf(" ORDER BY e.event_timestamp %s, e.event_id %s", sortOrder, sortOrder)
 
@@ -801,15 +1268,16 @@ func (s *InventoryService) GetAllEvents(
 	for rows.Next() {
 		var event models.InventoryEventWithItem
 		err := rows.Scan(
-			&event.EventID, &event.CompanyID, &event.ItemID, &event.EventType, &event.EventTimestamp,
+			&event.EventID, &event.CompanyID, &event.ItemID, &event.EstablishmentID, &event.EventType, &event.EventTimestamp,
 			&event.AggregateVersion, &event.Quantity, &event.UnitCost, &event.TotalCost,
 			&event.BalanceQuantityAfter, &event.BalanceTotalCostAfter,
 			&event.MovingAvgCostBefore, &event.MovingAvgCostAfter,
-			&event.DocumentType, &event.DocumentNumber, &event.SupplierName, &event.SupplierNIT,
+			&event.DocumentType, &event.DocumentNumber, &event.SupplierID, &event.SupplierName, &event.SupplierNIT,
 			&event.SupplierNationality, &event.CostSourceRef,
 			&event.ReferenceType, &event.ReferenceID, &event.CorrelationID,
 			&event.EventData, &event.Notes, &event.CreatedByUserID, &event.CreatedAt,
 			&event.SKU, &event.ItemName,
+			&event.EstablishmentCode, &event.EstablishmentName,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan event: %w", err)
@@ -820,11 +1288,13 @@ func (s *InventoryService) GetAllEvents(
 	return events, nil
 }
 
-// GetInventoryValuationAtDate calculates total inventory value at a specific date
+// GetInventoryValuationAtDate calculates total inventory value at a specific
+// date, for one location or summed over all of them
 func (s *InventoryService) GetInventoryValuationAtDate(
 	ctx context.Context,
 	companyID string,
 	asOfDate string,
+	establishmentID string,
 ) (*models.InventoryValuation, error) {
 	// Validate date format
 	targetDate, err := time.Parse("2006-01-02", asOfDate)
@@ -832,49 +1302,73 @@ func (s *InventoryService) GetInventoryValuationAtDate(
 		return nil, fmt.Errorf("invalid date format, use YYYY-MM-DD: %w", err)
 	}
 
-	// Get all product items
-	items, err := s.ListItems(ctx, companyID, false, "1") // tipo_item = "1" (products only)
+	// The last event of each product at each location up to the target date
+	// holds that location's balance
+	query := `
+		SELECT item_id, sku, name, quantity, total_value, event_id, event_timestamp
+		FROM (
+			SELECT DISTINCT ON (e.item_id, e.establishment_id)
+				e.item_id, i.sku, i.name,
+				e.balance_quantity_after AS quantity, e.balance_total_cost_after AS total_value,
+				e.event_id, e.event_timestamp
+			FROM inventory_events e
+			JOIN inventory_items i ON e.item_id = i.id
+			WHERE e.company_id = $1
+			  AND i.tipo_item = '1'
+			  AND e.event_timestamp <= $2
+			  AND ($3 = '' OR e.establishment_id::text = $3)
+			ORDER BY e.item_id, e.establishment_id, e.event_timestamp DESC, e.event_id DESC
+		) last
+		ORDER BY sku, item_id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, companyID, asOfDate+"T23:59:59Z", establishmentID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list items: %w", err)
+		return nil, fmt.Errorf("failed to get inventory balances: %w", err)
 	}
+	defer rows.Close()
 
 	valuation := &models.InventoryValuation{
-		AsOfDate:   targetDate,
-		CompanyID:  companyID,
-		ItemValues: []models.ItemValuation{},
+		AsOfDate:        targetDate,
+		CompanyID:       companyID,
+		EstablishmentID: establishmentID,
+		ItemValues:      []models.ItemValuation{},
 	}
 
-	var totalValue models.Money
-	var totalQuantity float64
-
-	// For each item, calculate its value at the target date
-	for _, item := range items {
-		// Get all events up to the target date
-		events, err := s.GetCostHistory(ctx, companyID, item.ID, 10000, "asc", "", asOfDate)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get history for item %s: %w", item.ID, err)
+	// Sum each item's locations; rows come grouped by item
+	for rows.Next() {
+		var balance models.ItemValuation
+		if err := rows.Scan(
+			&balance.ItemID, &balance.SKU, &balance.ItemName,
+			&balance.Quantity, &balance.TotalValue, &balance.LastEventID, &balance.LastEventAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan inventory balance: %w", err)
 		}
 
-		if len(events) == 0 {
-			// No events before this date - item had zero inventory
+		n := len(valuation.ItemValues)
+		if n == 0 || valuation.ItemValues[n-1].ItemID != balance.ItemID {
+			valuation.ItemValues = append(valuation.ItemValues, balance)
 			continue
 		}
-
-		// Get the last event before the target date
-		lastEvent := events[len(events)-1]
-
-		itemVal := models.ItemValuation{
-			ItemID:      item.ID,
-			SKU:         item.SKU,
-			ItemName:    item.Name,
-			Quantity:    lastEvent.BalanceQuantityAfter,
-			AvgCost:     lastEvent.MovingAvgCostAfter,
-			TotalValue:  lastEvent.BalanceTotalCostAfter,
-			LastEventID: lastEvent.EventID,
-			LastEventAt: lastEvent.EventTimestamp,
+		itemVal := &valuation.ItemValues[n-1]
+		itemVal.Quantity += balance.Quantity
+		itemVal.TotalValue = itemVal.TotalValue.Add(balance.TotalValue)
+		if balance.LastEventAt.After(itemVal.LastEventAt) {
+			itemVal.LastEventID = balance.LastEventID
+			itemVal.LastEventAt = balance.LastEventAt
 		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating inventory balances: %w", err)
+	}
 
-		valuation.ItemValues = append(valuation.ItemValues, itemVal)
+	var totalValue models.Money
+	var totalQuantity float64
+	for i := range valuation.ItemValues {
+		itemVal := &valuation.ItemValues[i]
+		if itemVal.Quantity > 0 {
+			itemVal.AvgCost = itemVal.TotalValue.Div(itemVal.Quantity)
+		}
 		totalValue = totalValue.Add(itemVal.TotalValue)
 		totalQuantity += itemVal.Quantity
 	}
@@ -885,3 +1379,362 @@ func (s *InventoryService) GetInventoryValuationAtDate(
 
 	return valuation, nil
 }
+
+// GetLegalRegisterEvents gets the events of an item's inventory register for
+// a period. For a location they are its events with its balances. Without
+// one the register is consolidated: transfers between locations are left
+// out, and each event carries the company-wide balance after it.
+func (s *InventoryService) GetLegalRegisterEvents(
+	ctx context.Context,
+	companyID, itemID, establishmentID string,
+	startDate, endDate string,
+) ([]models.InventoryEvent, error) {
+	if establishmentID != "" {
+		return s.GetCostHistory(ctx, companyID, itemID, establishmentID, 10000, "asc", startDate, endDate)
+	}
+
+	// Each location's balance when the period opens
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (establishment_id)
+			establishment_id, balance_quantity_after, balance_total_cost_after
+		FROM inventory_events
+		WHERE company_id = $1 AND item_id = $2 AND event_timestamp < $3
+		ORDER BY establishment_id, event_timestamp DESC, event_id DESC
+	`, companyID, itemID, startDate+"T00:00:00Z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get opening balances: %w", err)
+	}
+	type balance struct {
+		quantity  float64
+		totalCost models.Money
+	}
+	balances := map[string]balance{}
+	for rows.Next() {
+		var establishmentID string
+		var b balance
+		if err := rows.Scan(&establishmentID, &b.quantity, &b.totalCost); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan opening balance: %w", err)
+		}
+		balances[establishmentID] = b
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating opening balances: %w", err)
+	}
+
+	events, err := s.GetCostHistory(ctx, companyID, itemID, "", 10000, "asc", startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Transfers still move the locations' balances, so the sum stays right
+	// whichever location moves next
+	register := make([]models.InventoryEvent, 0, len(events))
+	for _, event := range events {
+		balances[event.EstablishmentID] = balance{event.BalanceQuantityAfter, event.BalanceTotalCostAfter}
+		if event.EventType == "TRANSFER_OUT" || event.EventType == "TRANSFER_IN" {
+			continue
+		}
+
+		var quantity float64
+		var totalCost models.Money
+		for _, b := range balances {
+			quantity += b.quantity
+			totalCost = totalCost.Add(b.totalCost)
+		}
+		event.BalanceQuantityAfter = quantity
+		event.BalanceTotalCostAfter = totalCost
+		event.MovingAvgCostAfter = 0
+		if quantity > 0 {
+			event.MovingAvgCostAfter = totalCost.Div(quantity)
+		}
+		register = append(register, event)
+	}
+
+	return register, nil
+}
+
+// ========================================
+// Transfers
+// ========================================
+
+// RecordTransfer moves goods from one location to another. The goods leave
+// the source at its average cost and enter the destination at that cost, as
+// a TRANSFER_OUT and TRANSFER_IN pair that shares the transfer's ID.
+func (s *InventoryService) RecordTransfer(
+	ctx context.Context,
+	companyID, itemID, userID string,
+	req *models.RecordTransferRequest,
+) (*models.InventoryTransfer, error) {
+	// Validate request
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// Verify item exists
+	item, err := s.GetItemByID(ctx, companyID, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("item not found: %w", err)
+	}
+
+	// Start transaction
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// A pooled company books every movement at its pool, so there is nothing
+	// to move until the stock is allocated
+	pool, err := inventoryPoolTx(ctx, tx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if pool != "" {
+		return nil, models.ErrInventoryPooled
+	}
+
+	transfer, err := s.recordTransferTx(ctx, tx, companyID, itemID, item.Name, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return transfer, nil
+}
+
+// AllocateInventoryLocations moves a pooled company's stock from the pool to
+// its establishments, as transfers at the pool's average cost, and from then
+// on books each movement at its own establishment. Allocations to the pool
+// itself are left where they are.
+func (s *InventoryService) AllocateInventoryLocations(
+	ctx context.Context,
+	companyID, userID string,
+	req *models.AllocateInventoryRequest,
+) (*models.InventoryAllocationResult, error) {
+	// Validate request
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// Start transaction
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the company so no movement is booked at the pool meanwhile
+	var pool sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT inventory_pool_establishment_id FROM companies WHERE id = $1 FOR UPDATE
+	`, companyID).Scan(&pool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory pool: %w", err)
+	}
+	if !pool.Valid {
+		return nil, models.ErrInventoryNotPooled
+	}
+
+	result := &models.InventoryAllocationResult{
+		PoolEstablishmentID: pool.String,
+		Transfers:           []models.InventoryTransfer{},
+	}
+	for i, allocation := range req.Allocations {
+		if allocation.EstablishmentID == pool.String {
+			continue
+		}
+		item, err := s.GetItemByID(ctx, companyID, allocation.ItemID)
+		if err != nil {
+			return nil, fmt.Errorf("allocations[%d]: item not found: %w", i, err)
+		}
+		transfer, err := s.recordTransferTx(ctx, tx, companyID, item.ID, item.Name, userID, &models.RecordTransferRequest{
+			FromEstablishmentID: pool.String,
+			ToEstablishmentID:   allocation.EstablishmentID,
+			Quantity:            allocation.Quantity,
+			Notes:               req.Notes,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("allocations[%d]: %w", i, err)
+		}
+		result.Transfers = append(result.Transfers, *transfer)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE companies SET inventory_pool_establishment_id = NULL WHERE id = $1
+	`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear inventory pool: %w", err)
+	}
+
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// recordTransferTx moves goods between two locations within a transaction
+func (s *InventoryService) recordTransferTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	companyID, itemID, itemName, userID string,
+	req *models.RecordTransferRequest,
+) (*models.InventoryTransfer, error) {
+	for _, establishmentID := range []string{req.FromEstablishmentID, req.ToEstablishmentID} {
+		if err := checkInventoryLocationTx(ctx, tx, companyID, establishmentID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Lock both states in a fixed order so crossing transfers cannot deadlock
+	first, second := req.FromEstablishmentID, req.ToEstablishmentID
+	if second < first {
+		first, second = second, first
+	}
+	states := map[string]*models.InventoryState{}
+	for _, establishmentID := range []string{first, second} {
+		state, err := s.getOrCreateInventoryStateTx(ctx, tx, companyID, itemID, establishmentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current state: %w", err)
+		}
+		states[establishmentID] = state
+	}
+	from, to := states[req.FromEstablishmentID], states[req.ToEstablishmentID]
+
+	if from.CurrentQuantity < req.Quantity {
+		return nil, fmt.Errorf("insufficient stock for %s at the source location: need %.2f, available %.2f",
+			itemName, req.Quantity, from.CurrentQuantity)
+	}
+
+	transferID := uuid.New().String()
+	unitCost := from.CurrentAvgCost
+	transferTotal := unitCost.Mul(req.Quantity)
+
+	transfer := &models.InventoryTransfer{TransferID: transferID}
+
+	// Source: goods leave at the average cost, which stays the same
+	fromQuantity := from.CurrentQuantity - req.Quantity
+	fromTotalCost := from.CurrentTotalCost.Sub(transferTotal)
+	fromAvgCost := from.CurrentAvgCost
+	if fromQuantity == 0 {
+		fromTotalCost = 0
+		fromAvgCost = 0
+	}
+	out, err := s.insertTransferEventTx(ctx, tx, companyID, itemID, userID, "TRANSFER_OUT",
+		from, req.ToEstablishmentID, transferID, req.Quantity, unitCost, transferTotal,
+		fromQuantity, fromTotalCost, fromAvgCost, req.Notes)
+	if err != nil {
+		return nil, err
+	}
+	transfer.Out = *out
+
+	// Destination: goods enter at the source's cost and are averaged in
+	toQuantity := to.CurrentQuantity + req.Quantity
+	toTotalCost := to.CurrentTotalCost.Add(transferTotal)
+	toAvgCost := toTotalCost.Div(toQuantity)
+	in, err := s.insertTransferEventTx(ctx, tx, companyID, itemID, userID, "TRANSFER_IN",
+		to, req.FromEstablishmentID, transferID, req.Quantity, unitCost, transferTotal,
+		toQuantity, toTotalCost, toAvgCost, req.Notes)
+	if err != nil {
+		return nil, err
+	}
+	transfer.In = *in
+
+	return transfer, nil
+}
+
+// insertTransferEventTx writes one side of a transfer at the state's location
+// and moves the state to the new balance
+func (s *InventoryService) insertTransferEventTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	companyID, itemID, userID, eventType string,
+	state *models.InventoryState,
+	counterpartID, transferID string,
+	quantity float64,
+	unitCost, total models.Money,
+	newQuantity float64,
+	newTotalCost, newAvgCost models.Money,
+	notes *string,
+) (*models.InventoryEvent, error) {
+	nextVersion := state.AggregateVersion + 1
+
+	// Build event data
+	eventData := map[string]interface{}{
+		"transfer_id": transferID,
+		"quantity":    quantity,
+		"unit_cost":   unitCost.Float64(),
+		"total_cost":  total.Float64(),
+	}
+	if eventType == "TRANSFER_OUT" {
+		eventData["to_establishment_id"] = counterpartID
+	} else {
+		eventData["from_establishment_id"] = counterpartID
+	}
+	if notes != nil {
+		eventData["notes"] = *notes
+	}
+
+	eventDataJSON, err := json.Marshal(eventData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	eventQuery := `
+		INSERT INTO inventory_events (
+			company_id, item_id, establishment_id, event_type, event_timestamp,
+			aggregate_version, quantity, unit_cost, total_cost,
+			balance_quantity_after, balance_total_cost_after,
+			moving_avg_cost_before, moving_avg_cost_after,
+			reference_type, reference_id, correlation_id,
+			event_data, notes, created_by_user_id, created_at
+		) VALUES (
+			$1, $2, $3, $4, NOW(),
+			$5, $6, $7, $8,
+			$9, $10,
+			$11, $12,
+			'TRANSFER', $13, $13,
+			$14, $15, $16, NOW()
+		)
+		RETURNING event_id, company_id, item_id, establishment_id, event_type, event_timestamp,
+				  aggregate_version, quantity, unit_cost, total_cost,
+				  balance_quantity_after, balance_total_cost_after,
+				  moving_avg_cost_before, moving_avg_cost_after,
+				  reference_type, reference_id, correlation_id,
+				  event_data, notes, created_by_user_id, created_at
+	`
+
+	var event models.InventoryEvent
+	err = tx.QueryRowContext(ctx, eventQuery,
+		companyID, itemID, state.EstablishmentID, eventType,
+		nextVersion, quantity, unitCost.Float64(), total.Float64(),
+		newQuantity, newTotalCost.Float64(),
+		state.CurrentAvgCost.Float64(), newAvgCost.Float64(),
+		transferID,
+		eventDataJSON, notes, nullIfBlank(&userID),
+	).Scan(
+		&event.EventID, &event.CompanyID, &event.ItemID, &event.EstablishmentID, &event.EventType, &event.EventTimestamp,
+		&event.AggregateVersion, &event.Quantity, &event.UnitCost, &event.TotalCost,
+		&event.BalanceQuantityAfter, &event.BalanceTotalCostAfter,
+		&event.MovingAvgCostBefore, &event.MovingAvgCostAfter,
+		&event.ReferenceType, &event.ReferenceID, &event.CorrelationID,
+		&event.EventData, &event.Notes, &event.CreatedByUserID, &event.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert event: %w", err)
+	}
+
+	err = s.updateInventoryStateTx(ctx, tx, companyID, itemID, state.EstablishmentID, newQuantity, newTotalCost.Float64(), event.EventID, nextVersion, state.AggregateVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update state: %w", err)
+	}
+
+	return &event, nil
+}
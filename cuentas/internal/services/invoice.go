@@ -932,6 +932,75 @@ func (s *InvoiceService) determineDTEType(tipoPersona string) string {
 
 // generateNumeroControl generates the DTE numero control with strict validation
 func (s *InvoiceService) generateNumeroControl(ctx context.Context, tx *sql.Tx, companyID, establishmentID, pointOfSaleID, posID, tipoDte string) (string, error) {
+	fmt.Println("these are the detaisl you sent the generate numero control")
+	fmt.Println(establishmentID, pointOfSaleID)
+
+	MHEstablishmentCode, MHPOSCode, err := s.getMHCodes(ctx, tx, posID)
+	if err != nil {
+		return "", err
+	}
+
+	// Get next sequence for this POS and tipoDte
+	sequence, err := s.getAndIncrementDTESequence(ctx, tx, companyID, posID, tipoDte)
+	if err != nil {
+		return "", err
+	}
+
+	// Build numero control using the validator (ensures correctness)
+	numeroControl, err := dte.BuildNumeroControl(tipoDte, MHEstablishmentCode, MHPOSCode, sequence)
+	if err != nil {
+		return "", fmt.Errorf("failed to build numero control: %w", err)
+	}
+
+	return numeroControl, nil
+}
+
+// PreviewNumeroControl returns the numero control the next finalize of this POS and
+// tipoDte would get. It reads dte_sequences without locking or incrementing it, so the
+// value is provisional: another document may claim it first.
+func (s *InvoiceService) PreviewNumeroControl(ctx context.Context, companyID, posID, tipoDte string) (string, error) {
+	tx, err := database.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	MHEstablishmentCode, MHPOSCode, err := s.getMHCodes(ctx, tx, posID)
+	if err != nil {
+		return "", err
+	}
+
+	var lastSeq int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT last_sequence
+		FROM dte_sequences
+		WHERE company_id = $1 AND point_of_sale_id = $2 AND tipo_dte = $3
+	`, companyID, posID, tipoDte).Scan(&lastSeq)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to get sequence: %w", err)
+	}
+
+	numeroControl, err := dte.BuildNumeroControl(tipoDte, MHEstablishmentCode, MHPOSCode, lastSeq+1)
+	if err != nil {
+		return "", fmt.Errorf("failed to build numero control: %w", err)
+	}
+
+	return numeroControl, nil
+}
+
+// InvoiceDTEType returns the DTE type an invoice is issued as on finalize
+func (s *InvoiceService) InvoiceDTEType(invoice *models.Invoice) string {
+	if invoice.IsExportInvoice() {
+		return "11" // Export invoice
+	}
+	if invoice.ClientTipoPersona != nil {
+		return s.determineDTEType(*invoice.ClientTipoPersona)
+	}
+	return "01" // Default to factura
+}
+
+// getMHCodes loads and validates the Hacienda-assigned establishment and POS codes
+func (s *InvoiceService) getMHCodes(ctx context.Context, tx *sql.Tx, posID string) (string, string, error) {
 	// Get establishment and POS codes (no COALESCE - must be set)
 	query := `
 		SELECT 
@@ -946,55 +1015,41 @@ func (s *InvoiceService) generateNumeroControl(ctx context.Context, tx *sql.Tx,
 
 	var MHEstablishmentCode, MHPOSCode *string
 	var establishmentName, posName string
-	fmt.Println("these are the detaisl you sent the generate numero control")
-	fmt.Println(establishmentID, pointOfSaleID)
 
 	err := tx.QueryRowContext(ctx, query, posID).Scan(&MHEstablishmentCode, &MHPOSCode, &establishmentName, &posName)
 	if err != nil {
-		return "", fmt.Errorf("failed to get establishment codes: %w", err)
+		return "", "", fmt.Errorf("failed to get establishment codes: %w", err)
 	}
 
 	// Strict validation: MH codes must be set
 	if MHEstablishmentCode == nil || *MHEstablishmentCode == "" {
-		return "", fmt.Errorf("establishment '%s' must have cod_establecimiento assigned by Hacienda before finalizing invoices", establishmentName)
+		return "", "", fmt.Errorf("establishment '%s' must have cod_establecimiento assigned by Hacienda before finalizing invoices", establishmentName)
 	}
 	if MHPOSCode == nil || *MHPOSCode == "" {
-		return "", fmt.Errorf("point of sale '%s' must have cod_punto_venta assigned by Hacienda before finalizing invoices", posName)
+		return "", "", fmt.Errorf("point of sale '%s' must have cod_punto_venta assigned by Hacienda before finalizing invoices", posName)
 	}
 
 	// Validate 4-character format
 	if len(*MHEstablishmentCode) != 4 {
-		return "", fmt.Errorf("establishment '%s' cod_establecimiento must be exactly 4 characters, got %d: '%s'",
+		return "", "", fmt.Errorf("establishment '%s' cod_establecimiento must be exactly 4 characters, got %d: '%s'",
 			establishmentName, len(*MHEstablishmentCode), *MHEstablishmentCode)
 	}
 	if len(*MHPOSCode) != 4 {
-		return "", fmt.Errorf("point of sale '%s' cod_punto_venta must be exactly 4 characters, got %d: '%s'",
+		return "", "", fmt.Errorf("point of sale '%s' cod_punto_venta must be exactly 4 characters, got %d: '%s'",
 			posName, len(*MHPOSCode), *MHPOSCode)
 	}
 
 	// Validate codes are alphanumeric (Hacienda uses numeric, but spec allows alphanumeric)
 	if !s.isValidMHCode(*MHEstablishmentCode) {
-		return "", fmt.Errorf("establishment '%s' cod_establecimiento contains invalid characters: '%s'",
+		return "", "", fmt.Errorf("establishment '%s' cod_establecimiento contains invalid characters: '%s'",
 			establishmentName, *MHEstablishmentCode)
 	}
 	if !s.isValidMHCode(*MHPOSCode) {
-		return "", fmt.Errorf("point of sale '%s' cod_punto_venta contains invalid characters: '%s'",
+		return "", "", fmt.Errorf("point of sale '%s' cod_punto_venta contains invalid characters: '%s'",
 			posName, *MHPOSCode)
 	}
 
-	// Get next sequence for this POS and tipoDte
-	sequence, err := s.getAndIncrementDTESequence(ctx, tx, companyID, posID, tipoDte)
-	if err != nil {
-		return "", err
-	}
-
-	// Build numero control using the validator (ensures correctness)
-	numeroControl, err := dte.BuildNumeroControl(tipoDte, *MHEstablishmentCode, *MHPOSCode, sequence)
-	if err != nil {
-		return "", fmt.Errorf("failed to build numero control: %w", err)
-	}
-
-	return numeroControl, nil
+	return *MHEstablishmentCode, *MHPOSCode, nil
 }
 
 // isValidMHCode checks if an MH code contains only alphanumeric characters
@@ -1164,14 +1219,7 @@ func (s *InvoiceService) FinalizeInvoice(ctx context.Context, companyID, invoice
 	}
 
 	// 3. Determine DTE type based on client tipo_persona
-	var tipoDte string
-	if invoice.IsExportInvoice() {
-		tipoDte = "11" // Export invoice
-	} else if invoice.ClientTipoPersona != nil {
-		tipoDte = s.determineDTEType(*invoice.ClientTipoPersona)
-	} else {
-		tipoDte = "01" // Default to factura
-	}
+	tipoDte := s.InvoiceDTEType(invoice)
 
 	// 4. Generate DTE identifiers
 	numeroControl, err := s.generateNumeroControl(ctx, tx, companyID, invoice.EstablishmentID, invoice.PointOfSaleID, invoice.PointOfSaleID, tipoDte)
@@ -1232,8 +1280,9 @@ func (s *InvoiceService) FinalizeInvoice(ctx context.Context, companyID, invoice
 
 				// Prepare sale request
 				saleReq := &models.RecordSaleRequest{
-					Quantity:      lineItem.Quantity,
-					UnitSalePrice: models.Money(lineItem.UnitPrice),
+					Quantity:        lineItem.Quantity,
+					EstablishmentID: invoice.EstablishmentID,
+					UnitSalePrice:   models.Money(lineItem.UnitPrice),
 					DiscountAmount: func() *models.Money {
 						if discountPerUnit > 0 {
 							return &discountPerUnit
@@ -1257,7 +1306,7 @@ func (s *InvoiceService) FinalizeInvoice(ctx context.Context, companyID, invoice
 					companyID, *lineItem.ItemID)
 
 				// Record sale (deducts inventory)
-				saleEvent, err := s.inventoryService.RecordSale(ctx, companyID, *lineItem.ItemID, saleReq)
+				saleEvent, err := s.inventoryService.RecordSale(ctx, companyID, *lineItem.ItemID, userID, saleReq)
 				if err != nil {
 					log.Printf("[ERROR] FinalizeInvoice: RecordSale failed: %v", err)
 					return nil, fmt.Errorf("no se pudo registrar la venta del artículo %s: %w", item.Name, err)
@@ -1315,7 +1364,7 @@ func (s *InvoiceService) FinalizeInvoice(ctx context.Context, companyID, invoice
 		numeroControl,
 		tipoDte,
 		now,
-		userID,
+		nullIfBlank(&userID),
 		invoiceID,
 		companyID,
 	)
@@ -1345,7 +1394,7 @@ func (s *InvoiceService) FinalizeInvoice(ctx context.Context, companyID, invoice
 		payment.PaymentMethod,
 		payment.ReferenceNumber,
 		paymentDate,
-		userID,
+		nullIfBlank(&userID),
 		payment.Notes,
 	)
 	if err != nil {
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"cuentas/internal/formats"
+	"cuentas/internal/models"
+)
+
+// GetIVADeclaration computes the F-07 for a fiscal month from the libros de
+// ventas and the libro de compras, so the declaration lines reconcile with the
+// books' totals. remanenteAnterior is the crédito fiscal carried over from the
+// previous month's declaration.
+func (s *TaxBookService) GetIVADeclaration(ctx context.Context, companyID string, period *models.TaxBookPeriod, remanenteAnterior float64) (*models.IVADeclaration, error) {
+	contributorSales, err := s.GetContributorSalesBook(ctx, companyID, period, "")
+	if err != nil {
+		return nil, err
+	}
+	consumerSales, err := s.GetConsumerSalesBook(ctx, companyID, period, "")
+	if err != nil {
+		return nil, err
+	}
+	purchases, err := s.GetPurchaseBook(ctx, companyID, period, "")
+	if err != nil {
+		return nil, err
+	}
+
+	decl := &models.IVADeclaration{
+		CompanyID:        companyID,
+		CompanyName:      purchases.CompanyName,
+		NIT:              purchases.NIT,
+		NRC:              purchases.NRC,
+		Year:             period.Year,
+		Month:            period.Month,
+		Anexos:           []models.F07AnexoSummary{},
+		GeneratedAt:      time.Now(),
+		ContributorSales: contributorSales,
+		ConsumerSales:    consumerSales,
+		Purchases:        purchases,
+	}
+
+	cs := contributorSales.Totals
+	cf := consumerSales.Totals
+	pt := purchases.Totals
+	l := &decl.Lines
+
+	l.VentasGravadasContribuyentes = cs.VentasGravadas
+	l.VentasGravadasConsumidor = cf.VentasGravadas
+	l.VentasExentas = round(cs.VentasExentas + cf.VentasExentas)
+	l.VentasNoSujetas = round(cs.VentasNoSujetas + cf.VentasNoSujetas)
+	l.Exportaciones = cf.Exportaciones
+	l.DebitoFiscalContribuyentes = cs.IVADebito
+	l.DebitoFiscalConsumidor = cf.IVADebito
+	l.DebitoFiscal = round(cs.IVADebito + cf.IVADebito)
+
+	l.ComprasGravadasInternas = pt.ComprasGravadasInternas
+	l.ImportacionesGravadas = pt.ImportacionesGravadas
+	l.ComprasExentas = round(pt.ComprasExentasInternas + pt.ComprasExentasImportaciones)
+	l.SujetosExcluidos = pt.SujetosExcluidos
+	l.CreditoFiscal = pt.IVACreditoFiscal
+	l.RemanenteAnterior = round(remanenteAnterior)
+
+	// Débito fiscal less crédito fiscal; an excess of crédito is the remanente
+	// carried to the next month
+	determinado := round(l.DebitoFiscal - l.CreditoFiscal - l.RemanenteAnterior)
+	l.ImpuestoDeterminado = math.Max(determinado, 0)
+	l.RemanenteSiguiente = math.Max(-determinado, 0)
+
+	l.RetencionesSufridas = purchases.RetencionesSufridas
+	l.RetencionesEfectuadas = purchases.RetencionesEmitidas
+	l.PercepcionesEfectuadas = cs.IVAPercibido
+
+	net := round(l.ImpuestoDeterminado - l.RetencionesSufridas)
+	l.ImpuestoAPagar = round(math.Max(net, 0) + l.RetencionesEfectuadas + l.PercepcionesEfectuadas)
+	l.ExcedenteRetenciones = math.Max(-net, 0)
+
+	for _, anexo := range models.F07Anexos {
+		records, amount := formats.F07AnexoRecords(decl, anexo)
+		decl.Anexos = append(decl.Anexos, models.F07AnexoSummary{
+			Anexo:    anexo,
+			Filename: formats.F07AnexoFilename(decl, anexo),
+			Rows:     len(records),
+			Amount:   round(amount),
+		})
+	}
+
+	return decl, nil
+}
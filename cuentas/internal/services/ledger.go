@@ -0,0 +1,696 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"cuentas/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ============================================
+// ERRORS
+// ============================================
+
+var (
+	ErrLedgerAccountNotFound    = errors.New("ledger account not found")
+	ErrLedgerAccountCodeTaken   = errors.New("an account with this code already exists")
+	ErrLedgerAccountNotPostable = errors.New("account is inactive or does not accept postings")
+	ErrLedgerAccountInUse       = errors.New("account is used by posting rules")
+	ErrLedgerParentInvalid      = errors.New("parent account must be a group account of the same type")
+	ErrLedgerChartExists        = errors.New("the company already has a chart of accounts")
+	ErrPostingRuleNotFound      = errors.New("posting rule not found")
+	ErrLedgerRulesIncomplete    = errors.New("posting rules are incomplete")
+	ErrJournalEntryNotFound     = errors.New("journal entry not found")
+)
+
+// ============================================
+// SERVICE DEFINITION
+// ============================================
+
+// LedgerService manages the chart of accounts, the posting rules and the
+// journal. Entries are written by the ledger worker (see ledger_posting.go);
+// this side only configures and reads them.
+type LedgerService struct {
+	db *sql.DB
+}
+
+// NewLedgerService creates a new ledger service
+func NewLedgerService(db *sql.DB) *LedgerService {
+	return &LedgerService{db: db}
+}
+
+// ledgerQuerier is a *sql.DB or a *sql.Tx
+type ledgerQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+const ledgerAccountColumns = `
+	id, company_id, code, name, account_type, parent_id, postable, active, created_at, updated_at
+`
+
+// ============================================
+// ACCOUNTS
+// ============================================
+
+// CreateAccount adds an account to the chart. A parent must be a group
+// (non-postable) account of the same type.
+func (s *LedgerService) CreateAccount(ctx context.Context, companyID string, req *models.CreateLedgerAccountRequest) (*models.LedgerAccount, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if req.ParentID != nil && *req.ParentID != "" {
+		if err := s.checkParent(ctx, companyID, *req.ParentID, req.AccountType); err != nil {
+			return nil, err
+		}
+	}
+	postable := true
+	if req.Postable != nil {
+		postable = *req.Postable
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO ledger_accounts (company_id, code, name, account_type, parent_id, postable)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+ledgerAccountColumns,
+		companyID, req.Code, req.Name, req.AccountType, nullIfBlank(req.ParentID), postable,
+	)
+	account, err := scanLedgerAccount(row)
+	if err != nil {
+		return nil, ledgerAccountWriteError(err)
+	}
+	return account, nil
+}
+
+// ListAccounts returns the chart of accounts by code
+func (s *LedgerService) ListAccounts(ctx context.Context, companyID string, includeInactive bool) ([]models.LedgerAccount, error) {
+	query := `SELECT ` + ledgerAccountColumns + ` FROM ledger_accounts WHERE company_id = $1`
+	if !includeInactive {
+		query += " AND active = true"
+	}
+	query += " ORDER BY code"
+
+	rows, err := s.db.QueryContext(ctx, query, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []models.LedgerAccount{}
+	for rows.Next() {
+		account, err := scanLedgerAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ledger account: %w", err)
+		}
+		accounts = append(accounts, *account)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ledger accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+// GetAccount returns one account
+func (s *LedgerService) GetAccount(ctx context.Context, companyID, accountID string) (*models.LedgerAccount, error) {
+	if _, err := uuid.Parse(accountID); err != nil {
+		return nil, ErrLedgerAccountNotFound
+	}
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+ledgerAccountColumns+`
+		FROM ledger_accounts
+		WHERE id = $1 AND company_id = $2
+	`, accountID, companyID)
+
+	account, err := scanLedgerAccount(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLedgerAccountNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger account: %w", err)
+	}
+	return account, nil
+}
+
+// UpdateAccount changes an account. An account posting rules point at must
+// stay active and postable.
+func (s *LedgerService) UpdateAccount(ctx context.Context, companyID, accountID string, req *models.UpdateLedgerAccountRequest) (*models.LedgerAccount, error) {
+	account, err := s.GetAccount(ctx, companyID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Apply(account); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if req.ParentID != nil && account.ParentID != nil {
+		if err := s.checkParent(ctx, companyID, *account.ParentID, account.AccountType); err != nil {
+			return nil, err
+		}
+	}
+
+	if !account.Active || !account.Postable {
+		var inUse bool
+		err := s.db.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM ledger_posting_rules WHERE account_id = $1)
+		`, accountID).Scan(&inUse)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check posting rules: %w", err)
+		}
+		if inUse {
+			return nil, ErrLedgerAccountInUse
+		}
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE ledger_accounts
+		SET name = $1, parent_id = $2, postable = $3, active = $4, updated_at = NOW()
+		WHERE id = $5 AND company_id = $6
+		RETURNING `+ledgerAccountColumns,
+		account.Name, account.ParentID, account.Postable, account.Active, accountID, companyID,
+	)
+	updated, err := scanLedgerAccount(row)
+	if err != nil {
+		return nil, ledgerAccountWriteError(err)
+	}
+	return updated, nil
+}
+
+// checkParent verifies a parent account is a group account of the same type
+func (s *LedgerService) checkParent(ctx context.Context, companyID, parentID, accountType string) error {
+	parent, err := s.GetAccount(ctx, companyID, parentID)
+	if errors.Is(err, ErrLedgerAccountNotFound) {
+		return ErrLedgerParentInvalid
+	}
+	if err != nil {
+		return err
+	}
+	if parent.Postable || parent.AccountType != accountType {
+		return ErrLedgerParentInvalid
+	}
+	return nil
+}
+
+// ============================================
+// DEFAULT CHART
+// ============================================
+
+// defaultChartAccount is an account of the starter chart. Accounts with roles
+// get a default posting rule for each of them.
+type defaultChartAccount struct {
+	Code        string
+	Name        string
+	AccountType string
+	Parent      string
+	Roles       []string
+}
+
+// defaultChart is a compact chart in the usual Salvadoran layout: 1 activo,
+// 2 pasivo, 3 patrimonio, 4 costos y gastos, 5 ingresos
+var defaultChart = []defaultChartAccount{
+	{Code: "1", Name: "Activo", AccountType: models.AccountTypeAsset},
+	{Code: "1101", Name: "Efectivo y equivalentes", AccountType: models.AccountTypeAsset, Parent: "1", Roles: []string{models.RoleCash}},
+	{Code: "1103", Name: "Cuentas por cobrar a clientes", AccountType: models.AccountTypeAsset, Parent: "1", Roles: []string{models.RoleReceivable}},
+	{Code: "1105", Name: "IVA crédito fiscal", AccountType: models.AccountTypeAsset, Parent: "1", Roles: []string{models.RoleIVACredito}},
+	{Code: "1106", Name: "IVA retenido por clientes", AccountType: models.AccountTypeAsset, Parent: "1", Roles: []string{models.RoleIVARetenidoClientes}},
+	{Code: "1107", Name: "IVA percibido por proveedores", AccountType: models.AccountTypeAsset, Parent: "1", Roles: []string{models.RoleIVAPercibidoProveedores}},
+	{Code: "1108", Name: "Inventarios", AccountType: models.AccountTypeAsset, Parent: "1", Roles: []string{models.RoleInventory}},
+	{Code: "2", Name: "Pasivo", AccountType: models.AccountTypeLiability},
+	{Code: "2101", Name: "Cuentas por pagar a proveedores", AccountType: models.AccountTypeLiability, Parent: "2", Roles: []string{models.RolePayable}},
+	{Code: "2105", Name: "IVA débito fiscal", AccountType: models.AccountTypeLiability, Parent: "2", Roles: []string{models.RoleIVADebito}},
+	{Code: "2106", Name: "IVA percibido a clientes", AccountType: models.AccountTypeLiability, Parent: "2", Roles: []string{models.RoleIVAPercibido}},
+	{Code: "2107", Name: "IVA retenido a proveedores", AccountType: models.AccountTypeLiability, Parent: "2", Roles: []string{models.RoleIVARetenidoProveedores}},
+	{Code: "2108", Name: "Retenciones de renta por pagar", AccountType: models.AccountTypeLiability, Parent: "2", Roles: []string{models.RoleRentaRetenida}},
+	{Code: "3", Name: "Patrimonio", AccountType: models.AccountTypeEquity},
+	{Code: "3101", Name: "Capital social", AccountType: models.AccountTypeEquity, Parent: "3", Roles: []string{models.RoleOpeningBalance}},
+	{Code: "3102", Name: "Resultados acumulados", AccountType: models.AccountTypeEquity, Parent: "3", Roles: []string{models.RoleRetainedEarnings}},
+	{Code: "4", Name: "Costos y gastos", AccountType: models.AccountTypeExpense},
+	{Code: "4101", Name: "Costo de ventas", AccountType: models.AccountTypeExpense, Parent: "4", Roles: []string{models.RoleCOGS}},
+	{Code: "4102", Name: "Compras", AccountType: models.AccountTypeExpense, Parent: "4", Roles: []string{models.RolePurchases, models.RoleInventoryReceived}},
+	{Code: "4103", Name: "Faltantes y sobrantes de inventario", AccountType: models.AccountTypeExpense, Parent: "4", Roles: []string{models.RoleInventoryAdjustment}},
+	{Code: "5", Name: "Ingresos", AccountType: models.AccountTypeIncome},
+	{Code: "5101", Name: "Ventas gravadas", AccountType: models.AccountTypeIncome, Parent: "5", Roles: []string{models.RoleRevenueGravada}},
+	{Code: "5102", Name: "Ventas exentas", AccountType: models.AccountTypeIncome, Parent: "5", Roles: []string{models.RoleRevenueExenta}},
+	{Code: "5103", Name: "Ventas no sujetas", AccountType: models.AccountTypeIncome, Parent: "5", Roles: []string{models.RoleRevenueNoSujeta}},
+	{Code: "5104", Name: "Exportaciones", AccountType: models.AccountTypeIncome, Parent: "5", Roles: []string{models.RoleRevenueExport}},
+}
+
+// SeedDefaultChart loads the starter chart of accounts and its default posting
+// rules into a company without accounts. Purchases and the counterpart of
+// inventory purchase events share the Compras account, so merchandise bought
+// through a purchase and received into inventory nets out of it.
+func (s *LedgerService) SeedDefaultChart(ctx context.Context, companyID string) ([]models.LedgerAccount, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM ledger_accounts WHERE company_id = $1)
+	`, companyID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check chart of accounts: %w", err)
+	}
+	if exists {
+		return nil, ErrLedgerChartExists
+	}
+
+	ids := map[string]string{}
+	for _, a := range defaultChart {
+		var parentID *string
+		if a.Parent != "" {
+			id := ids[a.Parent]
+			parentID = &id
+		}
+		var id string
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO ledger_accounts (company_id, code, name, account_type, parent_id, postable)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id
+		`, companyID, a.Code, a.Name, a.AccountType, parentID, a.Parent != "").Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create account %s: %w", a.Code, err)
+		}
+		ids[a.Code] = id
+
+		for _, role := range a.Roles {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO ledger_posting_rules (company_id, document_type, role, account_id)
+				VALUES ($1, $2, $3, $4)
+			`, companyID, models.LedgerDocDefault, role, id)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create posting rule %s: %w", role, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return s.ListAccounts(ctx, companyID, false)
+}
+
+// ============================================
+// POSTING RULES
+// ============================================
+
+// ListPostingRules returns the company's posting rules by document type and role
+func (s *LedgerService) ListPostingRules(ctx context.Context, companyID string) ([]models.LedgerPostingRule, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.id, r.document_type, r.role, r.account_id, a.code, a.name, r.updated_at
+		FROM ledger_posting_rules r
+		JOIN ledger_accounts a ON a.id = r.account_id
+		WHERE r.company_id = $1
+		ORDER BY r.document_type, r.role
+	`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list posting rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.LedgerPostingRule{}
+	for rows.Next() {
+		var r models.LedgerPostingRule
+		if err := rows.Scan(&r.ID, &r.DocumentType, &r.Role, &r.AccountID, &r.AccountCode, &r.AccountName, &r.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan posting rule: %w", err)
+		}
+		rules = append(rules, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating posting rules: %w", err)
+	}
+	return rules, nil
+}
+
+// SetPostingRule creates or replaces the account of a document type's role.
+// The account must be active and postable.
+func (s *LedgerService) SetPostingRule(ctx context.Context, companyID string, req *models.SetPostingRuleRequest) (*models.LedgerPostingRule, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	account, err := s.GetAccount(ctx, companyID, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if !account.Active || !account.Postable {
+		return nil, ErrLedgerAccountNotPostable
+	}
+
+	rule := models.LedgerPostingRule{
+		DocumentType: req.DocumentType,
+		Role:         req.Role,
+		AccountID:    account.ID,
+		AccountCode:  account.Code,
+		AccountName:  account.Name,
+	}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO ledger_posting_rules (company_id, document_type, role, account_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (company_id, document_type, role)
+		DO UPDATE SET account_id = EXCLUDED.account_id, updated_at = NOW()
+		RETURNING id, updated_at
+	`, companyID, req.DocumentType, req.Role, account.ID).Scan(&rule.ID, &rule.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save posting rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// DeletePostingRule removes a rule. While the ledger is enabled, every role
+// must keep resolving to an account.
+func (s *LedgerService) DeletePostingRule(ctx context.Context, companyID, ruleID string) error {
+	if _, err := uuid.Parse(ruleID); err != nil {
+		return ErrPostingRuleNotFound
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM ledger_posting_rules WHERE id = $1 AND company_id = $2
+	`, ruleID, companyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete posting rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrPostingRuleNotFound
+	}
+
+	var enabled bool
+	err = tx.QueryRowContext(ctx, `SELECT enabled FROM ledger_settings WHERE company_id = $1`, companyID).Scan(&enabled)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to load ledger settings: %w", err)
+	}
+	if enabled {
+		if err := checkPostingRules(ctx, tx, companyID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ============================================
+// SETTINGS
+// ============================================
+
+// GetSettings returns the ledger settings; a company that never set them has
+// the ledger disabled
+func (s *LedgerService) GetSettings(ctx context.Context, companyID string) (*models.LedgerSettings, error) {
+	settings := &models.LedgerSettings{CompanyID: companyID}
+	var closedThrough sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT enabled, start_date, closed_through, updated_at FROM ledger_settings WHERE company_id = $1
+	`, companyID).Scan(&settings.Enabled, &settings.StartDate.Time, &closedThrough, &settings.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger settings: %w", err)
+	}
+	if closedThrough.Valid {
+		settings.ClosedThrough = &models.DateOnly{Time: closedThrough.Time}
+	}
+	return settings, nil
+}
+
+// UpdateSettings changes the ledger settings. Enabling requires every role of
+// every document type to resolve to an account.
+func (s *LedgerService) UpdateSettings(ctx context.Context, companyID string, req *models.UpdateLedgerSettingsRequest) (*models.LedgerSettings, error) {
+	current, err := s.GetSettings(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Enabled != nil {
+		current.Enabled = *req.Enabled
+	}
+	if req.StartDate != nil && !req.StartDate.IsZero() {
+		current.StartDate = *req.StartDate
+	}
+
+	if current.Enabled {
+		if err := checkPostingRules(ctx, s.db, companyID); err != nil {
+			return nil, err
+		}
+	}
+
+	var startDate interface{}
+	if !current.StartDate.IsZero() {
+		startDate = current.StartDate.Format("2006-01-02")
+	}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO ledger_settings (company_id, enabled, start_date)
+		VALUES ($1, $2, COALESCE($3::date, CURRENT_DATE))
+		ON CONFLICT (company_id)
+		DO UPDATE SET enabled = EXCLUDED.enabled, start_date = EXCLUDED.start_date, updated_at = NOW()
+		RETURNING enabled, start_date, updated_at
+	`, companyID, current.Enabled, startDate).Scan(&current.Enabled, &current.StartDate.Time, &current.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save ledger settings: %w", err)
+	}
+	return current, nil
+}
+
+// checkPostingRules returns ErrLedgerRulesIncomplete listing the document
+// type roles without an account
+func checkPostingRules(ctx context.Context, q ledgerQuerier, companyID string) error {
+	rules, err := loadPostingRules(ctx, q, companyID)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for documentType, roles := range models.LedgerDocumentRoles {
+		for _, role := range roles {
+			if rules.account(documentType, role) == "" {
+				missing = append(missing, documentType+"."+role)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("%w: no account for %s", ErrLedgerRulesIncomplete, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// postingRules maps document type and role to an account ID
+type postingRules map[string]map[string]string
+
+// account resolves a role of a document type, falling back to the default rule
+func (r postingRules) account(documentType, role string) string {
+	if id := r[documentType][role]; id != "" {
+		return id
+	}
+	return r[models.LedgerDocDefault][role]
+}
+
+func loadPostingRules(ctx context.Context, q ledgerQuerier, companyID string) (postingRules, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT document_type, role, account_id FROM ledger_posting_rules WHERE company_id = $1
+	`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load posting rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := postingRules{}
+	for rows.Next() {
+		var documentType, role, accountID string
+		if err := rows.Scan(&documentType, &role, &accountID); err != nil {
+			return nil, fmt.Errorf("failed to scan posting rule: %w", err)
+		}
+		if rules[documentType] == nil {
+			rules[documentType] = map[string]string{}
+		}
+		rules[documentType][role] = accountID
+	}
+	return rules, rows.Err()
+}
+
+// ============================================
+// JOURNAL
+// ============================================
+
+const journalEntryColumns = `
+	e.id, e.company_id, e.entry_number, to_char(e.entry_date, 'YYYY-MM-DD'), e.description,
+	e.source_type, e.source_id, e.document_type, e.reference, e.reversal, e.reverses_entry_id,
+	e.establishment_id, e.total_debit, e.total_credit, e.created_at
+`
+
+// ListJournal returns journal entries with their lines, oldest first
+func (s *LedgerService) ListJournal(ctx context.Context, companyID string, filters *models.JournalFilters) ([]models.JournalEntry, error) {
+	query := `SELECT ` + journalEntryColumns + ` FROM journal_entries e WHERE e.company_id = $1`
+	args := []interface{}{companyID}
+
+	if filters.FromDate != "" {
+		args = append(args, filters.FromDate)
+		query += fmt.Sprintf(" AND e.entry_date >= $%d", len(args))
+	}
+	if filters.ToDate != "" {
+		args = append(args, filters.ToDate)
+		query += fmt.Sprintf(" AND e.entry_date <= $%d", len(args))
+	}
+	if filters.SourceType != "" {
+		args = append(args, filters.SourceType)
+		query += fmt.Sprintf(" AND e.source_type = $%d", len(args))
+	}
+	if filters.SourceID != "" {
+		args = append(args, filters.SourceID)
+		query += fmt.Sprintf(" AND UPPER(e.source_id) = UPPER($%d)", len(args))
+	}
+	if filters.EstablishmentID != "" {
+		args = append(args, filters.EstablishmentID)
+		query += fmt.Sprintf(" AND e.establishment_id::text = $%d", len(args))
+	}
+	if filters.AccountID != "" {
+		args = append(args, filters.AccountID)
+		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM journal_lines l WHERE l.entry_id = e.id AND l.account_id::text = $%d)", len(args))
+	}
+
+	query += " ORDER BY e.entry_date, e.entry_number"
+	if filters.Limit > 0 {
+		args = append(args, filters.Limit, filters.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journal entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.JournalEntry{}
+	for rows.Next() {
+		entry, err := scanJournalEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan journal entry: %w", err)
+		}
+		entries = append(entries, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating journal entries: %w", err)
+	}
+	rows.Close()
+
+	if err := s.attachJournalLines(ctx, entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetJournalEntry returns one entry with its lines
+func (s *LedgerService) GetJournalEntry(ctx context.Context, companyID, entryID string) (*models.JournalEntry, error) {
+	if _, err := uuid.Parse(entryID); err != nil {
+		return nil, ErrJournalEntryNotFound
+	}
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+journalEntryColumns+`
+		FROM journal_entries e
+		WHERE e.id = $1 AND e.company_id = $2
+	`, entryID, companyID)
+
+	entry, err := scanJournalEntry(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJournalEntryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journal entry: %w", err)
+	}
+
+	entries := []models.JournalEntry{*entry}
+	if err := s.attachJournalLines(ctx, entries); err != nil {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// attachJournalLines loads the lines of the entries in one query
+func (s *LedgerService) attachJournalLines(ctx context.Context, entries []models.JournalEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	ids := make([]string, len(entries))
+	index := make(map[string]int, len(entries))
+	for i := range entries {
+		ids[i] = entries[i].ID
+		index[entries[i].ID] = i
+		entries[i].Lines = []models.JournalLine{}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT l.entry_id, l.line_number, l.account_id, a.code, a.name, l.role, l.debit, l.credit
+		FROM journal_lines l
+		JOIN ledger_accounts a ON a.id = l.account_id
+		WHERE l.entry_id = ANY($1::uuid[])
+		ORDER BY l.entry_id, l.line_number
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to load journal lines: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entryID string
+		var line models.JournalLine
+		if err := rows.Scan(&entryID, &line.LineNumber, &line.AccountID, &line.AccountCode, &line.AccountName,
+			&line.Role, &line.Debit, &line.Credit); err != nil {
+			return fmt.Errorf("failed to scan journal line: %w", err)
+		}
+		i := index[entryID]
+		entries[i].Lines = append(entries[i].Lines, line)
+	}
+	return rows.Err()
+}
+
+// ============================================
+// HELPERS
+// ============================================
+
+func ledgerAccountWriteError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrLedgerAccountNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrLedgerAccountCodeTaken
+	}
+	return fmt.Errorf("failed to save ledger account: %w", err)
+}
+
+func scanLedgerAccount(row rowScanner) (*models.LedgerAccount, error) {
+	var a models.LedgerAccount
+	err := row.Scan(
+		&a.ID, &a.CompanyID, &a.Code, &a.Name, &a.AccountType, &a.ParentID,
+		&a.Postable, &a.Active, &a.CreatedAt, &a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func scanJournalEntry(row rowScanner) (*models.JournalEntry, error) {
+	var e models.JournalEntry
+	err := row.Scan(
+		&e.ID, &e.CompanyID, &e.EntryNumber, &e.EntryDate, &e.Description,
+		&e.SourceType, &e.SourceID, &e.DocumentType, &e.Reference, &e.Reversal, &e.ReversesEntryID,
+		&e.EstablishmentID, &e.TotalDebit, &e.TotalCredit, &e.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
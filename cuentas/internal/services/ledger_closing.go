@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"cuentas/internal/models"
+)
+
+var (
+	ErrFiscalYearNotEnded   = errors.New("the fiscal year has not ended yet")
+	ErrFiscalYearClosed     = errors.New("the fiscal year is already closed")
+	ErrFiscalYearOutOfOrder = errors.New("earlier fiscal years must be closed first")
+)
+
+// ============================================
+// FISCAL YEAR CLOSING
+// ============================================
+
+// CloseFiscalYear posts the closing entries of a fiscal year (January to
+// December): one per cost center, zeroing its income and expense accounts
+// against retained earnings on December 31. Pending documents are posted
+// first. Years close in order, starting with the year of the ledger's start
+// date; documents that arrive later for a closed year are posted on January 1
+// of the next one.
+func (s *LedgerService) CloseFiscalYear(ctx context.Context, companyID, userID string, year int) (*models.LedgerClosing, error) {
+	settings, err := s.GetSettings(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled {
+		return nil, ErrLedgerDisabled
+	}
+	if year >= time.Now().Year() {
+		return nil, ErrFiscalYearNotEnded
+	}
+
+	if _, err := s.post(ctx, companyID, ledgerManualBatch); err != nil {
+		return nil, fmt.Errorf("pending documents could not be posted: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		startDate     time.Time
+		closedThrough sql.NullTime
+	)
+	err = tx.QueryRowContext(ctx, `
+		SELECT start_date, closed_through FROM ledger_settings WHERE company_id = $1 FOR UPDATE
+	`, companyID).Scan(&startDate, &closedThrough)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock ledger settings: %w", err)
+	}
+	expected := startDate.Year()
+	if closedThrough.Valid {
+		expected = closedThrough.Time.Year() + 1
+	}
+	if year < expected {
+		return nil, ErrFiscalYearClosed
+	}
+	if year > expected {
+		return nil, fmt.Errorf("%w: next year to close is %d", ErrFiscalYearOutOfOrder, expected)
+	}
+
+	rules, err := loadPostingRules(ctx, tx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	retainedEarnings := rules.account(models.LedgerDocClosing, models.RoleRetainedEarnings)
+	if retainedEarnings == "" {
+		return nil, fmt.Errorf("%w: no account for %s.%s", ErrLedgerRulesIncomplete,
+			models.LedgerDocClosing, models.RoleRetainedEarnings)
+	}
+
+	entries, err := closingEntries(ctx, tx, companyID, year, retainedEarnings)
+	if err != nil {
+		return nil, err
+	}
+
+	var netIncome float64
+	for i := range entries {
+		// The retained earnings line is the last one; its credit is the result
+		netIncome -= entries[i].lines[len(entries[i].lines)-1].amount
+		if _, err := insertEntryTx(ctx, tx, &entries[i]); err != nil {
+			return nil, fmt.Errorf("closing entry %s: %w", entries[i].sourceID, err)
+		}
+	}
+
+	yearEnd := fmt.Sprintf("%d-12-31", year)
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE ledger_settings SET closed_through = $2, updated_at = NOW() WHERE company_id = $1
+	`, companyID, yearEnd); err != nil {
+		return nil, fmt.Errorf("failed to update ledger settings: %w", err)
+	}
+
+	closing := &models.LedgerClosing{
+		CompanyID:  companyID,
+		FiscalYear: year,
+		NetIncome:  round(netIncome),
+		EntryCount: len(entries),
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO ledger_closings (company_id, fiscal_year, net_income, entry_count, closed_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, closed_by, closed_at
+	`, companyID, year, closing.NetIncome, closing.EntryCount, nullIfBlank(&userID),
+	).Scan(&closing.ID, &closing.ClosedBy, &closing.ClosedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record closing: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return closing, nil
+}
+
+// closingEntries builds one entry per cost center with a line reversing the
+// year's balance of each income and expense account and the difference to
+// retained earnings. Cost centers whose accounts are all at zero get none.
+func closingEntries(ctx context.Context, tx *sql.Tx, companyID string, year int, retainedEarnings string) ([]ledgerEntry, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT e.establishment_id::text, l.account_id, SUM(l.debit - l.credit)
+		FROM journal_lines l
+		JOIN journal_entries e ON e.id = l.entry_id
+		JOIN ledger_accounts a ON a.id = l.account_id
+		WHERE e.company_id = $1
+		  AND e.entry_date BETWEEN $2 AND $3
+		  AND a.account_type IN ($4, $5)
+		GROUP BY e.establishment_id, l.account_id
+		HAVING SUM(l.debit - l.credit) <> 0
+		ORDER BY e.establishment_id NULLS FIRST, l.account_id
+	`, companyID, fmt.Sprintf("%d-01-01", year), fmt.Sprintf("%d-12-31", year),
+		models.AccountTypeIncome, models.AccountTypeExpense)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load result balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := []resultBalance{}
+	for rows.Next() {
+		var balance resultBalance
+		if err := rows.Scan(&balance.establishmentID, &balance.accountID, &balance.balance); err != nil {
+			return nil, fmt.Errorf("failed to scan result balance: %w", err)
+		}
+		balances = append(balances, balance)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating result balances: %w", err)
+	}
+
+	return buildClosingEntries(companyID, year, retainedEarnings, balances), nil
+}
+
+// resultBalance is the year's balance, debit positive, of an income or
+// expense account at a cost center
+type resultBalance struct {
+	establishmentID *string
+	accountID       string
+	balance         float64
+}
+
+// buildClosingEntries builds the closing entries from the result balances,
+// which come grouped by cost center
+func buildClosingEntries(companyID string, year int, retainedEarnings string, balances []resultBalance) []ledgerEntry {
+	entries := []ledgerEntry{}
+	index := map[string]int{}
+	for _, balance := range balances {
+		establishmentID := balance.establishmentID
+		key := ""
+		if establishmentID != nil {
+			key = *establishmentID
+		}
+		i, ok := index[key]
+		if !ok {
+			sourceID := fmt.Sprintf("%d", year)
+			if establishmentID != nil {
+				sourceID += "/" + *establishmentID
+			}
+			entries = append(entries, ledgerEntry{
+				companyID:       companyID,
+				date:            fmt.Sprintf("%d-12-31", year),
+				description:     fmt.Sprintf("Cierre del ejercicio %d", year),
+				sourceType:      models.LedgerSourceClosing,
+				sourceID:        sourceID,
+				documentType:    models.LedgerDocClosing,
+				establishmentID: establishmentID,
+			})
+			i = len(entries) - 1
+			index[key] = i
+		}
+		entries[i].lines = append(entries[i].lines, ledgerLine{
+			role:      models.RoleClosingBalance,
+			accountID: balance.accountID,
+			amount:    -balance.balance,
+		})
+	}
+
+	for i := range entries {
+		line := balanceLine(models.RoleRetainedEarnings, entries[i].lines)
+		line.accountID = retainedEarnings
+		entries[i].lines = append(entries[i].lines, line)
+	}
+	return entries
+}
+
+// ListClosings returns the company's closed fiscal years, latest first
+func (s *LedgerService) ListClosings(ctx context.Context, companyID string) ([]models.LedgerClosing, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, company_id, fiscal_year, net_income, entry_count, closed_by, closed_at
+		FROM ledger_closings
+		WHERE company_id = $1
+		ORDER BY fiscal_year DESC
+	`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list closings: %w", err)
+	}
+	defer rows.Close()
+
+	closings := []models.LedgerClosing{}
+	for rows.Next() {
+		var c models.LedgerClosing
+		if err := rows.Scan(&c.ID, &c.CompanyID, &c.FiscalYear, &c.NetIncome, &c.EntryCount,
+			&c.ClosedBy, &c.ClosedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan closing: %w", err)
+		}
+		closings = append(closings, c)
+	}
+	return closings, rows.Err()
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"testing"
+
+	"cuentas/internal/models"
+)
+
+func TestBuildClosingEntries(t *testing.T) {
+	establishment := "E1"
+
+	type wantEntry struct {
+		sourceID        string
+		establishmentID *string
+		accounts        map[string]float64
+	}
+	tests := []struct {
+		name     string
+		balances []resultBalance
+		want     []wantEntry
+	}{
+		{name: "no result balances"},
+		{
+			name: "net income credited to retained earnings",
+			balances: []resultBalance{
+				{accountID: "revenue", balance: -1000},
+				{accountID: "expense", balance: 600},
+			},
+			want: []wantEntry{{
+				sourceID: "2025",
+				accounts: map[string]float64{"revenue": 1000, "expense": -600, "retained": -400},
+			}},
+		},
+		{
+			name: "net loss debited to retained earnings",
+			balances: []resultBalance{
+				{accountID: "revenue", balance: -250.5},
+				{accountID: "expense", balance: 300.75},
+			},
+			want: []wantEntry{{
+				sourceID: "2025",
+				accounts: map[string]float64{"revenue": 250.5, "expense": -300.75, "retained": 50.25},
+			}},
+		},
+		{
+			name: "one entry per cost center",
+			balances: []resultBalance{
+				{accountID: "revenue", balance: -1000},
+				{accountID: "expense", balance: 600},
+				{establishmentID: &establishment, accountID: "expense", balance: 200},
+			},
+			want: []wantEntry{
+				{
+					sourceID: "2025",
+					accounts: map[string]float64{"revenue": 1000, "expense": -600, "retained": -400},
+				},
+				{
+					sourceID:        "2025/E1",
+					establishmentID: &establishment,
+					accounts:        map[string]float64{"expense": -200, "retained": 200},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := buildClosingEntries("company", 2025, "retained", tt.balances)
+			if len(entries) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d", len(entries), len(tt.want))
+			}
+
+			for i, want := range tt.want {
+				entry := entries[i]
+				if entry.sourceType != models.LedgerSourceClosing || entry.sourceID != want.sourceID {
+					t.Errorf("entry %d source = %s/%s, want %s/%s", i, entry.sourceType, entry.sourceID,
+						models.LedgerSourceClosing, want.sourceID)
+				}
+				if entry.date != "2025-12-31" {
+					t.Errorf("entry %d date = %s, want 2025-12-31", i, entry.date)
+				}
+				if (entry.establishmentID == nil) != (want.establishmentID == nil) ||
+					(entry.establishmentID != nil && *entry.establishmentID != *want.establishmentID) {
+					t.Errorf("entry %d establishment = %v, want %v", i, entry.establishmentID, want.establishmentID)
+				}
+
+				last := entry.lines[len(entry.lines)-1]
+				if last.role != models.RoleRetainedEarnings || last.accountID != "retained" {
+					t.Errorf("entry %d last line = %s/%s, want retained earnings", i, last.role, last.accountID)
+				}
+				if len(entry.lines) != len(want.accounts) {
+					t.Errorf("entry %d has %d lines, want %d", i, len(entry.lines), len(want.accounts))
+				}
+				for _, line := range entry.lines {
+					if line.amount != want.accounts[line.accountID] {
+						t.Errorf("entry %d %s = %v, want %v", i, line.accountID, line.amount, want.accounts[line.accountID])
+					}
+				}
+				if _, _, err := postableLines(entry.lines); err != nil {
+					t.Errorf("entry %d does not balance: %v", i, err)
+				}
+			}
+		})
+	}
+}
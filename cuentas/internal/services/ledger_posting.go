@@ -0,0 +1,882 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"cuentas/internal/codigos"
+	"cuentas/internal/models"
+)
+
+// ErrLedgerDisabled is returned when posting is requested for a company that
+// has not enabled the ledger
+var ErrLedgerDisabled = errors.New("the general ledger is not enabled")
+
+// ledgerManualBatch caps each source in a posting run started through the API
+const ledgerManualBatch = 500
+
+// ============================================
+// POSTING
+// ============================================
+
+// ledgerLine is a line before posting: a role and a signed amount, positive
+// for debits and negative for credits. Reversals carry the account already.
+type ledgerLine struct {
+	role      string
+	accountID string
+	amount    float64
+}
+
+// ledgerEntry is a journal entry waiting to be written
+type ledgerEntry struct {
+	companyID       string
+	date            string
+	description     string
+	sourceType      string
+	sourceID        string
+	documentType    string
+	reference       *string
+	reversal        bool
+	reversesEntryID *string
+	establishmentID *string
+	lines           []ledgerLine
+}
+
+// ledgerLoader returns the documents of one source that still need an entry
+type ledgerLoader func(ctx context.Context, companyID string, limit int) ([]ledgerEntry, error)
+
+// PostPending writes the entries of every document finalized, and the
+// reversals of every document invalidated or voided, since the last pass, for
+// all companies with the ledger enabled. limit caps each source per pass.
+// Documents that fail are skipped and reported in the joined error; the next
+// pass retries them.
+func (s *LedgerService) PostPending(ctx context.Context, limit int) (*models.LedgerPostingResult, error) {
+	return s.post(ctx, "", limit)
+}
+
+// PostCompany runs a posting pass for one company right away instead of
+// waiting for the ledger worker
+func (s *LedgerService) PostCompany(ctx context.Context, companyID string) (*models.LedgerPostingResult, error) {
+	settings, err := s.GetSettings(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled {
+		return nil, ErrLedgerDisabled
+	}
+	return s.post(ctx, companyID, ledgerManualBatch)
+}
+
+func (s *LedgerService) post(ctx context.Context, companyID string, limit int) (*models.LedgerPostingResult, error) {
+	result := &models.LedgerPostingResult{}
+	rules := map[string]postingRules{}
+	var failures []error
+
+	loaders := []ledgerLoader{
+		s.pendingSales,
+		s.pendingPurchases,
+		s.pendingRetentions,
+		s.pendingPayments,
+		s.pendingSupplierPayments,
+		s.pendingInventoryEvents,
+	}
+	for _, load := range loaders {
+		entries, err := load(ctx, companyID, limit)
+		if err != nil {
+			failures = append(failures, err)
+			continue
+		}
+		for i := range entries {
+			entry := &entries[i]
+			if err := s.resolveAccounts(ctx, entry, rules); err != nil {
+				failures = append(failures, fmt.Errorf("%s %s: %w", entry.sourceType, entry.sourceID, err))
+				continue
+			}
+			posted, err := s.insertEntry(ctx, entry)
+			if err != nil {
+				failures = append(failures, fmt.Errorf("%s %s: %w", entry.sourceType, entry.sourceID, err))
+				continue
+			}
+			if posted {
+				result.Posted++
+			}
+		}
+	}
+
+	reversals, err := s.pendingReversals(ctx, companyID, limit)
+	if err != nil {
+		failures = append(failures, err)
+	}
+	for i := range reversals {
+		entry := &reversals[i]
+		posted, err := s.insertEntry(ctx, entry)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("reversal of %s %s: %w", entry.sourceType, entry.sourceID, err))
+			continue
+		}
+		if posted {
+			result.Reversed++
+		}
+	}
+
+	return result, errors.Join(failures...)
+}
+
+// resolveAccounts fills each line's account from the company's posting rules
+func (s *LedgerService) resolveAccounts(ctx context.Context, entry *ledgerEntry, cache map[string]postingRules) error {
+	rules, ok := cache[entry.companyID]
+	if !ok {
+		var err error
+		rules, err = loadPostingRules(ctx, s.db, entry.companyID)
+		if err != nil {
+			return err
+		}
+		cache[entry.companyID] = rules
+	}
+
+	for i := range entry.lines {
+		line := &entry.lines[i]
+		line.accountID = rules.account(entry.documentType, line.role)
+		if line.accountID == "" {
+			return fmt.Errorf("%w: no account for %s.%s", ErrLedgerRulesIncomplete, entry.documentType, line.role)
+		}
+	}
+	return nil
+}
+
+// insertEntry writes an entry and its lines in their own transaction
+func (s *LedgerService) insertEntry(ctx context.Context, entry *ledgerEntry) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	posted, err := insertEntryTx(ctx, tx, entry)
+	if err != nil || !posted {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// insertEntryTx writes an entry and its lines. Lines of zero are dropped. The
+// entry number comes from ledger_settings, whose row lock serializes a
+// company's postings; an entry dated in a closed fiscal year is moved to the
+// day after it, and an entry that is already there is left alone and
+// reported as not posted.
+func insertEntryTx(ctx context.Context, tx *sql.Tx, entry *ledgerEntry) (bool, error) {
+	lines, total, err := postableLines(entry.lines)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		entryNumber int64
+		openFrom    sql.NullString
+	)
+	err = tx.QueryRowContext(ctx, `
+		UPDATE ledger_settings
+		SET next_entry_number = next_entry_number + 1
+		WHERE company_id = $1
+		RETURNING next_entry_number - 1, to_char(closed_through + 1, 'YYYY-MM-DD')
+	`, entry.companyID).Scan(&entryNumber, &openFrom)
+	if err != nil {
+		return false, fmt.Errorf("failed to number journal entry: %w", err)
+	}
+	if openFrom.Valid && entry.date < openFrom.String {
+		entry.date = openFrom.String
+	}
+
+	var entryID string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO journal_entries (
+			company_id, entry_number, entry_date, description,
+			source_type, source_id, document_type, reference,
+			reversal, reverses_entry_id, establishment_id, total_debit, total_credit
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $12)
+		ON CONFLICT (company_id, source_type, source_id, reversal) DO NOTHING
+		RETURNING id
+	`, entry.companyID, entryNumber, entry.date, entry.description,
+		entry.sourceType, entry.sourceID, entry.documentType, entry.reference,
+		entry.reversal, entry.reversesEntryID, entry.establishmentID, total,
+	).Scan(&entryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to insert journal entry: %w", err)
+	}
+
+	for i, line := range lines {
+		debit, credit := 0.0, 0.0
+		if line.amount > 0 {
+			debit = round(line.amount)
+		} else {
+			credit = round(-line.amount)
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO journal_lines (entry_id, line_number, account_id, role, debit, credit)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, entryID, i+1, line.accountID, line.role, debit, credit)
+		if err != nil {
+			return false, fmt.Errorf("failed to insert journal line: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// postableLines drops the lines of zero and checks, to the cent, that debits
+// equal credits; total is the entry's debits
+func postableLines(entryLines []ledgerLine) (lines []ledgerLine, total float64, err error) {
+	var debitCents, creditCents int64
+	lines = make([]ledgerLine, 0, len(entryLines))
+	for _, line := range entryLines {
+		cents := int64(math.Round(line.amount * 100))
+		if cents == 0 {
+			continue
+		}
+		if cents > 0 {
+			debitCents += cents
+		} else {
+			creditCents -= cents
+		}
+		lines = append(lines, line)
+	}
+	if debitCents != creditCents {
+		return nil, 0, fmt.Errorf("unbalanced entry: debits %.2f, credits %.2f",
+			float64(debitCents)/100, float64(creditCents)/100)
+	}
+	return lines, float64(debitCents) / 100, nil
+}
+
+// balanceLine returns the line of a role that makes the others balance
+func balanceLine(role string, lines []ledgerLine) ledgerLine {
+	sum := 0.0
+	for _, line := range lines {
+		sum += line.amount
+	}
+	return ledgerLine{role: role, amount: round(-sum)}
+}
+
+// ============================================
+// SALES
+// ============================================
+
+// ledgerSalesDocumentTypes maps DTE types to ledger document types
+var ledgerSalesDocumentTypes = map[string]string{
+	"01": models.LedgerDocFactura,
+	"03": models.LedgerDocCCF,
+	"05": models.LedgerDocNotaCredito,
+	"06": models.LedgerDocNotaDebito,
+	"11": models.LedgerDocExportacion,
+}
+
+// pendingSales loads the DTEs Hacienda accepted that have no entry yet. The
+// amounts are read exactly as the IVA books read them, so the ledger's IVA
+// débito matches the libros de ventas. The receivable is what the client owes
+// after the IVA it withheld.
+func (s *LedgerService) pendingSales(ctx context.Context, companyID string, limit int) ([]ledgerEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT company_id, codigo_generacion, fecha_emision, tipo_dte, numero_control, iva_amount, dte_unsigned,
+		       establishment_id
+		FROM (
+			SELECT DISTINCT ON (UPPER(cl.codigo_generacion))
+			       cl.company_id::text AS company_id, UPPER(cl.codigo_generacion) AS codigo_generacion,
+			       to_char(cl.fecha_emision, 'YYYY-MM-DD') AS fecha_emision, cl.tipo_dte, cl.numero_control,
+			       cl.iva_amount, cl.dte_unsigned, cl.establishment_id::text AS establishment_id
+			FROM dte_commit_log cl
+			JOIN ledger_settings ls ON ls.company_id = cl.company_id
+			WHERE ls.enabled
+			  AND cl.fecha_emision >= ls.start_date
+			  AND ($1 = '' OR cl.company_id::text = $1)
+			  AND cl.tipo_dte IN ('01', '03', '05', '06', '11')
+			  AND cl.hacienda_estado = 'PROCESADO'
+			  AND NOT EXISTS (
+			      SELECT 1 FROM dte_invalidations i
+			      WHERE UPPER(i.original_codigo_generacion) = UPPER(cl.codigo_generacion)
+			        AND i.hacienda_estado = 'PROCESADO'
+			  )
+			  AND NOT EXISTS (
+			      SELECT 1 FROM journal_entries je
+			      WHERE je.company_id = cl.company_id
+			        AND je.source_type = $2
+			        AND je.source_id = UPPER(cl.codigo_generacion)
+			        AND NOT je.reversal
+			  )
+			ORDER BY UPPER(cl.codigo_generacion), cl.created_at DESC
+		) d
+		ORDER BY fecha_emision, numero_control
+		LIMIT $3
+	`, companyID, models.LedgerSourceSale, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending sales: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []ledgerEntry{}
+	for rows.Next() {
+		var (
+			entry                  ledgerEntry
+			tipoDte, numeroControl string
+			ivaAmount              float64
+			dteUnsigned            []byte
+		)
+		if err := rows.Scan(&entry.companyID, &entry.sourceID, &entry.date, &tipoDte, &numeroControl,
+			&ivaAmount, &dteUnsigned, &entry.establishmentID); err != nil {
+			return nil, fmt.Errorf("failed to scan pending sale: %w", err)
+		}
+		entry.sourceType = models.LedgerSourceSale
+		entry.documentType = ledgerSalesDocumentTypes[tipoDte]
+		entry.reference = &numeroControl
+		entry.description = numeroControl
+		if name, ok := codigos.GetDocumentTypeName(tipoDte); ok {
+			entry.description = name + " " + numeroControl
+		}
+
+		lines, err := salesLines(tipoDte, dteUnsigned, ivaAmount)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", numeroControl, err)
+		}
+		entry.lines = lines
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// salesLines builds a sale's lines: revenue and taxes credited, the IVA
+// withheld by the client and the receivable debited. Notas de crédito come out
+// with every sign turned.
+func salesLines(tipoDte string, dteUnsigned []byte, ivaAmount float64) ([]ledgerLine, error) {
+	var lines []ledgerLine
+
+	switch tipoDte {
+	case "01", "11":
+		amounts, err := consumerSalesAmounts(tipoDte, dteUnsigned, ivaAmount)
+		if err != nil {
+			return nil, err
+		}
+		var dte struct {
+			Resumen struct {
+				IvaRete1 float64 `json:"ivaRete1"`
+			} `json:"resumen"`
+		}
+		if err := json.Unmarshal(dteUnsigned, &dte); err != nil {
+			return nil, fmt.Errorf("failed to parse DTE: %w", err)
+		}
+		// Facturas carry the IVA inside the gravadas
+		lines = []ledgerLine{
+			{role: models.RoleRevenueGravada, amount: -round(amounts.VentasGravadas - amounts.IVADebito)},
+			{role: models.RoleRevenueExenta, amount: -amounts.VentasExentas},
+			{role: models.RoleRevenueNoSujeta, amount: -amounts.VentasNoSujetas},
+			{role: models.RoleRevenueExport, amount: -amounts.Exportaciones},
+			{role: models.RoleIVADebito, amount: -amounts.IVADebito},
+			{role: models.RoleIVARetenidoClientes, amount: round(dte.Resumen.IvaRete1)},
+		}
+
+	default:
+		row := models.ContributorSalesBookRow{TipoDte: tipoDte}
+		if err := applyContributorSalesDTE(&row, dteUnsigned, ivaAmount); err != nil {
+			return nil, err
+		}
+		lines = []ledgerLine{
+			{role: models.RoleRevenueGravada, amount: -row.VentasGravadas},
+			{role: models.RoleRevenueExenta, amount: -row.VentasExentas},
+			{role: models.RoleRevenueNoSujeta, amount: -row.VentasNoSujetas},
+			{role: models.RoleIVADebito, amount: -row.IVADebito},
+			{role: models.RoleIVAPercibido, amount: -row.IVAPercibido},
+			{role: models.RoleIVARetenidoClientes, amount: row.IVARetenido},
+		}
+	}
+
+	return append(lines, balanceLine(models.RoleReceivable, lines)), nil
+}
+
+// ============================================
+// PURCHASES
+// ============================================
+
+// pendingPurchases loads the finalized purchases without an entry. FSEs wait
+// for Hacienda's acceptance.
+func (s *LedgerService) pendingPurchases(ctx context.Context, companyID string, limit int) ([]ledgerEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT p.company_id::text, UPPER(p.id::text), to_char(p.purchase_date, 'YYYY-MM-DD'),
+		       p.purchase_type, COALESCE(p.dte_type, ''), COALESCE(p.dte_numero_control, p.purchase_number),
+		       COALESCE(p.supplier_name, s.name, ''),
+		       p.total, COALESCE(p.total_taxes, 0), COALESCE(p.iva_retained, 0),
+		       COALESCE(p.income_tax_retained, 0), COALESCE(p.amount_paid, 0),
+		       COALESCE((
+		           SELECT SUM(sp.amount) FROM supplier_payments sp
+		           WHERE sp.purchase_id = p.id AND sp.voided_at IS NULL
+		       ), 0),
+		       p.dte_unsigned, p.establishment_id::text
+		FROM purchases p
+		JOIN ledger_settings ls ON ls.company_id = p.company_id
+		LEFT JOIN suppliers s ON s.id = p.supplier_id
+		WHERE ls.enabled
+		  AND p.purchase_date >= ls.start_date
+		  AND ($1 = '' OR p.company_id::text = $1)
+		  AND p.status = 'finalized'
+		  AND (p.purchase_type <> 'fse' OR p.dte_status = 'PROCESADO')
+		  AND NOT EXISTS (
+		      SELECT 1 FROM journal_entries je
+		      WHERE je.company_id = p.company_id
+		        AND je.source_type = $2
+		        AND je.source_id = UPPER(p.id::text)
+		        AND NOT je.reversal
+		  )
+		ORDER BY p.purchase_date, p.purchase_number
+		LIMIT $3
+	`, companyID, models.LedgerSourcePurchase, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending purchases: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []ledgerEntry{}
+	for rows.Next() {
+		var (
+			entry                                     ledgerEntry
+			purchaseType, dteType, document, supplier string
+			total, taxes, ivaRetained, rentaRetained  float64
+			amountPaid, supplierPaid                  float64
+			dteUnsigned                               []byte
+		)
+		if err := rows.Scan(&entry.companyID, &entry.sourceID, &entry.date,
+			&purchaseType, &dteType, &document, &supplier,
+			&total, &taxes, &ivaRetained, &rentaRetained, &amountPaid, &supplierPaid,
+			&dteUnsigned, &entry.establishmentID); err != nil {
+			return nil, fmt.Errorf("failed to scan pending purchase: %w", err)
+		}
+		entry.sourceType = models.LedgerSourcePurchase
+		entry.reference = &document
+		entry.description = "Compra " + document
+		switch purchaseType {
+		case "fse":
+			entry.documentType = models.LedgerDocFSE
+			entry.description = "Factura de sujeto excluido " + document
+		case "import":
+			entry.documentType = models.LedgerDocImport
+			entry.description = "Importación " + document
+		default:
+			entry.documentType = models.LedgerDocPurchase
+		}
+		if supplier != "" {
+			entry.description += " - " + supplier
+		}
+
+		// Only CCFs and imports carry crédito fiscal; on other documents the
+		// IVA is part of the cost
+		iva := 0.0
+		switch {
+		case purchaseType == "import":
+			iva = taxes
+		case purchaseType == "regular" && dteType == "03":
+			iva = purchaseIVA(dteUnsigned, taxes)
+		}
+		percibido := 0.0
+		if len(dteUnsigned) > 0 {
+			var dte struct {
+				Resumen struct {
+					IvaPerci1 float64 `json:"ivaPerci1"`
+				} `json:"resumen"`
+			}
+			if err := json.Unmarshal(dteUnsigned, &dte); err == nil {
+				percibido = dte.Resumen.IvaPerci1
+			}
+		}
+
+		// What was paid on the purchase itself rather than through supplier
+		// payments, which post their own entries
+		cash := math.Min(math.Max(round(amountPaid-supplierPaid), 0), total)
+
+		lines := []ledgerLine{
+			{role: models.RoleIVACredito, amount: round(iva)},
+			{role: models.RoleIVAPercibidoProveedores, amount: round(percibido)},
+			{role: models.RoleIVARetenidoProveedores, amount: -ivaRetained},
+			{role: models.RoleRentaRetenida, amount: -rentaRetained},
+			{role: models.RoleCash, amount: -cash},
+			{role: models.RolePayable, amount: -round(total - cash)},
+		}
+		entry.lines = append(lines, balanceLine(models.RolePurchases, lines))
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// pendingRetentions loads the comprobantes de retención (07) we issued that
+// Hacienda accepted. The IVA withheld moves from the supplier's balance to
+// the amount owed to Hacienda.
+func (s *LedgerService) pendingRetentions(ctx context.Context, companyID string, limit int) ([]ledgerEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.company_id::text, UPPER(r.codigo_generacion), to_char(r.fecha_emision, 'YYYY-MM-DD'),
+		       r.numero_control, r.supplier_name, r.iva_retenido, r.establishment_id::text
+		FROM retentions r
+		JOIN ledger_settings ls ON ls.company_id = r.company_id
+		WHERE ls.enabled
+		  AND r.fecha_emision >= ls.start_date
+		  AND ($1 = '' OR r.company_id::text = $1)
+		  AND r.hacienda_estado = 'PROCESADO'
+		  AND NOT EXISTS (
+		      SELECT 1 FROM dte_invalidations i
+		      WHERE UPPER(i.original_codigo_generacion) = UPPER(r.codigo_generacion)
+		        AND i.hacienda_estado = 'PROCESADO'
+		  )
+		  AND NOT EXISTS (
+		      SELECT 1 FROM journal_entries je
+		      WHERE je.company_id = r.company_id
+		        AND je.source_type = $2
+		        AND je.source_id = UPPER(r.codigo_generacion)
+		        AND NOT je.reversal
+		  )
+		ORDER BY r.fecha_emision, r.numero_control
+		LIMIT $3
+	`, companyID, models.LedgerSourceRetention, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending retentions: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []ledgerEntry{}
+	for rows.Next() {
+		var (
+			entry                   ledgerEntry
+			numeroControl, supplier string
+			ivaRetenido             float64
+		)
+		if err := rows.Scan(&entry.companyID, &entry.sourceID, &entry.date, &numeroControl, &supplier, &ivaRetenido,
+			&entry.establishmentID); err != nil {
+			return nil, fmt.Errorf("failed to scan pending retention: %w", err)
+		}
+		entry.sourceType = models.LedgerSourceRetention
+		entry.documentType = models.LedgerDocRetention
+		entry.reference = &numeroControl
+		entry.description = "Comprobante de retención " + numeroControl + " - " + supplier
+		entry.lines = []ledgerLine{
+			{role: models.RolePayable, amount: ivaRetenido},
+			{role: models.RoleIVARetenidoProveedores, amount: -ivaRetenido},
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// ============================================
+// PAYMENTS
+// ============================================
+
+// pendingPayments loads the client payments without an entry
+func (s *LedgerService) pendingPayments(ctx context.Context, companyID string, limit int) ([]ledgerEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT p.company_id::text, UPPER(p.id::text), to_char(p.payment_date, 'YYYY-MM-DD'),
+		       i.invoice_number, COALESCE(i.client_name, ''), p.amount, i.establishment_id::text
+		FROM payments p
+		JOIN invoices i ON i.id = p.invoice_id
+		JOIN ledger_settings ls ON ls.company_id = p.company_id
+		WHERE ls.enabled
+		  AND p.payment_date::date >= ls.start_date
+		  AND ($1 = '' OR p.company_id::text = $1)
+		  AND p.voided_at IS NULL
+		  AND NOT EXISTS (
+		      SELECT 1 FROM journal_entries je
+		      WHERE je.company_id = p.company_id
+		        AND je.source_type = $2
+		        AND je.source_id = UPPER(p.id::text)
+		        AND NOT je.reversal
+		  )
+		ORDER BY p.payment_date
+		LIMIT $3
+	`, companyID, models.LedgerSourcePayment, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending payments: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []ledgerEntry{}
+	for rows.Next() {
+		var (
+			entry                 ledgerEntry
+			invoiceNumber, client string
+			amount                float64
+		)
+		if err := rows.Scan(&entry.companyID, &entry.sourceID, &entry.date, &invoiceNumber, &client, &amount,
+			&entry.establishmentID); err != nil {
+			return nil, fmt.Errorf("failed to scan pending payment: %w", err)
+		}
+		entry.sourceType = models.LedgerSourcePayment
+		entry.documentType = models.LedgerDocPayment
+		entry.reference = &invoiceNumber
+		entry.description = "Cobro " + invoiceNumber
+		if client != "" {
+			entry.description += " - " + client
+		}
+		entry.lines = []ledgerLine{
+			{role: models.RoleCash, amount: amount},
+			{role: models.RoleReceivable, amount: -amount},
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// pendingSupplierPayments loads the supplier payments without an entry
+func (s *LedgerService) pendingSupplierPayments(ctx context.Context, companyID string, limit int) ([]ledgerEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT sp.company_id::text, UPPER(sp.id::text), to_char(sp.payment_date, 'YYYY-MM-DD'),
+		       COALESCE(pu.dte_numero_control, pu.purchase_number), COALESCE(pu.supplier_name, s.name, ''), sp.amount,
+		       pu.establishment_id::text
+		FROM supplier_payments sp
+		JOIN purchases pu ON pu.id = sp.purchase_id
+		LEFT JOIN suppliers s ON s.id = sp.supplier_id
+		JOIN ledger_settings ls ON ls.company_id = sp.company_id
+		WHERE ls.enabled
+		  AND sp.payment_date >= ls.start_date
+		  AND ($1 = '' OR sp.company_id::text = $1)
+		  AND sp.voided_at IS NULL
+		  AND NOT EXISTS (
+		      SELECT 1 FROM journal_entries je
+		      WHERE je.company_id = sp.company_id
+		        AND je.source_type = $2
+		        AND je.source_id = UPPER(sp.id::text)
+		        AND NOT je.reversal
+		  )
+		ORDER BY sp.payment_date, sp.created_at
+		LIMIT $3
+	`, companyID, models.LedgerSourceSupplierPayment, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending supplier payments: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []ledgerEntry{}
+	for rows.Next() {
+		var (
+			entry              ledgerEntry
+			document, supplier string
+			amount             float64
+		)
+		if err := rows.Scan(&entry.companyID, &entry.sourceID, &entry.date, &document, &supplier, &amount,
+			&entry.establishmentID); err != nil {
+			return nil, fmt.Errorf("failed to scan pending supplier payment: %w", err)
+		}
+		entry.sourceType = models.LedgerSourceSupplierPayment
+		entry.documentType = models.LedgerDocSupplierPayment
+		entry.reference = &document
+		entry.description = "Pago a proveedor " + document
+		if supplier != "" {
+			entry.description += " - " + supplier
+		}
+		entry.lines = []ledgerLine{
+			{role: models.RolePayable, amount: amount},
+			{role: models.RoleCash, amount: -amount},
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// ============================================
+// INVENTORY
+// ============================================
+
+// pendingInventoryEvents loads the inventory cost events without an entry.
+// Sales and returns move cost between inventory and cost of sales; purchases
+// are received against the purchases account, where the purchase's own entry
+// put their cost. Transfers between locations leave the company's inventory
+// unchanged and get no entry.
+func (s *LedgerService) pendingInventoryEvents(ctx context.Context, companyID string, limit int) ([]ledgerEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ie.company_id::text, ie.event_id::text,
+		       to_char(ie.event_timestamp AT TIME ZONE 'America/El_Salvador', 'YYYY-MM-DD'),
+		       ie.event_type, COALESCE(it.sku, ''), COALESCE(it.name, ''), ie.document_number, ie.total_cost,
+		       ie.establishment_id::text
+		FROM inventory_events ie
+		JOIN ledger_settings ls ON ls.company_id = ie.company_id
+		LEFT JOIN inventory_items it ON it.id = ie.item_id
+		WHERE ls.enabled
+		  AND (ie.event_timestamp AT TIME ZONE 'America/El_Salvador')::date >= ls.start_date
+		  AND ($1 = '' OR ie.company_id::text = $1)
+		  AND ie.total_cost <> 0
+		  AND ie.event_type NOT IN ('TRANSFER_OUT', 'TRANSFER_IN')
+		  AND NOT EXISTS (
+		      SELECT 1 FROM journal_entries je
+		      WHERE je.company_id = ie.company_id
+		        AND je.source_type = $2
+		        AND je.source_id = ie.event_id::text
+		        AND NOT je.reversal
+		  )
+		ORDER BY ie.event_id
+		LIMIT $3
+	`, companyID, models.LedgerSourceInventory, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending inventory events: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []ledgerEntry{}
+	for rows.Next() {
+		var (
+			entry                ledgerEntry
+			eventType, sku, name string
+			document             sql.NullString
+			cost                 float64
+		)
+		if err := rows.Scan(&entry.companyID, &entry.sourceID, &entry.date, &eventType, &sku, &name, &document, &cost, &entry.establishmentID); err != nil {
+			return nil, fmt.Errorf("failed to scan pending inventory event: %w", err)
+		}
+		entry.sourceType = models.LedgerSourceInventory
+		entry.documentType = models.LedgerDocInventory
+		if document.Valid && document.String != "" {
+			entry.reference = &document.String
+		}
+
+		var counterpart string
+		switch eventType {
+		case "SALE":
+			entry.description = "Costo de venta"
+			counterpart, cost = models.RoleCOGS, -cost
+		case "RETURN":
+			entry.description = "Devolución a inventario"
+			counterpart = models.RoleCOGS
+		case "PURCHASE":
+			entry.description = "Entrada a inventario"
+			counterpart = models.RoleInventoryReceived
+		case "INITIAL":
+			entry.description = "Inventario inicial"
+			counterpart = models.RoleOpeningBalance
+		default:
+			entry.description = "Ajuste de inventario"
+			counterpart = models.RoleInventoryAdjustment
+		}
+		entry.description += " " + sku + " " + name
+		entry.lines = []ledgerLine{
+			{role: models.RoleInventory, amount: cost},
+			{role: counterpart, amount: -cost},
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// ============================================
+// REVERSALS
+// ============================================
+
+// pendingReversals loads the posted entries whose document was invalidated
+// (sales and retentions) or voided (purchases and payments) and builds their
+// reversals: the same lines with debits and credits swapped, dated when the
+// document was voided but never before the original entry.
+func (s *LedgerService) pendingReversals(ctx context.Context, companyID string, limit int) ([]ledgerEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT je.id, je.company_id::text, je.source_type, je.source_id, je.document_type,
+		       je.description, je.reference, je.establishment_id::text,
+		       to_char(GREATEST(je.entry_date, v.voided_on), 'YYYY-MM-DD')
+		FROM journal_entries je
+		JOIN ledger_settings ls ON ls.company_id = je.company_id
+		JOIN (
+		    SELECT 'dte' AS kind, UPPER(i.original_codigo_generacion) AS source_id,
+		           MIN((COALESCE(i.hacienda_fh_procesamiento, i.created_at) AT TIME ZONE 'America/El_Salvador')::date) AS voided_on
+		    FROM dte_invalidations i
+		    WHERE i.hacienda_estado = 'PROCESADO'
+		    GROUP BY UPPER(i.original_codigo_generacion)
+		    UNION ALL
+		    SELECT 'purchase', UPPER(p.id::text), (COALESCE(p.voided_at, NOW()) AT TIME ZONE 'America/El_Salvador')::date
+		    FROM purchases p
+		    WHERE p.status = 'voided'
+		    UNION ALL
+		    SELECT 'payment', UPPER(p.id::text), p.voided_at::date
+		    FROM payments p
+		    WHERE p.voided_at IS NOT NULL
+		    UNION ALL
+		    SELECT 'supplier_payment', UPPER(sp.id::text), (sp.voided_at AT TIME ZONE 'America/El_Salvador')::date
+		    FROM supplier_payments sp
+		    WHERE sp.voided_at IS NOT NULL
+		) v ON v.source_id = je.source_id
+		   AND v.kind = CASE WHEN je.source_type IN ($2, $3) THEN 'dte' ELSE je.source_type END
+		WHERE ls.enabled
+		  AND ($1 = '' OR je.company_id::text = $1)
+		  AND NOT je.reversal
+		  AND NOT EXISTS (
+		      SELECT 1 FROM journal_entries r
+		      WHERE r.company_id = je.company_id
+		        AND r.source_type = je.source_type
+		        AND r.source_id = je.source_id
+		        AND r.reversal
+		  )
+		ORDER BY je.entry_date, je.entry_number
+		LIMIT $4
+	`, companyID, models.LedgerSourceSale, models.LedgerSourceRetention, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending reversals: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []ledgerEntry{}
+	for rows.Next() {
+		var (
+			entry    ledgerEntry
+			original string
+		)
+		if err := rows.Scan(&original, &entry.companyID, &entry.sourceType, &entry.sourceID, &entry.documentType,
+			&entry.description, &entry.reference, &entry.establishmentID, &entry.date); err != nil {
+			return nil, fmt.Errorf("failed to scan pending reversal: %w", err)
+		}
+		entry.description = "Anulación: " + entry.description
+		entry.reversal = true
+		entry.reversesEntryID = &original
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pending reversals: %w", err)
+	}
+	rows.Close()
+
+	for i := range entries {
+		lines, err := s.reversedLines(ctx, *entries[i].reversesEntryID)
+		if err != nil {
+			return nil, err
+		}
+		entries[i].lines = lines
+	}
+	return entries, nil
+}
+
+// reversedLines returns an entry's lines with debits and credits swapped
+func (s *LedgerService) reversedLines(ctx context.Context, entryID string) ([]ledgerLine, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT role, account_id, debit, credit
+		FROM journal_lines
+		WHERE entry_id = $1
+		ORDER BY line_number
+	`, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load journal lines: %w", err)
+	}
+	defer rows.Close()
+
+	lines := []ledgerLine{}
+	for rows.Next() {
+		var (
+			line          ledgerLine
+			debit, credit float64
+		)
+		if err := rows.Scan(&line.role, &line.accountID, &debit, &credit); err != nil {
+			return nil, fmt.Errorf("failed to scan journal line: %w", err)
+		}
+		lines = append(lines, reversedLine(line.role, line.accountID, debit, credit))
+	}
+	return lines, rows.Err()
+}
+
+// reversedLine returns the line that cancels a posted one: its credit as a
+// debit and its debit as a credit, on the same account
+func reversedLine(role, accountID string, debit, credit float64) ledgerLine {
+	return ledgerLine{role: role, accountID: accountID, amount: credit - debit}
+}
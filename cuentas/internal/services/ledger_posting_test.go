@@ -0,0 +1,289 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"cuentas/internal/models"
+)
+
+// lineAmounts keys a posting's non-zero lines by role
+func lineAmounts(lines []ledgerLine) map[string]float64 {
+	amounts := map[string]float64{}
+	for _, line := range lines {
+		if line.amount != 0 {
+			amounts[line.role] += line.amount
+		}
+	}
+	return amounts
+}
+
+func TestSalesLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		tipoDte   string
+		dte       string
+		ivaAmount float64
+		want      map[string]float64
+		wantErr   bool
+	}{
+		{
+			name:    "factura carries IVA inside gravadas",
+			tipoDte: "01",
+			dte:     `{"resumen":{"totalGravada":113,"totalExenta":10,"totalIva":13}}`,
+			want: map[string]float64{
+				models.RoleRevenueGravada: -100,
+				models.RoleRevenueExenta:  -10,
+				models.RoleIVADebito:      -13,
+				models.RoleReceivable:     123,
+			},
+		},
+		{
+			name:      "factura IVA from the commit log without totalIva",
+			tipoDte:   "01",
+			dte:       `{"resumen":{"totalGravada":56.5}}`,
+			ivaAmount: 6.5,
+			want: map[string]float64{
+				models.RoleRevenueGravada: -50,
+				models.RoleIVADebito:      -6.5,
+				models.RoleReceivable:     56.5,
+			},
+		},
+		{
+			name:    "factura with IVA retained by the client",
+			tipoDte: "01",
+			dte:     `{"resumen":{"totalGravada":113,"totalIva":13,"ivaRete1":1}}`,
+			want: map[string]float64{
+				models.RoleRevenueGravada:      -100,
+				models.RoleIVADebito:           -13,
+				models.RoleIVARetenidoClientes: 1,
+				models.RoleReceivable:          112,
+			},
+		},
+		{
+			name:    "export",
+			tipoDte: "11",
+			dte:     `{"resumen":{"totalGravada":500}}`,
+			want: map[string]float64{
+				models.RoleRevenueExport: -500,
+				models.RoleReceivable:    500,
+			},
+		},
+		{
+			name:    "CCF with percepción and retention",
+			tipoDte: "03",
+			dte:     `{"resumen":{"totalGravada":100,"totalNoSuj":5,"tributos":[{"codigo":"20","valor":13}],"ivaPerci1":1,"ivaRete1":1}}`,
+			want: map[string]float64{
+				models.RoleRevenueGravada:      -100,
+				models.RoleRevenueNoSujeta:     -5,
+				models.RoleIVADebito:           -13,
+				models.RoleIVAPercibido:        -1,
+				models.RoleIVARetenidoClientes: 1,
+				models.RoleReceivable:          118,
+			},
+		},
+		{
+			name:    "nota de débito posts like a CCF",
+			tipoDte: "06",
+			dte:     `{"resumen":{"totalGravada":20,"tributos":[{"codigo":"20","valor":2.6}]}}`,
+			want: map[string]float64{
+				models.RoleRevenueGravada: -20,
+				models.RoleIVADebito:      -2.6,
+				models.RoleReceivable:     22.6,
+			},
+		},
+		{
+			name:    "nota de crédito turns every sign",
+			tipoDte: "05",
+			dte:     `{"resumen":{"totalGravada":100,"totalNoSuj":5,"tributos":[{"codigo":"20","valor":13}],"ivaPerci1":1,"ivaRete1":1}}`,
+			want: map[string]float64{
+				models.RoleRevenueGravada:      100,
+				models.RoleRevenueNoSujeta:     5,
+				models.RoleIVADebito:           13,
+				models.RoleIVAPercibido:        1,
+				models.RoleIVARetenidoClientes: -1,
+				models.RoleReceivable:          -118,
+			},
+		},
+		{
+			name:    "invalid DTE",
+			tipoDte: "03",
+			dte:     `{"resumen":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := salesLines(tt.tipoDte, []byte(tt.dte), tt.ivaAmount)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("salesLines() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if last := lines[len(lines)-1]; last.role != models.RoleReceivable {
+				t.Errorf("last line role = %q, want %q", last.role, models.RoleReceivable)
+			}
+			got := lineAmounts(lines)
+			if len(got) != len(tt.want) {
+				t.Errorf("lines = %v, want %v", got, tt.want)
+			}
+			for role, want := range tt.want {
+				if got[role] != want {
+					t.Errorf("%s = %v, want %v", role, got[role], want)
+				}
+			}
+			if _, _, err := postableLines(lines); err != nil {
+				t.Errorf("lines do not balance: %v", err)
+			}
+		})
+	}
+}
+
+func TestBalanceLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []ledgerLine
+		want  float64
+	}{
+		{name: "no lines", want: 0},
+		{
+			name:  "credits exceed debits",
+			lines: []ledgerLine{{amount: 100}, {amount: -130}},
+			want:  30,
+		},
+		{
+			name:  "debits exceed credits",
+			lines: []ledgerLine{{amount: 100}, {amount: -30}},
+			want:  -70,
+		},
+		{
+			name:  "rounded to the cent",
+			lines: []ledgerLine{{amount: 0.1}, {amount: 0.2}},
+			want:  -0.3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := balanceLine(models.RoleReceivable, tt.lines)
+			if got.role != models.RoleReceivable {
+				t.Errorf("role = %q, want %q", got.role, models.RoleReceivable)
+			}
+			if got.amount != tt.want {
+				t.Errorf("amount = %v, want %v", got.amount, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostableLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []ledgerLine
+		wantRoles []string
+		wantTotal float64
+		wantErr   string
+	}{
+		{
+			name: "balanced",
+			lines: []ledgerLine{
+				{role: models.RoleReceivable, amount: 113},
+				{role: models.RoleRevenueGravada, amount: -100},
+				{role: models.RoleIVADebito, amount: -13},
+			},
+			wantRoles: []string{models.RoleReceivable, models.RoleRevenueGravada, models.RoleIVADebito},
+			wantTotal: 113,
+		},
+		{
+			name: "zero and sub-cent lines are dropped",
+			lines: []ledgerLine{
+				{role: models.RoleReceivable, amount: 50},
+				{role: models.RoleRevenueExenta, amount: 0},
+				{role: models.RoleIVAPercibido, amount: -0.004},
+				{role: models.RoleRevenueGravada, amount: -50},
+			},
+			wantRoles: []string{models.RoleReceivable, models.RoleRevenueGravada},
+			wantTotal: 50,
+		},
+		{
+			name: "balanced to the cent despite float error",
+			lines: []ledgerLine{
+				{role: models.RoleCash, amount: 0.1},
+				{role: models.RoleCash, amount: 0.2},
+				{role: models.RoleReceivable, amount: -0.3},
+			},
+			wantRoles: []string{models.RoleCash, models.RoleCash, models.RoleReceivable},
+			wantTotal: 0.3,
+		},
+		{
+			name: "unbalanced",
+			lines: []ledgerLine{
+				{role: models.RoleReceivable, amount: 113},
+				{role: models.RoleRevenueGravada, amount: -100},
+			},
+			wantErr: "unbalanced entry: debits 113.00, credits 100.00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, total, err := postableLines(tt.lines)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("postableLines() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("postableLines() error = %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("total = %v, want %v", total, tt.wantTotal)
+			}
+			if len(lines) != len(tt.wantRoles) {
+				t.Fatalf("got %d lines, want %d", len(lines), len(tt.wantRoles))
+			}
+			for i, role := range tt.wantRoles {
+				if lines[i].role != role {
+					t.Errorf("line %d role = %q, want %q", i+1, lines[i].role, role)
+				}
+			}
+		})
+	}
+}
+
+func TestReversedLine(t *testing.T) {
+	posted := []struct {
+		role          string
+		accountID     string
+		debit, credit float64
+	}{
+		{role: models.RoleReceivable, accountID: "receivable", debit: 113},
+		{role: models.RoleRevenueGravada, accountID: "revenue", credit: 100},
+		{role: models.RoleIVADebito, accountID: "iva", credit: 13},
+	}
+	want := []float64{-113, 100, 13}
+
+	var lines []ledgerLine
+	for i, p := range posted {
+		line := reversedLine(p.role, p.accountID, p.debit, p.credit)
+		if line.role != p.role || line.accountID != p.accountID {
+			t.Errorf("line %d = %s/%s, want %s/%s", i+1, line.role, line.accountID, p.role, p.accountID)
+		}
+		if line.amount != want[i] {
+			t.Errorf("line %d amount = %v, want %v", i+1, line.amount, want[i])
+		}
+		lines = append(lines, line)
+	}
+
+	_, total, err := postableLines(lines)
+	if err != nil {
+		t.Fatalf("reversal does not balance: %v", err)
+	}
+	if total != 113 {
+		t.Errorf("reversal total = %v, want 113", total)
+	}
+}
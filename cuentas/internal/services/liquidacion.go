@@ -0,0 +1,633 @@
+package services
+
+import (
+	"context"
+	"cuentas/internal/database"
+	"cuentas/internal/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ============================================
+// ERRORS
+// ============================================
+
+var (
+	ErrLiquidacionNotFound      = errors.New("liquidación not found")
+	ErrLiquidacionAlreadyIssued = errors.New("liquidación has already been accepted by Hacienda")
+	ErrLiquidacionInvalidSource = errors.New("document cannot be included in a liquidación")
+	ErrLiquidacionReceptor      = errors.New("receptor must be a contributor with NIT and NRC")
+)
+
+// liquidacionCommissionIVARate is the IVA charged on the seller commission (13%)
+const liquidacionCommissionIVARate = 0.13
+
+// liquidacionSourceTypes are the DTE types a liquidación can summarize (fe-cl-v1 cuerpoDocumento.tipoDte)
+var liquidacionSourceTypes = map[string]bool{
+	"01": true, // Factura
+	"03": true, // Comprobante de Crédito Fiscal
+	"05": true, // Nota de Crédito
+	"06": true, // Nota de Débito
+	"11": true, // Factura de Exportación
+}
+
+// ============================================
+// SERVICE DEFINITION
+// ============================================
+
+type LiquidacionService struct{}
+
+func NewLiquidacionService() *LiquidacionService {
+	return &LiquidacionService{}
+}
+
+// ============================================
+// CREATE LIQUIDACIÓN
+// ============================================
+
+// CreateLiquidacion creates a DTE 08 from documents already accepted by Hacienda.
+// Amounts are taken from the commit log; transmission happens when the handler finalizes it.
+func (s *LiquidacionService) CreateLiquidacion(
+	ctx context.Context,
+	companyID string,
+	req *models.CreateLiquidacionRequest,
+	userID string,
+) (*models.Liquidacion, error) {
+	// 1. Validate request
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// 2. Receptor must be a formal contributor
+	if err := s.validateReceptor(ctx, companyID, req.ClientID); err != nil {
+		return nil, err
+	}
+
+	// 3. Load and validate the referenced documents
+	documents, err := s.loadSourceDocuments(ctx, companyID, req.CodigosGeneracion)
+	if err != nil {
+		return nil, err
+	}
+
+	ambiente, err := s.getCompanyAmbiente(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Begin transaction
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Checked under lock so concurrent requests cannot liquidate a document twice
+	if err := s.checkSourcesAvailable(ctx, tx, companyID, req.CodigosGeneracion); err != nil {
+		return nil, err
+	}
+
+	numeroControl, err := s.generateLiquidacionNumeroControl(ctx, tx, companyID, req.EstablishmentID, req.PointOfSaleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate numero control: %w", err)
+	}
+
+	// 5. Build the liquidación and compute totals
+	now := time.Now()
+	liquidacion := &models.Liquidacion{
+		ID:                    strings.ToUpper(uuid.New().String()),
+		CompanyID:             companyID,
+		EstablishmentID:       req.EstablishmentID,
+		PointOfSaleID:         req.PointOfSaleID,
+		ClientID:              req.ClientID,
+		CodigoGeneracion:      strings.ToUpper(uuid.New().String()),
+		NumeroControl:         numeroControl,
+		TipoDte:               "08",
+		Ambiente:              ambiente,
+		CommissionRate:        req.CommissionRate,
+		CondicionOperacion:    req.CondicionOperacion,
+		Observaciones:         req.Observaciones,
+		FechaEmision:          now,
+		DteJSON:               "{}",
+		DteSigned:             "",
+		DteTransmissionStatus: "pending",
+		CreatedBy:             nullIfBlank(&userID),
+		CreatedAt:             now,
+		Documents:             documents,
+	}
+	calculateLiquidacionTotals(liquidacion)
+
+	// 6. Insert liquidación and its documents
+	if err := s.insertLiquidacion(ctx, tx, liquidacion); err != nil {
+		return nil, err
+	}
+	for i := range liquidacion.Documents {
+		liquidacion.Documents[i].LiquidacionID = liquidacion.ID
+		if err := s.insertLiquidacionDocument(ctx, tx, &liquidacion.Documents[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	// 7. Commit transaction
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return liquidacion, nil
+}
+
+// calculateLiquidacionTotals sums the referenced documents and computes the
+// seller commission and its IVA. NetAmount is what the seller must remit.
+func calculateLiquidacionTotals(l *models.Liquidacion) {
+	var noSuj, exenta, gravada, exportacion, iva float64
+	for _, doc := range l.Documents {
+		noSuj += doc.VentaNoSuj
+		exenta += doc.VentaExenta
+		gravada += doc.VentaGravada
+		exportacion += doc.Exportaciones
+		iva += doc.IvaItem
+	}
+
+	l.TotalNoSuj = round(noSuj)
+	l.TotalExenta = round(exenta)
+	l.TotalGravada = round(gravada)
+	l.TotalExportacion = round(exportacion)
+	l.SubTotalVentas = round(l.TotalNoSuj + l.TotalExenta + l.TotalGravada + l.TotalExportacion)
+	l.TotalIVA = round(iva)
+	l.MontoTotalOperacion = round(l.SubTotalVentas + l.TotalIVA)
+
+	l.CommissionAmount = round(l.SubTotalVentas * l.CommissionRate / 100)
+	l.CommissionIVA = round(l.CommissionAmount * liquidacionCommissionIVARate)
+	l.NetAmount = round(l.MontoTotalOperacion - l.CommissionAmount - l.CommissionIVA)
+}
+
+// ============================================
+// SOURCE DOCUMENTS
+// ============================================
+
+// loadSourceDocuments loads the referenced DTEs from the commit log. Every document
+// must be accepted by Hacienda, not invalidated and not part of another liquidación.
+func (s *LiquidacionService) loadSourceDocuments(ctx context.Context, companyID string, codigos []string) ([]models.LiquidacionDocument, error) {
+	query := `
+        SELECT DISTINCT ON (UPPER(codigo_generacion))
+            UPPER(codigo_generacion), tipo_dte, numero_control, fecha_emision, iva_amount, dte_unsigned
+        FROM dte_commit_log
+        WHERE company_id = $1
+          AND UPPER(codigo_generacion) = ANY($2)
+          AND hacienda_estado = 'PROCESADO'
+        ORDER BY UPPER(codigo_generacion), created_at DESC
+    `
+
+	rows, err := database.DB.QueryContext(ctx, query, companyID, pq.Array(codigos))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query commit log: %w", err)
+	}
+	defer rows.Close()
+
+	found := make(map[string]models.LiquidacionDocument, len(codigos))
+	for rows.Next() {
+		var doc models.LiquidacionDocument
+		var ivaAmount float64
+		var dteUnsigned []byte
+
+		if err := rows.Scan(&doc.CodigoGeneracion, &doc.TipoDte, &doc.NumeroControl, &doc.FechaGeneracion, &ivaAmount, &dteUnsigned); err != nil {
+			return nil, fmt.Errorf("failed to scan commit log entry: %w", err)
+		}
+
+		if !liquidacionSourceTypes[doc.TipoDte] {
+			return nil, fmt.Errorf("%w: %s is a type %s DTE", ErrLiquidacionInvalidSource, doc.CodigoGeneracion, doc.TipoDte)
+		}
+
+		if err := applyLiquidacionAmounts(&doc, dteUnsigned, ivaAmount); err != nil {
+			return nil, fmt.Errorf("failed to read amounts of %s: %w", doc.CodigoGeneracion, err)
+		}
+
+		doc.TipoGeneracion = 2 // Electrónico
+		doc.ObsItem = doc.NumeroControl
+		found[doc.CodigoGeneracion] = doc
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating commit log: %w", err)
+	}
+
+	documents := make([]models.LiquidacionDocument, 0, len(codigos))
+	for i, codigo := range codigos {
+		doc, ok := found[codigo]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s was not found or has not been accepted by Hacienda", ErrLiquidacionInvalidSource, codigo)
+		}
+		doc.LineNumber = i + 1
+		documents = append(documents, doc)
+	}
+
+	return documents, nil
+}
+
+// checkSourcesAvailable rejects invalidated documents and documents already
+// summarized by a liquidación that was not rejected or invalidated. It locks the
+// documents until tx ends, so it must run in the transaction that inserts them.
+func (s *LiquidacionService) checkSourcesAvailable(ctx context.Context, tx *sql.Tx, companyID string, codigos []string) error {
+	// Lock each document for the rest of the transaction, in a fixed order so
+	// requests sharing documents cannot deadlock
+	locked := append([]string(nil), codigos...)
+	sort.Strings(locked)
+	for _, codigo := range locked {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('liquidacion:' || $1 || ':' || $2))`, companyID, codigo); err != nil {
+			return fmt.Errorf("failed to lock %s: %w", codigo, err)
+		}
+	}
+
+	var invalidated string
+	err := tx.QueryRowContext(ctx, `
+        SELECT UPPER(original_codigo_generacion)
+        FROM dte_invalidations
+        WHERE company_id = $1
+          AND UPPER(original_codigo_generacion) = ANY($2)
+          AND hacienda_estado = 'PROCESADO'
+        LIMIT 1
+    `, companyID, pq.Array(codigos)).Scan(&invalidated)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to check invalidations: %w", err)
+	}
+	if err == nil {
+		return fmt.Errorf("%w: %s has been invalidated", ErrLiquidacionInvalidSource, invalidated)
+	}
+
+	var codigo, numeroControl string
+	err = tx.QueryRowContext(ctx, `
+        SELECT ld.codigo_generacion, l.numero_control
+        FROM liquidacion_documents ld
+        JOIN liquidaciones l ON l.id = ld.liquidacion_id
+        WHERE l.company_id = $1
+          AND ld.codigo_generacion = ANY($2)
+          AND (l.hacienda_estado IS NULL OR l.hacienda_estado != 'RECHAZADO')
+          AND NOT EXISTS (
+              SELECT 1 FROM dte_invalidations i
+              WHERE i.original_codigo_generacion = l.codigo_generacion
+                AND i.hacienda_estado = 'PROCESADO'
+          )
+        LIMIT 1
+    `, companyID, pq.Array(codigos)).Scan(&codigo, &numeroControl)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to check existing liquidaciones: %w", err)
+	}
+	if err == nil {
+		return fmt.Errorf("%w: %s is already included in liquidación %s", ErrLiquidacionInvalidSource, codigo, numeroControl)
+	}
+
+	return nil
+}
+
+// applyLiquidacionAmounts reads the document totals from the unsigned DTE stored in the commit log.
+// Facturas (01) carry IVA inside totalGravada; CCF and notas carry it in tributos.
+// Notas de crédito (05) reduce the liquidation and are stored as negative amounts.
+func applyLiquidacionAmounts(doc *models.LiquidacionDocument, dteUnsigned []byte, ivaAmount float64) error {
+	var dte struct {
+		Resumen struct {
+			TotalNoSuj   float64  `json:"totalNoSuj"`
+			TotalExenta  float64  `json:"totalExenta"`
+			TotalGravada float64  `json:"totalGravada"`
+			TotalIva     *float64 `json:"totalIva"`
+			Tributos     []struct {
+				Codigo string  `json:"codigo"`
+				Valor  float64 `json:"valor"`
+			} `json:"tributos"`
+		} `json:"resumen"`
+	}
+	if err := json.Unmarshal(dteUnsigned, &dte); err != nil {
+		return fmt.Errorf("failed to parse DTE: %w", err)
+	}
+	resumen := dte.Resumen
+
+	switch doc.TipoDte {
+	case "11":
+		// Exports are gravadas at 0%
+		doc.Exportaciones = resumen.TotalGravada
+	case "01":
+		iva := ivaAmount
+		if resumen.TotalIva != nil {
+			iva = *resumen.TotalIva
+		}
+		doc.VentaNoSuj = resumen.TotalNoSuj
+		doc.VentaExenta = resumen.TotalExenta
+		doc.VentaGravada = resumen.TotalGravada - iva
+		doc.IvaItem = iva
+	default:
+		iva := ivaAmount
+		if len(resumen.Tributos) > 0 {
+			iva = 0
+			for _, tributo := range resumen.Tributos {
+				if tributo.Codigo == "20" {
+					iva += tributo.Valor
+				}
+			}
+		}
+		doc.VentaNoSuj = resumen.TotalNoSuj
+		doc.VentaExenta = resumen.TotalExenta
+		doc.VentaGravada = resumen.TotalGravada
+		doc.IvaItem = iva
+	}
+
+	sign := 1.0
+	if doc.TipoDte == "05" {
+		sign = -1.0
+	}
+	doc.VentaNoSuj = round(sign * doc.VentaNoSuj)
+	doc.VentaExenta = round(sign * doc.VentaExenta)
+	doc.VentaGravada = round(sign * doc.VentaGravada)
+	doc.Exportaciones = round(sign * doc.Exportaciones)
+	doc.IvaItem = round(sign * doc.IvaItem)
+
+	return nil
+}
+
+// ============================================
+// DATABASE OPERATIONS
+// ============================================
+
+func (s *LiquidacionService) insertLiquidacion(ctx context.Context, tx *sql.Tx, l *models.Liquidacion) error {
+	query := `
+        INSERT INTO liquidaciones (
+            id, company_id, establishment_id, point_of_sale_id, client_id,
+            codigo_generacion, numero_control, tipo_dte, ambiente,
+            total_no_suj, total_exenta, total_gravada, total_exportacion,
+            sub_total_ventas, total_iva, monto_total_operacion,
+            commission_rate, commission_amount, commission_iva, net_amount,
+            condicion_operacion, observaciones,
+            fecha_emision, dte_json, dte_signed, dte_transmission_status,
+            created_by, created_at
+        ) VALUES (
+            $1, $2, $3, $4, $5,
+            $6, $7, $8, $9,
+            $10, $11, $12, $13,
+            $14, $15, $16,
+            $17, $18, $19, $20,
+            $21, $22,
+            $23, $24, $25, $26,
+            $27, $28
+        )
+    `
+
+	_, err := tx.ExecContext(ctx, query,
+		l.ID, l.CompanyID, l.EstablishmentID, l.PointOfSaleID, l.ClientID,
+		l.CodigoGeneracion, l.NumeroControl, l.TipoDte, l.Ambiente,
+		l.TotalNoSuj, l.TotalExenta, l.TotalGravada, l.TotalExportacion,
+		l.SubTotalVentas, l.TotalIVA, l.MontoTotalOperacion,
+		l.CommissionRate, l.CommissionAmount, l.CommissionIVA, l.NetAmount,
+		l.CondicionOperacion, l.Observaciones,
+		l.FechaEmision, l.DteJSON, l.DteSigned, l.DteTransmissionStatus,
+		l.CreatedBy, l.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert liquidación: %w", err)
+	}
+
+	return nil
+}
+
+func (s *LiquidacionService) insertLiquidacionDocument(ctx context.Context, tx *sql.Tx, doc *models.LiquidacionDocument) error {
+	query := `
+        INSERT INTO liquidacion_documents (
+            liquidacion_id, line_number,
+            codigo_generacion, tipo_dte, tipo_generacion, numero_control, fecha_generacion,
+            venta_no_suj, venta_exenta, venta_gravada, exportaciones, iva_item, obs_item
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+        RETURNING id, created_at
+    `
+
+	err := tx.QueryRowContext(ctx, query,
+		doc.LiquidacionID, doc.LineNumber,
+		doc.CodigoGeneracion, doc.TipoDte, doc.TipoGeneracion, doc.NumeroControl, doc.FechaGeneracion,
+		doc.VentaNoSuj, doc.VentaExenta, doc.VentaGravada, doc.Exportaciones, doc.IvaItem, doc.ObsItem,
+	).Scan(&doc.ID, &doc.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert liquidación document %s: %w", doc.CodigoGeneracion, err)
+	}
+
+	return nil
+}
+
+// ============================================
+// QUERY OPERATIONS
+// ============================================
+
+const liquidacionColumns = `
+            id, company_id, establishment_id, point_of_sale_id, client_id,
+            codigo_generacion, numero_control, tipo_dte, ambiente,
+            total_no_suj, total_exenta, total_gravada, total_exportacion,
+            sub_total_ventas, total_iva, monto_total_operacion,
+            commission_rate, commission_amount, commission_iva, net_amount,
+            condicion_operacion, observaciones,
+            fecha_emision, fecha_procesamiento,
+            dte_json, dte_signed,
+            hacienda_estado, hacienda_sello_recibido, hacienda_fh_procesamiento,
+            hacienda_codigo_msg, hacienda_descripcion_msg, hacienda_observaciones, hacienda_response,
+            contingency_period_id, COALESCE(dte_transmission_status, 'pending'),
+            created_by, created_at, submitted_at`
+
+func scanLiquidacion(scanner interface{ Scan(...interface{}) error }, l *models.Liquidacion) error {
+	var observaciones []string
+	err := scanner.Scan(
+		&l.ID, &l.CompanyID, &l.EstablishmentID, &l.PointOfSaleID, &l.ClientID,
+		&l.CodigoGeneracion, &l.NumeroControl, &l.TipoDte, &l.Ambiente,
+		&l.TotalNoSuj, &l.TotalExenta, &l.TotalGravada, &l.TotalExportacion,
+		&l.SubTotalVentas, &l.TotalIVA, &l.MontoTotalOperacion,
+		&l.CommissionRate, &l.CommissionAmount, &l.CommissionIVA, &l.NetAmount,
+		&l.CondicionOperacion, &l.Observaciones,
+		&l.FechaEmision, &l.FechaProcesamiento,
+		&l.DteJSON, &l.DteSigned,
+		&l.HaciendaEstado, &l.HaciendaSelloRecibido, &l.HaciendaFhProcesamiento,
+		&l.HaciendaCodigoMsg, &l.HaciendaDescripcionMsg, pq.Array(&observaciones), &l.HaciendaResponse,
+		&l.ContingencyPeriodID, &l.DteTransmissionStatus,
+		&l.CreatedBy, &l.CreatedAt, &l.SubmittedAt,
+	)
+	if err != nil {
+		return err
+	}
+	l.HaciendaObservaciones = observaciones
+	return nil
+}
+
+// GetLiquidacionByID retrieves a liquidación with its documents
+func (s *LiquidacionService) GetLiquidacionByID(ctx context.Context, companyID, liquidacionID string) (*models.Liquidacion, error) {
+	query := `SELECT ` + liquidacionColumns + `
+        FROM liquidaciones
+        WHERE id = $1 AND company_id = $2
+    `
+
+	liquidacion := &models.Liquidacion{}
+	err := scanLiquidacion(database.DB.QueryRowContext(ctx, query, liquidacionID, companyID), liquidacion)
+	if err == sql.ErrNoRows {
+		return nil, ErrLiquidacionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query liquidación: %w", err)
+	}
+
+	documents, err := s.getLiquidacionDocuments(ctx, liquidacion.ID)
+	if err != nil {
+		return nil, err
+	}
+	liquidacion.Documents = documents
+
+	return liquidacion, nil
+}
+
+// ListLiquidaciones retrieves all liquidaciones for a company with pagination
+func (s *LiquidacionService) ListLiquidaciones(ctx context.Context, companyID string, limit, offset int) ([]models.Liquidacion, error) {
+	query := `SELECT ` + liquidacionColumns + `
+        FROM liquidaciones
+        WHERE company_id = $1
+        ORDER BY created_at DESC
+        LIMIT $2 OFFSET $3
+    `
+
+	rows, err := database.DB.QueryContext(ctx, query, companyID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query liquidaciones: %w", err)
+	}
+	defer rows.Close()
+
+	var liquidaciones []models.Liquidacion
+	for rows.Next() {
+		var l models.Liquidacion
+		if err := scanLiquidacion(rows, &l); err != nil {
+			return nil, fmt.Errorf("failed to scan liquidación: %w", err)
+		}
+		liquidaciones = append(liquidaciones, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating liquidaciones: %w", err)
+	}
+
+	return liquidaciones, nil
+}
+
+func (s *LiquidacionService) getLiquidacionDocuments(ctx context.Context, liquidacionID string) ([]models.LiquidacionDocument, error) {
+	query := `
+        SELECT
+            id, liquidacion_id, line_number,
+            codigo_generacion, tipo_dte, tipo_generacion, numero_control, fecha_generacion,
+            venta_no_suj, venta_exenta, venta_gravada, exportaciones, iva_item, obs_item,
+            created_at
+        FROM liquidacion_documents
+        WHERE liquidacion_id = $1
+        ORDER BY line_number
+    `
+
+	rows, err := database.DB.QueryContext(ctx, query, liquidacionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query liquidación documents: %w", err)
+	}
+	defer rows.Close()
+
+	var documents []models.LiquidacionDocument
+	for rows.Next() {
+		var d models.LiquidacionDocument
+		err := rows.Scan(
+			&d.ID, &d.LiquidacionID, &d.LineNumber,
+			&d.CodigoGeneracion, &d.TipoDte, &d.TipoGeneracion, &d.NumeroControl, &d.FechaGeneracion,
+			&d.VentaNoSuj, &d.VentaExenta, &d.VentaGravada, &d.Exportaciones, &d.IvaItem, &d.ObsItem,
+			&d.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan liquidación document: %w", err)
+		}
+		documents = append(documents, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating liquidación documents: %w", err)
+	}
+
+	return documents, nil
+}
+
+// ============================================
+// HELPER FUNCTIONS
+// ============================================
+
+// validateReceptor checks the third-party seller is a contributor (NIT and NRC)
+func (s *LiquidacionService) validateReceptor(ctx context.Context, companyID, clientID string) error {
+	var nit, nrc sql.NullString
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT nit, ncr FROM clients WHERE id = $1 AND company_id = $2`,
+		clientID, companyID,
+	).Scan(&nit, &nrc)
+	if err == sql.ErrNoRows {
+		return ErrClientNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to query client: %w", err)
+	}
+
+	if !nit.Valid || nit.String == "" || !nrc.Valid || nrc.String == "" {
+		return ErrLiquidacionReceptor
+	}
+
+	return nil
+}
+
+func (s *LiquidacionService) getCompanyAmbiente(ctx context.Context, companyID string) (string, error) {
+	var ambiente string
+	err := database.DB.QueryRowContext(ctx, `SELECT dte_ambiente FROM companies WHERE id = $1`, companyID).Scan(&ambiente)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("company not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query company: %w", err)
+	}
+	return ambiente, nil
+}
+
+// generateLiquidacionNumeroControl generates a numero control for liquidación DTE 08
+func (s *LiquidacionService) generateLiquidacionNumeroControl(ctx context.Context, tx *sql.Tx, companyID, establishmentID, posID string) (string, error) {
+	// Load establishment and POS codes (scoped to the company)
+	var codEstablecimiento, codPuntoVenta string
+	query := `
+        SELECT e.cod_establecimiento, p.cod_punto_venta
+        FROM establishments e
+        JOIN point_of_sale p ON p.establishment_id = e.id
+        WHERE e.id = $1 AND p.id = $2 AND e.company_id = $3
+    `
+
+	err := tx.QueryRowContext(ctx, query, establishmentID, posID, companyID).Scan(&codEstablecimiento, &codPuntoVenta)
+	if err == sql.ErrNoRows {
+		return "", ErrPointOfSaleNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load establishment codes: %w", err)
+	}
+
+	// Get last sequence number for this establishment/POS
+	var lastSeq sql.NullInt64
+	seqQuery := `
+        SELECT MAX(CAST(SUBSTRING(numero_control FROM 21 FOR 15) AS BIGINT))
+        FROM liquidaciones
+        WHERE establishment_id = $1
+          AND point_of_sale_id = $2
+          AND numero_control IS NOT NULL
+    `
+
+	err = tx.QueryRowContext(ctx, seqQuery, establishmentID, posID).Scan(&lastSeq)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to query last sequence: %w", err)
+	}
+
+	nextSeq := int64(1)
+	if lastSeq.Valid {
+		nextSeq = lastSeq.Int64 + 1
+	}
+
+	// Format: DTE-08-{codEstable}{codPOS}-{sequence}
+	// Example: DTE-08-M001P001-000000000000001
+	return fmt.Sprintf("DTE-08-%s%s-%015d", codEstablecimiento, codPuntoVenta, nextSeq), nil
+}
@@ -0,0 +1,276 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Client sends email through an SMTP relay. Locally it points at MailHog
+// (localhost:1025), which accepts everything without auth or TLS.
+type Client struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     mail.Address
+	implicit bool // TLS from the first byte (port 465) instead of STARTTLS
+	timeout  time.Duration
+}
+
+// Config holds the SMTP client configuration
+type Config struct {
+	Host        string
+	Port        int
+	Username    string
+	Password    string
+	FromAddress string // Default sender when a company has none configured
+	FromName    string
+	ImplicitTLS bool
+	Timeout     time.Duration
+}
+
+// Attachment is a file attached to a message
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Message is a plain-text email with attachments
+type Message struct {
+	From        *mail.Address // nil uses the client's default sender
+	To          []string
+	Bcc         []string
+	ReplyTo     string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// NewClient creates a new SMTP client
+func NewClient(cfg *Config) *Client {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	// Set defaults for any missing config
+	if cfg.Host == "" {
+		cfg.Host = "localhost"
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 1025
+	}
+	if cfg.FromAddress == "" {
+		cfg.FromAddress = "no-reply@localhost"
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	return &Client{
+		host:     cfg.Host,
+		port:     cfg.Port,
+		username: cfg.Username,
+		password: cfg.Password,
+		from:     mail.Address{Name: cfg.FromName, Address: cfg.FromAddress},
+		implicit: cfg.ImplicitTLS,
+		timeout:  cfg.Timeout,
+	}
+}
+
+// NewClientFromViper creates a client from the smtp_* configuration keys
+func NewClientFromViper() *Client {
+	return NewClient(&Config{
+		Host:        viper.GetString("smtp_host"),
+		Port:        viper.GetInt("smtp_port"),
+		Username:    viper.GetString("smtp_username"),
+		Password:    viper.GetString("smtp_password"),
+		FromAddress: viper.GetString("smtp_from"),
+		FromName:    viper.GetString("smtp_from_name"),
+		ImplicitTLS: viper.GetBool("smtp_implicit_tls"),
+		Timeout:     viper.GetDuration("smtp_timeout"),
+	})
+}
+
+// GetAddress returns host:port of the SMTP relay
+func (c *Client) GetAddress() string {
+	return net.JoinHostPort(c.host, strconv.Itoa(c.port))
+}
+
+// DefaultFrom returns the sender used when a message has none
+func (c *Client) DefaultFrom() mail.Address {
+	return c.from
+}
+
+// Send delivers the message and returns the Message-ID it was sent with
+func (c *Client) Send(ctx context.Context, msg *Message) (string, error) {
+	if len(msg.To) == 0 {
+		return "", fmt.Errorf("message has no recipients")
+	}
+
+	from := c.from
+	if msg.From != nil {
+		from = *msg.From
+	}
+
+	messageID, err := newMessageID(from.Address)
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := buildMessage(from, messageID, msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to build message: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to SMTP server %s: %w", c.GetAddress(), err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		conn.Close()
+		return "", fmt.Errorf("SMTP handshake failed: %w", err)
+	}
+	defer client.Close()
+
+	if !c.implicit {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: c.host}); err != nil {
+				return "", fmt.Errorf("STARTTLS failed: %w", err)
+			}
+		}
+	}
+
+	if c.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.username, c.password, c.host)); err != nil {
+			return "", fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return "", fmt.Errorf("MAIL FROM rejected: %w", err)
+	}
+	for _, rcpt := range append(append([]string{}, msg.To...), msg.Bcc...) {
+		if err := client.Rcpt(rcpt); err != nil {
+			return "", fmt.Errorf("RCPT TO %s rejected: %w", rcpt, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return "", fmt.Errorf("DATA rejected: %w", err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		return "", fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("message rejected: %w", err)
+	}
+
+	return messageID, client.Quit()
+}
+
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{}
+	if c.implicit {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: c.host}}
+		return tlsDialer.DialContext(ctx, "tcp", c.GetAddress())
+	}
+	return dialer.DialContext(ctx, "tcp", c.GetAddress())
+}
+
+// buildMessage renders the message as multipart/mixed MIME
+func buildMessage(from mail.Address, messageID string, msg *Message) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", from.String())
+	header("To", strings.Join(msg.To, ", "))
+	if msg.ReplyTo != "" {
+		header("Reply-To", msg.ReplyTo)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID)
+	header("MIME-Version", "1.0")
+	header("Content-Type", fmt.Sprintf("multipart/mixed; boundary=%q", mw.Boundary()))
+	buf.WriteString("\r\n")
+
+	bodyPart, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(bodyPart)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(msg.Body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, att := range msg.Attachments {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(att.ContentType, map[string]string{"name": att.Filename})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": att.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(att.Data)
+		for len(encoded) > 76 {
+			part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		part.Write([]byte(encoded + "\r\n"))
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newMessageID generates a Message-ID under the sender's domain
+func newMessageID(fromAddress string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate message id: %w", err)
+	}
+	domain := "localhost"
+	if at := strings.LastIndex(fromAddress, "@"); at >= 0 && at < len(fromAddress)-1 {
+		domain = fromAddress[at+1:]
+	}
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain), nil
+}
@@ -676,7 +676,7 @@ func (s *InvoiceService) FinalizeRemision(ctx context.Context, companyID, remisi
 		numeroControl,
 		tipoDte,
 		now,
-		userID,
+		nullIfBlank(&userID),
 		remisionID,
 		companyID,
 	)
@@ -28,6 +28,7 @@ func NewNotaCreditoService() *NotaCreditoService {
 func (s *NotaCreditoService) CreateNotaCredito(
 	ctx context.Context,
 	companyID string,
+	userID string,
 	req *models.CreateNotaCreditoRequest,
 	invoiceService *InvoiceService,
 ) (*models.NotaCredito, error) {
@@ -72,7 +73,7 @@ func (s *NotaCreditoService) CreateNotaCredito(
 	}
 
 	// Step 6: Create nota record in database
-	nota, err := s.createNotaRecord(ctx, companyID, req, ccfs, lineItems, totals, isFullAnnulment)
+	nota, err := s.createNotaRecord(ctx, companyID, userID, req, ccfs, lineItems, totals, isFullAnnulment)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create nota record: %w", err)
 	}
@@ -92,6 +93,7 @@ func (s *NotaCreditoService) FinalizeNotaCredito(
 	ctx context.Context,
 	notaID string,
 	companyID string,
+	userID string,
 ) (*models.NotaCredito, error) {
 
 	fmt.Printf("🔄 Finalizing Nota de Crédito: %s\n", notaID)
@@ -129,12 +131,25 @@ func (s *NotaCreditoService) FinalizeNotaCredito(
 		fmt.Printf("   Generated Numero Control: %s\n", numeroControl)
 	}
 
-	// Step 4: Update nota status to finalized
+	// Step 4: Update nota status to finalized and apply it to the CCFs' balances
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	now := time.Now()
-	err = s.updateNotaStatusToFinalized(ctx, notaID, now)
+	err = s.updateNotaStatusToFinalized(ctx, tx, notaID, userID, now)
 	if err != nil {
 		return nil, fmt.Errorf("failed to finalize nota in database: %w", err)
 	}
+	if err := settleNotaCCFsTx(ctx, tx, models.StatementEntryNotaCredito, companyID, notaID); err != nil {
+		return nil, fmt.Errorf("failed to apply nota to CCF balances: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
 
 	// Step 5: Reload nota with updated data
 	nota, err = s.getNotaCredito(ctx, notaID, companyID)
@@ -586,6 +601,7 @@ func (s *NotaCreditoService) isFullAnnulment(
 func (s *NotaCreditoService) createNotaRecord(
 	ctx context.Context,
 	companyID string,
+	userID string,
 	req *models.CreateNotaCreditoRequest,
 	ccfs []*models.Invoice,
 	lineItems []models.NotaCreditoLineItem,
@@ -644,6 +660,7 @@ func (s *NotaCreditoService) createNotaRecord(
 		PaymentMethod:           firstCCF.PaymentMethod,
 		Status:                  "draft",
 		CreatedAt:               now,
+		CreatedBy:               nullIfBlank(&userID),
 	}
 
 	if req.CreditDescription != "" {
@@ -665,10 +682,10 @@ func (s *NotaCreditoService) createNotaRecord(
 			credit_reason, credit_description, is_full_annulment,
 			subtotal, total_discount, total_taxes, total,
 			currency, payment_terms, payment_method,
-			status, created_at, notes
+			status, created_at, created_by, notes
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
-			$18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30
+			$18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31
 		)
 	`
 
@@ -681,7 +698,7 @@ func (s *NotaCreditoService) createNotaRecord(
 		nota.CreditReason, nota.CreditDescription, nota.IsFullAnnulment,
 		nota.Subtotal, nota.TotalDiscount, nota.TotalTaxes, nota.Total,
 		nota.Currency, nota.PaymentTerms, nota.PaymentMethod,
-		nota.Status, nota.CreatedAt, nota.Notes,
+		nota.Status, nota.CreatedAt, nota.CreatedBy, nota.Notes,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert nota: %w", err)
@@ -812,7 +829,7 @@ func (s *NotaCreditoService) getNotaCredito(ctx context.Context, notaID, company
 			dte_numero_control, dte_codigo_generacion, dte_sello_recibido, 
 			dte_status, dte_hacienda_response, dte_submitted_at,
 			created_at, finalized_at, voided_at,
-			created_by, notes
+			created_by, finalized_by, notes
 		FROM notas_credito
 		WHERE id = $1 AND company_id = $2
 	`
@@ -831,7 +848,7 @@ func (s *NotaCreditoService) getNotaCredito(ctx context.Context, notaID, company
 		&nota.DteNumeroControl, &nota.DteCodigoGeneracion, &nota.DteSelloRecibido,
 		&nota.DteStatus, &nota.DteHaciendaResponse, &nota.DteSubmittedAt,
 		&nota.CreatedAt, &nota.FinalizedAt, &nota.VoidedAt,
-		&nota.CreatedBy, &nota.Notes,
+		&nota.CreatedBy, &nota.FinalizedBy, &nota.Notes,
 	)
 
 	if err == sql.ErrNoRows {
@@ -970,6 +987,15 @@ func (s *NotaCreditoService) generateNumeroControl(ctx context.Context, companyI
 	return numeroControl, nil
 }
 
+// PreviewNumeroControl returns the numero control the nota would be finalized with,
+// without saving it
+func (s *NotaCreditoService) PreviewNumeroControl(ctx context.Context, companyID string, nota *models.NotaCredito) (string, error) {
+	if nota.DteNumeroControl != nil {
+		return *nota.DteNumeroControl, nil
+	}
+	return s.generateNumeroControl(ctx, companyID, nota)
+}
+
 // saveNumeroControl saves the numero control to the nota
 func (s *NotaCreditoService) saveNumeroControl(ctx context.Context, notaID, numeroControl string) error {
 	query := `
@@ -983,15 +1009,16 @@ func (s *NotaCreditoService) saveNumeroControl(ctx context.Context, notaID, nume
 }
 
 // updateNotaStatusToFinalized updates the nota to finalized status
-func (s *NotaCreditoService) updateNotaStatusToFinalized(ctx context.Context, notaID string, finalizedAt time.Time) error {
+func (s *NotaCreditoService) updateNotaStatusToFinalized(ctx context.Context, tx *sql.Tx, notaID, userID string, finalizedAt time.Time) error {
 	query := `
 		UPDATE notas_credito
 		SET 
 			status = 'finalized',
-			finalized_at = $1
-		WHERE id = $2
+			finalized_at = $1,
+			finalized_by = $2
+		WHERE id = $3
 	`
 
-	_, err := database.DB.ExecContext(ctx, query, finalizedAt, notaID)
+	_, err := tx.ExecContext(ctx, query, finalizedAt, nullIfBlank(&userID), notaID)
 	return err
 }
@@ -30,6 +30,7 @@ func NewNotaService() *NotaService {
 func (s *NotaService) CreateNotaDebito(
 	ctx context.Context,
 	companyID string,
+	userID string,
 	req *models.CreateNotaDebitoRequest,
 	invoiceService *InvoiceService,
 ) (*models.NotaDebito, error) {
@@ -68,7 +69,7 @@ func (s *NotaService) CreateNotaDebito(
 	fmt.Printf("   Total: $%.2f\n", totals.Total)
 
 	// Step 5: Create nota record in database
-	nota, err := s.createNotaRecord(ctx, companyID, req, ccfs, lineItems, totals)
+	nota, err := s.createNotaRecord(ctx, companyID, userID, req, ccfs, lineItems, totals)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create nota record: %w", err)
 	}
@@ -88,6 +89,7 @@ func (s *NotaService) FinalizeNotaDebito(
 	ctx context.Context,
 	notaID string,
 	companyID string,
+	userID string,
 ) (*models.NotaDebito, error) {
 
 	fmt.Printf("🔄 Finalizing Nota de Débito: %s\n", notaID)
@@ -124,12 +126,25 @@ func (s *NotaService) FinalizeNotaDebito(
 		fmt.Printf("   Generated Numero Control: %s\n", numeroControl)
 	}
 
-	// Step 4: Update nota status to finalized
+	// Step 4: Update nota status to finalized and apply it to the CCFs' balances
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	now := time.Now()
-	err = s.updateNotaStatusToFinalized(ctx, notaID, now)
+	err = s.updateNotaStatusToFinalized(ctx, tx, notaID, userID, now)
 	if err != nil {
 		return nil, fmt.Errorf("failed to finalize nota in database: %w", err)
 	}
+	if err := settleNotaCCFsTx(ctx, tx, models.StatementEntryNotaDebito, companyID, notaID); err != nil {
+		return nil, fmt.Errorf("failed to apply nota to CCF balances: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
 
 	// Step 5: Reload nota with updated data
 	nota, err = s.getNotaDebito(ctx, notaID, companyID)
@@ -461,6 +476,7 @@ func (s *NotaService) calculateTotals(
 func (s *NotaService) createNotaRecord(
 	ctx context.Context,
 	companyID string,
+	userID string,
 	req *models.CreateNotaDebitoRequest,
 	ccfs []*models.Invoice,
 	lineItems []models.NotaDebitoLineItem,
@@ -516,6 +532,7 @@ func (s *NotaService) createNotaRecord(
 		PaymentMethod:           firstCCF.PaymentMethod,
 		Status:                  "draft",
 		CreatedAt:               now,
+		CreatedBy:               nullIfBlank(&userID),
 	}
 
 	if req.Notes != "" {
@@ -532,10 +549,10 @@ func (s *NotaService) createNotaRecord(
 			client_tipo_contribuyente, client_tipo_persona,
 			subtotal, total_discount, total_taxes, total,
 			currency, payment_terms, payment_method,
-			status, created_at, notes
+			status, created_at, created_by, notes
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
-			$18, $19, $20, $21, $22, $23, $24, $25, $26, $27
+			$18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28
 		)
 	`
 
@@ -547,7 +564,7 @@ func (s *NotaService) createNotaRecord(
 		nota.ClientTipoContribuyente, nota.ClientTipoPersona,
 		nota.Subtotal, nota.TotalDiscount, nota.TotalTaxes, nota.Total,
 		nota.Currency, nota.PaymentTerms, nota.PaymentMethod,
-		nota.Status, nota.CreatedAt, nota.Notes,
+		nota.Status, nota.CreatedAt, nota.CreatedBy, nota.Notes,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert nota: %w", err)
@@ -676,7 +693,7 @@ func (s *NotaService) getNotaDebito(ctx context.Context, notaID, companyID strin
 			dte_numero_control, dte_codigo_generacion, dte_sello_recibido, 
 			dte_status, dte_hacienda_response, dte_submitted_at,
 			created_at, finalized_at, voided_at,
-			created_by, notes
+			created_by, finalized_by, notes
 		FROM notas_debito
 		WHERE id = $1 AND company_id = $2
 	`
@@ -694,7 +711,7 @@ func (s *NotaService) getNotaDebito(ctx context.Context, notaID, companyID strin
 		&nota.DteNumeroControl, &nota.DteCodigoGeneracion, &nota.DteSelloRecibido,
 		&nota.DteStatus, &nota.DteHaciendaResponse, &nota.DteSubmittedAt,
 		&nota.CreatedAt, &nota.FinalizedAt, &nota.VoidedAt,
-		&nota.CreatedBy, &nota.Notes,
+		&nota.CreatedBy, &nota.FinalizedBy, &nota.Notes,
 	)
 
 	if err == sql.ErrNoRows {
@@ -833,6 +850,15 @@ func (s *NotaService) generateNumeroControl(ctx context.Context, companyID strin
 	return numeroControl, nil
 }
 
+// PreviewNumeroControl returns the numero control the nota would be finalized with,
+// without saving it
+func (s *NotaService) PreviewNumeroControl(ctx context.Context, companyID string, nota *models.NotaDebito) (string, error) {
+	if nota.DteNumeroControl != nil {
+		return *nota.DteNumeroControl, nil
+	}
+	return s.generateNumeroControl(ctx, companyID, nota)
+}
+
 // saveNumeroControl saves the numero control to the nota
 func (s *NotaService) saveNumeroControl(ctx context.Context, notaID, numeroControl string) error {
 	query := `
@@ -858,15 +884,16 @@ func (s *NotaService) updateNotaStatus(ctx context.Context, notaID, status strin
 }
 
 // updateNotaStatusToFinalized updates the nota to finalized status
-func (s *NotaService) updateNotaStatusToFinalized(ctx context.Context, notaID string, finalizedAt time.Time) error {
+func (s *NotaService) updateNotaStatusToFinalized(ctx context.Context, tx *sql.Tx, notaID, userID string, finalizedAt time.Time) error {
 	query := `
 		UPDATE notas_debito
 		SET 
 			status = 'finalized',
-			finalized_at = $1
-		WHERE id = $2
+			finalized_at = $1,
+			finalized_by = $2
+		WHERE id = $3
 	`
 
-	_, err := database.DB.ExecContext(ctx, query, finalizedAt, notaID)
+	_, err := tx.ExecContext(ctx, query, finalizedAt, nullIfBlank(&userID), notaID)
 	return err
 }
@@ -0,0 +1,409 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"cuentas/internal/models"
+)
+
+// ============================================
+// ERRORS
+// ============================================
+
+var (
+	ErrPaymentNotFound      = errors.New("payment not found")
+	ErrPaymentAlreadyVoided = errors.New("payment is already voided")
+)
+
+// ============================================
+// SERVICE DEFINITION
+// ============================================
+
+// PaymentService records payments against finalized invoices after the fact,
+// voids mistaken ones, and reports accounts receivable aging. Every change keeps
+// the invoice's amount_paid, balance_due and payment_status, and (for credit
+// sales) the client's current_balance, in step.
+type PaymentService struct {
+	db *sql.DB
+}
+
+// NewPaymentService creates a new payment service
+func NewPaymentService(db *sql.DB) *PaymentService {
+	return &PaymentService{db: db}
+}
+
+const paymentColumns = `
+	p.id, p.company_id, p.invoice_id, i.invoice_number, i.client_id, i.client_name,
+	p.amount, p.payment_method, p.payment_reference, p.payment_date, p.notes,
+	p.created_by, p.created_at, p.voided_at, p.voided_by, p.void_reason
+`
+
+// isCreditTerms reports whether an invoice's balance is carried on the
+// client's account (clients.current_balance)
+func isCreditTerms(terms string) bool {
+	return terms == "cuenta" || terms == "net_30" || terms == "net_60"
+}
+
+// ============================================
+// PAYMENTS
+// ============================================
+
+// RecordPayment applies a payment to a finalized invoice. The amount cannot
+// exceed what is outstanding on it, notas included.
+func (s *PaymentService) RecordPayment(ctx context.Context, companyID, invoiceID, userID string, req *models.CreatePaymentRequest) (*models.Payment, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	amount := round(req.Amount)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	inv, err := lockInvoiceForPaymentTx(ctx, tx, companyID, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if inv.status != "finalized" || inv.dteType == "04" {
+		return nil, ErrInvalidInvoiceStatus
+	}
+	outstanding, _, err := invoiceOutstanding(ctx, tx, companyID, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if amount > outstanding {
+		return nil, ErrInsufficientPayment
+	}
+
+	var paymentID string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO payments (
+			company_id, invoice_id, amount, payment_method,
+			payment_reference, payment_date, created_by, notes
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, companyID, invoiceID, amount, req.PaymentMethod,
+		req.ReferenceNumber, *req.PaymentDate, nullIfBlank(&userID), req.Notes,
+	).Scan(&paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record payment: %w", err)
+	}
+
+	if err := settleInvoiceBalanceTx(ctx, tx, companyID, inv); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return s.GetPayment(ctx, companyID, paymentID)
+}
+
+// VoidPayment reverses a payment entered by mistake: the amount goes back onto
+// the invoice's balance and, for credit sales, the client's. The payment row
+// is kept, marked voided.
+func (s *PaymentService) VoidPayment(ctx context.Context, companyID, paymentID, userID string, req *models.VoidPaymentRequest) (*models.Payment, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		invoiceID string
+		amount    float64
+		voidedAt  sql.NullTime
+	)
+	err = tx.QueryRowContext(ctx, `
+		SELECT invoice_id, amount, voided_at
+		FROM payments
+		WHERE id = $1 AND company_id = $2
+		FOR UPDATE
+	`, paymentID, companyID).Scan(&invoiceID, &amount, &voidedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load payment: %w", err)
+	}
+	if voidedAt.Valid {
+		return nil, ErrPaymentAlreadyVoided
+	}
+	// A payment found on a bank statement has to be unmatched first
+	if err := checkPaymentUnreconciledTx(ctx, tx, models.BankItemPayment, paymentID); err != nil {
+		return nil, err
+	}
+
+	inv, err := lockInvoiceForPaymentTx(ctx, tx, companyID, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	// An invalidated invoice no longer carries a balance to restore
+	if inv.status == "void" {
+		return nil, ErrInvoiceAlreadyVoid
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE payments
+		SET voided_at = NOW(), voided_by = $1, void_reason = $2
+		WHERE id = $3
+	`, nullIfBlank(&userID), req.Reason, paymentID); err != nil {
+		return nil, fmt.Errorf("failed to void payment: %w", err)
+	}
+
+	if err := settleInvoiceBalanceTx(ctx, tx, companyID, inv); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return s.GetPayment(ctx, companyID, paymentID)
+}
+
+// GetPayment returns one payment
+func (s *PaymentService) GetPayment(ctx context.Context, companyID, paymentID string) (*models.Payment, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+paymentColumns+`
+		FROM payments p
+		JOIN invoices i ON i.id = p.invoice_id
+		WHERE p.id = $1 AND p.company_id = $2
+	`, paymentID, companyID)
+
+	payment, err := scanPayment(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	return payment, nil
+}
+
+// ListPayments returns payments, newest first
+func (s *PaymentService) ListPayments(ctx context.Context, companyID string, filters *models.PaymentFilters) ([]models.Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments p
+		JOIN invoices i ON i.id = p.invoice_id
+		WHERE p.company_id = $1
+	`
+	args := []interface{}{companyID}
+
+	if filters.ClientID != "" {
+		args = append(args, filters.ClientID)
+		query += fmt.Sprintf(" AND i.client_id = $%d", len(args))
+	}
+	if filters.InvoiceID != "" {
+		args = append(args, filters.InvoiceID)
+		query += fmt.Sprintf(" AND p.invoice_id = $%d", len(args))
+	}
+	if filters.FromDate != "" {
+		args = append(args, filters.FromDate)
+		query += fmt.Sprintf(" AND p.payment_date::date >= $%d", len(args))
+	}
+	if filters.ToDate != "" {
+		args = append(args, filters.ToDate)
+		query += fmt.Sprintf(" AND p.payment_date::date <= $%d", len(args))
+	}
+	if !filters.IncludeVoided {
+		query += " AND p.voided_at IS NULL"
+	}
+
+	query += " ORDER BY p.payment_date DESC, p.created_at DESC"
+	if filters.Limit > 0 {
+		args = append(args, filters.Limit, filters.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments: %w", err)
+	}
+	defer rows.Close()
+
+	payments := []models.Payment{}
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		payments = append(payments, *payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payments: %w", err)
+	}
+	return payments, nil
+}
+
+// ============================================
+// AGING
+// ============================================
+
+// GetAgingReport buckets what each client (or establishment) owed on the as-of
+// date by days past due. An invoice's outstanding amount is its total less the
+// unvoided payments dated on or before that day, adjusted by the notas
+// finalized by then; days past due count from its due_date, or from
+// finalization when it has none. Invalidated invoices and remisiones are left
+// out.
+func (s *PaymentService) GetAgingReport(ctx context.Context, companyID string, filters *models.AgingFilters) (*models.AgingReport, error) {
+	asOf := filters.AsOfDate.Format("2006-01-02")
+
+	query := `
+		SELECT i.id, i.client_id, i.client_name, i.establishment_id, e.nombre,
+		       COALESCE(i.due_date, i.finalized_at::date) AS due,
+		       i.total - COALESCE((
+		           SELECT SUM(p.amount) FROM payments p
+		           WHERE p.invoice_id = i.id
+		             AND p.voided_at IS NULL
+		             AND p.payment_date::date <= $2
+		       ), 0) AS outstanding
+		FROM invoices i
+		JOIN establishments e ON e.id = i.establishment_id
+		WHERE i.company_id = $1
+		  AND i.status = 'finalized'
+		  AND COALESCE(i.dte_type, '') <> '04'
+		  AND i.finalized_at::date <= $2
+	`
+	args := []interface{}{companyID, asOf}
+	if filters.ClientID != "" {
+		args = append(args, filters.ClientID)
+		query += fmt.Sprintf(" AND i.client_id = $%d", len(args))
+	}
+	if filters.EstablishmentID != "" {
+		args = append(args, filters.EstablishmentID)
+		query += fmt.Sprintf(" AND i.establishment_id = $%d", len(args))
+	}
+
+	adjustments, err := notaBalanceAdjustments(ctx, s.db, companyID, filters.ClientID, "", asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query receivables: %w", err)
+	}
+	defer rows.Close()
+
+	report := &models.AgingReport{Ledger: models.AgingLedgerReceivable, AsOfDate: asOf, GroupBy: filters.GroupBy, Rows: []models.AgingRow{}}
+	byKey := map[string]*models.AgingRow{}
+	for rows.Next() {
+		var (
+			invoiceID, clientID, clientName, establishmentID, establishmentName string
+			due                                                                 time.Time
+			outstanding                                                         float64
+		)
+		if err := rows.Scan(&invoiceID, &clientID, &clientName, &establishmentID, &establishmentName, &due, &outstanding); err != nil {
+			return nil, fmt.Errorf("failed to scan receivable: %w", err)
+		}
+		outstanding = round(outstanding + adjustments[invoiceID])
+		if outstanding <= 0 {
+			continue
+		}
+
+		id, name := clientID, clientName
+		if filters.GroupBy == models.AgingGroupByEstablishment {
+			id, name = establishmentID, establishmentName
+		}
+		row, ok := byKey[id]
+		if !ok {
+			row = &models.AgingRow{ID: id, Name: name}
+			byKey[id] = row
+		}
+
+		daysPastDue := int(filters.AsOfDate.Sub(due).Hours() / 24)
+		row.Add(daysPastDue, outstanding)
+		row.InvoiceCount++
+		report.Totals.Add(daysPastDue, outstanding)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating receivables: %w", err)
+	}
+
+	for _, row := range byKey {
+		row.AgingBuckets = roundBuckets(row.AgingBuckets)
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].Total != report.Rows[j].Total {
+			return report.Rows[i].Total > report.Rows[j].Total
+		}
+		return report.Rows[i].Name < report.Rows[j].Name
+	})
+	report.Totals = roundBuckets(report.Totals)
+
+	return report, nil
+}
+
+// ============================================
+// HELPERS
+// ============================================
+
+type paymentInvoice struct {
+	id           string
+	clientID     string
+	status       string
+	dteType      string
+	paymentTerms string
+	balanceDue   float64
+}
+
+func lockInvoiceForPaymentTx(ctx context.Context, tx *sql.Tx, companyID, invoiceID string) (*paymentInvoice, error) {
+	var (
+		inv          = &paymentInvoice{id: invoiceID}
+		dteType      sql.NullString
+		paymentTerms sql.NullString
+	)
+	err := tx.QueryRowContext(ctx, `
+		SELECT client_id, status, dte_type, payment_terms, balance_due
+		FROM invoices
+		WHERE id = $1 AND company_id = $2
+		FOR UPDATE
+	`, invoiceID, companyID).Scan(&inv.clientID, &inv.status, &dteType, &paymentTerms, &inv.balanceDue)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvoiceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load invoice: %w", err)
+	}
+	inv.dteType = dteType.String
+	inv.paymentTerms = paymentTerms.String
+	return inv, nil
+}
+
+func roundBuckets(b models.AgingBuckets) models.AgingBuckets {
+	return models.AgingBuckets{
+		Days0To30:  round(b.Days0To30),
+		Days31To60: round(b.Days31To60),
+		Days61To90: round(b.Days61To90),
+		Over90:     round(b.Over90),
+		Total:      round(b.Total),
+	}
+}
+
+func scanPayment(row rowScanner) (*models.Payment, error) {
+	var p models.Payment
+	err := row.Scan(
+		&p.ID, &p.CompanyID, &p.InvoiceID, &p.InvoiceNumber, &p.ClientID, &p.ClientName,
+		&p.Amount, &p.PaymentMethod, &p.PaymentReference, &p.PaymentDate, &p.Notes,
+		&p.CreatedBy, &p.CreatedAt, &p.VoidedAt, &p.VoidedBy, &p.VoidReason,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
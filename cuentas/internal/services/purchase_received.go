@@ -0,0 +1,443 @@
+package services
+
+import (
+	"context"
+	"cuentas/internal/codigos"
+	"cuentas/internal/database"
+	"cuentas/internal/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ============================================
+// ERRORS
+// ============================================
+
+var (
+	ErrReceivedDTEDuplicate     = errors.New("this DTE has already been loaded as a purchase")
+	ErrReceivedDTENotForCompany = errors.New("DTE was not issued to this company")
+	ErrReceivedDTELineNotFound  = errors.New("mapped num_item is not on the DTE")
+)
+
+// receivedIVARate is the IVA rate of the lines of a received DTE (13%)
+const receivedIVARate = 0.13
+
+// ============================================
+// CREATE FROM RECEIVED DTE
+// ============================================
+
+// EnsureReceivedDTENotLoaded fails with ErrReceivedDTEDuplicate when the
+// supplier DTE was already loaded. Checked before consulting Hacienda; the
+// unique index on dte_codigo_generacion still guards the insert.
+func (s *PurchaseService) EnsureReceivedDTENotLoaded(ctx context.Context, companyID, codigoGeneracion string) error {
+	var exists bool
+	err := database.DB.QueryRowContext(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM purchases
+            WHERE company_id = $1 AND dte_codigo_generacion = $2
+        )
+    `, companyID, strings.ToUpper(codigoGeneracion)).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check for duplicate DTE: %w", err)
+	}
+	if exists {
+		return ErrReceivedDTEDuplicate
+	}
+	return nil
+}
+
+// CreateFromReceivedDTE records a supplier's Factura or CCF as a finalized
+// regular purchase. The document must already be schema-validated and
+// confirmed with Hacienda. Lines mapped to inventory items (explicitly, or by
+// codigo matching an item SKU) generate PURCHASE inventory events in the same
+// transaction.
+func (s *PurchaseService) CreateFromReceivedDTE(
+	ctx context.Context,
+	companyID, userID string,
+	req *models.CreateReceivedDTEPurchaseRequest,
+	doc *models.ReceivedDTEDocument,
+) (*models.Purchase, error) {
+	// 1. Validate request
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// 2. The DTE must be addressed to us when it names a receptor
+	if doc.ReceptorNIT != nil {
+		var companyNIT string
+		err := database.DB.QueryRowContext(ctx, `SELECT nit FROM companies WHERE id = $1`, companyID).Scan(&companyNIT)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load company NIT: %w", err)
+		}
+		if strings.ReplaceAll(companyNIT, "-", "") != strings.ReplaceAll(*doc.ReceptorNIT, "-", "") {
+			return nil, ErrReceivedDTENotForCompany
+		}
+	} else if doc.TipoDte == codigos.DocTypeComprobanteCredito {
+		return nil, ErrReceivedDTENotForCompany
+	}
+
+	// 3. Link the registered supplier: the one given, or the one with the emisor's NIT
+	var supplier *models.Supplier
+	var err error
+	if req.SupplierID != nil && *req.SupplierID != "" {
+		supplier, err = getActiveSupplier(ctx, database.DB, companyID, *req.SupplierID)
+	} else {
+		supplier, err = findSupplierByNIT(ctx, database.DB, companyID, *doc.Supplier.DocumentNumber)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var supplierID *string
+	if supplier != nil {
+		supplierID = &supplier.ID
+	}
+
+	// 4. Resolve the inventory item of each line
+	itemIDs, err := s.resolveReceivedLineItems(ctx, companyID, req.Lines, doc.Lines)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5. Begin transaction
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 6. Validate establishment and POS
+	if err := s.validatePointOfSale(ctx, tx, companyID, req.EstablishmentID, req.PointOfSaleID); err != nil {
+		return nil, err
+	}
+	inventoryLocation := req.EstablishmentID
+	if req.InventoryEstablishmentID != nil && *req.InventoryEstablishmentID != "" {
+		inventoryLocation = *req.InventoryEstablishmentID
+		if err := checkInventoryLocationTx(ctx, tx, companyID, inventoryLocation); err != nil {
+			return nil, err
+		}
+	}
+	if inventoryLocation, err = bookingLocationTx(ctx, tx, companyID, inventoryLocation); err != nil {
+		return nil, err
+	}
+
+	// 7. Generate purchase number
+	purchaseNumber, err := s.generatePurchaseNumber(ctx, tx, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate purchase number: %w", err)
+	}
+
+	// 8. Build line items
+	lineItems := make([]models.PurchaseLineItem, 0, len(doc.Lines))
+	for _, line := range doc.Lines {
+		lineItems = append(lineItems, receivedLineItem(doc, line, itemIDs[line.NumItem]))
+	}
+
+	// 9. Payment: contado is settled on the document, credit is owed
+	paymentCondition := doc.PaymentCondition
+	paymentStatus := "pending"
+	amountPaid := 0.0
+	balanceDue := round(doc.Total)
+	if paymentCondition == 1 { // Contado
+		paymentStatus = "paid"
+		amountPaid = balanceDue
+		balanceDue = 0
+	}
+
+	now := time.Now()
+	dteStatus := doc.EstadoHacienda
+	haciendaResponse := string(doc.HaciendaResponse)
+	purchase := &models.Purchase{
+		ID:              strings.ToUpper(uuid.New().String()),
+		CompanyID:       companyID,
+		EstablishmentID: req.EstablishmentID,
+		PointOfSaleID:   req.PointOfSaleID,
+
+		PurchaseNumber: purchaseNumber,
+		PurchaseType:   "regular",
+		PurchaseDate:   doc.FechaEmision,
+
+		// Supplier snapshot as printed on the received DTE
+		SupplierID:                supplierID,
+		SupplierName:              &doc.Supplier.Name,
+		SupplierDocumentType:      &doc.Supplier.DocumentType,
+		SupplierDocumentNumber:    doc.Supplier.DocumentNumber,
+		SupplierNRC:               doc.Supplier.NRC,
+		SupplierActivityCode:      &doc.Supplier.ActivityCode,
+		SupplierActivityDesc:      &doc.Supplier.ActivityDesc,
+		SupplierAddressDept:       &doc.Supplier.Address.Department,
+		SupplierAddressMuni:       &doc.Supplier.Address.Municipality,
+		SupplierAddressComplement: &doc.Supplier.Address.Complement,
+		SupplierPhone:             doc.Supplier.Phone,
+		SupplierEmail:             doc.Supplier.Email,
+
+		Subtotal:          round(doc.Subtotal),
+		TotalDiscount:     round(doc.TotalDiscount),
+		TotalTaxes:        round(doc.TotalTaxes),
+		TotalNoSujeta:     round(doc.TotalNoSujeta),
+		TotalExenta:       round(doc.TotalExenta),
+		TotalGravada:      round(doc.TotalGravada),
+		IVARetained:       round(doc.IVARetained),
+		IncomeTaxRetained: round(doc.IncomeTaxRetained),
+		Total:             round(doc.Total),
+		Currency:          "USD",
+
+		PaymentCondition: &paymentCondition,
+		PaymentMethod:    doc.PaymentMethod,
+		PaymentReference: doc.PaymentReference,
+		PaymentTerm:      doc.PaymentTerm,
+		PaymentPeriod:    doc.PaymentPeriod,
+		PaymentStatus:    paymentStatus,
+		AmountPaid:       amountPaid,
+		BalanceDue:       balanceDue,
+
+		DteNumeroControl:    &doc.NumeroControl,
+		DteStatus:           &dteStatus,
+		DteHaciendaResponse: &haciendaResponse,
+		DteSelloRecibido:    nullIfBlank(&doc.SelloRecibido),
+		DteType:             doc.TipoDte,
+		DteCodigoGeneracion: &doc.CodigoGeneracion,
+		DteUnsigned:         doc.DocumentJSON,
+		DteSigned:           doc.Signed,
+
+		// The supplier already issued it: nothing to sign or transmit
+		Status:      "finalized",
+		CreatedAt:   now,
+		FinalizedAt: &now,
+		CreatedBy:   nullIfBlank(&userID),
+		Notes:       req.Notes,
+	}
+
+	// Due date for credit purchases: the DTE's plazo, else the supplier's terms
+	if paymentCondition == 2 {
+		if doc.PaymentPeriod != nil {
+			dueDate := doc.FechaEmision.AddDate(0, 0, *doc.PaymentPeriod)
+			purchase.DueDate = &dueDate
+		} else if supplier != nil && supplier.PaymentTermDays > 0 {
+			dueDate := doc.FechaEmision.AddDate(0, 0, supplier.PaymentTermDays)
+			purchase.DueDate = &dueDate
+		}
+	}
+
+	// 10. Insert purchase
+	purchaseID, err := s.insertPurchase(ctx, tx, purchase)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, ErrReceivedDTEDuplicate
+		}
+		return nil, fmt.Errorf("failed to insert purchase: %w", err)
+	}
+
+	// 11. Insert line items, their IVA, and the inventory purchase events
+	inventory := NewInventoryService(database.DB)
+	nationality := models.SupplierNacional
+	for i := range lineItems {
+		lineItems[i].PurchaseID = purchaseID
+		lineItems[i].LineNumber = i + 1
+
+		if err := s.insertPurchaseLineItem(ctx, tx, &lineItems[i]); err != nil {
+			return nil, fmt.Errorf("failed to insert line item %d: %w", i+1, err)
+		}
+		for j := range lineItems[i].Taxes {
+			lineItems[i].Taxes[j].LineItemID = lineItems[i].ID
+			if err := s.insertPurchaseLineItemTax(ctx, tx, &lineItems[i].Taxes[j]); err != nil {
+				return nil, fmt.Errorf("failed to insert line item %d tax: %w", i+1, err)
+			}
+		}
+
+		if lineItems[i].ItemID == nil {
+			continue
+		}
+		eventReq := receivedPurchaseEventRequest(doc, purchaseID, supplierID, inventoryLocation, &lineItems[i])
+		if err := eventReq.Validate(); err != nil {
+			return nil, fmt.Errorf("line %d: validation failed: %w", i+1, err)
+		}
+		if _, err := inventory.recordPurchaseTx(ctx, tx, companyID, *lineItems[i].ItemID, userID, nationality, eventReq); err != nil {
+			return nil, fmt.Errorf("failed to record inventory purchase for line %d: %w", i+1, err)
+		}
+	}
+
+	// 12. Commit transaction
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// 13. Reload purchase with all relations
+	return s.GetPurchaseByID(ctx, companyID, purchaseID)
+}
+
+// resolveReceivedLineItems maps DTE line numbers to inventory item IDs:
+// explicit mappings first, then the line's codigo against active item SKUs.
+func (s *PurchaseService) resolveReceivedLineItems(
+	ctx context.Context,
+	companyID string,
+	mappings []models.ReceivedDTELineMapping,
+	lines []models.ReceivedDTELine,
+) (map[int]string, error) {
+	onDocument := make(map[int]bool, len(lines))
+	for _, line := range lines {
+		onDocument[line.NumItem] = true
+	}
+
+	itemIDs := make(map[int]string, len(lines))
+	for _, mapping := range mappings {
+		if !onDocument[mapping.NumItem] {
+			return nil, fmt.Errorf("%w: %d", ErrReceivedDTELineNotFound, mapping.NumItem)
+		}
+		var id string
+		err := database.DB.QueryRowContext(ctx, `
+            SELECT id FROM inventory_items
+            WHERE id = $1 AND company_id = $2 AND active = true
+        `, mapping.ItemID, companyID).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", ErrInventoryItemNotFound, mapping.ItemID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load item %s: %w", mapping.ItemID, err)
+		}
+		itemIDs[mapping.NumItem] = id
+	}
+
+	for _, line := range lines {
+		if _, mapped := itemIDs[line.NumItem]; mapped || line.Codigo == nil || *line.Codigo == "" {
+			continue
+		}
+		var id string
+		err := database.DB.QueryRowContext(ctx, `
+            SELECT id FROM inventory_items
+            WHERE company_id = $1 AND sku = $2 AND active = true
+        `, companyID, strings.ToUpper(strings.TrimSpace(*line.Codigo))).Scan(&id)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to match item by codigo %s: %w", *line.Codigo, err)
+		}
+		itemIDs[line.NumItem] = id
+	}
+
+	return itemIDs, nil
+}
+
+// receivedLineItem builds the purchase line of a DTE line
+func receivedLineItem(doc *models.ReceivedDTEDocument, line models.ReceivedDTELine, itemID string) models.PurchaseLineItem {
+	lineSubtotal := round(line.PrecioUni * line.Cantidad)
+	lineTotal := round(line.Amount)
+	if doc.TipoDte == codigos.DocTypeComprobanteCredito {
+		lineTotal = round(line.Amount + line.IVA) // CCF prices exclude IVA
+	}
+
+	item := models.PurchaseLineItem{
+		ID:            strings.ToUpper(uuid.New().String()),
+		ItemCode:      line.Codigo,
+		ItemName:      line.Descripcion,
+		ItemType:      line.TipoItem,
+		UnitOfMeasure: fmt.Sprintf("%d", line.UniMedida),
+
+		Quantity:     line.Cantidad,
+		UnitPrice:    line.PrecioUni,
+		LineSubtotal: lineSubtotal,
+
+		DiscountAmount: round(line.MontoDescu),
+
+		TaxableAmount: round(line.Amount),
+		TotalTaxes:    round(line.IVA),
+		LineTotal:     lineTotal,
+
+		CreatedAt: time.Now(),
+		Taxes:     []models.PurchaseLineItemTax{},
+	}
+	if itemID != "" {
+		item.ItemID = &itemID
+	}
+
+	if line.IVA > 0 {
+		name, _ := codigos.GetTributoName(codigos.TributoIVA13)
+		item.Taxes = append(item.Taxes, models.PurchaseLineItemTax{
+			ID:          strings.ToUpper(uuid.New().String()),
+			TributoCode: codigos.TributoIVA13,
+			TributoName: name,
+			TaxRate:     receivedIVARate,
+			TaxableBase: round(line.Amount),
+			TaxAmount:   round(line.IVA),
+			CreatedAt:   time.Now(),
+		})
+	}
+
+	return item
+}
+
+// receivedPurchaseEventRequest is the inventory PURCHASE event of a mapped
+// line, received at the given location. Its cost is the line amount: without
+// IVA on a CCF (credited), with IVA on a Factura (not creditable).
+func receivedPurchaseEventRequest(doc *models.ReceivedDTEDocument, purchaseID string, supplierID *string, establishmentID string, line *models.PurchaseLineItem) *models.RecordPurchaseRequest {
+	referenceType := "purchase"
+	costSourceRef := doc.CodigoGeneracion
+	return &models.RecordPurchaseRequest{
+		Quantity:        line.Quantity,
+		UnitCost:        models.NewMoney(line.TaxableAmount / line.Quantity),
+		EstablishmentID: establishmentID,
+		DocumentType:    doc.TipoDte,
+		DocumentNumber:  doc.NumeroControl,
+		SupplierID:      supplierID,
+		SupplierName:    doc.Supplier.Name,
+		SupplierNIT:     doc.Supplier.DocumentNumber,
+		CostSourceRef:   &costSourceRef,
+		ReferenceType:   &referenceType,
+		ReferenceID:     &purchaseID,
+	}
+}
+
+// insertPurchaseLineItemTax inserts a tax on a purchase line item
+func (s *PurchaseService) insertPurchaseLineItemTax(ctx context.Context, tx *sql.Tx, tax *models.PurchaseLineItemTax) error {
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO purchase_line_item_taxes (
+            id, line_item_id, tributo_code, tributo_name,
+            tax_rate, taxable_base, tax_amount, created_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `,
+		tax.ID, tax.LineItemID, tax.TributoCode, tax.TributoName,
+		tax.TaxRate, tax.TaxableBase, tax.TaxAmount, tax.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert purchase line item tax: %w", err)
+	}
+	return nil
+}
+
+// findSupplierByNIT returns the company's active supplier with this NIT, or
+// nil when none is registered
+func findSupplierByNIT(ctx context.Context, db *sql.DB, companyID, nit string) (*models.Supplier, error) {
+	row := db.QueryRowContext(ctx, `
+		SELECT `+supplierColumns+`
+		FROM suppliers
+		WHERE company_id = $1 AND document_type = '36'
+		  AND REPLACE(document_number, '-', '') = $2
+		  AND active = true
+		LIMIT 1
+	`, companyID, strings.ReplaceAll(nit, "-", ""))
+
+	supplier, err := scanSupplier(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find supplier by NIT: %w", err)
+	}
+	return supplier, nil
+}
+
+// nullableJSON passes a JSON document to a JSONB column, NULL when empty
+func nullableJSON(doc []byte) *string {
+	if len(doc) == 0 {
+		return nil
+	}
+	s := string(doc)
+	return &s
+}
@@ -48,31 +48,46 @@ func (s *PurchaseService) CreateFSE(ctx context.Context, companyID string, req *
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
-	// 2. Begin transaction
+	// 2. Resolve a registered supplier into the FSE supplier block
+	var supplierID *string
+	if req.SupplierID != nil && *req.SupplierID != "" {
+		supplier, err := getActiveSupplier(ctx, database.DB, companyID, *req.SupplierID)
+		if err != nil {
+			return nil, err
+		}
+		info, err := supplier.FSESupplierInfo()
+		if err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+		req.Supplier = info
+		supplierID = &supplier.ID
+	}
+
+	// 3. Begin transaction
 	tx, err := database.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// 3. Validate establishment and POS
+	// 4. Validate establishment and POS
 	if err := s.validatePointOfSale(ctx, tx, companyID, req.EstablishmentID, req.PointOfSaleID); err != nil {
 		return nil, err
 	}
 
-	// 4. Generate purchase number
+	// 5. Generate purchase number
 	purchaseNumber, err := s.generatePurchaseNumber(ctx, tx, companyID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate purchase number: %w", err)
 	}
 
-	// 5. Process line items
+	// 6. Process line items
 	lineItems, subtotal, totalDiscount, err := s.processLineItemsFSE(ctx, tx, req.LineItems)
 	if err != nil {
 		return nil, fmt.Errorf("failed to process line items: %w", err)
 	}
 
-	// 6. Calculate totals
+	// 7. Calculate totals
 	total := round(subtotal - totalDiscount - req.IVARetained - req.IncomeTaxRetained)
 	balanceDue := total
 
@@ -81,7 +96,7 @@ func (s *PurchaseService) CreateFSE(ctx context.Context, companyID string, req *
 		balanceDue = 0
 	}
 
-	// 7. Create purchase record
+	// 8. Create purchase record
 	purchase := &models.Purchase{
 		ID:              strings.ToUpper(uuid.New().String()), // This is also codigoGeneracion
 		CompanyID:       companyID,
@@ -92,8 +107,8 @@ func (s *PurchaseService) CreateFSE(ctx context.Context, companyID string, req *
 		PurchaseType:   "fse",
 		PurchaseDate:   req.PurchaseDate.Time,
 
-		// FSE: Embedded supplier info (no supplier_id)
-		SupplierID:                nil,
+		// FSE: supplier snapshot, linked to the registry when supplier_id was given
+		SupplierID:                supplierID,
 		SupplierName:              &req.Supplier.Name,
 		SupplierDocumentType:      &req.Supplier.DocumentType,
 		SupplierDocumentNumber:    req.Supplier.DocumentNumber,
@@ -140,13 +155,13 @@ func (s *PurchaseService) CreateFSE(ctx context.Context, companyID string, req *
 		purchase.DueDate = &dueDate
 	}
 
-	// 8. Insert purchase
+	// 9. Insert purchase
 	purchaseID, err := s.insertPurchase(ctx, tx, purchase)
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert purchase: %w", err)
 	}
 
-	// 9. Insert line items
+	// 10. Insert line items
 	for i := range lineItems {
 		lineItems[i].PurchaseID = purchaseID
 		lineItems[i].LineNumber = i + 1
@@ -156,12 +171,12 @@ func (s *PurchaseService) CreateFSE(ctx context.Context, companyID string, req *
 		}
 	}
 
-	// 10. Commit transaction
+	// 11. Commit transaction
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	// 11. Reload purchase with all relations
+	// 12. Reload purchase with all relations
 	purchase, err = s.GetPurchaseByID(ctx, companyID, purchaseID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to reload purchase: %w", err)
@@ -193,7 +208,7 @@ func (s *PurchaseService) processLineItemsFSE(
 		lineTotal := round(taxableAmount + lineTaxTotal)
 
 		// 2. Convert unit of measure to string
-		unitOfMeasure := fmt.Sprintf("%d", reqItem.UnitOfMeasure)
+		unitOfMeasure := reqItem.UnitOfMeasure
 
 		// 3. Create line item
 		lineItem := models.PurchaseLineItem{
@@ -251,7 +266,12 @@ func (s *PurchaseService) insertPurchase(ctx context.Context, tx *sql.Tx, purcha
             payment_term, payment_period, payment_status,
             amount_paid, balance_due, due_date,
             dte_type, status,
-            created_at, notes
+            created_at, notes,
+            total_no_sujeta, total_exenta, total_gravada,
+            dte_codigo_generacion, dte_numero_control, dte_status,
+            dte_hacienda_response, dte_sello_recibido,
+            dte_unsigned, dte_signed,
+            finalized_at, created_by
         ) VALUES (
             $1, $2, $3, $4,
             $5, $6, $7,
@@ -267,7 +287,12 @@ func (s *PurchaseService) insertPurchase(ctx context.Context, tx *sql.Tx, purcha
             $31, $32, $33,
             $34, $35, $36,
             $37, $38,
-            $39, $40
+            $39, $40,
+            $41, $42, $43,
+            $44, $45, $46,
+            $47, $48,
+            $49, $50,
+            $51, $52
         ) RETURNING id
     `
 
@@ -288,6 +313,11 @@ func (s *PurchaseService) insertPurchase(ctx context.Context, tx *sql.Tx, purcha
 		purchase.AmountPaid, purchase.BalanceDue, purchase.DueDate,
 		purchase.DteType, purchase.Status,
 		purchase.CreatedAt, purchase.Notes,
+		purchase.TotalNoSujeta, purchase.TotalExenta, purchase.TotalGravada,
+		purchase.DteCodigoGeneracion, purchase.DteNumeroControl, purchase.DteStatus,
+		purchase.DteHaciendaResponse, purchase.DteSelloRecibido,
+		nullableJSON(purchase.DteUnsigned), purchase.DteSigned,
+		purchase.FinalizedAt, purchase.CreatedBy,
 	).Scan(&id)
 
 	if err != nil {
@@ -372,12 +402,14 @@ func (s *PurchaseService) getPurchaseHeader(ctx context.Context, companyID, purc
             supplier_phone, supplier_email,
             subtotal, total_discount, discount_percentage,
             total_taxes, iva_retained, income_tax_retained, total,
+            total_no_sujeta, total_exenta, total_gravada,
             currency,
             payment_condition, payment_method, payment_reference,
             payment_term, payment_period, payment_status,
             amount_paid, balance_due, due_date,
             dte_numero_control, dte_status, dte_hacienda_response,
             dte_sello_recibido, dte_submitted_at, dte_type,
+            dte_codigo_generacion,
             status,
             created_at, finalized_at, voided_at,
             created_by, voided_by, notes
@@ -396,12 +428,14 @@ func (s *PurchaseService) getPurchaseHeader(ctx context.Context, companyID, purc
 		&purchase.SupplierPhone, &purchase.SupplierEmail,
 		&purchase.Subtotal, &purchase.TotalDiscount, &purchase.DiscountPercentage,
 		&purchase.TotalTaxes, &purchase.IVARetained, &purchase.IncomeTaxRetained, &purchase.Total,
+		&purchase.TotalNoSujeta, &purchase.TotalExenta, &purchase.TotalGravada,
 		&purchase.Currency,
 		&purchase.PaymentCondition, &purchase.PaymentMethod, &purchase.PaymentReference,
 		&purchase.PaymentTerm, &purchase.PaymentPeriod, &purchase.PaymentStatus,
 		&purchase.AmountPaid, &purchase.BalanceDue, &purchase.DueDate,
 		&purchase.DteNumeroControl, &purchase.DteStatus, &purchase.DteHaciendaResponse,
 		&purchase.DteSelloRecibido, &purchase.DteSubmittedAt, &purchase.DteType,
+		&purchase.DteCodigoGeneracion,
 		&purchase.Status,
 		&purchase.CreatedAt, &purchase.FinalizedAt, &purchase.VoidedAt,
 		&purchase.CreatedBy, &purchase.VoidedBy, &purchase.Notes,
@@ -481,12 +515,14 @@ func (s *PurchaseService) ListPurchases(ctx context.Context, companyID string, l
             supplier_phone, supplier_email,
             subtotal, total_discount, discount_percentage,
             total_taxes, iva_retained, income_tax_retained, total,
+            total_no_sujeta, total_exenta, total_gravada,
             currency,
             payment_condition, payment_method, payment_reference,
             payment_term, payment_period, payment_status,
             amount_paid, balance_due, due_date,
             dte_numero_control, dte_status, dte_hacienda_response,
             dte_sello_recibido, dte_submitted_at, dte_type,
+            dte_codigo_generacion,
             status,
             created_at, finalized_at, voided_at,
             created_by, voided_by, notes
@@ -515,12 +551,14 @@ func (s *PurchaseService) ListPurchases(ctx context.Context, companyID string, l
 			&p.SupplierPhone, &p.SupplierEmail,
 			&p.Subtotal, &p.TotalDiscount, &p.DiscountPercentage,
 			&p.TotalTaxes, &p.IVARetained, &p.IncomeTaxRetained, &p.Total,
+			&p.TotalNoSujeta, &p.TotalExenta, &p.TotalGravada,
 			&p.Currency,
 			&p.PaymentCondition, &p.PaymentMethod, &p.PaymentReference,
 			&p.PaymentTerm, &p.PaymentPeriod, &p.PaymentStatus,
 			&p.AmountPaid, &p.BalanceDue, &p.DueDate,
 			&p.DteNumeroControl, &p.DteStatus, &p.DteHaciendaResponse,
 			&p.DteSelloRecibido, &p.DteSubmittedAt, &p.DteType,
+			&p.DteCodigoGeneracion,
 			&p.Status,
 			&p.CreatedAt, &p.FinalizedAt, &p.VoidedAt,
 			&p.CreatedBy, &p.VoidedBy, &p.Notes,
@@ -652,7 +690,7 @@ func (s *PurchaseService) FinalizePurchase(ctx context.Context, companyID, purch
 	_, err = tx.ExecContext(ctx, updateQuery,
 		numeroControl,
 		now,
-		userID,
+		nullIfBlank(&userID),
 		purchaseID,
 		companyID,
 	)
@@ -678,6 +716,18 @@ func (s *PurchaseService) FinalizePurchase(ctx context.Context, companyID, purch
 // GENERATE NUMERO CONTROL
 // ============================================
 
+// PreviewNumeroControl returns the numero control the purchase would be finalized
+// with. It runs in a read-only transaction and reserves nothing.
+func (s *PurchaseService) PreviewNumeroControl(ctx context.Context, purchase *models.Purchase) (string, error) {
+	tx, err := database.DB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	return s.generateNumeroControl(ctx, tx, purchase.EstablishmentID, purchase.PointOfSaleID, "14")
+}
+
 // generateNumeroControl generates a numero control for purchase DTE
 func (s *PurchaseService) generateNumeroControl(ctx context.Context, tx *sql.Tx, establishmentID, posID, tipoDte string) (string, error) {
 	// Load establishment and POS codes
@@ -745,12 +795,14 @@ func (s *PurchaseService) getPurchaseForUpdate(ctx context.Context, tx *sql.Tx,
             supplier_phone, supplier_email,
             subtotal, total_discount, discount_percentage,
             total_taxes, iva_retained, income_tax_retained, total,
+            total_no_sujeta, total_exenta, total_gravada,
             currency,
             payment_condition, payment_method, payment_reference,
             payment_term, payment_period, payment_status,
             amount_paid, balance_due, due_date,
             dte_numero_control, dte_status, dte_hacienda_response,
             dte_sello_recibido, dte_submitted_at, dte_type,
+            dte_codigo_generacion,
             status,
             created_at, finalized_at, voided_at,
             created_by, voided_by, notes
@@ -770,12 +822,14 @@ func (s *PurchaseService) getPurchaseForUpdate(ctx context.Context, tx *sql.Tx,
 		&purchase.SupplierPhone, &purchase.SupplierEmail,
 		&purchase.Subtotal, &purchase.TotalDiscount, &purchase.DiscountPercentage,
 		&purchase.TotalTaxes, &purchase.IVARetained, &purchase.IncomeTaxRetained, &purchase.Total,
+		&purchase.TotalNoSujeta, &purchase.TotalExenta, &purchase.TotalGravada,
 		&purchase.Currency,
 		&purchase.PaymentCondition, &purchase.PaymentMethod, &purchase.PaymentReference,
 		&purchase.PaymentTerm, &purchase.PaymentPeriod, &purchase.PaymentStatus,
 		&purchase.AmountPaid, &purchase.BalanceDue, &purchase.DueDate,
 		&purchase.DteNumeroControl, &purchase.DteStatus, &purchase.DteHaciendaResponse,
 		&purchase.DteSelloRecibido, &purchase.DteSubmittedAt, &purchase.DteType,
+		&purchase.DteCodigoGeneracion,
 		&purchase.Status,
 		&purchase.CreatedAt, &purchase.FinalizedAt, &purchase.VoidedAt,
 		&purchase.CreatedBy, &purchase.VoidedBy, &purchase.Notes,
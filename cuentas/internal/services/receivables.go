@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"cuentas/internal/models"
+)
+
+// ============================================
+// RECEIVABLES
+// ============================================
+
+// What a client owes on a finalized invoice is its total less its unvoided
+// payments, plus the notas de débito and less the notas de crédito applied to
+// it. A nota is applied to the CCFs its line items reference, in proportion to
+// each CCF's line totals, so taxes follow the lines they belong to. The aging
+// report, the payment cap and the client statement all read balances this way.
+
+// notaApplication is a finalized nota with its total spread over the CCFs it
+// references
+type notaApplication struct {
+	id            string
+	number        string
+	numeroControl sql.NullString
+	finalizedAt   time.Time
+	date          string // finalization date, YYYY-MM-DD
+	total         float64
+	applications  []models.StatementApplication
+}
+
+// notaTables returns the tables of a kind of nota
+// (models.StatementEntryNotaDebito or models.StatementEntryNotaCredito)
+func notaTables(kind string) (notaTable, lineTable, notaColumn string) {
+	if kind == models.StatementEntryNotaCredito {
+		return "notas_credito", "notas_credito_line_items", "nota_credito_id"
+	}
+	return "notas_debito", "nota_debito_line_items", "nota_debito_id"
+}
+
+// loadNotaApplications loads a company's finalized notas of a kind. When not
+// empty, clientID keeps the client's notas, invoiceID the notas referencing
+// that CCF and toDate those finalized on or before it.
+func loadNotaApplications(ctx context.Context, q ledgerQuerier, kind, companyID, clientID, invoiceID, toDate string) ([]notaApplication, error) {
+	notaTable, lineTable, notaColumn := notaTables(kind)
+
+	query := fmt.Sprintf(`
+		SELECT n.id, n.nota_number, n.dte_numero_control, n.finalized_at,
+		       to_char(n.finalized_at::date, 'YYYY-MM-DD'), n.total,
+		       li.related_ccf_id, li.related_ccf_number, COALESCE(SUM(li.line_total), 0)
+		FROM %[1]s n
+		LEFT JOIN %[2]s li ON li.%[3]s = n.id
+		WHERE n.company_id = $1
+		  AND n.status = 'finalized'
+	`, notaTable, lineTable, notaColumn)
+	args := []interface{}{companyID}
+	if clientID != "" {
+		args = append(args, clientID)
+		query += fmt.Sprintf(" AND n.client_id = $%d", len(args))
+	}
+	if invoiceID != "" {
+		args = append(args, invoiceID)
+		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM %s r WHERE r.%s = n.id AND r.related_ccf_id = $%d)",
+			lineTable, notaColumn, len(args))
+	}
+	if toDate != "" {
+		args = append(args, toDate)
+		query += fmt.Sprintf(" AND n.finalized_at::date <= $%d", len(args))
+	}
+	query += `
+		GROUP BY n.id, n.nota_number, n.dte_numero_control, n.finalized_at, n.total,
+		         li.related_ccf_id, li.related_ccf_number
+		ORDER BY n.id, li.related_ccf_number
+	`
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", notaTable, err)
+	}
+	defer rows.Close()
+
+	notas := []notaApplication{}
+	lineTotals := [][]float64{}
+	byID := map[string]int{}
+	for rows.Next() {
+		var (
+			nota             notaApplication
+			ccfID, ccfNumber sql.NullString
+			lineTotal        float64
+		)
+		if err := rows.Scan(
+			&nota.id, &nota.number, &nota.numeroControl, &nota.finalizedAt,
+			&nota.date, &nota.total, &ccfID, &ccfNumber, &lineTotal,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", notaTable, err)
+		}
+
+		idx, ok := byID[nota.id]
+		if !ok {
+			nota.total = round(nota.total)
+			idx = len(notas)
+			byID[nota.id] = idx
+			notas = append(notas, nota)
+			lineTotals = append(lineTotals, nil)
+		}
+		if !ccfID.Valid {
+			continue
+		}
+		notas[idx].applications = append(notas[idx].applications, models.StatementApplication{
+			InvoiceID:     ccfID.String,
+			InvoiceNumber: ccfNumber.String,
+		})
+		lineTotals[idx] = append(lineTotals[idx], lineTotal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating %s: %w", notaTable, err)
+	}
+
+	for i := range notas {
+		allocateStatementAmount(notas[i].total, notas[i].applications, lineTotals[i])
+	}
+	return notas, nil
+}
+
+// notaBalanceAdjustments returns, keyed by invoice ID, what finalized notas
+// add to (débito) or take off (crédito) each CCF's balance. Filters are those
+// of loadNotaApplications.
+func notaBalanceAdjustments(ctx context.Context, q ledgerQuerier, companyID, clientID, invoiceID, toDate string) (map[string]float64, error) {
+	adjustments := map[string]float64{}
+	for _, kind := range []string{models.StatementEntryNotaDebito, models.StatementEntryNotaCredito} {
+		notas, err := loadNotaApplications(ctx, q, kind, companyID, clientID, invoiceID, toDate)
+		if err != nil {
+			return nil, err
+		}
+		for _, nota := range notas {
+			for _, applied := range nota.applications {
+				if kind == models.StatementEntryNotaCredito {
+					adjustments[applied.InvoiceID] -= applied.Amount
+				} else {
+					adjustments[applied.InvoiceID] += applied.Amount
+				}
+			}
+		}
+	}
+	return adjustments, nil
+}
+
+// invoiceOutstanding returns what is owed on an invoice now, and what has been
+// paid on it
+func invoiceOutstanding(ctx context.Context, q ledgerQuerier, companyID, invoiceID string) (outstanding, paid float64, err error) {
+	var total float64
+	err = q.QueryRowContext(ctx, `
+		SELECT i.total, COALESCE((
+		           SELECT SUM(p.amount) FROM payments p
+		           WHERE p.invoice_id = i.id AND p.voided_at IS NULL
+		       ), 0)
+		FROM invoices i
+		WHERE i.id = $1 AND i.company_id = $2
+	`, invoiceID, companyID).Scan(&total, &paid)
+	if err == sql.ErrNoRows {
+		return 0, 0, ErrInvoiceNotFound
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load invoice balance: %w", err)
+	}
+
+	adjustments, err := notaBalanceAdjustments(ctx, q, companyID, "", invoiceID, "")
+	if err != nil {
+		return 0, 0, err
+	}
+	return round(total - paid + adjustments[invoiceID]), round(paid), nil
+}
+
+// settleInvoiceBalanceTx brings a locked invoice's amount_paid, balance_due
+// and payment_status in line with its payments and notas and, for credit
+// sales, moves the client's balance by the change in balance due
+func settleInvoiceBalanceTx(ctx context.Context, tx *sql.Tx, companyID string, inv *paymentInvoice) error {
+	outstanding, paid, err := invoiceOutstanding(ctx, tx, companyID, inv.id)
+	if err != nil {
+		return err
+	}
+	balanceDue := math.Max(outstanding, 0)
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE invoices
+		SET amount_paid = $1,
+		    balance_due = $2,
+		    payment_status = CASE
+		        WHEN $2 <= 0 THEN 'paid'
+		        WHEN $1 <= 0 THEN 'unpaid'
+		        ELSE 'partial'
+		    END
+		WHERE id = $3
+	`, paid, balanceDue, inv.id)
+	if err != nil {
+		return fmt.Errorf("failed to update invoice balance: %w", err)
+	}
+
+	change := round(balanceDue - inv.balanceDue)
+	inv.balanceDue = balanceDue
+	if change == 0 || !isCreditTerms(inv.paymentTerms) {
+		return nil
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE clients
+		SET current_balance = GREATEST(current_balance + $1, 0),
+		    credit_status = CASE
+		        WHEN credit_status = 'suspended' THEN credit_status
+		        WHEN GREATEST(current_balance + $1, 0) > credit_limit THEN 'over_limit'
+		        ELSE 'good_standing'
+		    END
+		WHERE id = $2
+	`, change, inv.clientID)
+	if err != nil {
+		return fmt.Errorf("failed to update client balance: %w", err)
+	}
+	return nil
+}
+
+// settleNotaCCFsTx settles the balance of every finalized CCF a nota
+// references, once the nota has been finalized or voided. The CCFs are locked
+// in ID order so notas sharing CCFs cannot deadlock.
+func settleNotaCCFsTx(ctx context.Context, tx *sql.Tx, kind, companyID, notaID string) error {
+	_, lineTable, notaColumn := notaTables(kind)
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		SELECT DISTINCT related_ccf_id FROM %s WHERE %s = $1 ORDER BY related_ccf_id
+	`, lineTable, notaColumn), notaID)
+	if err != nil {
+		return fmt.Errorf("failed to query nota CCFs: %w", err)
+	}
+	var ccfIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan nota CCF: %w", err)
+		}
+		ccfIDs = append(ccfIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating nota CCFs: %w", err)
+	}
+
+	for _, id := range ccfIDs {
+		inv, err := lockInvoiceForPaymentTx(ctx, tx, companyID, id)
+		if err != nil {
+			return err
+		}
+		if inv.status != "finalized" {
+			continue
+		}
+		if err := settleInvoiceBalanceTx(ctx, tx, companyID, inv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -26,6 +26,7 @@ var (
 	ErrPurchaseNotEligible      = errors.New("purchase is not eligible for retention")
 	ErrPurchaseNotFinalized     = errors.New("purchase must be finalized before creating retention")
 	ErrInvalidRetentionRate     = errors.New("invalid retention rate: must be 1.00, 2.00, or 13.00")
+	ErrRetentionAlreadyIssued   = errors.New("retention has already been accepted by Hacienda")
 )
 
 // ============================================
@@ -101,12 +102,22 @@ func (s *RetentionService) ValidateRetentionEligibility(
 	}
 
 	// 8. Validate: Supplier must be formal (have NIT and NRC)
-	if purchase.SupplierNRC == nil || *purchase.SupplierNRC == "" {
+	// NIT/NRC come from the registered supplier or the purchase snapshot
+	supplierNIT, supplierNRC, err := s.getSupplierTaxIDs(ctx, purchase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load supplier: %w", err)
+	}
+	if supplierNRC == nil || *supplierNRC == "" {
 		result.Errors = append(result.Errors, "supplier must have NRC (formal IVA contributor)")
 	}
+	if supplierNIT == nil {
+		result.Errors = append(result.Errors, "supplier must have NIT")
+	}
 
-	// For non-FSE, we'd need supplier NIT from clients table
-	// For now, we check if supplier_nrc is present as proxy for formality
+	// The retention references the supplier's DTE by numero de control
+	if purchase.DteNumeroControl == nil || *purchase.DteNumeroControl == "" {
+		result.Errors = append(result.Errors, "purchase must have a DTE numero de control")
+	}
 
 	// 9. Validate: Purchase must have taxable amount (venta gravada)
 	// For FSE: no IVA, so no retention
@@ -155,7 +166,7 @@ func (s *RetentionService) CreateRetention(
 		return nil, fmt.Errorf("failed to validate eligibility: %w", err)
 	}
 	if !validation.CanCreateRetention {
-		return nil, fmt.Errorf("%s: %s", ErrPurchaseNotEligible.Error(), strings.Join(validation.Errors, "; "))
+		return nil, fmt.Errorf("%w: %s", ErrPurchaseNotEligible, strings.Join(validation.Errors, "; "))
 	}
 
 	// 3. Begin transaction
@@ -178,6 +189,11 @@ func (s *RetentionService) CreateRetention(
 		return nil, fmt.Errorf("failed to load purchase: %w", err)
 	}
 
+	supplierNIT, supplierNRC, err := s.getSupplierTaxIDs(ctx, purchase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load supplier: %w", err)
+	}
+
 	// 6. Calculate retention amounts
 	// For FSE: would use subtotal (but FSE can't have retention)
 	// For regular: would use ventaGravada field
@@ -208,8 +224,8 @@ func (s *RetentionService) CreateRetention(
 		// Supplier snapshot
 		SupplierID:   purchase.SupplierID,
 		SupplierName: purchase.GetSupplierName(),
-		SupplierNIT:  nil, // Would get from clients table if supplier_id present
-		SupplierNRC:  purchase.SupplierNRC,
+		SupplierNIT:  supplierNIT,
+		SupplierNRC:  supplierNRC,
 
 		// DTE identifiers
 		CodigoGeneracion: codigoGeneracion,
@@ -236,8 +252,11 @@ func (s *RetentionService) CreateRetention(
 		DteJSON:   "{}",
 		DteSigned: "",
 
+		// Transmission
+		DteTransmissionStatus: "pending",
+
 		// Audit
-		CreatedBy: &userID,
+		CreatedBy: nullIfBlank(&userID),
 		CreatedAt: now,
 	}
 
@@ -346,6 +365,7 @@ func (s *RetentionService) GetRetentionByID(ctx context.Context, companyID, rete
             dte_json, dte_signed,
             hacienda_estado, hacienda_sello_recibido, hacienda_fh_procesamiento,
             hacienda_codigo_msg, hacienda_descripcion_msg, hacienda_observaciones, hacienda_response,
+            contingency_period_id, COALESCE(dte_transmission_status, 'pending'),
             created_by, created_at, submitted_at
         FROM retentions
         WHERE id = $1 AND company_id = $2
@@ -364,6 +384,7 @@ func (s *RetentionService) GetRetentionByID(ctx context.Context, companyID, rete
 		&retention.DteJSON, &retention.DteSigned,
 		&retention.HaciendaEstado, &retention.HaciendaSelloRecibido, &retention.HaciendaFhProcesamiento,
 		&retention.HaciendaCodigoMsg, &retention.HaciendaDescripcionMsg, pq.Array(&observaciones), &retention.HaciendaResponse,
+		&retention.ContingencyPeriodID, &retention.DteTransmissionStatus,
 		&retention.CreatedBy, &retention.CreatedAt, &retention.SubmittedAt,
 	)
 
@@ -392,6 +413,7 @@ func (s *RetentionService) getRetentionByPurchaseID(ctx context.Context, company
             dte_json, dte_signed,
             hacienda_estado, hacienda_sello_recibido, hacienda_fh_procesamiento,
             hacienda_codigo_msg, hacienda_descripcion_msg, hacienda_observaciones, hacienda_response,
+            contingency_period_id, COALESCE(dte_transmission_status, 'pending'),
             created_by, created_at, submitted_at
         FROM retentions
         WHERE purchase_id = $1 AND company_id = $2
@@ -410,6 +432,7 @@ func (s *RetentionService) getRetentionByPurchaseID(ctx context.Context, company
 		&retention.DteJSON, &retention.DteSigned,
 		&retention.HaciendaEstado, &retention.HaciendaSelloRecibido, &retention.HaciendaFhProcesamiento,
 		&retention.HaciendaCodigoMsg, &retention.HaciendaDescripcionMsg, pq.Array(&observaciones), &retention.HaciendaResponse,
+		&retention.ContingencyPeriodID, &retention.DteTransmissionStatus,
 		&retention.CreatedBy, &retention.CreatedAt, &retention.SubmittedAt,
 	)
 
@@ -442,6 +465,7 @@ func (s *RetentionService) ListRetentions(ctx context.Context, companyID string,
             dte_json, dte_signed,
             hacienda_estado, hacienda_sello_recibido, hacienda_fh_procesamiento,
             hacienda_codigo_msg, hacienda_descripcion_msg, hacienda_observaciones, hacienda_response,
+            contingency_period_id, COALESCE(dte_transmission_status, 'pending'),
             created_by, created_at, submitted_at
         FROM retentions
         WHERE company_id = $1
@@ -470,6 +494,7 @@ func (s *RetentionService) ListRetentions(ctx context.Context, companyID string,
 			&r.DteJSON, &r.DteSigned,
 			&r.HaciendaEstado, &r.HaciendaSelloRecibido, &r.HaciendaFhProcesamiento,
 			&r.HaciendaCodigoMsg, &r.HaciendaDescripcionMsg, pq.Array(&observaciones), &r.HaciendaResponse,
+			&r.ContingencyPeriodID, &r.DteTransmissionStatus,
 			&r.CreatedBy, &r.CreatedAt, &r.SubmittedAt,
 		)
 		if err != nil {
@@ -539,6 +564,29 @@ func (s *RetentionService) generateRetentionNumeroControl(ctx context.Context, t
 	return numeroControl, nil
 }
 
+// getSupplierTaxIDs returns the supplier NIT and NRC, preferring the supplier
+// registry over the snapshot stored on the purchase
+func (s *RetentionService) getSupplierTaxIDs(ctx context.Context, purchase *models.Purchase) (*string, *string, error) {
+	if purchase.HasSupplierID() {
+		supplier, err := getSupplier(ctx, database.DB, purchase.CompanyID, *purchase.SupplierID)
+		if err != nil {
+			return nil, nil, err
+		}
+		nrc := supplier.NRC
+		if nrc != nil && *nrc == "" {
+			nrc = nil
+		}
+		return supplier.NIT(), nrc, nil
+	}
+
+	var nitPtr *string
+	if purchase.SupplierDocumentType != nil && *purchase.SupplierDocumentType == "36" &&
+		purchase.SupplierDocumentNumber != nil && *purchase.SupplierDocumentNumber != "" {
+		nitPtr = purchase.SupplierDocumentNumber
+	}
+	return nitPtr, purchase.SupplierNRC, nil
+}
+
 // getCompany loads company with retention configuration
 func (s *RetentionService) getCompany(ctx context.Context, companyID string) (*CompanyRetentionConfig, error) {
 	query := `
@@ -0,0 +1,437 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"cuentas/internal/formats"
+	"cuentas/internal/i18n"
+	"cuentas/internal/models"
+	"cuentas/internal/services/mailer"
+)
+
+// ============================================
+// ERRORS
+// ============================================
+
+var (
+	ErrStatementNoRecipient = errors.New("client has no email address; provide a recipient")
+	ErrStatementSendFailed  = errors.New("failed to send statement email")
+)
+
+// statementEntryOrder sorts same-instant entries: charges before the credits
+// that settle them
+var statementEntryOrder = map[string]int{
+	models.StatementEntryInvoice:     0,
+	models.StatementEntryNotaDebito:  1,
+	models.StatementEntryNotaCredito: 2,
+	models.StatementEntryPayment:     3,
+}
+
+// ============================================
+// SERVICE DEFINITION
+// ============================================
+
+// StatementService builds client account statements (estados de cuenta) from
+// invoices, notas de débito, notas de crédito and payments, and mails them
+type StatementService struct {
+	db     *sql.DB
+	email  *EmailDeliveryService
+	mailer *mailer.Client
+}
+
+// NewStatementService creates a new statement service. The email delivery
+// service supplies the company's sender settings.
+func NewStatementService(db *sql.DB, emailService *EmailDeliveryService, mailerClient *mailer.Client) *StatementService {
+	return &StatementService{db: db, email: emailService, mailer: mailerClient}
+}
+
+// ============================================
+// STATEMENTS
+// ============================================
+
+// GetStatement builds a client's statement for the period. Everything dated
+// before from_date goes into the opening balance. Notas are applied to the CCFs
+// their line items reference, in proportion to the lines; payments to their
+// invoice. Invalidated invoices, their payments and remisiones are left out.
+func (s *StatementService) GetStatement(ctx context.Context, companyID, clientID string, period *models.StatementPeriod) (*models.ClientStatement, error) {
+	fromDate := period.FromDate.Format("2006-01-02")
+	toDate := period.ToDate.Format("2006-01-02")
+
+	statement := &models.ClientStatement{
+		CompanyID:     companyID,
+		ClientID:      clientID,
+		FromDate:      fromDate,
+		ToDate:        toDate,
+		Entries:       []models.StatementEntry{},
+		OpenDocuments: []models.StatementOpenDocument{},
+		GeneratedAt:   time.Now(),
+	}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT co.name, cl.business_name, cl.legal_business_name, cl.correo
+		FROM clients cl
+		JOIN companies co ON co.id = cl.company_id
+		WHERE cl.id = $1 AND cl.company_id = $2
+	`, clientID, companyID).Scan(
+		&statement.CompanyName, &statement.ClientName, &statement.ClientLegalName, &statement.ClientEmail,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrClientNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client: %w", err)
+	}
+
+	args := []interface{}{companyID, clientID, toDate, fromDate}
+
+	invoices, open, err := s.statementInvoices(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	notasDebito, err := s.statementNotas(ctx, models.StatementEntryNotaDebito, companyID, clientID, toDate, fromDate)
+	if err != nil {
+		return nil, err
+	}
+	notasCredito, err := s.statementNotas(ctx, models.StatementEntryNotaCredito, companyID, clientID, toDate, fromDate)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := s.statementPayments(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make([]statementItem, 0, len(invoices)+len(notasDebito)+len(notasCredito)+len(payments))
+	all = append(all, invoices...)
+	all = append(all, notasDebito...)
+	all = append(all, notasCredito...)
+	all = append(all, payments...)
+	sort.SliceStable(all, func(i, j int) bool {
+		if !all[i].entry.Date.Equal(all[j].entry.Date) {
+			return all[i].entry.Date.Before(all[j].entry.Date)
+		}
+		return statementEntryOrder[all[i].entry.Type] < statementEntryOrder[all[j].entry.Type]
+	})
+
+	balance := 0.0
+	for _, item := range all {
+		entry := item.entry
+		balance = round(balance + entry.Charge - entry.Credit)
+		if item.beforePeriod {
+			statement.OpeningBalance = balance
+		} else {
+			entry.Balance = balance
+			statement.TotalCharges += entry.Charge
+			statement.TotalCredits += entry.Credit
+			statement.Entries = append(statement.Entries, entry)
+		}
+
+		for _, applied := range entry.AppliedTo {
+			doc, ok := open[applied.InvoiceID]
+			if !ok {
+				continue
+			}
+			switch entry.Type {
+			case models.StatementEntryNotaDebito:
+				doc.DebitNotes += applied.Amount
+			case models.StatementEntryNotaCredito:
+				doc.CreditNotes += applied.Amount
+			case models.StatementEntryPayment:
+				doc.Payments += applied.Amount
+			}
+		}
+	}
+	statement.TotalCharges = round(statement.TotalCharges)
+	statement.TotalCredits = round(statement.TotalCredits)
+	statement.ClosingBalance = balance
+
+	for _, item := range invoices {
+		doc := open[item.entry.DocumentID]
+		doc.DebitNotes = round(doc.DebitNotes)
+		doc.CreditNotes = round(doc.CreditNotes)
+		doc.Payments = round(doc.Payments)
+		doc.Balance = round(doc.Total + doc.DebitNotes - doc.CreditNotes - doc.Payments)
+		if doc.Balance == 0 {
+			continue
+		}
+		if due, err := time.Parse("2006-01-02", doc.DueDate); err == nil && doc.Balance > 0 {
+			if days := int(period.ToDate.Sub(due).Hours() / 24); days > 0 {
+				doc.DaysPastDue = days
+			}
+		}
+		statement.OpenDocuments = append(statement.OpenDocuments, *doc)
+	}
+
+	return statement, nil
+}
+
+// EmailStatement renders the statement as a PDF and mails it to the recipient,
+// or to the client's correo when none is given. The company's email settings
+// supply the sender, reply-to and bcc.
+func (s *StatementService) EmailStatement(ctx context.Context, companyID, clientID string, req *models.EmailStatementRequest) (*models.StatementEmailResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	period, err := models.ParseStatementPeriod(req.FromDate, req.ToDate)
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	statement, err := s.GetStatement(ctx, companyID, clientID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient := ""
+	if req.Recipient != nil {
+		recipient = *req.Recipient
+	} else if statement.ClientEmail != nil && *statement.ClientEmail != placeholderEmail {
+		recipient = strings.TrimSpace(*statement.ClientEmail)
+	}
+	if recipient == "" {
+		return nil, ErrStatementNoRecipient
+	}
+
+	lang := formats.DetermineLanguage(req.Language)
+	pdfBytes, err := formats.WriteStatementPDF(statement, lang)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render statement PDF: %w", err)
+	}
+
+	settings, err := s.email.GetSettings(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	t := i18n.New(lang)
+	msg := &mailer.Message{
+		To:      []string{recipient},
+		Subject: t.StatementEmailSubject(statement.CompanyName, statement.FromDate, statement.ToDate),
+		Body:    t.StatementEmailBody(statement.ClientName, statement.CompanyName, statement.FromDate, statement.ToDate, fmt.Sprintf("$%.2f", statement.ClosingBalance)),
+		Attachments: []mailer.Attachment{{
+			Filename:    fmt.Sprintf("estado_de_cuenta_%s_%s.pdf", statement.FromDate, statement.ToDate),
+			ContentType: "application/pdf",
+			Data:        pdfBytes,
+		}},
+	}
+
+	from := s.mailer.DefaultFrom()
+	if settings.FromAddress != nil {
+		from.Address = *settings.FromAddress
+	}
+	from.Name = statement.CompanyName
+	if settings.FromName != nil {
+		from.Name = *settings.FromName
+	}
+	msg.From = &from
+
+	if settings.ReplyTo != nil {
+		msg.ReplyTo = *settings.ReplyTo
+	}
+	if settings.Bcc != nil {
+		msg.Bcc = []string{*settings.Bcc}
+	}
+
+	messageID, err := s.mailer.Send(ctx, msg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStatementSendFailed, err)
+	}
+
+	return &models.StatementEmailResult{
+		Recipient:      recipient,
+		MessageID:      messageID,
+		FromDate:       statement.FromDate,
+		ToDate:         statement.ToDate,
+		ClosingBalance: statement.ClosingBalance,
+		SentAt:         time.Now(),
+	}, nil
+}
+
+// ============================================
+// HELPERS
+// ============================================
+
+// statementItem is an entry before it is placed in the period or the opening balance
+type statementItem struct {
+	entry        models.StatementEntry
+	beforePeriod bool
+}
+
+// The invoice and payment loaders share their arguments: $1 company,
+// $2 client, $3 to_date (inclusive), $4 from_date (start of the period)
+
+// statementInvoices loads finalized CCFs and facturas up to to_date, and starts
+// an open document for each
+func (s *StatementService) statementInvoices(ctx context.Context, args []interface{}) ([]statementItem, map[string]*models.StatementOpenDocument, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, invoice_number, dte_type, dte_numero_control, finalized_at,
+		       to_char(finalized_at::date, 'YYYY-MM-DD'),
+		       to_char(COALESCE(due_date, finalized_at::date), 'YYYY-MM-DD'),
+		       total, finalized_at::date < $4
+		FROM invoices
+		WHERE company_id = $1
+		  AND client_id = $2
+		  AND status = 'finalized'
+		  AND COALESCE(dte_type, '') <> '04'
+		  AND finalized_at::date <= $3
+	`, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query invoices: %w", err)
+	}
+	defer rows.Close()
+
+	items := []statementItem{}
+	open := map[string]*models.StatementOpenDocument{}
+	for rows.Next() {
+		var (
+			item          = statementItem{entry: models.StatementEntry{Type: models.StatementEntryInvoice}}
+			doc           models.StatementOpenDocument
+			dteType       sql.NullString
+			numeroControl sql.NullString
+		)
+		if err := rows.Scan(
+			&doc.InvoiceID, &doc.InvoiceNumber, &dteType, &numeroControl, &item.entry.Date,
+			&doc.Date, &doc.DueDate, &doc.Total, &item.beforePeriod,
+		); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan invoice: %w", err)
+		}
+		if dteType.Valid {
+			doc.DteType = &dteType.String
+		}
+		if numeroControl.Valid {
+			doc.NumeroControl = &numeroControl.String
+		}
+		doc.Total = round(doc.Total)
+
+		item.entry.DocumentID = doc.InvoiceID
+		item.entry.DocumentNumber = doc.InvoiceNumber
+		item.entry.DteType = doc.DteType
+		item.entry.NumeroControl = doc.NumeroControl
+		item.entry.Charge = doc.Total
+
+		items = append(items, item)
+		open[doc.InvoiceID] = &doc
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating invoices: %w", err)
+	}
+	return items, open, nil
+}
+
+// statementNotas loads finalized notas de débito or crédito up to to_date,
+// each applied to the CCFs it references as everywhere receivables are read
+func (s *StatementService) statementNotas(ctx context.Context, kind, companyID, clientID, toDate, fromDate string) ([]statementItem, error) {
+	dteType := "06"
+	if kind == models.StatementEntryNotaCredito {
+		dteType = "05"
+	}
+
+	notas, err := loadNotaApplications(ctx, s.db, kind, companyID, clientID, "", toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]statementItem, 0, len(notas))
+	for _, nota := range notas {
+		entry := models.StatementEntry{
+			Date:           nota.finalizedAt,
+			Type:           kind,
+			DocumentID:     nota.id,
+			DocumentNumber: nota.number,
+			DteType:        &dteType,
+			AppliedTo:      nota.applications,
+		}
+		if nota.numeroControl.Valid {
+			entry.NumeroControl = &nota.numeroControl.String
+		}
+		if kind == models.StatementEntryNotaCredito {
+			entry.Credit = nota.total
+		} else {
+			entry.Charge = nota.total
+		}
+		items = append(items, statementItem{entry: entry, beforePeriod: nota.date < fromDate})
+	}
+	return items, nil
+}
+
+// statementPayments loads unvoided payments up to to_date on the client's
+// finalized invoices
+func (s *StatementService) statementPayments(ctx context.Context, args []interface{}) ([]statementItem, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT p.id, p.invoice_id, i.invoice_number, p.payment_reference,
+		       p.payment_date, p.amount, p.payment_date::date < $4
+		FROM payments p
+		JOIN invoices i ON i.id = p.invoice_id
+		WHERE p.company_id = $1
+		  AND i.client_id = $2
+		  AND i.status = 'finalized'
+		  AND COALESCE(i.dte_type, '') <> '04'
+		  AND p.voided_at IS NULL
+		  AND p.payment_date::date <= $3
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payments: %w", err)
+	}
+	defer rows.Close()
+
+	items := []statementItem{}
+	for rows.Next() {
+		var (
+			item          = statementItem{entry: models.StatementEntry{Type: models.StatementEntryPayment}}
+			invoiceID     string
+			invoiceNumber string
+			amount        float64
+		)
+		if err := rows.Scan(
+			&item.entry.DocumentID, &invoiceID, &invoiceNumber, &item.entry.Reference,
+			&item.entry.Date, &amount, &item.beforePeriod,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		amount = round(amount)
+		item.entry.DocumentNumber = invoiceNumber
+		item.entry.Credit = amount
+		item.entry.AppliedTo = []models.StatementApplication{{
+			InvoiceID:     invoiceID,
+			InvoiceNumber: invoiceNumber,
+			Amount:        amount,
+		}}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payments: %w", err)
+	}
+	return items, nil
+}
+
+// allocateStatementAmount splits amount over applications in proportion to
+// weights. The last application takes the rounding remainder so the parts add
+// up to the amount.
+func allocateStatementAmount(amount float64, applications []models.StatementApplication, weights []float64) {
+	if len(applications) == 0 {
+		return
+	}
+	sum := 0.0
+	for _, w := range weights {
+		sum += w
+	}
+
+	remaining := amount
+	for i := range applications {
+		if i == len(applications)-1 {
+			applications[i].Amount = round(remaining)
+			break
+		}
+		share := amount / float64(len(applications))
+		if sum > 0 {
+			share = amount * weights[i] / sum
+		}
+		applications[i].Amount = round(share)
+		remaining -= applications[i].Amount
+	}
+}
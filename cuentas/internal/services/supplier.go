@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"cuentas/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ============================================
+// ERRORS
+// ============================================
+
+var (
+	ErrSupplierDocumentTaken = errors.New("a supplier with this document already exists")
+	ErrSupplierInactive      = errors.New("supplier is inactive")
+)
+
+// ============================================
+// SERVICE DEFINITION
+// ============================================
+
+// SupplierService manages the supplier registry. Purchases, retentions and
+// inventory purchase events reference suppliers by ID and keep a snapshot of
+// the fields printed on their documents.
+type SupplierService struct {
+	db *sql.DB
+}
+
+// NewSupplierService creates a new supplier service
+func NewSupplierService(db *sql.DB) *SupplierService {
+	return &SupplierService{db: db}
+}
+
+const supplierColumns = `
+	id, company_id, name, legal_name, document_type, document_number, nrc,
+	activity_code, activity_desc, nationality,
+	address_dept, address_muni, address_complement, phone, email, contact_name,
+	payment_term_days, active, notes, created_at, updated_at
+`
+
+// ============================================
+// SUPPLIERS
+// ============================================
+
+// CreateSupplier registers a supplier
+func (s *SupplierService) CreateSupplier(ctx context.Context, companyID string, req *models.CreateSupplierRequest) (*models.Supplier, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO suppliers (
+			company_id, name, legal_name, document_type, document_number, nrc,
+			activity_code, activity_desc, nationality,
+			address_dept, address_muni, address_complement, phone, email, contact_name,
+			payment_term_days, notes
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING `+supplierColumns,
+		companyID, req.Name, nullIfBlank(req.LegalName), req.DocumentType, nullIfBlank(req.DocumentNumber), nullIfBlank(req.NRC),
+		nullIfBlank(req.ActivityCode), nullIfBlank(req.ActivityDesc), req.Nationality,
+		nullIfBlank(req.AddressDept), nullIfBlank(req.AddressMuni), nullIfBlank(req.AddressComplement),
+		nullIfBlank(req.Phone), nullIfBlank(req.Email), nullIfBlank(req.ContactName),
+		req.PaymentTermDays, req.Notes,
+	)
+	supplier, err := scanSupplier(row)
+	if err != nil {
+		return nil, supplierWriteError(err)
+	}
+	return supplier, nil
+}
+
+// GetSupplier returns one supplier
+func (s *SupplierService) GetSupplier(ctx context.Context, companyID, supplierID string) (*models.Supplier, error) {
+	return getSupplier(ctx, s.db, companyID, supplierID)
+}
+
+// ListSuppliers returns the company's suppliers by name
+func (s *SupplierService) ListSuppliers(ctx context.Context, companyID string, filters *models.SupplierFilters) ([]models.Supplier, error) {
+	query := `
+		SELECT ` + supplierColumns + `
+		FROM suppliers
+		WHERE company_id = $1
+	`
+	args := []interface{}{companyID}
+
+	if !filters.IncludeInactive {
+		query += " AND active = true"
+	}
+	if search := strings.TrimSpace(filters.Search); search != "" {
+		args = append(args, "%"+search+"%")
+		query += fmt.Sprintf(" AND (name ILIKE $%d OR legal_name ILIKE $%d OR document_number ILIKE $%d)", len(args), len(args), len(args))
+	}
+
+	query += " ORDER BY name"
+	if filters.Limit > 0 {
+		args = append(args, filters.Limit, filters.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suppliers: %w", err)
+	}
+	defer rows.Close()
+
+	suppliers := []models.Supplier{}
+	for rows.Next() {
+		supplier, err := scanSupplier(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan supplier: %w", err)
+		}
+		suppliers = append(suppliers, *supplier)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating suppliers: %w", err)
+	}
+	return suppliers, nil
+}
+
+// UpdateSupplier changes a supplier. Documents already issued keep their snapshot.
+func (s *SupplierService) UpdateSupplier(ctx context.Context, companyID, supplierID string, req *models.UpdateSupplierRequest) (*models.Supplier, error) {
+	supplier, err := s.GetSupplier(ctx, companyID, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Apply(supplier); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE suppliers
+		SET name = $1, legal_name = $2, document_type = $3, document_number = $4, nrc = $5,
+		    activity_code = $6, activity_desc = $7, nationality = $8,
+		    address_dept = $9, address_muni = $10, address_complement = $11,
+		    phone = $12, email = $13, contact_name = $14,
+		    payment_term_days = $15, active = $16, notes = $17, updated_at = NOW()
+		WHERE id = $18 AND company_id = $19
+		RETURNING `+supplierColumns,
+		supplier.Name, nullIfBlank(supplier.LegalName), supplier.DocumentType, nullIfBlank(supplier.DocumentNumber), nullIfBlank(supplier.NRC),
+		nullIfBlank(supplier.ActivityCode), nullIfBlank(supplier.ActivityDesc), supplier.Nationality,
+		nullIfBlank(supplier.AddressDept), nullIfBlank(supplier.AddressMuni), nullIfBlank(supplier.AddressComplement),
+		nullIfBlank(supplier.Phone), nullIfBlank(supplier.Email), nullIfBlank(supplier.ContactName),
+		supplier.PaymentTermDays, supplier.Active, supplier.Notes,
+		supplierID, companyID,
+	)
+	updated, err := scanSupplier(row)
+	if err != nil {
+		return nil, supplierWriteError(err)
+	}
+	return updated, nil
+}
+
+// DeactivateSupplier hides a supplier from new documents. Suppliers are never
+// deleted: purchases and payments keep referencing them.
+func (s *SupplierService) DeactivateSupplier(ctx context.Context, companyID, supplierID string) error {
+	if _, err := uuid.Parse(supplierID); err != nil {
+		return ErrSupplierNotFound
+	}
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE suppliers SET active = false, updated_at = NOW()
+		WHERE id = $1 AND company_id = $2
+	`, supplierID, companyID)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate supplier: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrSupplierNotFound
+	}
+	return nil
+}
+
+// ============================================
+// HELPERS
+// ============================================
+
+// getSupplier loads a supplier for the services that link documents to one
+func getSupplier(ctx context.Context, db *sql.DB, companyID, supplierID string) (*models.Supplier, error) {
+	if _, err := uuid.Parse(supplierID); err != nil {
+		return nil, ErrSupplierNotFound
+	}
+	row := db.QueryRowContext(ctx, `
+		SELECT `+supplierColumns+`
+		FROM suppliers
+		WHERE id = $1 AND company_id = $2
+	`, supplierID, companyID)
+
+	supplier, err := scanSupplier(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSupplierNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get supplier: %w", err)
+	}
+	return supplier, nil
+}
+
+// getActiveSupplier is getSupplier for new documents, which cannot use an
+// inactive supplier
+func getActiveSupplier(ctx context.Context, db *sql.DB, companyID, supplierID string) (*models.Supplier, error) {
+	supplier, err := getSupplier(ctx, db, companyID, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	if !supplier.Active {
+		return nil, ErrSupplierInactive
+	}
+	return supplier, nil
+}
+
+func supplierWriteError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSupplierNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrSupplierDocumentTaken
+	}
+	return fmt.Errorf("failed to save supplier: %w", err)
+}
+
+func scanSupplier(row rowScanner) (*models.Supplier, error) {
+	var s models.Supplier
+	err := row.Scan(
+		&s.ID, &s.CompanyID, &s.Name, &s.LegalName, &s.DocumentType, &s.DocumentNumber, &s.NRC,
+		&s.ActivityCode, &s.ActivityDesc, &s.Nationality,
+		&s.AddressDept, &s.AddressMuni, &s.AddressComplement, &s.Phone, &s.Email, &s.ContactName,
+		&s.PaymentTermDays, &s.Active, &s.Notes, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
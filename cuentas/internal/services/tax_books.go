@@ -0,0 +1,739 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"cuentas/internal/models"
+	"cuentas/internal/tools"
+
+	"github.com/lib/pq"
+)
+
+// ============================================
+// SERVICE DEFINITION
+// ============================================
+
+// TaxBookService builds the IVA books (libros de IVA) required by Hacienda from
+// the documents accepted in a fiscal month
+type TaxBookService struct {
+	db *sql.DB
+}
+
+// NewTaxBookService creates a new tax book service
+func NewTaxBookService(db *sql.DB) *TaxBookService {
+	return &TaxBookService{db: db}
+}
+
+// ============================================
+// LIBRO DE VENTAS A CONSUMIDOR FINAL
+// ============================================
+
+// GetConsumerSalesBook builds the libro de ventas a consumidor final from the
+// commit log: facturas (01) and facturas de exportación (11) accepted by
+// Hacienda in the fiscal month, one row per day and point of sale with the
+// first and last numero de control. Invalidated documents are left out. An
+// empty establishmentID includes every establishment.
+func (s *TaxBookService) GetConsumerSalesBook(ctx context.Context, companyID string, period *models.TaxBookPeriod, establishmentID string) (*models.ConsumerSalesBook, error) {
+	book := &models.ConsumerSalesBook{
+		CompanyID:    companyID,
+		Year:         period.Year,
+		Month:        period.Month,
+		Rows:         []models.ConsumerSalesBookRow{},
+		PointsOfSale: []models.ConsumerSalesBookSubtotal{},
+		GeneratedAt:  time.Now(),
+	}
+	if err := loadReportCompany(ctx, s.db, companyID, &book.CompanyName, &book.NIT, &book.NRC); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (UPPER(cl.codigo_generacion))
+		       to_char(cl.fecha_emision, 'YYYY-MM-DD'), cl.tipo_dte, cl.numero_control,
+		       cl.iva_amount, cl.dte_unsigned,
+		       e.id, e.cod_establecimiento, e.nombre,
+		       p.id, COALESCE(p.cod_punto_venta, ''), p.nombre
+		FROM dte_commit_log cl
+		JOIN establishments e ON e.id = cl.establishment_id
+		JOIN point_of_sale p ON p.id = cl.point_of_sale_id
+		WHERE cl.company_id = $1
+		  AND cl.fiscal_year = $2
+		  AND cl.fiscal_month = $3
+		  AND cl.tipo_dte IN ('01', '11')
+		  AND cl.hacienda_estado = 'PROCESADO'
+		  AND ($4 = '' OR cl.establishment_id::text = $4)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM dte_invalidations i
+		      WHERE UPPER(i.original_codigo_generacion) = UPPER(cl.codigo_generacion)
+		        AND i.hacienda_estado = 'PROCESADO'
+		  )
+		ORDER BY UPPER(cl.codigo_generacion), cl.created_at DESC
+	`, companyID, period.Year, period.Month, establishmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query commit log: %w", err)
+	}
+	defer rows.Close()
+
+	docs := []consumerSalesDocument{}
+	for rows.Next() {
+		var (
+			doc         consumerSalesDocument
+			ivaAmount   float64
+			dteUnsigned []byte
+		)
+		if err := rows.Scan(
+			&doc.row.Date, &doc.row.TipoDte, &doc.numeroControl, &ivaAmount, &dteUnsigned,
+			&doc.row.EstablishmentID, &doc.row.EstablishmentCode, &doc.row.EstablishmentName,
+			&doc.row.PointOfSaleID, &doc.row.PointOfSaleCode, &doc.row.PointOfSaleName,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan commit log entry: %w", err)
+		}
+		amounts, err := consumerSalesAmounts(doc.row.TipoDte, dteUnsigned, ivaAmount)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", doc.numeroControl, err)
+		}
+		doc.row.ConsumerSalesAmounts = amounts
+		docs = append(docs, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating commit log: %w", err)
+	}
+
+	sort.Slice(docs, func(i, j int) bool {
+		a, b := docs[i], docs[j]
+		if a.row.Date != b.row.Date {
+			return a.row.Date < b.row.Date
+		}
+		if a.row.EstablishmentCode != b.row.EstablishmentCode {
+			return a.row.EstablishmentCode < b.row.EstablishmentCode
+		}
+		if a.row.PointOfSaleCode != b.row.PointOfSaleCode {
+			return a.row.PointOfSaleCode < b.row.PointOfSaleCode
+		}
+		if a.row.PointOfSaleID != b.row.PointOfSaleID {
+			return a.row.PointOfSaleID < b.row.PointOfSaleID
+		}
+		if a.row.TipoDte != b.row.TipoDte {
+			return a.row.TipoDte < b.row.TipoDte
+		}
+		return a.numeroControl < b.numeroControl
+	})
+
+	// Documents are sorted by row key and numero de control, so each row runs
+	// from its first document to its last
+	subtotals := map[string]*models.ConsumerSalesBookSubtotal{}
+	subtotalOrder := []string{}
+	for _, doc := range docs {
+		n := len(book.Rows)
+		if n == 0 || !doc.sameRow(book.Rows[n-1]) {
+			row := doc.row
+			row.FirstNumeroControl = doc.numeroControl
+			row.ConsumerSalesAmounts = models.ConsumerSalesAmounts{}
+			book.Rows = append(book.Rows, row)
+			n++
+		}
+		row := &book.Rows[n-1]
+		row.LastNumeroControl = doc.numeroControl
+		row.Add(doc.row.ConsumerSalesAmounts)
+
+		subtotal, ok := subtotals[doc.row.PointOfSaleID]
+		if !ok {
+			subtotal = &models.ConsumerSalesBookSubtotal{
+				EstablishmentID:   doc.row.EstablishmentID,
+				EstablishmentCode: doc.row.EstablishmentCode,
+				EstablishmentName: doc.row.EstablishmentName,
+				PointOfSaleID:     doc.row.PointOfSaleID,
+				PointOfSaleCode:   doc.row.PointOfSaleCode,
+				PointOfSaleName:   doc.row.PointOfSaleName,
+			}
+			subtotals[doc.row.PointOfSaleID] = subtotal
+			subtotalOrder = append(subtotalOrder, doc.row.PointOfSaleID)
+		}
+		subtotal.Add(doc.row.ConsumerSalesAmounts)
+		book.Totals.Add(doc.row.ConsumerSalesAmounts)
+	}
+
+	for i := range book.Rows {
+		roundConsumerSalesAmounts(&book.Rows[i].ConsumerSalesAmounts)
+	}
+	for _, id := range subtotalOrder {
+		subtotal := subtotals[id]
+		roundConsumerSalesAmounts(&subtotal.ConsumerSalesAmounts)
+		book.PointsOfSale = append(book.PointsOfSale, *subtotal)
+	}
+	sort.SliceStable(book.PointsOfSale, func(i, j int) bool {
+		a, b := book.PointsOfSale[i], book.PointsOfSale[j]
+		if a.EstablishmentCode != b.EstablishmentCode {
+			return a.EstablishmentCode < b.EstablishmentCode
+		}
+		return a.PointOfSaleCode < b.PointOfSaleCode
+	})
+	roundConsumerSalesAmounts(&book.Totals)
+
+	return book, nil
+}
+
+// ============================================
+// LIBRO DE VENTAS A CONTRIBUYENTES
+// ============================================
+
+// GetContributorSalesBook builds the libro de ventas a contribuyentes from the
+// commit log: every CCF (03), nota de crédito (05) and nota de débito (06)
+// accepted by Hacienda in the fiscal month and not invalidated, with the
+// receptor as printed on the DTE. Notas de crédito are negative and notas list
+// the CCFs they adjust, so the totals are the month's net débito fiscal as
+// declared on the F-07.
+func (s *TaxBookService) GetContributorSalesBook(ctx context.Context, companyID string, period *models.TaxBookPeriod, establishmentID string) (*models.ContributorSalesBook, error) {
+	book := &models.ContributorSalesBook{
+		CompanyID:     companyID,
+		Year:          period.Year,
+		Month:         period.Month,
+		Rows:          []models.ContributorSalesBookRow{},
+		DocumentTypes: []models.ContributorSalesBookSubtotal{},
+		GeneratedAt:   time.Now(),
+	}
+	if err := loadReportCompany(ctx, s.db, companyID, &book.CompanyName, &book.NIT, &book.NRC); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.contributorSalesRows(ctx, companyID, period, establishmentID)
+	if err != nil {
+		return nil, err
+	}
+	book.Rows = rows
+
+	subtotals := map[string]*models.ContributorSalesBookSubtotal{}
+	for _, row := range book.Rows {
+		subtotal, ok := subtotals[row.TipoDte]
+		if !ok {
+			subtotal = &models.ContributorSalesBookSubtotal{TipoDte: row.TipoDte}
+			subtotals[row.TipoDte] = subtotal
+		}
+		subtotal.DocumentCount++
+		subtotal.Add(row.ContributorSalesAmounts)
+		book.Totals.Add(row.ContributorSalesAmounts)
+	}
+
+	for _, tipoDte := range []string{"03", "05", "06"} {
+		if subtotal, ok := subtotals[tipoDte]; ok {
+			roundContributorSalesAmounts(&subtotal.ContributorSalesAmounts)
+			book.DocumentTypes = append(book.DocumentTypes, *subtotal)
+		}
+	}
+	roundContributorSalesAmounts(&book.Totals)
+
+	return book, nil
+}
+
+// contributorSalesRows loads the documents of the libro de ventas a
+// contribuyentes, in date and numero de control order
+func (s *TaxBookService) contributorSalesRows(ctx context.Context, companyID string, period *models.TaxBookPeriod, establishmentID string) ([]models.ContributorSalesBookRow, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (UPPER(cl.codigo_generacion))
+		       UPPER(cl.codigo_generacion), to_char(cl.fecha_emision, 'YYYY-MM-DD'),
+		       cl.tipo_dte, cl.numero_control, COALESCE(cl.hacienda_sello_recibido, ''),
+		       cl.iva_amount, cl.dte_unsigned
+		FROM dte_commit_log cl
+		WHERE cl.company_id = $1
+		  AND cl.fiscal_year = $2
+		  AND cl.fiscal_month = $3
+		  AND cl.tipo_dte IN ('03', '05', '06')
+		  AND cl.hacienda_estado = 'PROCESADO'
+		  AND ($4 = '' OR cl.establishment_id::text = $4)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM dte_invalidations i
+		      WHERE UPPER(i.original_codigo_generacion) = UPPER(cl.codigo_generacion)
+		        AND i.hacienda_estado = 'PROCESADO'
+		  )
+		ORDER BY UPPER(cl.codigo_generacion), cl.created_at DESC
+	`, companyID, period.Year, period.Month, establishmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query commit log: %w", err)
+	}
+	defer rows.Close()
+
+	bookRows := []models.ContributorSalesBookRow{}
+	notas := []string{}
+	for rows.Next() {
+		var (
+			row         models.ContributorSalesBookRow
+			ivaAmount   float64
+			dteUnsigned []byte
+		)
+		if err := rows.Scan(
+			&row.CodigoGeneracion, &row.Date, &row.TipoDte, &row.NumeroControl, &row.SelloRecibido,
+			&ivaAmount, &dteUnsigned,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan commit log entry: %w", err)
+		}
+		if err := applyContributorSalesDTE(&row, dteUnsigned, ivaAmount); err != nil {
+			return nil, fmt.Errorf("%s: %w", row.NumeroControl, err)
+		}
+		if row.TipoDte != "03" {
+			notas = append(notas, row.CodigoGeneracion)
+		}
+		bookRows = append(bookRows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating commit log: %w", err)
+	}
+	rows.Close()
+
+	related, err := s.notaRelatedCCFs(ctx, notas)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range bookRows {
+		bookRows[i].RelatedDocuments = related[bookRows[i].CodigoGeneracion]
+	}
+	sort.Slice(bookRows, func(i, j int) bool {
+		a, b := bookRows[i], bookRows[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		return a.NumeroControl < b.NumeroControl
+	})
+	return bookRows, nil
+}
+
+// notaRelatedCCFs loads the numeros de control of the CCFs each nota adjusts,
+// keyed by the nota's código de generación
+func (s *TaxBookService) notaRelatedCCFs(ctx context.Context, notas []string) (map[string][]string, error) {
+	related := map[string][]string{}
+	if len(notas) == 0 {
+		return related, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT UPPER(r.nota_credito_id), COALESCE(i.dte_numero_control, r.ccf_number)
+		FROM notas_credito_ccf_references r
+		JOIN invoices i ON i.id = r.ccf_id
+		WHERE UPPER(r.nota_credito_id) = ANY($1)
+		UNION ALL
+		SELECT UPPER(r.nota_debito_id::text), COALESCE(i.dte_numero_control, r.ccf_number)
+		FROM nota_debito_ccf_references r
+		JOIN invoices i ON i.id = r.ccf_id
+		WHERE UPPER(r.nota_debito_id::text) = ANY($1)
+		ORDER BY 1, 2
+	`, pq.Array(notas))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nota references: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var notaID, numeroControl string
+		if err := rows.Scan(&notaID, &numeroControl); err != nil {
+			return nil, fmt.Errorf("failed to scan nota reference: %w", err)
+		}
+		related[notaID] = append(related[notaID], numeroControl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating nota references: %w", err)
+	}
+	return related, nil
+}
+
+// ============================================
+// LIBRO DE COMPRAS
+// ============================================
+
+// GetPurchaseBook builds the libro de compras from the finalized purchases
+// dated in the fiscal month: supplier CCFs (03) loaded from their DTE, FSEs
+// (14) accepted by Hacienda and imports. Facturas (01) from suppliers carry
+// no crédito fiscal and are left out, as are invalidated (voided) purchases.
+// The retentions section lists the comprobantes de retención (07) we issued
+// and the IVA our clients withheld on the month's CCFs and notas.
+func (s *TaxBookService) GetPurchaseBook(ctx context.Context, companyID string, period *models.TaxBookPeriod, establishmentID string) (*models.PurchaseBook, error) {
+	book := &models.PurchaseBook{
+		CompanyID:   companyID,
+		Year:        period.Year,
+		Month:       period.Month,
+		Rows:        []models.PurchaseBookRow{},
+		Retentions:  []models.PurchaseBookRetention{},
+		GeneratedAt: time.Now(),
+	}
+	if err := loadReportCompany(ctx, s.db, companyID, &book.CompanyName, &book.NIT, &book.NRC); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT UPPER(p.id::text), p.purchase_number, p.purchase_type,
+		       to_char(p.purchase_date, 'YYYY-MM-DD'), p.dte_type, p.dte_numero_control,
+		       CASE WHEN p.purchase_type = 'fse' THEN UPPER(p.id::text) ELSE p.dte_codigo_generacion END,
+		       COALESCE(p.supplier_nrc, s.nrc, ''), COALESCE(p.supplier_document_type, s.document_type, ''),
+		       COALESCE(p.supplier_document_number, s.document_number, ''), COALESCE(p.supplier_name, s.name, ''),
+		       p.subtotal, COALESCE(p.total_discount, 0), COALESCE(p.total_taxes, 0),
+		       COALESCE(p.iva_retained, 0), p.total_no_sujeta, p.total_exenta, p.total_gravada,
+		       p.dte_unsigned, r.iva_retenido
+		FROM purchases p
+		LEFT JOIN suppliers s ON s.id = p.supplier_id
+		LEFT JOIN retentions r ON r.id = p.retention_id
+		     AND r.hacienda_estado = 'PROCESADO'
+		     AND NOT EXISTS (
+		         SELECT 1 FROM dte_invalidations i
+		         WHERE UPPER(i.original_codigo_generacion) = UPPER(r.codigo_generacion)
+		           AND i.hacienda_estado = 'PROCESADO'
+		     )
+		WHERE p.company_id = $1
+		  AND EXTRACT(YEAR FROM p.purchase_date) = $2
+		  AND EXTRACT(MONTH FROM p.purchase_date) = $3
+		  AND p.status = 'finalized'
+		  AND (
+		      p.purchase_type = 'import'
+		      OR (p.purchase_type = 'fse' AND p.dte_status = 'PROCESADO')
+		      OR (p.purchase_type = 'regular' AND p.dte_type = '03')
+		  )
+		  AND ($4 = '' OR p.establishment_id::text = $4)
+		ORDER BY p.purchase_date, p.dte_numero_control, p.purchase_number
+	`, companyID, period.Year, period.Month, establishmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query purchases: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			row                                 models.PurchaseBookRow
+			numeroControl                       sql.NullString
+			documentNumber                      string
+			subtotal, discount, taxes, retained float64
+			noSujeta, exenta, gravada           float64
+			dteUnsigned                         []byte
+			retentionIVA                        sql.NullFloat64
+		)
+		if err := rows.Scan(
+			&row.PurchaseID, &row.PurchaseNumber, &row.PurchaseType,
+			&row.Date, &row.TipoDte, &numeroControl, &row.CodigoGeneracion,
+			&row.SupplierNRC, &row.SupplierDocumentType, &documentNumber, &row.SupplierName,
+			&subtotal, &discount, &taxes, &retained, &noSujeta, &exenta, &gravada,
+			&dteUnsigned, &retentionIVA,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan purchase: %w", err)
+		}
+
+		row.DocumentNumber = row.PurchaseNumber
+		if numeroControl.Valid {
+			row.DocumentNumber = numeroControl.String
+		}
+		row.SupplierDocument = documentNumber
+		if row.SupplierDocumentType == "36" {
+			row.SupplierDocument = tools.FormatNIT(documentNumber)
+		}
+		if row.SupplierNRC != "" {
+			row.SupplierNRC = tools.FormatNRC(row.SupplierNRC)
+		}
+
+		a := &row.PurchaseBookAmounts
+		switch row.PurchaseType {
+		case "fse":
+			a.SujetosExcluidos = subtotal - discount
+		case "import":
+			if taxes > 0 {
+				a.ImportacionesGravadas = subtotal - discount
+				a.IVACreditoFiscal = taxes
+			} else {
+				a.ComprasExentasImportaciones = subtotal - discount
+			}
+		default:
+			a.ComprasExentasInternas = exenta + noSujeta
+			a.ComprasGravadasInternas = gravada
+			a.IVACreditoFiscal = purchaseIVA(dteUnsigned, taxes)
+		}
+		a.IVARetenido = retained
+		if retentionIVA.Valid {
+			a.IVARetenido = retentionIVA.Float64
+		}
+		a.Total = a.ComprasExentasInternas + a.ComprasExentasImportaciones + a.ComprasGravadasInternas +
+			a.ImportacionesGravadas + a.IVACreditoFiscal + a.SujetosExcluidos
+		roundPurchaseBookAmounts(a)
+
+		book.Totals.Add(row.PurchaseBookAmounts)
+		book.Rows = append(book.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating purchases: %w", err)
+	}
+	rows.Close()
+	roundPurchaseBookAmounts(&book.Totals)
+
+	issued, err := s.issuedRetentions(ctx, companyID, period, establishmentID)
+	if err != nil {
+		return nil, err
+	}
+	for _, retention := range issued {
+		book.RetencionesEmitidas += retention.IVARetenido
+	}
+	book.Retentions = append(book.Retentions, issued...)
+
+	sales, err := s.contributorSalesRows(ctx, companyID, period, establishmentID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sale := range sales {
+		if sale.IVARetenido == 0 {
+			continue
+		}
+		book.Retentions = append(book.Retentions, models.PurchaseBookRetention{
+			Kind:             models.RetentionSuffered,
+			Date:             sale.Date,
+			TipoDte:          sale.TipoDte,
+			NumeroControl:    sale.NumeroControl,
+			CodigoGeneracion: sale.CodigoGeneracion,
+			CounterpartyNRC:  sale.ReceptorNRC,
+			CounterpartyNIT:  sale.ReceptorNIT,
+			CounterpartyName: sale.ReceptorName,
+			MontoSujeto:      sale.VentasGravadas,
+			IVARetenido:      sale.IVARetenido,
+		})
+		book.RetencionesSufridas += sale.IVARetenido
+	}
+	book.RetencionesEmitidas = round(book.RetencionesEmitidas)
+	book.RetencionesSufridas = round(book.RetencionesSufridas)
+
+	return book, nil
+}
+
+// issuedRetentions loads the comprobantes de retención (07) accepted by
+// Hacienda in the month and not invalidated
+func (s *TaxBookService) issuedRetentions(ctx context.Context, companyID string, period *models.TaxBookPeriod, establishmentID string) ([]models.PurchaseBookRetention, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT to_char(r.fecha_emision, 'YYYY-MM-DD'), r.tipo_dte, r.numero_control, UPPER(r.codigo_generacion),
+		       COALESCE(r.supplier_nrc, ''), COALESCE(r.supplier_nit, ''), r.supplier_name,
+		       r.purchase_numero_control, r.monto_sujeto_grav, r.iva_retenido
+		FROM retentions r
+		WHERE r.company_id = $1
+		  AND EXTRACT(YEAR FROM r.fecha_emision) = $2
+		  AND EXTRACT(MONTH FROM r.fecha_emision) = $3
+		  AND r.hacienda_estado = 'PROCESADO'
+		  AND ($4 = '' OR r.establishment_id::text = $4)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM dte_invalidations i
+		      WHERE UPPER(i.original_codigo_generacion) = UPPER(r.codigo_generacion)
+		        AND i.hacienda_estado = 'PROCESADO'
+		  )
+		ORDER BY r.fecha_emision, r.numero_control
+	`, companyID, period.Year, period.Month, establishmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query retentions: %w", err)
+	}
+	defer rows.Close()
+
+	retentions := []models.PurchaseBookRetention{}
+	for rows.Next() {
+		retention := models.PurchaseBookRetention{Kind: models.RetentionIssued}
+		if err := rows.Scan(
+			&retention.Date, &retention.TipoDte, &retention.NumeroControl, &retention.CodigoGeneracion,
+			&retention.CounterpartyNRC, &retention.CounterpartyNIT, &retention.CounterpartyName,
+			&retention.RelatedDocument, &retention.MontoSujeto, &retention.IVARetenido,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan retention: %w", err)
+		}
+		if retention.CounterpartyNRC != "" {
+			retention.CounterpartyNRC = tools.FormatNRC(retention.CounterpartyNRC)
+		}
+		if retention.CounterpartyNIT != "" {
+			retention.CounterpartyNIT = tools.FormatNIT(retention.CounterpartyNIT)
+		}
+		retention.MontoSujeto = round(retention.MontoSujeto)
+		retention.IVARetenido = round(retention.IVARetenido)
+		retentions = append(retentions, retention)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating retentions: %w", err)
+	}
+	return retentions, nil
+}
+
+// ============================================
+// HELPERS
+// ============================================
+
+// loadReportCompany loads the name, NIT and NRC printed on every IVA book and
+// financial statement
+func loadReportCompany(ctx context.Context, db *sql.DB, companyID string, name, nit, nrc *string) error {
+	var nitNumber, nrcNumber int64
+	err := db.QueryRowContext(ctx, `SELECT name, nit, ncr FROM companies WHERE id = $1`, companyID).
+		Scan(name, &nitNumber, &nrcNumber)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("company not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load company: %w", err)
+	}
+	*nit = tools.FormatNIT(fmt.Sprintf("%d", nitNumber))
+	*nrc = tools.FormatNRC(fmt.Sprintf("%d", nrcNumber))
+	return nil
+}
+
+// consumerSalesDocument is one factura before it is grouped into its row
+type consumerSalesDocument struct {
+	row           models.ConsumerSalesBookRow
+	numeroControl string
+}
+
+// sameRow reports whether the document belongs to row: same day, point of
+// sale and DTE type
+func (d consumerSalesDocument) sameRow(row models.ConsumerSalesBookRow) bool {
+	return d.row.Date == row.Date &&
+		d.row.PointOfSaleID == row.PointOfSaleID &&
+		d.row.TipoDte == row.TipoDte
+}
+
+// consumerSalesAmounts reads a factura's columns from the unsigned DTE stored
+// in the commit log. Facturas (01) carry IVA inside totalGravada; exports (11)
+// are gravadas at 0%.
+func consumerSalesAmounts(tipoDte string, dteUnsigned []byte, ivaAmount float64) (models.ConsumerSalesAmounts, error) {
+	var dte struct {
+		Resumen struct {
+			TotalNoSuj   float64  `json:"totalNoSuj"`
+			TotalExenta  float64  `json:"totalExenta"`
+			TotalGravada float64  `json:"totalGravada"`
+			TotalIva     *float64 `json:"totalIva"`
+		} `json:"resumen"`
+	}
+	if err := json.Unmarshal(dteUnsigned, &dte); err != nil {
+		return models.ConsumerSalesAmounts{}, fmt.Errorf("failed to parse DTE: %w", err)
+	}
+	resumen := dte.Resumen
+
+	amounts := models.ConsumerSalesAmounts{DocumentCount: 1}
+	if tipoDte == "11" {
+		amounts.Exportaciones = resumen.TotalGravada
+	} else {
+		amounts.VentasNoSujetas = resumen.TotalNoSuj
+		amounts.VentasExentas = resumen.TotalExenta
+		amounts.VentasGravadas = resumen.TotalGravada
+		amounts.IVADebito = ivaAmount
+		if resumen.TotalIva != nil {
+			amounts.IVADebito = *resumen.TotalIva
+		}
+	}
+	amounts.Total = amounts.VentasNoSujetas + amounts.VentasExentas + amounts.VentasGravadas + amounts.Exportaciones
+	roundConsumerSalesAmounts(&amounts)
+	return amounts, nil
+}
+
+func roundConsumerSalesAmounts(a *models.ConsumerSalesAmounts) {
+	a.VentasExentas = round(a.VentasExentas)
+	a.VentasNoSujetas = round(a.VentasNoSujetas)
+	a.VentasGravadas = round(a.VentasGravadas)
+	a.Exportaciones = round(a.Exportaciones)
+	a.Total = round(a.Total)
+	a.IVADebito = round(a.IVADebito)
+}
+
+// applyContributorSalesDTE reads the receptor and a CCF's or nota's columns
+// from the unsigned DTE stored in the commit log. IVA is the tributo 20 in
+// resumen.tributos; notas de crédito (05) are negative.
+func applyContributorSalesDTE(row *models.ContributorSalesBookRow, dteUnsigned []byte, ivaAmount float64) error {
+	var dte struct {
+		Receptor struct {
+			NIT    *string `json:"nit"`
+			NRC    *string `json:"nrc"`
+			Nombre string  `json:"nombre"`
+		} `json:"receptor"`
+		Resumen struct {
+			TotalNoSuj   float64 `json:"totalNoSuj"`
+			TotalExenta  float64 `json:"totalExenta"`
+			TotalGravada float64 `json:"totalGravada"`
+			Tributos     []struct {
+				Codigo string  `json:"codigo"`
+				Valor  float64 `json:"valor"`
+			} `json:"tributos"`
+			IvaPerci1 float64 `json:"ivaPerci1"`
+			IvaRete1  float64 `json:"ivaRete1"`
+		} `json:"resumen"`
+	}
+	if err := json.Unmarshal(dteUnsigned, &dte); err != nil {
+		return fmt.Errorf("failed to parse DTE: %w", err)
+	}
+	resumen := dte.Resumen
+
+	if dte.Receptor.NIT != nil {
+		row.ReceptorNIT = tools.FormatNIT(*dte.Receptor.NIT)
+	}
+	if dte.Receptor.NRC != nil {
+		row.ReceptorNRC = tools.FormatNRC(*dte.Receptor.NRC)
+	}
+	row.ReceptorName = dte.Receptor.Nombre
+
+	iva := ivaAmount
+	if len(resumen.Tributos) > 0 {
+		iva = 0
+		for _, tributo := range resumen.Tributos {
+			if tributo.Codigo == "20" {
+				iva += tributo.Valor
+			}
+		}
+	}
+
+	sign := 1.0
+	if row.TipoDte == "05" {
+		sign = -1.0
+	}
+	a := models.ContributorSalesAmounts{
+		VentasExentas:   sign * resumen.TotalExenta,
+		VentasNoSujetas: sign * resumen.TotalNoSuj,
+		VentasGravadas:  sign * resumen.TotalGravada,
+		IVADebito:       sign * iva,
+		IVAPercibido:    sign * resumen.IvaPerci1,
+		IVARetenido:     sign * resumen.IvaRete1,
+	}
+	a.Total = a.VentasExentas + a.VentasNoSujetas + a.VentasGravadas + a.IVADebito + a.IVAPercibido
+	roundContributorSalesAmounts(&a)
+	row.ContributorSalesAmounts = a
+	return nil
+}
+
+func roundContributorSalesAmounts(a *models.ContributorSalesAmounts) {
+	a.VentasExentas = round(a.VentasExentas)
+	a.VentasNoSujetas = round(a.VentasNoSujetas)
+	a.VentasGravadas = round(a.VentasGravadas)
+	a.IVADebito = round(a.IVADebito)
+	a.IVAPercibido = round(a.IVAPercibido)
+	a.IVARetenido = round(a.IVARetenido)
+	a.Total = round(a.Total)
+}
+
+// purchaseIVA reads the crédito fiscal of a supplier CCF: the IVA tributo (20)
+// of the received DTE, or the purchase's total taxes without one
+func purchaseIVA(dteUnsigned []byte, totalTaxes float64) float64 {
+	if len(dteUnsigned) == 0 {
+		return totalTaxes
+	}
+	var dte struct {
+		Resumen struct {
+			Tributos []struct {
+				Codigo string  `json:"codigo"`
+				Valor  float64 `json:"valor"`
+			} `json:"tributos"`
+		} `json:"resumen"`
+	}
+	if err := json.Unmarshal(dteUnsigned, &dte); err != nil || len(dte.Resumen.Tributos) == 0 {
+		return totalTaxes
+	}
+	iva := 0.0
+	for _, tributo := range dte.Resumen.Tributos {
+		if tributo.Codigo == "20" {
+			iva += tributo.Valor
+		}
+	}
+	return iva
+}
+
+func roundPurchaseBookAmounts(a *models.PurchaseBookAmounts) {
+	a.ComprasExentasInternas = round(a.ComprasExentasInternas)
+	a.ComprasExentasImportaciones = round(a.ComprasExentasImportaciones)
+	a.ComprasGravadasInternas = round(a.ComprasGravadasInternas)
+	a.ImportacionesGravadas = round(a.ImportacionesGravadas)
+	a.IVACreditoFiscal = round(a.IVACreditoFiscal)
+	a.SujetosExcluidos = round(a.SujetosExcluidos)
+	a.Total = round(a.Total)
+	a.IVARetenido = round(a.IVARetenido)
+}
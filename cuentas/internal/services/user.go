@@ -0,0 +1,402 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cuentas/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ============================================
+// ERRORS
+// ============================================
+
+var (
+	ErrUserNotFound        = errors.New("user not found")
+	ErrUserEmailTaken      = errors.New("a user with that email already exists in the company")
+	ErrInvalidCredentials  = errors.New("invalid email or password")
+	ErrInvalidSession      = errors.New("invalid or expired session")
+	ErrLastAdmin           = errors.New("the company must keep at least one active admin")
+	ErrUserCompanyNotFound = errors.New("company not found")
+)
+
+const (
+	sessionTokenPrefix = "cs_"
+	sessionTTL         = 12 * time.Hour
+)
+
+// ============================================
+// SERVICE DEFINITION
+// ============================================
+
+// UserService manages company users, their passwords and login sessions
+type UserService struct {
+	db *sql.DB
+}
+
+// NewUserService creates a new user service
+func NewUserService(db *sql.DB) *UserService {
+	return &UserService{db: db}
+}
+
+const userColumns = `
+	id, company_id, email, full_name, role, active, last_login_at, created_at, updated_at
+`
+
+// ============================================
+// USERS
+// ============================================
+
+// CreateUser adds a user to the company
+func (s *UserService) CreateUser(ctx context.Context, companyID string, req *models.CreateUserRequest) (*models.User, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if _, err := uuid.Parse(companyID); err != nil {
+		return nil, ErrUserCompanyNotFound
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	row := s.db.QueryRowContext(ctx, `
+		INSERT INTO users (company_id, email, full_name, role, password_hash)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+userColumns,
+		companyID, req.Email, req.FullName, req.Role, string(hash),
+	)
+	user, err := scanUser(row)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			switch pqErr.Code {
+			case "23505":
+				return nil, ErrUserEmailTaken
+			case "23503":
+				return nil, ErrUserCompanyNotFound
+			}
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// ListUsers lists the company's users
+func (s *UserService) ListUsers(ctx context.Context, companyID string) ([]models.User, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE company_id = $1
+		ORDER BY full_name
+	`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+// GetUser returns one of the company's users
+func (s *UserService) GetUser(ctx context.Context, companyID, id string) (*models.User, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrUserNotFound
+	}
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE id = $1 AND company_id = $2
+	`, id, companyID)
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// UpdateUser changes a user's name, role or active flag. Deactivating or
+// demoting the last active admin is refused. Deactivated users lose their sessions.
+func (s *UserService) UpdateUser(ctx context.Context, companyID, id string, req *models.UpdateUserRequest) (*models.User, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	user, err := s.GetUser(ctx, companyID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.FullName != nil {
+		user.FullName = strings.TrimSpace(*req.FullName)
+	}
+	losesAdmin := user.Role == models.RoleAdmin && user.Active &&
+		((req.Role != nil && *req.Role != models.RoleAdmin) || (req.Active != nil && !*req.Active))
+	if req.Role != nil {
+		user.Role = *req.Role
+	}
+	if req.Active != nil {
+		user.Active = *req.Active
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if losesAdmin {
+		// Lock the company's admins so two concurrent demotions can't both pass
+		var others int
+		err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM (
+				SELECT id FROM users
+				WHERE company_id = $1 AND role = $2 AND active AND id <> $3
+				FOR UPDATE
+			) admins
+		`, companyID, models.RoleAdmin, id).Scan(&others)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count admins: %w", err)
+		}
+		if others == 0 {
+			return nil, ErrLastAdmin
+		}
+	}
+
+	row := tx.QueryRowContext(ctx, `
+		UPDATE users
+		SET full_name = $1, role = $2, active = $3, updated_at = NOW()
+		WHERE id = $4 AND company_id = $5
+		RETURNING `+userColumns,
+		user.FullName, user.Role, user.Active, id, companyID,
+	)
+	updated, err := scanUser(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if !updated.Active {
+		if err := revokeUserSessionsTx(ctx, tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return updated, nil
+}
+
+// ResetPassword sets a user's password and ends their sessions
+func (s *UserService) ResetPassword(ctx context.Context, companyID, id, password string) error {
+	if err := models.ValidatePassword(password); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if _, err := s.GetUser(ctx, companyID, id); err != nil {
+		return err
+	}
+	return s.setPassword(ctx, id, password)
+}
+
+// ChangePassword changes the user's own password after checking the current one
+func (s *UserService) ChangePassword(ctx context.Context, companyID, id string, req *models.ChangePasswordRequest) error {
+	if err := models.ValidatePassword(req.NewPassword); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	var hash string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT password_hash FROM users WHERE id = $1 AND company_id = $2
+	`, id, companyID).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load user: %w", err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.CurrentPassword)) != nil {
+		return ErrInvalidCredentials
+	}
+
+	return s.setPassword(ctx, id, req.NewPassword)
+}
+
+func (s *UserService) setPassword(ctx context.Context, id, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users SET password_hash = $1, updated_at = NOW() WHERE id = $2
+	`, string(hash), id); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if err := revokeUserSessionsTx(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ============================================
+// SESSIONS
+// ============================================
+
+// Login checks the credentials and opens a session
+func (s *UserService) Login(ctx context.Context, req *models.LoginRequest, ip, userAgent string) (*models.LoginResponse, error) {
+	if _, err := uuid.Parse(req.CompanyID); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	var (
+		user models.User
+		hash string
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, company_id, email, full_name, role, active, last_login_at, created_at, updated_at, password_hash
+		FROM users
+		WHERE company_id = $1 AND LOWER(email) = LOWER($2)
+	`, req.CompanyID, strings.TrimSpace(req.Email)).Scan(
+		&user.ID, &user.CompanyID, &user.Email, &user.FullName, &user.Role, &user.Active,
+		&user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt, &hash,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	if !user.Active || bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate session token: %w", err)
+	}
+	token := sessionTokenPrefix + hex.EncodeToString(b)
+	expiresAt := time.Now().Add(sessionTTL)
+
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_sessions (user_id, token_hash, expires_at, ip, user_agent)
+		VALUES ($1, $2, $3, $4, $5)
+	`, user.ID, hashToken(token), expiresAt, nullIfBlank(&ip), nullIfBlank(&userAgent)); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	now := time.Now()
+	if _, err := s.db.ExecContext(ctx, `UPDATE users SET last_login_at = $1 WHERE id = $2`, now, user.ID); err != nil {
+		return nil, fmt.Errorf("failed to record login: %w", err)
+	}
+	user.LastLoginAt = &now
+
+	return &models.LoginResponse{Token: token, ExpiresAt: expiresAt, User: &user}, nil
+}
+
+// IsSessionToken reports whether a bearer token looks like a session token
+func IsSessionToken(token string) bool {
+	return strings.HasPrefix(token, sessionTokenPrefix)
+}
+
+// AuthenticateSession resolves a session token to its active user and session id
+func (s *UserService) AuthenticateSession(ctx context.Context, token string) (*models.User, string, error) {
+	var sessionID string
+	user := &models.User{}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT us.id, u.id, u.company_id, u.email, u.full_name, u.role, u.active,
+		       u.last_login_at, u.created_at, u.updated_at
+		FROM user_sessions us
+		JOIN users u ON u.id = us.user_id
+		WHERE us.token_hash = $1
+		  AND us.revoked_at IS NULL
+		  AND us.expires_at > NOW()
+		  AND u.active
+	`, hashToken(strings.TrimSpace(token))).Scan(
+		&sessionID, &user.ID, &user.CompanyID, &user.Email, &user.FullName, &user.Role,
+		&user.Active, &user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", ErrInvalidSession
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load session: %w", err)
+	}
+	return user, sessionID, nil
+}
+
+// GetActiveUser returns the user an API key is bound to, or ErrUserNotFound
+// when it no longer exists or was deactivated
+func (s *UserService) GetActiveUser(ctx context.Context, companyID, id string) (*models.User, error) {
+	user, err := s.GetUser(ctx, companyID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// Logout ends one session
+func (s *UserService) Logout(ctx context.Context, sessionID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE user_sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL
+	`, sessionID)
+	return err
+}
+
+// ============================================
+// HELPERS
+// ============================================
+
+func revokeUserSessionsTx(ctx context.Context, tx *sql.Tx, userID string) error {
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE user_sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
+	user := &models.User{}
+	err := row.Scan(
+		&user.ID, &user.CompanyID, &user.Email, &user.FullName, &user.Role, &user.Active,
+		&user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan user: %w", err)
+	}
+	return user, nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	secret := "whsec_0123456789abcdef"
+	timestamp := "1700000000"
+	body := []byte(`{"id":"9b2c","type":"dte.accepted"}`)
+
+	// hex(HMAC-SHA256(secret, "<timestamp>.<body>")), as receivers compute it
+	want := "9d9235ec0e52b2479c5b0cc01505d290e10dac38d9071007e355cb06d8af2bc9"
+	if got := SignWebhookPayload(secret, timestamp, body); got != want {
+		t.Errorf("SignWebhookPayload() = %s, want %s", got, want)
+	}
+
+	if got := SignWebhookPayload(secret, "1700000001", body); got == want {
+		t.Error("signature does not depend on the timestamp")
+	}
+	if got := SignWebhookPayload("whsec_other", timestamp, body); got == want {
+		t.Error("signature does not depend on the secret")
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{name: "first retry waits the base", attempts: 1, want: 30 * time.Second},
+		{name: "second retry doubles", attempts: 2, want: time.Minute},
+		{name: "fifth retry", attempts: 5, want: 8 * time.Minute},
+		{name: "capped", attempts: 12, want: 6 * time.Hour},
+		{name: "far past the cap", attempts: 1000, want: 6 * time.Hour},
+		{name: "no attempts yet", attempts: 0, want: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := exponentialBackoff(webhookBaseBackoff, webhookMaxBackoff, tt.attempts)
+			if got != tt.want {
+				t.Errorf("exponentialBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+			}
+		})
+	}
+
+	for attempts := 0; attempts <= 64; attempts++ {
+		if got := exponentialBackoff(webhookBaseBackoff, webhookMaxBackoff, attempts); got > webhookMaxBackoff || got <= 0 {
+			t.Fatalf("exponentialBackoff(%d) = %v, outside (0, %v]", attempts, got, webhookMaxBackoff)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"cuentas/internal/dte"
@@ -27,6 +28,7 @@ type ContingencyWorker struct {
 	haciendaClient     *hacienda.Client
 	haciendaService    *services.HaciendaService
 	vaultService       *services.VaultService
+	webhooks           *services.WebhookService
 
 	// Configuration
 	signatureRetryInterval time.Duration
@@ -68,6 +70,7 @@ func NewContingencyWorker(
 	haciendaClient *hacienda.Client,
 	haciendaService *services.HaciendaService,
 	vaultService *services.VaultService,
+	webhooks *services.WebhookService,
 	config *WorkerConfig,
 ) *ContingencyWorker {
 	if config == nil {
@@ -83,6 +86,7 @@ func NewContingencyWorker(
 		haciendaClient:         haciendaClient,
 		haciendaService:        haciendaService,
 		vaultService:           vaultService,
+		webhooks:               webhooks,
 		signatureRetryInterval: config.SignatureRetryInterval,
 		periodCheckInterval:    config.PeriodCheckInterval,
 		loteSubmitInterval:     config.LoteSubmitInterval,
@@ -96,7 +100,7 @@ func NewContingencyWorker(
 func (w *ContingencyWorker) Start(ctx context.Context) {
 	log.Println("[ContingencyWorker] Starting workers...")
 
-	// Worker 1: Retry signatures for unsigned invoices
+	// Worker 1: Retry signatures for unsigned invoices and other documents
 	go w.runSignatureRetryWorker(ctx)
 
 	// Worker 2: Check if services recovered and close periods
@@ -132,48 +136,21 @@ func (w *ContingencyWorker) runSignatureRetryWorker(ctx context.Context) {
 }
 
 func (w *ContingencyWorker) processUnsignedInvoices(ctx context.Context) {
-	log.Println("[SignatureRetryWorker] Checking for unsigned invoices...")
-
-	// Get invoices with pending_signature status
-	query := `
-		SELECT i.id, i.company_id, i.dte_unsigned, i.signature_retry_count
-		FROM invoices i
-		WHERE i.dte_transmission_status = $1
-		  AND i.signature_retry_count < $2
-		ORDER BY i.finalized_at ASC
-		LIMIT 50
-	`
-
-	rows, err := w.db.QueryContext(ctx, query, models.DTEStatusPendingSignature, w.maxSignatureRetries)
+	log.Println("[SignatureRetryWorker] Checking for unsigned documents...")
+
+	// Get invoices and other documents with pending_signature status
+	invoices, err := w.contingencyService.GetUnsignedDocuments(ctx, w.maxSignatureRetries, 50)
 	if err != nil {
-		log.Printf("[SignatureRetryWorker] Failed to query invoices: %v", err)
+		log.Printf("[SignatureRetryWorker] Failed to query documents: %v", err)
 		return
 	}
-	defer rows.Close()
-
-	type unsignedInvoice struct {
-		ID                  string
-		CompanyID           string
-		DteUnsigned         []byte
-		SignatureRetryCount int
-	}
-
-	var invoices []unsignedInvoice
-	for rows.Next() {
-		var inv unsignedInvoice
-		if err := rows.Scan(&inv.ID, &inv.CompanyID, &inv.DteUnsigned, &inv.SignatureRetryCount); err != nil {
-			log.Printf("[SignatureRetryWorker] Failed to scan invoice: %v", err)
-			continue
-		}
-		invoices = append(invoices, inv)
-	}
 
 	if len(invoices) == 0 {
-		log.Println("[SignatureRetryWorker] No unsigned invoices to process")
+		log.Println("[SignatureRetryWorker] No unsigned documents to process")
 		return
 	}
 
-	log.Printf("[SignatureRetryWorker] Found %d unsigned invoices to retry", len(invoices))
+	log.Printf("[SignatureRetryWorker] Found %d unsigned documents to retry", len(invoices))
 
 	for _, inv := range invoices {
 		w.retrySignature(ctx, inv.ID, inv.CompanyID, inv.DteUnsigned)
@@ -181,7 +158,7 @@ func (w *ContingencyWorker) processUnsignedInvoices(ctx context.Context) {
 }
 
 func (w *ContingencyWorker) retrySignature(ctx context.Context, invoiceID, companyID string, dteUnsigned []byte) {
-	log.Printf("[SignatureRetryWorker] Retrying signature for invoice %s", invoiceID)
+	log.Printf("[SignatureRetryWorker] Retrying signature for document %s", invoiceID)
 
 	// Load credentials
 	creds, err := w.loadCredentials(ctx, companyID)
@@ -214,7 +191,7 @@ func (w *ContingencyWorker) retrySignature(ctx context.Context, invoiceID, compa
 		return
 	}
 
-	log.Printf("[SignatureRetryWorker] ✅ Successfully signed invoice %s", invoiceID)
+	log.Printf("[SignatureRetryWorker] ✅ Successfully signed document %s", invoiceID)
 }
 
 // =============================================================================
@@ -674,6 +651,7 @@ func (w *ContingencyWorker) processLoteResults(ctx context.Context, lote *models
 		} else {
 			log.Printf("[LotePollWorker] ✅ Invoice %s procesado", result.CodigoGeneracion)
 		}
+		w.publishLoteResult(ctx, lote, result, models.WebhookEventDTEAccepted)
 	}
 
 	// Update rejected invoices
@@ -690,6 +668,7 @@ func (w *ContingencyWorker) processLoteResults(ctx context.Context, lote *models
 		} else {
 			log.Printf("[LotePollWorker] ❌ Invoice %s rechazado: %v", result.CodigoGeneracion, result.Observaciones)
 		}
+		w.publishLoteResult(ctx, lote, result, models.WebhookEventDTERejected)
 	}
 
 	// Check if lote is complete
@@ -702,9 +681,37 @@ func (w *ContingencyWorker) processLoteResults(ctx context.Context, lote *models
 	if complete {
 		w.contingencyService.UpdateLoteCompleted(ctx, lote.ID)
 		log.Printf("[LotePollWorker] ✅ Lote %s completed", lote.ID)
+
+		w.webhooks.Publish(ctx, lote.CompanyID, models.WebhookEventLoteCompleted, map[string]interface{}{
+			"lote_id":              lote.ID,
+			"codigo_lote":          lote.CodigoLote,
+			"contingency_event_id": lote.ContingencyEventID,
+			"establishment_id":     lote.EstablishmentID,
+			"point_of_sale_id":     lote.PointOfSaleID,
+			"dte_count":            lote.DTECount,
+			"procesados":           len(response.Procesados),
+			"rechazados":           len(response.Rechazados),
+		})
 	}
 }
 
+// publishLoteResult announces Hacienda's verdict on a DTE sent in a lote
+func (w *ContingencyWorker) publishLoteResult(ctx context.Context, lote *models.Lote, result hacienda.DTEResultado, eventType string) {
+	loteID := lote.ID
+	w.webhooks.Publish(ctx, lote.CompanyID, eventType, models.WebhookDTEResult{
+		CodigoGeneracion: strings.ToUpper(result.CodigoGeneracion),
+		Ambiente:         result.Ambiente,
+		Estado:           result.Estado,
+		SelloRecibido:    result.SelloRecibido,
+		FhProcesamiento:  result.FhProcesamiento,
+		CodigoMsg:        result.CodigoMsg,
+		DescripcionMsg:   result.DescripcionMsg,
+		Observaciones:    result.Observaciones,
+		Source:           "contingency",
+		LoteID:           &loteID,
+	})
+}
+
 // =============================================================================
 // Helper Functions
 // =============================================================================
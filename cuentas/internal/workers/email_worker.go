@@ -0,0 +1,93 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"cuentas/internal/services"
+)
+
+// EmailWorker mails accepted DTEs to their receptors. Each pass queues the DTEs
+// Hacienda accepted since the last one, then sends every delivery that is due,
+// including retries of earlier failures.
+type EmailWorker struct {
+	emailService *services.EmailDeliveryService
+
+	// Configuration
+	interval  time.Duration
+	batchSize int
+}
+
+// EmailWorkerConfig holds configuration for the email worker
+type EmailWorkerConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// DefaultEmailWorkerConfig returns sensible defaults
+func DefaultEmailWorkerConfig() *EmailWorkerConfig {
+	return &EmailWorkerConfig{
+		Interval:  30 * time.Second,
+		BatchSize: 20,
+	}
+}
+
+// NewEmailWorker creates a new email worker
+func NewEmailWorker(emailService *services.EmailDeliveryService, config *EmailWorkerConfig) *EmailWorker {
+	if config == nil {
+		config = DefaultEmailWorkerConfig()
+	}
+
+	return &EmailWorker{
+		emailService: emailService,
+		interval:     config.Interval,
+		batchSize:    config.BatchSize,
+	}
+}
+
+// Start begins the worker goroutine
+func (w *EmailWorker) Start(ctx context.Context) {
+	go w.run(ctx)
+}
+
+func (w *EmailWorker) run(ctx context.Context) {
+	log.Println("[EmailWorker] Started")
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[EmailWorker] Shutting down")
+			return
+		case <-ticker.C:
+			w.processDeliveries(ctx)
+		}
+	}
+}
+
+func (w *EmailWorker) processDeliveries(ctx context.Context) {
+	queued, err := w.emailService.QueueAcceptedDTEs(ctx, w.batchSize)
+	if err != nil {
+		log.Printf("[EmailWorker] Failed to queue accepted DTEs: %v", err)
+	} else if queued > 0 {
+		log.Printf("[EmailWorker] Queued %d accepted DTEs for delivery", queued)
+	}
+
+	deliveries, err := w.emailService.ClaimDueDeliveries(ctx, w.batchSize)
+	if err != nil {
+		log.Printf("[EmailWorker] Failed to claim deliveries: %v", err)
+		return
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		if err := w.emailService.Deliver(ctx, delivery); err != nil {
+			log.Printf("[EmailWorker] Delivery %s for DTE %s failed (attempt %d/%d): %v",
+				delivery.ID, delivery.CodigoGeneracion, delivery.Attempts, delivery.MaxAttempts, err)
+			continue
+		}
+		log.Printf("[EmailWorker] ✅ DTE %s mailed", delivery.CodigoGeneracion)
+	}
+}
@@ -0,0 +1,78 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"cuentas/internal/services"
+)
+
+// LedgerWorker posts journal entries for the documents finalized, and
+// reversals for the documents invalidated or voided, since its last pass.
+// Companies without the ledger enabled are skipped.
+type LedgerWorker struct {
+	ledgerService *services.LedgerService
+
+	// Configuration
+	interval  time.Duration
+	batchSize int
+}
+
+// LedgerWorkerConfig holds configuration for the ledger worker
+type LedgerWorkerConfig struct {
+	Interval  time.Duration
+	BatchSize int // Per document source and pass
+}
+
+// DefaultLedgerWorkerConfig returns sensible defaults
+func DefaultLedgerWorkerConfig() *LedgerWorkerConfig {
+	return &LedgerWorkerConfig{
+		Interval:  30 * time.Second,
+		BatchSize: 100,
+	}
+}
+
+// NewLedgerWorker creates a new ledger worker
+func NewLedgerWorker(ledgerService *services.LedgerService, config *LedgerWorkerConfig) *LedgerWorker {
+	if config == nil {
+		config = DefaultLedgerWorkerConfig()
+	}
+
+	return &LedgerWorker{
+		ledgerService: ledgerService,
+		interval:      config.Interval,
+		batchSize:     config.BatchSize,
+	}
+}
+
+// Start begins the worker goroutine
+func (w *LedgerWorker) Start(ctx context.Context) {
+	go w.run(ctx)
+}
+
+func (w *LedgerWorker) run(ctx context.Context) {
+	log.Println("[LedgerWorker] Started")
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[LedgerWorker] Shutting down")
+			return
+		case <-ticker.C:
+			w.postEntries(ctx)
+		}
+	}
+}
+
+func (w *LedgerWorker) postEntries(ctx context.Context) {
+	result, err := w.ledgerService.PostPending(ctx, w.batchSize)
+	if err != nil {
+		log.Printf("[LedgerWorker] Some documents could not be posted: %v", err)
+	}
+	if result != nil && (result.Posted > 0 || result.Reversed > 0) {
+		log.Printf("[LedgerWorker] Posted %d entries and %d reversals", result.Posted, result.Reversed)
+	}
+}
@@ -0,0 +1,85 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"cuentas/internal/services"
+)
+
+// WebhookWorker sends the webhook deliveries that are due, including retries
+// of earlier failures
+type WebhookWorker struct {
+	webhookService *services.WebhookService
+
+	// Configuration
+	interval  time.Duration
+	batchSize int
+}
+
+// WebhookWorkerConfig holds configuration for the webhook worker
+type WebhookWorkerConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// DefaultWebhookWorkerConfig returns sensible defaults
+func DefaultWebhookWorkerConfig() *WebhookWorkerConfig {
+	return &WebhookWorkerConfig{
+		Interval:  5 * time.Second,
+		BatchSize: 50,
+	}
+}
+
+// NewWebhookWorker creates a new webhook worker
+func NewWebhookWorker(webhookService *services.WebhookService, config *WebhookWorkerConfig) *WebhookWorker {
+	if config == nil {
+		config = DefaultWebhookWorkerConfig()
+	}
+
+	return &WebhookWorker{
+		webhookService: webhookService,
+		interval:       config.Interval,
+		batchSize:      config.BatchSize,
+	}
+}
+
+// Start begins the worker goroutine
+func (w *WebhookWorker) Start(ctx context.Context) {
+	go w.run(ctx)
+}
+
+func (w *WebhookWorker) run(ctx context.Context) {
+	log.Println("[WebhookWorker] Started")
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[WebhookWorker] Shutting down")
+			return
+		case <-ticker.C:
+			w.processDeliveries(ctx)
+		}
+	}
+}
+
+func (w *WebhookWorker) processDeliveries(ctx context.Context) {
+	jobs, err := w.webhookService.ClaimDueDeliveries(ctx, w.batchSize)
+	if err != nil {
+		log.Printf("[WebhookWorker] Failed to claim deliveries: %v", err)
+		return
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		if err := w.webhookService.Deliver(ctx, job); err != nil {
+			log.Printf("[WebhookWorker] Delivery %s (%s) failed (attempt %d/%d): %v",
+				job.ID, job.EventType, job.Attempts, job.MaxAttempts, err)
+			continue
+		}
+		log.Printf("[WebhookWorker] ✅ Delivered %s (%s)", job.EventType, job.ID)
+	}
+}
@@ -0,0 +1,74 @@
+-- ============================================================================
+-- Migration 0059: DTE Invalidation (Evento de Invalidación / Anulación)
+-- ============================================================================
+
+CREATE TABLE dte_invalidations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+
+    -- Código de generación of the invalidation event itself
+    codigo_generacion VARCHAR(36) UNIQUE NOT NULL,
+    ambiente VARCHAR(2) NOT NULL CHECK (ambiente IN ('00', '01')),
+
+    -- Document being invalidated (snapshot from dte_commit_log)
+    original_codigo_generacion VARCHAR(36) NOT NULL,
+    original_tipo_dte VARCHAR(2) NOT NULL,
+    original_numero_control VARCHAR(50) NOT NULL,
+    original_sello_recibido VARCHAR(100) NOT NULL,
+    original_fecha_emision DATE NOT NULL,
+    invoice_id VARCHAR(36),
+    purchase_id UUID REFERENCES purchases(id),
+
+    -- Replacement document (required for tipo_anulacion 1 and 3)
+    codigo_generacion_r VARCHAR(36),
+
+    -- Motivo (CAT-024)
+    tipo_anulacion INT NOT NULL CHECK (tipo_anulacion IN (1, 2, 3)),
+    motivo_anulacion TEXT,
+    nombre_responsable VARCHAR(100) NOT NULL,
+    tip_doc_responsable VARCHAR(2) NOT NULL,
+    num_doc_responsable VARCHAR(20) NOT NULL,
+    nombre_solicita VARCHAR(100) NOT NULL,
+    tip_doc_solicita VARCHAR(2) NOT NULL,
+    num_doc_solicita VARCHAR(20) NOT NULL,
+
+    -- Event content
+    dte_unsigned JSONB NOT NULL,
+    dte_signed TEXT,
+
+    -- Hacienda response
+    hacienda_estado VARCHAR(20),
+    hacienda_sello_recibido VARCHAR(100),
+    hacienda_fh_procesamiento TIMESTAMPTZ,
+    hacienda_codigo_msg VARCHAR(10),
+    hacienda_descripcion_msg TEXT,
+    hacienda_observaciones TEXT[],
+    hacienda_response_full JSONB,
+
+    -- Whether inventory/client balance effects of the original were reversed
+    effects_reversed BOOLEAN NOT NULL DEFAULT false,
+    effects_reversed_at TIMESTAMPTZ,
+
+    -- Audit
+    created_by UUID,
+    submitted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT dte_invalidations_replacement_check CHECK (
+        (tipo_anulacion = 2 AND codigo_generacion_r IS NULL) OR
+        (tipo_anulacion IN (1, 3) AND codigo_generacion_r IS NOT NULL)
+    )
+);
+
+CREATE INDEX idx_dte_invalidations_company ON dte_invalidations(company_id);
+CREATE INDEX idx_dte_invalidations_original ON dte_invalidations(original_codigo_generacion);
+CREATE INDEX idx_dte_invalidations_estado ON dte_invalidations(hacienda_estado);
+
+-- Only one accepted invalidation per document
+CREATE UNIQUE INDEX idx_dte_invalidations_original_procesado
+    ON dte_invalidations(original_codigo_generacion)
+    WHERE hacienda_estado = 'PROCESADO';
+
+COMMENT ON TABLE dte_invalidations IS 'Eventos de invalidación (anulación) transmitted to Hacienda, one row per attempt';
+COMMENT ON COLUMN dte_invalidations.codigo_generacion_r IS 'Código de generación of the replacement DTE (tipo_anulacion 1 and 3)';
+COMMENT ON COLUMN dte_invalidations.effects_reversed IS 'True once inventory and client balance effects of the original document were reversed';
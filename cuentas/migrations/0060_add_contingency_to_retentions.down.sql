@@ -0,0 +1,7 @@
+-- Remove contingency tracking columns from retentions
+ALTER TABLE retentions
+DROP COLUMN IF EXISTS contingency_period_id,
+DROP COLUMN IF EXISTS contingency_event_id,
+DROP COLUMN IF EXISTS lote_id,
+DROP COLUMN IF EXISTS dte_transmission_status,
+DROP COLUMN IF EXISTS signature_retry_count;
@@ -0,0 +1,11 @@
+-- Add contingency tracking columns to retentions table (DTE 07)
+-- dte_json / dte_signed already hold the unsigned and signed documents
+ALTER TABLE retentions
+ADD COLUMN IF NOT EXISTS contingency_period_id UUID REFERENCES contingency_periods(id),
+ADD COLUMN IF NOT EXISTS contingency_event_id UUID REFERENCES contingency_events(id),
+ADD COLUMN IF NOT EXISTS lote_id UUID REFERENCES lotes(id),
+ADD COLUMN IF NOT EXISTS dte_transmission_status VARCHAR(20) DEFAULT 'pending',
+ADD COLUMN IF NOT EXISTS signature_retry_count INT DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_retentions_contingency_period ON retentions(contingency_period_id) WHERE contingency_period_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_retentions_transmission_status ON retentions(dte_transmission_status) WHERE dte_transmission_status != 'pending';
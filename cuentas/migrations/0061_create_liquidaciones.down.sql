@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS liquidacion_documents;
+DROP TABLE IF EXISTS liquidaciones;
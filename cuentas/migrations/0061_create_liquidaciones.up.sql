@@ -0,0 +1,104 @@
+-- ============================================================================
+-- Migration 0061: Comprobante de Liquidación (DTE 08)
+-- ============================================================================
+-- A liquidación summarizes DTEs already accepted by Hacienda (dte_commit_log)
+-- that were sold on consignment through a third-party seller (the receptor).
+
+CREATE TABLE liquidaciones (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- References
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    establishment_id UUID NOT NULL REFERENCES establishments(id),
+    point_of_sale_id UUID NOT NULL REFERENCES point_of_sale(id),
+    client_id UUID NOT NULL REFERENCES clients(id), -- Third-party seller (receptor)
+
+    -- DTE identifiers
+    codigo_generacion VARCHAR(36) UNIQUE NOT NULL,
+    numero_control VARCHAR(50) NOT NULL,
+    tipo_dte VARCHAR(2) NOT NULL DEFAULT '08',
+    ambiente VARCHAR(2) NOT NULL CHECK (ambiente IN ('00', '01')),
+
+    -- Liquidation totals (sum of the referenced documents)
+    total_no_suj NUMERIC(15,2) NOT NULL DEFAULT 0,
+    total_exenta NUMERIC(15,2) NOT NULL DEFAULT 0,
+    total_gravada NUMERIC(15,2) NOT NULL DEFAULT 0,
+    total_exportacion NUMERIC(15,2) NOT NULL DEFAULT 0,
+    sub_total_ventas NUMERIC(15,2) NOT NULL DEFAULT 0,
+    total_iva NUMERIC(15,2) NOT NULL DEFAULT 0,
+    monto_total_operacion NUMERIC(15,2) NOT NULL DEFAULT 0,
+
+    -- Seller commission
+    commission_rate NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (commission_rate >= 0 AND commission_rate <= 100),
+    commission_amount NUMERIC(15,2) NOT NULL DEFAULT 0,
+    commission_iva NUMERIC(15,2) NOT NULL DEFAULT 0,
+    net_amount NUMERIC(15,2) NOT NULL DEFAULT 0,
+
+    condicion_operacion INT NOT NULL DEFAULT 1 CHECK (condicion_operacion IN (1, 2, 3)),
+    observaciones TEXT,
+
+    -- Dates
+    fecha_emision DATE NOT NULL,
+    fecha_procesamiento TIMESTAMPTZ,
+
+    -- DTE data
+    dte_json JSONB NOT NULL,
+    dte_signed TEXT NOT NULL,
+
+    -- Hacienda response
+    hacienda_estado VARCHAR(20),
+    hacienda_sello_recibido VARCHAR(100),
+    hacienda_fh_procesamiento TIMESTAMPTZ,
+    hacienda_codigo_msg VARCHAR(10),
+    hacienda_descripcion_msg TEXT,
+    hacienda_observaciones TEXT[],
+    hacienda_response JSONB,
+
+    -- Contingency
+    contingency_period_id UUID REFERENCES contingency_periods(id),
+    contingency_event_id UUID REFERENCES contingency_events(id),
+    lote_id UUID REFERENCES lotes(id),
+    dte_transmission_status VARCHAR(20) DEFAULT 'pending',
+    signature_retry_count INT DEFAULT 0,
+
+    -- Audit
+    created_by UUID,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    submitted_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_liquidaciones_company ON liquidaciones(company_id);
+CREATE INDEX idx_liquidaciones_client ON liquidaciones(client_id);
+CREATE INDEX idx_liquidaciones_estado ON liquidaciones(hacienda_estado);
+CREATE INDEX idx_liquidaciones_fecha_emision ON liquidaciones(fecha_emision);
+CREATE INDEX idx_liquidaciones_contingency_period ON liquidaciones(contingency_period_id) WHERE contingency_period_id IS NOT NULL;
+
+-- Documents summarized by a liquidación (one cuerpoDocumento item each)
+CREATE TABLE liquidacion_documents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    liquidacion_id UUID NOT NULL REFERENCES liquidaciones(id) ON DELETE CASCADE,
+    line_number INT NOT NULL,
+
+    -- Referenced DTE (snapshot from dte_commit_log)
+    codigo_generacion VARCHAR(36) NOT NULL,
+    tipo_dte VARCHAR(2) NOT NULL CHECK (tipo_dte IN ('01', '03', '05', '06', '11')),
+    tipo_generacion INT NOT NULL DEFAULT 2 CHECK (tipo_generacion IN (1, 2)),
+    numero_control VARCHAR(50) NOT NULL,
+    fecha_generacion DATE NOT NULL,
+
+    -- Amounts (negative for notas de crédito)
+    venta_no_suj NUMERIC(15,2) NOT NULL DEFAULT 0,
+    venta_exenta NUMERIC(15,2) NOT NULL DEFAULT 0,
+    venta_gravada NUMERIC(15,2) NOT NULL DEFAULT 0,
+    exportaciones NUMERIC(15,2) NOT NULL DEFAULT 0,
+    iva_item NUMERIC(15,2) NOT NULL DEFAULT 0,
+    obs_item TEXT NOT NULL,
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    UNIQUE (liquidacion_id, line_number),
+    UNIQUE (liquidacion_id, codigo_generacion)
+);
+
+CREATE INDEX idx_liquidacion_documents_liquidacion ON liquidacion_documents(liquidacion_id);
+CREATE INDEX idx_liquidacion_documents_codigo ON liquidacion_documents(codigo_generacion);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS dcl_documents;
@@ -0,0 +1,84 @@
+-- ============================================================================
+-- Migration 0062: Documento Contable de Liquidación (DTE 09)
+-- ============================================================================
+-- Issued by the company acting as card-payment processor or collection agent
+-- to the affiliate (receptor) whose operations were settled in the period.
+
+CREATE TABLE dcl_documents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- References
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    establishment_id UUID NOT NULL REFERENCES establishments(id),
+    point_of_sale_id UUID NOT NULL REFERENCES point_of_sale(id),
+    client_id UUID NOT NULL REFERENCES clients(id), -- Affiliate (receptor)
+    receptor_tipo_establecimiento VARCHAR(2) NOT NULL DEFAULT '02',
+
+    -- DTE identifiers
+    codigo_generacion VARCHAR(36) UNIQUE NOT NULL,
+    numero_control VARCHAR(50) NOT NULL,
+    tipo_dte VARCHAR(2) NOT NULL DEFAULT '09',
+    ambiente VARCHAR(2) NOT NULL CHECK (ambiente IN ('00', '01')),
+
+    -- Liquidation period
+    periodo_inicio DATE NOT NULL,
+    periodo_fin DATE NOT NULL,
+    cod_liquidacion VARCHAR(30),
+    cantidad_doc INT,
+
+    -- Amounts
+    valor_operaciones NUMERIC(15,2) NOT NULL CHECK (valor_operaciones > 0),
+    monto_sin_percepcion NUMERIC(15,2) NOT NULL DEFAULT 0,
+    descrip_sin_percepcion VARCHAR(100),
+    sub_total NUMERIC(15,2) NOT NULL,
+    iva NUMERIC(15,2) NOT NULL,
+    monto_sujeto_percepcion NUMERIC(15,2) NOT NULL,
+    iva_percibido NUMERIC(15,2) NOT NULL,
+    commission_rate NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (commission_rate >= 0 AND commission_rate <= 100),
+    comision NUMERIC(15,2) NOT NULL DEFAULT 0,
+    iva_comision NUMERIC(15,2) NOT NULL DEFAULT 0,
+    liquido_a_pagar NUMERIC(15,2) NOT NULL,
+    observaciones VARCHAR(200),
+
+    -- Extension (person responsible for the liquidation)
+    nomb_entrega VARCHAR(100) NOT NULL,
+    docu_entrega VARCHAR(25) NOT NULL,
+    cod_empleado VARCHAR(15),
+
+    -- Dates
+    fecha_emision DATE NOT NULL,
+    fecha_procesamiento TIMESTAMPTZ,
+
+    -- DTE data
+    dte_json JSONB NOT NULL,
+    dte_signed TEXT NOT NULL,
+
+    -- Hacienda response
+    hacienda_estado VARCHAR(20),
+    hacienda_sello_recibido VARCHAR(100),
+    hacienda_fh_procesamiento TIMESTAMPTZ,
+    hacienda_codigo_msg VARCHAR(10),
+    hacienda_descripcion_msg TEXT,
+    hacienda_observaciones TEXT[],
+    hacienda_response JSONB,
+
+    -- Contingency
+    contingency_period_id UUID REFERENCES contingency_periods(id),
+    contingency_event_id UUID REFERENCES contingency_events(id),
+    lote_id UUID REFERENCES lotes(id),
+    dte_transmission_status VARCHAR(20) DEFAULT 'pending',
+    signature_retry_count INT DEFAULT 0,
+
+    -- Audit
+    created_by UUID,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    submitted_at TIMESTAMPTZ,
+
+    CONSTRAINT dcl_documents_periodo_check CHECK (periodo_fin >= periodo_inicio)
+);
+
+CREATE INDEX idx_dcl_documents_company ON dcl_documents(company_id);
+CREATE INDEX idx_dcl_documents_client ON dcl_documents(client_id);
+CREATE INDEX idx_dcl_documents_estado ON dcl_documents(hacienda_estado);
+CREATE INDEX idx_dcl_documents_fecha_emision ON dcl_documents(fecha_emision);
+CREATE INDEX idx_dcl_documents_contingency_period ON dcl_documents(contingency_period_id) WHERE contingency_period_id IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS donation_items;
+DROP TABLE IF EXISTS donations;
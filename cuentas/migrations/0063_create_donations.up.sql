@@ -0,0 +1,99 @@
+-- ============================================================================
+-- Migration 0063: Comprobante de Donación (DTE 15)
+-- ============================================================================
+-- Issued by the company (donatario, a qualified non-profit) to the donor
+-- (donante) for donations in cash, goods or services.
+
+CREATE TABLE donations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- References
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    establishment_id UUID NOT NULL REFERENCES establishments(id),
+    point_of_sale_id UUID NOT NULL REFERENCES point_of_sale(id),
+    client_id UUID NOT NULL REFERENCES clients(id), -- Donor (donante)
+    cod_domiciliado INT NOT NULL DEFAULT 1 CHECK (cod_domiciliado IN (1, 2)),
+    cod_pais VARCHAR(4) NOT NULL DEFAULT '9300', -- CAT-020
+
+    -- DTE identifiers
+    codigo_generacion VARCHAR(36) UNIQUE NOT NULL,
+    numero_control VARCHAR(50) NOT NULL,
+    tipo_dte VARCHAR(2) NOT NULL DEFAULT '15',
+    ambiente VARCHAR(2) NOT NULL CHECK (ambiente IN ('00', '01')),
+
+    -- Totals
+    valor_total NUMERIC(15,2) NOT NULL CHECK (valor_total > 0),
+    total_efectivo NUMERIC(15,2) NOT NULL DEFAULT 0,
+    total_bienes NUMERIC(15,2) NOT NULL DEFAULT 0,
+    total_servicios NUMERIC(15,2) NOT NULL DEFAULT 0,
+
+    -- Cash payment (only when the donation includes cash lines)
+    forma_pago VARCHAR(2), -- CAT-017
+    referencia_pago VARCHAR(50),
+
+    -- Associated documents (otrosDocumentos): [{cod_doc_asociado, desc_documento, detalle_documento}]
+    otros_documentos JSONB NOT NULL DEFAULT '[]',
+
+    -- Dates
+    fecha_emision DATE NOT NULL,
+    fecha_procesamiento TIMESTAMPTZ,
+
+    -- DTE data
+    dte_json JSONB NOT NULL,
+    dte_signed TEXT NOT NULL,
+
+    -- Hacienda response
+    hacienda_estado VARCHAR(20),
+    hacienda_sello_recibido VARCHAR(100),
+    hacienda_fh_procesamiento TIMESTAMPTZ,
+    hacienda_codigo_msg VARCHAR(10),
+    hacienda_descripcion_msg TEXT,
+    hacienda_observaciones TEXT[],
+    hacienda_response JSONB,
+
+    -- Contingency
+    contingency_period_id UUID REFERENCES contingency_periods(id),
+    contingency_event_id UUID REFERENCES contingency_events(id),
+    lote_id UUID REFERENCES lotes(id),
+    dte_transmission_status VARCHAR(20) DEFAULT 'pending',
+    signature_retry_count INT DEFAULT 0,
+
+    -- Audit
+    created_by UUID,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    submitted_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_donations_company ON donations(company_id);
+CREATE INDEX idx_donations_client ON donations(client_id);
+CREATE INDEX idx_donations_estado ON donations(hacienda_estado);
+CREATE INDEX idx_donations_fecha_emision ON donations(fecha_emision);
+CREATE INDEX idx_donations_contingency_period ON donations(contingency_period_id) WHERE contingency_period_id IS NOT NULL;
+
+-- Donated items (cuerpoDocumento)
+CREATE TABLE donation_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    donation_id UUID NOT NULL REFERENCES donations(id) ON DELETE CASCADE,
+    line_number INT NOT NULL,
+
+    tipo_donacion INT NOT NULL CHECK (tipo_donacion IN (1, 2, 3)), -- CAT-026
+    valuation_method VARCHAR(20) NOT NULL
+        CHECK (valuation_method IN ('nominal', 'market_value', 'acquisition_cost', 'book_value', 'appraisal')),
+    codigo VARCHAR(25),
+    descripcion VARCHAR(1000) NOT NULL,
+    uni_medida INT NOT NULL DEFAULT 99, -- CAT-014
+    cantidad NUMERIC(18,8) NOT NULL CHECK (cantidad > 0),
+    valor_uni NUMERIC(18,8) NOT NULL CHECK (valor_uni >= 0),
+    depreciacion NUMERIC(18,8) NOT NULL DEFAULT 0 CHECK (depreciacion >= 0),
+    valor NUMERIC(18,8) NOT NULL CHECK (valor >= 0),
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT donation_items_line_unique UNIQUE (donation_id, line_number),
+    -- Cash and services carry no depreciation and use unit 99 (Otra)
+    CONSTRAINT donation_items_tipo_check CHECK (
+        tipo_donacion = 2 OR (depreciacion = 0 AND uni_medida = 99)
+    )
+);
+
+CREATE INDEX idx_donation_items_donation ON donation_items(donation_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS dte_email_deliveries;
+DROP TABLE IF EXISTS company_email_settings;
@@ -0,0 +1,68 @@
+-- ============================================================================
+-- Migration 0064: Email delivery of accepted DTEs to the receptor
+-- ============================================================================
+-- Once Hacienda returns PROCESADO, the signed JSON and the readable PDF are
+-- mailed to the receptor. Each company opts in and may override the message
+-- templates; every send (automatic or resend) is tracked in its own row.
+
+CREATE TABLE company_email_settings (
+    company_id UUID PRIMARY KEY REFERENCES companies(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT false,
+    enabled_at TIMESTAMPTZ, -- Only DTEs accepted after this moment are mailed automatically
+
+    -- Sender
+    from_name VARCHAR(200),
+    from_address VARCHAR(255),
+    reply_to VARCHAR(255),
+    bcc VARCHAR(255),
+
+    -- Go text/template sources; NULL uses the built-in templates
+    subject_template TEXT,
+    body_template TEXT,
+
+    -- Attachments
+    attach_json BOOLEAN NOT NULL DEFAULT true,
+    attach_pdf BOOLEAN NOT NULL DEFAULT true,
+
+    max_attempts INT NOT NULL DEFAULT 5 CHECK (max_attempts BETWEEN 1 AND 20),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE dte_email_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    codigo_generacion VARCHAR(36) NOT NULL, -- dte_commit_log code; not unique there (a nota shares it across CCFs)
+    tipo_dte VARCHAR(2) NOT NULL,
+    recipient VARCHAR(255),
+
+    -- pending → sending → sent | failed; skipped when there is nobody to send to
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'sending', 'sent', 'failed', 'skipped')),
+    origin VARCHAR(20) NOT NULL DEFAULT 'auto' CHECK (origin IN ('auto', 'resend')),
+
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 5,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    message_id VARCHAR(255),
+    sent_at TIMESTAMPTZ,
+
+    requested_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- A DTE is mailed automatically at most once; resends add more rows
+CREATE UNIQUE INDEX idx_dte_email_deliveries_auto
+    ON dte_email_deliveries(company_id, codigo_generacion) WHERE origin = 'auto';
+CREATE INDEX idx_dte_email_deliveries_codigo ON dte_email_deliveries(company_id, codigo_generacion);
+CREATE INDEX idx_dte_email_deliveries_company ON dte_email_deliveries(company_id, created_at DESC);
+CREATE INDEX idx_dte_email_deliveries_due
+    ON dte_email_deliveries(next_attempt_at) WHERE status = 'pending';
+
+COMMENT ON TABLE company_email_settings IS 'Per-company configuration for mailing accepted DTEs to the receptor';
+COMMENT ON COLUMN company_email_settings.enabled_at IS 'When delivery was last enabled; earlier DTEs are not mailed automatically';
+COMMENT ON TABLE dte_email_deliveries IS 'Delivery status of every email sent for a DTE in the commit log';
+COMMENT ON COLUMN dte_email_deliveries.next_attempt_at IS 'Earliest time the worker retries a pending delivery';
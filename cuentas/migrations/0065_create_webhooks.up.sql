@@ -0,0 +1,67 @@
+-- ============================================================================
+-- Migration 0065: Outbound webhooks for document lifecycle events
+-- ============================================================================
+-- Companies subscribe an HTTPS endpoint to lifecycle events (DTE accepted or
+-- rejected, contingency periods, lotes, invalidations). Each event is stored
+-- once and delivered to every matching subscription with HMAC-signed POSTs,
+-- retried with exponential backoff.
+
+CREATE TABLE webhook_subscriptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    description VARCHAR(255),
+
+    -- Empty array subscribes to every event type
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+
+    -- HMAC-SHA256 key for the X-Cuentas-Signature header
+    secret VARCHAR(100) NOT NULL,
+
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_subscriptions_company ON webhook_subscriptions(company_id) WHERE active;
+
+CREATE TABLE webhook_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL, -- Full envelope as POSTed: {id, type, company_id, created_at, data}
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_events_company ON webhook_events(company_id, created_at DESC);
+
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id UUID NOT NULL REFERENCES webhook_events(id) ON DELETE CASCADE,
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'sending', 'delivered', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 8,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Last attempt
+    response_status INT, -- The body is not kept: endpoints are tenant-chosen
+    last_error TEXT,
+    duration_ms INT,
+    delivered_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE (event_id, subscription_id)
+);
+
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, created_at DESC);
+CREATE INDEX idx_webhook_deliveries_due
+    ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+COMMENT ON TABLE webhook_subscriptions IS 'Per-company endpoints notified of document lifecycle events';
+COMMENT ON TABLE webhook_events IS 'Lifecycle events published for companies with at least one matching subscription';
+COMMENT ON TABLE webhook_deliveries IS 'Delivery log: one row per event and subscription, updated on every attempt';
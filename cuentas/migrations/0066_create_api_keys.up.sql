@@ -0,0 +1,31 @@
+-- ============================================================================
+-- Migration 0066: Per-company API keys
+-- ============================================================================
+-- Requests authenticate with an API key instead of a bare X-Company-ID header;
+-- the company is derived from the key. Only the SHA-256 of each key is stored.
+
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+
+    -- First characters of the key, shown in listings to tell keys apart
+    prefix VARCHAR(20) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE, -- Hex SHA-256 of the full key
+
+    -- read: GET requests; write: everything else; admin: key and webhook management
+    scopes TEXT[] NOT NULL DEFAULT '{read,write}',
+
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    last_used_ip VARCHAR(45),
+
+    created_by_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_api_keys_company ON api_keys(company_id, created_at DESC);
+
+COMMENT ON TABLE api_keys IS 'Hashed per-company API keys; the authenticated company comes from the key, never from a header';
@@ -0,0 +1,46 @@
+-- ============================================================================
+-- Migration 0067: Users, roles and sessions
+-- ============================================================================
+-- People who use the API belong to one company and have one role:
+--   cashier     sells: invoices, remisiones, clients
+--   accountant  everything operational: purchases, notas, inventory,
+--               invalidations, contingency, reports
+--   auditor     read-only, reports included
+--   admin       everything, including users, API keys and settings
+-- Users log in for a bearer session token; API keys may be bound to a user so
+-- integrations act with that user's role and identity.
+
+CREATE TABLE users (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('cashier', 'accountant', 'auditor', 'admin')),
+    password_hash VARCHAR(100) NOT NULL, -- bcrypt
+    active BOOLEAN NOT NULL DEFAULT true,
+    last_login_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_users_company_email ON users(company_id, LOWER(email));
+
+CREATE TABLE user_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL UNIQUE, -- Hex SHA-256 of the bearer token
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    ip VARCHAR(45),
+    user_agent VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_sessions_user ON user_sessions(user_id);
+
+-- Keys created by a logged-in user act as that user
+ALTER TABLE api_keys ADD COLUMN user_id UUID REFERENCES users(id) ON DELETE CASCADE;
+
+COMMENT ON TABLE users IS 'Company users; role decides which route groups they can call';
+COMMENT ON TABLE user_sessions IS 'Login sessions; only the token hash is stored';
+COMMENT ON COLUMN api_keys.user_id IS 'When set, requests with the key carry this user''s id and role';
@@ -0,0 +1,29 @@
+-- ============================================================================
+-- Migration 0068: Idempotency keys
+-- ============================================================================
+-- Create and finalize endpoints accept an Idempotency-Key header. The first
+-- response for a key is stored here and replayed to retries, so a POS that
+-- retries after a timeout never creates or submits a document twice.
+-- Concurrent requests with the same key are serialized with a Redis lock.
+
+CREATE TABLE idempotency_keys (
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    idempotency_key VARCHAR(255) NOT NULL,
+
+    -- What the key was first used for; a retry must match
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    request_hash CHAR(64) NOT NULL, -- Hex SHA-256 of method, path and body
+
+    -- Stored response
+    status_code INTEGER NOT NULL,
+    content_type VARCHAR(100),
+    response_body BYTEA NOT NULL,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (company_id, idempotency_key)
+);
+
+COMMENT ON TABLE idempotency_keys IS 'First response per Idempotency-Key, replayed to retries until expires_at';
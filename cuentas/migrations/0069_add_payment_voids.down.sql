@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_invoices_open_balance;
+DROP INDEX IF EXISTS idx_payments_company_date;
+DROP INDEX IF EXISTS idx_payments_invoice_id;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS void_reason;
+ALTER TABLE payments DROP COLUMN IF EXISTS voided_by;
+ALTER TABLE payments DROP COLUMN IF EXISTS voided_at;
@@ -0,0 +1,20 @@
+-- ============================================================================
+-- Migration 0069: Accounts receivable payments
+-- ============================================================================
+-- Payments can now be recorded against a finalized invoice at any time, not
+-- only when it is finalized, and a mistaken payment can be voided. Voided
+-- payments stay in the table for the audit trail but no longer count toward
+-- the invoice's amount_paid or the client's current_balance.
+
+ALTER TABLE payments ADD COLUMN voided_at TIMESTAMP;
+ALTER TABLE payments ADD COLUMN voided_by UUID;
+ALTER TABLE payments ADD COLUMN void_reason TEXT;
+
+CREATE INDEX idx_payments_invoice_id ON payments(invoice_id);
+CREATE INDEX idx_payments_company_date ON payments(company_id, payment_date DESC);
+
+-- Aging reads open credit invoices
+CREATE INDEX idx_invoices_open_balance ON invoices(company_id, client_id)
+    WHERE status = 'finalized' AND balance_due > 0;
+
+COMMENT ON COLUMN payments.voided_at IS 'Set when the payment was entered by mistake; voided payments are ignored in balances';
@@ -0,0 +1,21 @@
+DROP INDEX IF EXISTS idx_purchases_open_balance;
+DROP TABLE IF EXISTS supplier_payments;
+
+DROP INDEX IF EXISTS idx_inventory_events_supplier;
+ALTER TABLE inventory_events DROP COLUMN IF EXISTS supplier_id;
+
+ALTER TABLE retentions DROP CONSTRAINT IF EXISTS retentions_supplier_id_fkey;
+ALTER TABLE retentions ADD CONSTRAINT retentions_supplier_id_fkey
+    FOREIGN KEY (supplier_id) REFERENCES clients(id) NOT VALID;
+
+ALTER TABLE purchases DROP CONSTRAINT IF EXISTS purchases_fse_supplier_check;
+ALTER TABLE purchases ADD CONSTRAINT purchases_fse_supplier_check CHECK (
+    (purchase_type = 'fse' AND supplier_id IS NULL AND supplier_name IS NOT NULL) OR
+    (purchase_type != 'fse')
+) NOT VALID;
+
+ALTER TABLE purchases DROP CONSTRAINT IF EXISTS purchases_supplier_id_fkey;
+ALTER TABLE purchases ADD CONSTRAINT purchases_supplier_id_fkey
+    FOREIGN KEY (supplier_id) REFERENCES clients(id) NOT VALID;
+
+DROP TABLE IF EXISTS suppliers;
@@ -0,0 +1,131 @@
+-- ============================================================================
+-- Migration 0070: Supplier registry and accounts payable
+-- ============================================================================
+-- Suppliers get their own master table instead of being re-typed on every
+-- purchase. purchases.supplier_id and retentions.supplier_id pointed at clients,
+-- and the comprobante de retención read the supplier from there; they now point
+-- at suppliers. Clients referenced as suppliers are copied into the registry
+-- under the same ID, so existing purchases and retentions keep their supplier.
+-- The supplier fields on purchases stay as the snapshot printed on the DTE.
+
+CREATE TABLE suppliers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+
+    name TEXT NOT NULL,
+    legal_name TEXT,
+
+    -- Identification: '36' (NIT), '13' (DUI), '02' (Carnet de Residente), '03' (Pasaporte), '37' (Otro)
+    document_type VARCHAR(2) NOT NULL,
+    document_number TEXT,
+    nrc TEXT,
+    activity_code TEXT,
+    activity_desc TEXT,
+    nationality TEXT NOT NULL DEFAULT 'Nacional',
+
+    address_dept TEXT,
+    address_muni TEXT,
+    address_complement TEXT,
+    phone TEXT,
+    email TEXT,
+    contact_name TEXT,
+
+    -- Default credit term for purchases from this supplier
+    payment_term_days INT NOT NULL DEFAULT 0,
+
+    active BOOLEAN NOT NULL DEFAULT true,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT suppliers_document_type_check CHECK (document_type IN ('36', '13', '02', '03', '37')),
+    CONSTRAINT suppliers_nationality_check CHECK (nationality IN ('Nacional', 'Extranjero')),
+    CONSTRAINT suppliers_payment_term_check CHECK (payment_term_days >= 0)
+);
+
+CREATE UNIQUE INDEX idx_suppliers_document ON suppliers(company_id, document_type, document_number)
+    WHERE document_number IS NOT NULL;
+CREATE INDEX idx_suppliers_company_name ON suppliers(company_id, name);
+
+-- Clients used as suppliers become suppliers with the same ID. NIT, NRC and
+-- DUI are stored as numbers on clients and formatted as the registry expects.
+INSERT INTO suppliers (
+    id, company_id, name, legal_name, document_type, document_number, nrc,
+    activity_code, activity_desc, address_dept, address_muni, address_complement,
+    phone, email, created_at
+)
+SELECT c.id, c.company_id, c.business_name, NULLIF(c.legal_business_name, ''),
+       CASE WHEN c.nit IS NOT NULL THEN '36' WHEN c.dui IS NOT NULL THEN '13' ELSE '37' END,
+       CASE
+           WHEN c.nit IS NOT NULL THEN
+               substr(lpad(c.nit::text, 14, '0'), 1, 4) || '-' || substr(lpad(c.nit::text, 14, '0'), 5, 6) || '-' ||
+               substr(lpad(c.nit::text, 14, '0'), 11, 3) || '-' || substr(lpad(c.nit::text, 14, '0'), 14, 1)
+           WHEN c.dui IS NOT NULL THEN
+               substr(lpad(c.dui::text, 9, '0'), 1, 8) || '-' || substr(lpad(c.dui::text, 9, '0'), 9, 1)
+       END,
+       CASE WHEN c.ncr IS NOT NULL AND c.ncr > 0 THEN
+           left(c.ncr::text, length(c.ncr::text) - 1) || '-' || right(c.ncr::text, 1)
+       END,
+       c.cod_actividad, c.desc_actividad, c.department_code,
+       CASE WHEN position('.' IN c.municipality_code) > 0
+            THEN split_part(c.municipality_code, '.', 2) ELSE c.municipality_code END,
+       c.full_address, c.telefono, c.correo, COALESCE(c.created_at, NOW())
+FROM clients c
+WHERE c.id IN (
+    SELECT supplier_id FROM purchases WHERE supplier_id IS NOT NULL
+    UNION
+    SELECT supplier_id FROM retentions WHERE supplier_id IS NOT NULL
+);
+
+-- Purchases and retentions reference the registry
+ALTER TABLE purchases DROP CONSTRAINT IF EXISTS purchases_supplier_id_fkey;
+ALTER TABLE purchases ADD CONSTRAINT purchases_supplier_id_fkey
+    FOREIGN KEY (supplier_id) REFERENCES suppliers(id);
+
+-- An FSE may now come from a registered supplier; it still carries the snapshot
+ALTER TABLE purchases DROP CONSTRAINT IF EXISTS purchases_fse_supplier_check;
+ALTER TABLE purchases ADD CONSTRAINT purchases_fse_supplier_check CHECK (
+    purchase_type != 'fse' OR supplier_name IS NOT NULL
+);
+
+ALTER TABLE retentions DROP CONSTRAINT IF EXISTS retentions_supplier_id_fkey;
+ALTER TABLE retentions ADD CONSTRAINT retentions_supplier_id_fkey
+    FOREIGN KEY (supplier_id) REFERENCES suppliers(id);
+
+-- Inventory purchase events
+ALTER TABLE inventory_events ADD COLUMN supplier_id UUID REFERENCES suppliers(id);
+CREATE INDEX idx_inventory_events_supplier ON inventory_events(supplier_id) WHERE supplier_id IS NOT NULL;
+
+-- Payments made to suppliers against finalized purchases
+CREATE TABLE supplier_payments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    purchase_id UUID NOT NULL REFERENCES purchases(id),
+    supplier_id UUID REFERENCES suppliers(id), -- NULL for purchases from unregistered suppliers
+
+    amount NUMERIC(12,2) NOT NULL,
+    payment_method VARCHAR(2) NOT NULL, -- CAT-017
+    payment_reference TEXT,
+    payment_date DATE NOT NULL,
+    notes TEXT,
+
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    voided_at TIMESTAMP WITH TIME ZONE,
+    voided_by UUID,
+    void_reason TEXT,
+
+    CONSTRAINT supplier_payments_amount_check CHECK (amount > 0)
+);
+
+CREATE INDEX idx_supplier_payments_purchase ON supplier_payments(purchase_id);
+CREATE INDEX idx_supplier_payments_supplier ON supplier_payments(supplier_id) WHERE supplier_id IS NOT NULL;
+CREATE INDEX idx_supplier_payments_company_date ON supplier_payments(company_id, payment_date DESC);
+
+-- AP aging and purchases due read open finalized purchases
+CREATE INDEX idx_purchases_open_balance ON purchases(company_id, supplier_id)
+    WHERE status = 'finalized' AND balance_due > 0;
+
+COMMENT ON TABLE suppliers IS 'Supplier master data (proveedores); purchases keep a snapshot of these fields';
+COMMENT ON COLUMN purchases.supplier_id IS 'Registered supplier (suppliers table); NULL for purchases from unregistered suppliers';
+COMMENT ON COLUMN supplier_payments.voided_at IS 'Set when the payment was entered by mistake; voided payments are ignored in balances';
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_purchases_fse_numero_control;
+ALTER TABLE purchases ADD CONSTRAINT purchases_dte_numero_control_key UNIQUE (dte_numero_control);
+
+DROP INDEX IF EXISTS idx_purchases_dte_codigo_generacion;
+
+ALTER TABLE purchases
+DROP COLUMN IF EXISTS total_gravada,
+DROP COLUMN IF EXISTS total_exenta,
+DROP COLUMN IF EXISTS total_no_sujeta,
+DROP COLUMN IF EXISTS dte_codigo_generacion;
@@ -0,0 +1,23 @@
+-- ============================================================================
+-- Migration 0071: Received supplier DTEs
+-- ============================================================================
+-- Regular purchases can now be loaded from the CCF or Factura a supplier
+-- issued to us. The supplier's codigoGeneracion identifies the document;
+-- its numero control is only unique per issuer, so the global unique
+-- constraint is kept for our own FSEs only.
+
+ALTER TABLE purchases
+ADD COLUMN IF NOT EXISTS dte_codigo_generacion TEXT,
+-- Resumen split of a received DTE (FSE purchases leave them at 0)
+ADD COLUMN IF NOT EXISTS total_no_sujeta NUMERIC(12,2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS total_exenta NUMERIC(12,2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS total_gravada NUMERIC(12,2) NOT NULL DEFAULT 0;
+
+CREATE UNIQUE INDEX idx_purchases_dte_codigo_generacion ON purchases(company_id, dte_codigo_generacion)
+    WHERE dte_codigo_generacion IS NOT NULL;
+
+ALTER TABLE purchases DROP CONSTRAINT IF EXISTS purchases_dte_numero_control_key;
+CREATE UNIQUE INDEX idx_purchases_fse_numero_control ON purchases(dte_numero_control)
+    WHERE purchase_type = 'fse' AND dte_numero_control IS NOT NULL;
+
+COMMENT ON COLUMN purchases.dte_codigo_generacion IS 'codigoGeneracion of a DTE received from the supplier (NULL for FSE, whose id is the codigoGeneracion)';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS journal_lines;
+DROP TABLE IF EXISTS journal_entries;
+DROP TABLE IF EXISTS ledger_settings;
+DROP TABLE IF EXISTS ledger_posting_rules;
+DROP TABLE IF EXISTS ledger_accounts;
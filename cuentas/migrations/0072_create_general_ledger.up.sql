@@ -0,0 +1,99 @@
+-- ============================================================================
+-- Migration 0072: General ledger (contabilidad)
+-- ============================================================================
+-- Chart of accounts per company, posting rules mapping each document type's
+-- roles (revenue, IVA débito, receivable...) to accounts, and the journal the
+-- ledger worker fills with one balanced entry per finalized document. An
+-- invalidated or voided document gets a reversal entry; entries are never
+-- edited or deleted.
+
+CREATE TABLE ledger_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+
+    code VARCHAR(30) NOT NULL,
+    name TEXT NOT NULL,
+    account_type VARCHAR(10) NOT NULL,
+    parent_id UUID REFERENCES ledger_accounts(id),
+    postable BOOLEAN NOT NULL DEFAULT true, -- Group accounts only total their children
+    active BOOLEAN NOT NULL DEFAULT true,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT ledger_accounts_code_key UNIQUE (company_id, code),
+    CONSTRAINT ledger_accounts_type_check CHECK (account_type IN ('asset', 'liability', 'equity', 'income', 'expense'))
+);
+
+CREATE INDEX idx_ledger_accounts_parent ON ledger_accounts(parent_id) WHERE parent_id IS NOT NULL;
+
+CREATE TABLE ledger_posting_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+
+    document_type VARCHAR(20) NOT NULL, -- 'default' applies to every type without its own rule
+    role VARCHAR(40) NOT NULL,
+    account_id UUID NOT NULL REFERENCES ledger_accounts(id),
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT ledger_posting_rules_key UNIQUE (company_id, document_type, role)
+);
+
+CREATE TABLE ledger_settings (
+    company_id UUID PRIMARY KEY REFERENCES companies(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT false,
+    start_date DATE NOT NULL DEFAULT CURRENT_DATE, -- Documents dated earlier are not posted
+    next_entry_number BIGINT NOT NULL DEFAULT 1,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE journal_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+
+    entry_number BIGINT NOT NULL,
+    entry_date DATE NOT NULL,
+    description TEXT NOT NULL,
+
+    -- Posted document
+    source_type VARCHAR(20) NOT NULL, -- sale, purchase, retention, payment, supplier_payment, inventory_event
+    source_id TEXT NOT NULL,
+    document_type VARCHAR(20) NOT NULL,
+    reference TEXT,
+
+    reversal BOOLEAN NOT NULL DEFAULT false,
+    reverses_entry_id UUID REFERENCES journal_entries(id),
+
+    total_debit NUMERIC(15,2) NOT NULL,
+    total_credit NUMERIC(15,2) NOT NULL,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT journal_entries_number_key UNIQUE (company_id, entry_number),
+    CONSTRAINT journal_entries_source_key UNIQUE (company_id, source_type, source_id, reversal),
+    CONSTRAINT journal_entries_balanced CHECK (total_debit = total_credit)
+);
+
+CREATE INDEX idx_journal_entries_company_date ON journal_entries(company_id, entry_date);
+
+CREATE TABLE journal_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entry_id UUID NOT NULL REFERENCES journal_entries(id) ON DELETE CASCADE,
+    line_number INT NOT NULL,
+
+    account_id UUID NOT NULL REFERENCES ledger_accounts(id),
+    role VARCHAR(40) NOT NULL,
+    debit NUMERIC(15,2) NOT NULL DEFAULT 0,
+    credit NUMERIC(15,2) NOT NULL DEFAULT 0,
+
+    CONSTRAINT journal_lines_number_key UNIQUE (entry_id, line_number),
+    CONSTRAINT journal_lines_side_check CHECK (debit >= 0 AND credit >= 0 AND (debit = 0 OR credit = 0))
+);
+
+CREATE INDEX idx_journal_lines_account ON journal_lines(account_id);
+
+COMMENT ON TABLE ledger_accounts IS 'Chart of accounts (catálogo de cuentas) per company';
+COMMENT ON TABLE ledger_posting_rules IS 'Account of each role a document type posts to (ventas gravadas, IVA débito, cuentas por cobrar...)';
+COMMENT ON TABLE journal_entries IS 'Libro diario: one balanced entry per posted document and one reversal per invalidated or voided document';
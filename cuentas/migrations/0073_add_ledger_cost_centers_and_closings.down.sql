@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS ledger_closings;
+
+ALTER TABLE ledger_settings DROP COLUMN IF EXISTS closed_through;
+
+DROP INDEX IF EXISTS idx_journal_entries_establishment;
+
+ALTER TABLE journal_entries DROP COLUMN IF EXISTS establishment_id;
@@ -0,0 +1,57 @@
+-- ============================================================================
+-- Migration 0073: Ledger cost centers and fiscal year closings
+-- ============================================================================
+-- Journal entries carry the establishment of their document, so financial
+-- statements can be broken down by establishment as a cost center. Closing a
+-- fiscal year moves the income and expense balances to equity; entries dated
+-- in a closed year are posted on the first day after it.
+
+ALTER TABLE journal_entries ADD COLUMN establishment_id UUID REFERENCES establishments(id);
+
+CREATE INDEX idx_journal_entries_establishment ON journal_entries(company_id, establishment_id);
+
+-- Entries posted before this migration take their document's establishment
+UPDATE journal_entries je SET establishment_id = cl.establishment_id
+FROM dte_commit_log cl
+WHERE je.source_type = 'sale'
+  AND cl.company_id = je.company_id
+  AND UPPER(cl.codigo_generacion) = je.source_id;
+
+UPDATE journal_entries je SET establishment_id = p.establishment_id
+FROM purchases p
+WHERE je.source_type = 'purchase'
+  AND UPPER(p.id::text) = je.source_id;
+
+UPDATE journal_entries je SET establishment_id = r.establishment_id
+FROM retentions r
+WHERE je.source_type = 'retention'
+  AND UPPER(r.codigo_generacion) = je.source_id;
+
+UPDATE journal_entries je SET establishment_id = i.establishment_id
+FROM payments p
+JOIN invoices i ON i.id = p.invoice_id
+WHERE je.source_type = 'payment'
+  AND UPPER(p.id::text) = je.source_id;
+
+UPDATE journal_entries je SET establishment_id = pu.establishment_id
+FROM supplier_payments sp
+JOIN purchases pu ON pu.id = sp.purchase_id
+WHERE je.source_type = 'supplier_payment'
+  AND UPPER(sp.id::text) = je.source_id;
+
+ALTER TABLE ledger_settings ADD COLUMN closed_through DATE; -- Last day of the last closed fiscal year
+
+CREATE TABLE ledger_closings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    fiscal_year INT NOT NULL,
+    net_income NUMERIC(15,2) NOT NULL,
+    entry_count INT NOT NULL, -- One closing entry per cost center
+    closed_by UUID,
+    closed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT ledger_closings_year_key UNIQUE (company_id, fiscal_year)
+);
+
+COMMENT ON COLUMN journal_entries.establishment_id IS 'Cost center: the establishment of the posted document, NULL when it has none (inventory events)';
+COMMENT ON TABLE ledger_closings IS 'Fiscal years closed into equity; their closing entries have source_type closing';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS bank_matches;
+DROP TABLE IF EXISTS bank_statement_lines;
+DROP TABLE IF EXISTS bank_statements;
+DROP TABLE IF EXISTS bank_accounts;
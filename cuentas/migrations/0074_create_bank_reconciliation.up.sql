@@ -0,0 +1,95 @@
+-- ============================================================================
+-- Migration 0074: Bank accounts, statement import and reconciliation
+-- ============================================================================
+-- Bank statements are imported per bank account from CSV (in the layout saved
+-- on the account) or OFX. Deposits are matched against client payments and
+-- withdrawals against supplier payments, automatically by amount, date and
+-- reference or by hand. A statement line may be matched to several payments
+-- (a deposit of the day's receipts); a payment matches one line at most.
+
+CREATE TABLE bank_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+
+    name TEXT NOT NULL,
+    bank_name TEXT NOT NULL,
+    account_number VARCHAR(40) NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    csv_layout JSONB, -- Column layout of the bank's CSV statements
+    active BOOLEAN NOT NULL DEFAULT true,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT bank_accounts_number_key UNIQUE (company_id, account_number)
+);
+
+CREATE TABLE bank_statements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    bank_account_id UUID NOT NULL REFERENCES bank_accounts(id),
+
+    format VARCHAR(3) NOT NULL,
+    filename TEXT,
+    from_date DATE NOT NULL,
+    to_date DATE NOT NULL,
+    closing_balance NUMERIC(15,2), -- When the file states it (OFX LEDGERBAL)
+    line_count INT NOT NULL,      -- Lines imported; lines already imported are skipped
+    skipped_count INT NOT NULL,
+
+    imported_by UUID,
+    imported_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT bank_statements_format_check CHECK (format IN ('csv', 'ofx'))
+);
+
+CREATE INDEX idx_bank_statements_account ON bank_statements(bank_account_id, to_date DESC);
+
+CREATE TABLE bank_statement_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    bank_account_id UUID NOT NULL REFERENCES bank_accounts(id),
+    statement_id UUID NOT NULL REFERENCES bank_statements(id) ON DELETE CASCADE,
+
+    transaction_date DATE NOT NULL,
+    amount NUMERIC(15,2) NOT NULL, -- Positive for deposits, negative for withdrawals
+    description TEXT NOT NULL DEFAULT '',
+    reference TEXT,
+    transaction_id TEXT NOT NULL, -- OFX FITID, or a digest of the CSV row
+
+    status VARCHAR(10) NOT NULL DEFAULT 'unmatched',
+    match_method VARCHAR(10),
+    note TEXT, -- Why a line was ignored (bank fees, interest...)
+    matched_by UUID,
+    matched_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT bank_statement_lines_transaction_key UNIQUE (bank_account_id, transaction_id),
+    CONSTRAINT bank_statement_lines_status_check CHECK (status IN ('unmatched', 'matched', 'ignored')),
+    CONSTRAINT bank_statement_lines_method_check CHECK (match_method IS NULL OR match_method IN ('auto', 'manual')),
+    CONSTRAINT bank_statement_lines_amount_check CHECK (amount <> 0)
+);
+
+CREATE INDEX idx_bank_statement_lines_account_date ON bank_statement_lines(bank_account_id, transaction_date);
+CREATE INDEX idx_bank_statement_lines_unmatched ON bank_statement_lines(bank_account_id) WHERE status = 'unmatched';
+
+CREATE TABLE bank_matches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    statement_line_id UUID NOT NULL REFERENCES bank_statement_lines(id) ON DELETE CASCADE,
+
+    payment_id UUID REFERENCES payments(id),
+    supplier_payment_id UUID REFERENCES supplier_payments(id),
+    amount NUMERIC(15,2) NOT NULL,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT bank_matches_payment_check CHECK ((payment_id IS NULL) <> (supplier_payment_id IS NULL))
+);
+
+CREATE INDEX idx_bank_matches_line ON bank_matches(statement_line_id);
+CREATE UNIQUE INDEX idx_bank_matches_payment ON bank_matches(payment_id) WHERE payment_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_bank_matches_supplier_payment ON bank_matches(supplier_payment_id) WHERE supplier_payment_id IS NOT NULL;
+
+COMMENT ON COLUMN bank_accounts.csv_layout IS 'Zero-based columns of the bank CSV: date, description, reference, and amount or debit/credit, plus date format, delimiter and header rows';
+COMMENT ON COLUMN bank_statement_lines.transaction_id IS 'Identifies the transaction across imports so overlapping statements do not duplicate lines';
+COMMENT ON TABLE bank_matches IS 'Payments a bank statement line is reconciled with; their amounts add up to the line amount';
@@ -0,0 +1,66 @@
+ALTER TABLE companies DROP COLUMN IF EXISTS inventory_pool_establishment_id;
+
+-- Stock goes back to one company-wide state per item; transfers only moved it
+-- between locations, so they are dropped.
+DELETE FROM inventory_events WHERE event_type IN ('TRANSFER_OUT', 'TRANSFER_IN');
+
+ALTER TABLE inventory_events DROP CONSTRAINT check_event_type_valid;
+ALTER TABLE inventory_events ADD CONSTRAINT check_event_type_valid CHECK (
+    event_type IN ('PURCHASE', 'SALE', 'RETURN', 'ADJUSTMENT', 'INITIAL')
+);
+
+-- Versions were per location; renumber them per item in event order
+ALTER TABLE inventory_events DROP CONSTRAINT unique_aggregate_version;
+
+UPDATE inventory_events ie SET aggregate_version = v.version
+FROM (
+    SELECT event_id, ROW_NUMBER() OVER (PARTITION BY company_id, item_id ORDER BY event_id) AS version
+    FROM inventory_events
+) v
+WHERE v.event_id = ie.event_id;
+
+ALTER TABLE inventory_events ADD CONSTRAINT unique_aggregate_version UNIQUE (company_id, item_id, aggregate_version);
+
+DROP INDEX IF EXISTS idx_inventory_events_location;
+ALTER TABLE inventory_events DROP COLUMN establishment_id;
+
+-- One state per item holding the sum of its locations
+UPDATE inventory_state s SET
+    current_quantity = t.current_quantity,
+    current_total_cost = t.current_total_cost,
+    updated_at = t.updated_at,
+    last_event_id = (
+        SELECT MAX(ie.event_id) FROM inventory_events ie
+        WHERE ie.company_id = s.company_id AND ie.item_id = s.item_id
+    ),
+    aggregate_version = (
+        SELECT COUNT(*) FROM inventory_events ie
+        WHERE ie.company_id = s.company_id AND ie.item_id = s.item_id
+    )
+FROM (
+    SELECT company_id, item_id,
+           SUM(current_quantity) AS current_quantity,
+           SUM(current_total_cost) AS current_total_cost,
+           MAX(updated_at) AS updated_at,
+           MIN(establishment_id::text) AS kept
+    FROM inventory_state
+    GROUP BY company_id, item_id
+) t
+WHERE t.company_id = s.company_id
+  AND t.item_id = s.item_id
+  AND s.establishment_id::text = t.kept;
+
+DELETE FROM inventory_state s
+WHERE EXISTS (
+    SELECT 1 FROM inventory_state keep
+    WHERE keep.company_id = s.company_id
+      AND keep.item_id = s.item_id
+      AND keep.establishment_id::text < s.establishment_id::text
+);
+
+DROP INDEX IF EXISTS idx_inventory_state_location;
+ALTER TABLE inventory_state DROP CONSTRAINT inventory_state_pkey;
+ALTER TABLE inventory_state DROP COLUMN establishment_id;
+ALTER TABLE inventory_state ADD PRIMARY KEY (company_id, item_id);
+
+COMMENT ON COLUMN journal_entries.establishment_id IS 'Cost center: the establishment of the posted document, NULL when it has none (inventory events)';
@@ -0,0 +1,92 @@
+-- ============================================================================
+-- Migration 0075: Inventory per location
+-- ============================================================================
+-- Inventory is kept per establishment (sucursal, casa matriz, bodega...): every
+-- event happens at one, and inventory_state holds the quantity and moving
+-- average cost of an item at each. Company-wide figures are the sum over
+-- locations. Goods move between locations with a TRANSFER_OUT / TRANSFER_IN
+-- pair of events sharing a reference_id.
+--
+-- Existing events and states go to one establishment per company: its casa
+-- matriz, else its oldest establishment. Their running balances were company
+-- wide, so splitting them by document would break them. A company with more
+-- than one establishment keeps that location as its inventory pool: every
+-- movement, at any of its establishments, is booked there until the stock is
+-- allocated to the locations (POST /v1/inventory/locations/allocate), so sales
+-- at a sucursal keep finding the stock they found before.
+
+-- Every company with inventory state or events needs an establishment to take
+-- them; stop with the company named rather than failing on a NOT NULL below.
+DO $$
+DECLARE
+    orphan_id UUID;
+    orphan_name TEXT;
+BEGIN
+    SELECT c.id, c.name INTO orphan_id, orphan_name
+    FROM companies c
+    WHERE (EXISTS (SELECT 1 FROM inventory_state s WHERE s.company_id = c.id)
+           OR EXISTS (SELECT 1 FROM inventory_events ev WHERE ev.company_id = c.id))
+      AND NOT EXISTS (SELECT 1 FROM establishments e WHERE e.company_id = c.id)
+    ORDER BY c.name
+    LIMIT 1;
+    IF orphan_id IS NOT NULL THEN
+        RAISE EXCEPTION 'company "%" (%) has inventory but no establishment; create one before migrating', orphan_name, orphan_id;
+    END IF;
+END $$;
+
+-- Events
+ALTER TABLE inventory_events ADD COLUMN establishment_id UUID REFERENCES establishments(id);
+
+UPDATE inventory_events ie SET establishment_id = d.establishment_id
+FROM (
+    SELECT DISTINCT ON (company_id) company_id, id AS establishment_id
+    FROM establishments
+    ORDER BY company_id, (tipo_establecimiento = '02') DESC, active DESC, created_at, id
+) d
+WHERE d.company_id = ie.company_id;
+
+ALTER TABLE inventory_events ALTER COLUMN establishment_id SET NOT NULL;
+
+ALTER TABLE inventory_events DROP CONSTRAINT unique_aggregate_version;
+ALTER TABLE inventory_events ADD CONSTRAINT unique_aggregate_version
+    UNIQUE (company_id, item_id, establishment_id, aggregate_version);
+
+ALTER TABLE inventory_events DROP CONSTRAINT check_event_type_valid;
+ALTER TABLE inventory_events ADD CONSTRAINT check_event_type_valid CHECK (
+    event_type IN ('PURCHASE', 'SALE', 'RETURN', 'ADJUSTMENT', 'INITIAL', 'TRANSFER_OUT', 'TRANSFER_IN')
+);
+
+CREATE INDEX idx_inventory_events_location ON inventory_events(company_id, establishment_id, item_id, event_timestamp);
+
+-- State
+ALTER TABLE inventory_state ADD COLUMN establishment_id UUID REFERENCES establishments(id);
+
+UPDATE inventory_state s SET establishment_id = d.establishment_id
+FROM (
+    SELECT DISTINCT ON (company_id) company_id, id AS establishment_id
+    FROM establishments
+    ORDER BY company_id, (tipo_establecimiento = '02') DESC, active DESC, created_at, id
+) d
+WHERE d.company_id = s.company_id;
+
+ALTER TABLE inventory_state ALTER COLUMN establishment_id SET NOT NULL;
+
+ALTER TABLE inventory_state DROP CONSTRAINT inventory_state_pkey;
+ALTER TABLE inventory_state ADD PRIMARY KEY (company_id, item_id, establishment_id);
+
+CREATE INDEX idx_inventory_state_location ON inventory_state(company_id, establishment_id);
+
+-- Inventory pool for companies whose stock predates locations
+ALTER TABLE companies ADD COLUMN inventory_pool_establishment_id UUID REFERENCES establishments(id);
+
+UPDATE companies c SET inventory_pool_establishment_id = s.establishment_id
+FROM (
+    SELECT DISTINCT company_id, establishment_id FROM inventory_state
+) s
+WHERE s.company_id = c.id
+  AND (SELECT COUNT(*) FROM establishments e WHERE e.company_id = c.id) > 1;
+
+COMMENT ON COLUMN companies.inventory_pool_establishment_id IS 'While set, inventory movements at every establishment are booked at this one; cleared when the stock is allocated to locations';
+COMMENT ON COLUMN inventory_events.establishment_id IS 'Location the goods entered or left; balances and average costs are per location';
+COMMENT ON COLUMN inventory_state.establishment_id IS 'Location of the stock';
+COMMENT ON COLUMN journal_entries.establishment_id IS 'Cost center: the establishment of the posted document or inventory event';
@@ -0,0 +1,2 @@
+ALTER TABLE notas_credito DROP COLUMN IF EXISTS finalized_by;
+ALTER TABLE notas_debito DROP COLUMN IF EXISTS finalized_by;
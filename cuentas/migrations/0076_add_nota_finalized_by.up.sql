@@ -0,0 +1,15 @@
+-- ============================================================================
+-- Migration 0076: Who finalized a nota
+-- ============================================================================
+-- created_by now records the user who created a nota, at insert. Finalizing
+-- used to overwrite it with the finalizer, so for finalized notas it holds the
+-- finalizer: carry that over to finalized_by.
+
+ALTER TABLE notas_debito ADD COLUMN finalized_by UUID;
+ALTER TABLE notas_credito ADD COLUMN finalized_by UUID;
+
+UPDATE notas_debito SET finalized_by = created_by WHERE finalized_at IS NOT NULL;
+UPDATE notas_credito SET finalized_by = created_by WHERE finalized_at IS NOT NULL;
+
+COMMENT ON COLUMN notas_debito.finalized_by IS 'User who finalized the nota';
+COMMENT ON COLUMN notas_credito.finalized_by IS 'User who finalized the nota';